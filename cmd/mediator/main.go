@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mandocaesar/mediator/pkg/scaffold"
+)
+
+const usage = `Usage: mediator <command> [flags]
+
+Commands:
+  new <name>   Generate an event-driven service skeleton
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "new":
+		if err := runNew(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// runNew handles the "new" command
+func runNew(args []string) error {
+	config := scaffold.DefaultConfig()
+
+	flags := flag.NewFlagSet("new", flag.ExitOnError)
+	flags.StringVar(&config.Module, "module", "", "Go module path (defaults to the service name)")
+	flags.StringVar(&config.Entity, "entity", config.Entity, "name of the sample domain entity")
+	flags.StringVar(&config.Store, "store", config.Store, "event store: memory, redis or postgres")
+	flags.StringVar(&config.Dir, "dir", "", "output directory (defaults to the service name)")
+	flags.StringVar(&config.MediatorPath, "mediator-path", "", "local mediator checkout to use via a replace directive")
+	flags.BoolVar(&config.Force, "force", false, "write into a non-empty directory")
+
+	// Allow the service name before or after the flags
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		config.Name = args[0]
+		args = args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if config.Name == "" {
+		config.Name = flags.Arg(0)
+	}
+
+	files, err := scaffold.Generate(config)
+	if err != nil {
+		return err
+	}
+
+	dir := config.Dir
+	if dir == "" {
+		dir = config.Name
+	}
+	fmt.Printf("Created %s:\n", dir)
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+	fmt.Printf("\nNext steps:\n  cd %s\n  go mod tidy\n  go test ./...\n  go run .\n", dir)
+	return nil
+}
@@ -4,4 +4,9 @@ go 1.21
 
 require github.com/mandocaesar/mediator v0.0.0
 
+require (
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	golang.org/x/time v0.5.0 // indirect
+)
+
 replace github.com/mandocaesar/mediator => ../..
@@ -39,8 +39,11 @@ func main() {
 	// med.Subscribe("product.created", productUseCase.HandleProductCreation)
 	// med.Subscribe("product.updated", productUseCase.HandleProductUpdate)
 	// med.Subscribe("product.detail.create", productDetailUseCase.CreateDefaultProductDetails)
-	med.Subscribe("product.update", productDetailUseCase.HandleProductUpdate)
-	med.Subscribe("sku.created", skuUseCase.HandleSKUCreation)
+	for _, handlers := range []interface{}{productDetailUseCase, skuUseCase} {
+		if _, err := mediator.RegisterHandlers(med, handlers); err != nil {
+			log.Fatalf("Error registering handlers: %v", err)
+		}
+	}
 
 	// Create a product
 	ctx := context.Background()
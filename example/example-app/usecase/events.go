@@ -0,0 +1,15 @@
+package usecase
+
+import "github.com/mandocaesar/mediator/pkg/mediator"
+
+// Event names published and handled by the use cases
+const (
+	EventProductCreated       mediator.EventName = "product.created"
+	EventProductUpdated       mediator.EventName = "product.updated"
+	EventProductUpdate        mediator.EventName = "product.update"
+	EventProductDetailCreate  mediator.EventName = "product.detail.create"
+	EventProductDetailCreated mediator.EventName = "product.detail.created"
+	EventProductDetailUpdated mediator.EventName = "product.detail.updated"
+	EventSKUCreated           mediator.EventName = "sku.created"
+	EventSKUUpdated           mediator.EventName = "sku.updated"
+)
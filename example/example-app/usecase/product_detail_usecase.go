@@ -63,10 +63,7 @@ func (uc *ProductDetailUseCase) HandleProductUpdate(ctx context.Context, event m
 	}
 
 	// Publish an event to notify other components about product detail update
-	uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "product.detail.updated",
-		Payload: existingDetails,
-	})
+	uc.mediator.Publish(ctx, EventProductDetailUpdated.Event(existingDetails))
 
 	return nil
 }
@@ -89,10 +86,7 @@ func (uc *ProductDetailUseCase) CreateDefaultProductDetails(ctx context.Context,
 	}
 
 	// Publish an event to notify other components about product detail creation
-	uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "product.detail.created",
-		Payload: productDetail,
-	})
+	uc.mediator.Publish(ctx, EventProductDetailCreated.Event(productDetail))
 
 	return nil
 }
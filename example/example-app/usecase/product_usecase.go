@@ -54,10 +54,7 @@ func (uc *ProductUseCase) CreateProduct(ctx context.Context, name, description s
 	}
 
 	// Publish product creation event
-	uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "product.created",
-		Payload: newProduct,
-	})
+	uc.mediator.Publish(ctx, EventProductCreated.Event(newProduct))
 
 	return newProduct, nil
 }
@@ -100,10 +97,7 @@ func (uc *ProductUseCase) UpdateProduct(ctx context.Context, productID, name, de
 	)
 
 	// Publish product update event
-	return uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "product.updated",
-		Payload: existingProduct,
-	})
+	return uc.mediator.Publish(ctx, EventProductUpdated.Event(existingProduct))
 }
 
 // HandleProductCreation handles product creation events
@@ -114,10 +108,7 @@ func (uc *ProductUseCase) HandleProductCreation(ctx context.Context, event media
 	}
 
 	// Publish event for product detail creation
-	uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "product.detail.create",
-		Payload: product,
-	})
+	uc.mediator.Publish(ctx, EventProductDetailCreate.Event(product))
 
 	return nil
 }
@@ -137,10 +128,7 @@ func (uc *ProductUseCase) HandleProductUpdate(ctx context.Context, event mediato
 	}
 
 	// Publish event for product detail update
-	uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "product.update",
-		Payload: product,
-	})
+	uc.mediator.Publish(ctx, EventProductUpdate.Event(product))
 
 	return nil
 }
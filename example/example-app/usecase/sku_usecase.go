@@ -14,6 +14,8 @@ import (
 
 // SKUUseCase handles business logic for SKU-related operations
 type SKUUseCase struct {
+	_ struct{} `event:"sku.created" handler:"HandleSKUCreation"`
+
 	skuRepo  repository.SKURepository
 	mediator *mediator.Mediator
 }
@@ -44,10 +46,7 @@ func (uc *SKUUseCase) CreateSKU(ctx context.Context, productID string, quantity
 	}
 
 	// Publish SKU creation event
-	uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "sku.created",
-		Payload: newSKU,
-	})
+	uc.mediator.Publish(ctx, EventSKUCreated.Event(newSKU))
 
 	return newSKU, nil
 }
@@ -82,8 +81,5 @@ func (uc *SKUUseCase) UpdateSKU(ctx context.Context, skuID string, quantity int)
 	}
 
 	// Publish SKU update event
-	return uc.mediator.Publish(ctx, mediator.Event{
-		Name:    "sku.updated",
-		Payload: existingSKU,
-	})
+	return uc.mediator.Publish(ctx, EventSKUUpdated.Event(existingSKU))
 }
@@ -0,0 +1,296 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrAggregateNotFound is returned by Repository.Load for an aggregate without events
+var ErrAggregateNotFound = errors.New("aggregate not found")
+
+// Aggregate is an event-sourced domain object whose state is rebuilt by
+// applying its events in order. Implementations embed AggregateBase and
+// change state only in ApplyEvent.
+type Aggregate interface {
+	// ApplyEvent changes the aggregate's state for one of its events
+	ApplyEvent(event Event) error
+
+	aggregateBase() *AggregateBase
+}
+
+// AggregateBase tracks the identity, version and uncommitted events of an
+// aggregate. Embed it in aggregates.
+type AggregateBase struct {
+	id          string
+	version     int64
+	uncommitted []Event
+}
+
+// ID returns the aggregate's ID
+func (a *AggregateBase) ID() string {
+	return a.id
+}
+
+// SetID sets the ID of a new aggregate, before its first event is saved
+func (a *AggregateBase) SetID(id string) {
+	a.id = id
+}
+
+// Version returns the version of the aggregate's stream its state was loaded
+// or saved at, not counting uncommitted events
+func (a *AggregateBase) Version() int64 {
+	return a.version
+}
+
+// UncommittedEvents returns the events raised since the aggregate was loaded or saved
+func (a *AggregateBase) UncommittedEvents() []Event {
+	return append([]Event(nil), a.uncommitted...)
+}
+
+// aggregateBase returns the base, making embedding types Aggregates
+func (a *AggregateBase) aggregateBase() *AggregateBase {
+	return a
+}
+
+// Raise applies a new event to agg and records it as uncommitted, to be
+// appended to its stream by Repository.Save
+func Raise(agg Aggregate, event Event) error {
+	if err := agg.ApplyEvent(event); err != nil {
+		return fmt.Errorf("failed to apply event %s: %w", event.Name, err)
+	}
+	base := agg.aggregateBase()
+	base.uncommitted = append(base.uncommitted, event)
+	return nil
+}
+
+// LoadFromHistory rebuilds agg by applying events already in its stream, in
+// order, and moves its version past them
+func LoadFromHistory(agg Aggregate, history []Event) error {
+	for _, event := range history {
+		if err := agg.ApplyEvent(event); err != nil {
+			return fmt.Errorf("failed to apply event %s: %w", event.Name, err)
+		}
+	}
+	agg.aggregateBase().version += int64(len(history))
+	return nil
+}
+
+// RepositoryConfig holds configuration for a Repository
+type RepositoryConfig struct {
+	// StreamPrefix is prepended to aggregate IDs to name their streams, e.g. "product-"
+	StreamPrefix string
+	// Publish dispatches saved events to the mediator's handlers, e.g. to
+	// update read models
+	Publish bool
+	// Snapshots keeps snapshots of aggregates, so Load replays only the
+	// events after the latest one
+	Snapshots SnapshotStore
+	// SnapshotEvery saves a snapshot each time a save moves a stream past a
+	// multiple of this many events; 0 saves snapshots only with SaveSnapshot
+	SnapshotEvery int64
+}
+
+// DefaultRepositoryConfig returns the default repository configuration
+func DefaultRepositoryConfig() RepositoryConfig {
+	return RepositoryConfig{
+		Publish: true,
+	}
+}
+
+// Repository loads and saves aggregates of type T, one stream per aggregate,
+// in a StreamEventStore
+type Repository[T Aggregate] struct {
+	mediator *Mediator
+	store    StreamEventStore
+	factory  func() T
+	config   RepositoryConfig
+}
+
+// NewRepository creates a repository keeping aggregates created by factory in store
+func NewRepository[T Aggregate](m *Mediator, store StreamEventStore, factory func() T, config RepositoryConfig) *Repository[T] {
+	return &Repository[T]{
+		mediator: m,
+		store:    store,
+		factory:  factory,
+		config:   config,
+	}
+}
+
+// Load rebuilds the aggregate of id by replaying its stream, from its latest
+// snapshot if any. Payloads are rehydrated into the types registered for
+// their event names, like GetEvents. A snapshot that can't be read is logged
+// and the whole stream replayed instead.
+func (r *Repository[T]) Load(ctx context.Context, id string) (T, error) {
+	agg, restored := r.restore(ctx, id)
+	if !restored {
+		agg = r.factory()
+		agg.aggregateBase().id = id
+	}
+
+	history, err := r.readStream(ctx, id, agg.aggregateBase().version+1)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to load aggregate %s: %w", id, err)
+	}
+	if len(history) == 0 && !restored {
+		var zero T
+		return zero, fmt.Errorf("failed to load aggregate %s: %w", id, ErrAggregateNotFound)
+	}
+	if err := LoadFromHistory(agg, history); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to load aggregate %s: %w", id, err)
+	}
+	return agg, nil
+}
+
+// Save appends the uncommitted events of agg to its stream, expecting the
+// stream to still be at the version agg was loaded at. When another writer
+// appended first, Save fails with a *VersionConflictError and agg keeps its
+// uncommitted events; load it again and retry. Saved events are then
+// dispatched to handlers if the config's Publish is set; handler errors are
+// returned, but the events stay saved. Events without handlers are fine.
+func (r *Repository[T]) Save(ctx context.Context, agg T) error {
+	base := agg.aggregateBase()
+	if base.id == "" {
+		return errors.New("failed to save aggregate: empty aggregate ID")
+	}
+	if len(base.uncommitted) == 0 {
+		return nil
+	}
+
+	m := r.mediator
+	ctx = m.storeContext(ctx)
+	events := make([]Event, len(base.uncommitted))
+	for i, event := range base.uncommitted {
+		if err := m.checkPublish(event); err != nil {
+			return fmt.Errorf("failed to save aggregate %s: %w", base.id, err)
+		}
+		events[i] = m.scope(ctx, event.inherit(ctx)).stamp(m.now())
+	}
+
+	stored := make([]Event, len(events))
+	for i, event := range events {
+		stored[i] = event.stored()
+	}
+	version, err := r.store.AppendToStream(ctx, r.streamID(ctx, base.id), base.version, stored)
+	if err != nil {
+		return fmt.Errorf("failed to save aggregate %s: %w", base.id, err)
+	}
+	previous := base.version
+	base.version = version
+	base.uncommitted = nil
+
+	if every := r.config.SnapshotEvery; every > 0 && r.config.Snapshots != nil && version/every > previous/every {
+		if err := r.SaveSnapshot(ctx, agg); err != nil {
+			m.logf("%v", err)
+		}
+	}
+
+	if !r.config.Publish {
+		return nil
+	}
+	var errs []error
+	for _, event := range events {
+		if err := m.PublishWith(ctx, event, withoutStore()); err != nil && !errors.Is(err, ErrNoHandlers) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SaveSnapshot saves the state of agg at its version in the config's
+// Snapshots, e.g. after loading an aggregate with a long stream. agg must have
+// no uncommitted events.
+func (r *Repository[T]) SaveSnapshot(ctx context.Context, agg T) error {
+	base := agg.aggregateBase()
+	if r.config.Snapshots == nil {
+		return fmt.Errorf("failed to snapshot aggregate %s: no snapshot store configured", base.id)
+	}
+	if len(base.uncommitted) > 0 {
+		return fmt.Errorf("failed to snapshot aggregate %s: it has unsaved events", base.id)
+	}
+	if base.version == 0 {
+		return fmt.Errorf("failed to snapshot aggregate %s: it has no saved events", base.id)
+	}
+
+	m := r.mediator
+	serializer := m.Serializer()
+	state, err := serializer.Marshal(agg)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot aggregate %s: %w", base.id, err)
+	}
+	ctx = m.storeContext(ctx)
+	snapshot := Snapshot{
+		StreamID:    r.streamID(ctx, base.id),
+		Version:     base.version,
+		State:       state,
+		ContentType: serializer.ContentType(),
+		Timestamp:   m.now(),
+	}
+	if err := r.config.Snapshots.SaveSnapshot(ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to snapshot aggregate %s: %w", base.id, err)
+	}
+	return nil
+}
+
+// restore returns the aggregate of id decoded from its latest snapshot, and
+// false when it has none or it can't be read
+func (r *Repository[T]) restore(ctx context.Context, id string) (T, bool) {
+	var zero T
+	if r.config.Snapshots == nil {
+		return zero, false
+	}
+
+	m := r.mediator
+	ctx = m.storeContext(ctx)
+	snapshot, err := r.config.Snapshots.LoadSnapshot(ctx, r.streamID(ctx, id))
+	if errors.Is(err, ErrSnapshotNotFound) {
+		return zero, false
+	}
+	if err != nil {
+		m.logf("failed to load snapshot of aggregate %s: %v", id, err)
+		return zero, false
+	}
+
+	serializer := m.Serializer()
+	if snapshot.ContentType != serializer.ContentType() {
+		m.logf("snapshot of aggregate %s is encoded as %s, want %s", id, snapshot.ContentType, serializer.ContentType())
+		return zero, false
+	}
+	agg := r.factory()
+	if err := serializer.Unmarshal(snapshot.State, agg); err != nil {
+		m.logf("failed to decode snapshot of aggregate %s: %v", id, err)
+		return zero, false
+	}
+	base := agg.aggregateBase()
+	base.id, base.version = id, snapshot.Version
+	return agg, true
+}
+
+// readStream reads the events of the aggregate of id from fromVersion onwards
+func (r *Repository[T]) readStream(ctx context.Context, id string, fromVersion int64) ([]Event, error) {
+	ctx = r.mediator.storeContext(ctx)
+	records, err := r.store.ReadStream(ctx, r.streamID(ctx, id), fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := namespacedName(r.mediator.namespaceOf(ctx), "")
+	events := make([]Event, len(records))
+	for i, record := range records {
+		// Rehydrate by the name events were raised with, not their namespaced one
+		record.Name = strings.TrimPrefix(record.Name, prefix)
+		if record.Payload, err = r.mediator.rehydrateStoredPayload(record.Name, record); err != nil {
+			return nil, fmt.Errorf("failed to rehydrate payload: %w", err)
+		}
+		events[i] = record.Event()
+	}
+	return events, nil
+}
+
+// streamID returns the stream of the aggregate of id, in the namespace of ctx
+func (r *Repository[T]) streamID(ctx context.Context, id string) string {
+	return namespacedName(r.mediator.namespaceOf(ctx), r.config.StreamPrefix+id)
+}
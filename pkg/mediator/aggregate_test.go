@@ -0,0 +1,356 @@
+package mediator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// jsonStreamStore is an in-memory StreamEventStore keeping payloads as JSON,
+// like stores backed by a database
+type jsonStreamStore struct {
+	mu      sync.Mutex
+	streams map[string][]StoredEvent
+	// reads records the versions streams were read from
+	reads []int64
+}
+
+func (s *jsonStreamStore) AppendToStream(ctx context.Context, streamID string, expectedVersion int64, events []Event) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streams == nil {
+		s.streams = make(map[string][]StoredEvent)
+	}
+	version := int64(len(s.streams[streamID]))
+	if expectedVersion != AnyVersion && version != expectedVersion {
+		return 0, &VersionConflictError{StreamID: streamID, Expected: expectedVersion, Actual: version}
+	}
+	for _, event := range events {
+		raw, err := json.Marshal(event.Payload)
+		if err != nil {
+			return 0, err
+		}
+		var payload interface{}
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return 0, err
+		}
+		version++
+		s.streams[streamID] = append(s.streams[streamID], StoredEvent{
+			ID: event.ID, Name: event.Name, Payload: payload, RawPayload: raw, ContentType: "application/json",
+			Timestamp: event.Timestamp, CorrelationID: event.CorrelationID, StreamID: streamID, Version: version,
+		})
+	}
+	return version, nil
+}
+
+func (s *jsonStreamStore) ReadStream(ctx context.Context, streamID string, fromVersion int64) ([]StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads = append(s.reads, fromVersion)
+	var events []StoredEvent
+	for _, event := range s.streams[streamID] {
+		if event.Version >= fromVersion {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// priceChanged is the payload of product.price_changed
+type priceChanged struct {
+	Price float64 `json:"price"`
+}
+
+// productAggregate is an event-sourced product
+type productAggregate struct {
+	AggregateBase
+	Price   float64
+	Deleted bool
+}
+
+func (p *productAggregate) ApplyEvent(event Event) error {
+	switch payload := event.Payload.(type) {
+	case *testProduct:
+		p.Price = payload.Price
+	case priceChanged:
+		if p.Deleted {
+			return errors.New("product is deleted")
+		}
+		p.Price = payload.Price
+	case nil:
+		p.Deleted = true
+	default:
+		return fmt.Errorf("unexpected payload %T", payload)
+	}
+	return nil
+}
+
+// productRepository returns a repository of products and its store
+func productRepository(t *testing.T, m *Mediator) (*Repository[*productAggregate], *jsonStreamStore) {
+	t.Helper()
+	if err := RegisterEvent[*testProduct](m, "product.created"); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+	if err := RegisterEvent[priceChanged](m, "product.price_changed"); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+	store := &jsonStreamStore{}
+	config := DefaultRepositoryConfig()
+	config.StreamPrefix = "product-"
+	return NewRepository(m, store, func() *productAggregate { return &productAggregate{} }, config), store
+}
+
+func TestRepository_SaveAndLoad(t *testing.T) {
+	m := NewMediator()
+	repo, store := productRepository(t, m)
+	ctx := context.Background()
+
+	var published []string
+	m.Subscribe("product.price_changed", func(ctx context.Context, event Event) error {
+		published = append(published, event.ID)
+		return nil
+	})
+
+	product := &productAggregate{}
+	product.SetID("p-1")
+	if err := Raise(product, Event{Name: "product.created", Payload: &testProduct{ID: "p-1", Price: 10}}); err != nil {
+		t.Fatalf("Raise() error = %v", err)
+	}
+	if err := Raise(product, Event{Name: "product.price_changed", Payload: priceChanged{Price: 12}}); err != nil {
+		t.Fatalf("Raise() error = %v", err)
+	}
+	if product.Price != 12 || len(product.UncommittedEvents()) != 2 || product.Version() != 0 {
+		t.Fatalf("product = %+v, want price 12 with 2 uncommitted events", product)
+	}
+
+	if err := repo.Save(ctx, product); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if product.Version() != 2 || len(product.UncommittedEvents()) != 0 {
+		t.Errorf("Save() left version %d and %d uncommitted events, want 2 and none", product.Version(), len(product.UncommittedEvents()))
+	}
+	if len(store.streams["product-p-1"]) != 2 {
+		t.Errorf("stream has %d events, want 2", len(store.streams["product-p-1"]))
+	}
+	if len(published) != 1 || published[0] != store.streams["product-p-1"][1].ID {
+		t.Errorf("published = %v, want the saved price change", published)
+	}
+
+	// Test the payloads stored as JSON are rehydrated into their types
+	loaded, err := repo.Load(ctx, "p-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.ID() != "p-1" || loaded.Price != 12 || loaded.Version() != 2 {
+		t.Errorf("Load() = %+v, want p-1 at price 12 and version 2", loaded)
+	}
+
+	// Test saving without new events is a no-op
+	if err := repo.Save(ctx, loaded); err != nil {
+		t.Errorf("Save() without events error = %v", err)
+	}
+	if _, err := repo.Load(ctx, "p-2"); !errors.Is(err, ErrAggregateNotFound) {
+		t.Errorf("Load() error = %v, want ErrAggregateNotFound", err)
+	}
+}
+
+func TestRepository_VersionConflict(t *testing.T) {
+	m := NewMediator()
+	repo, _ := productRepository(t, m)
+	ctx := context.Background()
+
+	product := &productAggregate{}
+	product.SetID("p-1")
+	Raise(product, Event{Name: "product.created", Payload: &testProduct{ID: "p-1", Price: 10}})
+	if err := repo.Save(ctx, product); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	first, _ := repo.Load(ctx, "p-1")
+	second, _ := repo.Load(ctx, "p-1")
+	Raise(first, Event{Name: "product.price_changed", Payload: priceChanged{Price: 11}})
+	Raise(second, Event{Name: "product.price_changed", Payload: priceChanged{Price: 9}})
+	if err := repo.Save(ctx, first); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	err := repo.Save(ctx, second)
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) || conflict.Expected != 1 || conflict.Actual != 2 {
+		t.Fatalf("Save() error = %v, want a conflict at version 2", err)
+	}
+	if len(second.UncommittedEvents()) != 1 {
+		t.Errorf("UncommittedEvents() = %d after a conflict, want 1 kept", len(second.UncommittedEvents()))
+	}
+
+	if loaded, _ := repo.Load(ctx, "p-1"); loaded.Price != 11 {
+		t.Errorf("Price = %v, want the first writer's 11", loaded.Price)
+	}
+}
+
+func TestRepository_SaveErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		mediator *Mediator
+		id       string
+		event    Event
+		wantErr  error
+	}{
+		{name: "empty ID", mediator: NewMediator(), event: Event{Name: "product.created", Payload: &testProduct{}}},
+		{name: "unknown event", mediator: NewMediator(WithStrictEvents()), id: "p-1", event: Event{Name: "product.renamed"}, wantErr: ErrUnknownEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, store := productRepository(t, tt.mediator)
+			product := &productAggregate{}
+			product.SetID(tt.id)
+			product.uncommitted = append(product.uncommitted, tt.event)
+
+			err := repo.Save(context.Background(), product)
+			if err == nil {
+				t.Fatal("Save() error = nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Save() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(store.streams) != 0 {
+				t.Errorf("streams = %v, want nothing appended", store.streams)
+			}
+		})
+	}
+}
+
+func TestRaise_ApplyError(t *testing.T) {
+	product := &productAggregate{Deleted: true}
+	if err := Raise(product, Event{Name: "product.price_changed", Payload: priceChanged{Price: 1}}); err == nil {
+		t.Fatal("Raise() error = nil, want the aggregate's error")
+	}
+	if len(product.UncommittedEvents()) != 0 {
+		t.Error("Raise() recorded an event the aggregate rejected")
+	}
+}
+
+func TestLoadFromHistory(t *testing.T) {
+	product := &productAggregate{}
+	history := []Event{
+		{Name: "product.created", Payload: &testProduct{Price: 5}},
+		{Name: "product.price_changed", Payload: priceChanged{Price: 7}},
+	}
+	if err := LoadFromHistory(product, history); err != nil {
+		t.Fatalf("LoadFromHistory() error = %v", err)
+	}
+	if product.Price != 7 || product.Version() != 2 || len(product.UncommittedEvents()) != 0 {
+		t.Errorf("product = %+v, want price 7 at version 2 without uncommitted events", product)
+	}
+}
+
+func TestRepository_Snapshots(t *testing.T) {
+	m := NewMediator()
+	repo, store := productRepository(t, m)
+	snapshots := NewMemorySnapshotStore()
+	repo.config.Snapshots = snapshots
+	repo.config.SnapshotEvery = 2
+	ctx := context.Background()
+
+	product := &productAggregate{}
+	product.SetID("p-1")
+	Raise(product, Event{Name: "product.created", Payload: &testProduct{ID: "p-1", Price: 1}})
+	for price := 2.0; price <= 6; price++ {
+		Raise(product, Event{Name: "product.price_changed", Payload: priceChanged{Price: price}})
+		if price == 3 || price == 6 {
+			if err := repo.Save(ctx, product); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+		}
+	}
+
+	// Test a snapshot is taken when a save crosses a multiple of SnapshotEvery
+	snapshot, err := snapshots.LoadSnapshot(ctx, "product-p-1")
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if snapshot.Version != 6 || snapshot.ContentType != "application/json" {
+		t.Errorf("LoadSnapshot() = version %d as %s, want version 6 as JSON", snapshot.Version, snapshot.ContentType)
+	}
+
+	Raise(product, Event{Name: "product.price_changed", Payload: priceChanged{Price: 7}})
+	if err := repo.Save(ctx, product); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	store.reads = nil
+	loaded, err := repo.Load(ctx, "p-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Price != 7 || loaded.Version() != 7 || loaded.ID() != "p-1" {
+		t.Errorf("Load() = %+v, want price 7 at version 7", loaded)
+	}
+	if len(store.reads) != 1 || store.reads[0] != 7 {
+		t.Errorf("read the stream from %v, want only the event after the snapshot", store.reads)
+	}
+
+	// Test a snapshot of the latest version loads without events after it
+	if err := repo.SaveSnapshot(ctx, loaded); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	if loaded, err := repo.Load(ctx, "p-1"); err != nil || loaded.Price != 7 || loaded.Version() != 7 {
+		t.Errorf("Load() = %+v, %v; want price 7 at version 7", loaded, err)
+	}
+}
+
+func TestRepository_UnreadableSnapshot(t *testing.T) {
+	m := NewMediator()
+	repo, _ := productRepository(t, m)
+	snapshots := NewMemorySnapshotStore()
+	repo.config.Snapshots = snapshots
+	ctx := context.Background()
+
+	product := &productAggregate{}
+	product.SetID("p-1")
+	Raise(product, Event{Name: "product.created", Payload: &testProduct{ID: "p-1", Price: 3}})
+	if err := repo.Save(ctx, product); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		snapshot Snapshot
+	}{
+		{name: "corrupt state", snapshot: Snapshot{StreamID: "product-p-1", Version: 1, State: []byte("{"), ContentType: "application/json"}},
+		{name: "other encoding", snapshot: Snapshot{StreamID: "product-p-1", Version: 2, State: []byte("{}"), ContentType: "application/x-gob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshots.SaveSnapshot(ctx, tt.snapshot)
+			loaded, err := repo.Load(ctx, "p-1")
+			if err != nil || loaded.Price != 3 || loaded.Version() != 1 {
+				t.Errorf("Load() = %+v, %v; want the stream replayed", loaded, err)
+			}
+		})
+	}
+}
+
+func TestRepository_SaveSnapshotErrors(t *testing.T) {
+	m := NewMediator()
+	repo, _ := productRepository(t, m)
+
+	product := &productAggregate{}
+	product.SetID("p-1")
+	if err := repo.SaveSnapshot(context.Background(), product); err == nil {
+		t.Error("SaveSnapshot() without a snapshot store error = nil")
+	}
+
+	repo.config.Snapshots = NewMemorySnapshotStore()
+	if err := repo.SaveSnapshot(context.Background(), product); err == nil {
+		t.Error("SaveSnapshot() without saved events error = nil")
+	}
+	Raise(product, Event{Name: "product.created", Payload: &testProduct{ID: "p-1"}})
+	if err := repo.SaveSnapshot(context.Background(), product); err == nil {
+		t.Error("SaveSnapshot() with unsaved events error = nil")
+	}
+}
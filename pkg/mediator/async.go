@@ -0,0 +1,199 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrMediatorClosed is returned when publishing asynchronously after Close
+var ErrMediatorClosed = errors.New("mediator is closed")
+
+// PartitionKey extracts the key events are partitioned by, e.g. a product ID
+type PartitionKey func(event Event) string
+
+// asyncQueueSize is the number of events each async worker buffers per priority
+const asyncQueueSize = 256
+
+// WithPartitioning partitions PublishAsync over workers by key: events with
+// the same key are handled in order by the same worker, while different keys
+// run in parallel. Without it, events are spread over runtime.NumCPU() workers
+// by event ID.
+func WithPartitioning(key PartitionKey, workers int) Option {
+	return func(m *Mediator) {
+		m.partitionKey = key
+		m.partitionWorkers = workers
+	}
+}
+
+// asyncDispatcher runs PublishAsync events on partitioned workers
+type asyncDispatcher struct {
+	key    PartitionKey
+	queues []asyncLanes
+	done   chan struct{}
+	// bytes estimates the memory of the queued events
+	bytes  atomic.Int64
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// asyncItem is an event queued for a worker
+type asyncItem struct {
+	ctx   context.Context
+	event Event
+	opts  []PublishOption
+	size  int64
+}
+
+// PublishAsync queues an event and returns without waiting for its handlers.
+// It blocks while the event's worker queue is full. Each worker runs waiting
+// events by priority (see WithEventPriority), then in order. Handler failures
+// are logged and handled by retries and the dead-letter queue. Close waits for
+// queued events to finish.
+func (m *Mediator) PublishAsync(ctx context.Context, event Event, opts ...PublishOption) error {
+	if err := m.checkPublish(event); err != nil {
+		return err
+	}
+	if err := m.admit(); err != nil {
+		return err
+	}
+	event = event.inherit(ctx).stamp(m.now())
+	if m.synchronous {
+		return m.publishSynchronously(ctx, event, opts)
+	}
+
+	dispatcher := m.asyncDispatcher()
+
+	dispatcher.mu.RLock()
+	defer dispatcher.mu.RUnlock()
+	if dispatcher.closed {
+		return ErrMediatorClosed
+	}
+
+	// Keep context values such as the outbox, but not the caller's cancellation
+	item := asyncItem{ctx: context.WithoutCancel(ctx), event: event, opts: opts, size: estimateSize(event)}
+	lane := m.priority(event, opts).lane()
+	dispatcher.bytes.Add(item.size)
+	select {
+	case dispatcher.queues[dispatcher.partition(event)][lane] <- item:
+		return nil
+	case <-ctx.Done():
+		dispatcher.bytes.Add(-item.size)
+		return ctx.Err()
+	}
+}
+
+// publishSynchronously publishes an event of PublishAsync on the calling
+// goroutine, as WithSynchronousDispatch asks
+func (m *Mediator) publishSynchronously(ctx context.Context, event Event, opts []PublishOption) error {
+	m.mu.RLock()
+	closed := m.closed
+	m.mu.RUnlock()
+	if closed {
+		return ErrMediatorClosed
+	}
+
+	if err := m.publish(context.WithoutCancel(ctx), event, opts); err != nil {
+		m.logf("async publish of event %s failed: %v", event.ID, err)
+	}
+	return nil
+}
+
+// asyncDispatcher starts the async workers on first use, stopping them at
+// once when the mediator is closed
+func (m *Mediator) asyncDispatcher() *asyncDispatcher {
+	m.asyncOnce.Do(func() {
+		m.mu.RLock()
+		key, workers := m.partitionKey, m.partitionWorkers
+		m.mu.RUnlock()
+
+		if key == nil {
+			key = func(event Event) string { return event.ID }
+		}
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+
+		d := &asyncDispatcher{key: key, queues: make([]asyncLanes, workers), done: make(chan struct{})}
+		for i := range d.queues {
+			for lane := range d.queues[i] {
+				d.queues[i][lane] = make(chan asyncItem, asyncQueueSize)
+			}
+			d.wg.Add(1)
+			go m.asyncWorker(d, d.queues[i])
+		}
+
+		// Close may have run already, and with it the closers
+		m.mu.Lock()
+		m.async = d
+		closed := m.closed
+		if !closed {
+			m.closers = append(m.closers, func() error {
+				d.close()
+				return nil
+			})
+		}
+		m.mu.Unlock()
+		if closed {
+			d.close()
+		}
+	})
+	return m.async
+}
+
+// QueueDepth returns the number of events waiting in the PublishAsync queues
+// and, with WithBufferedStore, the store write buffer
+func (m *Mediator) QueueDepth() int {
+	m.mu.RLock()
+	async := m.async
+	buffer, _ := m.eventStore.(*bufferedStore)
+	m.mu.RUnlock()
+
+	depth := 0
+	if async != nil {
+		for _, lanes := range async.queues {
+			depth += lanes.depth()
+		}
+	}
+	if buffer != nil {
+		depth += len(buffer.queue)
+	}
+	return depth
+}
+
+// asyncWorker publishes queued events one at a time, highest priority first
+func (m *Mediator) asyncWorker(d *asyncDispatcher, lanes asyncLanes) {
+	defer d.wg.Done()
+	for {
+		item, ok := lanes.next(d.done)
+		if !ok {
+			return
+		}
+		d.bytes.Add(-item.size)
+		if err := m.publish(item.ctx, item.event, item.opts); err != nil {
+			m.logf("async publish of event %s failed: %v", item.event.ID, err)
+		}
+	}
+}
+
+// partition returns the worker queue index of an event
+func (d *asyncDispatcher) partition(event Event) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(d.key(event)))
+	return int(hash.Sum32() % uint32(len(d.queues)))
+}
+
+// close stops accepting events and waits for queued events to be handled
+func (d *asyncDispatcher) close() {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
@@ -0,0 +1,152 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMediator_PublishAsyncOrdersPerKey(t *testing.T) {
+	m := NewMediator(WithPartitioning(func(event Event) string {
+		return event.Metadata["product_id"]
+	}, 4))
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error {
+		// Uneven handler durations would reorder events without partitioning
+		seq := event.Payload.(int)
+		time.Sleep(time.Duration(seq%3) * time.Millisecond)
+		mu.Lock()
+		key := event.Metadata["product_id"]
+		seen[key] = append(seen[key], seq)
+		mu.Unlock()
+		return nil
+	})
+
+	for seq := 0; seq < 20; seq++ {
+		for _, key := range []string{"p-1", "p-2", "p-3"} {
+			event := Event{
+				Name:     "product.updated",
+				Payload:  seq,
+				Metadata: map[string]string{"product_id": key},
+			}
+			if err := m.PublishAsync(context.Background(), event); err != nil {
+				t.Fatalf("PublishAsync() error = %v", err)
+			}
+		}
+	}
+
+	// Close waits for queued events
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, key := range []string{"p-1", "p-2", "p-3"} {
+		if len(seen[key]) != 20 {
+			t.Fatalf("key %s handled %d events, want 20", key, len(seen[key]))
+		}
+		for i, seq := range seen[key] {
+			if seq != i {
+				t.Fatalf("key %s handled out of order: %v", key, seen[key])
+			}
+		}
+	}
+
+	if err := m.PublishAsync(context.Background(), Event{Name: "product.updated"}); !errors.Is(err, ErrMediatorClosed) {
+		t.Errorf("PublishAsync() after Close error = %v, want ErrMediatorClosed", err)
+	}
+}
+
+func TestMediator_PublishAsyncFirstAfterClose(t *testing.T) {
+	m := NewMediator()
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error {
+		t.Error("handler ran after Close")
+		return nil
+	})
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Test the dispatcher started after Close is closed rather than leaked
+	if err := m.PublishAsync(context.Background(), Event{Name: "product.updated"}); !errors.Is(err, ErrMediatorClosed) {
+		t.Errorf("PublishAsync() error = %v, want ErrMediatorClosed", err)
+	}
+	if len(m.closers) != 0 {
+		t.Errorf("registered %d closers after Close, want none", len(m.closers))
+	}
+}
+
+func TestMediator_PublishAsyncRunsKeysInParallel(t *testing.T) {
+	m := NewMediator(WithPartitioning(func(event Event) string {
+		return fmt.Sprint(event.Payload)
+	}, 8))
+	defer m.Close()
+
+	// Both handlers must be running at once to release each other
+	var started sync.WaitGroup
+	started.Add(2)
+	done := make(chan struct{}, 2)
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error {
+		started.Done()
+		started.Wait()
+		done <- struct{}{}
+		return nil
+	})
+
+	// Pick two keys that land on different workers
+	d := m.asyncDispatcher()
+	first := Event{Payload: "a"}
+	second := Event{Payload: "b"}
+	for i := 0; d.partition(second) == d.partition(first); i++ {
+		second.Payload = fmt.Sprintf("b%d", i)
+	}
+	first.Name, second.Name = "product.updated", "product.updated"
+
+	for _, event := range []Event{first, second} {
+		if err := m.PublishAsync(context.Background(), event); err != nil {
+			t.Fatalf("PublishAsync() error = %v", err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("keys on different workers did not run in parallel")
+		}
+	}
+}
+
+func TestMediator_QueueDepth(t *testing.T) {
+	m := NewMediator(WithPartitioning(func(event Event) string { return "" }, 1))
+	defer m.Close()
+	if depth := m.QueueDepth(); depth != 0 {
+		t.Errorf("QueueDepth() = %d before publishing, want 0", depth)
+	}
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+
+	ctx := context.Background()
+	m.PublishAsync(ctx, Event{Name: "order.placed"})
+	<-started
+	for i := 0; i < 3; i++ {
+		m.PublishAsync(ctx, Event{Name: "order.placed"})
+	}
+	if depth := m.QueueDepth(); depth != 3 {
+		t.Errorf("QueueDepth() = %d, want 3", depth)
+	}
+	close(release)
+}
@@ -0,0 +1,33 @@
+package mediator
+
+import "context"
+
+// Await blocks until the next event of eventName accepted by filter is
+// published, or ctx is done. A nil filter accepts every event.
+func (m *Mediator) Await(ctx context.Context, eventName string, filter Filter) (Event, error) {
+	if err := m.ValidateEventName(eventName); err != nil {
+		return Event{}, err
+	}
+	received := make(chan Event, 1)
+
+	opts := []SubscribeOption{WithHandlerName("mediator.Await")}
+	if filter != nil {
+		opts = append(opts, WithFilter(filter))
+	}
+	sub := m.Subscribe(eventName, func(ctx context.Context, event Event) error {
+		// Keep the first match; later events must not block the publisher
+		select {
+		case received <- event:
+		default:
+		}
+		return nil
+	}, opts...)
+	defer sub.Unsubscribe()
+
+	select {
+	case event := <-received:
+		return event, nil
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMediator_Await(t *testing.T) {
+	m := NewMediator()
+	m.Subscribe("order.shipped", func(ctx context.Context, event Event) error { return nil })
+
+	done := make(chan Event, 1)
+	go func() {
+		event, err := m.Await(context.Background(), "order.shipped", Field("order_id", "==", "o-2"))
+		if err != nil {
+			t.Errorf("Await() error = %v", err)
+		}
+		done <- event
+	}()
+
+	// Publish until the waiter is subscribed and has seen its match
+	deadline := time.After(time.Second)
+	for {
+		for _, id := range []string{"o-1", "o-2"} {
+			payload := map[string]interface{}{"order_id": id}
+			if err := m.Publish(context.Background(), Event{Name: "order.shipped", Payload: payload}); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+		}
+
+		select {
+		case event := <-done:
+			if event.Payload.(map[string]interface{})["order_id"] != "o-2" {
+				t.Errorf("Await() = %v, want order o-2", event.Payload)
+			}
+			// Test the waiter unsubscribed
+			if infos := m.Subscriptions(); infos[0].HandlerCount != 1 {
+				t.Errorf("handlers after Await() = %d, want 1", infos[0].HandlerCount)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Await() did not return")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestMediator_AwaitCancelled(t *testing.T) {
+	m := NewMediator()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.Await(ctx, "order.shipped", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Await() error = %v, want context.DeadlineExceeded", err)
+	}
+	if infos := m.Subscriptions(); len(infos) != 0 {
+		t.Errorf("Await() left %d subscriptions behind", len(infos))
+	}
+}
@@ -0,0 +1,107 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+)
+
+// BatchEventStore is implemented by event stores that can persist several
+// events in a single round-trip
+type BatchEventStore interface {
+	StoreEvents(ctx context.Context, events []Event) error
+}
+
+// BatchResult is the outcome of one event of a PublishBatch call
+type BatchResult struct {
+	// Event is the published event with its envelope filled in
+	Event Event
+	// Err is the dispatch error of the event, if any
+	Err error
+}
+
+// PublishBatch dispatches events in order and persists every dispatched event
+// with one batched write when the store implements BatchEventStore. Handler
+// failures are reported per event; the returned error reports a failed store write.
+// With StoreFirst or StoreOnly delivery each event is published, and stored, on its own.
+func (m *Mediator) PublishBatch(ctx context.Context, events []Event) ([]BatchResult, error) {
+	for _, event := range events {
+		if err := m.checkPublish(event); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.admit(); err != nil {
+		return nil, err
+	}
+	results := make([]BatchResult, len(events))
+
+	m.mu.RLock()
+	deliveryMode := m.deliveryMode
+	m.mu.RUnlock()
+
+	// Within an outbox transaction the relay stores the events later; events
+	// stored before dispatch must each pass validation before they are written
+	if _, ok := outboxFromContext(ctx); ok || deliveryMode != DispatchFirst {
+		for i, event := range events {
+			results[i].Event = m.scope(ctx, event.inherit(ctx).stamp(m.now()))
+			results[i].Err = m.publish(ctx, results[i].Event, nil)
+		}
+		return results, nil
+	}
+
+	dispatched := make([]Event, 0, len(events))
+	for i, event := range events {
+		event = m.scope(ctx, event.inherit(ctx).stamp(m.now()))
+		results[i].Event = event
+
+		err := m.publish(ctx, event, []PublishOption{withoutStore()})
+		if err != nil && !hasHandlerErrors(err) {
+			// Events that never reached a handler are not stored, like Publish
+			results[i].Err = err
+			continue
+		}
+		results[i].Err = err
+		dispatched = append(dispatched, event.stored())
+	}
+
+	m.mu.RLock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
+
+	if eventStore == nil || len(dispatched) == 0 {
+		return results, nil
+	}
+
+	if batchStore, ok := eventStore.(BatchEventStore); ok {
+		eventName := dispatched[0].Name
+		for _, event := range dispatched[1:] {
+			if event.Name != eventName {
+				eventName = ""
+				break
+			}
+		}
+		err := m.observeStore(ctx, StoreWrite, eventName, func() error {
+			return batchStore.StoreEvents(ctx, dispatched)
+		})
+		if err != nil {
+			return results, fmt.Errorf("failed to store events: %w", err)
+		}
+		return results, nil
+	}
+
+	for _, event := range dispatched {
+		err := m.observeStore(ctx, StoreWrite, event.Name, func() error {
+			return eventStore.StoreEvent(ctx, event)
+		})
+		if err != nil {
+			return results, fmt.Errorf("failed to store event %s: %w", event.ID, err)
+		}
+	}
+	return results, nil
+}
+
+// hasHandlerErrors reports whether err is a *PublishError, i.e. the event
+// reached its handlers
+func hasHandlerErrors(err error) bool {
+	_, ok := err.(*PublishError)
+	return ok
+}
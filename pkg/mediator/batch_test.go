@@ -0,0 +1,85 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// batchEventStore records how events reach the store
+type batchEventStore struct {
+	mockEventStore
+	batches [][]Event
+}
+
+func (s *batchEventStore) StoreEvents(ctx context.Context, events []Event) error {
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+func TestMediator_PublishBatch(t *testing.T) {
+	store := &batchEventStore{}
+	m := NewMediator(WithEventStore(store))
+
+	var handled []string
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		handled = append(handled, event.Payload.(string))
+		if event.Payload == "bad" {
+			return errors.New("invalid product")
+		}
+		return nil
+	})
+
+	events := []Event{
+		{Name: "product.created", Payload: "a"},
+		{Name: "product.created", Payload: "bad"},
+		{Name: "product.unknown", Payload: "c"},
+		{Name: "product.created", Payload: "d"},
+	}
+	results, err := m.PublishBatch(context.Background(), events)
+	if err != nil {
+		t.Fatalf("PublishBatch() error = %v", err)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("PublishBatch() returned %d results, want 4", len(results))
+	}
+	for i, wantErr := range []bool{false, true, true, false} {
+		if (results[i].Err != nil) != wantErr {
+			t.Errorf("results[%d].Err = %v, wantErr %v", i, results[i].Err, wantErr)
+		}
+		if results[i].Event.ID == "" {
+			t.Errorf("results[%d].Event has no ID", i)
+		}
+	}
+	if len(handled) != 3 {
+		t.Errorf("handled %v, want 3 events", handled)
+	}
+
+	// Test dispatched events are stored in one batch, including failed ones
+	if len(store.events) != 0 || len(store.batches) != 1 || len(store.batches[0]) != 3 {
+		t.Errorf("stored individually=%d batches=%d, want one batch of 3", len(store.events), len(store.batches))
+	}
+}
+
+func TestMediator_PublishBatchFallsBackToStoreEvent(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error { return nil })
+
+	results, err := m.PublishBatch(context.Background(), []Event{
+		{Name: "product.created"},
+		{Name: "product.created"},
+	})
+	if err != nil {
+		t.Fatalf("PublishBatch() error = %v", err)
+	}
+	if len(results) != 2 || len(store.events) != 2 {
+		t.Errorf("results=%d stored=%d, want 2, 2", len(results), len(store.events))
+	}
+
+	store.storeErr = errors.New("store down")
+	if _, err := m.PublishBatch(context.Background(), []Event{{Name: "product.created"}}); err == nil {
+		t.Error("PublishBatch() expected store error")
+	}
+}
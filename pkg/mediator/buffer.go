@@ -0,0 +1,368 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BufferConfig configures buffered event store writes
+type BufferConfig struct {
+	// Size is how many events may wait to be written; Publish blocks while the buffer is full
+	Size int
+	// BatchSize is the most events written at once while the store keeps up
+	BatchSize int
+	// MaxBatchSize is how far batches grow while events queue up faster than
+	// they are written; 0 keeps batches at BatchSize
+	MaxBatchSize int
+	// FlushInterval is how long an incomplete batch waits before it is written
+	FlushInterval time.Duration
+}
+
+// DefaultBufferConfig returns the default buffer configuration
+func DefaultBufferConfig() BufferConfig {
+	return BufferConfig{
+		Size:          1024,
+		BatchSize:     100,
+		MaxBatchSize:  1000,
+		FlushInterval: 100 * time.Millisecond,
+	}
+}
+
+// WithBufferedStore queues event store writes and persists them in batches from
+// a background flusher, taking the store round-trip out of Publish. Writes that
+// fail are logged and reported by Flush. Close flushes the buffer.
+func WithBufferedStore(config BufferConfig) Option {
+	return func(m *Mediator) {
+		m.bufferConfig = &config
+	}
+}
+
+// Flush writes every buffered event to the event store and returns the errors
+// of buffered writes that failed since the last Flush. Without a buffered store
+// it does nothing.
+func (m *Mediator) Flush(ctx context.Context) error {
+	m.mu.RLock()
+	buffer, ok := m.eventStore.(*bufferedStore)
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	if err := buffer.sync(ctx); err != nil {
+		return err
+	}
+	return buffer.takeErrors()
+}
+
+// StoreBatchSize returns the number of events the store write buffer currently
+// writes at once, or 0 without WithBufferedStore
+func (m *Mediator) StoreBatchSize() int {
+	m.mu.RLock()
+	buffer, ok := m.eventStore.(*bufferedStore)
+	m.mu.RUnlock()
+
+	if !ok {
+		return 0
+	}
+	return int(buffer.batchSize.Load())
+}
+
+// unwrapStore returns the store behind a buffered store, for background work
+// that would otherwise flush the buffer on every read
+func unwrapStore(store EventStore) EventStore {
+	if buffer, ok := store.(*bufferedStore); ok {
+		return buffer.store
+	}
+	return store
+}
+
+// bufferedStore is an EventStore that queues writes for a background flusher.
+// Reads flush the buffer first so they see every published event.
+type bufferedStore struct {
+	store   EventStore
+	config  BufferConfig
+	logf    func(format string, args ...interface{})
+	queue   chan Event
+	syncs   chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	// batchSize grows from BatchSize towards MaxBatchSize under load
+	batchSize atomic.Int64
+	// bytes estimates the memory of the queued events
+	bytes atomic.Int64
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// newBufferedStore starts a background flusher writing to store
+func newBufferedStore(store EventStore, config BufferConfig, logf func(string, ...interface{})) *bufferedStore {
+	defaults := DefaultBufferConfig()
+	if config.Size <= 0 {
+		config.Size = defaults.Size
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	if config.MaxBatchSize < config.BatchSize {
+		config.MaxBatchSize = config.BatchSize
+	}
+
+	s := &bufferedStore{
+		store:   store,
+		config:  config,
+		logf:    logf,
+		queue:   make(chan Event, config.Size),
+		syncs:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	s.batchSize.Store(int64(config.BatchSize))
+	go s.run()
+	return s
+}
+
+// StoreEvent queues event, waiting while the buffer is full
+func (s *bufferedStore) StoreEvent(ctx context.Context, event Event) error {
+	select {
+	case <-s.done:
+		return ErrMediatorClosed
+	default:
+	}
+
+	size := estimateSize(event)
+	s.bytes.Add(size)
+	select {
+	case s.queue <- event:
+		return nil
+	case <-ctx.Done():
+		s.bytes.Add(-size)
+		return ctx.Err()
+	case <-s.done:
+		s.bytes.Add(-size)
+		return ErrMediatorClosed
+	}
+}
+
+// StoreEvents queues events in order
+func (s *bufferedStore) StoreEvents(ctx context.Context, events []Event) error {
+	for _, event := range events {
+		if err := s.StoreEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetEvents flushes the buffer and reads from the underlying store
+func (s *bufferedStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	if err := s.sync(ctx); err != nil {
+		return nil, err
+	}
+	return s.store.GetEvents(ctx, eventName, limit)
+}
+
+// ReadEvents flushes the buffer and reads from the underlying store
+func (s *bufferedStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]StoredEvent, error) {
+	if err := s.sync(ctx); err != nil {
+		return nil, err
+	}
+	return AsEventStoreV2(s.store).ReadEvents(ctx, eventName, limit)
+}
+
+// GetEventsPage flushes the buffer and reads a page from the underlying store
+func (s *bufferedStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]StoredEvent, string, error) {
+	if err := s.sync(ctx); err != nil {
+		return nil, "", err
+	}
+	return pageEvents(ctx, s.store, eventName, cursor, pageSize)
+}
+
+// QueryEvents flushes the buffer and queries the underlying store
+func (s *bufferedStore) QueryEvents(ctx context.Context, eventName string, query EventQuery, limit int64) ([]StoredEvent, error) {
+	if err := s.sync(ctx); err != nil {
+		return nil, err
+	}
+	return queryEvents(ctx, s.store, eventName, query, limit)
+}
+
+// GetStreams flushes the buffer and lists the streams of the underlying store
+func (s *bufferedStore) GetStreams(ctx context.Context) ([]StreamInfo, error) {
+	if err := s.sync(ctx); err != nil {
+		return nil, err
+	}
+	return getStreams(ctx, s.store)
+}
+
+// DeleteBefore flushes the buffer and deletes from the underlying store
+func (s *bufferedStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	if err := s.sync(ctx); err != nil {
+		return err
+	}
+	compacting, ok := s.store.(CompactingEventStore)
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+	return compacting.DeleteBefore(ctx, eventName, t)
+}
+
+// ClearEvents flushes the buffer and clears the underlying store
+func (s *bufferedStore) ClearEvents(ctx context.Context, eventName string) error {
+	if err := s.sync(ctx); err != nil {
+		return err
+	}
+	return s.store.ClearEvents(ctx, eventName)
+}
+
+// sync waits until every event queued so far has been written
+func (s *bufferedStore) sync(ctx context.Context) error {
+	reply := make(chan struct{})
+	select {
+	case s.syncs <- reply:
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops the flusher after writing the remaining events and returns the
+// errors of failed writes not yet reported by Flush
+func (s *bufferedStore) close() error {
+	s.once.Do(func() { close(s.done) })
+	<-s.stopped
+	return s.takeErrors()
+}
+
+// takeErrors returns and forgets the errors of failed writes
+func (s *bufferedStore) takeErrors() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := errors.Join(s.errs...)
+	s.errs = nil
+	return err
+}
+
+// run batches queued events until the store is closed
+func (s *bufferedStore) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	// filled records whether a batch filled up since the last tick
+	filled := false
+	batch := make([]Event, 0, s.config.BatchSize)
+	for {
+		select {
+		case event := <-s.queue:
+			if batch = append(batch, event); len(batch) >= s.size() {
+				batch = s.write(batch)
+				s.grow()
+				filled = true
+			}
+		case <-ticker.C:
+			batch = s.write(batch)
+			if !filled {
+				s.shrink()
+			}
+			filled = false
+		case reply := <-s.syncs:
+			batch = s.write(s.drain(batch))
+			close(reply)
+		case <-s.done:
+			s.write(s.drain(batch))
+			return
+		}
+	}
+}
+
+// drain moves every queued event into batch, writing full batches on the way
+func (s *bufferedStore) drain(batch []Event) []Event {
+	for {
+		select {
+		case event := <-s.queue:
+			if batch = append(batch, event); len(batch) >= s.size() {
+				batch = s.write(batch)
+				s.grow()
+			}
+		default:
+			return batch
+		}
+	}
+}
+
+// size returns the current batch size
+func (s *bufferedStore) size() int {
+	return int(s.batchSize.Load())
+}
+
+// grow doubles the batch size, up to MaxBatchSize, when another full batch is
+// already queued, so fewer store round-trips drain a backlog
+func (s *bufferedStore) grow() {
+	size := s.size()
+	if size >= s.config.MaxBatchSize || len(s.queue) < size {
+		return
+	}
+	s.batchSize.Store(int64(min(size*2, s.config.MaxBatchSize)))
+}
+
+// shrink halves the batch size, down to BatchSize, after a flush interval in
+// which no batch filled up, so a quiet buffer writes small batches again
+func (s *bufferedStore) shrink() {
+	if size := s.size(); size > s.config.BatchSize {
+		s.batchSize.Store(int64(max(size/2, s.config.BatchSize)))
+	}
+}
+
+// write persists batch and returns an empty batch; stores may keep the slice they got
+func (s *bufferedStore) write(batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	var size int64
+	for _, event := range batch {
+		size += estimateSize(event)
+	}
+	defer s.bytes.Add(-size)
+
+	// Writes outlive the publishes that queued them
+	ctx := context.Background()
+	var errs []error
+	if batchStore, ok := s.store.(BatchEventStore); ok {
+		if err := batchStore.StoreEvents(ctx, batch); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write %d buffered events: %w", len(batch), err))
+		}
+	} else {
+		for _, event := range batch {
+			if err := s.store.StoreEvent(ctx, event); err != nil {
+				errs = append(errs, fmt.Errorf("failed to write buffered event %s: %w", event.ID, err))
+			}
+		}
+	}
+
+	for _, err := range errs {
+		s.logf("%v", err)
+	}
+	s.mu.Lock()
+	s.errs = append(s.errs, errs...)
+	s.mu.Unlock()
+	return make([]Event, 0, s.size())
+}
@@ -0,0 +1,235 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBatchEventStore is a BatchEventStore safe for use by the background flusher
+type syncBatchEventStore struct {
+	mu      sync.Mutex
+	batches [][]Event
+	err     error
+	// gate, when set, holds writes until it is closed
+	gate chan struct{}
+}
+
+func (s *syncBatchEventStore) StoreEvent(ctx context.Context, event Event) error {
+	return s.StoreEvents(ctx, []Event{event})
+}
+
+func (s *syncBatchEventStore) StoreEvents(ctx context.Context, events []Event) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, events)
+	return nil
+}
+
+func (s *syncBatchEventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []map[string]interface{}
+	for _, batch := range s.batches {
+		for _, event := range batch {
+			if event.Name == eventName {
+				records = append(records, map[string]interface{}{"name": event.Name, "payload": event.Payload})
+			}
+		}
+	}
+	return records, nil
+}
+
+func (s *syncBatchEventStore) ClearEvents(ctx context.Context, eventName string) error {
+	return nil
+}
+
+func (s *syncBatchEventStore) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sizes := make([]int, len(s.batches))
+	for i, batch := range s.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func (s *syncBatchEventStore) counts() (batches, events int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, batch := range s.batches {
+		events += len(batch)
+	}
+	return len(s.batches), events
+}
+
+func TestMediator_BufferedStoreFlush(t *testing.T) {
+	store := &syncBatchEventStore{}
+	m := NewMediator(
+		WithBufferedStore(BufferConfig{Size: 16, BatchSize: 4, FlushInterval: time.Hour}),
+		WithEventStore(store),
+	)
+	defer m.Close()
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	for i := 0; i < 10; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "order.placed", Payload: i}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	batches, events := store.counts()
+	if events != 10 {
+		t.Errorf("stored %d events, want 10", events)
+	}
+	// Test writes are batched up to BatchSize
+	if batches != 3 {
+		t.Errorf("stored %d batches, want 3", batches)
+	}
+
+	// Test reads see events still in the buffer
+	if err := m.Publish(context.Background(), Event{Name: "order.placed", Payload: 10}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	records, err := m.GetEvents(context.Background(), "order.placed", 0)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(records) != 11 {
+		t.Errorf("GetEvents() = %d events, want 11", len(records))
+	}
+}
+
+func TestMediator_BufferedStoreFlushInterval(t *testing.T) {
+	store := &syncBatchEventStore{}
+	m := NewMediator(WithEventStore(store), WithBufferedStore(BufferConfig{FlushInterval: 10 * time.Millisecond}))
+	defer m.Close()
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, events := store.counts(); events == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffered event was not written within the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMediator_BufferedStoreClose(t *testing.T) {
+	errStore := errors.New("store down")
+	store := &syncBatchEventStore{err: errStore}
+	m := NewMediator(WithEventStore(store), WithBufferedStore(BufferConfig{FlushInterval: time.Hour}))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// Test Close flushes and reports the failed write
+	if err := m.Close(); !errors.Is(err, errStore) {
+		t.Errorf("Close() error = %v, want %v", err, errStore)
+	}
+
+	// Test the closed buffer rejects writes
+	err := m.Publish(context.Background(), Event{Name: "order.placed"})
+	if !errors.Is(err, ErrMediatorClosed) {
+		t.Errorf("Publish() after Close error = %v, want ErrMediatorClosed", err)
+	}
+}
+
+func TestMediator_BufferedStoreAdaptiveBatchSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxBatchSize int
+		wantSizes    []int
+		wantSize     int
+	}{
+		{
+			name:         "grows while events queue up",
+			maxBatchSize: 16,
+			wantSizes:    []int{4, 8, 16, 16},
+			wantSize:     16,
+		},
+		{
+			name:      "fixed without MaxBatchSize",
+			wantSizes: []int{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4},
+			wantSize:  4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &syncBatchEventStore{gate: make(chan struct{})}
+			m := NewMediator(
+				WithBufferedStore(BufferConfig{Size: 64, BatchSize: 4, MaxBatchSize: tt.maxBatchSize, FlushInterval: time.Hour}),
+				WithEventStore(store),
+			)
+			defer m.Close()
+			m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+			if got := m.StoreBatchSize(); got != 4 {
+				t.Errorf("StoreBatchSize() before load = %d, want 4", got)
+			}
+
+			// The first batch blocks in the store while the rest queue up
+			for i := 0; i < 44; i++ {
+				if err := m.Publish(context.Background(), Event{Name: "order.placed", Payload: i}); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+			close(store.gate)
+
+			if err := m.Flush(context.Background()); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if got := store.sizes(); !reflect.DeepEqual(got, tt.wantSizes) {
+				t.Errorf("batch sizes = %v, want %v", got, tt.wantSizes)
+			}
+			if got := m.StoreBatchSize(); got != tt.wantSize {
+				t.Errorf("StoreBatchSize() = %d, want %d", got, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestBufferedStore_ShrinksWhenIdle(t *testing.T) {
+	s := newBufferedStore(&syncBatchEventStore{}, BufferConfig{BatchSize: 4, MaxBatchSize: 64, FlushInterval: 5 * time.Millisecond}, t.Logf)
+	defer s.close()
+	s.batchSize.Store(64)
+
+	deadline := time.Now().Add(time.Second)
+	for s.size() != 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("batch size = %d after a second idle, want 4", s.size())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMediator_StoreBatchSizeWithoutBuffer(t *testing.T) {
+	m := NewMediator(WithEventStore(&syncBatchEventStore{}))
+	defer m.Close()
+
+	if got := m.StoreBatchSize(); got != 0 {
+		t.Errorf("StoreBatchSize() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,70 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrCatalogNotSupported is returned by GetStreams when the event store cannot list its streams
+var ErrCatalogNotSupported = errors.New("event store does not list streams")
+
+// StreamInfo describes the stored events of one event name
+type StreamInfo struct {
+	Name string
+	// Namespace is the namespace the stream belongs to, if any
+	Namespace string
+	// Count is the number of stored events
+	Count      int64
+	FirstEvent time.Time
+	LastEvent  time.Time
+}
+
+// CatalogEventStore is implemented by event stores that can list their streams
+type CatalogEventStore interface {
+	// GetStreams returns every event name with stored events, ordered by name
+	GetStreams(ctx context.Context) ([]StreamInfo, error)
+}
+
+// GetStreams lists the event names with stored events, with their counts and
+// first and last timestamps, so tools can enumerate streams without querying
+// the store directly. In a namespace only its streams are listed.
+func (m *Mediator) GetStreams(ctx context.Context) ([]StreamInfo, error) {
+	ctx = m.storeContext(ctx)
+	m.mu.RLock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
+
+	if eventStore == nil {
+		return nil, fmt.Errorf("no event store configured")
+	}
+
+	streams, err := getStreams(ctx, eventStore)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := m.namespaceOf(ctx)
+	if namespace == "" {
+		return streams, nil
+	}
+	scoped := streams[:0]
+	for _, stream := range streams {
+		if name, ok := strings.CutPrefix(stream.Name, namespace+NamespaceSeparator); ok {
+			stream.Name, stream.Namespace = name, namespace
+			scoped = append(scoped, stream)
+		}
+	}
+	return scoped, nil
+}
+
+// getStreams lists the streams of store
+func getStreams(ctx context.Context, store EventStore) ([]StreamInfo, error) {
+	catalog, ok := store.(CatalogEventStore)
+	if !ok {
+		return nil, ErrCatalogNotSupported
+	}
+	return catalog.GetStreams(ctx)
+}
@@ -0,0 +1,58 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// catalogEventStore is an event store listing fixed streams
+type catalogEventStore struct {
+	mockEventStore
+	streams []StreamInfo
+}
+
+func (s *catalogEventStore) GetStreams(ctx context.Context) ([]StreamInfo, error) {
+	return append([]StreamInfo(nil), s.streams...), nil
+}
+
+func TestMediator_GetStreams(t *testing.T) {
+	now := time.Now()
+	store := &catalogEventStore{streams: []StreamInfo{
+		{Name: "acme:order.placed", Count: 2, FirstEvent: now, LastEvent: now},
+		{Name: "globex:order.placed", Count: 1, FirstEvent: now, LastEvent: now},
+		{Name: "order.placed", Count: 3, FirstEvent: now, LastEvent: now},
+	}}
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      []StreamInfo
+	}{
+		{"all streams", "", store.streams},
+		{"namespace", "acme", []StreamInfo{{Name: "order.placed", Namespace: "acme", Count: 2, FirstEvent: now, LastEvent: now}}},
+		{"empty namespace", "initech", []StreamInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator(WithEventStore(store), WithNamespace(tt.namespace))
+			got, err := m.GetStreams(context.Background())
+			if err != nil {
+				t.Fatalf("GetStreams() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetStreams() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Test stores that cannot list streams are reported
+	m := NewMediator(WithEventStore(&mockEventStore{}), WithBufferedStore(DefaultBufferConfig()))
+	defer m.Close()
+	if _, err := m.GetStreams(context.Background()); !errors.Is(err, ErrCatalogNotSupported) {
+		t.Errorf("GetStreams() error = %v, want ErrCatalogNotSupported", err)
+	}
+}
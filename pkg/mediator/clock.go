@@ -0,0 +1,90 @@
+package mediator
+
+import "time"
+
+// Clock tells the time and creates timers. The mediator reads it to stamp
+// events and dead letters, back off between retries and fire scheduled
+// events, so tests can replace it with a clock they advance by hand.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a timer firing once d has elapsed
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock
+type Timer interface {
+	// C returns the channel the time is sent on when the timer fires
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, reporting whether it was pending
+	Stop() bool
+}
+
+// SystemClock is the Clock of the operating system
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a timer backed by time.NewTimer
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+// systemTimer adapts *time.Timer to Timer
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// WithClock sets the clock used for event timestamps, retry backoff,
+// schedules, saga timeouts and the retention janitor; SystemClock is used by
+// default
+func WithClock(clock Clock) Option {
+	return func(m *Mediator) {
+		m.clock = clock
+	}
+}
+
+// WithSynchronousDispatch makes PublishAsync publish on the calling goroutine
+// before returning, so tests observe its effects without waiting. Handler
+// failures are still logged rather than returned.
+func WithSynchronousDispatch() Option {
+	return func(m *Mediator) {
+		m.synchronous = true
+	}
+}
+
+// timeSource returns the mediator's clock, SystemClock when none is set
+func (m *Mediator) timeSource() Clock {
+	if m.clock == nil {
+		return SystemClock{}
+	}
+	return m.clock
+}
+
+// now returns the current time of the mediator's clock in UTC
+func (m *Mediator) now() time.Time {
+	return m.timeSource().Now().UTC()
+}
+
+// sleep waits for d on clock, returning false when done is closed first
+func sleep(clock Clock, d time.Duration, done <-chan struct{}) bool {
+	timer := clock.NewTimer(d)
+	select {
+	case <-done:
+		timer.Stop()
+		return false
+	case <-timer.C():
+		return true
+	}
+}
@@ -0,0 +1,76 @@
+package mediator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock stopped at a time whose timers fire at once
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c fixedClock) NewTimer(d time.Duration) Timer {
+	timer := time.NewTimer(0)
+	return systemTimer{timer}
+}
+
+func TestWithClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	dlq := NewMemoryDeadLetterQueue()
+	m := NewMediator(
+		WithClock(fixedClock{now: now}),
+		WithDeadLetterQueue(dlq),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}),
+	)
+
+	var stamped time.Time
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		stamped = event.Timestamp
+		return context.DeadlineExceeded
+	}, WithHandlerName("failing"))
+
+	// The hour of backoff passes on the clock, not in real time
+	done := make(chan struct{})
+	go func() {
+		m.Publish(context.Background(), Event{Name: "test.event"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the retry to wait on the clock")
+	}
+
+	if !stamped.Equal(now) {
+		t.Errorf("event timestamp = %v, want %v", stamped, now)
+	}
+	letters, _ := dlq.List(context.Background(), "test.event", 0)
+	if len(letters) != 1 || !letters[0].FailedAt.Equal(now) {
+		t.Errorf("dead letters = %+v, want one failed at %v", letters, now)
+	}
+}
+
+func TestWithSynchronousDispatch(t *testing.T) {
+	m := NewMediator(WithSynchronousDispatch())
+	handled := false
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		handled = true
+		return nil
+	})
+
+	if err := m.PublishAsync(context.Background(), Event{Name: "test.event"}); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+	if !handled {
+		t.Error("expected PublishAsync to handle the event before returning")
+	}
+	if m.QueueDepth() != 0 {
+		t.Errorf("QueueDepth() = %d, want no queue", m.QueueDepth())
+	}
+}
@@ -0,0 +1,111 @@
+package mediator
+
+import "context"
+
+// eventContextKey is the context key under which the event being handled is stored
+type eventContextKey struct{}
+
+// EventFromContext returns the event whose handler is running with ctx
+func EventFromContext(ctx context.Context) (Event, bool) {
+	event, ok := ctx.Value(eventContextKey{}).(*Event)
+	if !ok {
+		return Event{}, false
+	}
+	return *event, true
+}
+
+// eventContext carries the event being handled and the mediator dispatching
+// it. Unlike context.WithValue it holds the event without boxing it, so
+// dispatch allocates it once.
+type eventContext struct {
+	context.Context
+	event    Event
+	mediator *Mediator
+}
+
+// contextWithEvent returns a copy of ctx carrying the event being handled and
+// the mediator m dispatching it
+func contextWithEvent(ctx context.Context, m *Mediator, event Event) context.Context {
+	return &eventContext{Context: ctx, event: event, mediator: m}
+}
+
+// Value returns a pointer to the event for eventContextKey, and the mediator
+// for mediatorContextKey
+func (c *eventContext) Value(key interface{}) interface{} {
+	switch key.(type) {
+	case eventContextKey:
+		return &c.event
+	case mediatorContextKey:
+		return c.mediator
+	}
+	return c.Context.Value(key)
+}
+
+// mediatorContextKey is the context key under which a mediator is stored
+type mediatorContextKey struct{}
+
+// WithMediator returns a copy of ctx carrying m, so code called with it can
+// publish through FromContext instead of a global mediator
+func WithMediator(ctx context.Context, m *Mediator) context.Context {
+	return context.WithValue(ctx, mediatorContextKey{}, m)
+}
+
+// FromContext returns the mediator carried by ctx: inside a handler the
+// mediator dispatching the event, otherwise the one set with WithMediator
+func FromContext(ctx context.Context) (*Mediator, bool) {
+	m, ok := ctx.Value(mediatorContextKey{}).(*Mediator)
+	return m, ok && m != nil
+}
+
+// handlerContextKey is the context key under which the name of the running handler is stored
+type handlerContextKey struct{}
+
+// HandlerNameFromContext returns the name of the handler running with ctx,
+// e.g. for middleware to report which handler it wraps
+func HandlerNameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(handlerContextKey{}).(*string)
+	if !ok {
+		return "", false
+	}
+	return *name, true
+}
+
+// handlerContext carries the name of the running handler
+type handlerContext struct {
+	context.Context
+	name string
+}
+
+// handlerContexts returns the context of every invocation, derived from ctx
+// and allocated at once
+func handlerContexts(ctx context.Context, invocations []invocation) []handlerContext {
+	contexts := make([]handlerContext, len(invocations))
+	for i, inv := range invocations {
+		contexts[i] = handlerContext{Context: ctx, name: inv.name}
+	}
+	return contexts
+}
+
+// Value returns a pointer to the handler name for handlerContextKey
+func (c *handlerContext) Value(key interface{}) interface{} {
+	if _, ok := key.(handlerContextKey); ok {
+		return &c.name
+	}
+	return c.Context.Value(key)
+}
+
+// inherit links e to the event being handled with ctx, if any: e is caused by
+// that event and joins its correlation unless the caller set them explicitly
+func (e Event) inherit(ctx context.Context) Event {
+	parent, ok := EventFromContext(ctx)
+	if !ok {
+		return e
+	}
+	if e.CausationID == "" {
+		e.CausationID = parent.ID
+	}
+	if e.CorrelationID == "" {
+		e.CorrelationID = parent.CorrelationID
+	}
+	return e
+}
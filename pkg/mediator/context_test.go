@@ -0,0 +1,160 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventFromContext(t *testing.T) {
+	if _, ok := EventFromContext(context.Background()); ok {
+		t.Error("EventFromContext() ok = true for context without event")
+	}
+
+	m := NewMediator()
+	var got Event
+	var ok bool
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		got, ok = EventFromContext(ctx)
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed", ID: "evt-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if !ok || got.ID != "evt-1" {
+		t.Errorf("EventFromContext() = %+v, %v, want event evt-1", got, ok)
+	}
+}
+
+func TestHandlerNameFromContext(t *testing.T) {
+	if _, ok := HandlerNameFromContext(context.Background()); ok {
+		t.Error("HandlerNameFromContext() ok = true for context without handler")
+	}
+
+	m := NewMediator()
+	var got string
+	m.Use(func(ctx context.Context, event Event, next EventHandler) error {
+		got, _ = HandlerNameFromContext(ctx)
+		return next(ctx, event)
+	})
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil }, WithHandlerName("reserve-stock"))
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got != "reserve-stock" {
+		t.Errorf("HandlerNameFromContext() = %q, want reserve-stock", got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("FromContext() ok = true for context without mediator")
+	}
+	if _, ok := FromContext(WithMediator(context.Background(), nil)); ok {
+		t.Error("FromContext() ok = true for a nil mediator")
+	}
+
+	m := NewMediator()
+	if got, ok := FromContext(WithMediator(context.Background(), m)); !ok || got != m {
+		t.Errorf("FromContext() = %p, %v, want the mediator set with WithMediator", got, ok)
+	}
+}
+
+func TestFromContext_InHandler(t *testing.T) {
+	origin, other := NewMediator(), NewMediator()
+	defer origin.Close()
+
+	shipped := make(chan Event, 1)
+	origin.Subscribe("order.shipped", func(ctx context.Context, event Event) error {
+		shipped <- event
+		return nil
+	})
+	origin.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		// Test nested code publishes through the mediator dispatching the event
+		m, ok := FromContext(ctx)
+		if !ok {
+			return errors.New("no mediator in handler context")
+		}
+		return m.Publish(ctx, Event{Name: "order.shipped"})
+	})
+
+	tests := []struct {
+		name    string
+		publish func(ctx context.Context) error
+	}{
+		{name: "Publish", publish: func(ctx context.Context) error {
+			return origin.Publish(ctx, Event{Name: "order.placed", ID: "evt-1"})
+		}},
+		{name: "PublishAsync", publish: func(ctx context.Context) error {
+			return origin.PublishAsync(ctx, Event{Name: "order.placed", ID: "evt-1"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The handler context carries the dispatching mediator, not the caller's
+			if err := tt.publish(WithMediator(context.Background(), other)); err != nil {
+				t.Fatalf("%s() error = %v", tt.name, err)
+			}
+			select {
+			case event := <-shipped:
+				if event.CausationID != "evt-1" {
+					t.Errorf("CausationID = %q, want evt-1", event.CausationID)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the event published from the handler")
+			}
+		})
+	}
+}
+
+func TestPublish_InheritsCorrelationFromHandler(t *testing.T) {
+	m := NewMediator()
+
+	var shipped, billed Event
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		return m.Publish(ctx, Event{Name: "order.shipped"})
+	})
+	m.Subscribe("order.shipped", func(ctx context.Context, event Event) error {
+		shipped = event
+		return m.Publish(ctx, Event{Name: "order.billed"})
+	})
+	m.Subscribe("order.billed", func(ctx context.Context, event Event) error {
+		billed = event
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed", ID: "evt-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	if shipped.CausationID != "evt-1" || shipped.CorrelationID != "evt-1" {
+		t.Errorf("shipped causation/correlation = %s/%s, want evt-1/evt-1", shipped.CausationID, shipped.CorrelationID)
+	}
+	if billed.CausationID != shipped.ID || billed.CorrelationID != "evt-1" {
+		t.Errorf("billed causation/correlation = %s/%s, want %s/evt-1", billed.CausationID, billed.CorrelationID, shipped.ID)
+	}
+}
+
+func TestPublish_ExplicitCorrelationWins(t *testing.T) {
+	m := NewMediator()
+
+	var child Event
+	m.Subscribe("parent", func(ctx context.Context, event Event) error {
+		return m.Publish(ctx, Event{Name: "child", CorrelationID: "corr-x", CausationID: "evt-x"})
+	})
+	m.Subscribe("child", func(ctx context.Context, event Event) error {
+		child = event
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "parent"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if child.CorrelationID != "corr-x" || child.CausationID != "evt-x" {
+		t.Errorf("child causation/correlation = %s/%s, want evt-x/corr-x", child.CausationID, child.CorrelationID)
+	}
+}
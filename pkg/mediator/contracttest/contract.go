@@ -0,0 +1,223 @@
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Schema describes the shape of an event payload as a set of JSON paths
+// mapped to their JSON types ("string", "number", "boolean", "object", "array", "null")
+type Schema map[string]string
+
+// Contract describes an event a producer promises to publish
+type Contract struct {
+	EventName string            `json:"event_name"`
+	Schema    Schema            `json:"schema"`
+	Samples   []json.RawMessage `json:"samples"`
+}
+
+// ChangeKind classifies a difference between two schemas
+type ChangeKind string
+
+const (
+	// FieldAdded means the new schema has a field the old one did not
+	FieldAdded ChangeKind = "field_added"
+	// FieldRemoved means the new schema dropped a field
+	FieldRemoved ChangeKind = "field_removed"
+	// TypeChanged means a field changed its JSON type
+	TypeChanged ChangeKind = "type_changed"
+)
+
+// Change is a single difference between two schemas
+type Change struct {
+	Path     string
+	Kind     ChangeKind
+	OldType  string
+	NewType  string
+	Breaking bool
+}
+
+// String returns a human readable description of the change
+func (c Change) String() string {
+	switch c.Kind {
+	case FieldAdded:
+		return fmt.Sprintf("%s: field added (%s)", c.Path, c.NewType)
+	case FieldRemoved:
+		return fmt.Sprintf("%s: field removed (was %s)", c.Path, c.OldType)
+	default:
+		return fmt.Sprintf("%s: type changed from %s to %s", c.Path, c.OldType, c.NewType)
+	}
+}
+
+// InferSchema derives a schema from a sample payload by encoding it as JSON
+func InferSchema(sample interface{}) (Schema, error) {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sample: %w", err)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sample: %w", err)
+	}
+
+	schema := make(Schema)
+	walk("$", decoded, schema)
+	return schema, nil
+}
+
+// walk records the JSON type of every field reachable from value
+func walk(path string, value interface{}, schema Schema) {
+	schema[path] = jsonType(value)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			walk(path+"."+key, child, schema)
+		}
+	case []interface{}:
+		// Arrays are described by their first element only
+		if len(v) > 0 {
+			walk(path+"[]", v[0], schema)
+		}
+	}
+}
+
+// jsonType returns the JSON type name of a decoded value
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Validate checks that payload provides every field of the schema with a matching type
+func (s Schema) Validate(payload interface{}) error {
+	actual, err := InferSchema(payload)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, change := range Diff(s, actual) {
+		// Nulls are tolerated for any declared field and empty arrays carry no element type
+		if change.Kind == TypeChanged && change.NewType == "null" {
+			continue
+		}
+		if change.Kind == FieldRemoved && strings.Contains(change.Path, "[]") {
+			continue
+		}
+		if change.Breaking {
+			problems = append(problems, change.String())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("payload does not match schema: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Diff lists the differences between an old and a new schema, sorted by path.
+// Removed fields and type changes are breaking; added fields are not.
+func Diff(oldSchema, newSchema Schema) []Change {
+	var changes []Change
+	for path, oldType := range oldSchema {
+		newType, ok := newSchema[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Kind: FieldRemoved, OldType: oldType, Breaking: true})
+		case newType != oldType:
+			changes = append(changes, Change{Path: path, Kind: TypeChanged, OldType: oldType, NewType: newType, Breaking: true})
+		}
+	}
+	for path, newType := range newSchema {
+		if _, ok := oldSchema[path]; !ok {
+			changes = append(changes, Change{Path: path, Kind: FieldAdded, NewType: newType})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// Registry holds the contracts a producer publishes
+type Registry struct {
+	mu        sync.RWMutex
+	contracts map[string]*Contract
+}
+
+// NewRegistry creates an empty contract registry
+func NewRegistry() *Registry {
+	return &Registry{
+		contracts: make(map[string]*Contract),
+	}
+}
+
+// Register adds a sample payload for an event name. The first sample defines
+// the schema; later samples must conform to it.
+func (r *Registry) Register(eventName string, sample interface{}) error {
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sample for %s: %w", eventName, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	contract, exists := r.contracts[eventName]
+	if !exists {
+		schema, err := InferSchema(sample)
+		if err != nil {
+			return fmt.Errorf("failed to infer schema for %s: %w", eventName, err)
+		}
+		contract = &Contract{EventName: eventName, Schema: schema}
+		r.contracts[eventName] = contract
+	} else if err := contract.Schema.Validate(sample); err != nil {
+		return fmt.Errorf("sample for %s: %w", eventName, err)
+	}
+
+	contract.Samples = append(contract.Samples, data)
+	return nil
+}
+
+// Add registers a complete contract, replacing any existing one for the same event name
+func (r *Registry) Add(contract *Contract) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contracts[contract.EventName] = contract
+}
+
+// Contract returns the contract registered for an event name
+func (r *Registry) Contract(eventName string) (*Contract, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	contract, ok := r.contracts[eventName]
+	return contract, ok
+}
+
+// Contracts returns all registered contracts sorted by event name
+func (r *Registry) Contracts() []*Contract {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	contracts := make([]*Contract, 0, len(r.contracts))
+	for _, contract := range r.contracts {
+		contracts = append(contracts, contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].EventName < contracts[j].EventName })
+	return contracts
+}
@@ -0,0 +1,171 @@
+package contracttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+type product struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// recordingT captures failures so tests can assert that a verification failed
+type recordingT struct {
+	testing.TB
+	failures int
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.failures++
+}
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema(product{ID: "1", Name: "Coffee", Price: 9.5})
+	if err != nil {
+		t.Fatalf("InferSchema() error = %v", err)
+	}
+
+	want := Schema{"$": "object", "$.id": "string", "$.name": "string", "$.price": "number"}
+	if len(schema) != len(want) {
+		t.Fatalf("InferSchema() = %v, want %v", schema, want)
+	}
+	for path, typ := range want {
+		if schema[path] != typ {
+			t.Errorf("InferSchema()[%s] = %s, want %s", path, schema[path], typ)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldSchema := Schema{"$": "object", "$.id": "string", "$.price": "number"}
+	newSchema := Schema{"$": "object", "$.id": "number", "$.sku": "string"}
+
+	changes := Diff(oldSchema, newSchema)
+	if len(changes) != 3 {
+		t.Fatalf("Diff() returned %d changes, want 3: %v", len(changes), changes)
+	}
+
+	breaking := 0
+	for _, change := range changes {
+		if change.Breaking {
+			breaking++
+		}
+	}
+	if breaking != 2 {
+		t.Errorf("Diff() reported %d breaking changes, want 2", breaking)
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("product.created", product{ID: "1", Name: "Coffee", Price: 9.5}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// A conforming sample is accepted
+	if err := r.Register("product.created", product{ID: "2", Name: "Tea", Price: 4}); err != nil {
+		t.Errorf("Register() conforming sample error = %v", err)
+	}
+
+	// A sample missing fields is rejected
+	if err := r.Register("product.created", map[string]interface{}{"id": "3"}); err == nil {
+		t.Error("Register() accepted sample missing fields")
+	}
+
+	contract, ok := r.Contract("product.created")
+	if !ok {
+		t.Fatal("Contract() did not return registered contract")
+	}
+	if len(contract.Samples) != 2 {
+		t.Errorf("Contract has %d samples, want 2", len(contract.Samples))
+	}
+}
+
+func TestVerifyProducer(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("product.created", product{ID: "1", Name: "Coffee", Price: 9.5}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	rt := &recordingT{TB: t}
+	VerifyProducer(rt, r,
+		mediator.Event{Name: "product.created", Payload: &product{ID: "2", Name: "Tea", Price: 4}},
+		mediator.Event{Name: "product.created", Payload: map[string]interface{}{"id": 2}},
+		mediator.Event{Name: "product.unknown", Payload: nil},
+	)
+	if rt.failures != 2 {
+		t.Errorf("VerifyProducer() reported %d failures, want 2", rt.failures)
+	}
+}
+
+func TestFixturesAndConsumer(t *testing.T) {
+	producer := NewRegistry()
+	if err := producer.Register("product.created", product{ID: "1", Name: "Coffee", Price: 9.5}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := producer.WriteFixtures(dir); err != nil {
+		t.Fatalf("WriteFixtures() error = %v", err)
+	}
+
+	consumer, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+
+	contract, ok := consumer.Contract("product.created")
+	if !ok {
+		t.Fatal("LoadFixtures() did not load product.created contract")
+	}
+
+	t.Run("handler accepts samples", func(t *testing.T) {
+		var got *product
+		handler := func(ctx context.Context, event mediator.Event) error {
+			p, ok := event.Payload.(*product)
+			if !ok {
+				return errors.New("invalid payload type")
+			}
+			got = p
+			return nil
+		}
+
+		VerifyConsumer(t, contract, handler, DecodeInto(func() interface{} { return &product{} }))
+		if got == nil || got.Name != "Coffee" {
+			t.Errorf("handler received %+v, want decoded sample", got)
+		}
+	})
+
+	t.Run("handler rejecting samples fails", func(t *testing.T) {
+		rt := &recordingT{TB: t}
+		handler := func(ctx context.Context, event mediator.Event) error {
+			return errors.New("rejected")
+		}
+
+		VerifyConsumer(rt, contract, handler, nil)
+		if rt.failures != 1 {
+			t.Errorf("VerifyConsumer() reported %d failures, want 1", rt.failures)
+		}
+	})
+
+	t.Run("breaking change is detected", func(t *testing.T) {
+		changed := NewRegistry()
+		if err := changed.Register("product.created", map[string]interface{}{"id": 1, "name": "Coffee"}); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		current, _ := changed.Contract("product.created")
+
+		rt := &recordingT{TB: t}
+		VerifyCompatible(rt, contract, current)
+		if rt.failures != 2 {
+			t.Errorf("VerifyCompatible() reported %d failures, want 2", rt.failures)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fixtureExt is the file extension used for contract fixtures
+const fixtureExt = ".contract.json"
+
+// WriteFixtures writes one fixture file per registered contract into dir.
+// Producers run this in CI and publish the directory for their consumers.
+func (r *Registry) WriteFixtures(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	for _, contract := range r.Contracts() {
+		data, err := json.MarshalIndent(contract, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal contract %s: %w", contract.EventName, err)
+		}
+
+		path := filepath.Join(dir, contract.EventName+fixtureExt)
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write fixture %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadFixtures reads every contract fixture in dir into a new registry
+func LoadFixtures(dir string) (*Registry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture directory: %w", err)
+	}
+
+	registry := NewRegistry()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fixtureExt) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+		}
+
+		var contract Contract
+		if err := json.Unmarshal(data, &contract); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fixture %s: %w", path, err)
+		}
+		registry.Add(&contract)
+	}
+
+	return registry, nil
+}
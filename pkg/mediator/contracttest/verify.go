@@ -0,0 +1,97 @@
+package contracttest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// DecodeFunc converts a raw sample into the payload type a consumer expects
+type DecodeFunc func(data []byte) (interface{}, error)
+
+// DecodeInto returns a DecodeFunc that unmarshals samples into new values created by factory
+func DecodeInto(factory func() interface{}) DecodeFunc {
+	return func(data []byte) (interface{}, error) {
+		target := factory()
+		if err := json.Unmarshal(data, target); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+}
+
+// decodeGeneric unmarshals a sample into generic JSON values
+func decodeGeneric(data []byte) (interface{}, error) {
+	var payload interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Check validates a published event against its registered contract
+func (r *Registry) Check(event mediator.Event) error {
+	contract, ok := r.Contract(event.Name)
+	if !ok {
+		return fmt.Errorf("no contract registered for event: %s", event.Name)
+	}
+	return contract.Schema.Validate(event.Payload)
+}
+
+// Handler returns an event handler that fails t when a published event breaks its contract.
+// Producers subscribe it to the events they publish during their tests.
+func (r *Registry) Handler(t testing.TB) mediator.EventHandler {
+	return func(ctx context.Context, event mediator.Event) error {
+		t.Helper()
+		if err := r.Check(event); err != nil {
+			t.Errorf("contract violation: %v", err)
+			return err
+		}
+		return nil
+	}
+}
+
+// VerifyProducer fails t for every event that does not match its registered contract
+func VerifyProducer(t testing.TB, r *Registry, events ...mediator.Event) {
+	t.Helper()
+	for _, event := range events {
+		if err := r.Check(event); err != nil {
+			t.Errorf("contract violation: %v", err)
+		}
+	}
+}
+
+// VerifyConsumer feeds every sample of the contract to handler and fails t when it is rejected.
+// A nil decode passes samples as generic JSON values.
+func VerifyConsumer(t testing.TB, contract *Contract, handler mediator.EventHandler, decode DecodeFunc) {
+	t.Helper()
+	if decode == nil {
+		decode = decodeGeneric
+	}
+
+	for i, sample := range contract.Samples {
+		payload, err := decode(sample)
+		if err != nil {
+			t.Errorf("%s sample %d: failed to decode: %v", contract.EventName, i, err)
+			continue
+		}
+
+		event := mediator.Event{Name: contract.EventName, Payload: payload}
+		if err := handler(context.Background(), event); err != nil {
+			t.Errorf("%s sample %d: handler rejected sample: %v", contract.EventName, i, err)
+		}
+	}
+}
+
+// VerifyCompatible fails t when current introduces breaking changes relative to the pinned contract
+func VerifyCompatible(t testing.TB, pinned, current *Contract) {
+	t.Helper()
+	for _, change := range Diff(pinned.Schema, current.Schema) {
+		if change.Breaking {
+			t.Errorf("%s: breaking change: %s", current.EventName, change)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package mediator
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DedupePrefix prefixes the event names StoreDedupeStore records processed events under
+const DedupePrefix = "dedupe."
+
+// DedupeStore remembers which handlers processed which event IDs
+type DedupeStore interface {
+	// Seen reports whether handler already processed the event
+	Seen(ctx context.Context, handler, eventID string) (bool, error)
+	// Mark records that handler processed the event
+	Mark(ctx context.Context, handler, eventID string) error
+}
+
+// WithDeduplication skips handlers for events whose ID they already processed,
+// so retries, replays and redeliveries stay idempotent
+func WithDeduplication(store DedupeStore) Option {
+	return func(m *Mediator) {
+		m.dedupe = store
+	}
+}
+
+// dedupeHandler wraps a handler so events it already processed are skipped and
+// successfully processed events are marked
+func (m *Mediator) dedupeHandler(store DedupeStore, name string, handler EventHandler) EventHandler {
+	return func(ctx context.Context, event Event) error {
+		if event.ID == "" {
+			return handler(ctx, event)
+		}
+
+		seen, err := store.Seen(ctx, name, event.ID)
+		if err != nil {
+			// Fail open: a duplicate is better than a lost event
+			m.logf("dedupe lookup for event %s failed: %v", event.ID, err)
+		} else if seen {
+			return nil
+		}
+
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+		if err := store.Mark(context.WithoutCancel(ctx), name, event.ID); err != nil {
+			m.logf("failed to mark event %s processed by %s: %v", event.ID, name, err)
+		}
+		return nil
+	}
+}
+
+// MemoryDedupeStore is an in-memory DedupeStore that forgets the least
+// recently used entries beyond its capacity
+type MemoryDedupeStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+// NewMemoryDedupeStore creates an LRU dedupe store holding up to capacity
+// entries; capacity <= 0 defaults to 10000
+func NewMemoryDedupeStore(capacity int) *MemoryDedupeStore {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &MemoryDedupeStore{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Seen reports whether handler already processed the event
+func (s *MemoryDedupeStore) Seen(ctx context.Context, handler, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, exists := s.entries[dedupeKey(handler, eventID)]
+	if exists {
+		s.order.MoveToFront(element)
+	}
+	return exists, nil
+}
+
+// Mark records that handler processed the event
+func (s *MemoryDedupeStore) Mark(ctx context.Context, handler, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := dedupeKey(handler, eventID)
+	if element, exists := s.entries[key]; exists {
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(key)
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(string))
+	}
+	return nil
+}
+
+// dedupeKey combines a handler name and event ID into a single key
+func dedupeKey(handler, eventID string) string {
+	return handler + "\x00" + eventID
+}
+
+// StoreDedupeStore records processed events in an EventStore under
+// DedupePrefix and checks the most recent window of them per handler
+type StoreDedupeStore struct {
+	store  EventStore
+	window int64
+}
+
+// NewStoreDedupeStore creates a dedupe store backed by store that remembers
+// the last window events per handler; window <= 0 uses the store default
+func NewStoreDedupeStore(store EventStore, window int64) *StoreDedupeStore {
+	return &StoreDedupeStore{
+		store:  store,
+		window: window,
+	}
+}
+
+// Seen reports whether handler already processed the event
+func (s *StoreDedupeStore) Seen(ctx context.Context, handler, eventID string) (bool, error) {
+	records, err := s.store.GetEvents(ctx, DedupePrefix+handler, s.window)
+	if err != nil {
+		return false, fmt.Errorf("failed to get processed events: %w", err)
+	}
+	for _, record := range records {
+		if record["payload"] == eventID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Mark records that handler processed the event as a "dedupe.<handler>" event
+func (s *StoreDedupeStore) Mark(ctx context.Context, handler, eventID string) error {
+	return s.store.StoreEvent(ctx, Event{
+		Name:    DedupePrefix + handler,
+		Payload: eventID,
+	})
+}
@@ -0,0 +1,84 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMediator_DeduplicatesByEventID(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func() DedupeStore
+	}{
+		{name: "memory", store: func() DedupeStore { return NewMemoryDedupeStore(0) }},
+		{name: "event store", store: func() DedupeStore { return NewStoreDedupeStore(&mockEventStore{}, 0) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator(WithDeduplication(tt.store()))
+
+			fail := true
+			charged, notified := 0, 0
+			m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+				charged++
+				return nil
+			}, WithHandlerName("charge"))
+			m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+				notified++
+				if fail {
+					return errors.New("mail server down")
+				}
+				return nil
+			}, WithHandlerName("notify"))
+
+			event := Event{Name: "order.placed", ID: "evt-1"}
+			if err := m.Publish(context.Background(), event); err == nil {
+				t.Fatal("Publish() expected handler error")
+			}
+
+			// Test republishing only re-runs the handler that failed
+			fail = false
+			if err := m.Publish(context.Background(), event); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if err := m.Publish(context.Background(), event); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if charged != 1 || notified != 2 {
+				t.Errorf("charged=%d notified=%d, want 1, 2", charged, notified)
+			}
+
+			// Test a new event ID is processed
+			if err := m.Publish(context.Background(), Event{Name: "order.placed", ID: "evt-2"}); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if charged != 2 {
+				t.Errorf("charged=%d, want 2", charged)
+			}
+		})
+	}
+}
+
+func TestMemoryDedupeStore_Evicts(t *testing.T) {
+	store := NewMemoryDedupeStore(2)
+	ctx := context.Background()
+
+	_ = store.Mark(ctx, "h", "a")
+	_ = store.Mark(ctx, "h", "b")
+	// Touch "a" so "b" is the least recently used
+	if seen, _ := store.Seen(ctx, "h", "a"); !seen {
+		t.Error("Seen(a) = false, want true")
+	}
+	_ = store.Mark(ctx, "h", "c")
+
+	for id, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if seen, _ := store.Seen(ctx, "h", id); seen != want {
+			t.Errorf("Seen(%s) = %v, want %v", id, seen, want)
+		}
+	}
+	if seen, _ := store.Seen(ctx, "other", "a"); seen {
+		t.Error("Seen() shared entries across handlers")
+	}
+}
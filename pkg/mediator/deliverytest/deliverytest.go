@@ -0,0 +1,454 @@
+package deliverytest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+// Guarantee is a delivery property a configuration claims to provide
+type Guarantee string
+
+const (
+	// OrderingPerKey means events with the same key are handled in publish order
+	OrderingPerKey Guarantee = "ordering-per-key"
+	// NoLoss means every published event reaches its subscribers
+	NoLoss Guarantee = "no-loss"
+	// NoLossAcrossRestart means every published event can be read back from the store after a restart
+	NoLossAcrossRestart Guarantee = "no-loss-across-restart"
+	// NoDuplicates means a redelivered event is handled only once, even after a
+	// restart when the system has a dedupe store
+	NoDuplicates Guarantee = "no-duplicates"
+)
+
+// AllGuarantees lists every guarantee the kit can verify
+var AllGuarantees = []Guarantee{OrderingPerKey, NoLoss, NoLossAcrossRestart, NoDuplicates}
+
+// System is a running mediator + store + transport configuration under test
+type System struct {
+	// Publish publishes an event into the system
+	Publish func(ctx context.Context, event mediator.Event) error
+	// Subscribe registers a handler for an event name
+	Subscribe func(eventName string, handler mediator.EventHandler)
+	// Store is the event store backing the system; nil skips restart checks
+	Store mediator.EventStore
+	// Dedupe is the dedupe store the system's handlers skip processed events
+	// with; when set, events are redelivered to a restarted system
+	Dedupe mediator.DedupeStore
+	// Close shuts the system down; it is called before a restart
+	Close func() error
+}
+
+// Factory creates a fresh System whose mediators follow clock, e.g. with
+// mediatortest.Deterministic. It is called again to simulate a restart and
+// must connect to the same durable backend.
+type Factory func(t testing.TB, clock *mediatortest.Clock) System
+
+// Config describes a conformance run
+type Config struct {
+	// Backend names the configuration in the report
+	Backend string
+	// Factory creates the system under test
+	Factory Factory
+	// Claims lists the guarantees the configuration promises; unclaimed ones are reported only
+	Claims []Guarantee
+	// Publishers is the number of concurrent publishers, each publishing its own key
+	Publishers int
+	// EventsPerPublisher is the number of events each publisher sends
+	EventsPerPublisher int
+	// Timeout bounds the wait for events delivered asynchronously, e.g. over
+	// transports; events handled while publishing are checked right away
+	Timeout time.Duration
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Publishers:         4,
+		EventsPerPublisher: 25,
+		Timeout:            5 * time.Second,
+	}
+}
+
+// Result is the outcome of verifying a single guarantee
+type Result struct {
+	Guarantee Guarantee
+	Claimed   bool
+	Passed    bool
+	Skipped   bool
+	Detail    string
+}
+
+// Report is the conformance report of a configuration
+type Report struct {
+	Backend string
+	Results []Result
+}
+
+// Passed reports whether every claimed guarantee that was checked held
+func (r Report) Passed() bool {
+	for _, result := range r.Results {
+		if result.Claimed && !result.Passed && !result.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteMarkdown writes the report as a Markdown table
+func (r Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", r.Backend)
+	b.WriteString("| Guarantee | Claimed | Result | Detail |\n")
+	b.WriteString("|-----------|---------|--------|--------|\n")
+	for _, result := range r.Results {
+		status := "pass"
+		switch {
+		case result.Skipped:
+			status = "skip"
+		case !result.Passed:
+			status = "fail"
+		}
+		fmt.Fprintf(&b, "| %s | %t | %s | %s |\n", result.Guarantee, result.Claimed, status, result.Detail)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// message is the payload published by the kit
+type message struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+	Seq int    `json:"seq"`
+}
+
+// delivery records a handled message
+type delivery struct {
+	key string
+	seq int
+	id  string
+}
+
+// Run verifies every guarantee against the configuration, fails t when a claimed
+// guarantee does not hold and returns the conformance report
+func Run(t *testing.T, config Config) Report {
+	t.Helper()
+	defaults := DefaultConfig()
+	if config.Publishers <= 0 {
+		config.Publishers = defaults.Publishers
+	}
+	if config.EventsPerPublisher <= 0 {
+		config.EventsPerPublisher = defaults.EventsPerPublisher
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+
+	claimed := make(map[Guarantee]bool, len(config.Claims))
+	for _, g := range config.Claims {
+		claimed[g] = true
+	}
+
+	// Every run uses its own event names so handlers from earlier runs never interfere
+	prefix := fmt.Sprintf("deliverytest.%d", time.Now().UnixNano())
+	// Retries back off on the clock, so they run without waiting
+	clock := mediatortest.NewClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	clock.AutoAdvance(true)
+
+	report := Report{Backend: config.Backend}
+	report.Results = append(report.Results, checkDelivery(t, config, clock, prefix+".delivery")...)
+	report.Results = append(report.Results, checkRestart(t, config, clock, prefix+".restart"))
+	report.Results = append(report.Results, checkDuplicates(t, config, clock, prefix+".duplicates"))
+
+	for i := range report.Results {
+		result := &report.Results[i]
+		result.Claimed = claimed[result.Guarantee]
+		if result.Claimed && !result.Passed && !result.Skipped {
+			t.Errorf("%s: claimed guarantee %s does not hold: %s", config.Backend, result.Guarantee, result.Detail)
+		}
+	}
+
+	return report
+}
+
+// collector records deliveries from concurrent handlers
+type collector struct {
+	mu         sync.Mutex
+	deliveries []delivery
+	// changed is signalled after each delivery
+	changed chan struct{}
+}
+
+func newCollector() *collector {
+	return &collector{changed: make(chan struct{}, 1)}
+}
+
+func (c *collector) handler(ctx context.Context, event mediator.Event) error {
+	msg, err := decode(event.Payload)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.deliveries = append(c.deliveries, delivery{key: msg.Key, seq: msg.Seq, id: msg.ID})
+	c.mu.Unlock()
+
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (c *collector) snapshot() []delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]delivery(nil), c.deliveries...)
+}
+
+// decode accepts both live payloads and payloads decoded from a store
+func decode(payload interface{}) (message, error) {
+	switch p := payload.(type) {
+	case message:
+		return p, nil
+	case *message:
+		return *p, nil
+	case map[string]interface{}:
+		id, _ := p["id"].(string)
+		key, _ := p["key"].(string)
+		seq, _ := p["seq"].(float64)
+		return message{ID: id, Key: key, Seq: int(seq)}, nil
+	default:
+		return message{}, fmt.Errorf("unexpected payload type %T", payload)
+	}
+}
+
+// eventID returns the ID of the event of a message, the same every time the
+// message is published, so redeliveries can be recognized
+func eventID(eventName string, msg message) string {
+	return eventName + "." + msg.ID
+}
+
+// publishAll publishes EventsPerPublisher events for each key concurrently
+func publishAll(ctx context.Context, config Config, system System, eventName string) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for p := 0; p < config.Publishers; p++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for seq := 0; seq < config.EventsPerPublisher; seq++ {
+				msg := message{ID: fmt.Sprintf("%s-%d", key, seq), Key: key, Seq: seq}
+				event := mediator.Event{Name: eventName, ID: eventID(eventName, msg), Payload: msg}
+				if err := system.Publish(ctx, event); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}(fmt.Sprintf("key-%d", p))
+	}
+	wg.Wait()
+	return errs
+}
+
+// checkDelivery verifies OrderingPerKey and NoLoss
+func checkDelivery(t *testing.T, config Config, clock *mediatortest.Clock, eventName string) []Result {
+	system := config.Factory(t, clock)
+	defer closeSystem(t, system)
+
+	c := newCollector()
+	system.Subscribe(eventName, c.handler)
+
+	errs := publishAll(context.Background(), config, system, eventName)
+	deliveries := c.waitFor(config.Timeout, func(deliveries []delivery) bool {
+		return missing(config, deliveries) == 0
+	})
+
+	return []Result{
+		{Guarantee: OrderingPerKey, Passed: inOrder(deliveries) == "", Detail: orDefault(inOrder(deliveries), "events delivered in order per key")},
+		{Guarantee: NoLoss, Passed: len(errs) == 0 && missing(config, deliveries) == 0, Detail: lossDetail(config, deliveries, errs)},
+	}
+}
+
+// checkRestart verifies NoLossAcrossRestart by reading events back through a new system
+func checkRestart(t *testing.T, config Config, clock *mediatortest.Clock, eventName string) Result {
+	result := Result{Guarantee: NoLossAcrossRestart}
+
+	system := config.Factory(t, clock)
+	if system.Store == nil {
+		closeSystem(t, system)
+		result.Skipped = true
+		result.Detail = "no event store configured"
+		return result
+	}
+	system.Subscribe(eventName, func(ctx context.Context, event mediator.Event) error { return nil })
+
+	errs := publishAll(context.Background(), config, system, eventName)
+	closeSystem(t, system)
+
+	restarted := config.Factory(t, clock)
+	defer closeSystem(t, restarted)
+
+	stored, err := restarted.Store.GetEvents(context.Background(), eventName, int64(config.Publishers*config.EventsPerPublisher*2))
+	if err != nil {
+		result.Detail = fmt.Sprintf("failed to read events after restart: %v", err)
+		return result
+	}
+
+	var deliveries []delivery
+	for _, event := range stored {
+		msg, err := decode(event["payload"])
+		if err != nil {
+			result.Detail = fmt.Sprintf("failed to decode stored event: %v", err)
+			return result
+		}
+		deliveries = append(deliveries, delivery{key: msg.Key, seq: msg.Seq, id: msg.ID})
+	}
+
+	result.Passed = len(errs) == 0 && missing(config, deliveries) == 0
+	result.Detail = lossDetail(config, deliveries, errs)
+	return result
+}
+
+// checkDuplicates verifies NoDuplicates by publishing every event twice with
+// the same ID, restarting the system in between when it has a dedupe store.
+// A fence event published last marks the end of the redeliveries.
+func checkDuplicates(t *testing.T, config Config, clock *mediatortest.Clock, eventName string) Result {
+	c := newCollector()
+	system := config.Factory(t, clock)
+	system.Subscribe(eventName, c.handler)
+	errs := publishAll(context.Background(), config, system, eventName)
+	c.waitFor(config.Timeout, func(deliveries []delivery) bool {
+		return missing(config, deliveries) == 0
+	})
+
+	restarted := system.Dedupe != nil
+	if restarted {
+		closeSystem(t, system)
+		system = config.Factory(t, clock)
+		system.Subscribe(eventName, c.handler)
+	}
+	defer closeSystem(t, system)
+	errs = append(errs, publishAll(context.Background(), config, system, eventName)...)
+
+	fence := message{ID: "fence", Key: "fence"}
+	if err := system.Publish(context.Background(), mediator.Event{Name: eventName, ID: eventID(eventName, fence), Payload: fence}); err != nil {
+		errs = append(errs, err)
+	}
+	deliveries := c.waitFor(config.Timeout, func(deliveries []delivery) bool {
+		for _, d := range deliveries {
+			if d.id == fence.ID {
+				return true
+			}
+		}
+		return false
+	})
+
+	seen := make(map[string]int, len(deliveries))
+	duplicates := 0
+	for _, d := range deliveries {
+		seen[d.id]++
+		if seen[d.id] == 2 {
+			duplicates++
+		}
+	}
+
+	detail := fmt.Sprintf("%d of %d redelivered events handled more than once", duplicates, len(seen))
+	if restarted {
+		detail += " across a restart"
+	}
+	if len(errs) > 0 {
+		detail += fmt.Sprintf(", %d publish errors (first: %v)", len(errs), errs[0])
+	}
+	return Result{
+		Guarantee: NoDuplicates,
+		Passed:    duplicates == 0 && len(errs) == 0 && seen[fence.ID] > 0,
+		Detail:    detail,
+	}
+}
+
+// waitFor waits until done holds for the deliveries, or until timeout when
+// they don't arrive, and returns them
+func (c *collector) waitFor(timeout time.Duration, done func([]delivery) bool) []delivery {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		deliveries := c.snapshot()
+		if done(deliveries) {
+			return deliveries
+		}
+		select {
+		case <-c.changed:
+		case <-timer.C:
+			return c.snapshot()
+		}
+	}
+}
+
+// inOrder returns a description of the first ordering violation, or "" if none
+func inOrder(deliveries []delivery) string {
+	last := make(map[string]int)
+	for _, d := range deliveries {
+		if prev, ok := last[d.key]; ok && d.seq <= prev {
+			return fmt.Sprintf("key %s: seq %d handled after seq %d", d.key, d.seq, prev)
+		}
+		last[d.key] = d.seq
+	}
+	return ""
+}
+
+// missing counts published events that were never delivered
+func missing(config Config, deliveries []delivery) int {
+	seen := make(map[string]bool, len(deliveries))
+	for _, d := range deliveries {
+		seen[d.id] = true
+	}
+
+	count := 0
+	for p := 0; p < config.Publishers; p++ {
+		for seq := 0; seq < config.EventsPerPublisher; seq++ {
+			if !seen[fmt.Sprintf("key-%d-%d", p, seq)] {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// lossDetail describes the outcome of a loss check
+func lossDetail(config Config, deliveries []delivery, errs []error) string {
+	total := config.Publishers * config.EventsPerPublisher
+	detail := fmt.Sprintf("%d of %d events missing", missing(config, deliveries), total)
+	if len(errs) > 0 {
+		detail += fmt.Sprintf(", %d publish errors (first: %v)", len(errs), errs[0])
+	}
+	return detail
+}
+
+// closeSystem closes a system if it has a Close func
+func closeSystem(t testing.TB, system System) {
+	if system.Close == nil {
+		return
+	}
+	if err := system.Close(); err != nil {
+		t.Logf("failed to close system: %v", err)
+	}
+}
+
+// orDefault returns s, or fallback when s is empty
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
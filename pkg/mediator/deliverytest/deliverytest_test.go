@@ -0,0 +1,207 @@
+package deliverytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+// memoryStore is a minimal durable store shared across restarts
+type memoryStore struct {
+	mu     sync.Mutex
+	events map[string][]map[string]interface{}
+}
+
+func (s *memoryStore) StoreEvent(ctx context.Context, event mediator.Event) error {
+	data, err := json.Marshal(map[string]interface{}{"name": event.Name, "payload": event.Payload})
+	if err != nil {
+		return err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events[event.Name] = append(s.events[event.Name], decoded)
+	return nil
+}
+
+func (s *memoryStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.events[eventName]...), nil
+}
+
+func (s *memoryStore) ClearEvents(ctx context.Context, eventName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.events, eventName)
+	return nil
+}
+
+// bus is a minimal synchronous system used to exercise the kit
+type bus struct {
+	mu       sync.Mutex
+	handlers map[string][]mediator.EventHandler
+	store    mediator.EventStore
+}
+
+func (b *bus) system() System {
+	return System{
+		Publish: func(ctx context.Context, event mediator.Event) error {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for _, handler := range b.handlers[event.Name] {
+				if err := handler(ctx, event); err != nil {
+					return err
+				}
+			}
+			if b.store != nil {
+				return b.store.StoreEvent(ctx, event)
+			}
+			return nil
+		},
+		Subscribe: func(eventName string, handler mediator.EventHandler) {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.handlers[eventName] = append(b.handlers[eventName], handler)
+		},
+		Store: b.store,
+	}
+}
+
+// mediatorSystem returns a system of a single mediator
+func mediatorSystem(m *mediator.Mediator, store mediator.EventStore, dedupe mediator.DedupeStore) System {
+	return System{
+		Publish: m.Publish,
+		Subscribe: func(eventName string, handler mediator.EventHandler) {
+			m.Subscribe(eventName, handler)
+		},
+		Store:  store,
+		Dedupe: dedupe,
+		Close:  m.Close,
+	}
+}
+
+func TestRun(t *testing.T) {
+	store := &memoryStore{events: make(map[string][]map[string]interface{})}
+	dedupe := mediator.NewMemoryDedupeStore(1000)
+
+	config := DefaultConfig()
+	config.Backend = "memory"
+	config.Claims = AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) System {
+		m := mediator.NewMediator(
+			mediatortest.Deterministic(clock),
+			mediator.WithEventStore(store),
+			mediator.WithDeduplication(dedupe),
+		)
+		return mediatorSystem(m, store, dedupe)
+	}
+
+	report := Run(t, config)
+	if !report.Passed() {
+		t.Fatalf("Run() report did not pass: %+v", report)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteMarkdown(&buf); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	for _, row := range []string{"| no-loss | true | pass |", "| no-duplicates | true | pass | 0 of 101 redelivered events handled more than once across a restart |"} {
+		if !strings.Contains(buf.String(), row) {
+			t.Errorf("WriteMarkdown() output missing row %q:\n%s", row, buf.String())
+		}
+	}
+}
+
+func TestRun_Transport(t *testing.T) {
+	store := &memoryStore{events: make(map[string][]map[string]interface{})}
+	dedupe := mediator.NewMemoryDedupeStore(1000)
+
+	// Events published by one mediator are handled by another over a transport
+	config := DefaultConfig()
+	config.Backend = "memory transport"
+	config.Claims = AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) System {
+		transport := mediator.NewMemoryTransport()
+		publisher := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithEventStore(store), mediator.WithTransport(transport))
+		subscriber := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithDeduplication(dedupe), mediator.WithTransport(transport))
+		return System{
+			Publish: publisher.Publish,
+			Subscribe: func(eventName string, handler mediator.EventHandler) {
+				subscriber.Subscribe(eventName, handler)
+			},
+			Store:  store,
+			Dedupe: dedupe,
+			Close: func() error {
+				return errors.Join(subscriber.Close(), publisher.Close(), transport.Close())
+			},
+		}
+	}
+
+	if report := Run(t, config); !report.Passed() {
+		t.Fatalf("Run() report did not pass: %+v", report)
+	}
+}
+
+func TestRun_WithoutDedupe(t *testing.T) {
+	store := &memoryStore{events: make(map[string][]map[string]interface{})}
+
+	config := DefaultConfig()
+	config.Backend = "memory-no-dedupe"
+	config.Claims = []Guarantee{OrderingPerKey, NoLoss, NoLossAcrossRestart}
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) System {
+		b := &bus{handlers: make(map[string][]mediator.EventHandler), store: store}
+		return b.system()
+	}
+
+	report := Run(t, config)
+	if !report.Passed() {
+		t.Fatalf("Run() report did not pass: %+v", report)
+	}
+
+	// Without a dedupe store the bus handles redeliveries twice
+	last := report.Results[len(report.Results)-1]
+	if last.Guarantee != NoDuplicates || last.Passed || last.Claimed {
+		t.Errorf("unexpected no-duplicates result: %+v", last)
+	}
+}
+
+func TestRun_SkipsRestartWithoutStore(t *testing.T) {
+	config := DefaultConfig()
+	config.Backend = "memory-no-store"
+	config.Claims = []Guarantee{NoLoss}
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) System {
+		b := &bus{handlers: make(map[string][]mediator.EventHandler)}
+		return b.system()
+	}
+
+	report := Run(t, config)
+	for _, result := range report.Results {
+		if result.Guarantee == NoLossAcrossRestart && !result.Skipped {
+			t.Errorf("expected restart check to be skipped, got %+v", result)
+		}
+	}
+}
+
+func TestInOrder(t *testing.T) {
+	ordered := []delivery{{key: "a", seq: 0}, {key: "b", seq: 0}, {key: "a", seq: 1}}
+	if got := inOrder(ordered); got != "" {
+		t.Errorf("inOrder() = %q, want no violation", got)
+	}
+
+	unordered := []delivery{{key: "a", seq: 1}, {key: "a", seq: 0}}
+	if got := inOrder(unordered); got == "" {
+		t.Error("inOrder() did not report violation")
+	}
+}
@@ -0,0 +1,141 @@
+package deliverytest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// sentinelPrefix starts the IDs of the events Settle publishes
+const sentinelPrefix = "deliverytest.sentinel."
+
+// sentinels numbers the events Settle publishes
+var sentinels atomic.Int64
+
+// Recorder records the events a handler receives, for tests of transports
+// to check how many times each was delivered
+type Recorder struct {
+	mu     sync.Mutex
+	events []mediator.Event
+	// changed is signalled after each event
+	changed chan struct{}
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{changed: make(chan struct{}, 1)}
+}
+
+// Handle records an event; subscribe it as the handler under test
+func (r *Recorder) Handle(ctx context.Context, event mediator.Event) error {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Events returns the recorded events in the order they were handled, leaving
+// out the sentinels of Settle
+func (r *Recorder) Events() []mediator.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]mediator.Event, 0, len(r.events))
+	for _, event := range r.events {
+		if !strings.HasPrefix(event.ID, sentinelPrefix) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// WaitFor waits until an event with the ID is recorded and reports whether
+// it was before timeout
+func (r *Recorder) WaitFor(id string, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		if r.has(id) {
+			return true
+		}
+		select {
+		case <-r.changed:
+		case <-timer.C:
+			return r.has(id)
+		}
+	}
+}
+
+// has reports whether an event with the ID is recorded
+func (r *Recorder) has(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.events {
+		if event.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Settle publishes a sentinel copy of event with publish and waits until
+// every recorder handled it, failing t after timeout. The copy differs only
+// by its ID, so it takes the route of event: its topic, partition or
+// ordering key. A transport delivers the events of a route in order, so the
+// recorders have handled every copy of the events published before, and
+// their Events show what was not delivered without sleeping.
+func Settle(t testing.TB, timeout time.Duration, publish func(ctx context.Context, event mediator.Event) error, event mediator.Event, recorders ...*Recorder) {
+	t.Helper()
+	event.ID = fmt.Sprintf("%s%d", sentinelPrefix, sentinels.Add(1))
+	if err := publish(context.Background(), event); err != nil {
+		t.Fatalf("failed to publish sentinel %s: %v", event.ID, err)
+	}
+	for i, r := range recorders {
+		if !r.WaitFor(event.ID, timeout) {
+			t.Fatalf("recorder %d did not receive sentinel %s within %v", i, event.ID, timeout)
+		}
+	}
+}
+
+// CheckOnce checks that the instances attached to each other by a
+// transport each handled event once: the first, which published it, locally
+// and the others remotely. recorders[i] records the handler of the event's
+// name of instances[i]. Each instance settles the others in turn, so a copy
+// of the event echoed to any of them has arrived by then.
+func CheckOnce(t testing.TB, timeout time.Duration, event mediator.Event, instances []*mediator.Mediator, recorders []*Recorder) {
+	t.Helper()
+	for i, r := range recorders {
+		if !r.WaitFor(event.ID, timeout) {
+			t.Fatalf("instance %d did not receive %s within %v", i, event.ID, timeout)
+		}
+	}
+	for _, m := range instances {
+		Settle(t, timeout, m.Publish, event, recorders...)
+	}
+
+	for i, r := range recorders {
+		var handled []mediator.Event
+		for _, e := range r.Events() {
+			if e.ID == event.ID {
+				handled = append(handled, e)
+			}
+		}
+		if len(handled) != 1 {
+			t.Errorf("instance %d handled %s %d times, want once", i, event.ID, len(handled))
+			continue
+		}
+		if remote := mediator.IsRemote(handled[0]); remote != (i > 0) {
+			t.Errorf("instance %d handled %s with IsRemote = %v, want %v", i, event.ID, remote, i > 0)
+		}
+	}
+}
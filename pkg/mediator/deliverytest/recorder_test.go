@@ -0,0 +1,83 @@
+package deliverytest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// doublingTransport sends every event twice
+type doublingTransport struct {
+	*mediator.MemoryTransport
+}
+
+func (t doublingTransport) Publish(ctx context.Context, event mediator.Event) error {
+	if err := t.MemoryTransport.Publish(ctx, event); err != nil {
+		return err
+	}
+	return t.MemoryTransport.Publish(ctx, event)
+}
+
+// attached starts instances attached to each other by transport, each
+// recording order.placed
+func attached(t *testing.T, transport mediator.Transport) ([]*mediator.Mediator, []*Recorder) {
+	instances := make([]*mediator.Mediator, 2)
+	recorders := []*Recorder{NewRecorder(), NewRecorder()}
+	for i := range instances {
+		m := mediator.NewMediator(mediator.WithTransport(transport))
+		t.Cleanup(func() { m.Close() })
+		m.Subscribe("order.placed", recorders[i].Handle)
+		instances[i] = m
+	}
+	return instances, recorders
+}
+
+func TestCheckOnce(t *testing.T) {
+	transport := mediator.NewMemoryTransport()
+	defer transport.Close()
+	instances, recorders := attached(t, transport)
+
+	event := mediator.Event{Name: "order.placed", ID: "evt-1"}
+	if err := instances[0].Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	CheckOnce(t, 5*time.Second, event, instances, recorders)
+
+	// Test the sentinels are left out of the recorded events
+	for i, r := range recorders {
+		if events := r.Events(); len(events) != 1 {
+			t.Errorf("recorder %d Events() = %v, want evt-1 only", i, events)
+		}
+	}
+}
+
+func TestSettle_Duplicates(t *testing.T) {
+	transport := mediator.NewMemoryTransport()
+	defer transport.Close()
+	instances, recorders := attached(t, doublingTransport{transport})
+
+	event := mediator.Event{Name: "order.placed", ID: "evt-1"}
+	if err := instances[0].Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// Test the second copy has arrived once the sentinel published after it has
+	Settle(t, 5*time.Second, instances[0].Publish, event, recorders...)
+	if events := recorders[1].Events(); len(events) != 2 {
+		t.Errorf("remote recorder handled %d events, want both copies of evt-1", len(events))
+	}
+}
+
+func TestRecorder_WaitFor(t *testing.T) {
+	r := NewRecorder()
+	if r.WaitFor("evt-1", time.Millisecond) {
+		t.Error("WaitFor() = true before the event")
+	}
+
+	go r.Handle(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"})
+	if !r.WaitFor("evt-1", 5*time.Second) {
+		t.Error("WaitFor() = false after the event")
+	}
+}
@@ -0,0 +1,306 @@
+package mediator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeadLetterPrefix is prepended to event names when dead letters are kept in an EventStore
+const DeadLetterPrefix = "dlq."
+
+// ErrDeadLetterNotFound is returned when a dead letter does not exist
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is an event whose handler kept failing, with the failure details
+type DeadLetter struct {
+	ID          string    `json:"id"`
+	Event       Event     `json:"event"`
+	HandlerName string    `json:"handler_name"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+// DeadLetterQueue stores events whose handlers exhausted their attempts
+type DeadLetterQueue interface {
+	// Add stores a dead letter
+	Add(ctx context.Context, letter DeadLetter) error
+
+	// List returns dead letters of an event name, oldest first; limit <= 0 returns all
+	List(ctx context.Context, eventName string, limit int) ([]DeadLetter, error)
+
+	// Get returns a single dead letter
+	Get(ctx context.Context, eventName, id string) (DeadLetter, error)
+
+	// Remove deletes a dead letter
+	Remove(ctx context.Context, eventName, id string) error
+}
+
+// WithDeadLetterQueue routes events whose handlers fail after all retries to dlq
+func WithDeadLetterQueue(dlq DeadLetterQueue) Option {
+	return func(m *Mediator) {
+		m.deadLetters = dlq
+	}
+}
+
+// deadLetter records a final handler failure in the dead-letter queue, if configured
+func (m *Mediator) deadLetter(ctx context.Context, event Event, inv invocation, attempts int, err error) {
+	if m.deadLetters == nil {
+		return
+	}
+
+	letter := DeadLetter{
+		ID:          newID(),
+		Event:       event,
+		HandlerName: inv.name,
+		Error:       err.Error(),
+		Attempts:    attempts,
+		FailedAt:    m.now(),
+	}
+	// Use a fresh context so a cancelled publish still records its failure
+	if addErr := m.deadLetters.Add(context.WithoutCancel(ctx), letter); addErr != nil {
+		m.logf("failed to dead-letter event %s for handler %s: %v", event.Name, inv.name, addErr)
+	}
+}
+
+// DeadLetters lists dead-lettered events of an event name
+func (m *Mediator) DeadLetters(ctx context.Context, eventName string, limit int) ([]DeadLetter, error) {
+	if m.deadLetters == nil {
+		return nil, fmt.Errorf("no dead-letter queue configured")
+	}
+	return m.deadLetters.List(ctx, eventName, limit)
+}
+
+// Redrive re-dispatches a dead-lettered event to the handler that failed it and
+// removes it from the queue when the handler succeeds
+func (m *Mediator) Redrive(ctx context.Context, eventName, id string) error {
+	if m.deadLetters == nil {
+		return fmt.Errorf("no dead-letter queue configured")
+	}
+
+	letter, err := m.deadLetters.Get(ctx, eventName, id)
+	if err != nil {
+		return err
+	}
+
+	subs, _ := m.subscribers.get(eventName)
+	m.mu.RLock()
+	var handler EventHandler
+	for _, sub := range subs {
+		if sub.name == letter.HandlerName {
+			handler = chain(m.middlewares, sub.handler)
+			break
+		}
+	}
+	m.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Errorf("handler %s is no longer subscribed to %s", letter.HandlerName, eventName)
+	}
+
+	if err := handler(ctx, letter.Event); err != nil {
+		return fmt.Errorf("redrive of %s failed: %w", id, err)
+	}
+	return m.deadLetters.Remove(ctx, eventName, id)
+}
+
+// RedriveAll re-drives every dead letter of an event name and returns the
+// errors of those that failed again
+func (m *Mediator) RedriveAll(ctx context.Context, eventName string) error {
+	letters, err := m.DeadLetters(ctx, eventName, 0)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, letter := range letters {
+		if err := m.Redrive(ctx, eventName, letter.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MemoryDeadLetterQueue is an in-memory DeadLetterQueue
+type MemoryDeadLetterQueue struct {
+	mu      sync.RWMutex
+	letters map[string][]DeadLetter
+	count   int
+	bytes   int64
+}
+
+// NewMemoryDeadLetterQueue creates an empty in-memory dead-letter queue
+func NewMemoryDeadLetterQueue() *MemoryDeadLetterQueue {
+	return &MemoryDeadLetterQueue{
+		letters: make(map[string][]DeadLetter),
+	}
+}
+
+// Add stores a dead letter
+func (q *MemoryDeadLetterQueue) Add(ctx context.Context, letter DeadLetter) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.letters[letter.Event.Name] = append(q.letters[letter.Event.Name], letter)
+	q.count++
+	q.bytes += estimateLetterSize(letter)
+	return nil
+}
+
+// Usage returns the number of dead letters and their estimated size in bytes
+func (q *MemoryDeadLetterQueue) Usage() (letters int, bytes int64) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.count, q.bytes
+}
+
+// List returns dead letters of an event name, oldest first
+func (q *MemoryDeadLetterQueue) List(ctx context.Context, eventName string, limit int) ([]DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	letters := q.letters[eventName]
+	if limit > 0 && len(letters) > limit {
+		letters = letters[:limit]
+	}
+	return append([]DeadLetter(nil), letters...), nil
+}
+
+// Get returns a single dead letter
+func (q *MemoryDeadLetterQueue) Get(ctx context.Context, eventName, id string) (DeadLetter, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for _, letter := range q.letters[eventName] {
+		if letter.ID == id {
+			return letter, nil
+		}
+	}
+	return DeadLetter{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+}
+
+// Remove deletes a dead letter
+func (q *MemoryDeadLetterQueue) Remove(ctx context.Context, eventName, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters := q.letters[eventName]
+	for i, letter := range letters {
+		if letter.ID == id {
+			q.letters[eventName] = append(letters[:i:i], letters[i+1:]...)
+			q.count--
+			q.bytes -= estimateLetterSize(letter)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+}
+
+// StoreDeadLetterQueue keeps dead letters in an EventStore under DeadLetterPrefix
+type StoreDeadLetterQueue struct {
+	store EventStore
+	mu    sync.Mutex
+}
+
+// NewStoreDeadLetterQueue creates a dead-letter queue backed by store
+func NewStoreDeadLetterQueue(store EventStore) *StoreDeadLetterQueue {
+	return &StoreDeadLetterQueue{store: store}
+}
+
+// Add stores a dead letter as a "dlq.<event name>" event
+func (q *StoreDeadLetterQueue) Add(ctx context.Context, letter DeadLetter) error {
+	return q.store.StoreEvent(ctx, Event{
+		Name:    DeadLetterPrefix + letter.Event.Name,
+		Payload: letter,
+	})
+}
+
+// List returns dead letters of an event name, oldest first
+func (q *StoreDeadLetterQueue) List(ctx context.Context, eventName string, limit int) ([]DeadLetter, error) {
+	letters, err := q.load(ctx, eventName)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(letters) > limit {
+		letters = letters[:limit]
+	}
+	return letters, nil
+}
+
+// Get returns a single dead letter
+func (q *StoreDeadLetterQueue) Get(ctx context.Context, eventName, id string) (DeadLetter, error) {
+	letters, err := q.load(ctx, eventName)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	for _, letter := range letters {
+		if letter.ID == id {
+			return letter, nil
+		}
+	}
+	return DeadLetter{}, fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+}
+
+// Remove deletes a dead letter. EventStore can only clear whole streams, so the
+// remaining dead letters are written back after clearing.
+func (q *StoreDeadLetterQueue) Remove(ctx context.Context, eventName, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	letters, err := q.load(ctx, eventName)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	remaining := letters[:0]
+	for _, letter := range letters {
+		if letter.ID == id {
+			found = true
+			continue
+		}
+		remaining = append(remaining, letter)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrDeadLetterNotFound, id)
+	}
+
+	if err := q.store.ClearEvents(ctx, DeadLetterPrefix+eventName); err != nil {
+		return fmt.Errorf("failed to clear dead letters: %w", err)
+	}
+	for _, letter := range remaining {
+		if err := q.Add(ctx, letter); err != nil {
+			return fmt.Errorf("failed to restore dead letter %s: %w", letter.ID, err)
+		}
+	}
+	return nil
+}
+
+// load reads and decodes every dead letter of an event name, oldest first
+func (q *StoreDeadLetterQueue) load(ctx context.Context, eventName string) ([]DeadLetter, error) {
+	records, err := q.store.GetEvents(ctx, DeadLetterPrefix+eventName, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letters: %w", err)
+	}
+
+	letters := make([]DeadLetter, 0, len(records))
+	for _, record := range records {
+		data, err := json.Marshal(record["payload"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal dead letter: %w", err)
+		}
+		var letter DeadLetter
+		if err := json.Unmarshal(data, &letter); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead letter: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+
+	// Stores differ in the order they return events
+	sort.SliceStable(letters, func(i, j int) bool { return letters[i].FailedAt.Before(letters[j].FailedAt) })
+	return letters, nil
+}
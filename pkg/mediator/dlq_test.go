@@ -0,0 +1,80 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDeadLetterQueue(t *testing.T) {
+	queues := map[string]func() DeadLetterQueue{
+		"memory": func() DeadLetterQueue { return NewMemoryDeadLetterQueue() },
+		"store":  func() DeadLetterQueue { return NewStoreDeadLetterQueue(&mockEventStore{}) },
+	}
+
+	for name, newQueue := range queues {
+		t.Run(name, func(t *testing.T) {
+			dlq := newQueue()
+			m := NewMediator(WithDeadLetterQueue(dlq))
+
+			healthy := false
+			m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+				if !healthy {
+					return errors.New("inventory service down")
+				}
+				return nil
+			}, WithHandlerName("reserveStock"))
+			m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				if err := m.Publish(ctx, Event{Name: "order.placed", Payload: i}); err == nil {
+					t.Fatal("Publish() expected error")
+				}
+			}
+
+			// Test listing and inspecting dead letters
+			letters, err := m.DeadLetters(ctx, "order.placed", 0)
+			if err != nil {
+				t.Fatalf("DeadLetters() error = %v", err)
+			}
+			if len(letters) != 2 {
+				t.Fatalf("DeadLetters() returned %d letters, want 2", len(letters))
+			}
+			if letters[0].HandlerName != "reserveStock" || letters[0].Attempts != 1 || letters[0].Error != "inventory service down" {
+				t.Errorf("unexpected dead letter: %+v", letters[0])
+			}
+
+			letter, err := dlq.Get(ctx, "order.placed", letters[1].ID)
+			if err != nil || letter.ID != letters[1].ID {
+				t.Errorf("Get() = %+v, %v", letter, err)
+			}
+
+			// Test re-driving while the handler still fails
+			if err := m.Redrive(ctx, "order.placed", letters[0].ID); err == nil {
+				t.Error("Redrive() expected error while handler fails")
+			}
+
+			// Test re-driving after recovery
+			healthy = true
+			if err := m.RedriveAll(ctx, "order.placed"); err != nil {
+				t.Fatalf("RedriveAll() error = %v", err)
+			}
+			letters, _ = m.DeadLetters(ctx, "order.placed", 0)
+			if len(letters) != 0 {
+				t.Errorf("DeadLetters() after redrive returned %d letters, want 0", len(letters))
+			}
+
+			if _, err := dlq.Get(ctx, "order.placed", "missing"); !errors.Is(err, ErrDeadLetterNotFound) {
+				t.Errorf("Get() error = %v, want ErrDeadLetterNotFound", err)
+			}
+		})
+	}
+}
+
+func TestDeadLetters_NotConfigured(t *testing.T) {
+	m := NewMediator()
+	if _, err := m.DeadLetters(context.Background(), "order.placed", 0); err == nil {
+		t.Error("DeadLetters() expected error without queue")
+	}
+}
@@ -0,0 +1,57 @@
+package mediator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HandlerError records the failure of a single handler during Publish
+type HandlerError struct {
+	EventName    string
+	HandlerIndex int
+	HandlerName  string
+	Attempts     int
+	Err          error
+}
+
+// Error implements the error interface
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("handler %d (%s) for event %s: %v", e.HandlerIndex, e.HandlerName, e.EventName, e.Err)
+}
+
+// Unwrap returns the underlying handler error
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// PublishError aggregates every failure of a Publish call. Errors holds a
+// *HandlerError per failed handler, plus the event store error if any.
+type PublishError struct {
+	EventName string
+	Errors    []error
+}
+
+// Error implements the error interface
+func (e *PublishError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("errors in event handlers: [%s]", strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual errors so errors.Is and errors.As inspect each of them
+func (e *PublishError) Unwrap() []error {
+	return e.Errors
+}
+
+// HandlerErrors returns the handler failures, excluding store errors
+func (e *PublishError) HandlerErrors() []*HandlerError {
+	var handlerErrs []*HandlerError
+	for _, err := range e.Errors {
+		if handlerErr, ok := err.(*HandlerError); ok {
+			handlerErrs = append(handlerErrs, handlerErr)
+		}
+	}
+	return handlerErrs
+}
@@ -0,0 +1,60 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errOutOfStock = errors.New("out of stock")
+
+func reserveStock(ctx context.Context, event Event) error {
+	return errOutOfStock
+}
+
+func TestPublishError(t *testing.T) {
+	store := &mockEventStore{storeErr: errors.New("connection refused")}
+	m := NewMediator(WithEventStore(store))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+	m.Subscribe("order.placed", reserveStock)
+
+	err := m.Publish(context.Background(), Event{Name: "order.placed"})
+
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) {
+		t.Fatalf("Publish() error = %T, want *PublishError", err)
+	}
+	if publishErr.EventName != "order.placed" {
+		t.Errorf("EventName = %s, want order.placed", publishErr.EventName)
+	}
+	if len(publishErr.Errors) != 2 {
+		t.Fatalf("Errors has %d entries, want handler and store errors", len(publishErr.Errors))
+	}
+
+	// Test inspecting the failed handler
+	handlerErrs := publishErr.HandlerErrors()
+	if len(handlerErrs) != 1 {
+		t.Fatalf("HandlerErrors() returned %d errors, want 1", len(handlerErrs))
+	}
+	if handlerErrs[0].HandlerIndex != 1 {
+		t.Errorf("HandlerIndex = %d, want 1", handlerErrs[0].HandlerIndex)
+	}
+	if !strings.HasSuffix(handlerErrs[0].HandlerName, "reserveStock") {
+		t.Errorf("HandlerName = %s, want reserveStock", handlerErrs[0].HandlerName)
+	}
+
+	// Test errors.Is through both layers
+	if !errors.Is(err, errOutOfStock) {
+		t.Error("errors.Is() did not find handler error")
+	}
+
+	var handlerErr *HandlerError
+	if !errors.As(err, &handlerErr) || handlerErr.Err != errOutOfStock {
+		t.Errorf("errors.As() handler error = %v", handlerErr)
+	}
+
+	if !strings.HasPrefix(err.Error(), "errors in event handlers") {
+		t.Errorf("Error() = %s", err.Error())
+	}
+}
@@ -1,6 +1,11 @@
 package mediator
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // EventStore defines the interface for event storage
 type EventStore interface {
@@ -13,3 +18,179 @@ type EventStore interface {
 	// ClearEvents removes all events for a given event name
 	ClearEvents(ctx context.Context, eventName string) error
 }
+
+// EventStoreV2 is an event store that reads events back as typed records
+type EventStoreV2 interface {
+	// StoreEvent stores an event
+	StoreEvent(ctx context.Context, event Event) error
+
+	// ReadEvents retrieves events by event name
+	ReadEvents(ctx context.Context, eventName string, limit int64) ([]StoredEvent, error)
+
+	// ClearEvents removes all events for a given event name
+	ClearEvents(ctx context.Context, eventName string) error
+}
+
+// StoredEvent is an event read back from an event store
+type StoredEvent struct {
+	ID        string
+	Name      string
+	Namespace string
+	// Payload is the decoded payload
+	Payload interface{}
+	// RawPayload is the payload as stored, encoded as ContentType
+	RawPayload []byte
+	// ContentType is the encoding of RawPayload, e.g. "application/json"
+	ContentType   string
+	Timestamp     time.Time
+	CorrelationID string
+	CausationID   string
+	Metadata      map[string]string
+	// Offset is a cursor resuming after this event, set by stores that page
+	Offset string
+	// StreamID and Version locate events appended with AppendToStream
+	StreamID string
+	Version  int64
+}
+
+// Event returns the stored event as an Event
+func (e StoredEvent) Event() Event {
+	return Event{
+		Name:          e.Name,
+		Payload:       e.Payload,
+		ID:            e.ID,
+		Timestamp:     e.Timestamp,
+		CorrelationID: e.CorrelationID,
+		CausationID:   e.CausationID,
+		Metadata:      e.Metadata,
+		Namespace:     e.Namespace,
+	}
+}
+
+// record returns the stored event in the form returned by EventStore.GetEvents
+func (e StoredEvent) record() map[string]interface{} {
+	record := map[string]interface{}{
+		"id":             e.ID,
+		"name":           e.Name,
+		"payload":        e.Payload,
+		"timestamp":      e.Timestamp,
+		"correlation_id": e.CorrelationID,
+		"causation_id":   e.CausationID,
+		"metadata":       e.Metadata,
+	}
+	if e.Namespace != "" {
+		record["namespace"] = e.Namespace
+	}
+	return record
+}
+
+// storedEventFromRecord converts a record returned by EventStore.GetEvents
+// into a StoredEvent whose raw payload is the payload's JSON
+func storedEventFromRecord(record map[string]interface{}) (StoredEvent, error) {
+	event := eventFromRecord(record)
+	raw, err := json.Marshal(event.Payload)
+	if err != nil {
+		return StoredEvent{}, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	return StoredEvent{
+		ID:            event.ID,
+		Name:          event.Name,
+		Namespace:     event.Namespace,
+		Payload:       event.Payload,
+		RawPayload:    raw,
+		ContentType:   jsonContentType,
+		Timestamp:     event.Timestamp,
+		CorrelationID: event.CorrelationID,
+		CausationID:   event.CausationID,
+		Metadata:      event.Metadata,
+	}, nil
+}
+
+// AsEventStoreV2 adapts store to EventStoreV2. Stores that already implement
+// it are returned as is.
+func AsEventStoreV2(store EventStore) EventStoreV2 {
+	if v2, ok := store.(EventStoreV2); ok {
+		return v2
+	}
+	return eventStoreV2Adapter{store}
+}
+
+// AsEventStore adapts a v2 store to EventStore, e.g. to pass it to WithEventStore.
+// Stores that already implement it are returned as is.
+func AsEventStore(store EventStoreV2) EventStore {
+	if v1, ok := store.(EventStore); ok {
+		return v1
+	}
+	return eventStoreAdapter{store}
+}
+
+// eventStoreV2Adapter reads typed records from an EventStore
+type eventStoreV2Adapter struct {
+	EventStore
+}
+
+// ReadEvents converts the records returned by GetEvents
+func (a eventStoreV2Adapter) ReadEvents(ctx context.Context, eventName string, limit int64) ([]StoredEvent, error) {
+	records, err := a.GetEvents(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]StoredEvent, len(records))
+	for i, record := range records {
+		if events[i], err = storedEventFromRecord(record); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// eventStoreAdapter reads generic records from an EventStoreV2
+type eventStoreAdapter struct {
+	EventStoreV2
+}
+
+// GetEvents converts the records returned by ReadEvents
+func (a eventStoreAdapter) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	events, err := a.ReadEvents(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]interface{}, len(events))
+	for i, event := range events {
+		records[i] = event.record()
+	}
+	return records, nil
+}
+
+// eventFromRecord converts a record returned by EventStore.GetEvents into an Event
+func eventFromRecord(record map[string]interface{}) Event {
+	event := Event{Payload: record["payload"]}
+	event.Name, _ = record["name"].(string)
+	event.ID, _ = record["id"].(string)
+	event.CorrelationID, _ = record["correlation_id"].(string)
+	event.CausationID, _ = record["causation_id"].(string)
+	event.Namespace, _ = record["namespace"].(string)
+
+	switch ts := record["timestamp"].(type) {
+	case time.Time:
+		event.Timestamp = ts
+	case string:
+		event.Timestamp, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+
+	switch metadata := record["metadata"].(type) {
+	case map[string]string:
+		event.Metadata = metadata
+	case map[string]interface{}:
+		event.Metadata = make(map[string]string, len(metadata))
+		for key, value := range metadata {
+			if s, ok := value.(string); ok {
+				event.Metadata[key] = s
+			}
+		}
+	}
+	return event
+}
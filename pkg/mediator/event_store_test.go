@@ -0,0 +1,82 @@
+package mediator
+
+import (
+	"context"
+	"testing"
+)
+
+// v2EventStore is an EventStoreV2 that does not implement EventStore
+type v2EventStore struct {
+	events []Event
+}
+
+func (s *v2EventStore) StoreEvent(ctx context.Context, event Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *v2EventStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]StoredEvent, error) {
+	var events []StoredEvent
+	for _, event := range s.events {
+		if event.Name == eventName {
+			events = append(events, StoredEvent{ID: event.ID, Name: event.Name, Payload: event.Payload, Timestamp: event.Timestamp, CorrelationID: event.CorrelationID, Metadata: event.Metadata})
+		}
+	}
+	return events, nil
+}
+
+func (s *v2EventStore) ClearEvents(ctx context.Context, eventName string) error {
+	s.events = nil
+	return nil
+}
+
+func TestAsEventStoreV2(t *testing.T) {
+	store := &mockEventStore{}
+	ctx := context.Background()
+	if err := store.StoreEvent(ctx, Event{Name: "order.placed", Payload: map[string]interface{}{"id": "o-1"}}); err != nil {
+		t.Fatalf("StoreEvent() error = %v", err)
+	}
+
+	events, err := AsEventStoreV2(store).ReadEvents(ctx, "order.placed", 0)
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Name != "order.placed" {
+		t.Fatalf("ReadEvents() = %+v, want 1 order.placed event", events)
+	}
+	if string(events[0].RawPayload) != `{"id":"o-1"}` || events[0].ContentType != "application/json" {
+		t.Errorf("RawPayload = %s (%s), want the payload's JSON", events[0].RawPayload, events[0].ContentType)
+	}
+
+	// Test stores implementing both interfaces are returned as is
+	adapted := AsEventStore(&v2EventStore{})
+	if AsEventStoreV2(adapted) != adapted.(EventStoreV2) {
+		t.Error("AsEventStoreV2() wrapped a store that already implements EventStoreV2")
+	}
+}
+
+func TestAsEventStore(t *testing.T) {
+	m := NewMediator(WithEventStore(AsEventStore(&v2EventStore{})))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := context.Background()
+	if err := m.Publish(ctx, Event{Name: "order.placed", ID: "evt-1", Payload: "o-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	records, err := m.GetEvents(ctx, "order.placed", 0)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(records) != 1 || records[0]["id"] != "evt-1" || records[0]["payload"] != "o-1" {
+		t.Errorf("GetEvents() = %v, want the published event", records)
+	}
+
+	events, err := m.ReadEvents(ctx, "order.placed", 0)
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Event().ID != "evt-1" {
+		t.Errorf("ReadEvents() = %+v, want the published event", events)
+	}
+}
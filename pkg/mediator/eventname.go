@@ -0,0 +1,144 @@
+package mediator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownEvent is returned in strict mode for event names that were never
+// registered with RegisterEvent
+var ErrUnknownEvent = errors.New("unknown event")
+
+// EventName names an event. Declaring event names as constants of it, e.g.
+//
+//	const ProductUpdated mediator.EventName = "product.updated"
+//
+// turns a misspelt name into a compile error instead of an event no handler
+// receives.
+type EventName string
+
+// String returns the event name
+func (n EventName) String() string {
+	return string(n)
+}
+
+// Event returns an event of this name carrying payload
+func (n EventName) Event(payload interface{}) Event {
+	return Event{Name: string(n), Payload: payload}
+}
+
+// RegisterEvent declares an event name and binds it to the payload type T,
+// like SubscribeTyped, so stored payloads are rehydrated into T and
+// PublishTyped rejects other types. Register events without a payload type
+// with T of interface{}. Registering a name again with another type fails.
+func RegisterEvent[T any](m *Mediator, name EventName) error {
+	if name == "" {
+		return errors.New("failed to register event: empty event name")
+	}
+	typ := typeOf[T]()
+	if err := m.bindPayloadType(string(name), typ); err != nil {
+		return fmt.Errorf("failed to register event: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.events == nil {
+		m.events = make(map[string]bool)
+	}
+	m.events[string(name)] = true
+	return nil
+}
+
+// RegisteredEvents returns the names registered with RegisterEvent, sorted
+func (m *Mediator) RegisteredEvents() []EventName {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]EventName, 0, len(m.events))
+	for name := range m.events {
+		names = append(names, EventName(name))
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// WithStrictEvents makes Publish, PublishWith, PublishAsync and PublishBatch
+// fail fast with ErrUnknownEvent for event names not registered with
+// RegisterEvent, and reject payloads not of the registered type. Subscribing
+// to an unknown name fails too: TrySubscribe, SubscribeTyped, RegisterHandlers,
+// Module.Subscribe and Saga.Start return an error, and Subscribe and
+// MustSubscribe panic, so typos surface at startup.
+// With WithHierarchicalTopics the ancestors of registered names are known.
+func WithStrictEvents() Option {
+	return func(m *Mediator) {
+		m.strictEvents = true
+	}
+}
+
+// ValidateEventName reports, in strict mode, an event name that is neither
+// registered nor, with hierarchical topics, an ancestor of a registered one.
+// Check names from outside the program with it before passing them to Subscribe.
+func (m *Mediator) ValidateEventName(eventName string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.strictEvents || m.knownEvent(eventName) {
+		return nil
+	}
+	return fmt.Errorf("%w %s", ErrUnknownEvent, eventName)
+}
+
+// checkPublish reports, in strict mode, events of an unknown name or with a
+// payload not of the registered type
+func (m *Mediator) checkPublish(event Event) error {
+	if !m.publishSettings().strictEvents {
+		return nil
+	}
+
+	m.mu.RLock()
+	known := m.events[event.Name]
+	bound := m.payloadTypes[event.Name]
+	m.mu.RUnlock()
+	if !known {
+		return fmt.Errorf("cannot publish %w %s", ErrUnknownEvent, event.Name)
+	}
+	if !payloadAssignable(event.Payload, bound) {
+		return fmt.Errorf("%w %s: expects payload of type %s, got %T", ErrInvalidEvent, event.Name, bound, event.Payload)
+	}
+	return nil
+}
+
+// knownEvent reports whether eventName is registered or, with hierarchical
+// topics, an ancestor of a registered name. The caller holds m.mu.
+func (m *Mediator) knownEvent(eventName string) bool {
+	if m.events[eventName] {
+		return true
+	}
+	if !m.hierarchical {
+		return false
+	}
+	for name := range m.events {
+		if strings.HasPrefix(name, eventName+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadAssignable reports whether payload can be passed as typ; nil is
+// assignable to the types that can be nil
+func payloadAssignable(payload interface{}, typ reflect.Type) bool {
+	if typ == nil {
+		return true
+	}
+	if payload == nil {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return true
+		}
+		return false
+	}
+	return reflect.TypeOf(payload).AssignableTo(typ)
+}
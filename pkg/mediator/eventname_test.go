@@ -0,0 +1,177 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	testProductCreated EventName = "product.created"
+	testProductDeleted EventName = "product.deleted"
+)
+
+// strictMediator returns a strict mediator knowing the test product events
+func strictMediator(t *testing.T, opts ...Option) *Mediator {
+	t.Helper()
+	m := NewMediator(append([]Option{WithStrictEvents()}, opts...)...)
+	if err := RegisterEvent[*testProduct](m, testProductCreated); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+	if err := RegisterEvent[interface{}](m, testProductDeleted); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+	return m
+}
+
+func TestRegisterEvent(t *testing.T) {
+	m := NewMediator()
+	if err := RegisterEvent[*testProduct](m, testProductCreated); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+
+	// Test registering the same type again is allowed, another type is not
+	if err := RegisterEvent[*testProduct](m, testProductCreated); err != nil {
+		t.Errorf("RegisterEvent() again error = %v", err)
+	}
+	if err := RegisterEvent[string](m, testProductCreated); err == nil {
+		t.Error("RegisterEvent() with another type error = nil")
+	}
+	if err := RegisterEvent[string](m, ""); err == nil {
+		t.Error("RegisterEvent() with an empty name error = nil")
+	}
+
+	// Test the registered type binds typed subscriptions
+	if _, err := SubscribeTyped(m, testProductCreated.String(), func(ctx context.Context, p string) error { return nil }); err == nil {
+		t.Error("SubscribeTyped() with another type error = nil")
+	}
+
+	if got := m.RegisteredEvents(); len(got) != 1 || got[0] != testProductCreated {
+		t.Errorf("RegisteredEvents() = %v, want [%s]", got, testProductCreated)
+	}
+}
+
+func TestStrictEvents_Publish(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   Event
+		wantErr error
+	}{
+		{name: "registered", event: testProductCreated.Event(&testProduct{ID: "1"})},
+		{name: "nil payload of a pointer type", event: testProductCreated.Event(nil)},
+		{name: "any payload", event: testProductDeleted.Event("p-1")},
+		{name: "unknown name", event: Event{Name: "product.creatd"}, wantErr: ErrUnknownEvent},
+		{name: "wrong payload type", event: testProductCreated.Event(testProduct{ID: "1"}), wantErr: ErrInvalidEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := strictMediator(t)
+			defer m.Close()
+			var handled atomic.Int32
+			for _, name := range []EventName{testProductCreated, testProductDeleted} {
+				m.Subscribe(name.String(), func(ctx context.Context, event Event) error {
+					handled.Add(1)
+					return nil
+				})
+			}
+
+			publishes := map[string]func() error{
+				"Publish": func() error { return m.Publish(context.Background(), tt.event) },
+				"PublishAsync": func() error {
+					return m.PublishAsync(context.Background(), tt.event)
+				},
+				"PublishBatch": func() error {
+					_, err := m.PublishBatch(context.Background(), []Event{testProductDeleted.Event(nil), tt.event})
+					return err
+				},
+			}
+			for name, publish := range publishes {
+				err := publish()
+				if tt.wantErr == nil && err != nil {
+					t.Errorf("%s() error = %v", name, err)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s() error = %v, want %v", name, err, tt.wantErr)
+				}
+			}
+
+			m.Close()
+			if tt.wantErr != nil && handled.Load() != 0 {
+				t.Errorf("handled %d events, want none dispatched", handled.Load())
+			}
+		})
+	}
+}
+
+func TestStrictEvents_Subscribe(t *testing.T) {
+	m := strictMediator(t)
+
+	if _, err := SubscribeTyped(m, "product.creatd", func(ctx context.Context, p *testProduct) error { return nil }); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("SubscribeTyped() error = %v, want ErrUnknownEvent", err)
+	}
+	if _, err := RegisterHandlers(m, &catalogHandlers{}); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("RegisterHandlers() error = %v, want ErrUnknownEvent", err)
+	}
+	if _, err := m.Await(context.Background(), "product.creatd", nil); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Await() error = %v, want ErrUnknownEvent", err)
+	}
+	if _, err := m.TrySubscribe("product.creatd", func(ctx context.Context, event Event) error { return nil }); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("TrySubscribe() error = %v, want ErrUnknownEvent", err)
+	}
+	_, err := m.RegisterModule("catalog", func(mod *Module) error {
+		_, err := mod.Subscribe("product.creatd", func(ctx context.Context, event Event) error { return nil })
+		return err
+	})
+	if !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("RegisterModule() error = %v, want ErrUnknownEvent from Module.Subscribe", err)
+	}
+
+	for _, subscribe := range []func(string, EventHandler, ...SubscribeOption) *Subscription{m.Subscribe, m.MustSubscribe} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), "product.creatd") {
+					t.Errorf("Subscribe() panic = %v, want the unknown event name", r)
+				}
+			}()
+			subscribe("product.creatd", func(ctx context.Context, event Event) error { return nil })
+		}()
+	}
+	if len(m.Subscriptions()) != 0 {
+		t.Errorf("Subscriptions() = %d, want none", len(m.Subscriptions()))
+	}
+}
+
+func TestStrictEvents_HierarchicalTopics(t *testing.T) {
+	m := strictMediator(t, WithHierarchicalTopics())
+
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "product.created"},
+		{name: "product"},
+		{name: "prod", wantErr: true},
+		{name: "product.created.v2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.ValidateEventName(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEventName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateEventName_NotStrict(t *testing.T) {
+	m := NewMediator()
+	if err := m.ValidateEventName("anything"); err != nil {
+		t.Errorf("ValidateEventName() error = %v, want nil without WithStrictEvents", err)
+	}
+	if err := m.Publish(context.Background(), Event{Name: "anything", Payload: 1}); err != nil && errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("Publish() error = %v", err)
+	}
+}
@@ -0,0 +1,507 @@
+// Package admin serves a token-protected HTTP API to inspect and operate a
+// running mediator: subscriptions, stored events, dead letters, replays and
+// metrics, with an optional embedded web UI to browse events.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// ErrNoToken is returned by NewHandler when the configuration has no token
+var ErrNoToken = errors.New("admin API needs a token")
+
+// Config configures a Handler
+type Config struct {
+	// Token authorizes requests, sent as "Authorization: Bearer <token>"
+	Token string
+	// EventLimit is how many events and dead letters are listed when a
+	// request sets no limit
+	EventLimit int
+	// MaxEventLimit bounds the limit of a request
+	MaxEventLimit int
+	// UI serves the event browser under /ui/. Its static files are served
+	// without a token; the page asks for one to call the API.
+	UI bool
+	// StreamBuffer is how many events a live stream buffers; clients falling
+	// further behind are disconnected rather than holding up publishes
+	StreamBuffer int
+	// HeartbeatInterval is how often idle live streams send a comment, keeping
+	// proxies from closing them; 0 disables heartbeats
+	HeartbeatInterval time.Duration
+}
+
+// DefaultConfig returns default handler configuration; set Token before use
+func DefaultConfig() Config {
+	return Config{
+		EventLimit:        50,
+		MaxEventLimit:     1000,
+		StreamBuffer:      256,
+		HeartbeatInterval: 15 * time.Second,
+	}
+}
+
+// Metrics are the counters served by GET /metrics
+type Metrics struct {
+	QueueDepth    int                         `json:"queue_depth"`
+	Subscriptions int                         `json:"subscriptions"`
+	Published     map[string]int64            `json:"published"`
+	PublishErrors map[string]int64            `json:"publish_errors"`
+	HandlerErrors map[string]map[string]int64 `json:"handler_errors"`
+}
+
+// Handler is an http.Handler serving the admin API. Mount it under a prefix
+// with http.StripPrefix:
+//
+//	GET  /subscriptions                            handlers per event name
+//	POST /subscriptions/pause?event=&handler=      pause a handler
+//	POST /subscriptions/resume?event=&handler=     resume a handler
+//	GET  /modules                                  registered modules
+//	POST /modules/pause?module=                    pause a module's handlers
+//	POST /modules/resume?module=                   resume a module's handlers
+//	POST /modules/unregister?module=               unsubscribe a module's handlers
+//	GET  /streams                                  event names with stored events
+//	GET  /events?event=&since=&until=&correlation_id=&limit=
+//	                                               stored events
+//	GET  /events/live?event=&correlation_id=       published events as Server-Sent Events
+//	GET  /deadletters?event=&limit=                dead letters
+//	POST /deadletters/redrive?event=&id=           redrive one, or all without id
+//	POST /replay?event=&handler=&limit=            replay stored events
+//	GET  /metrics                                  counters in JSON
+//	GET  /ui/                                      event browser, when Config.UI is set
+type Handler struct {
+	mediator *mediator.Mediator
+	config   Config
+	mux      *http.ServeMux
+	ui       http.Handler
+
+	mu            sync.Mutex
+	published     map[string]int64
+	publishErrors map[string]int64
+	handlerErrors map[string]map[string]int64
+	watchers      map[*watcher]bool
+}
+
+// NewHandler creates an admin API for m. It counts publishes and handler
+// errors from its creation for /metrics.
+func NewHandler(m *mediator.Mediator, config Config) (*Handler, error) {
+	if config.Token == "" {
+		return nil, ErrNoToken
+	}
+	if config.EventLimit <= 0 {
+		config.EventLimit = DefaultConfig().EventLimit
+	}
+	if config.MaxEventLimit < config.EventLimit {
+		config.MaxEventLimit = config.EventLimit
+	}
+	if config.StreamBuffer < 1 {
+		config.StreamBuffer = 1
+	}
+
+	h := &Handler{
+		mediator:      m,
+		config:        config,
+		mux:           http.NewServeMux(),
+		published:     make(map[string]int64),
+		publishErrors: make(map[string]int64),
+		handlerErrors: make(map[string]map[string]int64),
+		watchers:      make(map[*watcher]bool),
+	}
+	m.OnAfterPublish(h.countPublish)
+	m.OnAfterPublish(h.broadcast)
+	m.OnHandlerError(h.countHandlerError)
+	if config.UI {
+		h.ui = uiHandler()
+	}
+
+	h.route("/subscriptions", http.MethodGet, h.subscriptions)
+	h.route("/subscriptions/pause", http.MethodPost, h.pause)
+	h.route("/subscriptions/resume", http.MethodPost, h.resume)
+	h.route("/modules", http.MethodGet, h.modules)
+	h.route("/modules/pause", http.MethodPost, h.pauseModule)
+	h.route("/modules/resume", http.MethodPost, h.resumeModule)
+	h.route("/modules/unregister", http.MethodPost, h.unregisterModule)
+	h.route("/streams", http.MethodGet, h.streams)
+	h.route("/events", http.MethodGet, h.events)
+	h.mux.HandleFunc("/events/live", h.live)
+	h.route("/deadletters", http.MethodGet, h.deadLetters)
+	h.route("/deadletters/redrive", http.MethodPost, h.redrive)
+	h.route("/replay", http.MethodPost, h.replay)
+	h.route("/metrics", http.MethodGet, h.metrics)
+	return h, nil
+}
+
+// ServeHTTP authorizes a request and serves it
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.ui != nil && (r.URL.Path == "/ui" || strings.HasPrefix(r.URL.Path, "/ui/")) {
+		h.ui.ServeHTTP(w, r)
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.Token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mediator"`)
+		writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// route registers an endpoint answering one method
+func (h *Handler) route(path, method string, serve func(r *http.Request) (interface{}, error)) {
+	h.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		body, err := serve(r)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, body)
+	})
+}
+
+// subscriptions lists the handlers per event name
+func (h *Handler) subscriptions(r *http.Request) (interface{}, error) {
+	return h.mediator.Subscriptions(), nil
+}
+
+// pause pauses a handler
+func (h *Handler) pause(r *http.Request) (interface{}, error) {
+	sub, err := h.subscription(r)
+	if err != nil {
+		return nil, err
+	}
+	sub.Pause()
+	return map[string]bool{"paused": true}, nil
+}
+
+// resume resumes a handler
+func (h *Handler) resume(r *http.Request) (interface{}, error) {
+	sub, err := h.subscription(r)
+	if err != nil {
+		return nil, err
+	}
+	sub.Resume()
+	return map[string]bool{"paused": false}, nil
+}
+
+// modules lists the registered modules
+func (h *Handler) modules(r *http.Request) (interface{}, error) {
+	return h.mediator.Modules(), nil
+}
+
+// pauseModule pauses the handlers of a module
+func (h *Handler) pauseModule(r *http.Request) (interface{}, error) {
+	mod, err := h.module(r)
+	if err != nil {
+		return nil, err
+	}
+	mod.Pause()
+	return map[string]bool{"paused": true}, nil
+}
+
+// resumeModule resumes the handlers of a module
+func (h *Handler) resumeModule(r *http.Request) (interface{}, error) {
+	mod, err := h.module(r)
+	if err != nil {
+		return nil, err
+	}
+	mod.Resume()
+	return map[string]bool{"paused": false}, nil
+}
+
+// unregisterModule unsubscribes the handlers of a module
+func (h *Handler) unregisterModule(r *http.Request) (interface{}, error) {
+	mod, err := h.module(r)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]bool{"unregistered": mod.Unregister()}, nil
+}
+
+// streams lists the event names with stored events
+func (h *Handler) streams(r *http.Request) (interface{}, error) {
+	streams, err := h.mediator.GetStreams(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	if streams == nil {
+		streams = []mediator.StreamInfo{}
+	}
+	return streams, nil
+}
+
+// events lists the stored events of an event name, narrowed by time and
+// correlation ID
+func (h *Handler) events(r *http.Request) (interface{}, error) {
+	name, err := required(r, "event")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := h.limit(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []mediator.QueryOption
+	query := r.URL.Query()
+	if since := query.Get("since"); since != "" {
+		t, err := parseTime("since", since)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mediator.WithQuerySince(t))
+	}
+	if until := query.Get("until"); until != "" {
+		t, err := parseTime("until", until)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mediator.WithQueryUntil(t))
+	}
+	if id := query.Get("correlation_id"); id != "" {
+		opts = append(opts, mediator.WithQueryCorrelationID(id))
+	}
+
+	stored, err := h.mediator.ReadEvents(r.Context(), name, int64(limit), opts...)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]mediator.Event, len(stored))
+	for i, event := range stored {
+		events[i] = event.Event()
+	}
+	return events, nil
+}
+
+// deadLetters lists the dead letters of an event name
+func (h *Handler) deadLetters(r *http.Request) (interface{}, error) {
+	name, err := required(r, "event")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := h.limit(r)
+	if err != nil {
+		return nil, err
+	}
+	letters, err := h.mediator.DeadLetters(r.Context(), name, limit)
+	if err != nil {
+		return nil, err
+	}
+	if letters == nil {
+		letters = []mediator.DeadLetter{}
+	}
+	return letters, nil
+}
+
+// redrive redrives a dead letter, or every dead letter of an event name
+func (h *Handler) redrive(r *http.Request) (interface{}, error) {
+	name, err := required(r, "event")
+	if err != nil {
+		return nil, err
+	}
+	if id := r.URL.Query().Get("id"); id != "" {
+		err = h.mediator.Redrive(r.Context(), name, id)
+	} else {
+		err = h.mediator.RedriveAll(r.Context(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]bool{"redriven": true}, nil
+}
+
+// replay replays stored events, to one handler when named
+func (h *Handler) replay(r *http.Request) (interface{}, error) {
+	name, err := required(r, "event")
+	if err != nil {
+		return nil, err
+	}
+	opts := []mediator.ReplayOption{mediator.WithReplayFlag()}
+	if r.URL.Query().Get("limit") != "" {
+		limit, err := h.limit(r)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mediator.WithReplayLimit(int64(limit)))
+	}
+	if r.URL.Query().Get("handler") != "" {
+		sub, err := h.subscription(r)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mediator.WithReplaySubscription(sub))
+	}
+
+	// The replay outlives a client that stops waiting for it
+	n, err := h.mediator.Replay(context.WithoutCancel(r.Context()), name, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("replayed %d events: %w", n, err)
+	}
+	return map[string]int{"replayed": n}, nil
+}
+
+// metrics returns the counters
+func (h *Handler) metrics(r *http.Request) (interface{}, error) {
+	metrics := Metrics{
+		QueueDepth:    h.mediator.QueueDepth(),
+		Published:     make(map[string]int64),
+		PublishErrors: make(map[string]int64),
+		HandlerErrors: make(map[string]map[string]int64),
+	}
+	for _, info := range h.mediator.Subscriptions() {
+		metrics.Subscriptions += info.HandlerCount
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, n := range h.published {
+		metrics.Published[name] = n
+	}
+	for name, n := range h.publishErrors {
+		metrics.PublishErrors[name] = n
+	}
+	for name, handlers := range h.handlerErrors {
+		metrics.HandlerErrors[name] = make(map[string]int64, len(handlers))
+		for handler, n := range handlers {
+			metrics.HandlerErrors[name][handler] = n
+		}
+	}
+	return metrics, nil
+}
+
+// countPublish counts a publish and its failure
+func (h *Handler) countPublish(ctx context.Context, event mediator.Event, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.published[event.Name]++
+	if err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+		h.publishErrors[event.Name]++
+	}
+}
+
+// countHandlerError counts the final failure of a handler
+func (h *Handler) countHandlerError(ctx context.Context, event mediator.Event, err *mediator.HandlerError) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.handlerErrors[event.Name] == nil {
+		h.handlerErrors[event.Name] = make(map[string]int64)
+	}
+	h.handlerErrors[event.Name][err.HandlerName]++
+}
+
+// subscription returns the subscription named by the event and handler
+// query parameters
+func (h *Handler) subscription(r *http.Request) (*mediator.Subscription, error) {
+	name, err := required(r, "event")
+	if err != nil {
+		return nil, err
+	}
+	handler, err := required(r, "handler")
+	if err != nil {
+		return nil, err
+	}
+	sub, ok := h.mediator.LookupSubscription(name, handler)
+	if !ok {
+		return nil, &requestError{status: http.StatusNotFound, msg: fmt.Sprintf("handler %s is not subscribed to %s", handler, name)}
+	}
+	return sub, nil
+}
+
+// module returns the module named by the module query parameter
+func (h *Handler) module(r *http.Request) (*mediator.Module, error) {
+	name, err := required(r, "module")
+	if err != nil {
+		return nil, err
+	}
+	mod, ok := h.mediator.LookupModule(name)
+	if !ok {
+		return nil, &requestError{status: http.StatusNotFound, msg: fmt.Sprintf("module %s is not registered", name)}
+	}
+	return mod, nil
+}
+
+// limit returns the limit query parameter, EventLimit when absent
+func (h *Handler) limit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return h.config.EventLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, &requestError{status: http.StatusBadRequest, msg: fmt.Sprintf("invalid limit %q", value)}
+	}
+	if limit > h.config.MaxEventLimit {
+		limit = h.config.MaxEventLimit
+	}
+	return limit, nil
+}
+
+// parseTime parses an RFC 3339 time query parameter
+func parseTime(name, value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, &requestError{status: http.StatusBadRequest, msg: fmt.Sprintf("invalid %s %q, want an RFC 3339 time", name, value)}
+	}
+	return t, nil
+}
+
+// required returns a query parameter that must be set
+func required(r *http.Request, name string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return "", &requestError{status: http.StatusBadRequest, msg: name + " is required"}
+	}
+	return value, nil
+}
+
+// requestError is an error answered with its own status
+type requestError struct {
+	status int
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+// statusOf returns the status answering an error
+func statusOf(err error) int {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		return reqErr.status
+	case errors.Is(err, mediator.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, mediator.ErrCatalogNotSupported):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
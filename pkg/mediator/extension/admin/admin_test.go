@@ -0,0 +1,312 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+const testToken = "secret"
+
+// setup serves the admin API of a mediator with a store and a dead-letter queue
+func setup(t *testing.T) (*mediator.Mediator, *httptest.Server) {
+	return setupConfig(t, DefaultConfig())
+}
+
+// setupConfig is setup with a handler configuration, given the test token
+func setupConfig(t *testing.T, config Config) (*mediator.Mediator, *httptest.Server) {
+	t.Helper()
+	m := mediator.NewMediator(
+		mediator.WithEventStore(mediatortest.NewEventStore()),
+		mediator.WithDeadLetterQueue(mediator.NewMemoryDeadLetterQueue()),
+	)
+	config.Token = testToken
+	h, err := NewHandler(m, config)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+	srv := httptest.NewServer(http.StripPrefix("/admin", h))
+	t.Cleanup(srv.Close)
+	return m, srv
+}
+
+// call sends an authorized request and decodes the response into v
+func call(t *testing.T, srv *httptest.Server, method, path string, v interface{}) int {
+	t.Helper()
+	req, _ := http.NewRequest(method, srv.URL+"/admin"+path, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if v != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("Failed to decode %s: %v", path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestNewHandler_RequiresToken(t *testing.T) {
+	if _, err := NewHandler(mediator.NewMediator(), DefaultConfig()); !errors.Is(err, ErrNoToken) {
+		t.Errorf("NewHandler() error = %v, want ErrNoToken", err)
+	}
+}
+
+func TestHandler_Authorization(t *testing.T) {
+	_, srv := setup(t)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"not bearer", testToken, http.StatusUnauthorized},
+		{"token", "Bearer " + testToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/admin/subscriptions", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_Subscriptions(t *testing.T) {
+	m, srv := setup(t)
+	var calls int32
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, mediator.WithHandlerName("reserve-stock"))
+
+	var infos []mediator.SubscriptionInfo
+	if code := call(t, srv, http.MethodGet, "/subscriptions", &infos); code != http.StatusOK || len(infos) != 1 {
+		t.Fatalf("GET /subscriptions = %d %+v", code, infos)
+	}
+
+	if code := call(t, srv, http.MethodPost, "/subscriptions/pause?event=order.placed&handler=reserve-stock", nil); code != http.StatusOK {
+		t.Fatalf("POST pause = %d", code)
+	}
+	m.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("Paused handler called %d times", n)
+	}
+	call(t, srv, http.MethodGet, "/subscriptions", &infos)
+	if !infos[0].Handlers[0].Paused {
+		t.Error("Expected the handler to be reported paused")
+	}
+
+	call(t, srv, http.MethodPost, "/subscriptions/resume?event=order.placed&handler=reserve-stock", nil)
+	m.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Resumed handler called %d times, want 1", n)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"unknown handler", http.MethodPost, "/subscriptions/pause?event=order.placed&handler=other", http.StatusNotFound},
+		{"missing handler", http.MethodPost, "/subscriptions/pause?event=order.placed", http.StatusBadRequest},
+		{"wrong method", http.MethodGet, "/subscriptions/pause?event=order.placed&handler=reserve-stock", http.StatusMethodNotAllowed},
+		{"unknown path", http.MethodGet, "/unknown", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := call(t, srv, tt.method, tt.path, nil); code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_Modules(t *testing.T) {
+	m, srv := setup(t)
+	var calls int32
+	_, err := m.RegisterModule("stock", func(mod *mediator.Module) error {
+		_, err := mod.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		}, mediator.WithHandlerName("reserve-stock"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("RegisterModule() error = %v", err)
+	}
+
+	var infos []mediator.ModuleInfo
+	if code := call(t, srv, http.MethodGet, "/modules", &infos); code != http.StatusOK || len(infos) != 1 || infos[0].Handlers != 1 {
+		t.Fatalf("GET /modules = %d %+v", code, infos)
+	}
+
+	if code := call(t, srv, http.MethodPost, "/modules/pause?module=stock", nil); code != http.StatusOK {
+		t.Fatalf("POST pause = %d", code)
+	}
+	m.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Errorf("Handler of a paused module called %d times", n)
+	}
+	call(t, srv, http.MethodGet, "/modules", &infos)
+	if !infos[0].Paused {
+		t.Error("Expected the module to be reported paused")
+	}
+
+	call(t, srv, http.MethodPost, "/modules/resume?module=stock", nil)
+	m.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Handler of a resumed module called %d times, want 1", n)
+	}
+
+	if code := call(t, srv, http.MethodPost, "/modules/unregister?module=stock", nil); code != http.StatusOK {
+		t.Fatalf("POST unregister = %d", code)
+	}
+	if _, ok := m.LookupSubscription("order.placed", "reserve-stock"); ok {
+		t.Error("Expected the module's handler to be unsubscribed")
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"unregistered module", http.MethodPost, "/modules/pause?module=stock", http.StatusNotFound},
+		{"missing module", http.MethodPost, "/modules/resume", http.StatusBadRequest},
+		{"wrong method", http.MethodGet, "/modules/unregister?module=stock", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := call(t, srv, tt.method, tt.path, nil); code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_EventsAndReplay(t *testing.T) {
+	m, srv := setup(t)
+	var calls int32
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, mediator.WithHandlerName("reserve-stock"))
+
+	for i := 0; i < 3; i++ {
+		m.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+	}
+
+	var events []mediator.Event
+	if code := call(t, srv, http.MethodGet, "/events?event=order.placed&limit=2", &events); code != http.StatusOK || len(events) != 2 {
+		t.Errorf("GET /events = %d with %d events, want 2", code, len(events))
+	}
+	if code := call(t, srv, http.MethodGet, "/events", nil); code != http.StatusBadRequest {
+		t.Errorf("GET /events without event = %d, want 400", code)
+	}
+
+	var replayed map[string]int
+	if code := call(t, srv, http.MethodPost, "/replay?event=order.placed&handler=reserve-stock", &replayed); code != http.StatusOK || replayed["replayed"] != 3 {
+		t.Fatalf("POST /replay = %d %v, want 3 replayed", code, replayed)
+	}
+	if n := atomic.LoadInt32(&calls); n != 6 {
+		t.Errorf("handler called %d times, want 6", n)
+	}
+}
+
+func TestHandler_EventSearch(t *testing.T) {
+	m, srv := setup(t)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, correlation := range []string{"c-1", "c-2", "c-1"} {
+		m.Publish(context.Background(), mediator.Event{Name: "order.placed", CorrelationID: correlation, Timestamp: start.Add(time.Duration(i) * time.Hour)})
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		want  int
+	}{
+		{"correlation ID", "&correlation_id=c-1", http.StatusOK, 2},
+		{"since", "&since=2024-01-01T01:00:00Z", http.StatusOK, 2},
+		{"since and until", "&since=2024-01-01T00:30:00Z&until=2024-01-01T01:30:00Z", http.StatusOK, 1},
+		{"invalid time", "&since=yesterday", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []mediator.Event
+			code := call(t, srv, http.MethodGet, "/events?event=order.placed"+tt.query, &events)
+			if code != tt.code || len(events) != tt.want {
+				t.Errorf("GET /events = %d with %d events, want %d with %d", code, len(events), tt.code, tt.want)
+			}
+		})
+	}
+
+	var streams []mediator.StreamInfo
+	if code := call(t, srv, http.MethodGet, "/streams", &streams); code != http.StatusOK || len(streams) != 1 || streams[0].Name != "order.placed" || streams[0].Count != 3 {
+		t.Errorf("GET /streams = %d %+v, want order.placed with 3 events", code, streams)
+	}
+}
+
+func TestHandler_DeadLettersAndMetrics(t *testing.T) {
+	m, srv := setup(t)
+	var failing atomic.Bool
+	failing.Store(true)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		if failing.Load() {
+			return errors.New("smtp down")
+		}
+		return nil
+	}, mediator.WithHandlerName("send-email"))
+
+	m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"})
+
+	var letters []mediator.DeadLetter
+	if code := call(t, srv, http.MethodGet, "/deadletters?event=order.placed", &letters); code != http.StatusOK || len(letters) != 1 {
+		t.Fatalf("GET /deadletters = %d %+v, want 1 letter", code, letters)
+	}
+
+	var metrics Metrics
+	call(t, srv, http.MethodGet, "/metrics", &metrics)
+	if metrics.Published["order.placed"] != 1 || metrics.PublishErrors["order.placed"] != 1 ||
+		metrics.HandlerErrors["order.placed"]["send-email"] != 1 || metrics.Subscriptions != 1 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+
+	if code := call(t, srv, http.MethodPost, "/deadletters/redrive?event=order.placed&id=missing", nil); code != http.StatusNotFound {
+		t.Errorf("POST redrive of a missing letter = %d, want 404", code)
+	}
+	failing.Store(false)
+	if code := call(t, srv, http.MethodPost, "/deadletters/redrive?event=order.placed&id="+letters[0].ID, nil); code != http.StatusOK {
+		t.Errorf("POST redrive = %d, want 200", code)
+	}
+	call(t, srv, http.MethodGet, "/deadletters?event=order.placed", &letters)
+	if len(letters) != 0 {
+		t.Errorf("Expected the dead letter to be removed, got %+v", letters)
+	}
+}
@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// LiveEvent is an event sent by GET /events/live: the published event, with
+// the error of its publish if it failed
+type LiveEvent struct {
+	mediator.Event
+	Error string `json:"error,omitempty"`
+}
+
+// watcher receives the events published while a live stream is open
+type watcher struct {
+	events      chan LiveEvent
+	names       map[string]bool
+	correlation string
+	overflow    chan struct{}
+	once        sync.Once
+}
+
+// wants reports whether the stream asked for event
+func (w *watcher) wants(event mediator.Event) bool {
+	if len(w.names) > 0 && !w.names[event.Name] {
+		return false
+	}
+	return w.correlation == "" || event.CorrelationID == w.correlation
+}
+
+// broadcast sends a published event to the open live streams; streams that
+// fall StreamBuffer events behind are ended instead of blocking the publish
+func (h *Handler) broadcast(ctx context.Context, event mediator.Event, err error) {
+	msg := LiveEvent{Event: event}
+	if err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+		msg.Error = err.Error()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for w := range h.watchers {
+		if !w.wants(event) {
+			continue
+		}
+		select {
+		case w.events <- msg:
+		default:
+			w.once.Do(func() { close(w.overflow) })
+		}
+	}
+}
+
+// live streams published events as Server-Sent Events until the client
+// disconnects. The event query parameter, repeated or comma separated, and
+// correlation_id narrow the stream.
+func (h *Handler) live(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	watch := &watcher{
+		events:      make(chan LiveEvent, h.config.StreamBuffer),
+		names:       make(map[string]bool),
+		correlation: r.URL.Query().Get("correlation_id"),
+		overflow:    make(chan struct{}),
+	}
+	for _, value := range r.URL.Query()["event"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				watch.names[name] = true
+			}
+		}
+	}
+	h.mu.Lock()
+	h.watchers[watch] = true
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.watchers, watch)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var heartbeat <-chan time.Time
+	if h.config.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(h.config.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-watch.overflow:
+			fmt.Fprint(w, "event: overflow\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case msg := <-watch.events:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				data, _ = json.Marshal(LiveEvent{Event: mediator.Event{Name: msg.Name, ID: msg.ID}, Error: fmt.Sprintf("failed to encode event: %v", err)})
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", msg.ID, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// openLive opens a live stream and returns a function reading its next event
+func openLive(t *testing.T, url string) func() LiveEvent {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET %s = %d %s, want an event stream", url, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				lines <- data
+			}
+		}
+		close(lines)
+	}()
+
+	return func() LiveEvent {
+		t.Helper()
+		select {
+		case data, ok := <-lines:
+			if !ok {
+				t.Fatal("live stream ended")
+			}
+			var event LiveEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				t.Fatalf("failed to decode %q: %v", data, err)
+			}
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for a live event")
+			return LiveEvent{}
+		}
+	}
+}
+
+func TestHandler_Live(t *testing.T) {
+	m, srv := setup(t)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+	m.Subscribe("order.failed", func(ctx context.Context, event mediator.Event) error { return errors.New("boom") })
+
+	tests := []struct {
+		name   string
+		query  string
+		events []mediator.Event
+		want   string
+	}{
+		{"every event", "", []mediator.Event{{Name: "order.placed", ID: "1"}}, "1"},
+		{"by name", "?event=order.failed", []mediator.Event{{Name: "order.placed", ID: "1"}, {Name: "order.failed", ID: "2"}}, "2"},
+		{"by correlation ID", "?correlation_id=c-2", []mediator.Event{{Name: "order.placed", ID: "1"}, {Name: "order.placed", ID: "2", CorrelationID: "c-2"}}, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := openLive(t, srv.URL+"/admin/events/live"+tt.query)
+			for _, event := range tt.events {
+				m.Publish(context.Background(), event)
+			}
+			if got := next(); got.ID != tt.want {
+				t.Errorf("live event = %+v, want ID %s", got, tt.want)
+			}
+		})
+	}
+
+	// Failed publishes carry their error
+	next := openLive(t, srv.URL+"/admin/events/live?event=order.failed")
+	m.Publish(context.Background(), mediator.Event{Name: "order.failed"})
+	if got := next(); !strings.Contains(got.Error, "boom") {
+		t.Errorf("live event error = %q, want the handler error", got.Error)
+	}
+}
+
+func TestHandler_LiveOverflow(t *testing.T) {
+	h, err := NewHandler(mediator.NewMediator(), Config{Token: testToken})
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+
+	watch := &watcher{events: make(chan LiveEvent, 1), overflow: make(chan struct{})}
+	h.watchers[watch] = true
+	for i := 0; i < 3; i++ {
+		h.broadcast(context.Background(), mediator.Event{Name: "order.placed"}, nil)
+	}
+	select {
+	case <-watch.overflow:
+	default:
+		t.Error("expected a watcher falling behind to overflow")
+	}
+}
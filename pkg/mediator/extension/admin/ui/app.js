@@ -0,0 +1,238 @@
+// Event browser of the mediator admin API. The API is served next to the UI,
+// e.g. /admin/events for /admin/ui/, and every call sends the bearer token,
+// kept for the browser session only.
+(function () {
+  "use strict";
+
+  const base = location.pathname.replace(/\/ui\/.*$/, "");
+  const maxLiveEvents = 500;
+  const $ = (id) => document.getElementById(id);
+
+  let token = sessionStorage.getItem("mediator-admin-token") || "";
+  let liveAbort = null;
+
+  // api calls a JSON endpoint with the token
+  async function api(path, params) {
+    const query = new URLSearchParams(params || {});
+    const response = await fetch(base + path + (query.toString() ? "?" + query : ""), {
+      headers: { Authorization: "Bearer " + token },
+    });
+    const body = await response.json().catch(() => ({}));
+    if (!response.ok) {
+      throw new Error(body.error || response.statusText);
+    }
+    return body;
+  }
+
+  function setStatus(id, text, isError) {
+    const status = $(id);
+    status.textContent = text;
+    status.classList.toggle("error", !!isError);
+  }
+
+  function setConnected(connected) {
+    $("login").hidden = connected;
+    $("logout").hidden = !connected;
+    $("browser").querySelectorAll("form button, form input").forEach((el) => {
+      el.disabled = !connected;
+    });
+    if (connected) {
+      loadStreams();
+    }
+  }
+
+  // showEvent shows the JSON of an event in the detail pane
+  function showEvent(item, event) {
+    document.querySelectorAll(".events li.selected").forEach((li) => li.classList.remove("selected"));
+    item.classList.add("selected");
+    $("detail-title").textContent = event.name + " " + (event.id || "");
+    $("detail-json").textContent = JSON.stringify(event, null, 2);
+  }
+
+  // eventItem renders an event as a list item; text only, never HTML
+  function eventItem(event) {
+    const item = document.createElement("li");
+    const time = document.createElement("span");
+    time.textContent = event.timestamp ? new Date(event.timestamp).toLocaleTimeString() : "";
+    const name = document.createElement("span");
+    name.className = "name";
+    name.textContent = event.name + (event.error ? " (failed)" : "");
+    item.append(time, name);
+    item.classList.toggle("failed", !!event.error);
+    item.addEventListener("click", () => showEvent(item, event));
+    return item;
+  }
+
+  function splitNames(value) {
+    return value.split(",").map((name) => name.trim()).filter(Boolean);
+  }
+
+  // Live events are read from the Server-Sent Events stream with fetch, since
+  // EventSource cannot send the Authorization header
+  async function startLive() {
+    const params = new URLSearchParams();
+    splitNames($("live-events").value).forEach((name) => params.append("event", name));
+    const correlation = $("live-correlation").value.trim();
+    if (correlation) {
+      params.set("correlation_id", correlation);
+    }
+
+    liveAbort = new AbortController();
+    $("live-toggle").textContent = "Stop";
+    setStatus("live-status", "Connecting...");
+    try {
+      const response = await fetch(base + "/events/live?" + params, {
+        headers: { Authorization: "Bearer " + token },
+        signal: liveAbort.signal,
+      });
+      if (!response.ok) {
+        const body = await response.json().catch(() => ({}));
+        throw new Error(body.error || response.statusText);
+      }
+      setStatus("live-status", "Streaming");
+
+      const reader = response.body.getReader();
+      const decoder = new TextDecoder();
+      let buffer = "";
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) {
+          break;
+        }
+        buffer += decoder.decode(value, { stream: true });
+        let end;
+        while ((end = buffer.indexOf("\n\n")) >= 0) {
+          handleMessage(buffer.slice(0, end));
+          buffer = buffer.slice(end + 2);
+        }
+      }
+      setStatus("live-status", "Stream ended", true);
+    } catch (err) {
+      if (err.name !== "AbortError") {
+        setStatus("live-status", err.message, true);
+      }
+    } finally {
+      liveAbort = null;
+      $("live-toggle").textContent = "Start";
+    }
+  }
+
+  function stopLive() {
+    if (liveAbort) {
+      liveAbort.abort();
+      setStatus("live-status", "Stopped");
+    }
+  }
+
+  // handleMessage adds the event of a Server-Sent Events message to the list
+  function handleMessage(message) {
+    let type = "message";
+    let data = "";
+    message.split("\n").forEach((line) => {
+      if (line.startsWith("event:")) {
+        type = line.slice(6).trim();
+      } else if (line.startsWith("data:")) {
+        data += line.slice(5).trim();
+      }
+    });
+    if (type === "overflow") {
+      setStatus("live-status", "Disconnected: the browser fell too far behind", true);
+      return;
+    }
+    if (!data) {
+      return;
+    }
+
+    const list = $("live-list");
+    list.prepend(eventItem(JSON.parse(data)));
+    while (list.children.length > maxLiveEvents) {
+      list.lastChild.remove();
+    }
+  }
+
+  async function loadStreams() {
+    try {
+      const streams = await api("/streams");
+      const options = streams.map((stream) => {
+        const option = document.createElement("option");
+        option.value = stream.Name;
+        option.label = stream.Count + " events";
+        return option;
+      });
+      $("streams").replaceChildren(...options);
+    } catch (err) {
+      // Stores that cannot list their streams still allow typing a name
+    }
+  }
+
+  // isoTime converts a datetime-local value to RFC 3339
+  function isoTime(value) {
+    return value ? new Date(value).toISOString() : "";
+  }
+
+  async function search(e) {
+    e.preventDefault();
+    const params = {
+      event: $("search-event").value.trim(),
+      limit: $("search-limit").value,
+    };
+    const since = isoTime($("search-since").value);
+    const until = isoTime($("search-until").value);
+    const correlation = $("search-correlation").value.trim();
+    if (since) params.since = since;
+    if (until) params.until = until;
+    if (correlation) params.correlation_id = correlation;
+
+    setStatus("search-status", "Searching...");
+    try {
+      const events = await api("/events", params);
+      $("search-list").replaceChildren(...events.map(eventItem));
+      setStatus("search-status", events.length + " events");
+    } catch (err) {
+      setStatus("search-status", err.message, true);
+    }
+  }
+
+  $("login").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    token = $("token").value;
+    try {
+      await api("/metrics");
+      sessionStorage.setItem("mediator-admin-token", token);
+      $("token").value = "";
+      setConnected(true);
+    } catch (err) {
+      token = "";
+      alert("Could not connect: " + err.message);
+    }
+  });
+
+  $("logout").addEventListener("click", () => {
+    stopLive();
+    token = "";
+    sessionStorage.removeItem("mediator-admin-token");
+    setConnected(false);
+  });
+
+  $("live-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    if (liveAbort) {
+      stopLive();
+    } else {
+      startLive();
+    }
+  });
+  $("live-clear").addEventListener("click", () => $("live-list").replaceChildren());
+  $("search-form").addEventListener("submit", search);
+
+  document.querySelectorAll(".tab").forEach((tab) => {
+    tab.addEventListener("click", () => {
+      document.querySelectorAll(".tab").forEach((t) => t.classList.toggle("active", t === tab));
+      document.querySelectorAll(".panel").forEach((panel) => {
+        panel.hidden = panel.id !== tab.dataset.tab;
+      });
+    });
+  });
+
+  setConnected(!!token);
+})();
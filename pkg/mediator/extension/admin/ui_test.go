@@ -0,0 +1,65 @@
+package admin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandler_UI(t *testing.T) {
+	config := DefaultConfig()
+	config.UI = true
+	_, srv := setupConfig(t, config)
+	_, disabled := setup(t)
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	get := func(url string) (*http.Response, string) {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", url, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		code     int
+		contains string
+	}{
+		{"page without a token", srv.URL + "/admin/ui/", http.StatusOK, "Mediator Events"},
+		{"script", srv.URL + "/admin/ui/app.js", http.StatusOK, "/events/live"},
+		{"stylesheet", srv.URL + "/admin/ui/style.css", http.StatusOK, "body"},
+		{"missing file", srv.URL + "/admin/ui/missing.js", http.StatusNotFound, ""},
+		{"disabled", disabled.URL + "/admin/ui/", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(tt.url)
+			if resp.StatusCode != tt.code || !strings.Contains(body, tt.contains) {
+				t.Errorf("GET %s = %d, want %d containing %q", tt.url, resp.StatusCode, tt.code, tt.contains)
+			}
+			if tt.code == http.StatusOK && resp.Header.Get("Content-Security-Policy") == "" {
+				t.Error("expected a Content-Security-Policy header")
+			}
+		})
+	}
+
+	// The redirect keeps the prefix the API is mounted under
+	resp, _ := get(srv.URL + "/admin/ui")
+	if resp.StatusCode != http.StatusMovedPermanently || resp.Header.Get("Location") != "ui/" {
+		t.Errorf("GET /admin/ui = %d to %q, want a redirect to ui/", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// The API still needs the token
+	resp, _ = get(srv.URL + "/admin/subscriptions")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /admin/subscriptions without token = %d, want 401", resp.StatusCode)
+	}
+}
@@ -0,0 +1,161 @@
+// Package audit records who published which event in an append-only audit
+// log, from publish middleware, without changes to the publishing code.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Actor is who published an event
+type Actor struct {
+	// ID identifies the user or service, e.g. a subject claim
+	ID string `json:"id"`
+	// Tenant is the tenant the actor published for
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// actorContextKey is the context key under which the actor is stored
+type actorContextKey struct{}
+
+// ContextWithActor returns a copy of ctx carrying the actor publishing with
+// it, e.g. set by authentication middleware
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with ContextWithActor
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(Actor)
+	return actor, ok
+}
+
+// Record is the audit record of a publish
+type Record struct {
+	ID            string `json:"id"`
+	EventID       string `json:"event_id"`
+	EventName     string `json:"event_name"`
+	Namespace     string `json:"namespace,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Actor         string `json:"actor"`
+	Tenant        string `json:"tenant,omitempty"`
+	// PayloadDigest is the SHA-256 digest of the serialized payload, empty
+	// for events without one
+	PayloadDigest string    `json:"payload_digest,omitempty"`
+	PublishedAt   time.Time `json:"published_at"`
+	RecordedAt    time.Time `json:"recorded_at"`
+}
+
+// Config configures the audit middleware
+type Config struct {
+	// Actor extracts the actor from the publish context; ActorFromContext
+	// when nil. Actors without a tenant are recorded with the event namespace.
+	Actor func(ctx context.Context) (Actor, bool)
+	// RequireActor fails publishes without an actor instead of recording
+	// them as anonymous
+	RequireActor bool
+	// FailOpen lets publishes go ahead when their record cannot be written,
+	// logging the failure; such publishes fail when false
+	FailOpen bool
+	// Serializer encodes payloads for their digest
+	Serializer mediator.Serializer
+	// Logger reports records that could not be written with FailOpen
+	Logger mediator.Logger
+}
+
+// DefaultConfig returns default middleware configuration
+func DefaultConfig() Config {
+	return Config{
+		Serializer: mediator.JSONSerializer{},
+	}
+}
+
+// Middleware returns publish middleware appending a record of every event
+// published to log before dispatching it. Events received through a transport are
+// not recorded again.
+func Middleware(log Log, config Config) mediator.Middleware {
+	if config.Actor == nil {
+		config.Actor = ActorFromContext
+	}
+	if config.Serializer == nil {
+		config.Serializer = mediator.JSONSerializer{}
+	}
+	return func(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+		if mediator.IsRemote(event) {
+			return next(ctx, event)
+		}
+
+		record, err := newRecord(ctx, event, config)
+		if err != nil {
+			return err
+		}
+		if err := log.Append(ctx, record); err != nil {
+			if !config.FailOpen {
+				return fmt.Errorf("failed to audit event %s: %w", event.ID, err)
+			}
+			if config.Logger != nil {
+				config.Logger.Printf("audit: failed to audit event %s: %v", event.ID, err)
+			}
+		}
+		return next(ctx, event)
+	}
+}
+
+// newRecord builds the record of an event published with ctx
+func newRecord(ctx context.Context, event mediator.Event, config Config) (Record, error) {
+	actor, ok := config.Actor(ctx)
+	if !ok || actor.ID == "" {
+		if config.RequireActor {
+			return Record{}, fmt.Errorf("event %s has no actor to audit", event.ID)
+		}
+		actor.ID = "anonymous"
+	}
+	if actor.Tenant == "" {
+		actor.Tenant = event.Namespace
+	}
+
+	digest, err := Digest(config.Serializer, event.Payload)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to digest payload of event %s: %w", event.ID, err)
+	}
+	return Record{
+		ID:            newID(),
+		EventID:       event.ID,
+		EventName:     event.Name,
+		Namespace:     event.Namespace,
+		CorrelationID: event.CorrelationID,
+		Actor:         actor.ID,
+		Tenant:        actor.Tenant,
+		PayloadDigest: digest,
+		PublishedAt:   event.Timestamp,
+		RecordedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// Digest returns the "sha256:"-prefixed hex digest of a payload serialized
+// with serializer, or "" for a nil payload, e.g. to check a stored event
+// against its audit record
+func Digest(serializer mediator.Serializer, payload interface{}) (string, error) {
+	if payload == nil {
+		return "", nil
+	}
+	data, err := serializer.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// newID returns a random hex ID
+func newID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
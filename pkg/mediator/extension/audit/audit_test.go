@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// failingLog is a Log whose appends fail
+type failingLog struct{}
+
+func (failingLog) Append(ctx context.Context, record Record) error {
+	return errors.New("disk full")
+}
+
+func (failingLog) List(ctx context.Context, query Query) ([]Record, error) {
+	return nil, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	log := NewMemoryLog()
+	m := mediator.NewMediator()
+	m.UsePublish(Middleware(log, DefaultConfig()))
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+
+	ctx := ContextWithActor(context.Background(), Actor{ID: "alice", Tenant: "acme"})
+	payload := map[string]interface{}{"id": "o-1"}
+	if err := m.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-1", Payload: payload}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-2", Namespace: "globex"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	remote := mediator.Event{Name: "order.placed", ID: "evt-3", Metadata: map[string]string{mediator.RemoteMetadataKey: "true"}}
+	m.Publish(context.Background(), remote)
+
+	records, _ := log.List(context.Background(), Query{})
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %+v", records)
+	}
+
+	digest, _ := Digest(mediator.JSONSerializer{}, payload)
+	first := records[0]
+	if first.EventID != "evt-1" || first.Actor != "alice" || first.Tenant != "acme" || first.PayloadDigest != digest {
+		t.Errorf("Unexpected record %+v", first)
+	}
+	if first.PublishedAt.IsZero() || first.RecordedAt.IsZero() || first.ID == "" {
+		t.Errorf("Expected the record to be stamped, got %+v", first)
+	}
+
+	// Anonymous publishes are recorded with the namespace as tenant
+	second := records[1]
+	if second.Actor != "anonymous" || second.Tenant != "globex" || second.PayloadDigest != "" {
+		t.Errorf("Unexpected record %+v", second)
+	}
+}
+
+func TestMiddleware_Failures(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      Log
+		config   Config
+		ctx      context.Context
+		wantErr  bool
+		wantCall bool
+	}{
+		{"requires actor", NewMemoryLog(), Config{RequireActor: true}, context.Background(), true, false},
+		{"has required actor", NewMemoryLog(), Config{RequireActor: true}, ContextWithActor(context.Background(), Actor{ID: "alice"}), false, true},
+		{"fails closed", failingLog{}, Config{}, context.Background(), true, false},
+		{"fails open", failingLog{}, Config{FailOpen: true}, context.Background(), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mediator.NewMediator()
+			m.UsePublish(Middleware(tt.log, tt.config))
+			called := false
+			m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+				called = true
+				return nil
+			})
+
+			err := m.Publish(tt.ctx, mediator.Event{Name: "order.placed"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if called != tt.wantCall {
+				t.Errorf("handler called = %v, want %v", called, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestDigest(t *testing.T) {
+	a, _ := Digest(mediator.JSONSerializer{}, map[string]interface{}{"id": "o-1"})
+	b, _ := Digest(mediator.JSONSerializer{}, map[string]interface{}{"id": "o-2"})
+	if a == b || len(a) != len("sha256:")+64 {
+		t.Errorf("Digest() = %q and %q", a, b)
+	}
+	if _, err := Digest(mediator.JSONSerializer{}, func() {}); err == nil {
+		t.Error("Expected an error for a payload that cannot be serialized")
+	}
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// EventName is the event name StoreLog keeps records under
+const EventName = "audit"
+
+// Query narrows the records listed; zero fields match every record
+type Query struct {
+	EventName string
+	Actor     string
+	Tenant    string
+	// Since and Until bound PublishedAt, inclusive and exclusive
+	Since time.Time
+	Until time.Time
+	// Limit bounds the records returned; every record when <= 0
+	Limit int
+}
+
+// Matches reports whether a record matches the query, ignoring Limit
+func (q Query) Matches(record Record) bool {
+	switch {
+	case q.EventName != "" && record.EventName != q.EventName:
+		return false
+	case q.Actor != "" && record.Actor != q.Actor:
+		return false
+	case q.Tenant != "" && record.Tenant != q.Tenant:
+		return false
+	case !q.Since.IsZero() && record.PublishedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !record.PublishedAt.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// Log is an append-only log of audit records. It offers no way to change or
+// remove a record once appended.
+type Log interface {
+	// Append adds a record
+	Append(ctx context.Context, record Record) error
+	// List returns the records matching a query, oldest first
+	List(ctx context.Context, query Query) ([]Record, error)
+}
+
+// MemoryLog is an in-memory Log
+type MemoryLog struct {
+	mu      sync.RWMutex
+	records []Record
+}
+
+// NewMemoryLog creates an empty in-memory log
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+// Append adds a record
+func (l *MemoryLog) Append(ctx context.Context, record Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+	return nil
+}
+
+// List returns the records matching a query, oldest first
+func (l *MemoryLog) List(ctx context.Context, query Query) ([]Record, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return filter(l.records, query), nil
+}
+
+// StoreLog keeps records in an EventStore as EventName events, e.g. in a
+// database the publishing service can only insert into
+type StoreLog struct {
+	store mediator.EventStore
+}
+
+// NewStoreLog creates a log backed by store. Use a store dedicated to
+// auditing rather than the mediator's own, whose events may be cleared.
+func NewStoreLog(store mediator.EventStore) *StoreLog {
+	return &StoreLog{store: store}
+}
+
+// Append stores a record
+func (l *StoreLog) Append(ctx context.Context, record Record) error {
+	return l.store.StoreEvent(ctx, mediator.Event{
+		Name:      EventName,
+		ID:        record.ID,
+		Timestamp: record.RecordedAt,
+		Payload:   record,
+	})
+}
+
+// List reads every record and returns those matching a query, oldest first
+func (l *StoreLog) List(ctx context.Context, query Query) ([]Record, error) {
+	stored, err := mediator.AsEventStoreV2(l.store).ReadEvents(ctx, EventName, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit records: %w", err)
+	}
+
+	records := make([]Record, 0, len(stored))
+	for _, event := range stored {
+		data, err := json.Marshal(event.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal audit record: %w", err)
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	// Stores differ in the order they return events
+	sort.SliceStable(records, func(i, j int) bool { return records[i].RecordedAt.Before(records[j].RecordedAt) })
+	return filter(records, query), nil
+}
+
+// filter returns the records matching a query, up to its limit
+func filter(records []Record, query Query) []Record {
+	var matched []Record
+	for _, record := range records {
+		if query.Limit > 0 && len(matched) >= query.Limit {
+			break
+		}
+		if query.Matches(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
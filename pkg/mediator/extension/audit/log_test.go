@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+func TestQuery_Matches(t *testing.T) {
+	now := time.Now()
+	record := Record{EventName: "order.placed", Actor: "alice", Tenant: "acme", PublishedAt: now}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  bool
+	}{
+		{"empty", Query{}, true},
+		{"event name", Query{EventName: "order.placed"}, true},
+		{"other event name", Query{EventName: "order.shipped"}, false},
+		{"actor", Query{Actor: "alice"}, true},
+		{"other actor", Query{Actor: "bob"}, false},
+		{"other tenant", Query{Tenant: "globex"}, false},
+		{"since", Query{Since: now}, true},
+		{"before since", Query{Since: now.Add(time.Second)}, false},
+		{"until", Query{Until: now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Matches(record); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogs(t *testing.T) {
+	logs := map[string]Log{
+		"memory": NewMemoryLog(),
+		"store":  NewStoreLog(mediatortest.NewEventStore()),
+	}
+
+	for name, log := range logs {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			start := time.Now().UTC()
+			for i, actor := range []string{"alice", "bob", "alice"} {
+				record := Record{
+					ID:          newID(),
+					EventName:   "order.placed",
+					Actor:       actor,
+					PublishedAt: start.Add(time.Duration(i) * time.Second),
+					RecordedAt:  start.Add(time.Duration(i) * time.Second),
+				}
+				if err := log.Append(ctx, record); err != nil {
+					t.Fatalf("Append() error = %v", err)
+				}
+			}
+
+			records, err := log.List(ctx, Query{Actor: "alice"})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(records) != 2 || !records[0].PublishedAt.Before(records[1].PublishedAt) {
+				t.Errorf("Expected alice's 2 records oldest first, got %+v", records)
+			}
+
+			if records, _ := log.List(ctx, Query{Limit: 1}); len(records) != 1 || records[0].Actor != "alice" {
+				t.Errorf("Expected the oldest record, got %+v", records)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Package cloudevents converts mediator events to and from CloudEvents 1.0,
+// in the JSON event format and the structured and binary HTTP modes.
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// SpecVersion is the CloudEvents version produced and accepted
+const SpecVersion = "1.0"
+
+// ContentType is the media type of events in the JSON event format
+const ContentType = "application/cloudevents+json"
+
+// ErrInvalidEvent is matched by the errors of events missing a required
+// attribute or using an unsupported spec version
+var ErrInvalidEvent = errors.New("invalid CloudEvent")
+
+// contextAttributes are the attributes defined by the spec, which extensions
+// cannot use
+var contextAttributes = map[string]bool{
+	"id": true, "source": true, "specversion": true, "type": true,
+	"datacontenttype": true, "dataschema": true, "subject": true, "time": true,
+	"data": true, "data_base64": true,
+}
+
+// CloudEvent is a CloudEvents 1.0 event
+type CloudEvent struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	Time            time.Time
+	// Data is the event data encoded as DataContentType
+	Data []byte
+	// Extensions holds extension attributes in their string form
+	Extensions map[string]string
+}
+
+// Validate checks the required attributes and extension names
+func (e CloudEvent) Validate() error {
+	switch {
+	case e.SpecVersion != SpecVersion:
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEvent, e.SpecVersion)
+	case e.ID == "":
+		return fmt.Errorf("%w: id is required", ErrInvalidEvent)
+	case e.Source == "":
+		return fmt.Errorf("%w: source is required", ErrInvalidEvent)
+	case e.Type == "":
+		return fmt.Errorf("%w: type is required", ErrInvalidEvent)
+	}
+	for name := range e.Extensions {
+		if !validExtensionName(name) {
+			return fmt.Errorf("%w: invalid extension name %q", ErrInvalidEvent, name)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the event in the JSON event format. JSON data is
+// embedded as-is, other data is base64 encoded in data_base64.
+func (e CloudEvent) MarshalJSON() ([]byte, error) {
+	attributes := make(map[string]interface{}, len(e.Extensions)+9)
+	for name, value := range e.Extensions {
+		attributes[name] = value
+	}
+	attributes["specversion"] = e.SpecVersion
+	attributes["id"] = e.ID
+	attributes["source"] = e.Source
+	attributes["type"] = e.Type
+	if e.DataContentType != "" {
+		attributes["datacontenttype"] = e.DataContentType
+	}
+	if e.DataSchema != "" {
+		attributes["dataschema"] = e.DataSchema
+	}
+	if e.Subject != "" {
+		attributes["subject"] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		attributes["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.Data != nil {
+		if isJSON(e.DataContentType) && json.Valid(e.Data) {
+			attributes["data"] = json.RawMessage(e.Data)
+		} else {
+			attributes["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+	return json.Marshal(attributes)
+}
+
+// UnmarshalJSON decodes an event in the JSON event format. Extension values
+// that are not strings are kept in their JSON form, e.g. 42 or true.
+func (e *CloudEvent) UnmarshalJSON(data []byte) error {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+
+	event := CloudEvent{}
+	fields := map[string]*string{
+		"specversion":     &event.SpecVersion,
+		"id":              &event.ID,
+		"source":          &event.Source,
+		"type":            &event.Type,
+		"datacontenttype": &event.DataContentType,
+		"dataschema":      &event.DataSchema,
+		"subject":         &event.Subject,
+	}
+	for name, raw := range attributes {
+		switch name {
+		case "time":
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("failed to decode time: %w", err)
+			}
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return fmt.Errorf("failed to parse time: %w", err)
+			}
+			event.Time = t
+		case "data":
+			if bytes.Equal(raw, []byte("null")) {
+				continue
+			}
+			// JSON data is kept as JSON; string data of other types is its content
+			var value string
+			if !isJSON(jsonString(attributes["datacontenttype"])) && json.Unmarshal(raw, &value) == nil {
+				event.Data = []byte(value)
+			} else {
+				event.Data = append([]byte(nil), raw...)
+			}
+		case "data_base64":
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("failed to decode data_base64: %w", err)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return fmt.Errorf("failed to decode data_base64: %w", err)
+			}
+			event.Data = decoded
+		default:
+			if target, ok := fields[name]; ok {
+				if err := json.Unmarshal(raw, target); err != nil {
+					return fmt.Errorf("failed to decode %s: %w", name, err)
+				}
+				continue
+			}
+			if bytes.Equal(raw, []byte("null")) {
+				continue
+			}
+			if event.Extensions == nil {
+				event.Extensions = make(map[string]string)
+			}
+			var value string
+			if json.Unmarshal(raw, &value) != nil {
+				value = string(raw)
+			}
+			event.Extensions[name] = value
+		}
+	}
+	*e = event
+	return nil
+}
+
+// jsonString returns the string a raw JSON value holds, or ""
+func jsonString(raw json.RawMessage) string {
+	var value string
+	json.Unmarshal(raw, &value)
+	return value
+}
+
+// isJSON reports whether a content type is JSON; events without one hold JSON
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// validExtensionName reports whether a name is lowercase letters and digits,
+// as the spec requires of attribute names
+func validExtensionName(name string) bool {
+	if name == "" || contextAttributes[name] {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
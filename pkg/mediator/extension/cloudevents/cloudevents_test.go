@@ -0,0 +1,114 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCloudEvent_Validate(t *testing.T) {
+	valid := CloudEvent{ID: "evt-1", Source: "/orders", SpecVersion: SpecVersion, Type: "order.placed"}
+
+	tests := []struct {
+		name    string
+		modify  func(e *CloudEvent)
+		wantErr bool
+	}{
+		{"valid", func(e *CloudEvent) {}, false},
+		{"no id", func(e *CloudEvent) { e.ID = "" }, true},
+		{"no source", func(e *CloudEvent) { e.Source = "" }, true},
+		{"no type", func(e *CloudEvent) { e.Type = "" }, true},
+		{"other version", func(e *CloudEvent) { e.SpecVersion = "0.3" }, true},
+		{"invalid extension", func(e *CloudEvent) { e.Extensions = map[string]string{"Trace-ID": "1"} }, true},
+		{"reserved extension", func(e *CloudEvent) { e.Extensions = map[string]string{"data": "1"} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := valid
+			tt.modify(&event)
+			err := event.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidEvent) {
+				t.Errorf("Expected ErrInvalidEvent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCloudEvent_JSON(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		event    CloudEvent
+		wantData string
+		wantB64  bool
+	}{
+		{
+			name: "JSON data",
+			event: CloudEvent{
+				ID: "evt-1", Source: "/orders", SpecVersion: SpecVersion, Type: "order.placed",
+				DataContentType: "application/json", Subject: "o-1", Time: timestamp,
+				Data: []byte(`{"id":"o-1"}`), Extensions: map[string]string{"traceparent": "00-abc"},
+			},
+			wantData: `{"id":"o-1"}`,
+		},
+		{
+			name: "binary data",
+			event: CloudEvent{
+				ID: "evt-2", Source: "/orders", SpecVersion: SpecVersion, Type: "order.placed",
+				DataContentType: "application/x-gob", Data: []byte{0x01, 0xff},
+			},
+			wantB64: true,
+		},
+		{
+			name:  "no data",
+			event: CloudEvent{ID: "evt-3", Source: "/orders", SpecVersion: SpecVersion, Type: "order.placed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.event)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var attributes map[string]json.RawMessage
+			json.Unmarshal(data, &attributes)
+			if tt.wantData != "" && string(attributes["data"]) != tt.wantData {
+				t.Errorf("data = %s, want %s", attributes["data"], tt.wantData)
+			}
+			if _, ok := attributes["data_base64"]; ok != tt.wantB64 {
+				t.Errorf("data_base64 present = %v, want %v", ok, tt.wantB64)
+			}
+
+			var decoded CloudEvent
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.event) {
+				t.Errorf("Round trip = %+v, want %+v", decoded, tt.event)
+			}
+		})
+	}
+}
+
+func TestCloudEvent_UnmarshalExtensions(t *testing.T) {
+	var event CloudEvent
+	data := `{"specversion":"1.0","id":"evt-1","source":"/s","type":"t","count":42,"sampled":true,"region":"eu","data":"plain","datacontenttype":"text/plain"}`
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := map[string]string{"count": "42", "sampled": "true", "region": "eu"}
+	if !reflect.DeepEqual(event.Extensions, want) {
+		t.Errorf("Extensions = %v, want %v", event.Extensions, want)
+	}
+	if string(event.Data) != "plain" {
+		t.Errorf("Data = %q, want plain", event.Data)
+	}
+}
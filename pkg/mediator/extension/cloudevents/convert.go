@@ -0,0 +1,165 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Metadata keys holding the CloudEvents attributes that have no field in the
+// mediator event envelope
+const (
+	// SourceMetadataKey holds the source of an event
+	SourceMetadataKey = "source"
+	// SubjectMetadataKey holds the subject of an event
+	SubjectMetadataKey = "subject"
+	// DataSchemaMetadataKey holds the schema the data of an event adheres to
+	DataSchemaMetadataKey = "dataschema"
+)
+
+// Extension attributes carrying the envelope fields CloudEvents does not define
+const (
+	CorrelationIDExtension = "correlationid"
+	CausationIDExtension   = "causationid"
+	NamespaceExtension     = "namespace"
+)
+
+// Config configures the conversion of events
+type Config struct {
+	// Source is the source of events without one in their metadata
+	Source string
+	// Serializer encodes and decodes payloads; JSON is used when nil
+	Serializer mediator.Serializer
+}
+
+// DefaultConfig returns default conversion configuration
+func DefaultConfig() Config {
+	return Config{
+		Source: "/mediator",
+	}
+}
+
+// FromEvent converts a mediator event to a CloudEvent. ID, Name and
+// Timestamp become id, type and time; source, subject and dataschema are read
+// from the metadata, the source falling back to config.Source. The
+// correlation ID, causation ID and namespace become extension attributes, as
+// do the other metadata keys that are valid attribute names; other keys are
+// dropped.
+func FromEvent(event mediator.Event, config Config) (CloudEvent, error) {
+	serializer := config.Serializer
+	if serializer == nil {
+		serializer = mediator.JSONSerializer{}
+	}
+
+	ce := CloudEvent{
+		ID:          event.ID,
+		Source:      config.Source,
+		SpecVersion: SpecVersion,
+		Type:        event.Name,
+		Time:        event.Timestamp,
+	}
+	for key, value := range event.Metadata {
+		switch key {
+		case SourceMetadataKey:
+			ce.Source = value
+		case SubjectMetadataKey:
+			ce.Subject = value
+		case DataSchemaMetadataKey:
+			ce.DataSchema = value
+		default:
+			if validExtensionName(key) {
+				ce.setExtension(key, value)
+			}
+		}
+	}
+	ce.setExtension(CorrelationIDExtension, event.CorrelationID)
+	ce.setExtension(CausationIDExtension, event.CausationID)
+	ce.setExtension(NamespaceExtension, event.Namespace)
+
+	if event.Payload != nil {
+		data, err := serializer.Marshal(event.Payload)
+		if err != nil {
+			return CloudEvent{}, fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		ce.Data = data
+		ce.DataContentType = serializer.ContentType()
+	}
+
+	if err := ce.Validate(); err != nil {
+		return CloudEvent{}, err
+	}
+	return ce, nil
+}
+
+// ToEvent converts a CloudEvent to a mediator event, the reverse of
+// FromEvent. JSON data and data in the serializer's content type are decoded
+// into the payload as generic values; data of other types is kept as []byte.
+func ToEvent(ce CloudEvent, config Config) (mediator.Event, error) {
+	if err := ce.Validate(); err != nil {
+		return mediator.Event{}, err
+	}
+
+	event := mediator.Event{
+		ID:        ce.ID,
+		Name:      ce.Type,
+		Timestamp: ce.Time,
+		Metadata:  map[string]string{SourceMetadataKey: ce.Source},
+	}
+	if ce.Subject != "" {
+		event.Metadata[SubjectMetadataKey] = ce.Subject
+	}
+	if ce.DataSchema != "" {
+		event.Metadata[DataSchemaMetadataKey] = ce.DataSchema
+	}
+	for name, value := range ce.Extensions {
+		switch name {
+		case CorrelationIDExtension:
+			event.CorrelationID = value
+		case CausationIDExtension:
+			event.CausationID = value
+		case NamespaceExtension:
+			event.Namespace = value
+		default:
+			event.Metadata[name] = value
+		}
+	}
+
+	if ce.Data != nil {
+		payload, err := decodeData(ce, config.Serializer)
+		if err != nil {
+			return mediator.Event{}, fmt.Errorf("failed to decode data: %w", err)
+		}
+		event.Payload = payload
+	}
+	return event, nil
+}
+
+// decodeData decodes the data of an event by its content type
+func decodeData(ce CloudEvent, serializer mediator.Serializer) (interface{}, error) {
+	var payload interface{}
+	switch {
+	case serializer != nil && ce.DataContentType == serializer.ContentType():
+		if err := serializer.Unmarshal(ce.Data, &payload); err != nil {
+			return nil, err
+		}
+	case isJSON(ce.DataContentType):
+		if err := json.Unmarshal(ce.Data, &payload); err != nil {
+			return nil, err
+		}
+	default:
+		payload = ce.Data
+	}
+	return payload, nil
+}
+
+// setExtension sets an extension attribute unless value is empty
+func (e *CloudEvent) setExtension(name, value string) {
+	if value == "" {
+		return
+	}
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]string)
+	}
+	e.Extensions[name] = value
+}
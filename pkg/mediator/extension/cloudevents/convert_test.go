@@ -0,0 +1,127 @@
+package cloudevents
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestFromEvent(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := mediator.Event{
+		Name:          "order.placed",
+		ID:            "evt-1",
+		Payload:       map[string]interface{}{"id": "o-1"},
+		Timestamp:     timestamp,
+		CorrelationID: "corr-1",
+		CausationID:   "cause-1",
+		Namespace:     "acme",
+		Metadata: map[string]string{
+			SubjectMetadataKey: "o-1",
+			"traceparent":      "00-abc",
+			"Not_Valid":        "dropped",
+		},
+	}
+
+	ce, err := FromEvent(event, DefaultConfig())
+	if err != nil {
+		t.Fatalf("FromEvent() error = %v", err)
+	}
+	want := CloudEvent{
+		ID:              "evt-1",
+		Source:          "/mediator",
+		SpecVersion:     SpecVersion,
+		Type:            "order.placed",
+		DataContentType: "application/json",
+		Subject:         "o-1",
+		Time:            timestamp,
+		Data:            []byte(`{"id":"o-1"}`),
+		Extensions: map[string]string{
+			"traceparent":          "00-abc",
+			CorrelationIDExtension: "corr-1",
+			CausationIDExtension:   "cause-1",
+			NamespaceExtension:     "acme",
+		},
+	}
+	if !reflect.DeepEqual(ce, want) {
+		t.Errorf("FromEvent() = %+v, want %+v", ce, want)
+	}
+
+	// Test the source of the metadata wins over the configured one
+	event.Metadata[SourceMetadataKey] = "/billing"
+	if ce, _ := FromEvent(event, DefaultConfig()); ce.Source != "/billing" {
+		t.Errorf("Source = %s, want /billing", ce.Source)
+	}
+
+	if _, err := FromEvent(mediator.Event{Name: "order.placed"}, DefaultConfig()); err == nil {
+		t.Error("Expected an error converting an event without an ID")
+	}
+}
+
+func TestToEvent(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	base := CloudEvent{
+		ID:          "evt-1",
+		Source:      "/orders",
+		SpecVersion: SpecVersion,
+		Type:        "order.placed",
+		Subject:     "o-1",
+		Time:        timestamp,
+		Extensions:  map[string]string{CorrelationIDExtension: "corr-1", NamespaceExtension: "acme", "region": "eu"},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		serializer  mediator.Serializer
+		wantPayload interface{}
+		wantErr     bool
+	}{
+		{"JSON", "application/json", []byte(`{"id":"o-1"}`), nil, map[string]interface{}{"id": "o-1"}, false},
+		{"JSON suffix", "application/vnd.orders+json", []byte(`[1]`), nil, []interface{}{1.0}, false},
+		{"no data", "", nil, nil, nil, false},
+		{"other type", "text/plain", []byte("hello"), nil, []byte("hello"), false},
+		{"serializer type", "application/x-gob", mustGob(t, "hello"), mediator.GobSerializer{}, "hello", false},
+		{"malformed JSON", "application/json", []byte(`{`), nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := base
+			ce.DataContentType = tt.contentType
+			ce.Data = tt.data
+
+			event, err := ToEvent(ce, Config{Serializer: tt.serializer})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if event.ID != "evt-1" || event.Name != "order.placed" || !event.Timestamp.Equal(timestamp) ||
+				event.CorrelationID != "corr-1" || event.Namespace != "acme" {
+				t.Errorf("Unexpected envelope %+v", event)
+			}
+			wantMetadata := map[string]string{SourceMetadataKey: "/orders", SubjectMetadataKey: "o-1", "region": "eu"}
+			if !reflect.DeepEqual(event.Metadata, wantMetadata) {
+				t.Errorf("Metadata = %v, want %v", event.Metadata, wantMetadata)
+			}
+			if !reflect.DeepEqual(event.Payload, tt.wantPayload) {
+				t.Errorf("Payload = %#v, want %#v", event.Payload, tt.wantPayload)
+			}
+		})
+	}
+}
+
+// mustGob encodes v with the gob serializer
+func mustGob(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := mediator.GobSerializer{}.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	return data
+}
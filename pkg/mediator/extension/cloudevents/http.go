@@ -0,0 +1,226 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Mode is a CloudEvents HTTP content mode
+type Mode int
+
+const (
+	// Structured sends the whole event as an application/cloudevents+json body
+	Structured Mode = iota
+	// Binary sends the attributes as ce- headers and the data as the body
+	Binary
+)
+
+// headerPrefix prefixes the headers of attributes in binary mode
+const headerPrefix = "Ce-"
+
+// maxBodySize bounds the bodies ReadRequest reads
+const maxBodySize = 10 << 20
+
+// NewRequest creates a POST request delivering an event to url in a mode
+func NewRequest(ctx context.Context, url string, ce CloudEvent, mode Mode) (*http.Request, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	var body []byte
+	switch mode {
+	case Structured:
+		data, err := json.Marshal(ce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event: %w", err)
+		}
+		body = data
+		header.Set("Content-Type", ContentType)
+	case Binary:
+		writeHeaders(header, ce)
+		body = ce.Data
+	default:
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// ReadRequest reads the event a request delivers, in structured mode when its
+// content type is application/cloudevents+json and in binary mode otherwise
+func ReadRequest(r *http.Request) (CloudEvent, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return CloudEvent{}, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(body) > maxBodySize {
+		return CloudEvent{}, fmt.Errorf("%w: body larger than %d bytes", ErrInvalidEvent, maxBodySize)
+	}
+
+	var ce CloudEvent
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case mediaType == ContentType:
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return CloudEvent{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+	case strings.HasPrefix(mediaType, "application/cloudevents-batch"):
+		return CloudEvent{}, fmt.Errorf("%w: batched events are not supported", ErrInvalidEvent)
+	default:
+		if ce, err = readHeaders(r.Header); err != nil {
+			return CloudEvent{}, err
+		}
+		if len(body) > 0 {
+			ce.Data = body
+		}
+	}
+
+	if err := ce.Validate(); err != nil {
+		return CloudEvent{}, err
+	}
+	return ce, nil
+}
+
+// writeHeaders sets the attributes of an event as binary mode headers
+func writeHeaders(header http.Header, ce CloudEvent) {
+	set := func(name, value string) {
+		if value != "" {
+			header.Set(headerPrefix+name, encodeHeader(value))
+		}
+	}
+	set("specversion", ce.SpecVersion)
+	set("id", ce.ID)
+	set("source", ce.Source)
+	set("type", ce.Type)
+	set("subject", ce.Subject)
+	set("dataschema", ce.DataSchema)
+	if !ce.Time.IsZero() {
+		set("time", ce.Time.Format(time.RFC3339Nano))
+	}
+	for name, value := range ce.Extensions {
+		set(name, value)
+	}
+	if ce.DataContentType != "" {
+		header.Set("Content-Type", ce.DataContentType)
+	}
+}
+
+// readHeaders reads the attributes of an event from binary mode headers
+func readHeaders(header http.Header) (CloudEvent, error) {
+	ce := CloudEvent{DataContentType: header.Get("Content-Type")}
+	for key, values := range header {
+		if len(key) <= len(headerPrefix) || !strings.EqualFold(key[:len(headerPrefix)], headerPrefix) || len(values) == 0 {
+			continue
+		}
+		name := strings.ToLower(key[len(headerPrefix):])
+		value, err := url.PathUnescape(values[0])
+		if err != nil {
+			return CloudEvent{}, fmt.Errorf("%w: malformed header %s", ErrInvalidEvent, key)
+		}
+
+		switch name {
+		case "specversion":
+			ce.SpecVersion = value
+		case "id":
+			ce.ID = value
+		case "source":
+			ce.Source = value
+		case "type":
+			ce.Type = value
+		case "subject":
+			ce.Subject = value
+		case "dataschema":
+			ce.DataSchema = value
+		case "time":
+			if ce.Time, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				return CloudEvent{}, fmt.Errorf("%w: malformed time %q", ErrInvalidEvent, value)
+			}
+		default:
+			ce.setExtension(name, value)
+		}
+	}
+	return ce, nil
+}
+
+// encodeHeader percent-encodes the characters the spec does not allow in
+// header values: controls, non-ASCII, '"' and '%'
+func encodeHeader(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c < 0x20 || c > 0x7e || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// Handler is an http.Handler publishing the CloudEvents POSTed to it, in
+// either mode, through a mediator, e.g. as the sink of a Knative trigger or
+// an EventBridge API destination
+type Handler struct {
+	mediator *mediator.Mediator
+	config   Config
+}
+
+// NewHandler creates a handler publishing received events through m
+func NewHandler(m *mediator.Mediator, config Config) *Handler {
+	return &Handler{mediator: m, config: config}
+}
+
+// ServeHTTP publishes the event of a request. It answers 204 once the event
+// is published, including when no handler subscribes to it, 400 for invalid
+// events, 403 for vetoed ones, 429 for rate limited ones and 500 otherwise.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ce, err := ReadRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := ToEvent(ce, h.config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = h.mediator.Publish(r.Context(), event)
+	switch {
+	case err == nil || errors.Is(err, mediator.ErrNoHandlers):
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, mediator.ErrInvalidEvent):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, mediator.ErrPublishVetoed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, mediator.ErrRateLimited):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,148 @@
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestRequest_RoundTrip(t *testing.T) {
+	ce := CloudEvent{
+		ID:              "evt-1",
+		Source:          "/orders",
+		SpecVersion:     SpecVersion,
+		Type:            "order.placed",
+		DataContentType: "application/json",
+		Subject:         "Zürich \"HQ\" 100%",
+		Time:            time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Data:            []byte(`{"id":"o-1"}`),
+		Extensions:      map[string]string{"traceparent": "00-abc"},
+	}
+
+	tests := []struct {
+		name            string
+		mode            Mode
+		wantContentType string
+	}{
+		{"structured", Structured, ContentType},
+		{"binary", Binary, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewRequest(context.Background(), "http://example.com/events", ce, tt.mode)
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			if ct := req.Header.Get("Content-Type"); ct != tt.wantContentType {
+				t.Errorf("Content-Type = %s, want %s", ct, tt.wantContentType)
+			}
+			if tt.mode == Binary && req.Header.Get("Ce-Subject") != "Z%C3%BCrich %22HQ%22 100%25" {
+				t.Errorf("Ce-Subject = %s, want it percent-encoded", req.Header.Get("Ce-Subject"))
+			}
+
+			got, err := ReadRequest(req)
+			if err != nil {
+				t.Fatalf("ReadRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, ce) {
+				t.Errorf("ReadRequest() = %+v, want %+v", got, ce)
+			}
+		})
+	}
+}
+
+func TestReadRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		body   string
+	}{
+		{"binary without attributes", map[string]string{"Content-Type": "application/json"}, `{}`},
+		{"malformed structured", map[string]string{"Content-Type": ContentType}, `{`},
+		{"structured without type", map[string]string{"Content-Type": ContentType}, `{"specversion":"1.0","id":"1","source":"/s"}`},
+		{"batch", map[string]string{"Content-Type": "application/cloudevents-batch+json"}, `[]`},
+		{"malformed time", map[string]string{"Ce-Specversion": "1.0", "Ce-Id": "1", "Ce-Source": "/s", "Ce-Type": "t", "Ce-Time": "yesterday"}, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/events", strings.NewReader(tt.body))
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			if _, err := ReadRequest(req); !errors.Is(err, ErrInvalidEvent) {
+				t.Errorf("ReadRequest() error = %v, want ErrInvalidEvent", err)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := mediator.NewMediator()
+	received := make(chan mediator.Event, 1)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		received <- event
+		return nil
+	})
+	m.OnBeforePublish(func(ctx context.Context, event *mediator.Event) error {
+		if event.Name == "order.cancelled" {
+			return errors.New("not allowed")
+		}
+		return nil
+	})
+	srv := httptest.NewServer(NewHandler(m, DefaultConfig()))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		eventType  string
+		mode       Mode
+		wantStatus int
+	}{
+		{"structured", "order.placed", Structured, http.StatusNoContent},
+		{"binary", "order.placed", Binary, http.StatusNoContent},
+		{"without handlers", "user.created", Binary, http.StatusNoContent},
+		{"vetoed", "order.cancelled", Binary, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce := CloudEvent{ID: "evt-1", Source: "/orders", SpecVersion: SpecVersion, Type: tt.eventType,
+				DataContentType: "application/json", Data: []byte(`{"id":"o-1"}`)}
+			req, _ := NewRequest(context.Background(), srv.URL, ce, tt.mode)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to send: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if tt.eventType == "order.placed" {
+				event := <-received
+				payload, ok := event.Payload.(map[string]interface{})
+				if event.ID != "evt-1" || event.Metadata[SourceMetadataKey] != "/orders" || !ok || payload["id"] != "o-1" {
+					t.Errorf("Received %+v", event)
+				}
+			}
+		})
+	}
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Failed to send: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status of a request without attributes = %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,114 @@
+// Package di builds a mediator and its event store from a single Config for
+// dependency injection containers: Module for go.uber.org/fx, and provider
+// sets for github.com/google/wire. Constructors then take the
+// *mediator.Mediator as a dependency instead of the global singleton.
+package di
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/extension/postgres"
+	redisstore "github.com/mandocaesar/mediator/pkg/mediator/extension/redis/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// Config configures the mediator and the event stores the providers build
+type Config struct {
+	// Namespace scopes the events of the mediator to a tenant
+	Namespace      string
+	Concurrency    mediator.ConcurrencyMode
+	MaxConcurrency int
+	ErrorStrategy  mediator.ErrorStrategy
+	DeliveryMode   mediator.DeliveryMode
+	HandlerTimeout time.Duration
+	// Retry, when set, retries failed handlers
+	Retry *mediator.RetryPolicy
+	// Buffer, when set, writes events to the store in batches
+	Buffer *mediator.BufferConfig
+	// Limits sheds load past hard limits; the zero value sets none
+	Limits mediator.Limits
+	// DeadLetters keeps the events whose handlers fail in a MemoryDeadLetterQueue
+	DeadLetters bool
+	// Logger reports failures; nothing is logged when nil
+	Logger mediator.Logger
+	// Redis configures the store of the Redis providers
+	Redis redisstore.Config
+	// Postgres configures the store of the PostgreSQL providers
+	Postgres postgres.Config
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Redis:    redisstore.DefaultConfig(),
+		Postgres: postgres.DefaultConfig(),
+	}
+}
+
+// Transports are the transports a mediator sends its events through
+type Transports []mediator.Transport
+
+// Middlewares wrap every handler of a mediator
+type Middlewares []mediator.Middleware
+
+// Options returns the mediator options of the config
+func (c Config) Options() []mediator.Option {
+	opts := []mediator.Option{
+		mediator.WithConcurrency(c.Concurrency),
+		mediator.WithMaxConcurrency(c.MaxConcurrency),
+		mediator.WithErrorStrategy(c.ErrorStrategy),
+		mediator.WithDeliveryMode(c.DeliveryMode),
+		mediator.WithHandlerTimeout(c.HandlerTimeout),
+	}
+	if c.Namespace != "" {
+		opts = append(opts, mediator.WithNamespace(c.Namespace))
+	}
+	if c.Retry != nil {
+		opts = append(opts, mediator.WithRetryPolicy(*c.Retry))
+	}
+	if c.Buffer != nil {
+		opts = append(opts, mediator.WithBufferedStore(*c.Buffer))
+	}
+	if c.Limits != (mediator.Limits{}) {
+		opts = append(opts, mediator.WithLimits(c.Limits))
+	}
+	if c.DeadLetters {
+		opts = append(opts, mediator.WithDeadLetterQueue(mediator.NewMemoryDeadLetterQueue()))
+	}
+	if c.Logger != nil {
+		opts = append(opts, mediator.WithLogger(c.Logger))
+	}
+	return opts
+}
+
+// NewMediator creates a mediator configured by config, storing events in
+// store unless it is nil
+func NewMediator(config Config, store mediator.EventStore, transports Transports, middlewares Middlewares) *mediator.Mediator {
+	opts := config.Options()
+	if store != nil {
+		opts = append(opts, mediator.WithEventStore(store))
+	}
+	if len(transports) > 0 {
+		opts = append(opts, mediator.WithTransport(transports...))
+	}
+	if len(middlewares) > 0 {
+		opts = append(opts, mediator.WithMiddleware(middlewares...))
+	}
+	return mediator.NewMediator(opts...)
+}
+
+// NewRedisStore creates the event store configured by config.Redis on client,
+// failing on an invalid configuration
+func NewRedisStore(client redis.UniversalClient, config Config) (mediator.EventStore, error) {
+	if err := config.Redis.Validate(); err != nil {
+		return nil, err
+	}
+	return redisstore.NewEventStore(client, config.Redis), nil
+}
+
+// NewPostgresStore creates the event store configured by config.Postgres on db
+func NewPostgresStore(db *sql.DB, config Config) (mediator.EventStore, error) {
+	return postgres.NewEventStore(db, config.Postgres)
+}
@@ -0,0 +1,152 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/redis/go-redis/v9"
+)
+
+// recordingTransport records the events sent through it
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []mediator.Event
+}
+
+func (t *recordingTransport) Publish(ctx context.Context, event mediator.Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+	return nil
+}
+
+func (t *recordingTransport) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	return make(chan mediator.Event), nil
+}
+
+func (t *recordingTransport) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.events)
+}
+
+func setupRedis(t *testing.T) redis.UniversalClient {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+		mr.Close()
+	})
+	return client
+}
+
+func TestNewMediator(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultConfig()
+	config.Namespace = "acme"
+	config.DeadLetters = true
+	config.Retry = &mediator.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+
+	store, err := NewRedisStore(setupRedis(t), config)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error = %v", err)
+	}
+	transport := &recordingTransport{}
+	wrapped := 0
+	middleware := func(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+		wrapped++
+		return next(ctx, event)
+	}
+
+	m := NewMediator(config, store, Transports{transport}, Middlewares{middleware})
+	defer m.Close()
+
+	attempts := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		attempts++
+		return errors.New("boom")
+	})
+	m.Publish(ctx, mediator.Event{Name: "order.placed"})
+
+	if attempts != 2 {
+		t.Errorf("handler ran %d times, want 2 with the retry policy", attempts)
+	}
+	if wrapped != 2 {
+		t.Errorf("middleware ran %d times, want 2", wrapped)
+	}
+	if transport.count() != 1 {
+		t.Errorf("transport sent %d events, want 1", transport.count())
+	}
+	if letters, err := m.DeadLetters(ctx, "order.placed", 0); err != nil || len(letters) != 1 {
+		t.Errorf("DeadLetters() = %d letters, %v; want 1", len(letters), err)
+	}
+	events, err := m.ReadEvents(ctx, "order.placed", 0)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("ReadEvents() = %d events, %v; want 1", len(events), err)
+	}
+	if events[0].Namespace != "acme" {
+		t.Errorf("stored event namespace = %q, want acme", events[0].Namespace)
+	}
+}
+
+func TestConfig_Options(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   int
+	}{
+		{name: "defaults", config: DefaultConfig(), want: 5},
+		{
+			name: "every setting",
+			config: Config{
+				Namespace:   "acme",
+				Retry:       &mediator.RetryPolicy{MaxAttempts: 3},
+				Buffer:      &mediator.BufferConfig{},
+				Limits:      mediator.Limits{MaxQueuedEvents: 10},
+				DeadLetters: true,
+				Logger:      testLogger{t},
+			},
+			want: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.config.Options()); got != tt.want {
+				t.Errorf("Options() = %d options, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStores_InvalidConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.Redis.MaxEventsPerType = -1
+	if _, err := NewRedisStore(setupRedis(t), config); err == nil {
+		t.Error("NewRedisStore() error = nil, want the invalid config reported")
+	}
+
+	config = DefaultConfig()
+	config.Postgres.TrimBatchSize = -1
+	if _, err := NewPostgresStore(nil, config); err == nil {
+		t.Error("NewPostgresStore() error = nil, want the invalid config reported")
+	}
+}
+
+// testLogger logs to a test
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Printf(format string, args ...interface{}) {
+	l.t.Logf(format, args...)
+}
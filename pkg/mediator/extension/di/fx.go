@@ -0,0 +1,60 @@
+package di
+
+import (
+	"context"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"go.uber.org/fx"
+)
+
+const (
+	// TransportGroup is the fx value group of the transports of Module's mediator
+	TransportGroup = "mediator.transports"
+	// MiddlewareGroup is the fx value group of the middleware of Module's mediator
+	MiddlewareGroup = "mediator.middlewares"
+)
+
+// Module provides the *mediator.Mediator built from the Config in the
+// container, closed when the application stops. It stores events in the
+// mediator.EventStore of the container, if any, e.g. from RedisStore or
+// PostgresStore, and uses the transports and middleware of AsTransport and
+// AsMiddleware.
+var Module = fx.Module("mediator", fx.Provide(newFxMediator))
+
+// RedisStore provides the mediator.EventStore of NewRedisStore to Module
+var RedisStore = fx.Provide(NewRedisStore)
+
+// PostgresStore provides the mediator.EventStore of NewPostgresStore to Module
+var PostgresStore = fx.Provide(NewPostgresStore)
+
+// AsTransport annotates a constructor of a mediator.Transport so Module's
+// mediator sends its events through it
+func AsTransport(constructor interface{}) interface{} {
+	return fx.Annotate(constructor, fx.As(new(mediator.Transport)), fx.ResultTags(`group:"`+TransportGroup+`"`))
+}
+
+// AsMiddleware annotates a constructor of a mediator.Middleware so it wraps
+// the handlers of Module's mediator
+func AsMiddleware(constructor interface{}) interface{} {
+	return fx.Annotate(constructor, fx.ResultTags(`group:"`+MiddlewareGroup+`"`))
+}
+
+// mediatorParams are the dependencies of Module's mediator
+type mediatorParams struct {
+	fx.In
+
+	Config      Config
+	Lifecycle   fx.Lifecycle
+	Store       mediator.EventStore   `optional:"true"`
+	Transports  []mediator.Transport  `group:"mediator.transports"`
+	Middlewares []mediator.Middleware `group:"mediator.middlewares"`
+}
+
+// newFxMediator creates Module's mediator and closes it on stop
+func newFxMediator(p mediatorParams) *mediator.Mediator {
+	m := NewMediator(p.Config, p.Store, p.Transports, p.Middlewares)
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error { return m.Close() },
+	})
+	return m
+}
@@ -0,0 +1,72 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestModule(t *testing.T) {
+	client := setupRedis(t)
+	transport := &recordingTransport{}
+	wrapped := 0
+
+	var m *mediator.Mediator
+	app := fxtest.New(t,
+		Module,
+		RedisStore,
+		fx.Supply(DefaultConfig()),
+		fx.Provide(
+			func() redis.UniversalClient { return client },
+			AsTransport(func() *recordingTransport { return transport }),
+			AsMiddleware(func() mediator.Middleware {
+				return func(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+					wrapped++
+					return next(ctx, event)
+				}
+			}),
+		),
+		fx.Populate(&m),
+	)
+	app.RequireStart()
+
+	ctx := context.Background()
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+	if err := m.Publish(ctx, mediator.Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if wrapped != 1 {
+		t.Errorf("middleware ran %d times, want 1", wrapped)
+	}
+	if transport.count() != 1 {
+		t.Errorf("transport sent %d events, want 1", transport.count())
+	}
+	if events, err := m.ReadEvents(ctx, "order.placed", 0); err != nil || len(events) != 1 {
+		t.Errorf("ReadEvents() = %d events, %v; want 1 in the Redis store", len(events), err)
+	}
+	if err := m.PublishAsync(ctx, mediator.Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+
+	// Test stopping the app closes the mediator
+	app.RequireStop()
+	if err := m.PublishAsync(ctx, mediator.Event{Name: "order.placed"}); !errors.Is(err, mediator.ErrMediatorClosed) {
+		t.Errorf("PublishAsync() after stop error = %v, want ErrMediatorClosed", err)
+	}
+}
+
+func TestModule_WithoutStore(t *testing.T) {
+	var m *mediator.Mediator
+	app := fxtest.New(t, Module, fx.Supply(DefaultConfig()), fx.Populate(&m))
+	app.RequireStart()
+	defer app.RequireStop()
+
+	if _, err := m.ReadEvents(context.Background(), "order.placed", 0); err == nil {
+		t.Error("ReadEvents() error = nil, want no event store configured")
+	}
+}
@@ -0,0 +1,31 @@
+package di
+
+import (
+	"github.com/google/wire"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// ProviderSet provides a *mediator.Mediator, closed by the injector's cleanup,
+// from a Config, a mediator.EventStore, Transports and Middlewares. Provide a
+// nil store with NoStoreSet and empty slices with wire.Value when unused.
+var ProviderSet = wire.NewSet(ProvideMediator)
+
+// RedisSet provides the mediator.EventStore of NewRedisStore
+var RedisSet = wire.NewSet(NewRedisStore)
+
+// PostgresSet provides the mediator.EventStore of NewPostgresStore
+var PostgresSet = wire.NewSet(NewPostgresStore)
+
+// NoStoreSet provides a nil mediator.EventStore, for a mediator without a store
+var NoStoreSet = wire.NewSet(NoStore)
+
+// ProvideMediator creates the mediator of NewMediator and a cleanup closing it
+func ProvideMediator(config Config, store mediator.EventStore, transports Transports, middlewares Middlewares) (*mediator.Mediator, func()) {
+	m := NewMediator(config, store, transports, middlewares)
+	return m, func() { m.Close() }
+}
+
+// NoStore returns a nil event store
+func NoStore() mediator.EventStore {
+	return nil
+}
@@ -0,0 +1,23 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestProvideMediator(t *testing.T) {
+	m, cleanup := ProvideMediator(DefaultConfig(), NoStore(), nil, nil)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+	if err := m.PublishAsync(context.Background(), mediator.Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+
+	// Test the cleanup closes the mediator
+	cleanup()
+	if err := m.PublishAsync(context.Background(), mediator.Event{Name: "order.placed"}); !errors.Is(err, mediator.ErrMediatorClosed) {
+		t.Errorf("PublishAsync() after cleanup error = %v, want ErrMediatorClosed", err)
+	}
+}
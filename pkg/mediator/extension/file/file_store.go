@@ -0,0 +1,505 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// SyncPolicy decides when writes are flushed to disk
+type SyncPolicy int
+
+const (
+	// SyncEveryWrite syncs each write before it returns, so stored events
+	// survive a crash or power loss
+	SyncEveryWrite SyncPolicy = iota
+	// SyncPeriodic syncs in the background every SyncInterval, losing at most
+	// the events of the last interval on power loss
+	SyncPeriodic
+	// SyncNone leaves flushing to the operating system; stored events survive
+	// a crash of the process, not of the machine
+	SyncNone
+)
+
+// EventStore represents an append-only event store keeping each event name
+// in a directory of JSON lines files. A directory must be used by a single
+// store at a time.
+type EventStore struct {
+	dir    string
+	config Config
+
+	mu   sync.Mutex
+	logs map[string]*eventLog
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// Config represents file event store configuration
+type Config struct {
+	// MaxFileSize rotates a file before a write would grow it past this many
+	// bytes; 0 means no limit
+	MaxFileSize int64
+	// RotateInterval rotates a file once it has been written to for this
+	// long; 0 means no limit
+	RotateInterval time.Duration
+	// Sync decides when writes are flushed to disk
+	Sync SyncPolicy
+	// SyncInterval is how often SyncPeriodic syncs
+	SyncInterval time.Duration
+	// MaxEventsPerType keeps the most recent events of each event name up to
+	// this count when Retention has no default policy, and is how many events
+	// GetEvents returns when no limit is given; 0 means no limit
+	MaxEventsPerType int64
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Retention decides which events are kept; it is applied to an event name
+	// when it is written to and to every event name by EnforceRetention
+	Retention mediator.Retention
+	// DisableTrim stops writes from applying retention; EnforceRetention still does
+	DisableTrim bool
+	// TrimInterval is the least time between trims of an event name on write;
+	// 0 trims on every write
+	TrimInterval time.Duration
+	// Logger reports background syncs that failed; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxFileSize:      16 << 20,
+		RotateInterval:   24 * time.Hour,
+		Sync:             SyncEveryWrite,
+		SyncInterval:     time.Second,
+		MaxEventsPerType: 1000,
+	}
+}
+
+// NewEventStore creates a new file event store in dir, creating it if missing
+func NewEventStore(dir string, config Config) (*EventStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if config.Sync == SyncPeriodic && config.SyncInterval <= 0 {
+		config.SyncInterval = DefaultConfig().SyncInterval
+	}
+
+	store := &EventStore{
+		dir:    dir,
+		config: config,
+		logs:   make(map[string]*eventLog),
+		done:   make(chan struct{}),
+	}
+	if config.Sync == SyncPeriodic {
+		store.wg.Add(1)
+		go store.syncLoop()
+	}
+	return store, nil
+}
+
+// log returns the log of an event name, whose directory is the escaped name
+func (s *EventStore) log(eventName string) (*eventLog, error) {
+	if eventName == "" || eventName == "." || eventName == ".." {
+		return nil, fmt.Errorf("invalid event name %q", eventName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.logs[eventName]
+	if !ok {
+		l = &eventLog{dir: filepath.Join(s.dir, url.QueryEscape(eventName))}
+		s.logs[eventName] = l
+	}
+	return l, nil
+}
+
+// StoreEvent appends an event to the file of its event name
+func (s *EventStore) StoreEvent(ctx context.Context, event mediator.Event) error {
+	return s.StoreEvents(ctx, []mediator.Event{event})
+}
+
+// StoreEvents appends several events, syncing each file written to once
+func (s *EventStore) StoreEvents(ctx context.Context, events []mediator.Event) error {
+	lines := make(map[string][][]byte)
+	var names []string
+	records := make([]*mediator.RecordBuffer, 0, len(events))
+	defer func() {
+		for _, record := range records {
+			record.Release()
+		}
+	}()
+	for _, event := range events {
+		// Default the timestamp of events stored outside Publish
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now().UTC()
+		}
+		record, err := mediator.EncodeEventRecordBuffer(s.config.Serializer, event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		records = append(records, record)
+		if _, ok := lines[event.Name]; !ok {
+			names = append(names, event.Name)
+		}
+		lines[event.Name] = append(lines[event.Name], record.Line())
+	}
+
+	for _, name := range names {
+		l, err := s.log(name)
+		if err != nil {
+			return err
+		}
+		l.mu.Lock()
+		err = l.append(lines[name], s.config)
+		if err == nil {
+			err = s.trimOnWrite(name, l)
+		}
+		l.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to store events: %w", err)
+		}
+	}
+	return nil
+}
+
+// trimOnWrite applies retention to the log of an event name that was written
+// to, unless trimming is disabled or it was trimmed less than TrimInterval ago
+func (s *EventStore) trimOnWrite(eventName string, l *eventLog) error {
+	if s.config.DisableTrim {
+		return nil
+	}
+	if s.config.TrimInterval > 0 {
+		now := time.Now()
+		if now.Sub(l.lastTrim) < s.config.TrimInterval {
+			return nil
+		}
+		l.lastTrim = now
+	}
+	return s.applyRetention(eventName, l)
+}
+
+// policy returns the retention policy of an event name; without a default
+// policy, event names are capped at MaxEventsPerType
+func (s *EventStore) policy(eventName string) mediator.RetentionPolicy {
+	if policy, ok := s.config.Retention.Events[eventName]; ok {
+		return policy
+	}
+	if s.config.Retention.Default.Forever() && s.config.MaxEventsPerType > 0 {
+		return mediator.KeepLast(s.config.MaxEventsPerType)
+	}
+	return s.config.Retention.Default
+}
+
+// applyRetention drops the files of an event name whose events its retention
+// policy no longer keeps. Files are dropped whole, so an event name keeps
+// events up to a file more than its policy asks for.
+func (s *EventStore) applyRetention(eventName string, l *eventLog) error {
+	policy := s.policy(eventName)
+	if policy.MaxAge > 0 {
+		if err := l.dropBefore(time.Now().Add(-policy.MaxAge), s.config); err != nil {
+			return err
+		}
+	}
+	if policy.MaxCount > 0 {
+		if err := l.trim(policy.MaxCount, s.config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBefore removes the files of an event name last written to before t
+func (s *EventStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	l, err := s.log(eventName)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.dropBefore(t, s.config); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return nil
+}
+
+// EnforceRetention applies the retention policy of every event name, removing
+// events that have aged out of streams no longer written to
+func (s *EventStore) EnforceRetention(ctx context.Context) error {
+	names, err := s.eventNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		l, err := s.log(name)
+		if err != nil {
+			return err
+		}
+		l.mu.Lock()
+		err = s.applyRetention(name, l)
+		l.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to enforce retention: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetEvents retrieves the most recent events of an event name, oldest first
+func (s *EventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	lines, err := s.readLast(eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(lines))
+	for _, line := range lines {
+		event, err := mediator.DecodeEventRecord(s.config.Serializer, line.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ReadEvents retrieves the most recent events of an event name as typed
+// records, oldest first
+func (s *EventStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]mediator.StoredEvent, error) {
+	lines, err := s.readLast(eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(lines)
+}
+
+// readLast returns the lines of the most recent events of an event name
+func (s *EventStore) readLast(eventName string, limit int64) ([]line, error) {
+	if limit <= 0 {
+		limit = s.config.MaxEventsPerType
+	}
+	l, err := s.log(eventName)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines, err := l.readLast(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+	return lines, nil
+}
+
+// GetEventsPage returns up to pageSize events of an event name, oldest first,
+// using the file and byte offset after an event as its cursor
+func (s *EventStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]mediator.StoredEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = mediator.DefaultPageSize
+	}
+	l, err := s.log(eventName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Read one extra event to learn whether there is a next page
+	l.mu.Lock()
+	lines, err := l.readAfter(cursor, int64(pageSize)+1)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read events: %w", err)
+	}
+
+	next := ""
+	if len(lines) > pageSize {
+		lines = lines[:pageSize]
+		next = lines[pageSize-1].offset
+	}
+
+	events, err := s.decodeStored(lines)
+	if err != nil {
+		return nil, "", err
+	}
+	return events, next, nil
+}
+
+// decodeStored decodes lines into typed events whose offset is their cursor
+func (s *EventStore) decodeStored(lines []line) ([]mediator.StoredEvent, error) {
+	events := make([]mediator.StoredEvent, 0, len(lines))
+	for _, line := range lines {
+		event, err := mediator.DecodeStoredEvent(s.config.Serializer, line.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		event.Offset = line.offset
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// eventNames returns the event names with a directory in the store
+func (s *EventStore) eventNames() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event names: %w", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name, err := url.QueryUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetStreams returns every event name with stored events and their counts and
+// first and last timestamps, ordered by name
+func (s *EventStore) GetStreams(ctx context.Context) ([]mediator.StreamInfo, error) {
+	names, err := s.eventNames()
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make([]mediator.StreamInfo, 0, len(names))
+	for _, name := range names {
+		l, err := s.log(name)
+		if err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		stream, err := streamInfo(name, l)
+		l.mu.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stream %s: %w", name, err)
+		}
+		if stream.Count > 0 {
+			streams = append(streams, stream)
+		}
+	}
+	return streams, nil
+}
+
+// streamInfo describes the log of an event name, decoding only the
+// timestamps of its first and last events
+func streamInfo(eventName string, l *eventLog) (mediator.StreamInfo, error) {
+	stream := mediator.StreamInfo{Name: eventName}
+	if err := l.load(); err != nil {
+		return stream, err
+	}
+	for _, seg := range l.segments {
+		stream.Count += seg.count
+	}
+	if stream.Count == 0 {
+		return stream, nil
+	}
+
+	first, err := l.read(0, 0, 0, 1)
+	if err != nil {
+		return stream, err
+	}
+	last, err := l.read(0, 0, stream.Count-1, 1)
+	if err != nil {
+		return stream, err
+	}
+	if stream.FirstEvent, err = timestamp(first[0].data); err != nil {
+		return stream, err
+	}
+	if stream.LastEvent, err = timestamp(last[0].data); err != nil {
+		return stream, err
+	}
+	return stream, nil
+}
+
+// timestamp decodes the timestamp of an encoded event
+func timestamp(data []byte) (time.Time, error) {
+	var record struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return time.Time{}, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	return record.Timestamp, nil
+}
+
+// ClearEvents removes all events for a given event name
+func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
+	l, err := s.log(eventName)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.clear(s.config); err != nil {
+		return fmt.Errorf("failed to clear events: %w", err)
+	}
+	return nil
+}
+
+// syncLoop syncs the files written to every SyncInterval until Close
+func (s *EventStore) syncLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			for _, l := range s.snapshot() {
+				l.mu.Lock()
+				err := l.sync()
+				l.mu.Unlock()
+				if err != nil && s.config.Logger != nil {
+					s.config.Logger.Printf("file store: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// snapshot returns the logs opened so far
+func (s *EventStore) snapshot() []*eventLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logs := make([]*eventLog, 0, len(s.logs))
+	for _, l := range s.logs {
+		logs = append(logs, l)
+	}
+	return logs
+}
+
+// Close stops background syncing, then syncs and closes the open files
+func (s *EventStore) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+
+		for _, l := range s.snapshot() {
+			l.mu.Lock()
+			if closeErr := l.closeActive(s.config); err == nil {
+				err = closeErr
+			}
+			l.mu.Unlock()
+		}
+	})
+	return err
+}
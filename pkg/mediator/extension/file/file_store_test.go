@@ -0,0 +1,380 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"github.com/mandocaesar/mediator/pkg/mediator/storetest"
+)
+
+// setupTestStore creates a store in a temporary directory
+func setupTestStore(t *testing.T, dir string, config Config) *EventStore {
+	t.Helper()
+	store, err := NewEventStore(dir, config)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// storeEvents stores count events of an event name with ids evt-0 onwards
+func storeEvents(t *testing.T, store *EventStore, eventName string, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		event := mediator.Event{Name: eventName, ID: fmt.Sprintf("evt-%d", i), Payload: float64(i)}
+		if err := store.StoreEvent(context.Background(), event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+}
+
+// eventIDs returns the ids of events, in order
+func eventIDs(events []mediator.StoredEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestEventStore(t *testing.T) {
+	store := setupTestStore(t, t.TempDir(), DefaultConfig())
+	var _ mediator.EventStore = store
+	var _ mediator.EventStoreV2 = store
+	var _ mediator.BatchEventStore = store
+	var _ mediator.PagedEventStore = store
+	var _ mediator.CatalogEventStore = store
+	var _ mediator.CompactingEventStore = store
+	var _ mediator.RetentionEnforcer = store
+
+	ctx := context.Background()
+	event := mediator.Event{
+		Name:     "product.created",
+		ID:       "evt-1",
+		Payload:  map[string]interface{}{"id": "p-1"},
+		Metadata: map[string]string{"source": "test"},
+	}
+	if err := store.StoreEvent(ctx, event); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	records, err := store.GetEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(records) != 1 || records[0]["id"] != "evt-1" {
+		t.Errorf("Expected evt-1, got %v", records)
+	}
+
+	events, err := store.ReadEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 || events[0].Metadata["source"] != "test" || string(events[0].RawPayload) != `{"id":"p-1"}` {
+		t.Errorf("Expected the envelope of evt-1, got %+v", events)
+	}
+
+	if err := store.ClearEvents(ctx, "product.created"); err != nil {
+		t.Fatalf("Failed to clear events: %v", err)
+	}
+	if events, _ := store.ReadEvents(ctx, "product.created", 10); len(events) != 0 {
+		t.Errorf("Expected no events after clear, got %d", len(events))
+	}
+
+	// Test names that are not valid file names are escaped
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "acme:orders/placed", ID: "evt-2"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+	if events, _ := store.ReadEvents(ctx, "acme:orders/placed", 10); len(events) != 1 {
+		t.Errorf("Expected 1 escaped event, got %d", len(events))
+	}
+	if err := store.StoreEvent(ctx, mediator.Event{Name: ".."}); err == nil {
+		t.Error("Expected an error storing an event named ..")
+	}
+}
+
+func TestEventStore_ReadEvents(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxFileSize = 1 // one event per file
+	store := setupTestStore(t, t.TempDir(), config)
+	storeEvents(t, store, "order.placed", 5)
+
+	tests := []struct {
+		name  string
+		limit int64
+		want  []string
+	}{
+		{"most recent", 2, []string{"evt-3", "evt-4"}},
+		{"more than stored", 10, []string{"evt-0", "evt-1", "evt-2", "evt-3", "evt-4"}},
+		{"default limit", 0, []string{"evt-0", "evt-1", "evt-2", "evt-3", "evt-4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.ReadEvents(context.Background(), "order.placed", tt.limit)
+			if err != nil {
+				t.Fatalf("Failed to read events: %v", err)
+			}
+			if got := eventIDs(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventStore_GetEventsPage(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxFileSize = 300 // a few events per file
+	store := setupTestStore(t, t.TempDir(), config)
+	storeEvents(t, store, "order.placed", 10)
+	ctx := context.Background()
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatal("Expected pagination to end after 4 pages")
+		}
+		page, next, err := store.GetEventsPage(ctx, "order.placed", cursor, 3)
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		ids = append(ids, eventIDs(page)...)
+		if next == "" {
+			break
+		}
+		if next != page[len(page)-1].Offset {
+			t.Errorf("Expected the next cursor %s to be the offset of the last event", next)
+		}
+		cursor = next
+	}
+
+	want := []string{"evt-0", "evt-1", "evt-2", "evt-3", "evt-4", "evt-5", "evt-6", "evt-7", "evt-8", "evt-9"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected every event once, got %v", ids)
+	}
+
+	if _, _, err := store.GetEventsPage(ctx, "order.placed", "bogus", 3); err == nil {
+		t.Error("Expected an error for an invalid cursor")
+	}
+}
+
+func TestEventStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	store := setupTestStore(t, dir, DefaultConfig())
+	storeEvents(t, store, "order.placed", 2)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Failed to close store: %v", err)
+	}
+
+	// Simulate a crash tearing the last write of the previous process
+	segmentPath := filepath.Join(dir, "order.placed", segmentName(1))
+	f, err := os.OpenFile(segmentPath, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Failed to open segment: %v", err)
+	}
+	f.WriteString(`{"id":"torn`)
+	f.Close()
+
+	reopened := setupTestStore(t, dir, DefaultConfig())
+	ctx := context.Background()
+	if err := reopened.StoreEvent(ctx, mediator.Event{Name: "order.placed", ID: "evt-2"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	events, err := reopened.ReadEvents(ctx, "order.placed", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if got := eventIDs(events); !reflect.DeepEqual(got, []string{"evt-0", "evt-1", "evt-2"}) {
+		t.Errorf("Expected the torn line to be skipped, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "order.placed", segmentName(2))); err != nil {
+		t.Errorf("Expected a new segment for the new process: %v", err)
+	}
+}
+
+func TestEventStore_GetStreams(t *testing.T) {
+	store := setupTestStore(t, t.TempDir(), DefaultConfig())
+	ctx := context.Background()
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []mediator.Event{
+		{Name: "order.placed", ID: "evt-1", Timestamp: first},
+		{Name: "order.placed", ID: "evt-2", Timestamp: first.Add(time.Hour)},
+		{Name: "acme:user.created", ID: "evt-3", Timestamp: first},
+	}
+	if err := store.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+	if err := store.ClearEvents(ctx, "acme:user.created"); err != nil {
+		t.Fatalf("Failed to clear events: %v", err)
+	}
+
+	streams, err := store.GetStreams(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	want := []mediator.StreamInfo{
+		{Name: "order.placed", Count: 2, FirstEvent: first, LastEvent: first.Add(time.Hour)},
+	}
+	if !reflect.DeepEqual(streams, want) {
+		t.Errorf("Expected streams %+v, got %+v", want, streams)
+	}
+}
+
+func TestEventStore_Retention(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy mediator.RetentionPolicy
+		want   []string
+	}{
+		{"max count", mediator.KeepLast(3), []string{"evt-3", "evt-4", "evt-5"}},
+		{"forever", mediator.KeepForever(), []string{"evt-0", "evt-1", "evt-2", "evt-3", "evt-4", "evt-5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.MaxEventsPerType = 0
+			config.MaxFileSize = 1
+			config.Retention = mediator.Retention{Default: tt.policy}
+			store := setupTestStore(t, t.TempDir(), config)
+			storeEvents(t, store, "retained", 6)
+
+			events, err := store.ReadEvents(context.Background(), "retained", 0)
+			if err != nil {
+				t.Fatalf("Failed to read events: %v", err)
+			}
+			if got := eventIDs(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventStore_DeleteBefore(t *testing.T) {
+	dir := t.TempDir()
+	config := DefaultConfig()
+	config.MaxFileSize = 1
+	config.DisableTrim = true
+	config.Retention = mediator.Retention{Default: mediator.KeepFor(time.Hour)}
+	store := setupTestStore(t, dir, config)
+	storeEvents(t, store, "aged", 3)
+
+	// Age the file of evt-0
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "aged", segmentName(1)), old, old); err != nil {
+		t.Fatalf("Failed to age segment: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.EnforceRetention(ctx); err != nil {
+		t.Fatalf("Failed to enforce retention: %v", err)
+	}
+	events, _ := store.ReadEvents(ctx, "aged", 10)
+	if got := eventIDs(events); !reflect.DeepEqual(got, []string{"evt-1", "evt-2"}) {
+		t.Errorf("Expected the aged file to be dropped, got %v", got)
+	}
+
+	if err := store.DeleteBefore(ctx, "aged", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to delete events: %v", err)
+	}
+	if events, _ := store.ReadEvents(ctx, "aged", 10); len(events) != 0 {
+		t.Errorf("Expected no events after DeleteBefore, got %v", eventIDs(events))
+	}
+
+	// Test cursors into dropped files resume at the file written next
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "aged", ID: "evt-3"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+	page, _, err := store.GetEventsPage(ctx, "aged", formatCursor(3, 1<<20), 10)
+	if got := eventIDs(page); err != nil || !reflect.DeepEqual(got, []string{"evt-3"}) {
+		t.Errorf("GetEventsPage() = %v, %v, want evt-3", got, err)
+	}
+}
+
+func TestEventStore_Sync(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SyncPolicy
+	}{
+		{"every write", SyncEveryWrite},
+		{"periodic", SyncPeriodic},
+		{"none", SyncNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := DefaultConfig()
+			config.Sync = tt.policy
+			config.SyncInterval = 10 * time.Millisecond
+			store, err := NewEventStore(dir, config)
+			if err != nil {
+				t.Fatalf("Failed to create store: %v", err)
+			}
+			storeEvents(t, store, "synced", 3)
+			time.Sleep(20 * time.Millisecond)
+			if err := store.Close(); err != nil {
+				t.Fatalf("Failed to close store: %v", err)
+			}
+
+			reopened := setupTestStore(t, dir, config)
+			if events, _ := reopened.ReadEvents(context.Background(), "synced", 10); len(events) != 3 {
+				t.Errorf("Expected 3 events after reopening, got %d", len(events))
+			}
+		})
+	}
+}
+
+func TestEventStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, retention mediator.Retention) mediator.EventStore {
+		// Retention drops whole files; a file per event makes it exact
+		config := DefaultConfig()
+		config.MaxFileSize = 1
+		config.Retention = retention
+		return setupTestStore(t, t.TempDir(), config)
+	})
+}
+
+func TestDeliveryGuarantees(t *testing.T) {
+	// Every restart reopens the same directory
+	dir := t.TempDir()
+
+	config := deliverytest.DefaultConfig()
+	config.Backend = "file"
+	config.Claims = deliverytest.AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) deliverytest.System {
+		store, err := NewEventStore(dir, DefaultConfig())
+		if err != nil {
+			t.Fatalf("Failed to create store: %v", err)
+		}
+		dedupe := mediator.NewStoreDedupeStore(store, 1000)
+
+		m := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithEventStore(store), mediator.WithDeduplication(dedupe))
+		return deliverytest.System{
+			Publish: m.Publish,
+			Subscribe: func(eventName string, handler mediator.EventHandler) {
+				m.Subscribe(eventName, handler)
+			},
+			Store:  store,
+			Dedupe: dedupe,
+			Close:  store.Close,
+		}
+	}
+
+	report := deliverytest.Run(t, config)
+	var buf bytes.Buffer
+	report.WriteMarkdown(&buf)
+	t.Logf("conformance report:\n%s", buf.String())
+}
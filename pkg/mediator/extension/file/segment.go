@@ -0,0 +1,446 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// segmentExt is the extension of segment files
+const segmentExt = ".jsonl"
+
+// segment is a file of an event log, named by its sequence number
+type segment struct {
+	seq int64
+	// count is the number of complete lines, i.e. events, in the file
+	count int64
+}
+
+// line is an encoded event and the cursor after it
+type line struct {
+	data   []byte
+	offset string
+}
+
+// eventLog is the directory of segment files of one event name. Events are
+// appended to the active segment, the last one, which this process creates
+// on its first write: a line torn by a crash stays at the end of an earlier
+// segment, where reads skip it, and never runs into a later event.
+type eventLog struct {
+	mu  sync.Mutex
+	dir string
+
+	loaded   bool
+	segments []segment
+	// lastSeq is the highest sequence number used, so that dropping every
+	// segment doesn't reuse the numbers of cursors handed out
+	lastSeq int64
+
+	active *os.File
+	// size is the size of the active segment and opened when it was created
+	size   int64
+	opened time.Time
+	// dirty is set while the active segment has writes not yet synced
+	dirty bool
+
+	lastTrim time.Time
+}
+
+// segmentName returns the file name of segment seq; zero padding keeps
+// directory listings in sequence order
+func segmentName(seq int64) string {
+	return fmt.Sprintf("%020d%s", seq, segmentExt)
+}
+
+// path returns the path of segment seq
+func (l *eventLog) path(seq int64) string {
+	return filepath.Join(l.dir, segmentName(seq))
+}
+
+// load lists the segments of the log and counts their events, once
+func (l *eventLog) load() error {
+	if l.loaded {
+		return nil
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to list segments: %w", err)
+	}
+	segments := make([]segment, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := countLines(l.path(seq))
+		if err != nil {
+			return err
+		}
+		segments = append(segments, segment{seq: seq, count: count})
+		if seq > l.lastSeq {
+			l.lastSeq = seq
+		}
+	}
+
+	l.segments = segments
+	l.loaded = true
+	return nil
+}
+
+// countLines counts the complete lines of a file
+func countLines(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer f.Close()
+
+	var count int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		count += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read segment: %w", err)
+		}
+	}
+}
+
+// append writes events, each encoded on a line ending in a newline, to the
+// active segment, rotating it as config requires
+func (l *eventLog) append(lines [][]byte, config Config) error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	for _, data := range lines {
+		if l.active == nil || l.rotateDue(int64(len(data)), config) {
+			if err := l.rotate(config); err != nil {
+				return err
+			}
+		}
+		if _, err := l.active.Write(data); err != nil {
+			// Leave any partial line at the end of the segment, where reads skip it
+			l.closeActive(config)
+			return fmt.Errorf("failed to write event: %w", err)
+		}
+		l.size += int64(len(data))
+		l.segments[len(l.segments)-1].count++
+		l.dirty = true
+	}
+
+	if config.Sync == SyncEveryWrite {
+		return l.sync()
+	}
+	return nil
+}
+
+// rotateDue reports whether the active segment is full or old enough to be
+// rotated before n more bytes are written to it
+func (l *eventLog) rotateDue(n int64, config Config) bool {
+	if config.MaxFileSize > 0 && l.size > 0 && l.size+n > config.MaxFileSize {
+		return true
+	}
+	return config.RotateInterval > 0 && time.Since(l.opened) >= config.RotateInterval
+}
+
+// rotate closes the active segment and creates the next one
+func (l *eventLog) rotate(config Config) error {
+	if err := l.closeActive(config); err != nil {
+		return err
+	}
+
+	err := os.Mkdir(l.dir, 0o755)
+	created := err == nil
+	if err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	seq := l.lastSeq + 1
+	f, err := os.OpenFile(l.path(seq), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create segment: %w", err)
+	}
+
+	// Sync the new directory entries so the segment survives a crash
+	if config.Sync != SyncNone {
+		if created {
+			err = syncDir(filepath.Dir(l.dir))
+		}
+		if err == nil {
+			err = syncDir(l.dir)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	l.active, l.size, l.opened = f, 0, time.Now()
+	l.segments = append(l.segments, segment{seq: seq})
+	l.lastSeq = seq
+	return nil
+}
+
+// sync flushes the writes to the active segment to disk
+func (l *eventLog) sync() error {
+	if l.active == nil || !l.dirty {
+		return nil
+	}
+	if err := l.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync segment: %w", err)
+	}
+	l.dirty = false
+	return nil
+}
+
+// closeActive syncs, unless config disables syncing, and closes the active
+// segment, so the next write creates a new one
+func (l *eventLog) closeActive(config Config) error {
+	if l.active == nil {
+		return nil
+	}
+
+	var err error
+	if config.Sync != SyncNone {
+		err = l.sync()
+	}
+	if closeErr := l.active.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close segment: %w", closeErr)
+	}
+	l.active, l.dirty = nil, false
+	return err
+}
+
+// syncDir syncs a directory, persisting the files created in it. Windows
+// cannot sync directories, and persists their entries itself.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
+
+// readLast returns the limit most recent events of the log, oldest first;
+// every event when limit <= 0
+func (l *eventLog) readLast(limit int64) ([]line, error) {
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	// Start at the segment holding the oldest of the events, using the counts
+	first, skip := 0, int64(0)
+	if limit > 0 {
+		var total int64
+		first = len(l.segments)
+		for first > 0 && total < limit {
+			first--
+			total += l.segments[first].count
+		}
+		if total > limit {
+			skip = total - limit
+		}
+	}
+	return l.read(first, 0, skip, 0)
+}
+
+// readAfter returns up to max events of the log stored after cursor
+func (l *eventLog) readAfter(cursor string, max int64) ([]line, error) {
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	var seq, from int64
+	if cursor != "" {
+		var err error
+		if seq, from, err = parseCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// The segment of the cursor may have been dropped by retention since
+	first := 0
+	for first < len(l.segments) && l.segments[first].seq < seq {
+		first++
+	}
+	if first < len(l.segments) && l.segments[first].seq != seq {
+		from = 0
+	}
+	return l.read(first, from, 0, max)
+}
+
+// read returns the events from byte offset from of segment first onwards,
+// skipping skip events and stopping after max unless max <= 0
+func (l *eventLog) read(first int, from, skip, max int64) ([]line, error) {
+	var lines []line
+	for i := first; i < len(l.segments); i++ {
+		seg := l.segments[i]
+		if skip >= seg.count && from == 0 {
+			skip -= seg.count
+			continue
+		}
+
+		err := l.scan(seg.seq, from, func(data []byte, offset string) bool {
+			if skip > 0 {
+				skip--
+				return true
+			}
+			lines = append(lines, line{data: data, offset: offset})
+			return max <= 0 || int64(len(lines)) < max
+		})
+		if err != nil {
+			return nil, err
+		}
+		if max > 0 && int64(len(lines)) >= max {
+			break
+		}
+		from = 0
+	}
+	return lines, nil
+}
+
+// scan calls fn with each complete line of segment seq from byte offset from,
+// without its newline, until fn returns false
+func (l *eventLog) scan(seq, from int64, fn func(data []byte, offset string) bool) error {
+	f, err := os.Open(l.path(seq))
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer f.Close()
+
+	if from > 0 {
+		if _, err := f.Seek(from, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek segment: %w", err)
+		}
+	}
+
+	reader := bufio.NewReader(f)
+	offset := from
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// A line without a newline was torn by a crash
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read segment: %w", err)
+		}
+		offset += int64(len(data))
+		if !fn(data[:len(data)-1], formatCursor(seq, offset)) {
+			return nil
+		}
+	}
+}
+
+// drop removes the n oldest segments of the log
+func (l *eventLog) drop(n int, config Config) error {
+	for i := 0; i < n; i++ {
+		if i == len(l.segments)-1 {
+			if err := l.closeActive(config); err != nil {
+				return err
+			}
+		}
+		if err := os.Remove(l.path(l.segments[i].seq)); err != nil && !os.IsNotExist(err) {
+			l.segments = l.segments[i:]
+			return fmt.Errorf("failed to remove segment: %w", err)
+		}
+	}
+	l.segments = l.segments[n:]
+	return nil
+}
+
+// trim drops the oldest segments while the rest hold at least maxCount events
+func (l *eventLog) trim(maxCount int64, config Config) error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	var total int64
+	for _, seg := range l.segments {
+		total += seg.count
+	}
+	n := 0
+	for n < len(l.segments) && total-l.segments[n].count >= maxCount {
+		total -= l.segments[n].count
+		n++
+	}
+	return l.drop(n, config)
+}
+
+// dropBefore drops the oldest segments last written to before t
+func (l *eventLog) dropBefore(t time.Time, config Config) error {
+	if err := l.load(); err != nil {
+		return err
+	}
+
+	n := 0
+	for ; n < len(l.segments); n++ {
+		info, err := os.Stat(l.path(l.segments[n].seq))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat segment: %w", err)
+		}
+		if !info.ModTime().Before(t) {
+			break
+		}
+	}
+	return l.drop(n, config)
+}
+
+// clear removes the directory of the log
+func (l *eventLog) clear(config Config) error {
+	if err := l.closeActive(config); err != nil {
+		return err
+	}
+	l.loaded, l.segments = false, nil
+	if err := os.RemoveAll(l.dir); err != nil {
+		return fmt.Errorf("failed to remove log directory: %w", err)
+	}
+	return nil
+}
+
+// formatCursor returns the cursor of byte offset of segment seq
+func formatCursor(seq, offset int64) string {
+	return fmt.Sprintf("%d:%d", seq, offset)
+}
+
+// parseCursor parses a cursor made by formatCursor
+func parseCursor(cursor string) (seq, offset int64, err error) {
+	seqPart, offsetPart, ok := strings.Cut(cursor, ":")
+	if ok {
+		seq, err = strconv.ParseInt(seqPart, 10, 64)
+	}
+	if ok && err == nil {
+		offset, err = strconv.ParseInt(offsetPart, 10, 64)
+	}
+	if !ok || err != nil || seq < 0 || offset < 0 {
+		return 0, 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return seq, offset, nil
+}
@@ -0,0 +1,112 @@
+package file
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseCursor(t *testing.T) {
+	tests := []struct {
+		cursor  string
+		seq     int64
+		offset  int64
+		wantErr bool
+	}{
+		{cursor: formatCursor(3, 120), seq: 3, offset: 120},
+		{cursor: "0:0"},
+		{cursor: "12", wantErr: true},
+		{cursor: "a:1", wantErr: true},
+		{cursor: "1:-5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cursor, func(t *testing.T) {
+			seq, offset, err := parseCursor(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCursor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if seq != tt.seq || offset != tt.offset {
+				t.Errorf("parseCursor() = %d, %d, want %d, %d", seq, offset, tt.seq, tt.offset)
+			}
+		})
+	}
+}
+
+func TestEventLog_Rotate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []int64
+	}{
+		{"no limits", Config{Sync: SyncNone}, []int64{4}},
+		{"max file size", Config{Sync: SyncNone, MaxFileSize: 6}, []int64{3, 1}},
+		{"line larger than max", Config{Sync: SyncNone, MaxFileSize: 2}, []int64{1, 1, 1, 1}},
+		{"rotate interval", Config{Sync: SyncNone, RotateInterval: time.Nanosecond}, []int64{1, 1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &eventLog{dir: t.TempDir()}
+			defer l.closeActive(tt.config)
+			lines := [][]byte{[]byte("a\n"), []byte("b\n"), []byte("c\n"), []byte("d\n")}
+			for _, line := range lines {
+				time.Sleep(time.Millisecond)
+				if err := l.append([][]byte{line}, tt.config); err != nil {
+					t.Fatalf("Failed to append: %v", err)
+				}
+			}
+
+			var counts []int64
+			for _, seg := range l.segments {
+				counts = append(counts, seg.count)
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("Expected segments of %v events, got %v", tt.want, counts)
+			}
+
+			entries, _ := os.ReadDir(l.dir)
+			if len(entries) != len(tt.want) {
+				t.Errorf("Expected %d files, got %d", len(tt.want), len(entries))
+			}
+		})
+	}
+}
+
+func TestEventLog_Trim(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxCount int64
+		want     []int64
+	}{
+		// Whole files are dropped, keeping the file of the oldest event kept
+		{"within a file", 3, []int64{2, 2}},
+		{"file boundary", 4, []int64{2, 2}},
+		{"more than stored", 10, []int64{2, 2, 2}},
+		{"latest only", 1, []int64{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Sync: SyncNone, MaxFileSize: 4}
+			l := &eventLog{dir: t.TempDir()}
+			defer l.closeActive(config)
+			lines := [][]byte{[]byte("a\n"), []byte("b\n"), []byte("c\n"), []byte("d\n"), []byte("e\n"), []byte("f\n")}
+			if err := l.append(lines, config); err != nil {
+				t.Fatalf("Failed to append: %v", err)
+			}
+
+			if err := l.trim(tt.maxCount, config); err != nil {
+				t.Fatalf("Failed to trim: %v", err)
+			}
+			var counts []int64
+			for _, seg := range l.segments {
+				counts = append(counts, seg.count)
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("Expected segments of %v events, got %v", tt.want, counts)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package gcppubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SubscriberConfig configures a Subscriber
+type SubscriberConfig struct {
+	// Name names the subscriptions of the subscriber, each the name followed
+	// by '.' and a topic ID; the instances of a service share them, each
+	// message going to one of them
+	Name string
+	// Events are the event names subscribed to
+	Events []string
+	// TopicPrefix is prepended to event names to form their topic IDs
+	TopicPrefix string
+	// CreateSubscriptions creates the missing subscriptions, and their
+	// topics; subscriptions must exist otherwise
+	CreateSubscriptions bool
+	// Ordered creates subscriptions delivering the events sharing an
+	// ordering key in order
+	Ordered bool
+	// AckDeadline is the ack deadline of the subscriptions created
+	AckDeadline time.Duration
+	// MaxOutstandingMessages bounds the messages being handled at once per
+	// subscription; negative for no limit
+	MaxOutstandingMessages int
+	// MaxOutstandingBytes bounds the size of the messages being handled at
+	// once per subscription; negative for no limit
+	MaxOutstandingBytes int
+	// NumGoroutines is the number of streams pulling each subscription
+	NumGoroutines int
+	// MaxExtension bounds how long the ack deadline of a message being
+	// handled is extended
+	MaxExtension time.Duration
+	// Origin identifies this instance, whose own events are acknowledged
+	// without being dispatched; the random ID of the process's transports is
+	// used when empty
+	Origin string
+	// Serializer decodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be decoded or dispatched;
+	// nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultSubscriberConfig returns default subscriber configuration
+func DefaultSubscriberConfig() SubscriberConfig {
+	return SubscriberConfig{
+		TopicPrefix:            "mediator.",
+		CreateSubscriptions:    true,
+		AckDeadline:            30 * time.Second,
+		MaxOutstandingMessages: 100,
+		MaxOutstandingBytes:    64 << 20,
+		NumGoroutines:          1,
+		MaxExtension:           10 * time.Minute,
+	}
+}
+
+// Subscriber feeds the events of Google Cloud Pub/Sub subscriptions into a
+// mediator, acknowledging each message once its handlers succeeded
+type Subscriber struct {
+	client   *pubsub.Client
+	mediator *mediator.Mediator
+	config   SubscriberConfig
+}
+
+// NewSubscriber creates a subscriber dispatching events into m
+func NewSubscriber(client *pubsub.Client, m *mediator.Mediator, config SubscriberConfig) *Subscriber {
+	if config.Origin == "" {
+		config.Origin = processOrigin
+	}
+	return &Subscriber{client: client, mediator: m, config: config}
+}
+
+// SubscriptionID returns the ID of the subscription to an event name
+func (s *Subscriber) SubscriptionID(eventName string) string {
+	return s.config.Name + "." + TopicID(s.config.TopicPrefix, eventName)
+}
+
+// Run receives the messages of the subscriptions of the event names and
+// dispatches them until ctx is cancelled. Messages whose handlers fail,
+// after the mediator's retries, are nacked for redelivery.
+func (s *Subscriber) Run(ctx context.Context) error {
+	if s.config.Name == "" {
+		return fmt.Errorf("a subscription name is required")
+	}
+	if len(s.config.Events) == 0 {
+		return fmt.Errorf("at least one event name is required")
+	}
+
+	subs := make([]*pubsub.Subscription, 0, len(s.config.Events))
+	for _, name := range s.config.Events {
+		sub, err := s.subscription(ctx, name)
+		if err != nil {
+			return err
+		}
+		subs = append(subs, sub)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, len(subs))
+	var wg sync.WaitGroup
+	for i, sub := range subs {
+		wg.Add(1)
+		go func(i int, sub *pubsub.Subscription) {
+			defer wg.Done()
+			if err := sub.Receive(ctx, s.handle); err != nil {
+				errs[i] = fmt.Errorf("failed to receive from %s: %w", sub.ID(), err)
+				// Stop the other subscriptions with the failed one
+				cancel()
+			}
+		}(i, sub)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// subscription returns the subscription to an event name with the flow
+// control settings, creating it with CreateSubscriptions
+func (s *Subscriber) subscription(ctx context.Context, eventName string) (*pubsub.Subscription, error) {
+	id := s.SubscriptionID(eventName)
+	sub := s.client.Subscription(id)
+
+	if s.config.CreateSubscriptions {
+		topicID := TopicID(s.config.TopicPrefix, eventName)
+		topic, err := s.client.CreateTopic(ctx, topicID)
+		if status.Code(err) == codes.AlreadyExists {
+			topic, err = s.client.Topic(topicID), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create topic %s: %w", topicID, err)
+		}
+
+		created, err := s.client.CreateSubscription(ctx, id, pubsub.SubscriptionConfig{
+			Topic:                 topic,
+			AckDeadline:           s.config.AckDeadline,
+			EnableMessageOrdering: s.config.Ordered,
+		})
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return nil, fmt.Errorf("failed to create subscription %s: %w", id, err)
+		}
+		if err == nil {
+			sub = created
+		}
+	}
+
+	sub.ReceiveSettings.MaxOutstandingMessages = s.config.MaxOutstandingMessages
+	sub.ReceiveSettings.MaxOutstandingBytes = s.config.MaxOutstandingBytes
+	sub.ReceiveSettings.NumGoroutines = s.config.NumGoroutines
+	sub.ReceiveSettings.MaxExtension = s.config.MaxExtension
+	return sub, nil
+}
+
+// handle dispatches the event of a message, then acknowledges it, or nacks
+// it when its handlers fail
+func (s *Subscriber) handle(ctx context.Context, msg *pubsub.Message) {
+	if msg.Attributes[OriginAttribute] == s.config.Origin {
+		msg.Ack()
+		return
+	}
+
+	stored, err := mediator.DecodeStoredEvent(s.config.Serializer, msg.Data)
+	if err != nil {
+		// Redelivering a message that is not an event would fail again
+		msg.Ack()
+		s.logf("failed to unmarshal message %s: %v", msg.ID, err)
+		return
+	}
+
+	err = s.mediator.DispatchRemote(ctx, stored.Event())
+	if err != nil {
+		msg.Nack()
+		s.logf("failed to dispatch event %s: %v", stored.ID, err)
+		return
+	}
+	msg.Ack()
+}
+
+// logf reports a message to the Logger
+func (s *Subscriber) logf(format string, args ...interface{}) {
+	if s.config.Logger != nil {
+		s.config.Logger.Printf("pubsub subscriber: "+format, args...)
+	}
+}
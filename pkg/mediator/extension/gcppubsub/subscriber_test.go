@@ -0,0 +1,118 @@
+package gcppubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestSubscriber(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	// The billing service subscribes to the events of the orders service
+	billing := mediator.NewMediator()
+	received := make(chan mediator.Event, 10)
+	var mu sync.Mutex
+	attempts := 0
+	billing.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		received <- event
+		if event.ID == "evt-fail" {
+			mu.Lock()
+			defer mu.Unlock()
+			// Fail the first delivery only
+			if attempts++; attempts == 1 {
+				return errors.New("handler failed")
+			}
+		}
+		return nil
+	})
+
+	config := DefaultSubscriberConfig()
+	config.Name = "billing"
+	config.Events = []string{"order.placed"}
+	config.Origin = "billing"
+	subscriber := NewSubscriber(client, billing, config)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		done <- subscriber.Run(runCtx)
+	}()
+
+	// Wait for the subscription before publishing
+	sub := client.Subscription(subscriber.SubscriptionID("order.placed"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if exists, _ := sub.Exists(ctx); exists {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	transportConfig := DefaultTransportConfig()
+	transportConfig.Origin = "orders"
+	orders := NewTransport(client, transportConfig)
+	defer orders.Close()
+	own := NewTransport(client, TransportConfig{TopicPrefix: "mediator.", Origin: "billing"})
+	defer own.Close()
+
+	for _, publish := range []func() error{
+		func() error { return own.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-own"}) },
+		func() error { return orders.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-fail"}) },
+		func() error { return orders.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-ok"}) },
+	} {
+		if err := publish(); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// Test the other service's events are dispatched, marked remote, and the
+	// failed one redelivered
+	counts := make(map[string]int)
+	for counts["evt-ok"] < 1 || counts["evt-fail"] < 2 {
+		select {
+		case event := <-received:
+			if !mediator.IsRemote(event) {
+				t.Errorf("Expected %s to be remote", event.ID)
+			}
+			counts[event.ID]++
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for events, got %v", counts)
+		}
+	}
+	if counts["evt-own"] != 0 {
+		t.Errorf("Expected own event to be skipped, got %v", counts)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestSubscriber_Run_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SubscriberConfig
+	}{
+		{"no name", SubscriberConfig{Events: []string{"order.placed"}}},
+		{"no events", SubscriberConfig{Name: "billing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscriber := NewSubscriber(nil, mediator.NewMediator(), tt.config)
+			if err := subscriber.Run(context.Background()); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
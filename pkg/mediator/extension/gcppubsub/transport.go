@@ -0,0 +1,317 @@
+package gcppubsub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Attributes set on the messages of events
+const (
+	EventNameAttribute = "mediator-event-name"
+	EventIDAttribute   = "mediator-event-id"
+	OriginAttribute    = "mediator-origin"
+)
+
+// processOrigin is the Origin of transports and subscribers configured
+// without one, so those of a process skip its own events
+var processOrigin = randomID()
+
+// randomID returns a random hex ID
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// TopicID returns the ID of the topic of an event name: prefix followed by
+// the name, with the characters topic IDs don't allow replaced by '.'
+func TopicID(prefix, eventName string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("-_.~+%", r):
+			return r
+		}
+		return '.'
+	}, prefix+eventName)
+}
+
+// TransportConfig configures a Transport
+type TransportConfig struct {
+	// TopicPrefix is prepended to event names to form their topic IDs
+	TopicPrefix string
+	// CreateTopics creates the topic of an event name the first time one is
+	// published or subscribed to; topics must exist otherwise
+	CreateTopics bool
+	// OrderingKey names the payload field whose value orders the events
+	// sharing it; events are published unordered when empty or when their
+	// payload has no such field
+	OrderingKey string
+	// Events limits the events published to these names; every event when empty
+	Events []string
+	// Expiration is how long the subscriptions of a transport last without
+	// subscribers, deleting those of instances that stopped without closing
+	Expiration time.Duration
+	// Buffer is how many events a subscription buffers for the mediator
+	Buffer int
+	// Origin identifies this instance, whose own events its subscribers skip;
+	// a random ID shared by the process is used when empty
+	Origin string
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be decoded and subscriptions
+	// that could not be deleted; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultTransportConfig returns default transport configuration
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		TopicPrefix:  "mediator.",
+		CreateTopics: true,
+		Expiration:   24 * time.Hour,
+		Buffer:       64,
+	}
+}
+
+// Transport is a mediator.Transport carrying events over a Google Cloud
+// Pub/Sub topic per event name. Attach it with mediator.WithTransport: the
+// events dispatched locally are published to their topics, and each event
+// name subscribed to is received through a subscription of the transport's
+// own, deleted when it is closed. Those subscriptions get the events
+// published while the transport is attached only; use a Subscriber of
+// shared subscriptions for durable delivery.
+type Transport struct {
+	client *pubsub.Client
+	config TransportConfig
+	events map[string]bool
+	// id tells the subscriptions of the transport apart from those of others
+	id string
+
+	mu     sync.Mutex
+	topics map[string]*pubsub.Topic
+
+	// ctx stops the subscriptions when the transport is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ mediator.Transport = (*Transport)(nil)
+
+// NewTransport creates a transport on client
+func NewTransport(client *pubsub.Client, config TransportConfig) *Transport {
+	if config.Origin == "" {
+		config.Origin = processOrigin
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{
+		client: client,
+		config: config,
+		events: make(map[string]bool, len(config.Events)),
+		id:     randomID(),
+		topics: make(map[string]*pubsub.Topic),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, name := range config.Events {
+		t.events[name] = true
+	}
+	return t
+}
+
+// Publish publishes an event to its topic, unless Events leaves its name
+// out, and waits for the server to accept it
+func (t *Transport) Publish(ctx context.Context, event mediator.Event) error {
+	if len(t.events) > 0 && !t.events[event.Name] {
+		return nil
+	}
+
+	topic, err := t.topic(ctx, event.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := mediator.EncodeEventRecord(t.config.Serializer, event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := &pubsub.Message{
+		Data: data,
+		Attributes: map[string]string{
+			EventNameAttribute: event.Name,
+			EventIDAttribute:   event.ID,
+			OriginAttribute:    t.config.Origin,
+		},
+		OrderingKey: t.orderingKey(event),
+	}
+	if _, err := topic.Publish(ctx, msg).Get(ctx); err != nil {
+		// A failed ordered publish pauses its key until resumed
+		if msg.OrderingKey != "" {
+			topic.ResumePublish(msg.OrderingKey)
+		}
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// topic returns the topic of an event name, creating it with CreateTopics
+func (t *Transport) topic(ctx context.Context, eventName string) (*pubsub.Topic, error) {
+	id := TopicID(t.config.TopicPrefix, eventName)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if topic, ok := t.topics[id]; ok {
+		return topic, nil
+	}
+
+	topic := t.client.Topic(id)
+	if t.config.CreateTopics {
+		created, err := t.client.CreateTopic(ctx, id)
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			return nil, fmt.Errorf("failed to create topic %s: %w", id, err)
+		}
+		if err == nil {
+			topic = created
+		}
+	}
+	topic.EnableMessageOrdering = t.config.OrderingKey != ""
+	t.topics[id] = topic
+	return topic, nil
+}
+
+// orderingKey returns the value of the OrderingKey field of an event's payload
+func (t *Transport) orderingKey(event mediator.Event) string {
+	if t.config.OrderingKey == "" {
+		return ""
+	}
+
+	fields, ok := event.Payload.(map[string]interface{})
+	if !ok {
+		// Read struct payloads through their JSON field names
+		data, err := json.Marshal(event.Payload)
+		if err != nil || json.Unmarshal(data, &fields) != nil {
+			return ""
+		}
+	}
+	value, ok := fields[t.config.OrderingKey]
+	if !ok || value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// Subscribe creates a subscription of the transport to the topic of an
+// event name and returns its events, until the transport is closed, when
+// the subscription is deleted and the channel closed
+func (t *Transport) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	if t.ctx.Err() != nil {
+		return nil, fmt.Errorf("transport is closed")
+	}
+
+	topic, err := t.topic(t.ctx, eventName)
+	if err != nil {
+		return nil, err
+	}
+	id := t.SubscriptionID(eventName)
+	ordered := t.config.OrderingKey != ""
+	sub, err := t.client.CreateSubscription(t.ctx, id, pubsub.SubscriptionConfig{
+		Topic:                 topic,
+		ExpirationPolicy:      t.config.Expiration,
+		EnableMessageOrdering: ordered,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription %s: %w", id, err)
+	}
+	if ordered {
+		// Forward one message at a time, so the events of a key keep their order
+		sub.ReceiveSettings.NumGoroutines = 1
+		sub.ReceiveSettings.MaxOutstandingMessages = 1
+	}
+
+	events := make(chan mediator.Event, t.config.Buffer)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(events)
+		t.receive(sub, events)
+	}()
+	return events, nil
+}
+
+// SubscriptionID returns the ID of the subscription of the transport to an
+// event name: the topic ID followed by '.' and an ID of the transport
+func (t *Transport) SubscriptionID(eventName string) string {
+	return TopicID(t.config.TopicPrefix, eventName) + "." + t.id
+}
+
+// receive decodes the messages of a subscription into events until the
+// transport is closed, then deletes the subscription. A message is acked
+// once its event is buffered, and nacked if the transport closes first.
+func (t *Transport) receive(sub *pubsub.Subscription, events chan<- mediator.Event) {
+	err := sub.Receive(t.ctx, func(ctx context.Context, msg *pubsub.Message) {
+		stored, err := mediator.DecodeStoredEvent(t.config.Serializer, msg.Data)
+		if err != nil {
+			// Redelivering the message would fail the same way
+			msg.Ack()
+			t.logf("failed to unmarshal message %s: %v", msg.ID, err)
+			return
+		}
+
+		select {
+		case events <- stored.Event():
+			msg.Ack()
+		case <-ctx.Done():
+			msg.Nack()
+		}
+	})
+	if err != nil {
+		t.logf("failed to receive from %s: %v", sub.ID(), err)
+	}
+
+	if err := sub.Delete(context.Background()); err != nil {
+		t.logf("failed to delete subscription %s: %v", sub.ID(), err)
+	}
+}
+
+// Close stops the subscriptions, deleting them and closing their channels,
+// and publishes the events still buffered. It does not close the client.
+func (t *Transport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, topic := range t.topics {
+		topic.Stop()
+	}
+	return nil
+}
+
+// logf reports a failure to the configured logger
+func (t *Transport) logf(format string, args ...interface{}) {
+	if t.config.Logger != nil {
+		t.config.Logger.Printf("pubsub transport: "+format, args...)
+	}
+}
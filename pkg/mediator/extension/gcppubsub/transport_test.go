@@ -0,0 +1,248 @@
+package gcppubsub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestClient starts an in-memory Pub/Sub server and returns a client of it
+func newTestClient(t *testing.T) (*pubsub.Client, *pstest.Server) {
+	t.Helper()
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+
+	client, err := pubsub.NewClient(context.Background(), "test-project",
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, srv
+}
+
+// orderPlaced is a struct payload with an ordering field
+type orderPlaced struct {
+	OrderID    string `json:"order_id"`
+	CustomerID int    `json:"customer_id"`
+}
+
+func TestTopicID(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		eventName string
+		want      string
+	}{
+		{"mediator.", "order.placed", "mediator.order.placed"},
+		{"mediator.", "acme:order.placed", "mediator.acme.order.placed"},
+		{"events-", "user created/v2", "events-user.created.v2"},
+		{"", "order_placed~v1", "order_placed~v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventName, func(t *testing.T) {
+			if got := TopicID(tt.prefix, tt.eventName); got != tt.want {
+				t.Errorf("TopicID() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransport_OrderingKey(t *testing.T) {
+	config := DefaultTransportConfig()
+	config.OrderingKey = "customer_id"
+	transport := &Transport{config: config}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		want    string
+	}{
+		{"map string", map[string]interface{}{"customer_id": "c-1"}, "c-1"},
+		{"map number", map[string]interface{}{"customer_id": float64(1234567)}, "1234567"},
+		{"struct", orderPlaced{OrderID: "o-1", CustomerID: 42}, "42"},
+		{"struct pointer", &orderPlaced{CustomerID: 7}, "7"},
+		{"missing field", map[string]interface{}{"order_id": "o-1"}, ""},
+		{"no payload", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := transport.orderingKey(mediator.Event{Name: "order.placed", Payload: tt.payload})
+			if got != tt.want {
+				t.Errorf("orderingKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransport_Publish(t *testing.T) {
+	client, srv := newTestClient(t)
+
+	config := DefaultTransportConfig()
+	config.OrderingKey = "customer_id"
+	config.Origin = "orders"
+	config.Events = []string{"order.placed"}
+	transport := NewTransport(client, config)
+	defer transport.Close()
+
+	m := mediator.NewMediator(mediator.WithTransport(transport))
+	m.Subscribe("user.created", func(ctx context.Context, event mediator.Event) error { return nil })
+
+	ctx := context.Background()
+	m.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"customer_id": "c-1"}})
+	m.Publish(ctx, mediator.Event{Name: "user.created", ID: "evt-2"})
+	m.DispatchRemote(ctx, mediator.Event{Name: "order.placed", ID: "evt-3"})
+
+	// Test the topic was created and only the local configured event published
+	if exists, err := client.Topic("mediator.order.placed").Exists(ctx); err != nil || !exists {
+		t.Fatalf("Expected topic mediator.order.placed to exist, got %v %v", exists, err)
+	}
+	messages := srv.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.OrderingKey != "c-1" {
+		t.Errorf("Expected ordering key c-1, got %q", message.OrderingKey)
+	}
+	for key, want := range map[string]string{EventNameAttribute: "order.placed", EventIDAttribute: "evt-1", OriginAttribute: "orders"} {
+		if got := message.Attributes[key]; got != want {
+			t.Errorf("Attribute %s = %s, want %s", key, got, want)
+		}
+	}
+
+	stored, err := mediator.DecodeStoredEvent(nil, message.Data)
+	if err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	if stored.ID != "evt-1" || stored.Name != "order.placed" {
+		t.Errorf("Expected evt-1 order.placed, got %s %s", stored.ID, stored.Name)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	// Start two instances attached to each other, ordering the events of a
+	// customer so the sentinels of CheckOnce follow the events before them
+	config := DefaultTransportConfig()
+	config.OrderingKey = "customer_id"
+	recorders := []*deliverytest.Recorder{deliverytest.NewRecorder(), deliverytest.NewRecorder()}
+	mediators := make([]*mediator.Mediator, len(recorders))
+	for i := range mediators {
+		transport := NewTransport(client, config)
+		defer transport.Close()
+
+		m := mediator.NewMediator(mediator.WithTransport(transport))
+		m.Subscribe("order.placed", recorders[i].Handle)
+		mediators[i] = m
+	}
+
+	placed := mediator.Event{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"customer_id": "c-1"}}
+	if err := mediators[0].Publish(ctx, placed); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Test the publishing instance dispatches once, the other once, remotely
+	deliverytest.CheckOnce(t, 10*time.Second, placed, mediators, recorders)
+}
+
+func TestTransport_Ordering(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+
+	config := DefaultTransportConfig()
+	config.OrderingKey = "customer_id"
+	receiver := NewTransport(client, config)
+	defer receiver.Close()
+	events, err := receiver.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	subConfig, err := client.Subscription(receiver.SubscriptionID("order.placed")).Config(ctx)
+	if err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	if !subConfig.EnableMessageOrdering {
+		t.Error("Expected the subscription to enable message ordering")
+	}
+
+	config.Origin = "orders"
+	sender := NewTransport(client, config)
+	defer sender.Close()
+	const count = 20
+	for i := 0; i < count; i++ {
+		event := mediator.Event{Name: "order.placed", ID: fmt.Sprintf("evt-%d", i), Payload: map[string]interface{}{"customer_id": "c-1"}}
+		if err := sender.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// Test the events of a key arrive in the order they were published
+	for i := 0; i < count; i++ {
+		select {
+		case event := <-events:
+			if want := fmt.Sprintf("evt-%d", i); event.ID != want {
+				t.Fatalf("Received %s, want %s", event.ID, want)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for event %d", i)
+		}
+	}
+}
+
+func TestTransport_MissingTopic(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	config := DefaultTransportConfig()
+	config.CreateTopics = false
+	transport := NewTransport(client, config)
+	defer transport.Close()
+
+	if err := transport.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"}); err == nil {
+		t.Error("Expected an error publishing to a missing topic")
+	}
+	if _, err := transport.Subscribe("order.placed"); err == nil {
+		t.Error("Expected an error subscribing to a missing topic")
+	}
+}
+
+func TestTransport_Close(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	transport := NewTransport(client, DefaultTransportConfig())
+	events, err := transport.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	sub := client.Subscription(transport.SubscriptionID("order.placed"))
+	if exists, err := sub.Exists(ctx); err != nil || !exists {
+		t.Fatalf("Expected the subscription to exist, got %v %v", exists, err)
+	}
+
+	// Test closing the transport closes the channel and deletes the subscription
+	transport.Close()
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if exists, err := sub.Exists(ctx); err != nil || exists {
+		t.Errorf("Expected the subscription to be deleted, got %v %v", exists, err)
+	}
+	if _, err := transport.Subscribe("order.placed"); err == nil {
+		t.Error("Subscribe() error = nil after Close")
+	}
+}
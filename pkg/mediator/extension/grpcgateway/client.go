@@ -0,0 +1,161 @@
+package grpcgateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/extension/grpcgateway/gatewaypb"
+	"google.golang.org/grpc"
+)
+
+// ClientConfig configures a Client
+type ClientConfig struct {
+	// ReconnectInterval is how long a subscription waits before reopening a
+	// stream that failed
+	ReconnectInterval time.Duration
+	// Buffer is how many events a subscription buffers for the mediator
+	Buffer int
+	// Logger reports streams that failed; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultClientConfig returns default client configuration
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		ReconnectInterval: time.Second,
+		Buffer:            64,
+	}
+}
+
+// Client publishes into and consumes from a mediator behind a gateway
+// Server. It is a mediator.Transport, attaching a local mediator to the
+// remote one with mediator.WithTransport.
+type Client struct {
+	client gatewaypb.GatewayClient
+	config ClientConfig
+
+	// ctx stops the subscriptions when the client is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ mediator.Transport = (*Client)(nil)
+
+// NewClient creates a client of the gateway served on conn
+func NewClient(conn grpc.ClientConnInterface, config ClientConfig) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		client: gatewaypb.NewGatewayClient(conn),
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Publish publishes an event into the remote mediator. An event no remote
+// handler subscribes to is not an error.
+func (c *Client) Publish(ctx context.Context, event mediator.Event) error {
+	pb, err := toProto(event)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Publish(ctx, &gatewaypb.PublishRequest{Event: pb}); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Subscribe streams the events of an event name published in the remote
+// mediator. The stream is reopened when it fails, losing the events
+// published in between, until the client is closed, when the channel is
+// closed.
+func (c *Client) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	if c.ctx.Err() != nil {
+		return nil, fmt.Errorf("client is closed")
+	}
+
+	events := make(chan mediator.Event, c.config.Buffer)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(events)
+		for {
+			err := c.stream(eventName, events)
+			if c.ctx.Err() != nil {
+				return
+			}
+			c.logf("stream of %s failed: %v", eventName, err)
+
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(c.config.ReconnectInterval):
+			}
+		}
+	}()
+	return events, nil
+}
+
+// stream receives the events of an event name into events until the stream fails
+func (c *Client) stream(eventName string, events chan<- mediator.Event) error {
+	stream, err := c.client.Subscribe(c.ctx, &gatewaypb.SubscribeRequest{EventNames: []string{eventName}})
+	if err != nil {
+		return err
+	}
+
+	for {
+		pb, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		event, err := fromProto(pb)
+		if err != nil {
+			c.logf("event %s not received: %v", pb.GetId(), err)
+			continue
+		}
+
+		select {
+		case events <- event:
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+	}
+}
+
+// GetEvents reads the most recent events of a name from the remote
+// mediator's event store, with generic payloads
+func (c *Client) GetEvents(ctx context.Context, eventName string, limit int64) ([]mediator.Event, error) {
+	resp, err := c.client.GetEvents(ctx, &gatewaypb.GetEventsRequest{EventName: eventName, Limit: limit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	events := make([]mediator.Event, 0, len(resp.GetEvents()))
+	for _, pb := range resp.GetEvents() {
+		event, err := fromProto(pb)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Close stops the subscriptions and closes their channels. It does not
+// close the connection.
+func (c *Client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+	return nil
+}
+
+// logf reports a message to the Logger
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.config.Logger != nil {
+		c.config.Logger.Printf("grpc gateway client: "+format, args...)
+	}
+}
@@ -0,0 +1,112 @@
+package grpcgateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+func TestClient_Transport(t *testing.T) {
+	// The hub mediator behind the gateway, and a service attached to it
+	hub := mediator.NewMediator(mediator.WithEventStore(mediatortest.NewEventStore()))
+	client := NewClient(serve(t, hub), DefaultClientConfig())
+	defer client.Close()
+
+	service := mediator.NewMediator(mediator.WithTransport(client))
+	defer service.Close()
+
+	hubReceived := make(chan mediator.Event, 10)
+	hub.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		hubReceived <- event
+		return nil
+	})
+	serviceReceived := make(chan mediator.Event, 10)
+	service.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		serviceReceived <- event
+		return nil
+	})
+	service.Subscribe("payment.captured", func(ctx context.Context, event mediator.Event) error {
+		serviceReceived <- event
+		return nil
+	})
+
+	// Wait for the service's streams before publishing
+	deadline := time.Now().Add(5 * time.Second)
+	for handlerCount(hub, "payment.captured") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the subscription")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Test an event published by the service reaches the hub, and only once
+	// the service
+	ctx := context.Background()
+	if err := service.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-1"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if event := receive(t, hubReceived); event.ID != "evt-1" {
+		t.Errorf("Hub received %s, want evt-1", event.ID)
+	}
+	if event := receive(t, serviceReceived); event.ID != "evt-1" || mediator.IsRemote(event) {
+		t.Errorf("Service received %+v, want local evt-1", event)
+	}
+
+	// Test an event published in the hub reaches the service, marked remote
+	if err := hub.Publish(ctx, mediator.Event{Name: "payment.captured", ID: "evt-2", Payload: map[string]interface{}{"amount": 10.5}}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	event := receive(t, serviceReceived)
+	if event.ID != "evt-2" || !mediator.IsRemote(event) {
+		t.Errorf("Service received %+v, want remote evt-2", event)
+	}
+	if payload, ok := event.Payload.(map[string]interface{}); !ok || payload["amount"] != 10.5 {
+		t.Errorf("Expected payload {amount: 10.5}, got %#v", event.Payload)
+	}
+
+	select {
+	case event := <-serviceReceived:
+		t.Errorf("Expected no other event, got %s", event.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Test the hub's stored events are read back
+	events, err := client.GetEvents(ctx, "order.placed", 10)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "evt-1" {
+		t.Errorf("Expected evt-1, got %+v", events)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	client := NewClient(serve(t, mediator.NewMediator()), DefaultClientConfig())
+	events, err := client.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	client.Close()
+
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if _, err := client.Subscribe("order.placed"); err == nil {
+		t.Error("Expected an error subscribing with a closed client")
+	}
+}
+
+// receive returns the next event of ch
+func receive(t *testing.T, ch <-chan mediator.Event) mediator.Event {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return mediator.Event{}
+	}
+}
@@ -0,0 +1,55 @@
+package grpcgateway
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/extension/grpcgateway/gatewaypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProto converts an event to its protocol buffer form, encoding its
+// payload as JSON
+func toProto(event mediator.Event) (*gatewaypb.Event, error) {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	pb := &gatewaypb.Event{
+		Name:          event.Name,
+		Payload:       payload,
+		Id:            event.ID,
+		CorrelationId: event.CorrelationID,
+		CausationId:   event.CausationID,
+		Metadata:      event.Metadata,
+		Namespace:     event.Namespace,
+	}
+	if !event.Timestamp.IsZero() {
+		pb.Timestamp = timestamppb.New(event.Timestamp)
+	}
+	return pb, nil
+}
+
+// fromProto converts an event from its protocol buffer form, decoding its
+// JSON payload into generic values
+func fromProto(pb *gatewaypb.Event) (mediator.Event, error) {
+	event := mediator.Event{
+		Name:          pb.GetName(),
+		ID:            pb.GetId(),
+		CorrelationID: pb.GetCorrelationId(),
+		CausationID:   pb.GetCausationId(),
+		Metadata:      pb.GetMetadata(),
+		Namespace:     pb.GetNamespace(),
+	}
+	if pb.GetTimestamp() != nil {
+		event.Timestamp = pb.GetTimestamp().AsTime()
+	}
+	if len(pb.GetPayload()) > 0 {
+		if err := json.Unmarshal(pb.GetPayload(), &event.Payload); err != nil {
+			return event, fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,5 @@
+// Package gatewaypb holds the protocol buffer definitions of the gRPC event
+// gateway, generated from gateway.proto
+package gatewaypb
+
+//go:generate protoc --go_out=paths=source_relative:. --go-grpc_out=paths=source_relative:. gateway.proto
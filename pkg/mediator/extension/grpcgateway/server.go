@@ -0,0 +1,185 @@
+package grpcgateway
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/extension/grpcgateway/gatewaypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServerConfig configures a Server
+type ServerConfig struct {
+	// SubscribeBuffer is how many events a Subscribe stream buffers before
+	// the publishes of its events wait for the client to receive them
+	SubscribeBuffer int
+	// MaxEvents bounds the events GetEvents returns; no bound when zero
+	MaxEvents int64
+	// Logger reports streams that failed; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultServerConfig returns default server configuration
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		SubscribeBuffer: 256,
+		MaxEvents:       1000,
+	}
+}
+
+// Server exposes a mediator as the gRPC Gateway service, so services in
+// other languages and sidecars can publish into it and consume from it
+type Server struct {
+	gatewaypb.UnimplementedGatewayServer
+
+	mediator *mediator.Mediator
+	config   ServerConfig
+}
+
+// NewServer creates a gateway server around m
+func NewServer(m *mediator.Mediator, config ServerConfig) *Server {
+	return &Server{mediator: m, config: config}
+}
+
+// Register registers the Gateway service on a gRPC server
+func (s *Server) Register(registrar grpc.ServiceRegistrar) {
+	gatewaypb.RegisterGatewayServer(registrar, s)
+}
+
+// Publish dispatches an event to the mediator's handlers and stores it. An
+// event no handler subscribes to is not an error; the response reports it.
+func (s *Server) Publish(ctx context.Context, req *gatewaypb.PublishRequest) (*gatewaypb.PublishResponse, error) {
+	if req.GetEvent().GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "an event name is required")
+	}
+	event, err := fromProto(req.GetEvent())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// Stamp the ID here, so it can be returned
+	if event.ID == "" {
+		event.ID = newID()
+	}
+
+	err = s.mediator.Publish(ctx, event)
+	if errors.Is(err, mediator.ErrNoHandlers) {
+		return &gatewaypb.PublishResponse{Id: event.ID}, nil
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gatewaypb.PublishResponse{Id: event.ID, Handled: true}, nil
+}
+
+// Subscribe streams the events published with the requested names until the
+// client cancels the stream. Publishes wait for a client that falls
+// SubscribeBuffer events behind.
+func (s *Server) Subscribe(req *gatewaypb.SubscribeRequest, stream gatewaypb.Gateway_SubscribeServer) error {
+	if len(req.GetEventNames()) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one event name is required")
+	}
+	for _, name := range req.GetEventNames() {
+		if err := s.mediator.ValidateEventName(name); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	ctx := stream.Context()
+	events := make(chan mediator.Event, s.config.SubscribeBuffer)
+	handler := func(publishCtx context.Context, event mediator.Event) error {
+		select {
+		case events <- event:
+			return nil
+		case <-ctx.Done():
+			// The stream ended; the event is not this client's anymore
+			return nil
+		case <-publishCtx.Done():
+			return publishCtx.Err()
+		}
+	}
+	// Name each stream's handler apart, for dead letters and deduplication
+	handlerName := "grpcgateway.Subscribe." + newID()
+	for _, name := range req.GetEventNames() {
+		sub := s.mediator.Subscribe(name, handler, mediator.WithHandlerName(handlerName))
+		defer sub.Unsubscribe()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-events:
+			pb, err := toProto(event)
+			if err != nil {
+				s.logf("event %s not streamed: %v", event.ID, err)
+				continue
+			}
+			if err := stream.Send(pb); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetEvents reads the most recent events of a name from the event store
+func (s *Server) GetEvents(ctx context.Context, req *gatewaypb.GetEventsRequest) (*gatewaypb.GetEventsResponse, error) {
+	if req.GetEventName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "an event name is required")
+	}
+	limit := req.GetLimit()
+	if s.config.MaxEvents > 0 && (limit <= 0 || limit > s.config.MaxEvents) {
+		limit = s.config.MaxEvents
+	}
+
+	stored, err := s.mediator.ReadEvents(ctx, req.GetEventName(), limit)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &gatewaypb.GetEventsResponse{Events: make([]*gatewaypb.Event, 0, len(stored))}
+	for _, event := range stored {
+		pb, err := toProto(event.Event())
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		resp.Events = append(resp.Events, pb)
+	}
+	return resp, nil
+}
+
+// logf reports a message to the Logger
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.config.Logger != nil {
+		s.config.Logger.Printf("grpc gateway: "+format, args...)
+	}
+}
+
+// newID returns a random event ID
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// statusError converts a mediator error to a gRPC status error
+func statusError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, mediator.ErrInvalidEvent):
+		code = codes.InvalidArgument
+	case errors.Is(err, mediator.ErrPublishVetoed):
+		code = codes.FailedPrecondition
+	case errors.Is(err, mediator.ErrRateLimited):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,192 @@
+package grpcgateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/extension/grpcgateway/gatewaypb"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve serves a gateway around m on an in-memory listener and returns a
+// connection to it
+func serve(t *testing.T, m *mediator.Mediator) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	NewServer(m, DefaultServerConfig()).Register(srv)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// handlerCount returns the number of handlers of an event name
+func handlerCount(m *mediator.Mediator, eventName string) int {
+	for _, info := range m.Subscriptions() {
+		if info.EventName == eventName {
+			return info.HandlerCount
+		}
+	}
+	return 0
+}
+
+func TestServer_Publish(t *testing.T) {
+	store := mediatortest.NewEventStore()
+	m := mediator.NewMediator(mediator.WithEventStore(store))
+	received := make(chan mediator.Event, 1)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		received <- event
+		return nil
+	})
+	m.OnBeforePublish(func(ctx context.Context, event *mediator.Event) error {
+		if event.Name == "order.cancelled" {
+			return errors.New("not allowed")
+		}
+		return nil
+	})
+	client := gatewaypb.NewGatewayClient(serve(t, m))
+
+	tests := []struct {
+		name        string
+		event       *gatewaypb.Event
+		wantCode    codes.Code
+		wantHandled bool
+	}{
+		{"handled", &gatewaypb.Event{Name: "order.placed", Payload: []byte(`{"id":"o-1"}`)}, codes.OK, true},
+		{"without handlers", &gatewaypb.Event{Name: "user.created"}, codes.OK, false},
+		{"no name", &gatewaypb.Event{}, codes.InvalidArgument, false},
+		{"invalid payload", &gatewaypb.Event{Name: "order.placed", Payload: []byte("{")}, codes.InvalidArgument, false},
+		{"vetoed", &gatewaypb.Event{Name: "order.cancelled"}, codes.FailedPrecondition, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Publish(context.Background(), &gatewaypb.PublishRequest{Event: tt.event})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("Publish() code = %v, want %v (%v)", code, tt.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+			if resp.GetId() == "" || resp.GetHandled() != tt.wantHandled {
+				t.Errorf("Publish() = %+v, want an ID and handled %v", resp, tt.wantHandled)
+			}
+		})
+	}
+
+	event := <-received
+	payload, ok := event.Payload.(map[string]interface{})
+	if !ok || payload["id"] != "o-1" {
+		t.Errorf("Expected payload {id: o-1}, got %#v", event.Payload)
+	}
+}
+
+func TestServer_Subscribe(t *testing.T) {
+	m := mediator.NewMediator()
+	client := gatewaypb.NewGatewayClient(serve(t, m))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Subscribe(ctx, &gatewaypb.SubscribeRequest{EventNames: []string{"order.placed", "order.shipped"}})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// Wait for the stream's handlers before publishing
+	deadline := time.Now().Add(5 * time.Second)
+	for handlerCount(m, "order.shipped") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the subscription")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	publish := context.Background()
+	m.Publish(publish, mediator.Event{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"id": "o-1"}})
+	m.Publish(publish, mediator.Event{Name: "user.created", ID: "evt-2"})
+	m.Publish(publish, mediator.Event{Name: "order.shipped", ID: "evt-3"})
+
+	for _, want := range []string{"evt-1", "evt-3"} {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+		if event.GetId() != want {
+			t.Errorf("Received %s, want %s", event.GetId(), want)
+		}
+	}
+
+	// Test the handlers are removed once the stream ends
+	cancel()
+	deadline = time.Now().Add(5 * time.Second)
+	for handlerCount(m, "order.placed") > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the stream's handlers to be removed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServer_SubscribeUnknownEvent(t *testing.T) {
+	m := mediator.NewMediator(mediator.WithStrictEvents())
+	if err := mediator.RegisterEvent[interface{}](m, "order.placed"); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+	client := gatewaypb.NewGatewayClient(serve(t, m))
+
+	stream, err := client.Subscribe(context.Background(), &gatewaypb.SubscribeRequest{EventNames: []string{"order.placed", "order.plcaed"}})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Recv() error = %v, want InvalidArgument", err)
+	}
+	if n := handlerCount(m, "order.placed"); n != 0 {
+		t.Errorf("subscribed %d handlers, want none", n)
+	}
+}
+
+func TestServer_GetEvents(t *testing.T) {
+	store := mediatortest.NewEventStore()
+	m := mediator.NewMediator(mediator.WithEventStore(store))
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+	for _, id := range []string{"evt-1", "evt-2", "evt-3"} {
+		m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: id, Payload: map[string]interface{}{"id": id}})
+	}
+	client := gatewaypb.NewGatewayClient(serve(t, m))
+
+	resp, err := client.GetEvents(context.Background(), &gatewaypb.GetEventsRequest{EventName: "order.placed", Limit: 2})
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	events := resp.GetEvents()
+	if len(events) != 2 || events[0].GetId() != "evt-2" || events[1].GetId() != "evt-3" {
+		t.Fatalf("Expected the 2 most recent events, got %+v", events)
+	}
+	if string(events[1].GetPayload()) != `{"id":"evt-3"}` {
+		t.Errorf("Expected a JSON payload, got %s", events[1].GetPayload())
+	}
+
+	if _, err := client.GetEvents(context.Background(), &gatewaypb.GetEventsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetEvents() without a name code = %v, want InvalidArgument", status.Code(err))
+	}
+}
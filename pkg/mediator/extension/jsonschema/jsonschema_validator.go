@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Validator checks event payloads against JSON Schemas registered per event
+// name. Events without a registered schema pass.
+type Validator struct {
+	mu      sync.RWMutex
+	schemas map[string]*jsonschema.Schema
+}
+
+var _ mediator.Validator = (*Validator)(nil)
+
+// NewValidator creates a validator without schemas
+func NewValidator() *Validator {
+	return &Validator{
+		schemas: make(map[string]*jsonschema.Schema),
+	}
+}
+
+// Register compiles schema and applies it to payloads of eventName
+func (v *Validator) Register(eventName, schema string) error {
+	compiled, err := jsonschema.CompileString(eventName+".json", schema)
+	if err != nil {
+		return fmt.Errorf("failed to compile schema for %s: %w", eventName, err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.schemas[eventName] = compiled
+	return nil
+}
+
+// Validate checks the JSON form of the event payload against its schema
+func (v *Validator) Validate(ctx context.Context, event mediator.Event) error {
+	v.mu.RLock()
+	schema, ok := v.schemas[event.Name]
+	v.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	data, ok := event.Payload.(json.RawMessage)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(event.Payload); err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
+
+	// Decode numbers as json.Number so integer constraints see exact values
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+	return schema.Validate(doc)
+}
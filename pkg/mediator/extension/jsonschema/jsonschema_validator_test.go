@@ -0,0 +1,78 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+const productSchema = `{
+	"type": "object",
+	"required": ["id", "price"],
+	"properties": {
+		"id": {"type": "string", "minLength": 1},
+		"price": {"type": "number", "minimum": 0}
+	}
+}`
+
+type product struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v := NewValidator()
+	if err := v.Register("product.created", productSchema); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		event   mediator.Event
+		wantErr bool
+	}{
+		{"valid struct", mediator.Event{Name: "product.created", Payload: product{ID: "p-1", Price: 4.5}}, false},
+		{"valid map", mediator.Event{Name: "product.created", Payload: map[string]interface{}{"id": "p-1", "price": 1}}, false},
+		{"valid raw JSON", mediator.Event{Name: "product.created", Payload: json.RawMessage(`{"id":"p-1","price":0}`)}, false},
+		{"negative price", mediator.Event{Name: "product.created", Payload: product{ID: "p-1", Price: -1}}, true},
+		{"missing field", mediator.Event{Name: "product.created", Payload: map[string]interface{}{"id": "p-1"}}, true},
+		{"wrong type", mediator.Event{Name: "product.created", Payload: "p-1"}, true},
+		{"no schema", mediator.Event{Name: "product.deleted", Payload: "p-1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(context.Background(), tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidator_RegisterInvalidSchema(t *testing.T) {
+	if err := NewValidator().Register("product.created", `{"type": 42}`); err == nil {
+		t.Error("Register() expected error for invalid schema")
+	}
+}
+
+func TestValidator_RejectsPublish(t *testing.T) {
+	v := NewValidator()
+	if err := v.Register("product.created", productSchema); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	m := mediator.NewMediator(mediator.WithValidator(v))
+	m.Subscribe("product.created", func(ctx context.Context, event mediator.Event) error {
+		t.Error("handler invoked for invalid payload")
+		return nil
+	})
+
+	err := m.Publish(context.Background(), mediator.Event{Name: "product.created", Payload: product{Price: 1}})
+	if !errors.Is(err, mediator.ErrInvalidEvent) {
+		t.Errorf("Publish() error = %v, want ErrInvalidEvent", err)
+	}
+}
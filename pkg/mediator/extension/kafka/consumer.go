@@ -0,0 +1,118 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/segmentio/kafka-go"
+)
+
+// ConsumerConfig configures a Consumer
+type ConsumerConfig struct {
+	// Origin names this service; messages its producers stamped with the same
+	// Origin are skipped, as they were dispatched when published
+	Origin string
+	// Serializer decodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be decoded or dispatched;
+	// nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// messageReader is the part of *kafka.Reader a Consumer uses
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Consumer reads events from Kafka topics and dispatches them to the
+// handlers of the local mediator, committing the offset of each message of
+// a consumer group once it was dispatched
+type Consumer struct {
+	reader   messageReader
+	mediator *mediator.Mediator
+	config   ConsumerConfig
+}
+
+// NewConsumer creates a consumer dispatching the messages read by reader
+// into m. Set GroupID and GroupTopics on the reader to consume topics as a
+// consumer group, whose offsets are committed by the consumer.
+func NewConsumer(reader *kafka.Reader, m *mediator.Mediator, config ConsumerConfig) *Consumer {
+	return newConsumer(reader, m, config)
+}
+
+// newConsumer creates a consumer on any message reader
+func newConsumer(reader messageReader, m *mediator.Mediator, config ConsumerConfig) *Consumer {
+	return &Consumer{reader: reader, mediator: m, config: config}
+}
+
+// Run dispatches messages until ctx is cancelled. Events whose handlers
+// fail, after the mediator's retries, and messages that are not events are
+// logged and committed, so they don't hold up the partition; a message is
+// only read again when Run stops before committing it.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		message, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to fetch message: %w", err)
+		}
+
+		if err := c.deliver(ctx, message); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.logf("%v", err)
+		}
+		if err := c.reader.CommitMessages(ctx, message); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to commit message: %w", err)
+		}
+	}
+}
+
+// deliver dispatches the event of a message unless this service produced it
+func (c *Consumer) deliver(ctx context.Context, message kafka.Message) error {
+	if c.config.Origin != "" && header(message, OriginHeader) == c.config.Origin {
+		return nil
+	}
+
+	stored, err := mediator.DecodeStoredEvent(c.config.Serializer, message.Value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal message %s/%d/%d: %w", message.Topic, message.Partition, message.Offset, err)
+	}
+
+	err = c.mediator.DispatchRemote(ctx, stored.Event())
+	if err != nil {
+		return fmt.Errorf("failed to dispatch event %s: %w", stored.ID, err)
+	}
+	return nil
+}
+
+// header returns the value of a message header, empty if it is missing
+func header(message kafka.Message, key string) string {
+	for _, h := range message.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Close closes the reader, leaving the consumer group
+func (c *Consumer) Close() error {
+	return c.reader.Close()
+}
+
+// logf reports a failure to the configured logger
+func (c *Consumer) logf(format string, args ...interface{}) {
+	if c.config.Logger != nil {
+		c.config.Logger.Printf("kafka consumer: "+format, args...)
+	}
+}
@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader serves queued messages and records commits
+type fakeReader struct {
+	messages chan kafka.Message
+
+	mu        sync.Mutex
+	committed []kafka.Message
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	case message := <-r.messages:
+		return message, nil
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
+
+func (r *fakeReader) commits() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.committed)
+}
+
+func TestConsumer(t *testing.T) {
+	// Produce the messages of two services
+	writer := &fakeWriter{}
+	for _, origin := range []string{"orders", "billing"} {
+		config := DefaultProducerConfig()
+		config.Origin = origin
+		event := mediator.Event{Name: "order.placed", ID: "evt-" + origin, Payload: map[string]interface{}{"by": origin}}
+		if err := newProducer(writer, config).StoreEvent(context.Background(), event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	reader := &fakeReader{messages: make(chan kafka.Message, 10)}
+	for _, message := range writer.messages {
+		reader.messages <- message
+	}
+	// A message that is not an event, and an event without handlers
+	reader.messages <- kafka.Message{Topic: "mediator.order.placed", Value: []byte("not json")}
+	other, _ := mediator.EncodeEventRecord(nil, mediator.Event{Name: "user.created", ID: "evt-user"})
+	reader.messages <- kafka.Message{Topic: "mediator.user.created", Value: other}
+
+	m := mediator.NewMediator()
+	received := make(chan mediator.Event, 10)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		received <- event
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- newConsumer(reader, m, ConsumerConfig{Origin: "orders"}).Run(ctx)
+	}()
+
+	// Test only the other service's event is dispatched, marked remote
+	select {
+	case event := <-received:
+		if event.ID != "evt-billing" || !mediator.IsRemote(event) {
+			t.Errorf("Expected remote evt-billing, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+
+	// Test every message is committed, including those skipped or failing
+	deadline := time.Now().Add(5 * time.Second)
+	for reader.commits() < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 4 commits, got %d", reader.commits())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case event := <-received:
+		t.Errorf("Expected no other event, got %s", event.ID)
+	default:
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrNotSupported is returned by the Producer's reads and ClearEvents, as
+// Kafka topics are read by consumers rather than queried
+var ErrNotSupported = errors.New("not supported by the kafka producer")
+
+// Headers set on every produced message, so consumers and tools can route
+// messages without decoding them
+const (
+	EventNameHeader = "mediator-event-name"
+	EventIDHeader   = "mediator-event-id"
+	// OriginHeader carries the Origin of the producing service
+	OriginHeader = "mediator-origin"
+)
+
+// ProducerConfig configures a Producer
+type ProducerConfig struct {
+	// TopicPrefix is prepended to event names to form their topics
+	TopicPrefix string
+	// Topics maps event names to topics, overriding TopicPrefix
+	Topics map[string]string
+	// Key returns the message key of an event, which the writer's balancer
+	// partitions by; the event ID when nil. Keying by an aggregate ID keeps
+	// its events in order.
+	Key func(event mediator.Event) []byte
+	// Origin names the service producing the events; consumers with the same
+	// Origin skip them, as the service dispatched them when they were published
+	Origin string
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+}
+
+// DefaultProducerConfig returns default producer configuration
+func DefaultProducerConfig() ProducerConfig {
+	return ProducerConfig{
+		TopicPrefix: "mediator.",
+	}
+}
+
+// messageWriter is the part of *kafka.Writer a Producer uses
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Producer is a write-only event store producing each stored event to the
+// Kafka topic of its event name. Pass it to mediator.WithEventStore to
+// produce the events published, or to an outbox relay.
+type Producer struct {
+	writer messageWriter
+	config ProducerConfig
+}
+
+// NewProducer creates a producer writing with writer, which must not set a
+// Topic, as each message names its own
+func NewProducer(writer *kafka.Writer, config ProducerConfig) *Producer {
+	return newProducer(writer, config)
+}
+
+// newProducer creates a producer on any message writer
+func newProducer(writer messageWriter, config ProducerConfig) *Producer {
+	return &Producer{writer: writer, config: config}
+}
+
+// Topic returns the topic of an event name. Characters Kafka does not allow
+// in topic names, such as the separator of namespaced names, become dots.
+func (p *Producer) Topic(eventName string) string {
+	if topic, ok := p.config.Topics[eventName]; ok {
+		return topic
+	}
+	return p.config.TopicPrefix + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '.'
+	}, eventName)
+}
+
+// StoreEvent produces an event to its topic
+func (p *Producer) StoreEvent(ctx context.Context, event mediator.Event) error {
+	return p.StoreEvents(ctx, []mediator.Event{event})
+}
+
+// StoreEvents produces several events with a single write
+func (p *Producer) StoreEvents(ctx context.Context, events []mediator.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	messages := make([]kafka.Message, len(events))
+	for i, event := range events {
+		message, err := p.message(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = message
+	}
+
+	if err := p.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to produce events: %w", err)
+	}
+	return nil
+}
+
+// message encodes an event as a message of its topic
+func (p *Producer) message(event mediator.Event) (kafka.Message, error) {
+	value, err := mediator.EncodeEventRecord(p.config.Serializer, event)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	key := []byte(event.ID)
+	if p.config.Key != nil {
+		key = p.config.Key(event)
+	}
+	headers := []kafka.Header{
+		{Key: EventNameHeader, Value: []byte(event.Name)},
+		{Key: EventIDHeader, Value: []byte(event.ID)},
+	}
+	if p.config.Origin != "" {
+		headers = append(headers, kafka.Header{Key: OriginHeader, Value: []byte(p.config.Origin)})
+	}
+
+	return kafka.Message{
+		Topic:   p.Topic(event.Name),
+		Key:     key,
+		Value:   value,
+		Headers: headers,
+		Time:    event.Timestamp,
+	}, nil
+}
+
+// GetEvents is not supported: consume the topic with a Consumer instead
+func (p *Producer) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	return nil, ErrNotSupported
+}
+
+// ClearEvents is not supported: Kafka removes messages by topic retention
+func (p *Producer) ClearEvents(ctx context.Context, eventName string) error {
+	return ErrNotSupported
+}
+
+// Close flushes pending messages and closes the writer
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeWriter records the messages written
+type fakeWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func TestProducer_Topic(t *testing.T) {
+	config := DefaultProducerConfig()
+	config.Topics = map[string]string{"audit.logged": "audit"}
+	producer := newProducer(&fakeWriter{}, config)
+
+	tests := []struct {
+		eventName string
+		want      string
+	}{
+		{"order.placed", "mediator.order.placed"},
+		{"acme:order.placed", "mediator.acme.order.placed"},
+		{"user created/v2", "mediator.user.created.v2"},
+		{"audit.logged", "audit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventName, func(t *testing.T) {
+			if got := producer.Topic(tt.eventName); got != tt.want {
+				t.Errorf("Topic() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProducer_StoreEvents(t *testing.T) {
+	writer := &fakeWriter{}
+	config := DefaultProducerConfig()
+	config.Origin = "orders"
+	producer := newProducer(writer, config)
+	var _ mediator.EventStore = producer
+	var _ mediator.BatchEventStore = producer
+
+	ctx := context.Background()
+	events := []mediator.Event{
+		{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"id": "o-1"}},
+		{Name: "order.shipped", ID: "evt-2"},
+	}
+	if err := producer.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	if len(writer.messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(writer.messages))
+	}
+	message := writer.messages[0]
+	if message.Topic != "mediator.order.placed" || string(message.Key) != "evt-1" {
+		t.Errorf("Expected evt-1 keyed on mediator.order.placed, got %s %s", message.Topic, message.Key)
+	}
+	for key, want := range map[string]string{EventNameHeader: "order.placed", EventIDHeader: "evt-1", OriginHeader: "orders"} {
+		if got := header(message, key); got != want {
+			t.Errorf("Expected header %s = %s, got %s", key, want, got)
+		}
+	}
+	stored, err := mediator.DecodeStoredEvent(nil, message.Value)
+	if err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	if stored.ID != "evt-1" || !reflect.DeepEqual(stored.Payload, map[string]interface{}{"id": "o-1"}) {
+		t.Errorf("Expected the record of evt-1, got %+v", stored)
+	}
+
+	// Test reads are not supported and write errors are returned
+	if _, err := producer.GetEvents(ctx, "order.placed", 10); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GetEvents() error = %v, want ErrNotSupported", err)
+	}
+	writer.err = errors.New("broker down")
+	if err := producer.StoreEvent(ctx, events[0]); err == nil {
+		t.Error("Expected the write error")
+	}
+}
+
+func TestProducer_Mediator(t *testing.T) {
+	writer := &fakeWriter{}
+	config := DefaultProducerConfig()
+	config.Key = func(event mediator.Event) []byte {
+		return []byte(event.CorrelationID)
+	}
+	m := mediator.NewMediator(mediator.WithEventStore(newProducer(writer, config)))
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		return nil
+	})
+
+	event := mediator.Event{Name: "order.placed", ID: "evt-1", CorrelationID: "order-42"}
+	if err := m.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if len(writer.messages) != 1 || string(writer.messages[0].Key) != "order-42" {
+		t.Errorf("Expected the published event keyed by order-42, got %+v", writer.messages)
+	}
+}
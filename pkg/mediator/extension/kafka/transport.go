@@ -0,0 +1,191 @@
+package kafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/segmentio/kafka-go"
+)
+
+// TransportConfig configures a Transport
+type TransportConfig struct {
+	// Brokers are the addresses subscriptions read from; the transport only
+	// publishes when empty
+	Brokers []string
+	// TopicPrefix is prepended to event names to form their topics
+	TopicPrefix string
+	// Topics maps event names to topics, overriding TopicPrefix
+	Topics map[string]string
+	// Key returns the message key of an event; the event ID when nil
+	Key func(event mediator.Event) []byte
+	// Events limits the events published to these names; every event when empty
+	Events []string
+	// Buffer is how many events a subscription buffers for the mediator
+	Buffer int
+	// Origin names the service publishing the events; consumers with the
+	// same Origin skip them
+	Origin string
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be read or decoded; nothing is
+	// logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultTransportConfig returns default transport configuration
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		TopicPrefix: "mediator.",
+		Buffer:      64,
+	}
+}
+
+// Transport is a mediator.Transport carrying events over a Kafka topic per
+// event name. Attach it with mediator.WithTransport: the events dispatched
+// locally are produced to their topics, and the topics of the event names
+// subscribed to are read as a consumer group of the transport's own, from
+// their end. A transport gets the events produced while it is attached
+// only; use a Consumer of a shared group for durable delivery.
+type Transport struct {
+	producer  *Producer
+	config    TransportConfig
+	events    map[string]bool
+	newReader func(topic string) messageReader
+
+	// ctx stops the subscriptions when the transport is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ mediator.Transport = (*Transport)(nil)
+
+// NewTransport creates a transport producing with writer, which must not
+// set a Topic, as each message names its own, and reading from Brokers
+func NewTransport(writer *kafka.Writer, config TransportConfig) *Transport {
+	var newReader func(topic string) messageReader
+	if len(config.Brokers) > 0 {
+		group := "mediator-" + randomID()
+		newReader = func(topic string) messageReader {
+			return kafka.NewReader(kafka.ReaderConfig{
+				Brokers:     config.Brokers,
+				GroupID:     group,
+				Topic:       topic,
+				StartOffset: kafka.LastOffset,
+			})
+		}
+	}
+	return newTransport(writer, newReader, config)
+}
+
+// newTransport creates a transport on any message writer and readers
+func newTransport(writer messageWriter, newReader func(topic string) messageReader, config TransportConfig) *Transport {
+	producer := newProducer(writer, ProducerConfig{
+		TopicPrefix: config.TopicPrefix,
+		Topics:      config.Topics,
+		Key:         config.Key,
+		Origin:      config.Origin,
+		Serializer:  config.Serializer,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{
+		producer:  producer,
+		config:    config,
+		events:    make(map[string]bool, len(config.Events)),
+		newReader: newReader,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for _, name := range config.Events {
+		t.events[name] = true
+	}
+	return t
+}
+
+// randomID returns a random hex ID
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Topic returns the topic of an event name, as the Producer names it
+func (t *Transport) Topic(eventName string) string {
+	return t.producer.Topic(eventName)
+}
+
+// Publish produces an event to its topic, unless Events leaves its name out
+func (t *Transport) Publish(ctx context.Context, event mediator.Event) error {
+	if len(t.events) > 0 && !t.events[event.Name] {
+		return nil
+	}
+	return t.producer.StoreEvent(ctx, event)
+}
+
+// Subscribe reads the topic of an event name from its end and returns its
+// events, until the transport is closed, when the channel is closed
+func (t *Transport) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	if t.ctx.Err() != nil {
+		return nil, fmt.Errorf("transport is closed")
+	}
+	if t.newReader == nil {
+		return nil, fmt.Errorf("brokers are required to subscribe")
+	}
+
+	reader := t.newReader(t.Topic(eventName))
+	events := make(chan mediator.Event, t.config.Buffer)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(events)
+		defer reader.Close()
+		t.receive(reader, events)
+	}()
+	return events, nil
+}
+
+// receive decodes the messages of a reader into events until the transport
+// is closed. The offsets of the transport's group are not committed, as no
+// other reader resumes from them.
+func (t *Transport) receive(reader messageReader, events chan<- mediator.Event) {
+	for {
+		message, err := reader.FetchMessage(t.ctx)
+		if err != nil {
+			if t.ctx.Err() == nil {
+				t.logf("failed to fetch message: %v", err)
+			}
+			return
+		}
+		stored, err := mediator.DecodeStoredEvent(t.config.Serializer, message.Value)
+		if err != nil {
+			t.logf("failed to unmarshal message %s/%d/%d: %v", message.Topic, message.Partition, message.Offset, err)
+			continue
+		}
+
+		select {
+		case events <- stored.Event():
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the subscriptions, closing their readers and channels. It does
+// not close the writer.
+func (t *Transport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// logf reports a failure to the configured logger
+func (t *Transport) logf(format string, args ...interface{}) {
+	if t.config.Logger != nil {
+		t.config.Logger.Printf("kafka transport: "+format, args...)
+	}
+}
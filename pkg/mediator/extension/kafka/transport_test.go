@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeTopics writes messages to the readers of their topics
+type fakeTopics struct {
+	mu      sync.Mutex
+	written []kafka.Message
+	readers map[string][]*fakeReader
+	closed  int
+}
+
+func (f *fakeTopics) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.written = append(f.written, msgs...)
+	for _, message := range msgs {
+		for _, reader := range f.readers[message.Topic] {
+			reader.messages <- message
+		}
+	}
+	return nil
+}
+
+func (f *fakeTopics) Close() error {
+	return nil
+}
+
+// reader returns a new reader of a topic
+func (f *fakeTopics) reader(topic string) messageReader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.readers == nil {
+		f.readers = make(map[string][]*fakeReader)
+	}
+	reader := &fakeReader{messages: make(chan kafka.Message, 10)}
+	f.readers[topic] = append(f.readers[topic], reader)
+	return &closingReader{fakeReader: reader, topics: f}
+}
+
+// closingReader counts the readers closed
+type closingReader struct {
+	*fakeReader
+	topics *fakeTopics
+}
+
+func (r *closingReader) Close() error {
+	r.topics.mu.Lock()
+	defer r.topics.mu.Unlock()
+	r.topics.closed++
+	return nil
+}
+
+func TestTransport(t *testing.T) {
+	topics := &fakeTopics{}
+	ctx := context.Background()
+
+	// Start two instances attached to each other
+	recorders := []*deliverytest.Recorder{deliverytest.NewRecorder(), deliverytest.NewRecorder()}
+	mediators := make([]*mediator.Mediator, len(recorders))
+	for i := range mediators {
+		config := DefaultTransportConfig()
+		config.Origin = "orders"
+		config.Events = []string{"order.placed"}
+		transport := newTransport(topics, topics.reader, config)
+		defer transport.Close()
+
+		m := mediator.NewMediator(mediator.WithTransport(transport))
+		m.Subscribe("order.placed", recorders[i].Handle)
+		m.Subscribe("user.created", func(ctx context.Context, event mediator.Event) error { return nil })
+		mediators[i] = m
+	}
+
+	placed := mediator.Event{Name: "order.placed", ID: "evt-1"}
+	if err := mediators[0].Publish(ctx, placed); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if err := mediators[0].Publish(ctx, mediator.Event{Name: "user.created", ID: "evt-2"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Test only the event of the configured names is produced, with its origin
+	topics.mu.Lock()
+	written := append([]kafka.Message(nil), topics.written...)
+	topics.mu.Unlock()
+	if len(written) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(written))
+	}
+	message := written[0]
+	if message.Topic != "mediator.order.placed" || header(message, OriginHeader) != "orders" {
+		t.Errorf("Expected order.placed from orders, got %s from %s", message.Topic, header(message, OriginHeader))
+	}
+
+	// Test the publishing instance dispatches once, the other once, remotely
+	deliverytest.CheckOnce(t, 5*time.Second, placed, mediators, recorders)
+}
+
+func TestTransport_Subscribe(t *testing.T) {
+	topics := &fakeTopics{}
+	tests := []struct {
+		name      string
+		newReader func(topic string) messageReader
+		wantErr   bool
+	}{
+		{"with brokers", topics.reader, false},
+		{"without brokers", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newTransport(topics, tt.newReader, DefaultTransportConfig())
+			defer transport.Close()
+			if _, err := transport.Subscribe("order.placed"); (err != nil) != tt.wantErr {
+				t.Errorf("Subscribe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransport_Close(t *testing.T) {
+	topics := &fakeTopics{}
+	transport := newTransport(topics, topics.reader, DefaultTransportConfig())
+	events, err := transport.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// Test closing the transport closes the channel and the reader
+	transport.Close()
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if topics.closed != 1 {
+		t.Errorf("Expected the reader to be closed, got %d closed", topics.closed)
+	}
+	if _, err := transport.Subscribe("order.placed"); err == nil {
+		t.Error("Subscribe() error = nil after Close")
+	}
+}
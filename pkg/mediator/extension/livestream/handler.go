@@ -0,0 +1,253 @@
+// Package livestream streams the events published through a mediator to
+// browsers and dashboards over Server-Sent Events or WebSocket.
+package livestream
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// errSlowClient ends the streams of clients that fall Buffer events behind
+var errSlowClient = errors.New("client fell too far behind")
+
+// Config configures a Handler
+type Config struct {
+	// Events limits the event names clients may stream; every name when empty
+	Events []string
+	// Buffer is how many events a stream buffers; clients falling further
+	// behind are disconnected rather than holding up publishes
+	Buffer int
+	// MaxReplay bounds the stored events replayed per event name; 0 for no bound
+	MaxReplay int
+	// HeartbeatInterval is how often idle Server-Sent Events streams send a
+	// comment, keeping proxies from closing them; 0 disables heartbeats
+	HeartbeatInterval time.Duration
+	// CheckOrigin accepts or rejects the Origin of WebSocket requests; when
+	// nil, requests without an Origin or from the same host are accepted
+	CheckOrigin func(r *http.Request) bool
+	// Logger reports streams that failed; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultConfig returns default handler configuration
+func DefaultConfig() Config {
+	return Config{
+		Buffer:            256,
+		MaxReplay:         1000,
+		HeartbeatInterval: 15 * time.Second,
+	}
+}
+
+// Message is an event sent to a client: the event envelope, with the offset
+// of replayed events to resume after them
+type Message struct {
+	mediator.Event
+	// Offset is the store cursor of a replayed event, empty for live events
+	Offset string `json:"offset,omitempty"`
+}
+
+// request is a parsed stream request
+type request struct {
+	eventNames []string
+	// replay is set when the client asked for stored events after from
+	replay bool
+	from   string
+}
+
+// sink sends messages to a client over one protocol
+type sink interface {
+	send(msg Message) error
+	heartbeat() error
+}
+
+// Handler is an http.Handler streaming the events published through a
+// mediator. Requests name their events in the "events" query parameter,
+// repeated or comma separated, and may replay stored events after an offset
+// with "from" before live ones; an empty "from" replays from the oldest
+// event. WebSocket upgrade requests are served over WebSocket, others over
+// Server-Sent Events.
+type Handler struct {
+	mediator *mediator.Mediator
+	config   Config
+	allowed  map[string]bool
+}
+
+// NewHandler creates a handler streaming the events published through m
+func NewHandler(m *mediator.Mediator, config Config) *Handler {
+	if config.Buffer < 1 {
+		config.Buffer = 1
+	}
+	h := &Handler{
+		mediator: m,
+		config:   config,
+		allowed:  make(map[string]bool, len(config.Events)),
+	}
+	for _, name := range config.Events {
+		h.allowed[name] = true
+	}
+	return h
+}
+
+// ServeHTTP streams events until the client disconnects
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := h.parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if isWebSocket(r) {
+		h.serveWebSocket(w, r, req)
+		return
+	}
+	h.serveSSE(w, r, req)
+}
+
+// parse reads the event names and replay offset of a request
+func (h *Handler) parse(r *http.Request) (request, error) {
+	query := r.URL.Query()
+	var req request
+	seen := make(map[string]bool)
+	for _, value := range query["events"] {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			if len(h.allowed) > 0 && !h.allowed[name] {
+				return request{}, fmt.Errorf("event %q cannot be streamed", name)
+			}
+			if err := h.mediator.ValidateEventName(name); err != nil {
+				return request{}, err
+			}
+			seen[name] = true
+			req.eventNames = append(req.eventNames, name)
+		}
+	}
+	if len(req.eventNames) == 0 {
+		return request{}, errors.New("at least one event name is required")
+	}
+	if _, ok := query["from"]; ok {
+		req.replay = true
+		req.from = query.Get("from")
+	}
+	return req, nil
+}
+
+// stream subscribes to the requested events, replays the stored ones and
+// sends them to the sink until ctx is done or sending fails
+func (h *Handler) stream(ctx context.Context, req request, out sink) error {
+	events := make(chan mediator.Event, h.config.Buffer)
+	overflow := make(chan struct{})
+	var once sync.Once
+	handler := func(publishCtx context.Context, event mediator.Event) error {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			// The stream ended; the event is not this client's anymore
+		default:
+			once.Do(func() { close(overflow) })
+		}
+		return nil
+	}
+	// Subscribe before replaying so no event falls between the two, and name
+	// each stream's handler apart, for dead letters and deduplication
+	handlerName := "livestream." + newID()
+	for _, name := range req.eventNames {
+		sub := h.mediator.Subscribe(name, handler, mediator.WithHandlerName(handlerName))
+		defer sub.Unsubscribe()
+	}
+
+	var replayed map[string]bool
+	if req.replay {
+		var err error
+		if replayed, err = h.replay(ctx, req, out); err != nil {
+			return err
+		}
+	}
+
+	var heartbeat <-chan time.Time
+	if h.config.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(h.config.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-overflow:
+			return errSlowClient
+		case <-heartbeat:
+			if err := out.heartbeat(); err != nil {
+				return err
+			}
+		case event := <-events:
+			if replayed[event.ID] {
+				continue
+			}
+			if err := out.send(Message{Event: event}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replay sends the stored events of the requested names after the offset,
+// oldest first, and returns their IDs
+func (h *Handler) replay(ctx context.Context, req request, out sink) (map[string]bool, error) {
+	replayed := make(map[string]bool)
+	for _, name := range req.eventNames {
+		cursor, sent := req.from, 0
+		for {
+			page, next, err := h.mediator.GetEventsPage(ctx, name, cursor, mediator.DefaultPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to replay events: %w", err)
+			}
+			for _, stored := range page {
+				if h.config.MaxReplay > 0 && sent >= h.config.MaxReplay {
+					break
+				}
+				event := stored.Event()
+				if err := out.send(Message{Event: event, Offset: stored.Offset}); err != nil {
+					return nil, err
+				}
+				replayed[event.ID] = true
+				sent++
+			}
+			if next == "" || (h.config.MaxReplay > 0 && sent >= h.config.MaxReplay) {
+				break
+			}
+			cursor = next
+		}
+	}
+	return replayed, nil
+}
+
+// logf reports a message to the Logger
+func (h *Handler) logf(format string, args ...interface{}) {
+	if h.config.Logger != nil {
+		h.config.Logger.Printf("livestream: "+format, args...)
+	}
+}
+
+// newID returns a random hex ID
+func newID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,114 @@
+package livestream
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+// handlerCount returns the number of handlers of an event name
+func handlerCount(m *mediator.Mediator, eventName string) int {
+	for _, info := range m.Subscriptions() {
+		if info.EventName == eventName {
+			return info.HandlerCount
+		}
+	}
+	return 0
+}
+
+// waitHandlers waits until an event name has n handlers
+func waitHandlers(t *testing.T, m *mediator.Mediator, eventName string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for handlerCount(m, eventName) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %d handlers of %s", n, eventName)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// storedMediator returns a mediator with events evt-1 to evt-3 of
+// "order.placed" stored
+func storedMediator(t *testing.T) *mediator.Mediator {
+	t.Helper()
+	m := mediator.NewMediator(mediator.WithEventStore(mediatortest.NewEventStore()))
+	sub := m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+	start := time.Now()
+	for i, id := range []string{"evt-1", "evt-2", "evt-3"} {
+		event := mediator.Event{Name: "order.placed", ID: id, Timestamp: start.Add(time.Duration(i) * time.Millisecond)}
+		if err := m.Publish(context.Background(), event); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	sub.Unsubscribe()
+	return m
+}
+
+func TestHandler_Parse(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowed    []string
+		query      string
+		wantNames  []string
+		wantReplay bool
+		wantFrom   string
+		wantErr    bool
+	}{
+		{"comma separated", nil, "events=order.placed,order.shipped", []string{"order.placed", "order.shipped"}, false, "", false},
+		{"repeated and duplicated", nil, "events=order.placed&events=order.shipped&events=order.placed", []string{"order.placed", "order.shipped"}, false, "", false},
+		{"replay from offset", nil, "events=order.placed&from=42", []string{"order.placed"}, true, "42", false},
+		{"replay from oldest", nil, "events=order.placed&from=", []string{"order.placed"}, true, "", false},
+		{"no events", nil, "from=42", nil, false, "", true},
+		{"allowed", []string{"order.placed"}, "events=order.placed", []string{"order.placed"}, false, "", false},
+		{"not allowed", []string{"order.placed"}, "events=user.created", nil, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Events = tt.allowed
+			h := NewHandler(mediator.NewMediator(), config)
+
+			req, err := h.parse(httptest.NewRequest("GET", "/events?"+tt.query, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(req.eventNames, tt.wantNames) || req.replay != tt.wantReplay || req.from != tt.wantFrom {
+				t.Errorf("parse() = %+v, want names %v, replay %v from %q", req, tt.wantNames, tt.wantReplay, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestHandler_ParseUnknownEvent(t *testing.T) {
+	m := mediator.NewMediator(mediator.WithStrictEvents())
+	if err := mediator.RegisterEvent[interface{}](m, "order.placed"); err != nil {
+		t.Fatalf("RegisterEvent() error = %v", err)
+	}
+	h := NewHandler(m, DefaultConfig())
+
+	if _, err := h.parse(httptest.NewRequest("GET", "/events?events=order.placed", nil)); err != nil {
+		t.Errorf("parse() of a registered event error = %v", err)
+	}
+	if _, err := h.parse(httptest.NewRequest("GET", "/events?events=order.plcaed", nil)); !errors.Is(err, mediator.ErrUnknownEvent) {
+		t.Errorf("parse() of an unknown event error = %v, want ErrUnknownEvent", err)
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(mediator.NewMediator(), DefaultConfig()).ServeHTTP(rec, httptest.NewRequest("POST", "/events?events=order.placed", nil))
+	if rec.Code != 405 {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package livestream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// sseSink writes messages as Server-Sent Events
+type sseSink struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// send writes a message as an event named after the mediator event, with its
+// ID as the event ID and its JSON encoding as data
+func (s *sseSink) send(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", msg.ID, err)
+	}
+	if _, err := fmt.Fprintf(s.w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Name, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// heartbeat writes a comment, which clients ignore
+func (s *sseSink) heartbeat() error {
+	if _, err := fmt.Fprint(s.w, ": heartbeat\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// serveSSE streams events as Server-Sent Events
+func (h *Handler) serveSSE(w http.ResponseWriter, r *http.Request, req request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keep proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if err := h.stream(r.Context(), req, &sseSink{w: w, flusher: flusher}); err != nil {
+		h.logf("event stream of %s ended: %v", r.RemoteAddr, err)
+	}
+}
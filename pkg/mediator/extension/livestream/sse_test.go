@@ -0,0 +1,186 @@
+package livestream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// sseEvent is an event read from a Server-Sent Events stream
+type sseEvent struct {
+	id, name string
+	message  Message
+}
+
+// readSSE reads the events of a stream, skipping comments
+func readSSE(t *testing.T, resp *http.Response) <-chan sseEvent {
+	t.Helper()
+	events := make(chan sseEvent, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.message)
+			case line == "" && event.id != "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextSSE returns the next event of a stream
+func nextSSE(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("Stream ended")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return sseEvent{}
+	}
+}
+
+// openSSE opens a stream on a test server serving h
+func openSSE(t *testing.T, h *Handler, query string) (*http.Response, context.CancelFunc) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/events?"+query, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, cancel
+}
+
+func TestServeSSE(t *testing.T) {
+	m := mediator.NewMediator()
+	resp, cancel := openSSE(t, NewHandler(m, DefaultConfig()), "events=order.placed,order.shipped")
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s, want text/event-stream", ct)
+	}
+	events := readSSE(t, resp)
+	waitHandlers(t, m, "order.shipped", 1)
+
+	ctx := context.Background()
+	m.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"id": "o-1"}})
+	m.Publish(ctx, mediator.Event{Name: "user.created", ID: "evt-2"})
+	m.Publish(ctx, mediator.Event{Name: "order.shipped", ID: "evt-3"})
+
+	event := nextSSE(t, events)
+	if event.id != "evt-1" || event.name != "order.placed" || event.message.ID != "evt-1" {
+		t.Errorf("Received %+v, want evt-1", event)
+	}
+	if payload, ok := event.message.Payload.(map[string]interface{}); !ok || payload["id"] != "o-1" {
+		t.Errorf("Expected payload {id: o-1}, got %#v", event.message.Payload)
+	}
+	if event := nextSSE(t, events); event.id != "evt-3" {
+		t.Errorf("Received %s, want evt-3", event.id)
+	}
+
+	// Test the stream's handlers are removed once the client disconnects
+	cancel()
+	waitHandlers(t, m, "order.placed", 0)
+}
+
+func TestServeSSE_Replay(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      string
+		maxReplay int
+		want      []string
+	}{
+		{"from oldest", "", 0, []string{"evt-1", "evt-2", "evt-3", "evt-4"}},
+		{"from offset", "2", 0, []string{"evt-3", "evt-4"}},
+		{"bounded", "", 2, []string{"evt-1", "evt-2", "evt-4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := storedMediator(t)
+			config := DefaultConfig()
+			config.MaxReplay = tt.maxReplay
+			resp, _ := openSSE(t, NewHandler(m, config), "events=order.placed&from="+tt.from)
+			events := readSSE(t, resp)
+
+			for _, want := range tt.want[:len(tt.want)-1] {
+				if event := nextSSE(t, events); event.id != want || event.message.Offset == "" {
+					t.Errorf("Received %+v, want replayed %s", event, want)
+				}
+			}
+			waitHandlers(t, m, "order.placed", 1)
+			m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-4"})
+			if event := nextSSE(t, events); event.id != "evt-4" || event.message.Offset != "" {
+				t.Errorf("Received %+v, want live evt-4", event)
+			}
+		})
+	}
+}
+
+func TestServeSSE_SlowClient(t *testing.T) {
+	m := mediator.NewMediator()
+	config := DefaultConfig()
+	config.Buffer = 1
+	h := NewHandler(m, config)
+
+	// A recorder that is never read from stands for a client that stopped reading
+	block := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(&blockingRecorder{ResponseRecorder: httptest.NewRecorder(), block: block}, httptest.NewRequest("GET", "/events?events=order.placed", nil))
+	}()
+	waitHandlers(t, m, "order.placed", 1)
+
+	// Test publishes do not wait for the client, and its stream ends once
+	// the pending write returns
+	for _, id := range []string{"evt-1", "evt-2", "evt-3", "evt-4"} {
+		if err := m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: id}); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	close(block)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stream of the slow client to end")
+	}
+	waitHandlers(t, m, "order.placed", 0)
+}
+
+// blockingRecorder is a ResponseRecorder whose event writes block until
+// block is closed
+type blockingRecorder struct {
+	*httptest.ResponseRecorder
+	block chan struct{}
+}
+
+func (r *blockingRecorder) Write(b []byte) (int, error) {
+	if strings.HasPrefix(string(b), "id: ") {
+		<-r.block
+	}
+	return r.ResponseRecorder.Write(b)
+}
@@ -0,0 +1,76 @@
+package livestream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+// websocketSink writes messages as WebSocket text messages
+type websocketSink struct {
+	conn *websocket.Conn
+}
+
+// send writes the JSON encoding of a message
+func (s *websocketSink) send(msg Message) error {
+	return websocket.JSON.Send(s.conn, msg)
+}
+
+// heartbeat does nothing; WebSocket connections stay open without traffic
+func (s *websocketSink) heartbeat() error {
+	return nil
+}
+
+// isWebSocket reports whether a request asks to upgrade to WebSocket
+func isWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// serveWebSocket upgrades the connection and streams events as JSON text
+// messages. Messages from the client are discarded; the stream ends when the
+// client closes the connection.
+func (h *Handler) serveWebSocket(w http.ResponseWriter, r *http.Request, req request) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if !h.checkOrigin(r) {
+				return errors.New("origin not allowed")
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			// Read until the client closes the connection
+			go func() {
+				defer cancel()
+				var discard []byte
+				for websocket.Message.Receive(conn, &discard) == nil {
+				}
+			}()
+
+			if err := h.stream(ctx, req, &websocketSink{conn: conn}); err != nil {
+				h.logf("event stream of %s ended: %v", r.RemoteAddr, err)
+			}
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// checkOrigin applies CheckOrigin, or accepts requests without an Origin or
+// from the host they are sent to
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	if h.config.CheckOrigin != nil {
+		return h.config.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
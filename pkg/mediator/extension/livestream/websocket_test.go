@@ -0,0 +1,78 @@
+package livestream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"golang.org/x/net/websocket"
+)
+
+// dial opens a WebSocket stream on a test server serving h, from the
+// server's own origin when origin is empty
+func dial(t *testing.T, h *Handler, query, origin string) (*websocket.Conn, error) {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	if origin == "" {
+		origin = srv.URL
+	}
+	return websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/events?"+query, "", origin)
+}
+
+func TestServeWebSocket(t *testing.T) {
+	m := storedMediator(t)
+	h := NewHandler(m, DefaultConfig())
+	conn, err := dial(t, h, "events=order.placed&from=2", "")
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	waitHandlers(t, m, "order.placed", 1)
+	m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-4"})
+
+	for _, want := range []string{"evt-3", "evt-4"} {
+		var msg Message
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+		if msg.ID != want || msg.Name != "order.placed" {
+			t.Errorf("Received %+v, want %s", msg, want)
+		}
+	}
+
+	// Test the stream's handlers are removed once the client disconnects
+	conn.Close()
+	waitHandlers(t, m, "order.placed", 0)
+}
+
+func TestServeWebSocket_Origin(t *testing.T) {
+	tests := []struct {
+		name        string
+		origin      string
+		checkOrigin func(r *http.Request) bool
+		wantErr     bool
+	}{
+		{"same host", "", nil, false},
+		{"other host", "http://evil.example.com", nil, true},
+		{"allowed by CheckOrigin", "http://app.example.com", func(r *http.Request) bool {
+			return r.Header.Get("Origin") == "http://app.example.com"
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.CheckOrigin = tt.checkOrigin
+			conn, err := dial(t, NewHandler(mediator.NewMediator(), config), "events=order.placed", tt.origin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Dial() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if conn != nil {
+				conn.Close()
+			}
+		})
+	}
+}
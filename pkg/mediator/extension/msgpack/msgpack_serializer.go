@@ -0,0 +1,27 @@
+package msgpack
+
+import (
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/shamaton/msgpack/v2"
+)
+
+// Serializer encodes event payloads as MessagePack. Like JSON, decoding into
+// *interface{} yields generic maps and slices.
+type Serializer struct{}
+
+var _ mediator.Serializer = Serializer{}
+
+// ContentType returns "application/msgpack"
+func (Serializer) ContentType() string {
+	return "application/msgpack"
+}
+
+// Marshal encodes v as MessagePack
+func (Serializer) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes MessagePack data into v
+func (Serializer) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,183 @@
+package msgpack
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+type product struct {
+	ID    string
+	Name  string
+	Price float64
+}
+
+func TestSerializer_RoundTrip(t *testing.T) {
+	s := Serializer{}
+	want := product{ID: "p-1", Name: "Coffee", Price: 4.5}
+
+	data, err := s.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got product
+	if err := s.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSerializer_TypedHandler(t *testing.T) {
+	m := mediator.NewMediator(mediator.WithSerializer(Serializer{}))
+
+	var got product
+	_, err := mediator.SubscribeTyped(m, "product.created", func(ctx context.Context, payload product) error {
+		got = payload
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SubscribeTyped() error = %v", err)
+	}
+
+	data, err := Serializer{}.Marshal(product{ID: "p-1", Name: "Coffee"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := m.Publish(context.Background(), mediator.Event{Name: "product.created", Payload: data}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got.ID != "p-1" || got.Name != "Coffee" {
+		t.Errorf("handler received %+v, want p-1 Coffee", got)
+	}
+}
+
+// order holds every kind of field a registered payload may have
+type order struct {
+	ID     string
+	Count  int64
+	Big    uint64
+	Ratio  float64
+	Active bool
+	Tags   []string
+	Attrs  map[string]string
+	Data   []byte
+	At     time.Time
+	Lines  []line
+}
+
+type line struct {
+	SKU string
+	Qty int
+}
+
+// Generate fills every field, leaving empty slices and maps nil
+func (order) Generate(r *rand.Rand, size int) reflect.Value {
+	text := func() string {
+		v, _ := quick.Value(reflect.TypeOf(""), r)
+		return v.String()
+	}
+	o := order{
+		ID:     text(),
+		Count:  r.Int63() - r.Int63(),
+		Big:    r.Uint64(),
+		Ratio:  r.NormFloat64() * math.Pow(10, float64(r.Intn(40)-20)),
+		Active: r.Intn(2) == 1,
+		At:     time.Unix(r.Int63n(1<<33), r.Int63n(1e9)).UTC(),
+	}
+	for i := r.Intn(size + 1); i > 0; i-- {
+		o.Tags = append(o.Tags, text())
+		o.Data = append(o.Data, byte(r.Intn(256)))
+		o.Lines = append(o.Lines, line{SKU: text(), Qty: r.Int() - r.Int()})
+	}
+	if n := r.Intn(size + 1); n > 0 {
+		o.Attrs = make(map[string]string, n)
+		for ; n > 0; n-- {
+			o.Attrs[text()] = text()
+		}
+	}
+	return reflect.ValueOf(o)
+}
+
+// roundTrip encodes payload into a stored record, decodes it again and
+// dispatches it, returning the payload the handler received. MessagePack
+// decodes times in the local time zone, so At is compared as an instant.
+func roundTrip(payload order) (interface{}, error) {
+	registry := mediator.NewTypeRegistry()
+	mediator.RegisterType[order](registry, "order.placed")
+	m := mediator.NewMediator(mediator.WithSerializer(Serializer{}), mediator.WithTypeRegistry(registry))
+
+	var got interface{}
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		got = event.Payload
+		return nil
+	})
+
+	data, err := mediator.EncodeEventRecord(Serializer{}, mediator.Event{Name: "order.placed", ID: "evt-1", Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	stored, err := mediator.DecodeStoredEvent(Serializer{}, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.DispatchStored(context.Background(), stored); err != nil {
+		return nil, err
+	}
+	if o, ok := got.(order); ok && o.At.Equal(payload.At) {
+		o.At = payload.At
+		got = o
+	}
+	return got, nil
+}
+
+func TestSerializer_RoundTripProperty(t *testing.T) {
+	property := func(payload order) bool {
+		got, err := roundTrip(payload)
+		if err != nil {
+			t.Logf("round trip of %+v failed: %v", payload, err)
+			return false
+		}
+		if !reflect.DeepEqual(got, payload) {
+			t.Logf("round trip changed\n  %+v\nto\n  %+v", payload, got)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzSerializer_RoundTrip(f *testing.F) {
+	f.Add("o-1", int64(42), uint64(1)<<63, 9.5, []byte("data"), "tag")
+	f.Add("", int64(math.MinInt64), uint64(math.MaxUint64), -0.0, []byte{}, "")
+	f.Add("\xff\xfe", int64(1)<<53+1, uint64(1)<<53+1, math.Inf(1), []byte{0, 255}, "\x00")
+
+	// Unlike JSON, MessagePack keeps invalid UTF-8 and non-finite floats
+	f.Fuzz(func(t *testing.T, id string, count int64, big uint64, ratio float64, data []byte, tag string) {
+		if math.IsNaN(ratio) {
+			t.Skip()
+		}
+		payload := order{ID: id, Count: count, Big: big, Ratio: ratio, Tags: []string{tag}, Attrs: map[string]string{tag: id}}
+		if len(data) > 0 {
+			payload.Data = data
+		}
+
+		got, err := roundTrip(payload)
+		if err != nil {
+			t.Fatalf("round trip failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, payload) {
+			t.Errorf("round trip changed %#v to %#v", payload, got)
+		}
+	})
+}
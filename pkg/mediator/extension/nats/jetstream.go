@@ -0,0 +1,138 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ConsumerConfig configures a Consumer
+type ConsumerConfig struct {
+	// Stream is the JetStream stream consumed
+	Stream string
+	// Durable names the durable consumer, whose position the server keeps;
+	// the instances of a service share it, each message going to one of them
+	Durable string
+	// FilterSubject limits the consumer to a subject; the whole stream when empty
+	FilterSubject string
+	// Since starts a new durable consumer at the events stored since then,
+	// replaying them; the whole stream is replayed when zero
+	Since time.Time
+	// AckWait is how long the server waits for an event to be acknowledged
+	// before redelivering it
+	AckWait time.Duration
+	// MaxDeliver is how many times an event is delivered before the server
+	// gives up on it; -1 redelivers forever
+	MaxDeliver int
+	// Origin identifies this instance, whose own events are acknowledged
+	// without being dispatched; the random ID of the process's transports is
+	// used when empty
+	Origin string
+	// Serializer decodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports events that could not be decoded or dispatched;
+	// nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultConsumerConfig returns default consumer configuration
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		Stream:     "MEDIATOR",
+		AckWait:    30 * time.Second,
+		MaxDeliver: 5,
+	}
+}
+
+// Consumer feeds the events of a JetStream stream into a mediator through a
+// durable consumer, acknowledging each event once it was dispatched, so
+// events are delivered at least once and survive restarts
+type Consumer struct {
+	js       jetstream.JetStream
+	mediator *mediator.Mediator
+	config   ConsumerConfig
+}
+
+// NewConsumer creates a consumer dispatching the events of a stream into m
+func NewConsumer(js jetstream.JetStream, m *mediator.Mediator, config ConsumerConfig) *Consumer {
+	if config.Origin == "" {
+		config.Origin = processOrigin
+	}
+	return &Consumer{js: js, mediator: m, config: config}
+}
+
+// Run creates or updates the durable consumer and dispatches its events
+// until ctx is cancelled. Events whose handlers fail, after the mediator's
+// retries, are redelivered up to MaxDeliver times; messages that are not
+// events are terminated.
+func (c *Consumer) Run(ctx context.Context) error {
+	if c.config.Durable == "" {
+		return fmt.Errorf("a durable consumer name is required")
+	}
+
+	config := jetstream.ConsumerConfig{
+		Durable:       c.config.Durable,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: c.config.FilterSubject,
+		AckWait:       c.config.AckWait,
+		MaxDeliver:    c.config.MaxDeliver,
+	}
+	if !c.config.Since.IsZero() {
+		since := c.config.Since
+		config.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		config.OptStartTime = &since
+	}
+	consumer, err := c.js.CreateOrUpdateConsumer(ctx, c.config.Stream, config)
+	if err != nil {
+		return fmt.Errorf("failed to create consumer %s: %w", c.config.Durable, err)
+	}
+
+	messages, err := consumer.Messages()
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", c.config.Durable, err)
+	}
+	defer messages.Stop()
+	go func() {
+		<-ctx.Done()
+		messages.Stop()
+	}()
+
+	for {
+		msg, err := messages.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to receive message: %w", err)
+		}
+		if err := c.handle(ctx, msg); err != nil && c.config.Logger != nil {
+			c.config.Logger.Printf("nats consumer: %v", err)
+		}
+	}
+}
+
+// handle dispatches the event of a message and acknowledges it
+func (c *Consumer) handle(ctx context.Context, msg jetstream.Msg) error {
+	if msg.Headers().Get(OriginHeader) == c.config.Origin {
+		return msg.Ack()
+	}
+
+	stored, err := mediator.DecodeStoredEvent(c.config.Serializer, msg.Data())
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal event: %w", err)
+		return errors.Join(err, msg.Term())
+	}
+	if err := c.mediator.DispatchRemote(ctx, stored.Event()); err != nil {
+		err = fmt.Errorf("failed to dispatch remote event %s: %w", stored.ID, err)
+		// Leave an event interrupted by shutdown to AckWait
+		if ctx.Err() != nil {
+			return err
+		}
+		return errors.Join(err, msg.Nak())
+	}
+	return msg.Ack()
+}
@@ -0,0 +1,110 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+)
+
+func TestConsumer(t *testing.T) {
+	_, conn := runTestServer(t)
+	js := createStream(t, conn)
+
+	// Publish before the consumer exists, as another service
+	transportConfig := DefaultTransportConfig()
+	transportConfig.JetStream = true
+	transportConfig.Origin = "orders"
+	transport, err := NewTransport(conn, transportConfig)
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	defer transport.Close()
+	producer := mediator.NewMediator(mediator.WithTransport(transport))
+	ctx := context.Background()
+	for _, id := range []string{"evt-1", "evt-2"} {
+		err := producer.Publish(ctx, mediator.Event{Name: "order.placed", ID: id})
+		if err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// Fail evt-1 once to have it redelivered
+	recorder := deliverytest.NewRecorder()
+	var failed atomic.Bool
+	m := mediator.NewMediator()
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		if event.ID == "evt-1" && failed.CompareAndSwap(false, true) {
+			return errors.New("temporary failure")
+		}
+		if !mediator.IsRemote(event) {
+			t.Errorf("Expected %s to be marked remote", event.ID)
+		}
+		return recorder.Handle(ctx, event)
+	})
+	received := func() []string {
+		var ids []string
+		for _, event := range recorder.Events() {
+			ids = append(ids, event.ID)
+		}
+		return ids
+	}
+
+	config := DefaultConsumerConfig()
+	config.Durable = "billing"
+	config.Origin = "billing"
+	run := func() (context.CancelFunc, chan error) {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- NewConsumer(js, m, config).Run(runCtx) }()
+		return cancel, done
+	}
+
+	cancel, done := run()
+	for _, id := range []string{"evt-1", "evt-2"} {
+		if !recorder.WaitFor(id, 5*time.Second) {
+			t.Fatalf("Expected evt-1 and evt-2, got %v", received())
+		}
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+
+	// Test the durable consumer resumes after the acknowledged events
+	if err := producer.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-3"}); err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	cancel, done = run()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	if !recorder.WaitFor("evt-3", 5*time.Second) {
+		t.Fatalf("Expected evt-3 after restarting, got %v", received())
+	}
+
+	// Test nothing acknowledged before the restart is redelivered
+	publish := func(ctx context.Context, event mediator.Event) error {
+		if err := producer.Publish(ctx, event); err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+			return err
+		}
+		return nil
+	}
+	deliverytest.Settle(t, 5*time.Second, publish, mediator.Event{Name: "order.placed"}, recorder)
+	if got := received(); len(got) != 3 || got[2] != "evt-3" {
+		t.Errorf("Expected each event once, got %v", got)
+	}
+}
+
+func TestConsumer_RequiresDurable(t *testing.T) {
+	_, conn := runTestServer(t)
+	js := createStream(t, conn)
+	if err := NewConsumer(js, mediator.NewMediator(), DefaultConsumerConfig()).Run(context.Background()); err == nil {
+		t.Error("Expected an error without a durable name")
+	}
+}
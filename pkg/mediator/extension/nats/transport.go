@@ -0,0 +1,222 @@
+package nats
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// OriginHeader carries the Origin of the publishing instance
+const OriginHeader = "Mediator-Origin"
+
+// processOrigin is the Origin of transports and consumers configured
+// without one, so those of a process skip its own events
+var processOrigin = randomID()
+
+// randomID returns a random hex ID
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// TransportConfig configures a Transport
+type TransportConfig struct {
+	// SubjectPrefix is prepended to event names to form their subjects
+	SubjectPrefix string
+	// Events limits the events published to these names; every event when empty
+	Events []string
+	// JetStream publishes to a JetStream stream capturing the subjects,
+	// waiting for it to acknowledge each event
+	JetStream bool
+	// Queue is the queue group shared by the instances of a service, each
+	// event going to one of them; every instance gets every event when empty
+	Queue string
+	// Buffer is how many events a subscription buffers for the mediator
+	Buffer int
+	// Origin identifies this instance, whose own events its consumers skip;
+	// a random ID shared by the process is used when empty
+	Origin string
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be decoded; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultTransportConfig returns default transport configuration
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		SubjectPrefix: "mediator.",
+		Buffer:        64,
+	}
+}
+
+// Transport is a mediator.Transport carrying events over NATS subjects
+// named after the events. Attach it with mediator.WithTransport: the events
+// dispatched locally are published to their subjects, and the subjects of
+// the event names subscribed to are dispatched to the local handlers. Core
+// NATS delivers at most once, to the subscribers connected at the time; use
+// a Consumer on a JetStream stream for durable delivery.
+type Transport struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	config TransportConfig
+	events map[string]bool
+
+	// ctx stops the subscriptions when the transport is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ mediator.Transport = (*Transport)(nil)
+
+// NewTransport creates a transport on conn
+func NewTransport(conn *nats.Conn, config TransportConfig) (*Transport, error) {
+	if config.SubjectPrefix == "" {
+		config.SubjectPrefix = DefaultTransportConfig().SubjectPrefix
+	}
+	if config.Origin == "" {
+		config.Origin = processOrigin
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{
+		conn:   conn,
+		config: config,
+		events: make(map[string]bool, len(config.Events)),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if config.JetStream {
+		js, err := jetstream.New(conn)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+		t.js = js
+	}
+	for _, name := range config.Events {
+		t.events[name] = true
+	}
+	return t, nil
+}
+
+// Subject returns the subject of an event name. Characters NATS does not
+// allow in subjects become underscores; the dots of an event name separate
+// subject tokens, so wildcards such as "mediator.order.>" match event names.
+func (t *Transport) Subject(eventName string) string {
+	return t.config.SubjectPrefix + strings.Map(func(r rune) rune {
+		if r == '*' || r == '>' || r <= ' ' {
+			return '_'
+		}
+		return r
+	}, eventName)
+}
+
+// Publish publishes an event to its subject, unless Events leaves its name
+// out. With JetStream the event ID is the message ID, so the stream drops an
+// event published twice within its duplicate window.
+func (t *Transport) Publish(ctx context.Context, event mediator.Event) error {
+	if len(t.events) > 0 && !t.events[event.Name] {
+		return nil
+	}
+
+	data, err := mediator.EncodeEventRecord(t.config.Serializer, event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := nats.NewMsg(t.Subject(event.Name))
+	msg.Data = data
+	msg.Header.Set(OriginHeader, t.config.Origin)
+
+	if t.js != nil {
+		if event.ID != "" {
+			msg.Header.Set(nats.MsgIdHdr, event.ID)
+		}
+		if _, err := t.js.PublishMsg(ctx, msg); err != nil {
+			return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+		}
+		return nil
+	}
+	if err := t.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Subscribe returns the events published to the subject of an event name,
+// in the Queue group if any, until the transport is closed, when the
+// channel is closed
+func (t *Transport) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	if t.ctx.Err() != nil {
+		return nil, fmt.Errorf("transport is closed")
+	}
+
+	msgs := make(chan *nats.Msg, t.config.Buffer)
+	sub, err := t.conn.ChanQueueSubscribe(t.Subject(eventName), t.config.Queue, msgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", t.Subject(eventName), err)
+	}
+	// Have the server register the subscription before events are published
+	if err := t.conn.Flush(); err != nil {
+		sub.Unsubscribe()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", t.Subject(eventName), err)
+	}
+
+	events := make(chan mediator.Event, t.config.Buffer)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(events)
+		defer sub.Unsubscribe()
+		t.receive(msgs, events)
+	}()
+	return events, nil
+}
+
+// receive decodes the messages of a subscription into events until the
+// transport is closed
+func (t *Transport) receive(msgs <-chan *nats.Msg, events chan<- mediator.Event) {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case msg := <-msgs:
+			stored, err := mediator.DecodeStoredEvent(t.config.Serializer, msg.Data)
+			if err != nil {
+				t.logf("failed to unmarshal event: %v", err)
+				continue
+			}
+
+			select {
+			case events <- stored.Event():
+			case <-t.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Close stops the subscriptions and closes their channels. It does not
+// close the connection.
+func (t *Transport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// logf reports a failure to the configured logger
+func (t *Transport) logf(format string, args ...interface{}) {
+	if t.config.Logger != nil {
+		t.config.Logger.Printf("nats transport: "+format, args...)
+	}
+}
@@ -0,0 +1,176 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// runTestServer starts an embedded NATS server with JetStream and connects to it
+func runTestServer(t *testing.T) (*server.Server, *nats.Conn) {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create NATS server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("Timed out waiting for the NATS server")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS: %v", err)
+	}
+	t.Cleanup(conn.Close)
+	return srv, conn
+}
+
+// createStream creates the stream capturing every mediator subject
+func createStream(t *testing.T, conn *nats.Conn) jetstream.JetStream {
+	t.Helper()
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	_, err = js.CreateOrUpdateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "MEDIATOR",
+		Subjects: []string{"mediator.>"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create stream: %v", err)
+	}
+	return js
+}
+
+func TestTransport_Subject(t *testing.T) {
+	_, conn := runTestServer(t)
+	transport, err := NewTransport(conn, DefaultTransportConfig())
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+
+	tests := []struct {
+		eventName string
+		want      string
+	}{
+		{"order.placed", "mediator.order.placed"},
+		{"acme:order.placed", "mediator.acme:order.placed"},
+		{"order *>", "mediator.order___"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventName, func(t *testing.T) {
+			if got := transport.Subject(tt.eventName); got != tt.want {
+				t.Errorf("Subject() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	_, conn := runTestServer(t)
+	ctx := context.Background()
+
+	// Start two instances attached to each other
+	recorders := []*deliverytest.Recorder{deliverytest.NewRecorder(), deliverytest.NewRecorder()}
+	mediators := make([]*mediator.Mediator, len(recorders))
+	for i := range mediators {
+		transport, err := NewTransport(conn, DefaultTransportConfig())
+		if err != nil {
+			t.Fatalf("Failed to create transport: %v", err)
+		}
+		defer transport.Close()
+
+		m := mediator.NewMediator(mediator.WithTransport(transport))
+		m.Subscribe("order.placed", recorders[i].Handle)
+		mediators[i] = m
+	}
+
+	placed := mediator.Event{Name: "order.placed", ID: "evt-1"}
+	if err := mediators[0].Publish(ctx, placed); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Test the publishing instance dispatches once, the other once, remotely
+	deliverytest.CheckOnce(t, 5*time.Second, placed, mediators, recorders)
+}
+
+func TestTransport_JetStream(t *testing.T) {
+	_, conn := runTestServer(t)
+	js := createStream(t, conn)
+
+	config := DefaultTransportConfig()
+	config.JetStream = true
+	config.Events = []string{"order.placed"}
+	transport, err := NewTransport(conn, config)
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	defer transport.Close()
+	m := mediator.NewMediator(mediator.WithTransport(transport))
+
+	ctx := context.Background()
+	events := []mediator.Event{
+		{Name: "order.placed", ID: "evt-1"},
+		// Published again, e.g. by an outbox relay retrying
+		{Name: "order.placed", ID: "evt-1"},
+		{Name: "cache.warmed", ID: "evt-2"},
+	}
+	for _, event := range events {
+		if err := m.Publish(ctx, event); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	// Events of other instances are not published again
+	if err := m.DispatchRemote(ctx, mediator.Event{Name: "order.placed", ID: "evt-3"}); err != nil {
+		t.Fatalf("Failed to dispatch: %v", err)
+	}
+
+	stream, err := js.Stream(ctx, "MEDIATOR")
+	if err != nil {
+		t.Fatalf("Failed to get stream: %v", err)
+	}
+	info, err := stream.Info(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get stream info: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Errorf("Expected evt-1 to be stored once, got %d messages", info.State.Msgs)
+	}
+}
+
+func TestTransport_Close(t *testing.T) {
+	_, conn := runTestServer(t)
+	transport, err := NewTransport(conn, DefaultTransportConfig())
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	events, err := transport.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// Test closing the transport closes the channel of its subscription
+	transport.Close()
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if _, err := transport.Subscribe("order.placed"); err == nil {
+		t.Error("Subscribe() error = nil after Close")
+	}
+}
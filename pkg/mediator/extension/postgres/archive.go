@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// archiveColumns are the columns copied to the archive table
+const archiveColumns = "id, event_name, event_data, created_at, stream_id, stream_version"
+
+// archiveBatchSize is how many rows of a dropped partition an Archiver is
+// given at a time
+const archiveBatchSize = 1000
+
+// ArchivedEvent is an event removed from the events table by retention
+type ArchivedEvent struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// Data is the stored event record, payload and metadata included
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Archiver keeps the events retention removes, e.g. in cold storage. Events
+// are archived before they are deleted and kept when Archive fails, so an
+// event may be archived twice if deleting it fails afterwards.
+type Archiver interface {
+	// Archive stores events removed from the events table named table
+	Archive(ctx context.Context, table string, events []ArchivedEvent) error
+}
+
+// ArchiverFunc adapts a function to an Archiver
+type ArchiverFunc func(ctx context.Context, table string, events []ArchivedEvent) error
+
+// Archive calls f
+func (f ArchiverFunc) Archive(ctx context.Context, table string, events []ArchivedEvent) error {
+	return f(ctx, table, events)
+}
+
+// JSONLArchiver writes every batch of archived events as JSON lines to a
+// writer of its own, e.g. an object uploaded to S3 or GCS
+type JSONLArchiver struct {
+	// Open returns the writer of a batch of events removed from table. It is
+	// closed once the batch is written and must report a failed upload then.
+	Open func(ctx context.Context, table string) (io.WriteCloser, error)
+}
+
+// Archive writes events to a writer returned by Open
+func (a JSONLArchiver) Archive(ctx context.Context, table string, events []ArchivedEvent) error {
+	w, err := a.Open(ctx, table)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			w.Close()
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+	return nil
+}
+
+// deleteStatement returns a statement deleting the rows of the events table of
+// t matching where, which moves them to the archive table when ArchiveTable is set
+func (s *EventStore) deleteStatement(t tables, where string) string {
+	if !s.config.ArchiveTable {
+		return fmt.Sprintf("DELETE FROM %s WHERE %s", t.events(), where)
+	}
+	return fmt.Sprintf(`
+		WITH moved AS (
+			DELETE FROM %s WHERE %s
+			RETURNING %s
+		)
+		INSERT INTO %s (%s)
+		SELECT %s FROM moved
+	`, t.events(), where, archiveColumns, t.archive(), archiveColumns, archiveColumns)
+}
+
+// archiveRows gives the rows selectIDs returns to the Archiver, then deletes
+// them, and returns how many there were
+func (s *EventStore) archiveRows(ctx context.Context, t tables, selectIDs string, args ...interface{}) (int64, error) {
+	query := fmt.Sprintf(`
+		SELECT id, event_name, event_data, created_at
+		FROM %s
+		WHERE id IN (%s)
+		ORDER BY id
+	`, t.events(), selectIDs)
+
+	events, err := s.queryArchived(ctx, query, args...)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	if err := s.config.Archiver.Archive(ctx, t.name, events); err != nil {
+		return 0, fmt.Errorf("failed to archive events: %w", err)
+	}
+
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	if _, err := s.db.ExecContext(ctx, s.deleteStatement(t, "id = ANY($1::bigint[])"), pq.Array(ids)); err != nil {
+		return 0, err
+	}
+	return int64(len(events)), nil
+}
+
+// archivePartition gives the rows of a partition about to be dropped to the
+// Archiver, archiveBatchSize rows at a time
+func (s *EventStore) archivePartition(ctx context.Context, t tables, name string) error {
+	if s.config.Archiver == nil {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, event_name, event_data, created_at
+		FROM %s
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`, t.qualify(name))
+
+	var after int64
+	for {
+		events, err := s.queryArchived(ctx, query, after, archiveBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to archive partition %s: %w", name, err)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		if err := s.config.Archiver.Archive(ctx, t.name, events); err != nil {
+			return fmt.Errorf("failed to archive partition %s: %w", name, err)
+		}
+		if len(events) < archiveBatchSize {
+			return nil
+		}
+		after = events[len(events)-1].ID
+	}
+}
+
+// queryArchived returns the events the query selects as archived events
+func (s *EventStore) queryArchived(ctx context.Context, query string, args ...interface{}) ([]ArchivedEvent, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events to archive: %w", err)
+	}
+	defer rows.Close()
+
+	var events []ArchivedEvent
+	for rows.Next() {
+		var event ArchivedEvent
+		var data []byte
+		if err := rows.Scan(&event.ID, &event.Name, &data, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event to archive: %w", err)
+		}
+		event.Data = json.RawMessage(data)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events to archive: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// archiveBuffer collects an archive written by a JSONLArchiver
+type archiveBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *archiveBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestJSONLArchiver(t *testing.T) {
+	var buffer archiveBuffer
+	var openedTable string
+	archiver := JSONLArchiver{Open: func(ctx context.Context, table string) (io.WriteCloser, error) {
+		openedTable = table
+		return &buffer, nil
+	}}
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []ArchivedEvent{
+		{ID: 1, Name: "order.placed", Data: []byte(`{"id":"evt-1"}`), CreatedAt: created},
+		{ID: 2, Name: "order.paid", Data: []byte(`{"id":"evt-2"}`), CreatedAt: created},
+	}
+	if err := archiver.Archive(context.Background(), "mediator_events", events); err != nil {
+		t.Fatalf("Archive() error = %v", err)
+	}
+
+	want := `{"id":1,"name":"order.placed","data":{"id":"evt-1"},"created_at":"2024-01-02T03:04:05Z"}
+{"id":2,"name":"order.paid","data":{"id":"evt-2"},"created_at":"2024-01-02T03:04:05Z"}
+`
+	if buffer.String() != want {
+		t.Errorf("Archive() wrote %s, want %s", buffer.String(), want)
+	}
+	if !buffer.closed || openedTable != "mediator_events" {
+		t.Errorf("Archive() closed = %v, table = %s, want a closed archive of mediator_events", buffer.closed, openedTable)
+	}
+
+	failing := JSONLArchiver{Open: func(ctx context.Context, table string) (io.WriteCloser, error) {
+		return nil, errors.New("bucket unavailable")
+	}}
+	if err := failing.Archive(context.Background(), "mediator_events", events); err == nil {
+		t.Error("Archive() with a failing Open succeeded")
+	}
+}
+
+func TestEventStore_ArchiveTable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	config := DefaultConfig()
+	config.ArchiveTable = true
+	config.MaxEventsPerType = 100
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	// Expect trimmed rows to move to the archive table in the deleting statement
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`WITH moved AS \( DELETE FROM "mediator_events" WHERE id IN \(.*OFFSET \$2 \) RETURNING id, event_name, event_data, created_at, stream_id, stream_version \) INSERT INTO "mediator_events_archive" \(id, event_name, event_data, created_at, stream_id, stream_version\) SELECT .* FROM moved`).
+		WithArgs("order.placed", int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	if err := store.StoreEvent(context.Background(), mediator.Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_Archiver(t *testing.T) {
+	tests := []struct {
+		name       string
+		archiveErr error
+		wantIDs    []int64
+		wantErr    bool
+	}{
+		{name: "archived before delete", wantIDs: []int64{1, 2, 3}},
+		{name: "failed archive keeps events", archiveErr: errors.New("bucket unavailable"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create mock database: %v", err)
+			}
+			defer db.Close()
+
+			expectMigrations(mock)
+			var archived []int64
+			config := DefaultConfig()
+			config.TrimBatchSize = 2
+			config.Archiver = ArchiverFunc(func(ctx context.Context, table string, events []ArchivedEvent) error {
+				if tt.archiveErr != nil {
+					return tt.archiveErr
+				}
+				for _, event := range events {
+					archived = append(archived, event.ID)
+				}
+				return nil
+			})
+			store, err := NewEventStore(db, config)
+			if err != nil {
+				t.Fatalf("Failed to create event store: %v", err)
+			}
+
+			columns := []string{"id", "event_name", "event_data", "created_at"}
+			now := time.Now()
+			mock.ExpectQuery(`SELECT id, event_name, event_data, created_at FROM "mediator_events" WHERE id IN \(.* LIMIT 2\) ORDER BY id`).
+				WithArgs("order.placed", sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(1, "order.placed", `{"id":"evt-1"}`, now).
+					AddRow(2, "order.placed", `{"id":"evt-2"}`, now))
+			if !tt.wantErr {
+				mock.ExpectExec(`DELETE FROM "mediator_events" WHERE id = ANY\(\$1::bigint\[\]\)`).
+					WithArgs("{1,2}").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectQuery(`SELECT id, event_name, event_data, created_at FROM "mediator_events"`).
+					WithArgs("order.placed", sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(3, "order.placed", `{"id":"evt-3"}`, now))
+				mock.ExpectExec(`DELETE FROM "mediator_events" WHERE id = ANY\(\$1::bigint\[\]\)`).
+					WithArgs("{3}").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = store.DeleteBefore(context.Background(), "order.placed", now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeleteBefore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(archived) != len(tt.wantIDs) {
+				t.Errorf("archived %v, want %v", archived, tt.wantIDs)
+			}
+			for i := range tt.wantIDs {
+				if i < len(archived) && archived[i] != tt.wantIDs[i] {
+					t.Errorf("archived %v, want %v", archived, tt.wantIDs)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("There were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEventStore_ArchivePartitions(t *testing.T) {
+	var archived []ArchivedEvent
+	config := DefaultConfig()
+	config.Retention = mediator.Retention{Default: mediator.KeepFor(24 * time.Hour)}
+	config.ArchiveTable = true
+	config.Archiver = ArchiverFunc(func(ctx context.Context, table string, events []ArchivedEvent) error {
+		archived = append(archived, events...)
+		return nil
+	})
+	store, mock, db := newPartitionedStore(t, config)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT c.relname FROM pg_inherits").
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).AddRow("mediator_events_p20200101"))
+	mock.ExpectQuery(`SELECT id, event_name, event_data, created_at FROM "mediator_events_p20200101" WHERE id > \$1 ORDER BY id LIMIT \$2`).
+		WithArgs(int64(0), archiveBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_name", "event_data", "created_at"}).
+			AddRow(5, "order.placed", `{"id":"evt-5"}`, time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectExec(`INSERT INTO "mediator_events_archive" \(.*\) SELECT .* FROM "mediator_events_p20200101"; DROP TABLE IF EXISTS "mediator_events_p20200101"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := store.EnforceRetention(context.Background()); err != nil {
+		t.Fatalf("EnforceRetention() error = %v", err)
+	}
+	if len(archived) != 1 || archived[0].ID != 5 || !strings.Contains(string(archived[0].Data), "evt-5") {
+		t.Errorf("archived %+v, want evt-5", archived)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
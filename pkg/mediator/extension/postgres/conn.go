@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolStats describes the connection pool of an event store
+type PoolStats struct {
+	MaxConns   int
+	TotalConns int
+	IdleConns  int
+	InUseConns int
+	// WaitCount is the number of times a connection had to be waited for
+	WaitCount int64
+	// WaitDuration is the total time spent waiting for connections
+	WaitDuration time.Duration
+}
+
+// conn runs the store's statements on a database/sql or pgx connection pool
+type conn interface {
+	// ExecContext runs a statement and returns the number of rows affected
+	ExecContext(ctx context.Context, query string, args ...interface{}) (int64, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) row
+	// session returns a connection of the pool reserved until it is closed,
+	// for statements that must share a database session
+	session(ctx context.Context) (session, error)
+	Stats() PoolStats
+	Close() error
+}
+
+// session is a connection reserved from the pool
+type session interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) row
+	// Close returns the connection to the pool
+	Close() error
+}
+
+// rows iterates the rows of a query
+type rows interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close() error
+}
+
+// row is the single row of a query
+type row interface {
+	Scan(dest ...interface{}) error
+}
+
+// sqlConn runs statements on a database/sql pool
+type sqlConn struct {
+	db *sql.DB
+}
+
+func (c sqlConn) ExecContext(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	result, err := c.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (c sqlConn) QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error) {
+	return c.db.QueryContext(ctx, query, args...)
+}
+
+func (c sqlConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	return c.db.QueryRowContext(ctx, query, args...)
+}
+
+func (c sqlConn) session(ctx context.Context) (session, error) {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqlSession{conn}, nil
+}
+
+func (c sqlConn) Stats() PoolStats {
+	stats := c.db.Stats()
+	return PoolStats{
+		MaxConns:     stats.MaxOpenConnections,
+		TotalConns:   stats.OpenConnections,
+		IdleConns:    stats.Idle,
+		InUseConns:   stats.InUse,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+}
+
+func (c sqlConn) Close() error {
+	return c.db.Close()
+}
+
+// pgxQuerier is the part of *pgxpool.Pool the store queries through
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// pgxConn runs statements on a pgx pool over the binary protocol
+type pgxConn struct {
+	pool    pgxQuerier
+	acquire func(ctx context.Context) (*pgxpool.Conn, error)
+	stats   func() PoolStats
+	close   func()
+}
+
+// newPgxConn wraps a pgx pool
+func newPgxConn(pool *pgxpool.Pool) pgxConn {
+	return pgxConn{
+		pool:    pool,
+		acquire: pool.Acquire,
+		stats: func() PoolStats {
+			stat := pool.Stat()
+			return PoolStats{
+				MaxConns:     int(stat.MaxConns()),
+				TotalConns:   int(stat.TotalConns()),
+				IdleConns:    int(stat.IdleConns()),
+				InUseConns:   int(stat.AcquiredConns()),
+				WaitCount:    stat.EmptyAcquireCount(),
+				WaitDuration: stat.AcquireDuration(),
+			}
+		},
+		close: pool.Close,
+	}
+}
+
+func (c pgxConn) ExecContext(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	tag, err := c.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (c pgxConn) QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error) {
+	r, err := c.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgxRows{r}, nil
+}
+
+func (c pgxConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	return pgxRow{c.pool.QueryRow(ctx, query, args...)}
+}
+
+func (c pgxConn) session(ctx context.Context) (session, error) {
+	if c.acquire == nil {
+		return nil, errors.New("pool does not reserve connections")
+	}
+	conn, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pgxSession{conn}, nil
+}
+
+func (c pgxConn) Stats() PoolStats {
+	return c.stats()
+}
+
+func (c pgxConn) Close() error {
+	c.close()
+	return nil
+}
+
+// pgxRows adapts pgx.Rows to rows
+type pgxRows struct {
+	pgx.Rows
+}
+
+func (r pgxRows) Close() error {
+	r.Rows.Close()
+	return nil
+}
+
+// pgxRow adapts pgx.Row to row, reporting a missing row as sql.ErrNoRows
+type pgxRow struct {
+	pgx.Row
+}
+
+func (r pgxRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// sqlSession is a connection reserved from a database/sql pool
+type sqlSession struct {
+	conn *sql.Conn
+}
+
+func (s sqlSession) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	return s.conn.QueryRowContext(ctx, query, args...)
+}
+
+func (s sqlSession) Close() error {
+	return s.conn.Close()
+}
+
+// pgxSession is a connection reserved from a pgx pool
+type pgxSession struct {
+	conn *pgxpool.Conn
+}
+
+func (s pgxSession) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	return pgxRow{s.conn.QueryRow(ctx, query, args...)}
+}
+
+func (s pgxSession) Close() error {
+	s.conn.Release()
+	return nil
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestEventStore_Pgx(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectQuery("SELECT COALESCE").WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec("add_streams").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("add_tenants").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("add_archive").WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	config := DefaultConfig()
+	config.TrimBatchSize = 10
+	pool := pgxConn{
+		pool:  mock,
+		stats: func() PoolStats { return PoolStats{MaxConns: 4, TotalConns: 2, InUseConns: 1, IdleConns: 1} },
+		close: mock.Close,
+	}
+	store, err := newEventStore(pool, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Expect batched trims to stop once a batch deletes fewer rows than its size
+	mock.ExpectExec("INSERT INTO").
+		WithArgs("product.created", pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`DELETE FROM .* LIMIT 10\)`).
+		WithArgs("product.created", int64(1000)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	mock.ExpectExec(`DELETE FROM .* LIMIT 10\)`).
+		WithArgs("product.created", int64(1000)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT id, event_data").
+		WithArgs("product.created", int64(10)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "event_data"}).
+			AddRow(int64(1), []byte(`{"id":"evt-1","name":"product.created","payload":{"sku":"a-1"}}`)))
+	events, err := store.ReadEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "evt-1" || events[0].Offset != "1" {
+		t.Errorf("Expected evt-1 at offset 1, got %+v", events)
+	}
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT event_name, COUNT").
+		WillReturnRows(pgxmock.NewRows([]string{"event_name", "count", "min", "max"}).
+			AddRow("product.created", int64(1), now, now))
+	streams, err := store.GetStreams(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	if len(streams) != 1 || streams[0].Count != 1 {
+		t.Errorf("Expected 1 stream with 1 event, got %+v", streams)
+	}
+
+	if stats := store.Stats(); stats.MaxConns != 4 || stats.InUseConns != 1 {
+		t.Errorf("Expected pool stats of the pgx pool, got %+v", stats)
+	}
+
+	// Expect a missing row to be reported as sql.ErrNoRows, as with database/sql
+	mock.ExpectQuery("SELECT version FROM").
+		WithArgs("order-1").
+		WillReturnRows(pgxmock.NewRows([]string{"version"}))
+	if version, err := store.AppendToStream(ctx, "order-1", mediator.NoStream, nil); err != nil || version != 0 {
+		t.Errorf("Expected a missing stream at version 0, got %d, %v", version, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_SQLStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(3)
+
+	expectMigrations(mock)
+
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	if stats := store.Stats(); stats.MaxConns != 3 || stats.TotalConns != 1 {
+		t.Errorf("Expected pool stats of the database/sql pool, got %+v", stats)
+	}
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// janitorLockID is the second key of the janitor's advisory lock, the first
+// being the hash of its lock key
+const janitorLockID = 1
+
+// JanitorStats describes the maintenance runs of a store
+type JanitorStats struct {
+	// Leader reports whether this store holds the janitor lock
+	Leader bool
+	// Runs counts the EnforceRetention runs this store completed
+	Runs int64
+	// Skipped counts the runs skipped as another instance held the lock
+	Skipped int64
+	// LastRun is when this store last completed a run
+	LastRun time.Time
+}
+
+// JanitorHolder describes the database session holding the janitor lock; set
+// application_name in each instance's connection string to tell them apart
+type JanitorHolder struct {
+	PID             int
+	ApplicationName string
+	ClientAddr      string
+}
+
+// janitor holds the advisory lock electing the single instance that runs
+// maintenance
+type janitor struct {
+	mu sync.Mutex
+	// session holds the lock while this store leads
+	session session
+	stats   JanitorStats
+}
+
+// lead reports whether this store holds the janitor lock, taking it when no
+// other instance does. The lock stays with the session until Close, or until
+// the session's connection is lost.
+func (s *EventStore) lead(ctx context.Context) (bool, error) {
+	s.janitor.mu.Lock()
+	defer s.janitor.mu.Unlock()
+
+	if s.janitor.session != nil {
+		var alive int
+		if err := s.janitor.session.QueryRowContext(ctx, "SELECT 1").Scan(&alive); err == nil {
+			return true, nil
+		}
+		// The lock went with the lost connection
+		s.logf("janitor lock lost with its connection")
+		s.janitor.session.Close()
+		s.janitor.session = nil
+		s.janitor.stats.Leader = false
+	}
+
+	sess, err := s.db.session(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve janitor connection: %w", err)
+	}
+	var locked bool
+	err = sess.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1), $2)", s.janitorLockKey(), janitorLockID).Scan(&locked)
+	if err != nil || !locked {
+		sess.Close()
+		if err != nil {
+			return false, fmt.Errorf("failed to take janitor lock: %w", err)
+		}
+		s.janitor.stats.Skipped++
+		return false, nil
+	}
+
+	s.janitor.session = sess
+	s.janitor.stats.Leader = true
+	return true, nil
+}
+
+// completeRun records a completed maintenance run
+func (s *EventStore) completeRun() {
+	s.janitor.mu.Lock()
+	defer s.janitor.mu.Unlock()
+	s.janitor.stats.Runs++
+	s.janitor.stats.LastRun = time.Now()
+}
+
+// resign releases the janitor lock if this store holds it
+func (s *EventStore) resign(ctx context.Context) error {
+	s.janitor.mu.Lock()
+	defer s.janitor.mu.Unlock()
+
+	if s.janitor.session == nil {
+		return nil
+	}
+	var unlocked bool
+	err := s.janitor.session.QueryRowContext(ctx, "SELECT pg_advisory_unlock(hashtext($1), $2)", s.janitorLockKey(), janitorLockID).Scan(&unlocked)
+	if closeErr := s.janitor.session.Close(); err == nil {
+		err = closeErr
+	}
+	s.janitor.session = nil
+	s.janitor.stats.Leader = false
+	if err != nil {
+		return fmt.Errorf("failed to release janitor lock: %w", err)
+	}
+	return nil
+}
+
+// janitorLockKey returns the key of the janitor lock, shared by the stores
+// of the same tables
+func (s *EventStore) janitorLockKey() string {
+	return s.baseTables().lockKey("janitor")
+}
+
+// JanitorStats returns the statistics of the store's maintenance runs
+func (s *EventStore) JanitorStats() JanitorStats {
+	s.janitor.mu.Lock()
+	defer s.janitor.mu.Unlock()
+	return s.janitor.stats
+}
+
+// JanitorHolder returns the database session holding the janitor lock, and
+// false when no instance holds it
+func (s *EventStore) JanitorHolder(ctx context.Context) (JanitorHolder, bool, error) {
+	query := fmt.Sprintf(`
+		SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), '')
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted
+			AND l.database = (SELECT oid FROM pg_database WHERE datname = current_database())
+			AND l.classid = hashtext($1)::oid AND l.objid = %d AND l.objsubid = 2
+	`, janitorLockID)
+
+	rows, err := s.db.QueryContext(ctx, query, s.janitorLockKey())
+	if err != nil {
+		return JanitorHolder{}, false, fmt.Errorf("failed to query janitor lock: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return JanitorHolder{}, false, fmt.Errorf("error iterating janitor lock: %w", err)
+		}
+		return JanitorHolder{}, false, nil
+	}
+	var holder JanitorHolder
+	if err := rows.Scan(&holder.PID, &holder.ApplicationName, &holder.ClientAddr); err != nil {
+		return JanitorHolder{}, false, fmt.Errorf("failed to scan janitor lock: %w", err)
+	}
+	return holder, true, nil
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestEventStore_JanitorLock(t *testing.T) {
+	tests := []struct {
+		name   string
+		locked bool
+		want   JanitorStats
+	}{
+		{"leader", true, JanitorStats{Leader: true, Runs: 2}},
+		{"follower", false, JanitorStats{Skipped: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create mock database: %v", err)
+			}
+			defer db.Close()
+
+			expectMigrations(mock)
+			config := DefaultConfig()
+			config.JanitorLock = true
+			store, err := NewEventStore(db, config)
+			if err != nil {
+				t.Fatalf("Failed to create event store: %v", err)
+			}
+
+			streams := []string{"event_name", "count", "min", "max"}
+			mock.ExpectQuery(`SELECT pg_try_advisory_lock\(hashtext\(\$1\), \$2\)`).
+				WithArgs("mediator_events_janitor", janitorLockID).
+				WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(tt.locked))
+			if tt.locked {
+				// Expect the leader to keep its lock for the next run
+				mock.ExpectQuery("SELECT event_name, COUNT").WillReturnRows(sqlmock.NewRows(streams))
+				mock.ExpectQuery(`SELECT 1`).WillReturnRows(sqlmock.NewRows([]string{"alive"}).AddRow(1))
+				mock.ExpectQuery("SELECT event_name, COUNT").WillReturnRows(sqlmock.NewRows(streams))
+			} else {
+				// Expect a follower to try again on its next run
+				mock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := store.EnforceRetention(context.Background()); err != nil {
+					t.Fatalf("EnforceRetention() error = %v", err)
+				}
+			}
+
+			got := store.JanitorStats()
+			got.LastRun = tt.want.LastRun
+			if got != tt.want {
+				t.Errorf("JanitorStats() = %+v, want %+v", got, tt.want)
+			}
+
+			if tt.locked {
+				mock.ExpectQuery(`SELECT pg_advisory_unlock\(hashtext\(\$1\), \$2\)`).
+					WithArgs("mediator_events_janitor", janitorLockID).
+					WillReturnRows(sqlmock.NewRows([]string{"unlocked"}).AddRow(true))
+				// The lock's session kept a second connection open
+				mock.ExpectClose()
+			}
+			mock.ExpectClose()
+			if err := store.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+			if store.JanitorStats().Leader {
+				t.Error("Expected Close to give up the lead")
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("There were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEventStore_JanitorLockLost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	config := DefaultConfig()
+	config.JanitorLock = true
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	streams := []string{"event_name", "count", "min", "max"}
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery("SELECT event_name, COUNT").WillReturnRows(sqlmock.NewRows(streams))
+	// Expect a lost session to be replaced and the lock taken again
+	mock.ExpectQuery(`SELECT 1`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := store.EnforceRetention(ctx); err != nil {
+			t.Fatalf("EnforceRetention() error = %v", err)
+		}
+	}
+	if stats := store.JanitorStats(); stats.Leader || stats.Runs != 1 || stats.Skipped != 1 {
+		t.Errorf("JanitorStats() = %+v, want a lost lead after 1 run", stats)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_JanitorHolder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	ctx := context.Background()
+
+	columns := []string{"pid", "application_name", "client_addr"}
+	mock.ExpectQuery(`FROM pg_locks l JOIN pg_stat_activity a .* l.classid = hashtext\(\$1\)::oid AND l.objid = 1 AND l.objsubid = 2`).
+		WithArgs("mediator_events_janitor").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(42, "orders-1", "10.0.0.7"))
+	holder, ok, err := store.JanitorHolder(ctx)
+	if err != nil || !ok || holder != (JanitorHolder{PID: 42, ApplicationName: "orders-1", ClientAddr: "10.0.0.7"}) {
+		t.Errorf("JanitorHolder() = %+v, %v, %v, want orders-1", holder, ok, err)
+	}
+
+	mock.ExpectQuery(`FROM pg_locks`).WillReturnRows(sqlmock.NewRows(columns))
+	if _, ok, err := store.JanitorHolder(ctx); err != nil || ok {
+		t.Errorf("JanitorHolder() = %v, %v, want no holder", ok, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// ListenerConfig configures a Listener
+type ListenerConfig struct {
+	// Channel is the channel to LISTEN on; it defaults to the store's NotifyChannel
+	Channel string
+	// MinReconnectInterval is the first wait before reconnecting a lost connection
+	MinReconnectInterval time.Duration
+	// MaxReconnectInterval caps the doubling wait between reconnection attempts
+	MaxReconnectInterval time.Duration
+	// Logger reports events that could not be dispatched; it defaults to the
+	// store's Logger
+	Logger mediator.Logger
+}
+
+// DefaultListenerConfig returns the default listener configuration
+func DefaultListenerConfig() ListenerConfig {
+	return ListenerConfig{
+		MinReconnectInterval: 10 * time.Second,
+		MaxReconnectInterval: time.Minute,
+	}
+}
+
+// Listener dispatches events stored by other processes to the local subscribers
+// of a mediator as their notifications arrive, giving instances sharing a
+// database fan-out without a separate broker. Events stored while the listener
+// is disconnected are not dispatched.
+type Listener struct {
+	store         *EventStore
+	mediator      *mediator.Mediator
+	logger        mediator.Logger
+	notifications <-chan *pq.Notification
+	close         func() error
+}
+
+// notification is the payload the store sends for a stored event
+type notification struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Origin string `json:"origin"`
+	// Tenant names the tables holding the event, empty for the base tables
+	Tenant string `json:"tenant"`
+}
+
+// NewListener connects to dsn and listens for the events store notifies of.
+// Pass the store the local mediator writes to, so events it stored itself,
+// which Publish already dispatched, are skipped.
+func NewListener(dsn string, store *EventStore, m *mediator.Mediator, config ListenerConfig) (*Listener, error) {
+	defaults := DefaultListenerConfig()
+	if config.Channel == "" {
+		config.Channel = store.config.NotifyChannel
+	}
+	if config.Channel == "" {
+		return nil, fmt.Errorf("no notify channel configured")
+	}
+	if config.Logger == nil {
+		config.Logger = store.config.Logger
+	}
+	if config.MinReconnectInterval <= 0 {
+		config.MinReconnectInterval = defaults.MinReconnectInterval
+	}
+	if config.MaxReconnectInterval <= 0 {
+		config.MaxReconnectInterval = defaults.MaxReconnectInterval
+	}
+
+	listener := pq.NewListener(dsn, config.MinReconnectInterval, config.MaxReconnectInterval, nil)
+	if err := listener.Listen(config.Channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on channel %s: %w", config.Channel, err)
+	}
+	return newListener(store, m, config.Logger, listener.Notify, listener.Close), nil
+}
+
+// newListener creates a listener dispatching the events of notifications
+func newListener(store *EventStore, m *mediator.Mediator, logger mediator.Logger, notifications <-chan *pq.Notification, close func() error) *Listener {
+	return &Listener{
+		store:         store,
+		mediator:      m,
+		logger:        logger,
+		notifications: notifications,
+		close:         close,
+	}
+}
+
+// Run dispatches notified events until ctx is cancelled or the listener is closed
+func (l *Listener) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n, ok := <-l.notifications:
+			if !ok {
+				return nil
+			}
+			// A nil notification reports a re-established connection
+			if n == nil {
+				continue
+			}
+			if err := l.handle(ctx, n.Extra); err != nil && l.logger != nil {
+				l.logger.Printf("postgres listener: %v", err)
+			}
+		}
+	}
+}
+
+// Close stops listening
+func (l *Listener) Close() error {
+	return l.close()
+}
+
+// handle reads the event a notification refers to and dispatches it
+func (l *Listener) handle(ctx context.Context, payload string) error {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	if n.Origin == l.store.origin {
+		return nil
+	}
+
+	t, err := l.store.tablesFor(n.Tenant)
+	if err != nil {
+		return fmt.Errorf("failed to read event %s %d: %w", n.Name, n.ID, err)
+	}
+	event, err := l.store.readEvent(ctx, t, n.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read event %s %d: %w", n.Name, n.ID, err)
+	}
+
+	err = l.mediator.DispatchStored(ctx, event)
+	if err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+		return fmt.Errorf("failed to dispatch event %s %d: %w", n.Name, n.ID, err)
+	}
+	return nil
+}
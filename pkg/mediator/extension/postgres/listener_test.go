@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestListener(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	config := DefaultConfig()
+	config.NotifyChannel = "mediator_events"
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	m := mediator.NewMediator(mediator.WithEventStore(store))
+	received := make(chan mediator.Event, 10)
+	m.Subscribe("product.created", func(ctx context.Context, event mediator.Event) error {
+		received <- event
+		return nil
+	})
+
+	notifications := make(chan *pq.Notification, 10)
+	listener := newListener(store, m, nil, notifications, func() error { return nil })
+
+	notify := func(id int64, name, origin string) {
+		notifications <- &pq.Notification{
+			Channel: config.NotifyChannel,
+			Extra:   fmt.Sprintf(`{"id":%d,"name":%q,"origin":%q}`, id, name, origin),
+		}
+	}
+
+	// Expect only events stored by other processes to be read
+	mock.ExpectQuery(`SELECT id, event_data .* WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data"}).
+			AddRow(7, `{"id":"evt-7","name":"product.created","payload":{"sku":"a-1"}}`))
+	mock.ExpectQuery(`SELECT id, event_data .* WHERE id = \$1`).
+		WithArgs(int64(8)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data"}).
+			AddRow(8, `{"id":"evt-8","name":"product.deleted","payload":null}`))
+
+	notify(1, "product.created", store.origin)
+	notifications <- nil
+	notify(7, "product.created", "other")
+	notify(8, "product.deleted", "other")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- listener.Run(ctx) }()
+
+	select {
+	case event := <-received:
+		if event.ID != "evt-7" {
+			t.Errorf("Expected evt-7 to be dispatched, got %s", event.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for a dispatched event")
+	}
+
+	// Let the unhandled event be processed before stopping
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected Run to stop with context.Canceled, got %v", err)
+	}
+	if len(received) != 0 {
+		t.Errorf("Expected a single dispatched event, got %d more", len(received))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_NotifyChannel(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	config := DefaultConfig()
+	config.NotifyChannel = "mediator_events"
+	config.DisableTrim = true
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	// Expect the insert to notify the channel in the same statement
+	mock.ExpectExec(`WITH inserted AS \( INSERT INTO .* RETURNING id, event_name\) SELECT pg_notify\(\$4, .*\$5::text, 'tenant', \$6::text`).
+		WithArgs("product.created", sqlmock.AnyArg(), sqlmock.AnyArg(), "mediator_events", store.origin, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := store.StoreEvent(context.Background(), mediator.Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	mock.ExpectExec(`WITH inserted AS .* SELECT pg_notify\(\$7, .*\$8::text`).
+		WithArgs("a", sqlmock.AnyArg(), sqlmock.AnyArg(), "b", sqlmock.AnyArg(), sqlmock.AnyArg(), "mediator_events", store.origin, "").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	if err := store.StoreEvents(context.Background(), []mediator.Event{{Name: "a"}, {Name: "b"}}); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/lib/pq"
+)
+
+// migrationFiles holds the schema migrations, named NNNN_description.sql and
+// applied in order. Migrations must be idempotent, as tables created before
+// versioning already hold their changes.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is a versioned change to the schema
+type migration struct {
+	version int
+	name    string
+	sql     *template.Template
+}
+
+// migrationData fills in the table names of a migration
+type migrationData struct {
+	tables       tables
+	Table        string
+	StreamsTable string
+	ArchiveTable string
+	TenantsTable string
+	// Tenant is set when migrating the tables of a tenant
+	Tenant      string
+	Partitioned bool
+}
+
+// Index returns the quoted name of an index of the events table
+func (d migrationData) Index(suffix string) string {
+	return d.tables.index(suffix)
+}
+
+// loadMigrations returns the embedded migrations ordered by version
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration name %s: %w", entry.Name(), err)
+		}
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		sql, err := template.New(name).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration %s: %w", name, err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: sql})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// migrate applies the migrations newer than the schema version of t. Each
+// runs with its version record in a single implicit transaction, holding an
+// advisory lock so concurrent processes apply it one at a time.
+func (s *EventStore) migrate(ctx context.Context, t tables) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if t.schema != "" {
+		query := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(t.schema))
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`, t.versions())
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema version table: %w", err)
+	}
+
+	current, err := s.schemaVersion(ctx, t)
+	if err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].version; current > latest {
+		return fmt.Errorf("schema version %d is newer than the latest known version %d", current, latest)
+	}
+
+	data := migrationData{
+		tables:       t,
+		Table:        t.events(),
+		StreamsTable: t.streams(),
+		ArchiveTable: t.archive(),
+		TenantsTable: s.tenantsTable(),
+		Tenant:       t.tenant,
+		Partitioned:  s.partitioned(),
+	}
+	lockKey := t.lockKey("schema_version")
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		var script bytes.Buffer
+		fmt.Fprintf(&script, "SELECT pg_advisory_xact_lock(hashtext(%s));\n", pq.QuoteLiteral(lockKey))
+		if err := m.sql.Execute(&script, data); err != nil {
+			return fmt.Errorf("failed to render migration %s: %w", m.name, err)
+		}
+		fmt.Fprintf(&script, "\nINSERT INTO %s (version, name) VALUES (%d, %s) ON CONFLICT (version) DO NOTHING;\n",
+			t.versions(), m.version, pq.QuoteLiteral(m.name))
+
+		// Without arguments the script runs over the simple protocol, which
+		// allows several statements
+		if _, err := s.db.ExecContext(ctx, script.String()); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the version of the latest migration applied to the
+// tables of the tenant of ctx, 0 before any
+func (s *EventStore) SchemaVersion(ctx context.Context) (int, error) {
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return s.schemaVersion(ctx, t)
+}
+
+// schemaVersion returns the version of the latest migration applied to t
+func (s *EventStore) schemaVersion(ctx context.Context, t tables) (int, error) {
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", t.versions())
+
+	var version int
+	if err := s.db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	return version, nil
+}
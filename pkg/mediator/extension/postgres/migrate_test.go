@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectMigrations expects every migration to be applied to an empty schema
+func expectMigrations(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_schema_version"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM "mediator_events_schema_version"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+	for _, name := range []string{"0001_create_events_table", "0002_add_event_data_index", "0003_add_streams", "0004_add_tenants", "0005_add_archive"} {
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtext\('mediator_events_schema_version'\)\);.*` + name).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migrations[%d] has version %d, want %d", i, m.version, i+1)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		partitioned bool
+		want        string
+		exclude     string
+	}{
+		{"plain table", false, "id SERIAL PRIMARY KEY", "PARTITION BY"},
+		{"partitioned table", true, `PARTITION BY RANGE (created_at)`, "id SERIAL PRIMARY KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sql strings.Builder
+			data := migrationData{tables: tables{name: "events"}, Table: `"events"`, Partitioned: tt.partitioned}
+			if err := migrations[0].sql.Execute(&sql, data); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if !strings.Contains(sql.String(), `CREATE TABLE IF NOT EXISTS "events"`) || !strings.Contains(sql.String(), tt.want) {
+				t.Errorf("migration = %s, want %s", sql.String(), tt.want)
+			}
+			if strings.Contains(sql.String(), tt.exclude) {
+				t.Errorf("migration = %s, want no %s", sql.String(), tt.exclude)
+			}
+		})
+	}
+}
+
+func TestEventStore_Migrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		applied []string
+		wantErr bool
+	}{
+		{"up to date", 5, nil, false},
+		{"pending", 1, []string{"0002_add_event_data_index", "0003_add_streams", "0004_add_tenants", "0005_add_archive"}, false},
+		{"newer schema", 99, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create mock database: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_schema_version"`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\)`).
+				WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(tt.current))
+			for _, name := range tt.applied {
+				// Expect each migration to record its version in the same script
+				mock.ExpectExec(`INSERT INTO "mediator_events_schema_version" \(version, name\) VALUES \(\d+, '` + name + `'\) ON CONFLICT \(version\) DO NOTHING`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			_, err = NewEventStore(db, DefaultConfig())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewEventStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("There were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEventStore_SchemaVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	if version, err := store.SchemaVersion(context.Background()); err != nil || version != 3 {
+		t.Errorf("SchemaVersion() = %d, %v, want 3", version, err)
+	}
+}
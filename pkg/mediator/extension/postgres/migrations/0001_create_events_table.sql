@@ -0,0 +1,23 @@
+{{if .Partitioned}}
+CREATE TABLE IF NOT EXISTS {{.Table}} (
+	id SERIAL,
+	event_name TEXT NOT NULL,
+	event_data JSONB NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	-- The primary key of a partitioned table must include the partition key
+	PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+{{else}}
+CREATE TABLE IF NOT EXISTS {{.Table}} (
+	id SERIAL PRIMARY KEY,
+	event_name TEXT NOT NULL,
+	event_data JSONB NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+{{end}}
+
+-- Index on event_name for faster lookups
+CREATE INDEX IF NOT EXISTS {{.Index "event_name_idx"}} ON {{.Table}} (event_name);
+
+-- Index on created_at for faster sorting
+CREATE INDEX IF NOT EXISTS {{.Index "created_at_idx"}} ON {{.Table}} (created_at);
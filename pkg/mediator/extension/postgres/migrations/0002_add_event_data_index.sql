@@ -0,0 +1,2 @@
+-- GIN index on event_data for containment and JSONPath queries
+CREATE INDEX IF NOT EXISTS {{.Index "event_data_idx"}} ON {{.Table}} USING GIN (event_data jsonb_path_ops);
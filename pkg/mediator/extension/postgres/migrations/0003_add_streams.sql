@@ -0,0 +1,13 @@
+-- Stream columns locate events appended with AppendToStream
+ALTER TABLE {{.Table}}
+	ADD COLUMN IF NOT EXISTS stream_id TEXT,
+	ADD COLUMN IF NOT EXISTS stream_version BIGINT;
+
+CREATE INDEX IF NOT EXISTS {{.Index "stream_idx"}} ON {{.Table}} (stream_id, stream_version)
+	WHERE stream_id IS NOT NULL;
+
+-- Stream versions serialize concurrent appends
+CREATE TABLE IF NOT EXISTS {{.StreamsTable}} (
+	stream_id TEXT PRIMARY KEY,
+	version BIGINT NOT NULL
+);
@@ -0,0 +1,7 @@
+{{if not .Tenant}}
+-- Tenants with tables of their own
+CREATE TABLE IF NOT EXISTS {{.TenantsTable}} (
+	tenant TEXT PRIMARY KEY,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+{{end}}
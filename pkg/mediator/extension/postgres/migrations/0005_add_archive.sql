@@ -0,0 +1,12 @@
+-- Events moved out of the events table by trims when ArchiveTable is set
+CREATE TABLE IF NOT EXISTS {{.ArchiveTable}} (
+	id BIGINT NOT NULL,
+	event_name TEXT NOT NULL,
+	event_data JSONB NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	stream_id TEXT,
+	stream_version BIGINT,
+	archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS {{.Index "archive_idx"}} ON {{.ArchiveTable}} (event_name, created_at);
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Option sets a field of the Config NewConfig builds
+type Option func(*Config)
+
+// WithPrefix sets the name of the events table, which other tables are named after
+func WithPrefix(prefix string) Option {
+	return func(c *Config) {
+		c.Prefix = prefix
+	}
+}
+
+// WithSchema keeps the store's tables in schema instead of the search path
+func WithSchema(schema string) Option {
+	return func(c *Config) {
+		c.Schema = schema
+	}
+}
+
+// WithMaxEventsPerType caps each event name when retention has no default
+// policy, and sets the default read limit; 0 means no limit
+func WithMaxEventsPerType(n int64) Option {
+	return func(c *Config) {
+		c.MaxEventsPerType = n
+	}
+}
+
+// WithRetention sets the retention policies applied on write and by EnforceRetention
+func WithRetention(retention mediator.Retention) Option {
+	return func(c *Config) {
+		c.Retention = retention
+	}
+}
+
+// WithSerializer encodes event payloads with serializer
+func WithSerializer(serializer mediator.Serializer) Option {
+	return func(c *Config) {
+		c.Serializer = serializer
+	}
+}
+
+// WithLogger reports the store's background failures and is the default
+// logger of its Listeners
+func WithLogger(logger mediator.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithNotifyChannel sends a NOTIFY on channel for every stored event
+func WithNotifyChannel(channel string) Option {
+	return func(c *Config) {
+		c.NotifyChannel = channel
+	}
+}
+
+// WithPartition range-partitions the events table by created_at
+func WithPartition(interval PartitionInterval) Option {
+	return func(c *Config) {
+		c.Partition = interval
+	}
+}
+
+// NewConfig builds a Config from DefaultConfig and opts, and validates it
+func NewConfig(opts ...Option) (Config, error) {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// New creates a PostgreSQL event store configured by opts on a database/sql
+// pool, failing on an invalid configuration instead of falling back to defaults
+func New(db *sql.DB, opts ...Option) (*EventStore, error) {
+	config, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventStore(db, config)
+}
+
+// Validate reports every setting that is invalid or conflicts with another
+func (c Config) Validate() error {
+	var errs []error
+	switch {
+	case c.Prefix == "":
+		errs = append(errs, errors.New("empty Prefix"))
+	case len(c.Prefix) > maxTableName:
+		errs = append(errs, fmt.Errorf("table prefix %s is longer than %d bytes", c.Prefix, maxTableName))
+	case c.TenantTables && len(c.Prefix)+len(TenantSeparator) >= maxTableName:
+		errs = append(errs, fmt.Errorf("table prefix %s leaves no room for tenants in table names of %d bytes", c.Prefix, maxTableName))
+	}
+	if c.TenantFromContext != nil && !c.TenantTables {
+		errs = append(errs, errors.New("a TenantFromContext is set without TenantTables"))
+	}
+	if c.MaxEventsPerType < 0 {
+		errs = append(errs, fmt.Errorf("negative MaxEventsPerType %d", c.MaxEventsPerType))
+	}
+	if c.TrimBatchSize < 0 {
+		errs = append(errs, fmt.Errorf("negative TrimBatchSize %d", c.TrimBatchSize))
+	}
+	if c.TrimInterval < 0 {
+		errs = append(errs, fmt.Errorf("negative TrimInterval %s", c.TrimInterval))
+	}
+	if c.TrimInterval > 0 && (c.DisableTrim || c.Partition != "") {
+		errs = append(errs, errors.New("a TrimInterval is set, but writes don't trim with DisableTrim or Partition"))
+	}
+	if err := c.Partition.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.PartitionsAhead < 0 {
+		errs = append(errs, fmt.Errorf("negative PartitionsAhead %d", c.PartitionsAhead))
+	}
+	if err := c.Retention.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid postgres store config: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    func(c Config) bool
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: func(c Config) bool { return c.Prefix == DefaultConfig().Prefix && c.MaxEventsPerType == 1000 },
+		},
+		{
+			name: "options",
+			opts: []Option{
+				WithPrefix("orders"),
+				WithSchema("app"),
+				WithMaxEventsPerType(50),
+				WithRetention(mediator.Retention{Default: mediator.KeepFor(time.Hour)}),
+				WithNotifyChannel("orders_events"),
+				WithPartition(PartitionMonthly),
+			},
+			want: func(c Config) bool {
+				return c.Prefix == "orders" && c.Schema == "app" && c.MaxEventsPerType == 50 &&
+					c.Retention.Default.MaxAge == time.Hour && c.NotifyChannel == "orders_events" && c.Partition == PartitionMonthly
+			},
+		},
+		{name: "empty prefix", opts: []Option{WithPrefix("")}, wantErr: "empty Prefix"},
+		{name: "long prefix", opts: []Option{WithPrefix(strings.Repeat("a", maxTableName+1))}, wantErr: "longer than"},
+		{name: "unknown partition", opts: []Option{WithPartition("daily")}, wantErr: "unknown partition interval"},
+		{name: "negative cap", opts: []Option{WithMaxEventsPerType(-1)}, wantErr: "negative MaxEventsPerType"},
+		{name: "negative retention", opts: []Option{WithRetention(mediator.Retention{Default: mediator.KeepFor(-time.Hour)})}, wantErr: "negative MaxAge"},
+		{
+			name:    "tenant resolver without tenant tables",
+			opts:    []Option{func(c *Config) { c.TenantFromContext = func(ctx context.Context) (string, bool) { return "", false } }},
+			wantErr: "without TenantTables",
+		},
+		{
+			name:    "no room for tenants",
+			opts:    []Option{WithPrefix(strings.Repeat("a", maxTableName-1)), func(c *Config) { c.TenantTables = true }},
+			wantErr: "no room for tenants",
+		},
+		{
+			name:    "trim interval on a partitioned table",
+			opts:    []Option{WithPartition(PartitionWeekly), func(c *Config) { c.TrimInterval = time.Minute }},
+			wantErr: "writes don't trim",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConfig(tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewConfig() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewConfig() error = %v", err)
+			}
+			if !tt.want(got) {
+				t.Errorf("NewConfig() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateReportsEveryError(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxEventsPerType = -1
+	config.TrimBatchSize = -1
+	config.PartitionsAhead = -1
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{"negative MaxEventsPerType", "negative TrimBatchSize", "negative PartitionsAhead"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	// Test an invalid configuration fails before touching the database
+	if _, err := New(db, func(c *Config) { c.TrimBatchSize = -1 }); err == nil {
+		t.Error("New() with an invalid option error = nil")
+	}
+
+	expectMigrations(mock)
+	store, err := New(db, WithMaxEventsPerType(10))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if store.config.MaxEventsPerType != 10 {
+		t.Errorf("MaxEventsPerType = %d, want 10", store.config.MaxEventsPerType)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Outbox is a PostgreSQL transactional outbox. Events published with a context
+// from WithTx are inserted in that transaction; a mediator.Relay dispatches
+// them once committed. Run a single relay per outbox table.
+type Outbox struct {
+	db    *sql.DB
+	table string
+}
+
+var _ mediator.Outbox = (*Outbox)(nil)
+
+// NewOutbox creates a PostgreSQL outbox in the table "<prefix>_outbox"
+func NewOutbox(db *sql.DB, config Config) (*Outbox, error) {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+
+	outbox := &Outbox{
+		db:    db,
+		table: config.Prefix + "_outbox",
+	}
+
+	if err := outbox.initTable(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to initialize outbox table: %w", err)
+	}
+
+	return outbox, nil
+}
+
+// initTable creates the outbox table if it doesn't exist
+func (o *Outbox) initTable(ctx context.Context) error {
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			event_name TEXT NOT NULL,
+			event_data JSONB NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			dispatched_at TIMESTAMP WITH TIME ZONE
+		)
+	`, pq.QuoteIdentifier(o.table))
+
+	if _, err := o.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create outbox table: %w", err)
+	}
+
+	// Partial index keeps the relay query cheap as dispatched rows accumulate
+	indexQuery := fmt.Sprintf(`
+		CREATE INDEX IF NOT EXISTS %s ON %s (id) WHERE dispatched_at IS NULL
+	`, pq.QuoteIdentifier(o.table+"_pending_idx"), pq.QuoteIdentifier(o.table))
+
+	if _, err := o.db.ExecContext(ctx, indexQuery); err != nil {
+		return fmt.Errorf("failed to create outbox index: %w", err)
+	}
+
+	return nil
+}
+
+// WithTx returns a copy of ctx in which mediator.Publish writes events to the
+// outbox within tx instead of dispatching them
+func (o *Outbox) WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return mediator.ContextWithOutbox(ctx, &txWriter{outbox: o, tx: tx})
+}
+
+// txWriter writes outbox events within a transaction
+type txWriter struct {
+	outbox *Outbox
+	tx     *sql.Tx
+}
+
+// WriteOutbox inserts the event into the outbox table within the transaction
+func (w *txWriter) WriteOutbox(ctx context.Context, event mediator.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (event_name, event_data)
+		VALUES ($1, $2)
+	`, pq.QuoteIdentifier(w.outbox.table))
+
+	if _, err := w.tx.ExecContext(ctx, query, event.Name, data); err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// Pending returns up to limit committed, undispatched events, oldest first
+func (o *Outbox) Pending(ctx context.Context, limit int) ([]mediator.OutboxRecord, error) {
+	query := fmt.Sprintf(`
+		SELECT id, event_data
+		FROM %s
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, pq.QuoteIdentifier(o.table))
+
+	rows, err := o.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]mediator.OutboxRecord, 0)
+	for rows.Next() {
+		var id int64
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox record: %w", err)
+		}
+
+		var event mediator.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		records = append(records, mediator.OutboxRecord{ID: strconv.FormatInt(id, 10), Event: event})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox: %w", err)
+	}
+
+	return records, nil
+}
+
+// MarkDispatched records that the given outbox records were dispatched
+func (o *Outbox) MarkDispatched(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	numericIDs := make([]int64, len(ids))
+	for i, id := range ids {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid outbox record id %q: %w", id, err)
+		}
+		numericIDs[i] = n
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET dispatched_at = NOW()
+		WHERE id = ANY($1)
+	`, pq.QuoteIdentifier(o.table))
+
+	if _, err := o.db.ExecContext(ctx, query, pq.Array(numericIDs)); err != nil {
+		return fmt.Errorf("failed to mark outbox records dispatched: %w", err)
+	}
+	return nil
+}
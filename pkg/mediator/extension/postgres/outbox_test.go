@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestOutbox(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	outbox, err := NewOutbox(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+
+	m := mediator.NewMediator()
+	var received []mediator.Event
+	m.Subscribe("product.created", func(ctx context.Context, event mediator.Event) error {
+		received = append(received, event)
+		return nil
+	})
+
+	t.Run("publish within transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO \"mediator_events_outbox\"").
+			WithArgs("product.created", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+		ctx := outbox.WithTx(context.Background(), tx)
+		if err := m.Publish(ctx, mediator.Event{Name: "product.created", Payload: map[string]interface{}{"id": "p-1"}}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if len(received) != 0 {
+			t.Errorf("Publish() dispatched %d events before commit, want 0", len(received))
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+	})
+
+	t.Run("relay dispatches committed events", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "event_data"}).
+			AddRow(7, `{"name":"product.created","id":"evt-1","payload":{"id":"p-1"},"timestamp":"2025-05-11T13:00:00Z"}`)
+		mock.ExpectQuery("SELECT id, event_data").WithArgs(100).WillReturnRows(rows)
+		mock.ExpectExec("UPDATE \"mediator_events_outbox\"").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		relay := mediator.NewRelay(m, outbox, mediator.DefaultRelayConfig())
+		n, err := relay.RelayOnce(context.Background())
+		if err != nil {
+			t.Fatalf("RelayOnce() error = %v", err)
+		}
+		if n != 1 || len(received) != 1 {
+			t.Fatalf("RelayOnce() = %d, handler received %d events, want 1", n, len(received))
+		}
+		if received[0].ID != "evt-1" {
+			t.Errorf("Expected event ID 'evt-1', got %s", received[0].ID)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
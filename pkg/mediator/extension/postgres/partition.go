@@ -0,0 +1,205 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PartitionInterval is the time range covered by each partition of a
+// partitioned events table
+type PartitionInterval string
+
+const (
+	// PartitionWeekly creates a partition per week, starting on Monday (UTC)
+	PartitionWeekly PartitionInterval = "week"
+	// PartitionMonthly creates a partition per calendar month (UTC)
+	PartitionMonthly PartitionInterval = "month"
+)
+
+// partitionNameLayout formats the start of a partition into its name
+const partitionNameLayout = "20060102"
+
+// start returns the start of the partition holding t
+func (p PartitionInterval) start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if p == PartitionWeekly {
+		// Weekday counts from Sunday; weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day.AddDate(0, 0, 1-day.Day())
+}
+
+// next returns the start of the partition after the one starting at start
+func (p PartitionInterval) next(start time.Time) time.Time {
+	if p == PartitionWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// validate reports whether p is a known interval
+func (p PartitionInterval) validate() error {
+	switch p {
+	case "", PartitionWeekly, PartitionMonthly:
+		return nil
+	}
+	return fmt.Errorf("unknown partition interval %q", p)
+}
+
+// partitioned reports whether the events table is partitioned
+func (s *EventStore) partitioned() bool {
+	return s.config.Partition != ""
+}
+
+// partition returns the unquoted name of the partition of t starting at start
+func (t tables) partition(start time.Time) string {
+	return t.name + "_p" + start.Format(partitionNameLayout)
+}
+
+// checkPartitioned fails if the events table of t exists but is not
+// partitioned; an existing table is never converted
+func (s *EventStore) checkPartitioned(ctx context.Context, t tables) error {
+	var kind string
+	err := s.db.QueryRowContext(ctx, "SELECT relkind::text FROM pg_class WHERE oid = $1::regclass", t.events()).Scan(&kind)
+	if err != nil {
+		return fmt.Errorf("failed to inspect events table: %w", err)
+	}
+	if kind != "p" {
+		return fmt.Errorf("events table %s exists and is not partitioned", t.name)
+	}
+	return nil
+}
+
+// ensurePartitions creates the partitions of t holding times that this store
+// has not created yet
+func (s *EventStore) ensurePartitions(ctx context.Context, t tables, times ...time.Time) error {
+	if !s.partitioned() {
+		return nil
+	}
+
+	for _, timestamp := range times {
+		start := s.config.Partition.start(timestamp)
+		name := t.partition(start)
+
+		s.partitionMu.Lock()
+		exists := s.partitions[name]
+		s.partitionMu.Unlock()
+		if exists {
+			continue
+		}
+
+		query := fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s PARTITION OF %s
+			FOR VALUES FROM (%s) TO (%s)
+		`, t.qualify(name), t.events(),
+			pq.QuoteLiteral(start.Format(time.RFC3339)),
+			pq.QuoteLiteral(s.config.Partition.next(start).Format(time.RFC3339)))
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+
+		s.partitionMu.Lock()
+		s.partitions[name] = true
+		s.partitionMu.Unlock()
+	}
+	return nil
+}
+
+// ensureUpcomingPartitions creates the current partition of t and
+// PartitionsAhead partitions after it
+func (s *EventStore) ensureUpcomingPartitions(ctx context.Context, t tables) error {
+	start := s.config.Partition.start(time.Now())
+	times := []time.Time{start}
+	for i := 0; i < s.config.PartitionsAhead; i++ {
+		start = s.config.Partition.next(start)
+		times = append(times, start)
+	}
+	return s.ensurePartitions(ctx, t, times...)
+}
+
+// partitionRetention returns how long every retention policy keeps events; it
+// is 0 when a policy keeps events by count or forever, as partitions can then
+// never be dropped
+func (s *EventStore) partitionRetention() time.Duration {
+	retention := s.policy("").MaxAge
+	if retention == 0 {
+		return 0
+	}
+	for name := range s.config.Retention.Events {
+		maxAge := s.policy(name).MaxAge
+		if maxAge == 0 {
+			return 0
+		}
+		if maxAge > retention {
+			retention = maxAge
+		}
+	}
+	return retention
+}
+
+// dropPartitions drops the partitions of t whose every event has aged out of
+// the longest retention policy, archiving their events first
+func (s *EventStore) dropPartitions(ctx context.Context, t tables) error {
+	retention := s.partitionRetention()
+	if retention == 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-retention)
+
+	query := `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::regclass
+	`
+	rows, err := s.db.QueryContext(ctx, query, t.events())
+	if err != nil {
+		return fmt.Errorf("failed to list partitions: %w", err)
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan partition: %w", err)
+		}
+		// Skip partitions not named by this store
+		start, err := time.Parse(partitionNameLayout, strings.TrimPrefix(name, t.name+"_p"))
+		if err != nil || !strings.HasPrefix(name, t.name+"_p") {
+			continue
+		}
+		if !s.config.Partition.next(start).After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating partitions: %w", err)
+	}
+	rows.Close()
+
+	for _, name := range expired {
+		if err := s.archivePartition(ctx, t, name); err != nil {
+			return err
+		}
+
+		drop := fmt.Sprintf("DROP TABLE IF EXISTS %s", t.qualify(name))
+		if s.config.ArchiveTable {
+			// Without arguments both statements run in one implicit transaction
+			drop = fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s; %s",
+				t.archive(), archiveColumns, archiveColumns, t.qualify(name), drop)
+		}
+		if _, err := s.db.ExecContext(ctx, drop); err != nil {
+			return fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+		s.partitionMu.Lock()
+		delete(s.partitions, name)
+		s.partitionMu.Unlock()
+	}
+	return nil
+}
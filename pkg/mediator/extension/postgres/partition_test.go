@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestPartitionInterval(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  PartitionInterval
+		t         time.Time
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{
+			name:      "monthly",
+			interval:  PartitionMonthly,
+			t:         time.Date(2026, 12, 17, 15, 4, 5, 0, time.UTC),
+			wantStart: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "weekly",
+			interval:  PartitionWeekly,
+			t:         time.Date(2026, 10, 17, 15, 4, 5, 0, time.UTC), // Saturday
+			wantStart: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "weekly on sunday",
+			interval:  PartitionWeekly,
+			t:         time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "converted to utc",
+			interval:  PartitionMonthly,
+			t:         time.Date(2026, 11, 1, 1, 0, 0, 0, time.FixedZone("CET", 2*60*60)),
+			wantStart: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			wantNext:  time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := tt.interval.start(tt.t)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("start() = %v, want %v", start, tt.wantStart)
+			}
+			if next := tt.interval.next(start); !next.Equal(tt.wantNext) {
+				t.Errorf("next() = %v, want %v", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+// newPartitionedStore creates a monthly partitioned store, expecting the
+// current and next partitions to be created
+func newPartitionedStore(t *testing.T, config Config) (*EventStore, sqlmock.Sqlmock, *sql.DB) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_schema_version"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\)`).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events" .* PARTITION BY RANGE \(created_at\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("add_event_data_index").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("add_streams").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("add_tenants").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("add_archive").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT relkind::text FROM pg_class`).
+		WithArgs(`"mediator_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"relkind"}).AddRow("p"))
+	current := PartitionMonthly.start(time.Now())
+	for _, start := range []time.Time{current, PartitionMonthly.next(current)} {
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_p` + start.Format(partitionNameLayout) + `" PARTITION OF "mediator_events"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	config.Partition = PartitionMonthly
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	return store, mock, db
+}
+
+func TestEventStore_PartitionedWrites(t *testing.T) {
+	store, mock, db := newPartitionedStore(t, DefaultConfig())
+	defer db.Close()
+	ctx := context.Background()
+
+	// Expect no trim and no partition creation for the current month
+	mock.ExpectExec("INSERT INTO").
+		WithArgs("product.created", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	// Expect a backdated event to create its partition once
+	old := time.Date(2020, 2, 14, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_p20200201" PARTITION OF "mediator_events"\s+FOR VALUES FROM \('2020-02-01T00:00:00Z'\) TO \('2020-03-01T00:00:00Z'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO").
+		WithArgs("a", sqlmock.AnyArg(), sqlmock.AnyArg(), "b", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	if err := store.StoreEvents(ctx, []mediator.Event{{Name: "a", Timestamp: old}, {Name: "b", Timestamp: old}}); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_PartitionRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention mediator.Retention
+		wantDrop  bool
+	}{
+		{
+			name:      "kept by count",
+			retention: mediator.Retention{},
+		},
+		{
+			name: "override kept by count",
+			retention: mediator.Retention{
+				Default: mediator.KeepFor(24 * time.Hour),
+				Events:  map[string]mediator.RetentionPolicy{"audit": mediator.KeepForever()},
+			},
+		},
+		{
+			name:      "expired by age",
+			retention: mediator.Retention{Default: mediator.KeepFor(24 * time.Hour)},
+			wantDrop:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Retention = tt.retention
+			store, mock, db := newPartitionedStore(t, config)
+			defer db.Close()
+
+			if tt.wantDrop {
+				mock.ExpectQuery("SELECT c.relname FROM pg_inherits").
+					WithArgs(`"mediator_events"`).
+					WillReturnRows(sqlmock.NewRows([]string{"relname"}).
+						AddRow("mediator_events_p20200101").
+						AddRow("mediator_events_default").
+						AddRow(store.baseTables().partition(PartitionMonthly.start(time.Now()))))
+				mock.ExpectExec(`DROP TABLE IF EXISTS "mediator_events_p20200101"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+
+			if err := store.EnforceRetention(context.Background()); err != nil {
+				t.Fatalf("EnforceRetention() error = %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("There were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEventStore_PartitionErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	config := DefaultConfig()
+	config.Partition = "day"
+	if _, err := NewEventStore(db, config); err == nil {
+		t.Error("NewEventStore() with an unknown interval succeeded")
+	}
+
+	// Expect an existing table that is not partitioned to be refused
+	expectMigrations(mock)
+	mock.ExpectQuery(`SELECT relkind::text FROM pg_class`).
+		WillReturnRows(sqlmock.NewRows([]string{"relkind"}).AddRow("r"))
+	config.Partition = PartitionWeekly
+	if _, err := NewEventStore(db, config); err == nil {
+		t.Error("NewEventStore() on an unpartitioned table succeeded")
+	}
+}
@@ -2,25 +2,98 @@ package postgres
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mandocaesar/mediator/pkg/mediator"
 )
 
 // EventStore represents a PostgreSQL-based event store
 type EventStore struct {
-	db     *sql.DB
-	prefix string
+	db     conn
+	config Config
+	// replicas serve reads of events when set, taken in turn
+	replicas    []conn
+	nextReplica uint64
+	// origin identifies the notifications of this store
+	origin string
+
+	trimMu   sync.Mutex
+	lastTrim map[string]time.Time
+
+	// partitions are the partitions this store has created
+	partitionMu sync.Mutex
+	partitions  map[string]bool
+
+	// readyTables are the tenant tables this store has created
+	tablesMu    sync.Mutex
+	readyTables map[string]bool
+
+	janitor janitor
 }
 
 // Config represents PostgreSQL event store configuration
 type Config struct {
-	Prefix           string
+	Prefix string
+	// Schema, when set, holds the store's tables instead of the search path;
+	// it is created if missing
+	Schema string
+	// TenantTables stores the events of each tenant in tables of their own,
+	// named Prefix + TenantSeparator + tenant and created on first use.
+	// Events without a tenant stay in the Prefix tables.
+	TenantTables bool
+	// TenantFromContext returns the tenant of a context when TenantTables is
+	// set; the mediator namespace is used when nil. Events written with a
+	// namespace go to the tables of that namespace.
+	TenantFromContext func(ctx context.Context) (string, bool)
+	// MaxEventsPerType keeps the most recent events of each event name up to
+	// this count when Retention has no default policy, and is how many events
+	// GetEvents returns when no limit is given; 0 means no limit
 	MaxEventsPerType int64
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Retention decides which events are kept; it is applied to an event name
+	// when it is written to and to every event name by EnforceRetention
+	Retention mediator.Retention
+	// DisableTrim stops writes from applying retention; EnforceRetention still does
+	DisableTrim bool
+	// TrimBatchSize caps the rows deleted per statement so trims hold locks
+	// briefly; 0 deletes in a single statement
+	TrimBatchSize int
+	// ArchiveTable moves the events retention removes to the {prefix}_archive
+	// table, in the statement deleting them, instead of discarding them
+	ArchiveTable bool
+	// Archiver, when set, is given the events retention removes before they
+	// are deleted, e.g. a JSONLArchiver writing them to cold storage
+	Archiver Archiver
+	// TrimInterval is the least time between trims of an event name on write;
+	// 0 trims on every write
+	TrimInterval time.Duration
+	// JanitorLock makes EnforceRetention run on a single instance of those
+	// sharing the tables: the first to run takes a PostgreSQL advisory lock
+	// and keeps it until Close, and the others skip their runs meanwhile
+	JanitorLock bool
+	// NotifyChannel, when set, is sent a NOTIFY for every stored event so
+	// Listeners in other processes can dispatch it
+	NotifyChannel string
+	// Partition, when set, creates the events table range-partitioned by
+	// created_at with a partition per interval. Writes don't trim it and
+	// EnforceRetention drops whole partitions instead of deleting rows.
+	Partition PartitionInterval
+	// PartitionsAhead is how many partitions after the current one
+	// EnforceRetention creates in advance
+	PartitionsAhead int
+	// Logger reports failures of the store's background work, such as losing
+	// the janitor lock, and is the default Logger of its Listeners
+	Logger mediator.Logger
 }
 
 // DefaultConfig returns default configuration
@@ -28,63 +101,67 @@ func DefaultConfig() Config {
 	return Config{
 		Prefix:           "mediator_events",
 		MaxEventsPerType: 1000,
+		PartitionsAhead:  1,
 	}
 }
 
-// NewEventStore creates a new PostgreSQL event store
+// NewEventStore creates a new PostgreSQL event store on a database/sql pool
 func NewEventStore(db *sql.DB, config Config) (*EventStore, error) {
+	return newEventStore(sqlConn{db}, config)
+}
+
+// NewPgxEventStore creates a new PostgreSQL event store on a pgx pool, which
+// talks the binary protocol and reports richer pool statistics
+func NewPgxEventStore(pool *pgxpool.Pool, config Config) (*EventStore, error) {
+	return newEventStore(newPgxConn(pool), config)
+}
+
+// newEventStore creates a store running its statements on db, and reads of
+// events on replicas when given
+func newEventStore(db conn, config Config, replicas ...conn) (*EventStore, error) {
 	if config.Prefix == "" {
 		config.Prefix = DefaultConfig().Prefix
 	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
+	origin, err := newOrigin()
+	if err != nil {
+		return nil, err
+	}
 	store := &EventStore{
-		db:     db,
-		prefix: config.Prefix,
+		db:          db,
+		config:      config,
+		replicas:    replicas,
+		origin:      origin,
+		lastTrim:    make(map[string]time.Time),
+		partitions:  make(map[string]bool),
+		readyTables: make(map[string]bool),
 	}
 
 	// Initialize tables
-	if err := store.initTables(context.Background()); err != nil {
+	if err := store.initTables(context.Background(), store.baseTables()); err != nil {
 		return nil, fmt.Errorf("failed to initialize tables: %w", err)
 	}
 
 	return store, nil
 }
 
-// initTables creates the necessary tables if they don't exist
-func (s *EventStore) initTables(ctx context.Context) error {
-	// Create events table
-	query := fmt.Sprintf(`
-		CREATE TABLE IF NOT EXISTS %s (
-			id SERIAL PRIMARY KEY,
-			event_name TEXT NOT NULL,
-			event_data JSONB NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
-		)
-	`, pq.QuoteIdentifier(s.prefix))
-
-	_, err := s.db.ExecContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("failed to create events table: %w", err)
-	}
-
-	// Create index on event_name for faster lookups
-	indexQuery := fmt.Sprintf(`
-		CREATE INDEX IF NOT EXISTS %s_event_name_idx ON %s (event_name)
-	`, s.prefix, pq.QuoteIdentifier(s.prefix))
-
-	_, err = s.db.ExecContext(ctx, indexQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create index: %w", err)
+// initTables migrates t to the latest schema version and creates the
+// partitions a partitioned table needs
+func (s *EventStore) initTables(ctx context.Context, t tables) error {
+	if err := s.migrate(ctx, t); err != nil {
+		return err
 	}
 
-	// Create index on created_at for faster sorting
-	timeIndexQuery := fmt.Sprintf(`
-		CREATE INDEX IF NOT EXISTS %s_created_at_idx ON %s (created_at)
-	`, s.prefix, pq.QuoteIdentifier(s.prefix))
-
-	_, err = s.db.ExecContext(ctx, timeIndexQuery)
-	if err != nil {
-		return fmt.Errorf("failed to create time index: %w", err)
+	if s.partitioned() {
+		if err := s.checkPartitioned(ctx, t); err != nil {
+			return err
+		}
+		if err := s.ensureUpcomingPartitions(ctx, t); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -93,112 +170,620 @@ func (s *EventStore) initTables(ctx context.Context) error {
 // StoreEvent stores an event in PostgreSQL
 func (s *EventStore) StoreEvent(ctx context.Context, event mediator.Event) error {
 	// Create event data with metadata
-	timestamp := time.Now().UTC()
-	eventData := map[string]interface{}{
-		"name":      event.Name,
-		"payload":   event.Payload,
-		"timestamp": timestamp,
+	timestamp := event.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+	event.Timestamp = timestamp
+
+	t, err := s.eventTables(ctx, event)
+	if err != nil {
+		return err
+	}
+	if err := s.ensurePartitions(ctx, t, timestamp); err != nil {
+		return err
 	}
 
-	// Convert to JSON
-	data, err := json.Marshal(eventData)
+	// Convert to a JSON record, encoding the payload with the configured serializer
+	record, err := mediator.EncodeEventRecordBuffer(s.config.Serializer, event)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
+	defer record.Release()
 
 	// Insert event
 	query := fmt.Sprintf(`
 		INSERT INTO %s (event_name, event_data, created_at)
 		VALUES ($1, $2, $3)
-	`, pq.QuoteIdentifier(s.prefix))
+	`, t.events())
 
-	_, err = s.db.ExecContext(ctx, query, event.Name, data, timestamp)
+	query, args := s.notifying(t, query, event.Name, record.Bytes(), timestamp)
+	_, err = s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to store event: %w", err)
 	}
 
-	// Trim events if needed
-	if DefaultConfig().MaxEventsPerType > 0 {
-		err = s.trimEvents(ctx, event.Name)
+	if err := s.trimOnWrite(ctx, t, event.Name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StoreEvents stores several events with a multi-row INSERT per table they
+// are written to
+func (s *EventStore) StoreEvents(ctx context.Context, events []mediator.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var order []tables
+	groups := make(map[tables][]mediator.Event)
+	for _, event := range events {
+		t, err := s.eventTables(ctx, event)
+		if err != nil {
+			return err
+		}
+		if _, ok := groups[t]; !ok {
+			order = append(order, t)
+		}
+		groups[t] = append(groups[t], event)
+	}
+
+	for _, t := range order {
+		if err := s.storeEvents(ctx, t, groups[t]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeEvents stores events in the events table of t with a single INSERT
+func (s *EventStore) storeEvents(ctx context.Context, t tables, events []mediator.Event) error {
+	placeholders := make([]string, len(events))
+	args := make([]interface{}, 0, len(events)*3)
+	names := make(map[string]bool)
+	timestamps := make([]time.Time, len(events))
+	records := make(recordBuffers, 0, len(events))
+	defer func() { records.release() }()
+	for i, event := range events {
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now().UTC()
+		}
+		timestamps[i] = event.Timestamp
+		record, err := mediator.EncodeEventRecordBuffer(s.config.Serializer, event)
 		if err != nil {
-			return fmt.Errorf("failed to trim events: %w", err)
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		records = append(records, record)
+
+		placeholders[i] = fmt.Sprintf("($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3)
+		args = append(args, event.Name, record.Bytes(), event.Timestamp)
+		names[event.Name] = true
+	}
+
+	if err := s.ensurePartitions(ctx, t, timestamps...); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (event_name, event_data, created_at)
+		VALUES %s
+	`, t.events(), strings.Join(placeholders, ", "))
+
+	query, args = s.notifying(t, query, args...)
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to store events: %w", err)
+	}
+
+	for name := range names {
+		if err := s.trimOnWrite(ctx, t, name); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// trimEvents ensures that only the most recent MaxEventsPerType events are kept
-func (s *EventStore) trimEvents(ctx context.Context, eventName string) error {
+// notifying extends an INSERT into the events table of t to NOTIFY
+// NotifyChannel of every inserted row, in the same statement
+func (s *EventStore) notifying(t tables, insert string, args ...interface{}) (string, []interface{}) {
+	if s.config.NotifyChannel == "" {
+		return insert, args
+	}
+
 	query := fmt.Sprintf(`
-		DELETE FROM %s
-		WHERE id IN (
-			SELECT id FROM %s
-			WHERE event_name = $1
-			ORDER BY created_at DESC
-			OFFSET $2
-		)
-	`, pq.QuoteIdentifier(s.prefix), pq.QuoteIdentifier(s.prefix))
+		WITH inserted AS (%s RETURNING id, event_name)
+		SELECT pg_notify($%d, json_build_object('id', id, 'name', event_name, 'origin', $%d::text, 'tenant', $%d::text)::text)
+		FROM inserted
+	`, insert, len(args)+1, len(args)+2, len(args)+3)
+	return query, append(args, s.config.NotifyChannel, s.origin, t.tenant)
+}
 
-	_, err := s.db.ExecContext(ctx, query, eventName, DefaultConfig().MaxEventsPerType)
-	if err != nil {
+// recordBuffers holds the records of a multi-row INSERT until it has run
+type recordBuffers []*mediator.RecordBuffer
+
+// release returns every record buffer to the pool
+func (r recordBuffers) release() {
+	for _, record := range r {
+		record.Release()
+	}
+}
+
+// logf reports a failure to the configured logger
+func (s *EventStore) logf(format string, args ...interface{}) {
+	if s.config.Logger != nil {
+		s.config.Logger.Printf("postgres store: "+format, args...)
+	}
+}
+
+// newOrigin returns a random identifier for the notifications of a store
+func newOrigin() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate origin: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// trimOnWrite applies retention to an event name of t that was written to,
+// unless trimming is disabled, the table is partitioned or the name was
+// trimmed less than TrimInterval ago
+func (s *EventStore) trimOnWrite(ctx context.Context, t tables, eventName string) error {
+	if s.config.DisableTrim || s.partitioned() {
+		return nil
+	}
+	if s.config.TrimInterval > 0 {
+		now := time.Now()
+		key := t.name + "/" + eventName
+		s.trimMu.Lock()
+		due := now.Sub(s.lastTrim[key]) >= s.config.TrimInterval
+		if due {
+			s.lastTrim[key] = now
+		}
+		s.trimMu.Unlock()
+		if !due {
+			return nil
+		}
+	}
+	return s.applyRetention(ctx, t, eventName)
+}
+
+// policy returns the retention policy of an event name; without a default
+// policy, event names are capped at MaxEventsPerType
+func (s *EventStore) policy(eventName string) mediator.RetentionPolicy {
+	if policy, ok := s.config.Retention.Events[eventName]; ok {
+		return policy
+	}
+	if s.config.Retention.Default.Forever() && s.config.MaxEventsPerType > 0 {
+		return mediator.KeepLast(s.config.MaxEventsPerType)
+	}
+	return s.config.Retention.Default
+}
+
+// applyRetention deletes the events of an event name of t its retention
+// policy no longer keeps
+func (s *EventStore) applyRetention(ctx context.Context, t tables, eventName string) error {
+	policy := s.policy(eventName)
+	if policy.MaxAge > 0 {
+		if err := s.deleteBefore(ctx, t, eventName, time.Now().Add(-policy.MaxAge)); err != nil {
+			return err
+		}
+	}
+	if policy.MaxCount > 0 {
+		if err := s.trimEvents(ctx, t, eventName, policy.MaxCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimEvents ensures that only the most recent maxCount events are kept
+func (s *EventStore) trimEvents(ctx context.Context, t tables, eventName string, maxCount int64) error {
+	selectIDs := fmt.Sprintf(`
+		SELECT id FROM %s
+		WHERE event_name = $1
+		ORDER BY created_at DESC, id DESC
+		OFFSET $2
+	`, t.events())
+
+	if err := s.deleteRows(ctx, t, selectIDs, eventName, maxCount); err != nil {
 		return fmt.Errorf("failed to trim events: %w", err)
 	}
 
 	return nil
 }
 
+// DeleteBefore removes the events of an event name stored before t
+func (s *EventStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	tbl, err := s.tablesOf(ctx)
+	if err != nil {
+		return err
+	}
+	return s.deleteBefore(ctx, tbl, eventName, t)
+}
+
+// deleteBefore removes the events of an event name of t stored before before
+func (s *EventStore) deleteBefore(ctx context.Context, t tables, eventName string, before time.Time) error {
+	selectIDs := fmt.Sprintf(`
+		SELECT id FROM %s
+		WHERE event_name = $1 AND created_at < $2
+	`, t.events())
+
+	if err := s.deleteRows(ctx, t, selectIDs, eventName, before); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+
+	return nil
+}
+
+// deleteRows deletes the rows of the events table of t whose ids selectIDs
+// returns, TrimBatchSize rows per statement when it is set, archiving them
+// first when an Archiver is set
+func (s *EventStore) deleteRows(ctx context.Context, t tables, selectIDs string, args ...interface{}) error {
+	if s.config.TrimBatchSize > 0 {
+		selectIDs = fmt.Sprintf("%s LIMIT %d", selectIDs, s.config.TrimBatchSize)
+	}
+	query := s.deleteStatement(t, fmt.Sprintf("id IN (%s)", selectIDs))
+
+	for {
+		var deleted int64
+		var err error
+		if s.config.Archiver != nil {
+			deleted, err = s.archiveRows(ctx, t, selectIDs, args...)
+		} else {
+			deleted, err = s.db.ExecContext(ctx, query, args...)
+		}
+		if err != nil {
+			return err
+		}
+		if s.config.TrimBatchSize <= 0 || deleted < int64(s.config.TrimBatchSize) {
+			return nil
+		}
+	}
+}
+
+// EnforceRetention applies the retention policy of every event name, removing
+// events that have aged out of streams no longer written to. A partitioned
+// table instead gets its upcoming partitions created and expired ones dropped.
+// With TenantTables the tables of every registered tenant are included, and
+// with JanitorLock instances that don't hold the lock do nothing.
+func (s *EventStore) EnforceRetention(ctx context.Context) error {
+	if s.config.JanitorLock {
+		leader, err := s.lead(ctx)
+		if err != nil || !leader {
+			return err
+		}
+	}
+
+	all, err := s.allTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range all {
+		if err := s.enforceRetention(ctx, t); err != nil {
+			return err
+		}
+	}
+	s.completeRun()
+	return nil
+}
+
+// enforceRetention applies retention to the tables t
+func (s *EventStore) enforceRetention(ctx context.Context, t tables) error {
+	if s.partitioned() {
+		if err := s.ensureUpcomingPartitions(ctx, t); err != nil {
+			return err
+		}
+		return s.dropPartitions(ctx, t)
+	}
+
+	streams, err := s.streams(ctx, s.db, t)
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		if err := s.applyRetention(ctx, t, stream.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetEvents retrieves events from PostgreSQL by event name
 func (s *EventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	records, err := s.fetch(ctx, eventName, mediator.EventQuery{}, "", limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		event, err := mediator.DecodeEventRecord(s.config.Serializer, record.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ReadEvents retrieves events by event name as typed records
+func (s *EventStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]mediator.StoredEvent, error) {
+	records, err := s.fetch(ctx, eventName, mediator.EventQuery{}, "", limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(records)
+}
+
+// GetEventsPage returns up to pageSize events of an event name, oldest first,
+// using the row id as a keyset cursor
+func (s *EventStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]mediator.StoredEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = mediator.DefaultPageSize
+	}
+	var after int64
+	if cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, "", fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+	}
+
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	query := fmt.Sprintf(`
+		SELECT id, event_data
+		FROM %s
+		WHERE event_name = $1 AND id > $2
+		ORDER BY id ASC
+		LIMIT $3
+	`, t.events())
+
+	rows, err := s.reader(ctx).QueryContext(ctx, query, eventName, after, pageSize+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]mediator.StoredEvent, 0, pageSize)
+	var lastID int64
+	more := false
+	for rows.Next() {
+		if len(events) == pageSize {
+			more = true
+			break
+		}
+
+		var data []byte
+		if err := rows.Scan(&lastID, &data); err != nil {
+			return nil, "", fmt.Errorf("failed to scan event data: %w", err)
+		}
+		event, err := mediator.DecodeStoredEvent(s.config.Serializer, data)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		event.Offset = strconv.FormatInt(lastID, 10)
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating events: %w", err)
+	}
+
+	if !more {
+		return events, "", nil
+	}
+	return events, strconv.FormatInt(lastID, 10), nil
+}
+
+// QueryEvents retrieves the most recent events of an event name matching query.
+// Filters run in PostgreSQL; metadata and payload fields are matched with JSONB
+// containment on the GIN index of event_data, so object and array values
+// match when they contain the given ones.
+func (s *EventStore) QueryEvents(ctx context.Context, eventName string, query mediator.EventQuery, limit int64) ([]mediator.StoredEvent, error) {
+	records, err := s.fetch(ctx, eventName, query, "", limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(records)
+}
+
+// QueryEventsByPath retrieves the most recent events of an event name whose
+// stored record matches a SQL/JSON path predicate, evaluated with the @@
+// operator on the GIN index of event_data. The payload is at $.payload and
+// metadata at $.metadata, e.g. `$.payload.ProductID == "p-1"`.
+func (s *EventStore) QueryEventsByPath(ctx context.Context, eventName, path string, limit int64) ([]mediator.StoredEvent, error) {
+	records, err := s.fetch(ctx, eventName, mediator.EventQuery{}, path, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(records)
+}
+
+// record is an encoded event and the id of its row
+type record struct {
+	id   int64
+	data []byte
+}
+
+// fetch returns the records of the most recent events of an event name matching
+// filter and, when set, the JSON path predicate path
+func (s *EventStore) fetch(ctx context.Context, eventName string, filter mediator.EventQuery, path string, limit int64) ([]record, error) {
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if limit <= 0 {
-		limit = DefaultConfig().MaxEventsPerType
+		limit = s.config.MaxEventsPerType
+	}
+
+	conditions := []string{"event_name = $1"}
+	args := []interface{}{eventName}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < $%d", filter.Until)
+	}
+	if filter.CorrelationID != "" {
+		where("event_data->>'correlation_id' = $%d", filter.CorrelationID)
+	}
+	if len(filter.Metadata) > 0 || len(filter.Payload) > 0 {
+		contained := make(map[string]interface{})
+		if len(filter.Metadata) > 0 {
+			contained["metadata"] = filter.Metadata
+		}
+		if len(filter.Payload) > 0 {
+			contained["payload"] = filter.Payload
+		}
+		data, err := json.Marshal(contained)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal query filter: %w", err)
+		}
+		where("event_data @> $%d::jsonb", string(data))
+	}
+	if path != "" {
+		where("event_data @@ $%d::jsonpath", path)
+	}
+	if limit > 0 {
+		args = append(args, limit)
+	} else {
+		// LIMIT NULL returns every row
+		args = append(args, nil)
 	}
 
 	// Query for events
 	query := fmt.Sprintf(`
-		SELECT event_data
+		SELECT id, event_data
 		FROM %s
-		WHERE event_name = $1
-		ORDER BY created_at DESC
-		LIMIT $2
-	`, pq.QuoteIdentifier(s.prefix))
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, t.events(), strings.Join(conditions, " AND "), len(args))
 
-	rows, err := s.db.QueryContext(ctx, query, eventName, limit)
+	rows, err := s.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
 	defer rows.Close()
 
-	events := make([]map[string]interface{}, 0)
+	var records []record
 	for rows.Next() {
-		var data []byte
-		if err := rows.Scan(&data); err != nil {
+		var r record
+		if err := rows.Scan(&r.id, &r.data); err != nil {
 			return nil, fmt.Errorf("failed to scan event data: %w", err)
 		}
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
 
-		var event map[string]interface{}
-		if err := json.Unmarshal(data, &event); err != nil {
+	return records, nil
+}
+
+// readEvent returns the event stored in the row of the events table of t
+// with the given id
+func (s *EventStore) readEvent(ctx context.Context, t tables, id int64) (mediator.StoredEvent, error) {
+	query := fmt.Sprintf(`
+		SELECT id, event_data
+		FROM %s
+		WHERE id = $1
+	`, t.events())
+
+	var r record
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&r.id, &r.data); err != nil {
+		return mediator.StoredEvent{}, fmt.Errorf("failed to query event: %w", err)
+	}
+
+	events, err := s.decodeStored([]record{r})
+	if err != nil {
+		return mediator.StoredEvent{}, err
+	}
+	return events[0], nil
+}
+
+// decodeStored decodes records into typed events whose offset is their row id
+func (s *EventStore) decodeStored(records []record) ([]mediator.StoredEvent, error) {
+	events := make([]mediator.StoredEvent, 0, len(records))
+	for _, record := range records {
+		event, err := mediator.DecodeStoredEvent(s.config.Serializer, record.data)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
 		}
-
+		event.Offset = strconv.FormatInt(record.id, 10)
 		events = append(events, event)
 	}
+	return events, nil
+}
+
+// GetStreams returns every event name with stored events and their counts and
+// first and last timestamps, ordered by name
+func (s *EventStore) GetStreams(ctx context.Context) ([]mediator.StreamInfo, error) {
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.streams(ctx, s.reader(ctx), t)
+}
+
+// streams returns the event names stored in the events table of t, querying db
+func (s *EventStore) streams(ctx context.Context, db conn, t tables) ([]mediator.StreamInfo, error) {
+	query := fmt.Sprintf(`
+		SELECT event_name, COUNT(*), MIN(created_at), MAX(created_at)
+		FROM %s
+		GROUP BY event_name
+		ORDER BY event_name
+	`, t.events())
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streams: %w", err)
+	}
+	defer rows.Close()
+
+	streams := make([]mediator.StreamInfo, 0)
+	for rows.Next() {
+		var stream mediator.StreamInfo
+		if err := rows.Scan(&stream.Name, &stream.Count, &stream.FirstEvent, &stream.LastEvent); err != nil {
+			return nil, fmt.Errorf("failed to scan stream: %w", err)
+		}
+		streams = append(streams, stream)
+	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating events: %w", err)
+		return nil, fmt.Errorf("error iterating streams: %w", err)
 	}
 
-	return events, nil
+	return streams, nil
 }
 
 // ClearEvents removes all events for a given event name
 func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf(`
 		DELETE FROM %s
 		WHERE event_name = $1
-	`, pq.QuoteIdentifier(s.prefix))
+	`, t.events())
 
-	_, err := s.db.ExecContext(ctx, query, eventName)
+	_, err = s.db.ExecContext(ctx, query, eventName)
 	if err != nil {
 		return fmt.Errorf("failed to clear events: %w", err)
 	}
@@ -206,7 +791,36 @@ func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
 	return nil
 }
 
-// Close closes the database connection
+// Stats returns the statistics of the connection pool of the store's primary
+func (s *EventStore) Stats() PoolStats {
+	return s.db.Stats()
+}
+
+// HealthCheck queries the primary and every replica
+func (s *EventStore) HealthCheck(ctx context.Context) error {
+	var alive int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&alive); err != nil {
+		return fmt.Errorf("failed to query primary: %w", err)
+	}
+	for i, replica := range s.replicas {
+		if err := replica.QueryRowContext(ctx, "SELECT 1").Scan(&alive); err != nil {
+			return fmt.Errorf("failed to query replica %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Close releases the janitor lock and closes the database connections of the
+// primary and every replica
 func (s *EventStore) Close() error {
-	return s.db.Close()
+	err := s.resign(context.Background())
+	if closeErr := s.db.Close(); err == nil {
+		err = closeErr
+	}
+	for _, replica := range s.replicas {
+		if closeErr := replica.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
 }
@@ -1,13 +1,18 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"github.com/mandocaesar/mediator/pkg/mediator/storetest"
 )
 
 func TestEventStore(t *testing.T) {
@@ -19,9 +24,7 @@ func TestEventStore(t *testing.T) {
 	defer db.Close()
 
 	// Set up expectations for table creation
-	mock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectExec("CREATE INDEX IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectExec("CREATE INDEX IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectMigrations(mock)
 
 	// Create a new event store
 	store, err := NewEventStore(db, DefaultConfig())
@@ -49,9 +52,9 @@ func TestEventStore(t *testing.T) {
 		}
 
 		// Expect the select query to be executed
-		rows := sqlmock.NewRows([]string{"event_data"}).
-			AddRow(`{"name":"test.event","payload":{"key":"value"},"timestamp":"2025-05-11T13:00:00Z"}`)
-		mock.ExpectQuery("SELECT event_data").WillReturnRows(rows)
+		rows := sqlmock.NewRows([]string{"id", "event_data"}).
+			AddRow(1, `{"name":"test.event","payload":{"key":"value"},"timestamp":"2025-05-11T13:00:00Z"}`)
+		mock.ExpectQuery("SELECT id, event_data").WillReturnRows(rows)
 
 		// Get events
 		events, err := store.GetEvents(ctx, "test.event", 10)
@@ -87,8 +90,8 @@ func TestEventStore(t *testing.T) {
 		}
 
 		// Expect the select query to return no rows
-		rows := sqlmock.NewRows([]string{"event_data"})
-		mock.ExpectQuery("SELECT event_data").WillReturnRows(rows)
+		rows := sqlmock.NewRows([]string{"id", "event_data"})
+		mock.ExpectQuery("SELECT id, event_data").WillReturnRows(rows)
 
 		// Get events after clearing
 		events, err := store.GetEvents(ctx, "test.event", 10)
@@ -108,6 +111,406 @@ func TestEventStore(t *testing.T) {
 	}
 }
 
+func TestEventStore_StoreEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	// Expect a single multi-row insert followed by one trim per event name
+	mock.ExpectExec(`INSERT INTO .* VALUES \(\$1, \$2, \$3\), \(\$4, \$5, \$6\)`).
+		WithArgs("batch.test", sqlmock.AnyArg(), sqlmock.AnyArg(), "batch.test", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	mock.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	events := []mediator.Event{
+		{Name: "batch.test", Payload: "a"},
+		{Name: "batch.test", Payload: "b"},
+	}
+	if err := store.StoreEvents(context.Background(), events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_ReadEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	var _ mediator.EventStoreV2 = store
+
+	rows := sqlmock.NewRows([]string{"id", "event_data"}).
+		AddRow(1, `{"id":"evt-1","name":"test.event","payload":{"key":"value"},"timestamp":"2025-05-11T13:00:00Z"}`)
+	mock.ExpectQuery("SELECT id, event_data").WillReturnRows(rows)
+
+	events, err := store.ReadEvents(context.Background(), "test.event", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0].ID != "evt-1" || events[0].Timestamp.IsZero() {
+		t.Errorf("Expected envelope of evt-1, got %+v", events[0])
+	}
+	if events[0].Offset != "1" {
+		t.Errorf("Expected offset of row 1, got %q", events[0].Offset)
+	}
+	if string(events[0].RawPayload) != `{"key":"value"}` {
+		t.Errorf("Expected raw payload, got %s", events[0].RawPayload)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_GetEventsPage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	var _ mediator.PagedEventStore = store
+
+	// Expect a keyset query fetching one row more than the page size
+	rows := sqlmock.NewRows([]string{"id", "event_data"}).
+		AddRow(11, `{"id":"evt-11","name":"test.event","payload":1}`).
+		AddRow(12, `{"id":"evt-12","name":"test.event","payload":2}`).
+		AddRow(13, `{"id":"evt-13","name":"test.event","payload":3}`)
+	mock.ExpectQuery(`SELECT id, event_data .* id > \$2 ORDER BY id ASC`).
+		WithArgs("test.event", int64(10), 3).
+		WillReturnRows(rows)
+
+	events, next, err := store.GetEventsPage(context.Background(), "test.event", "10", 2)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(events) != 2 || events[1].ID != "evt-12" {
+		t.Errorf("Expected events evt-11 and evt-12, got %+v", events)
+	}
+	if next != "12" {
+		t.Errorf("Expected next cursor 12, got %q", next)
+	}
+
+	// Expect the last page to have no next cursor
+	rows = sqlmock.NewRows([]string{"id", "event_data"}).
+		AddRow(13, `{"id":"evt-13","name":"test.event","payload":3}`)
+	mock.ExpectQuery("SELECT id, event_data").WithArgs("test.event", int64(12), 3).WillReturnRows(rows)
+
+	events, next, err = store.GetEventsPage(context.Background(), "test.event", next, 2)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(events) != 1 || next != "" {
+		t.Errorf("Expected 1 event and no next cursor, got %d, %q", len(events), next)
+	}
+
+	if _, _, err := store.GetEventsPage(context.Background(), "test.event", "abc", 2); err == nil {
+		t.Error("Expected error for invalid cursor")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_QueryEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	var _ mediator.QueryableEventStore = store
+
+	since := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour)
+
+	// Expect each filter to become a condition of the query
+	rows := sqlmock.NewRows([]string{"id", "event_data"}).
+		AddRow(1, `{"id":"evt-1","name":"test.event","payload":1,"correlation_id":"corr-1","metadata":{"tenant":"acme"}}`)
+	mock.ExpectQuery(`WHERE event_name = \$1 AND created_at >= \$2 AND created_at < \$3 AND event_data->>'correlation_id' = \$4 AND event_data @> \$5::jsonb ORDER BY created_at DESC, id DESC LIMIT \$6`).
+		WithArgs("test.event", since, until, "corr-1", `{"metadata":{"tenant":"acme"},"payload":{"sku":"a-1"}}`, int64(10)).
+		WillReturnRows(rows)
+
+	events, err := store.QueryEvents(context.Background(), "test.event", mediator.EventQuery{
+		Since:         since,
+		Until:         until,
+		CorrelationID: "corr-1",
+		Metadata:      map[string]string{"tenant": "acme"},
+		Payload:       map[string]interface{}{"sku": "a-1"},
+	}, 10)
+	if err != nil {
+		t.Fatalf("Failed to query events: %v", err)
+	}
+	if len(events) != 1 || events[0].CorrelationID != "corr-1" {
+		t.Errorf("Expected evt-1 of corr-1, got %+v", events)
+	}
+
+	// Expect a JSON path predicate to be matched against the whole record
+	mock.ExpectQuery(`WHERE event_name = \$1 AND event_data @@ \$2::jsonpath ORDER BY created_at DESC, id DESC LIMIT \$3`).
+		WithArgs("test.event", `$.payload.quantity > 10`, int64(1000)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data"}).
+			AddRow(2, `{"id":"evt-2","name":"test.event","payload":{"quantity":12}}`))
+	events, err = store.QueryEventsByPath(context.Background(), "test.event", `$.payload.quantity > 10`, 0)
+	if err != nil {
+		t.Fatalf("Failed to query events by path: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "evt-2" {
+		t.Errorf("Expected evt-2, got %+v", events)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_GetStreams(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	var _ mediator.CatalogEventStore = store
+
+	first := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+	rows := sqlmock.NewRows([]string{"event_name", "count", "min", "max"}).
+		AddRow("order.placed", 42, first, last)
+	mock.ExpectQuery(`SELECT event_name, COUNT\(\*\), MIN\(created_at\), MAX\(created_at\) .* GROUP BY event_name`).
+		WillReturnRows(rows)
+
+	streams, err := store.GetStreams(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	if len(streams) != 1 {
+		t.Fatalf("Expected 1 stream, got %d", len(streams))
+	}
+	if got := streams[0]; got.Name != "order.placed" || got.Count != 42 || !got.FirstEvent.Equal(first) || !got.LastEvent.Equal(last) {
+		t.Errorf("Expected order.placed with 42 events, got %+v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_Retention(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	config := DefaultConfig()
+	config.Retention = mediator.Retention{
+		Default: mediator.KeepLast(50),
+		Events: map[string]mediator.RetentionPolicy{
+			"session.seen": mediator.KeepFor(time.Hour),
+			"audit.logged": mediator.KeepForever(),
+		},
+	}
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	var _ mediator.CompactingEventStore = store
+	var _ mediator.RetentionEnforcer = store
+
+	ctx := context.Background()
+
+	// Expect writes to apply the policy of their event name
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM .* WHERE event_name = \$1 AND created_at < \$2`).
+		WithArgs("session.seen", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "session.seen"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(2, 1))
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "audit.logged"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	// Expect EnforceRetention to apply each stream's policy
+	now := time.Now()
+	mock.ExpectQuery("SELECT event_name, COUNT").WillReturnRows(
+		sqlmock.NewRows([]string{"event_name", "count", "min", "max"}).
+			AddRow("audit.logged", 1, now, now).
+			AddRow("order.placed", 60, now, now))
+	mock.ExpectExec(`DELETE FROM .* OFFSET \$2`).
+		WithArgs("order.placed", int64(50)).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	if err := store.EnforceRetention(ctx); err != nil {
+		t.Fatalf("Failed to enforce retention: %v", err)
+	}
+
+	// Expect DeleteBefore to delete by creation time
+	cutoff := now.Add(-24 * time.Hour)
+	mock.ExpectExec(`DELETE FROM .* created_at < \$2`).
+		WithArgs("order.placed", cutoff).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	if err := store.DeleteBefore(ctx, "order.placed", cutoff); err != nil {
+		t.Fatalf("Failed to delete events: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_TrimConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:   "custom max events per type",
+			config: func(c *Config) { c.MaxEventsPerType = 5 },
+			expect: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 2; i++ {
+					mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+					mock.ExpectExec("DELETE FROM .* OFFSET").WithArgs("test.event", int64(5)).WillReturnResult(sqlmock.NewResult(0, 0))
+				}
+			},
+		},
+		{
+			name:   "trimming disabled",
+			config: func(c *Config) { c.DisableTrim = true },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:   "trim batch size",
+			config: func(c *Config) { c.TrimBatchSize = 2 },
+			expect: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 2; i++ {
+					mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+					// Expect full batches to be followed by another batch
+					mock.ExpectExec(`DELETE FROM .* OFFSET \$2 LIMIT 2\)`).WillReturnResult(sqlmock.NewResult(0, 2))
+					mock.ExpectExec(`DELETE FROM .* OFFSET \$2 LIMIT 2\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			},
+		},
+		{
+			name:   "trim interval",
+			config: func(c *Config) { c.TrimInterval = time.Hour },
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create mock database: %v", err)
+			}
+			defer db.Close()
+
+			expectMigrations(mock)
+
+			config := DefaultConfig()
+			tt.config(&config)
+			store, err := NewEventStore(db, config)
+			if err != nil {
+				t.Fatalf("Failed to create event store: %v", err)
+			}
+
+			tt.expect(mock)
+			for i := 0; i < 2; i++ {
+				if err := store.StoreEvent(context.Background(), mediator.Event{Name: "test.event"}); err != nil {
+					t.Fatalf("Failed to store event: %v", err)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("There were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEventStore_GetEventsDefaultLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	config := DefaultConfig()
+	config.MaxEventsPerType = 5
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	// Expect a missing limit to fall back to the configured MaxEventsPerType
+	mock.ExpectQuery("SELECT id, event_data").
+		WithArgs("test.event", int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data"}))
+	if _, err := store.GetEvents(context.Background(), "test.event", 0); err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
 // TestWithRealDB is a more comprehensive test using a real database connection
 // This test is skipped by default and can be enabled by setting the POSTGRES_TEST_DSN environment variable
 func TestWithRealDB(t *testing.T) {
@@ -186,3 +589,96 @@ func TestWithRealDB(t *testing.T) {
 		t.Errorf("Expected 0 events after clearing, got %d", len(events))
 	}
 }
+
+func TestEventStore_HealthCheck(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	ctx := context.Background()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+	if err := store.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+
+	mock.ExpectQuery("SELECT 1").WillReturnError(sql.ErrConnDone)
+	if err := store.HealthCheck(ctx); err == nil {
+		t.Error("Expected HealthCheck() to fail when the query fails")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+// This test is skipped by default and can be enabled by setting the POSTGRES_TEST_DSN environment variable
+func TestEventStore_Conformance(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("Skipping PostgreSQL conformance test. Set POSTGRES_TEST_DSN to enable.")
+	}
+
+	storetest.Run(t, func(t *testing.T, retention mediator.Retention) mediator.EventStore {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("Failed to connect to database: %v", err)
+		}
+		config := DefaultConfig()
+		config.Prefix = "mediator_events_conformance"
+		config.Retention = retention
+		store, err := NewEventStore(db, config)
+		if err != nil {
+			t.Fatalf("Failed to create event store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	})
+}
+
+// This test is skipped by default and can be enabled by setting the POSTGRES_TEST_DSN environment variable
+func TestDeliveryGuarantees(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("Skipping PostgreSQL delivery test. Set POSTGRES_TEST_DSN to enable.")
+	}
+
+	config := deliverytest.DefaultConfig()
+	config.Backend = "postgres"
+	config.Claims = deliverytest.AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) deliverytest.System {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("Failed to connect to database: %v", err)
+		}
+		config := DefaultConfig()
+		config.Prefix = "mediator_events_delivery"
+		store, err := NewEventStore(db, config)
+		if err != nil {
+			t.Fatalf("Failed to create event store: %v", err)
+		}
+		dedupe := mediator.NewStoreDedupeStore(store, 1000)
+
+		m := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithEventStore(store), mediator.WithDeduplication(dedupe))
+		return deliverytest.System{
+			Publish: m.Publish,
+			Subscribe: func(eventName string, handler mediator.EventHandler) {
+				m.Subscribe(eventName, handler)
+			},
+			Store:  store,
+			Dedupe: dedupe,
+			Close:  store.Close,
+		}
+	}
+
+	report := deliverytest.Run(t, config)
+	var buf bytes.Buffer
+	report.WriteMarkdown(&buf)
+	t.Logf("conformance report:\n%s", buf.String())
+}
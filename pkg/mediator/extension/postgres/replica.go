@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// primaryKey marks contexts whose reads go to the primary
+type primaryKey struct{}
+
+// ReadFromPrimary returns a copy of ctx whose reads go to the primary instead
+// of a replica, e.g. to read events just written despite replication lag
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// NewReplicatedEventStore creates a PostgreSQL event store writing to primary
+// and reading events from replicas in turn
+func NewReplicatedEventStore(primary *sql.DB, replicas []*sql.DB, config Config) (*EventStore, error) {
+	readers := make([]conn, len(replicas))
+	for i, replica := range replicas {
+		readers[i] = sqlConn{replica}
+	}
+	return newEventStore(sqlConn{primary}, config, readers...)
+}
+
+// NewPgxReplicatedEventStore creates a PostgreSQL event store writing to the
+// primary pgx pool and reading events from the replica pools in turn
+func NewPgxReplicatedEventStore(primary *pgxpool.Pool, replicas []*pgxpool.Pool, config Config) (*EventStore, error) {
+	readers := make([]conn, len(replicas))
+	for i, replica := range replicas {
+		readers[i] = newPgxConn(replica)
+	}
+	return newEventStore(newPgxConn(primary), config, readers...)
+}
+
+// reader returns the connection reads of ctx run on: the next replica, or the
+// primary without replicas or with ReadFromPrimary
+func (s *EventStore) reader(ctx context.Context) conn {
+	if len(s.replicas) == 0 {
+		return s.db
+	}
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return s.db
+	}
+	n := atomic.AddUint64(&s.nextReplica, 1)
+	return s.replicas[(n-1)%uint64(len(s.replicas))]
+}
+
+// ReplicaStats returns the statistics of the connection pool of every replica
+func (s *EventStore) ReplicaStats() []PoolStats {
+	stats := make([]PoolStats, len(s.replicas))
+	for i, replica := range s.replicas {
+		stats[i] = replica.Stats()
+	}
+	return stats
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestEventStore_Replicas(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer primary.Close()
+
+	var replicas []*sql.DB
+	var replicaMocks []sqlmock.Sqlmock
+	for i := 0; i < 2; i++ {
+		replica, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create mock database: %v", err)
+		}
+		defer replica.Close()
+		replicas = append(replicas, replica)
+		replicaMocks = append(replicaMocks, mock)
+	}
+
+	expectMigrations(primaryMock)
+	config := DefaultConfig()
+	config.DisableTrim = true
+	store, err := NewReplicatedEventStore(primary, replicas, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	ctx := context.Background()
+
+	// Expect writes to go to the primary
+	primaryMock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	// Expect reads to take the replicas in turn
+	columns := []string{"id", "event_data"}
+	for _, mock := range []sqlmock.Sqlmock{replicaMocks[0], replicaMocks[1], replicaMocks[0]} {
+		mock.ExpectQuery("SELECT id, event_data").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, `{"id":"evt-1","name":"order.placed","payload":null}`))
+	}
+	for i := 0; i < 3; i++ {
+		if events, err := store.ReadEvents(ctx, "order.placed", 10); err != nil || len(events) != 1 {
+			t.Fatalf("ReadEvents() = %v, %v, want 1 event", events, err)
+		}
+	}
+
+	// Expect reads marked for the primary and stream reads to go to the primary
+	primaryMock.ExpectQuery("SELECT id, event_data").
+		WillReturnRows(sqlmock.NewRows(columns))
+	if _, err := store.ReadEvents(ReadFromPrimary(ctx), "order.placed", 10); err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	primaryMock.ExpectQuery("SELECT id, event_data, stream_version").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data", "stream_version"}))
+	if _, err := store.ReadStream(ctx, "order-1", 1); err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+
+	if stats := store.ReplicaStats(); len(stats) != 2 {
+		t.Errorf("ReplicaStats() = %+v, want 2 replicas", stats)
+	}
+
+	for _, mock := range append([]sqlmock.Sqlmock{primaryMock}, replicaMocks...) {
+		mock.ExpectClose()
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	for _, mock := range append([]sqlmock.Sqlmock{primaryMock}, replicaMocks...) {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("There were unfulfilled expectations: %s", err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// SagaStore is a PostgreSQL mediator.SagaStore keeping each saga instance in
+// a row
+type SagaStore struct {
+	db     *sql.DB
+	tables *tenantTable
+}
+
+var _ mediator.SagaStore = (*SagaStore)(nil)
+
+// sagaColumns define the sagas table
+const sagaColumns = `
+	saga TEXT NOT NULL,
+	id TEXT NOT NULL,
+	version BIGINT NOT NULL,
+	deadline TIMESTAMP WITH TIME ZONE,
+	state BYTEA NOT NULL,
+	content_type TEXT NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	PRIMARY KEY (saga, id)
+`
+
+// NewSagaStore creates a PostgreSQL saga store in the table "<prefix>_sagas"
+// of the Schema of config. With TenantTables, the instances of each tenant
+// are kept in "<prefix>__<tenant>_sagas".
+func NewSagaStore(db *sql.DB, config Config) (*SagaStore, error) {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	tables, err := newTenantTable(context.Background(), db, config, "_sagas", sagaColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sagas table: %w", err)
+	}
+	return &SagaStore{db: db, tables: tables}, nil
+}
+
+// SaveSaga inserts the first version of an instance or updates the row of
+// the previous one, and returns mediator.ErrSagaConflict if there is no such
+// row
+func (s *SagaStore) SaveSaga(ctx context.Context, record mediator.SagaRecord) error {
+	table, err := s.tables.forContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save saga %s instance %s: %w", record.Saga, record.ID, err)
+	}
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET version = $3, deadline = $4, state = $5, content_type = $6, updated_at = $7
+		WHERE saga = $1 AND id = $2 AND version = $3 - 1
+	`, table)
+	if record.Version == 1 {
+		query = fmt.Sprintf(`
+			INSERT INTO %s (saga, id, version, deadline, state, content_type, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (saga, id) DO NOTHING
+		`, table)
+	}
+
+	deadline := sql.NullTime{Time: record.Deadline, Valid: !record.Deadline.IsZero()}
+	state := record.State
+	if state == nil {
+		state = []byte{}
+	}
+	result, err := s.db.ExecContext(ctx, query, record.Saga, record.ID, record.Version, deadline, state, record.ContentType, record.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save saga %s instance %s: %w", record.Saga, record.ID, err)
+	}
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save saga %s instance %s: %w", record.Saga, record.ID, err)
+	}
+	if saved == 0 {
+		return fmt.Errorf("failed to save saga %s instance %s version %d: %w", record.Saga, record.ID, record.Version, mediator.ErrSagaConflict)
+	}
+	return nil
+}
+
+// LoadSaga returns the record of an instance, or mediator.ErrSagaNotFound
+func (s *SagaStore) LoadSaga(ctx context.Context, saga, id string) (mediator.SagaRecord, error) {
+	table, err := s.tables.forContext(ctx)
+	if err != nil {
+		return mediator.SagaRecord{}, fmt.Errorf("failed to load saga %s instance %s: %w", saga, id, err)
+	}
+	query := fmt.Sprintf(`
+		SELECT version, deadline, state, content_type, updated_at
+		FROM %s
+		WHERE saga = $1 AND id = $2
+	`, table)
+
+	record := mediator.SagaRecord{Saga: saga, ID: id}
+	var deadline sql.NullTime
+	err = s.db.QueryRowContext(ctx, query, saga, id).Scan(&record.Version, &deadline, &record.State, &record.ContentType, &record.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return mediator.SagaRecord{}, mediator.ErrSagaNotFound
+	}
+	if err != nil {
+		return mediator.SagaRecord{}, fmt.Errorf("failed to load saga %s instance %s: %w", saga, id, err)
+	}
+	record.Deadline = deadline.Time
+	return record, nil
+}
+
+// SagaDeadlines returns the deadlines of the instances of a saga that have one
+func (s *SagaStore) SagaDeadlines(ctx context.Context, saga string) (map[string]time.Time, error) {
+	table, err := s.tables.forContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deadlines of saga %s: %w", saga, err)
+	}
+	query := fmt.Sprintf(`
+		SELECT id, deadline
+		FROM %s
+		WHERE saga = $1 AND deadline IS NOT NULL
+	`, table)
+
+	rows, err := s.db.QueryContext(ctx, query, saga)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deadlines of saga %s: %w", saga, err)
+	}
+	defer rows.Close()
+
+	deadlines := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var deadline time.Time
+		if err := rows.Scan(&id, &deadline); err != nil {
+			return nil, fmt.Errorf("failed to scan deadline of saga %s: %w", saga, err)
+		}
+		deadlines[id] = deadline
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load deadlines of saga %s: %w", saga, err)
+	}
+	return deadlines, nil
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestSagaStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_sagas"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	store, err := NewSagaStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewSagaStore() error = %v", err)
+	}
+	ctx := context.Background()
+	now := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	deadline := now.Add(time.Hour)
+
+	saves := []struct {
+		name     string
+		version  int64
+		deadline time.Time
+		query    string
+		stored   sql.NullTime
+		affected int64
+		wantErr  error
+	}{
+		{
+			name:     "insert first version",
+			version:  1,
+			deadline: deadline,
+			query:    `INSERT INTO "mediator_events_sagas" .* ON CONFLICT \(saga, id\) DO NOTHING`,
+			stored:   sql.NullTime{Time: deadline, Valid: true},
+			affected: 1,
+		},
+		{
+			name:     "first version exists",
+			version:  1,
+			deadline: deadline,
+			query:    `INSERT INTO "mediator_events_sagas" .* ON CONFLICT \(saga, id\) DO NOTHING`,
+			stored:   sql.NullTime{Time: deadline, Valid: true},
+			wantErr:  mediator.ErrSagaConflict,
+		},
+		{
+			name:     "update previous version",
+			version:  2,
+			query:    `UPDATE "mediator_events_sagas" .* WHERE saga = \$1 AND id = \$2 AND version = \$3 - 1`,
+			affected: 1,
+		},
+		{
+			name:    "previous version moved on",
+			version: 2,
+			query:   `UPDATE "mediator_events_sagas" .* WHERE saga = \$1 AND id = \$2 AND version = \$3 - 1`,
+			wantErr: mediator.ErrSagaConflict,
+		},
+	}
+	for _, tt := range saves {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectExec(tt.query).
+				WithArgs("billing", "o-1", tt.version, tt.stored, []byte(`{}`), "application/json", now).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			record := mediator.SagaRecord{Saga: "billing", ID: "o-1", Version: tt.version, Deadline: tt.deadline, State: []byte(`{}`), ContentType: "application/json", UpdatedAt: now}
+			if err := store.SaveSaga(ctx, record); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SaveSaga() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("load", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"version", "deadline", "state", "content_type", "updated_at"}).
+			AddRow(2, deadline, []byte(`{}`), "application/json", now)
+		mock.ExpectQuery(`SELECT version, deadline, state, content_type, updated_at`).WithArgs("billing", "o-1").WillReturnRows(rows)
+
+		record, err := store.LoadSaga(ctx, "billing", "o-1")
+		if err != nil {
+			t.Fatalf("LoadSaga() error = %v", err)
+		}
+		if record.Saga != "billing" || record.ID != "o-1" || record.Version != 2 || !record.Deadline.Equal(deadline) || !record.UpdatedAt.Equal(now) {
+			t.Errorf("LoadSaga() = %+v, want version 2 of billing o-1", record)
+		}
+	})
+
+	t.Run("load missing", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT version, deadline, state, content_type, updated_at`).WithArgs("billing", "o-2").WillReturnError(sql.ErrNoRows)
+		if _, err := store.LoadSaga(ctx, "billing", "o-2"); !errors.Is(err, mediator.ErrSagaNotFound) {
+			t.Errorf("LoadSaga() error = %v, want ErrSagaNotFound", err)
+		}
+	})
+
+	t.Run("deadlines", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "deadline"}).AddRow("o-1", deadline).AddRow("o-3", now)
+		mock.ExpectQuery(`SELECT id, deadline .* WHERE saga = \$1 AND deadline IS NOT NULL`).WithArgs("billing").WillReturnRows(rows)
+
+		deadlines, err := store.SagaDeadlines(ctx, "billing")
+		if err != nil {
+			t.Fatalf("SagaDeadlines() error = %v", err)
+		}
+		if len(deadlines) != 2 || !deadlines["o-1"].Equal(deadline) || !deadlines["o-3"].Equal(now) {
+			t.Errorf("SagaDeadlines() = %v, want o-1 and o-3", deadlines)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSagaStore_TenantTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "app"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "app"."mediator_events_sagas"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	config := DefaultConfig()
+	config.Schema = "app"
+	config.TenantTables = true
+	store, err := NewSagaStore(db, config)
+	if err != nil {
+		t.Fatalf("NewSagaStore() error = %v", err)
+	}
+
+	// Test each tenant's instances are kept in its own table, created once
+	ctx := mediator.ContextWithNamespace(context.Background(), "acme")
+	mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "app"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "app"."mediator_events__acme_sagas"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, id := range []string{"o-1", "o-2"} {
+		mock.ExpectQuery(`SELECT version, deadline, state, content_type, updated_at\s+FROM "app"."mediator_events__acme_sagas"`).
+			WithArgs("acme:billing", id).WillReturnError(sql.ErrNoRows)
+		if _, err := store.LoadSaga(ctx, "acme:billing", id); !errors.Is(err, mediator.ErrSagaNotFound) {
+			t.Errorf("LoadSaga() error = %v, want ErrSagaNotFound", err)
+		}
+	}
+
+	mock.ExpectQuery(`SELECT id, deadline\s+FROM "app"."mediator_events_sagas"`).
+		WithArgs("billing").WillReturnRows(sqlmock.NewRows([]string{"id", "deadline"}))
+	if _, err := store.SagaDeadlines(context.Background(), "billing"); err != nil {
+		t.Errorf("SagaDeadlines() error = %v", err)
+	}
+
+	if _, err := store.LoadSaga(mediator.ContextWithNamespace(context.Background(), "acme corp"), "billing", "o-1"); err == nil {
+		t.Error("LoadSaga() error = nil for an invalid tenant")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
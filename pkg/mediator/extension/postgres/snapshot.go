@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// SnapshotStore is a PostgreSQL mediator.SnapshotStore keeping the latest
+// snapshot of each stream in a row
+type SnapshotStore struct {
+	db     *sql.DB
+	tables *tenantTable
+}
+
+var _ mediator.SnapshotStore = (*SnapshotStore)(nil)
+
+// snapshotColumns define the snapshots table
+const snapshotColumns = `
+	stream_id TEXT PRIMARY KEY,
+	version BIGINT NOT NULL,
+	state BYTEA NOT NULL,
+	content_type TEXT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL
+`
+
+// NewSnapshotStore creates a PostgreSQL snapshot store in the table
+// "<prefix>_snapshots" of the Schema of config. With TenantTables, the
+// snapshots of each tenant are kept in "<prefix>__<tenant>_snapshots".
+func NewSnapshotStore(db *sql.DB, config Config) (*SnapshotStore, error) {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	tables, err := newTenantTable(context.Background(), db, config, "_snapshots", snapshotColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize snapshots table: %w", err)
+	}
+	return &SnapshotStore{db: db, tables: tables}, nil
+}
+
+// SaveSnapshot stores a snapshot unless its stream has a snapshot of the same
+// or a later version
+func (s *SnapshotStore) SaveSnapshot(ctx context.Context, snapshot mediator.Snapshot) error {
+	table, err := s.tables.forContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot of stream %s: %w", snapshot.StreamID, err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s AS s (stream_id, version, state, content_type, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (stream_id) DO UPDATE SET
+			version = EXCLUDED.version,
+			state = EXCLUDED.state,
+			content_type = EXCLUDED.content_type,
+			created_at = EXCLUDED.created_at
+		WHERE s.version < EXCLUDED.version
+	`, table)
+
+	_, err = s.db.ExecContext(ctx, query, snapshot.StreamID, snapshot.Version, snapshot.State, snapshot.ContentType, snapshot.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot of stream %s: %w", snapshot.StreamID, err)
+	}
+	return nil
+}
+
+// LoadSnapshot returns the latest snapshot of a stream, or mediator.ErrSnapshotNotFound
+func (s *SnapshotStore) LoadSnapshot(ctx context.Context, streamID string) (mediator.Snapshot, error) {
+	table, err := s.tables.forContext(ctx)
+	if err != nil {
+		return mediator.Snapshot{}, fmt.Errorf("failed to load snapshot of stream %s: %w", streamID, err)
+	}
+	query := fmt.Sprintf(`
+		SELECT version, state, content_type, created_at
+		FROM %s
+		WHERE stream_id = $1
+	`, table)
+
+	snapshot := mediator.Snapshot{StreamID: streamID}
+	err = s.db.QueryRowContext(ctx, query, streamID).Scan(&snapshot.Version, &snapshot.State, &snapshot.ContentType, &snapshot.Timestamp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return mediator.Snapshot{}, mediator.ErrSnapshotNotFound
+	}
+	if err != nil {
+		return mediator.Snapshot{}, fmt.Errorf("failed to load snapshot of stream %s: %w", streamID, err)
+	}
+	return snapshot, nil
+}
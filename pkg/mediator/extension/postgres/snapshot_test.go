@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "mediator_events_snapshots"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	store, err := NewSnapshotStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("NewSnapshotStore() error = %v", err)
+	}
+	ctx := context.Background()
+	now := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+
+	t.Run("save keeps later versions", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO "mediator_events_snapshots" AS s .* WHERE s.version < EXCLUDED.version`).
+			WithArgs("product-p-1", int64(100), []byte(`{"price":12}`), "application/json", now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		snapshot := mediator.Snapshot{StreamID: "product-p-1", Version: 100, State: []byte(`{"price":12}`), ContentType: "application/json", Timestamp: now}
+		if err := store.SaveSnapshot(ctx, snapshot); err != nil {
+			t.Fatalf("SaveSnapshot() error = %v", err)
+		}
+	})
+
+	t.Run("load", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"version", "state", "content_type", "created_at"}).
+			AddRow(100, []byte(`{"price":12}`), "application/json", now)
+		mock.ExpectQuery(`SELECT version, state, content_type, created_at`).WithArgs("product-p-1").WillReturnRows(rows)
+
+		snapshot, err := store.LoadSnapshot(ctx, "product-p-1")
+		if err != nil {
+			t.Fatalf("LoadSnapshot() error = %v", err)
+		}
+		if snapshot.StreamID != "product-p-1" || snapshot.Version != 100 || string(snapshot.State) != `{"price":12}` || !snapshot.Timestamp.Equal(now) {
+			t.Errorf("LoadSnapshot() = %+v, want version 100 of product-p-1", snapshot)
+		}
+	})
+
+	t.Run("load missing", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT version, state, content_type, created_at`).WithArgs("product-p-2").WillReturnError(sql.ErrNoRows)
+		if _, err := store.LoadSnapshot(ctx, "product-p-2"); !errors.Is(err, mediator.ErrSnapshotNotFound) {
+			t.Errorf("LoadSnapshot() error = %v, want ErrSnapshotNotFound", err)
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSnapshotStore_TenantTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "app"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "app"."mediator_events_snapshots"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	config := DefaultConfig()
+	config.Schema = "app"
+	config.TenantTables = true
+	store, err := NewSnapshotStore(db, config)
+	if err != nil {
+		t.Fatalf("NewSnapshotStore() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		table  string
+		create bool
+	}{
+		{name: "without tenant", ctx: context.Background(), table: `"app"."mediator_events_snapshots"`},
+		{name: "tenant", ctx: mediator.ContextWithNamespace(context.Background(), "acme"), table: `"app"."mediator_events__acme_snapshots"`, create: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A tenant's table is created the first time it is used
+			if tt.create {
+				mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "app"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(`CREATE TABLE IF NOT EXISTS ` + regexp.QuoteMeta(tt.table)).WillReturnResult(sqlmock.NewResult(0, 0))
+			}
+			mock.ExpectQuery(`SELECT version, state, content_type, created_at\s+FROM ` + regexp.QuoteMeta(tt.table)).
+				WithArgs("product-p-1").WillReturnError(sql.ErrNoRows)
+			if _, err := store.LoadSnapshot(tt.ctx, "product-p-1"); !errors.Is(err, mediator.ErrSnapshotNotFound) {
+				t.Errorf("LoadSnapshot() error = %v, want ErrSnapshotNotFound", err)
+			}
+		})
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// AppendToStream appends events to a stream if it is at expectedVersion, or
+// whatever its version with mediator.AnyVersion, and returns its new version.
+// The stream's version row serializes concurrent appends: all but one of the
+// appends expecting the same version fail with a *mediator.VersionConflictError.
+// Appends are not trimmed on write; give streamed event names a retention
+// policy that keeps their events.
+func (s *EventStore) AppendToStream(ctx context.Context, streamID string, expectedVersion int64, events []mediator.Event) (int64, error) {
+	if expectedVersion < mediator.AnyVersion {
+		return 0, fmt.Errorf("invalid expected version %d", expectedVersion)
+	}
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		version, err := s.streamVersion(ctx, t, streamID)
+		if err != nil {
+			return 0, err
+		}
+		if expectedVersion != mediator.AnyVersion && version != expectedVersion {
+			return 0, &mediator.VersionConflictError{StreamID: streamID, Expected: expectedVersion, Actual: version}
+		}
+		return version, nil
+	}
+
+	args := []interface{}{streamID, int64(len(events))}
+
+	// bump moves the stream's version forward by the number of events, and
+	// returns no row when the stream is not at the expected version
+	var bump string
+	switch expectedVersion {
+	case mediator.AnyVersion:
+		bump = fmt.Sprintf(`
+			INSERT INTO %s AS s (stream_id, version) VALUES ($1, $2)
+			ON CONFLICT (stream_id) DO UPDATE SET version = s.version + EXCLUDED.version
+			RETURNING version
+		`, t.streams())
+	case mediator.NoStream:
+		bump = fmt.Sprintf(`
+			INSERT INTO %s (stream_id, version) VALUES ($1, $2)
+			ON CONFLICT (stream_id) DO NOTHING
+			RETURNING version
+		`, t.streams())
+	default:
+		args = append(args, expectedVersion)
+		bump = fmt.Sprintf(`
+			UPDATE %s SET version = version + $2
+			WHERE stream_id = $1 AND version = $3
+			RETURNING version
+		`, t.streams())
+	}
+
+	values := make([]string, len(events))
+	timestamps := make([]time.Time, len(events))
+	records := make(recordBuffers, 0, len(events))
+	defer func() { records.release() }()
+	for i, event := range events {
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now().UTC()
+		}
+		timestamps[i] = event.Timestamp
+		record, err := mediator.EncodeEventRecordBuffer(s.config.Serializer, event)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		records = append(records, record)
+
+		n := len(args)
+		values[i] = fmt.Sprintf("($%d::text, $%d::jsonb, $%d::timestamptz, %d)", n+1, n+2, n+3, i+1)
+		args = append(args, event.Name, record.Bytes(), event.Timestamp)
+	}
+
+	if err := s.ensurePartitions(ctx, t, timestamps...); err != nil {
+		return 0, err
+	}
+
+	// The inserted events get the versions the bump made room for
+	from := "inserted"
+	if s.config.NotifyChannel != "" {
+		from = fmt.Sprintf(`
+			inserted,
+			pg_notify($%d, json_build_object('id', inserted.id, 'name', inserted.event_name, 'origin', $%d::text, 'tenant', $%d::text)::text) AS notified
+		`, len(args)+1, len(args)+2, len(args)+3)
+		args = append(args, s.config.NotifyChannel, s.origin, t.tenant)
+	}
+	query := fmt.Sprintf(`
+		WITH bumped AS (%s),
+		inserted AS (
+			INSERT INTO %s (event_name, event_data, created_at, stream_id, stream_version)
+			SELECT v.event_name, v.event_data, v.created_at, $1, bumped.version - $2::bigint + v.k
+			FROM bumped, (VALUES %s) AS v (event_name, event_data, created_at, k)
+			RETURNING id, event_name, stream_version
+		)
+		SELECT MAX(inserted.stream_version) FROM %s
+	`, bump, t.events(), strings.Join(values, ", "), from)
+
+	var version sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to append to stream %s: %w", streamID, err)
+	}
+	if version.Valid {
+		return version.Int64, nil
+	}
+
+	// Nothing was appended, as another writer moved the stream first
+	actual, err := s.streamVersion(ctx, t, streamID)
+	if err != nil {
+		return 0, err
+	}
+	return 0, &mediator.VersionConflictError{StreamID: streamID, Expected: expectedVersion, Actual: actual}
+}
+
+// streamVersion returns the version of a stream of t, 0 if it has no events
+func (s *EventStore) streamVersion(ctx context.Context, t tables, streamID string) (int64, error) {
+	query := fmt.Sprintf("SELECT version FROM %s WHERE stream_id = $1", t.streams())
+
+	var version int64
+	err := s.db.QueryRowContext(ctx, query, streamID).Scan(&version)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("failed to query stream version: %w", err)
+	}
+	return version, nil
+}
+
+// ReadStream returns the events of a stream from fromVersion onwards, oldest first
+func (s *EventStore) ReadStream(ctx context.Context, streamID string, fromVersion int64) ([]mediator.StoredEvent, error) {
+	t, err := s.tablesOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, event_data, stream_version
+		FROM %s
+		WHERE stream_id = $1 AND stream_version >= $2
+		ORDER BY stream_version ASC
+	`, t.events())
+
+	rows, err := s.db.QueryContext(ctx, query, streamID, fromVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stream: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]mediator.StoredEvent, 0)
+	for rows.Next() {
+		var r record
+		var version int64
+		if err := rows.Scan(&r.id, &r.data, &version); err != nil {
+			return nil, fmt.Errorf("failed to scan event data: %w", err)
+		}
+		decoded, err := s.decodeStored([]record{r})
+		if err != nil {
+			return nil, err
+		}
+		event := decoded[0]
+		event.StreamID, event.Version = streamID, version
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stream: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestEventStore_AppendToStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int64
+		bump     string
+		args     []driver.Value
+		appended bool
+		want     int64
+		wantErr  error
+	}{
+		{
+			name:     "new stream",
+			expected: mediator.NoStream,
+			bump:     `INSERT INTO "mediator_events_streams" \(stream_id, version\) VALUES \(\$1, \$2\) ON CONFLICT \(stream_id\) DO NOTHING`,
+			args:     []driver.Value{"order-1", int64(2), "order.placed", sqlmock.AnyArg(), sqlmock.AnyArg(), "order.paid", sqlmock.AnyArg(), sqlmock.AnyArg()},
+			appended: true,
+			want:     2,
+		},
+		{
+			name:     "expected version",
+			expected: 4,
+			bump:     `UPDATE "mediator_events_streams" SET version = version \+ \$2 WHERE stream_id = \$1 AND version = \$3`,
+			args:     []driver.Value{"order-1", int64(2), int64(4), "order.placed", sqlmock.AnyArg(), sqlmock.AnyArg(), "order.paid", sqlmock.AnyArg(), sqlmock.AnyArg()},
+			appended: true,
+			want:     6,
+		},
+		{
+			name:     "any version",
+			expected: mediator.AnyVersion,
+			bump:     `ON CONFLICT \(stream_id\) DO UPDATE SET version = s.version \+ EXCLUDED.version`,
+			args:     []driver.Value{"order-1", int64(2), "order.placed", sqlmock.AnyArg(), sqlmock.AnyArg(), "order.paid", sqlmock.AnyArg(), sqlmock.AnyArg()},
+			appended: true,
+			want:     9,
+		},
+		{
+			name:     "conflict",
+			expected: 4,
+			bump:     `UPDATE "mediator_events_streams"`,
+			args:     []driver.Value{"order-1", int64(2), int64(4), "order.placed", sqlmock.AnyArg(), sqlmock.AnyArg(), "order.paid", sqlmock.AnyArg(), sqlmock.AnyArg()},
+			wantErr:  &mediator.VersionConflictError{StreamID: "order-1", Expected: 4, Actual: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create mock database: %v", err)
+			}
+			defer db.Close()
+
+			expectMigrations(mock)
+			store, err := NewEventStore(db, DefaultConfig())
+			if err != nil {
+				t.Fatalf("Failed to create event store: %v", err)
+			}
+
+			version := sqlmock.NewRows([]string{"max"})
+			if tt.appended {
+				version.AddRow(tt.want)
+			} else {
+				version.AddRow(nil)
+			}
+			mock.ExpectQuery(`WITH bumped AS \(.*` + tt.bump + `.*\), inserted AS \( INSERT INTO "mediator_events" \(event_name, event_data, created_at, stream_id, stream_version\)`).
+				WithArgs(tt.args...).
+				WillReturnRows(version)
+			if !tt.appended {
+				mock.ExpectQuery(`SELECT version FROM "mediator_events_streams" WHERE stream_id = \$1`).
+					WithArgs("order-1").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(5))
+			}
+
+			events := []mediator.Event{{Name: "order.placed"}, {Name: "order.paid"}}
+			got, err := store.AppendToStream(context.Background(), "order-1", tt.expected, events)
+			if tt.wantErr != nil {
+				var conflict *mediator.VersionConflictError
+				if !errors.As(err, &conflict) || *conflict != *tt.wantErr.(*mediator.VersionConflictError) {
+					t.Errorf("AppendToStream() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("AppendToStream() error = %v", err)
+			} else if got != tt.want {
+				t.Errorf("AppendToStream() = %d, want %d", got, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("There were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestEventStore_AppendToStreamEmpty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := store.AppendToStream(ctx, "order-1", -2, nil); err == nil {
+		t.Error("AppendToStream() with an invalid expected version succeeded")
+	}
+
+	// Expect a missing stream to be at version 0
+	mock.ExpectQuery(`SELECT version FROM "mediator_events_streams"`).
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	if version, err := store.AppendToStream(ctx, "order-1", mediator.NoStream, nil); err != nil || version != 0 {
+		t.Errorf("AppendToStream() = %d, %v, want 0", version, err)
+	}
+
+	mock.ExpectQuery(`SELECT version FROM "mediator_events_streams"`).
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	if _, err := store.AppendToStream(ctx, "order-1", 2, nil); !errors.Is(err, mediator.ErrVersionConflict) {
+		t.Errorf("AppendToStream() error = %v, want ErrVersionConflict", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_ReadStream(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+	store, err := NewEventStore(db, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT id, event_data, stream_version FROM "mediator_events" WHERE stream_id = \$1 AND stream_version >= \$2 ORDER BY stream_version ASC`).
+		WithArgs("order-1", int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data", "stream_version"}).
+			AddRow(11, `{"id":"evt-2","name":"order.paid","payload":null}`, 2).
+			AddRow(12, `{"id":"evt-3","name":"order.shipped","payload":null}`, 3))
+
+	events, err := store.ReadStream(context.Background(), "order-1", 2)
+	if err != nil {
+		t.Fatalf("ReadStream() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("ReadStream() returned %d events, want 2", len(events))
+	}
+	for i, want := range []struct {
+		id      string
+		version int64
+	}{{"evt-2", 2}, {"evt-3", 3}} {
+		if events[i].ID != want.id || events[i].StreamID != "order-1" || events[i].Version != want.version {
+			t.Errorf("events[%d] = %+v, want %s at version %d", i, events[i], want.id, want.version)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// TenantSeparator joins the table prefix and a tenant in the names of the
+// tenant's tables, e.g. mediator_events__acme
+const TenantSeparator = "__"
+
+// maxTableName is the longest events table name whose derived table and index
+// names, up to 15 bytes longer, fit PostgreSQL's 63-byte identifiers
+const maxTableName = 48
+
+// tables names the tables holding the events of one tenant, or of events
+// without a tenant
+type tables struct {
+	tenant string
+	schema string
+	// name is the unquoted name of the events table; the other tables,
+	// indexes and partitions are named after it
+	name string
+}
+
+// qualify quotes name and prefixes it with the schema, if any
+func (t tables) qualify(name string) string {
+	if t.schema == "" {
+		return pq.QuoteIdentifier(name)
+	}
+	return pq.QuoteIdentifier(t.schema) + "." + pq.QuoteIdentifier(name)
+}
+
+// events returns the qualified name of the events table
+func (t tables) events() string {
+	return t.qualify(t.name)
+}
+
+// streams returns the qualified name of the table holding stream versions
+func (t tables) streams() string {
+	return t.qualify(t.name + "_streams")
+}
+
+// archive returns the qualified name of the table holding trimmed events
+func (t tables) archive() string {
+	return t.qualify(t.name + "_archive")
+}
+
+// versions returns the qualified name of the table recording applied migrations
+func (t tables) versions() string {
+	return t.qualify(t.name + "_schema_version")
+}
+
+// lockKey returns the advisory lock key of a job on t, named after the table
+// suffix it guards
+func (t tables) lockKey(suffix string) string {
+	key := t.name + "_" + suffix
+	if t.schema != "" {
+		key = t.schema + "." + key
+	}
+	return key
+}
+
+// index returns the quoted name of an index of the events table; indexes
+// live in the schema of their table
+func (t tables) index(suffix string) string {
+	return pq.QuoteIdentifier(t.name + "_" + suffix)
+}
+
+// baseTables returns the tables of events without a tenant
+func (s *EventStore) baseTables() tables {
+	return s.config.baseTables()
+}
+
+// tenantsTable returns the qualified name of the table registering tenants
+func (s *EventStore) tenantsTable() string {
+	return s.baseTables().qualify(s.config.Prefix + "_tenants")
+}
+
+// tablesFor returns the tables of tenant, the base tables when tenant is empty
+// or tenant tables are disabled
+func (s *EventStore) tablesFor(tenant string) (tables, error) {
+	return s.config.tablesFor(tenant)
+}
+
+// baseTables returns the tables of events without a tenant
+func (c Config) baseTables() tables {
+	return tables{schema: c.Schema, name: c.Prefix}
+}
+
+// tablesFor returns the tables of tenant, the base tables when tenant is empty
+// or tenant tables are disabled
+func (c Config) tablesFor(tenant string) (tables, error) {
+	if !c.TenantTables || tenant == "" {
+		return c.baseTables(), nil
+	}
+	if err := validateTenant(tenant); err != nil {
+		return tables{}, err
+	}
+
+	t := tables{tenant: tenant, schema: c.Schema, name: c.Prefix + TenantSeparator + tenant}
+	if len(t.name) > maxTableName {
+		return tables{}, fmt.Errorf("table name %s of tenant %s is longer than %d bytes", t.name, tenant, maxTableName)
+	}
+	return t, nil
+}
+
+// validateTenant reports whether tenant may name tables: letters, digits and
+// hyphens only, so the names of different tenants' tables never collide
+func validateTenant(tenant string) error {
+	for _, r := range tenant {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return fmt.Errorf("invalid tenant %q: only letters, digits and hyphens are allowed", tenant)
+		}
+	}
+	return nil
+}
+
+// tenantOf returns the tenant of ctx
+func (s *EventStore) tenantOf(ctx context.Context) string {
+	return s.config.tenantOf(ctx)
+}
+
+// tenantOf returns the tenant of ctx, none without TenantTables
+func (c Config) tenantOf(ctx context.Context) string {
+	if !c.TenantTables {
+		return ""
+	}
+	tenantFromContext := c.TenantFromContext
+	if tenantFromContext == nil {
+		tenantFromContext = mediator.NamespaceFromContext
+	}
+	tenant, _ := tenantFromContext(ctx)
+	return tenant
+}
+
+// tablesOf returns the tables of the tenant of ctx
+func (s *EventStore) tablesOf(ctx context.Context) (tables, error) {
+	return s.tenantTables(ctx, s.tenantOf(ctx))
+}
+
+// eventTables returns the tables an event is written to: those of its
+// namespace, or of the tenant of ctx when it has none
+func (s *EventStore) eventTables(ctx context.Context, event mediator.Event) (tables, error) {
+	if s.config.TenantTables && event.Namespace != "" {
+		return s.tenantTables(ctx, event.Namespace)
+	}
+	return s.tablesOf(ctx)
+}
+
+// tenantTables returns the tables of tenant, creating and registering them
+// the first time the store uses them
+func (s *EventStore) tenantTables(ctx context.Context, tenant string) (tables, error) {
+	t, err := s.tablesFor(tenant)
+	if err != nil || t.tenant == "" {
+		return t, err
+	}
+
+	s.tablesMu.Lock()
+	ready := s.readyTables[t.name]
+	s.tablesMu.Unlock()
+	if ready {
+		return t, nil
+	}
+
+	if err := s.initTables(ctx, t); err != nil {
+		return tables{}, fmt.Errorf("failed to initialize tables of tenant %s: %w", tenant, err)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (tenant) VALUES ($1) ON CONFLICT (tenant) DO NOTHING", s.tenantsTable())
+	if _, err := s.db.ExecContext(ctx, query, tenant); err != nil {
+		return tables{}, fmt.Errorf("failed to register tenant %s: %w", tenant, err)
+	}
+
+	s.tablesMu.Lock()
+	s.readyTables[t.name] = true
+	s.tablesMu.Unlock()
+	return t, nil
+}
+
+// allTables returns the base tables and those of every registered tenant
+func (s *EventStore) allTables(ctx context.Context) ([]tables, error) {
+	all := []tables{s.baseTables()}
+	if !s.config.TenantTables {
+		return all, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT tenant FROM %s ORDER BY tenant", s.tenantsTable()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tenant string
+		if err := rows.Scan(&tenant); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		t, err := s.tablesFor(tenant)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenants: %w", err)
+	}
+
+	return all, nil
+}
+
+// tenantTable is the table of a store kept beside the events, such as
+// snapshots or sagas: in the schema of the events and, with TenantTables, a
+// table per tenant named after the tenant's events table
+type tenantTable struct {
+	db     *sql.DB
+	config Config
+	suffix string
+	// columns define the table, created on first use
+	columns string
+
+	mu    sync.Mutex
+	ready map[string]bool
+}
+
+// newTenantTable creates the table of the events without a tenant
+func newTenantTable(ctx context.Context, db *sql.DB, config Config, suffix, columns string) (*tenantTable, error) {
+	t := &tenantTable{db: db, config: config, suffix: suffix, columns: columns, ready: make(map[string]bool)}
+	if _, err := t.of(ctx, ""); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// forContext returns the qualified name of the table of the tenant of ctx
+func (t *tenantTable) forContext(ctx context.Context) (string, error) {
+	return t.of(ctx, t.config.tenantOf(ctx))
+}
+
+// of returns the qualified name of the table of tenant, creating it and its
+// schema the first time
+func (t *tenantTable) of(ctx context.Context, tenant string) (string, error) {
+	tbl, err := t.config.tablesFor(tenant)
+	if err != nil {
+		return "", err
+	}
+	name := tbl.qualify(tbl.name + t.suffix)
+
+	t.mu.Lock()
+	ready := t.ready[name]
+	t.mu.Unlock()
+	if ready {
+		return name, nil
+	}
+
+	if tbl.schema != "" {
+		query := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pq.QuoteIdentifier(tbl.schema))
+		if _, err := t.db.ExecContext(ctx, query); err != nil {
+			return "", fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", name, t.columns)
+	if _, err := t.db.ExecContext(ctx, query); err != nil {
+		return "", fmt.Errorf("failed to create table %s: %w", name, err)
+	}
+
+	t.mu.Lock()
+	t.ready[name] = true
+	t.mu.Unlock()
+	return name, nil
+}
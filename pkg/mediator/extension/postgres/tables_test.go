@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestEventStore_TablesFor(t *testing.T) {
+	store := &EventStore{config: Config{Prefix: "events", Schema: "app", TenantTables: true}}
+
+	tests := []struct {
+		name    string
+		tenant  string
+		want    string
+		wantErr bool
+	}{
+		{"base tables", "", `"app"."events"`, false},
+		{"tenant", "acme-1", `"app"."events__acme-1"`, false},
+		{"separator in tenant", "acme__x", "", true},
+		{"quote in tenant", `acme"`, "", true},
+		{"too long", strings.Repeat("a", maxTableName), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.tablesFor(tt.tenant)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tablesFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.events() != tt.want {
+				t.Errorf("tablesFor() events table = %s, want %s", got.events(), tt.want)
+			}
+		})
+	}
+}
+
+// expectTenantMigrations expects the tables of tenant to be created and the
+// tenant registered
+func expectTenantMigrations(mock sqlmock.Sqlmock, tenant string) {
+	table := "mediator_events__" + tenant
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "` + table + `_schema_version"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM "` + table + `_schema_version"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+	mock.ExpectExec(`hashtext\('` + table + `_schema_version'\).*CREATE TABLE IF NOT EXISTS "` + table + `"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`CREATE INDEX IF NOT EXISTS "` + table + `_event_data_idx"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "` + table + `_streams"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`0004_add_tenants`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "` + table + `_archive"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "mediator_events_tenants" \(tenant\) VALUES \(\$1\) ON CONFLICT \(tenant\) DO NOTHING`).
+		WithArgs(tenant).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestEventStore_TenantTables(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	expectMigrations(mock)
+
+	config := DefaultConfig()
+	config.TenantTables = true
+	config.DisableTrim = true
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	acme := mediator.ContextWithNamespace(context.Background(), "acme")
+
+	// Expect the tenant's tables to be created on first use only
+	expectTenantMigrations(mock, "acme")
+	mock.ExpectExec(`INSERT INTO "mediator_events__acme"`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO "mediator_events__acme"`).WillReturnResult(sqlmock.NewResult(2, 1))
+	for i := 0; i < 2; i++ {
+		if err := store.StoreEvent(acme, mediator.Event{Name: "acme:order.placed"}); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	// Expect an event's namespace to pick its tables over the context
+	expectTenantMigrations(mock, "globex")
+	mock.ExpectExec(`INSERT INTO "mediator_events__globex"`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO "mediator_events" `).WillReturnResult(sqlmock.NewResult(1, 1))
+	events := []mediator.Event{
+		{Name: "globex:order.placed", Namespace: "globex"},
+		{Name: "order.placed"},
+	}
+	if err := store.StoreEvents(context.Background(), events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT id, event_data FROM "mediator_events__acme"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_data"}))
+	if _, err := store.ReadEvents(acme, "acme:order.placed", 10); err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+
+	// Expect retention to cover the base tables and every registered tenant
+	mock.ExpectQuery(`SELECT tenant FROM "mediator_events_tenants" ORDER BY tenant`).
+		WillReturnRows(sqlmock.NewRows([]string{"tenant"}).AddRow("acme").AddRow("globex"))
+	for _, table := range []string{"mediator_events", "mediator_events__acme", "mediator_events__globex"} {
+		mock.ExpectQuery(`SELECT event_name, COUNT\(\*\), MIN\(created_at\), MAX\(created_at\) FROM "` + table + `"`).
+			WillReturnRows(sqlmock.NewRows([]string{"event_name", "count", "min", "max"}))
+	}
+	if err := store.EnforceRetention(context.Background()); err != nil {
+		t.Fatalf("Failed to enforce retention: %v", err)
+	}
+
+	if _, err := store.GetStreams(mediator.ContextWithNamespace(context.Background(), "bad tenant")); err == nil {
+		t.Error("Expected an invalid tenant to be rejected")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestEventStore_Schema(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`CREATE SCHEMA IF NOT EXISTS "app"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "app"."mediator_events_schema_version"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(version\), 0\) FROM "app"."mediator_events_schema_version"`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec(`hashtext\('app.mediator_events_schema_version'\).*CREATE TABLE IF NOT EXISTS "app"."mediator_events_tenants"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "app"."mediator_events_archive"`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	config := DefaultConfig()
+	config.Schema = "app"
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		t.Fatalf("Failed to create event store: %v", err)
+	}
+
+	mock.ExpectExec(`DELETE FROM "app"."mediator_events" WHERE event_name = \$1`).
+		WithArgs("order.placed").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := store.ClearEvents(context.Background(), "order.placed"); err != nil {
+		t.Fatalf("Failed to clear events: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
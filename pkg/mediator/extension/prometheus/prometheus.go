@@ -0,0 +1,154 @@
+// Package prometheus exposes the metrics of a mediator and its event store as
+// a prometheus.Collector.
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Config configures a Collector
+type Config struct {
+	// Namespace prefixes the metric names
+	Namespace string
+	// HandlerBuckets are the buckets of the handler duration histogram
+	HandlerBuckets []float64
+	// StoreBuckets are the buckets of the store operation duration histogram
+	StoreBuckets []float64
+	// ScrapeTimeout bounds the dead-letter queue reads of a scrape
+	ScrapeTimeout time.Duration
+}
+
+// DefaultConfig returns default collector configuration
+func DefaultConfig() Config {
+	return Config{
+		Namespace:      "mediator",
+		HandlerBuckets: prometheus.DefBuckets,
+		StoreBuckets:   prometheus.DefBuckets,
+		ScrapeTimeout:  5 * time.Second,
+	}
+}
+
+// Collector collects the metrics of a mediator:
+//
+//   - events_published_total{event}: publishes that were not vetoed
+//   - handler_duration_seconds{event,handler}: duration of handler attempts
+//   - handler_errors_total{event,handler}: failed handler attempts
+//   - store_operation_duration_seconds{operation,event}: event store reads,
+//     writes and clears
+//   - queue_depth: events waiting for async workers or the store write buffer
+//   - store_batch_size: events the store write buffer writes at once, with
+//     mediator.WithBufferedStore
+//   - dead_letters{event}: dead letters of subscribed event names
+type Collector struct {
+	mediator      *mediator.Mediator
+	config        Config
+	published     *prometheus.CounterVec
+	handlerTime   *prometheus.HistogramVec
+	handlerErrors *prometheus.CounterVec
+	storeTime     *prometheus.HistogramVec
+	queueDepth    *prometheus.Desc
+	batchSize     *prometheus.Desc
+	deadLetters   *prometheus.Desc
+}
+
+// NewCollector creates a collector instrumenting m. Register it with a
+// prometheus.Registerer to expose the metrics.
+func NewCollector(m *mediator.Mediator, config Config) *Collector {
+	ns := config.Namespace
+	c := &Collector{
+		mediator: m,
+		config:   config,
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "events_published_total",
+			Help:      "Events published, by event name.",
+		}, []string{"event"}),
+		handlerTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "handler_duration_seconds",
+			Help:      "Duration of handler attempts, by event and handler name.",
+			Buckets:   config.HandlerBuckets,
+		}, []string{"event", "handler"}),
+		handlerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "handler_errors_total",
+			Help:      "Failed handler attempts, by event and handler name.",
+		}, []string{"event", "handler"}),
+		storeTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: ns,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Duration of event store operations, by operation and event name.",
+			Buckets:   config.StoreBuckets,
+		}, []string{"operation", "event"}),
+		queueDepth: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "queue_depth"),
+			"Events waiting for async workers or the store write buffer.", nil, nil),
+		batchSize: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "store_batch_size"),
+			"Events the store write buffer currently writes at once.", nil, nil),
+		deadLetters: prometheus.NewDesc(prometheus.BuildFQName(ns, "", "dead_letters"),
+			"Dead letters in the dead-letter queue, by event name.", []string{"event"}, nil),
+	}
+
+	m.OnAfterPublish(func(ctx context.Context, event mediator.Event, err error) {
+		c.published.WithLabelValues(event.Name).Inc()
+	})
+	m.OnStoreOperation(func(ctx context.Context, op mediator.StoreOperation) {
+		c.storeTime.WithLabelValues(op.Kind, op.EventName).Observe(op.Duration.Seconds())
+	})
+	m.Use(c.middleware)
+	return c
+}
+
+// middleware measures each handler attempt
+func (c *Collector) middleware(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+	handler, _ := mediator.HandlerNameFromContext(ctx)
+	start := time.Now()
+	err := next(ctx, event)
+	c.handlerTime.WithLabelValues(event.Name, handler).Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.handlerErrors.WithLabelValues(event.Name, handler).Inc()
+	}
+	return err
+}
+
+// Describe sends the descriptors of the metrics
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.published.Describe(ch)
+	c.handlerTime.Describe(ch)
+	c.handlerErrors.Describe(ch)
+	c.storeTime.Describe(ch)
+	ch <- c.queueDepth
+	ch <- c.batchSize
+	ch <- c.deadLetters
+}
+
+// Collect sends the current metrics, reading the dead-letter queue for the
+// dead letters of each subscribed event name
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.published.Collect(ch)
+	c.handlerTime.Collect(ch)
+	c.handlerErrors.Collect(ch)
+	c.storeTime.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(c.mediator.QueueDepth()))
+	if size := c.mediator.StoreBatchSize(); size > 0 {
+		ch <- prometheus.MustNewConstMetric(c.batchSize, prometheus.GaugeValue, float64(size))
+	}
+
+	ctx := context.Background()
+	if c.config.ScrapeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.ScrapeTimeout)
+		defer cancel()
+	}
+	for _, sub := range c.mediator.Subscriptions() {
+		letters, err := c.mediator.DeadLetters(ctx, sub.EventName, 0)
+		if err != nil {
+			// Without a dead-letter queue, or with one failing, none are reported
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.deadLetters, prometheus.GaugeValue, float64(len(letters)), sub.EventName)
+	}
+}
@@ -0,0 +1,99 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	m := mediator.NewMediator(
+		mediator.WithEventStore(mediatortest.NewEventStore()),
+		mediator.WithDeadLetterQueue(mediator.NewMemoryDeadLetterQueue()),
+	)
+	c := NewCollector(m, DefaultConfig())
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(c); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil },
+		mediator.WithHandlerName("reserve-stock"))
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return errors.New("smtp down") },
+		mediator.WithHandlerName("send-email"))
+
+	ctx := context.Background()
+	m.Publish(ctx, mediator.Event{Name: "order.placed"})
+	m.Publish(ctx, mediator.Event{Name: "order.placed"})
+	m.GetEvents(ctx, "order.placed", 10)
+
+	if got := testutil.ToFloat64(c.published.WithLabelValues("order.placed")); got != 2 {
+		t.Errorf("events_published_total = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(c.handlerErrors.WithLabelValues("order.placed", "send-email")); got != 2 {
+		t.Errorf("handler_errors_total{handler=send-email} = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(c.handlerErrors); got != 1 {
+		t.Errorf("Expected errors of the failing handler only, got %d series", got)
+	}
+	if got := testutil.CollectAndCount(c.handlerTime); got != 2 {
+		t.Errorf("handler_duration_seconds has %d series, want 2", got)
+	}
+	if got := testutil.CollectAndCount(c.storeTime); got != 2 {
+		t.Errorf("store_operation_duration_seconds has %d series, want read and write", got)
+	}
+
+	expected := `
+# HELP mediator_dead_letters Dead letters in the dead-letter queue, by event name.
+# TYPE mediator_dead_letters gauge
+mediator_dead_letters{event="order.placed"} 2
+# HELP mediator_queue_depth Events waiting for async workers or the store write buffer.
+# TYPE mediator_queue_depth gauge
+mediator_queue_depth 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "mediator_dead_letters", "mediator_queue_depth"); err != nil {
+		t.Error(err)
+	}
+	if problems, err := testutil.GatherAndLint(registry); err != nil || len(problems) > 0 {
+		t.Errorf("GatherAndLint() = %v, %v", problems, err)
+	}
+}
+
+func TestCollector_WithoutDeadLetterQueue(t *testing.T) {
+	m := mediator.NewMediator()
+	c := NewCollector(m, Config{Namespace: "app"})
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+
+	// Queue depth only; no dead letters are reported without a queue
+	if got := testutil.CollectAndCount(c, "app_dead_letters", "app_queue_depth"); got != 1 {
+		t.Errorf("Expected 1 series, got %d", got)
+	}
+}
+
+func TestCollector_StoreBatchSize(t *testing.T) {
+	config := mediator.DefaultBufferConfig()
+	config.BatchSize = 50
+	m := mediator.NewMediator(mediator.WithEventStore(mediatortest.NewEventStore()), mediator.WithBufferedStore(config))
+	defer m.Close()
+	c := NewCollector(m, DefaultConfig())
+
+	expected := `
+# HELP mediator_store_batch_size Events the store write buffer currently writes at once.
+# TYPE mediator_store_batch_size gauge
+mediator_store_batch_size 50
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "mediator_store_batch_size"); err != nil {
+		t.Error(err)
+	}
+
+	// Test the gauge is not reported without a store write buffer
+	if got := testutil.CollectAndCount(NewCollector(mediator.NewMediator(), DefaultConfig()), "mediator_store_batch_size"); got != 0 {
+		t.Errorf("Expected no store_batch_size series without a buffer, got %d", got)
+	}
+}
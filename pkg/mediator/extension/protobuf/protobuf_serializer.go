@@ -0,0 +1,68 @@
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Serializer encodes protobuf message payloads wrapped in an Any, so decoding
+// into *interface{} yields the original message type as long as it is linked
+// into the binary.
+type Serializer struct{}
+
+var _ mediator.Serializer = Serializer{}
+
+// ContentType returns "application/x-protobuf"
+func (Serializer) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// Marshal encodes v, which must be a proto.Message
+func (Serializer) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("payload of type %T is not a proto.Message", v)
+	}
+	wrapped, err := anypb.New(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap message: %w", err)
+	}
+	return proto.Marshal(wrapped)
+}
+
+// Unmarshal decodes data into v, which must be a proto.Message or *interface{}
+func (Serializer) Unmarshal(data []byte, v interface{}) error {
+	var wrapped anypb.Any
+	if err := proto.Unmarshal(data, &wrapped); err != nil {
+		return err
+	}
+
+	switch target := v.(type) {
+	case proto.Message:
+		return wrapped.UnmarshalTo(target)
+	case *interface{}:
+		msg, err := wrapped.UnmarshalNew()
+		if err != nil {
+			return fmt.Errorf("failed to resolve message type %s: %w", wrapped.GetTypeUrl(), err)
+		}
+		*target = msg
+		return nil
+	default:
+		// Allocate the message for pointer-to-message targets such as **T
+		ptr := reflect.ValueOf(v)
+		if ptr.Kind() == reflect.Pointer && !ptr.IsNil() && ptr.Elem().Kind() == reflect.Pointer {
+			if msg, ok := reflect.New(ptr.Elem().Type().Elem()).Interface().(proto.Message); ok {
+				if err := wrapped.UnmarshalTo(msg); err != nil {
+					return err
+				}
+				ptr.Elem().Set(reflect.ValueOf(msg))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot decode protobuf message into %T", v)
+	}
+}
@@ -0,0 +1,175 @@
+package protobuf
+
+import (
+	"context"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSerializer_RoundTrip(t *testing.T) {
+	s := Serializer{}
+	data, err := s.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	// Test decoding into an interface keeps the concrete type
+	var decoded interface{}
+	if err := s.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	msg, ok := decoded.(*wrapperspb.StringValue)
+	if !ok || msg.GetValue() != "hello" {
+		t.Errorf("Unmarshal() = %#v, want *wrapperspb.StringValue hello", decoded)
+	}
+
+	// Test decoding into a message
+	var target wrapperspb.StringValue
+	if err := s.Unmarshal(data, &target); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !proto.Equal(&target, msg) {
+		t.Errorf("Unmarshal() = %v, want %v", &target, msg)
+	}
+
+	// Test decoding into a different message type
+	var wrong wrapperspb.Int64Value
+	if err := s.Unmarshal(data, &wrong); err == nil {
+		t.Error("Unmarshal() expected error for mismatched message type")
+	}
+}
+
+func TestSerializer_MarshalNonMessage(t *testing.T) {
+	if _, err := (Serializer{}).Marshal(map[string]string{"a": "b"}); err == nil {
+		t.Error("Marshal() expected error for non-message payload")
+	}
+}
+
+func TestSerializer_TypedHandler(t *testing.T) {
+	m := mediator.NewMediator(mediator.WithSerializer(Serializer{}))
+
+	var got *wrapperspb.StringValue
+	_, err := mediator.SubscribeTyped(m, "greeting", func(ctx context.Context, payload *wrapperspb.StringValue) error {
+		got = payload
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SubscribeTyped() error = %v", err)
+	}
+
+	data, err := Serializer{}.Marshal(wrapperspb.String("hi"))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := m.Publish(context.Background(), mediator.Event{Name: "greeting", Payload: data}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got.GetValue() != "hi" {
+		t.Errorf("handler received %v, want hi", got)
+	}
+}
+
+// field describes a field of a generated typepb.Type payload
+type field struct {
+	Name    string
+	Number  int32
+	Packed  bool
+	Default string
+}
+
+// newType builds a payload with scalar, repeated and nested fields
+func newType(name string, fields []field) *typepb.Type {
+	msg := &typepb.Type{Name: name, Syntax: typepb.Syntax_SYNTAX_PROTO3}
+	for _, f := range fields {
+		msg.Fields = append(msg.Fields, &typepb.Field{
+			Kind:         typepb.Field_TYPE_STRING,
+			Cardinality:  typepb.Field_CARDINALITY_REPEATED,
+			Number:       f.Number,
+			Name:         f.Name,
+			Packed:       f.Packed,
+			DefaultValue: f.Default,
+		})
+		msg.Oneofs = append(msg.Oneofs, f.Name)
+	}
+	return msg
+}
+
+// roundTrip encodes payload into a stored record, decodes it again and
+// dispatches it, returning the payload the handler received
+func roundTrip(payload *typepb.Type) (interface{}, error) {
+	registry := mediator.NewTypeRegistry()
+	mediator.RegisterType[*typepb.Type](registry, "schema.changed")
+	m := mediator.NewMediator(mediator.WithSerializer(Serializer{}), mediator.WithTypeRegistry(registry))
+
+	var got interface{}
+	m.Subscribe("schema.changed", func(ctx context.Context, event mediator.Event) error {
+		got = event.Payload
+		return nil
+	})
+
+	data, err := mediator.EncodeEventRecord(Serializer{}, mediator.Event{Name: "schema.changed", ID: "evt-1", Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	stored, err := mediator.DecodeStoredEvent(Serializer{}, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.DispatchStored(context.Background(), stored); err != nil {
+		return nil, err
+	}
+	return got, nil
+}
+
+// sameMessage reports whether got is a *typepb.Type equal to want
+func sameMessage(got interface{}, want *typepb.Type) bool {
+	msg, ok := got.(*typepb.Type)
+	return ok && proto.Equal(msg, want)
+}
+
+func TestSerializer_RoundTripProperty(t *testing.T) {
+	property := func(name string, fields []field) bool {
+		payload := newType(name, fields)
+		got, err := roundTrip(payload)
+		if err != nil {
+			t.Logf("round trip of %v failed: %v", payload, err)
+			return false
+		}
+		if !sameMessage(got, payload) {
+			t.Logf("round trip changed\n  %v\nto\n  %v", payload, got)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func FuzzSerializer_RoundTrip(f *testing.F) {
+	f.Add("Order", "sku", int32(1), true, "")
+	f.Add("", "", int32(-1), false, "\x00")
+	f.Add("é世\U0001f600", "qty", int32(1<<29-1), false, "default")
+
+	f.Fuzz(func(t *testing.T, name, fieldName string, number int32, packed bool, defaultValue string) {
+		// proto3 strings are UTF-8, so no serializer is asked to keep others
+		if !utf8.ValidString(name) || !utf8.ValidString(fieldName) || !utf8.ValidString(defaultValue) {
+			t.Skip()
+		}
+		payload := newType(name, []field{{Name: fieldName, Number: number, Packed: packed, Default: defaultValue}})
+
+		got, err := roundTrip(payload)
+		if err != nil {
+			t.Fatalf("round trip failed: %v", err)
+		}
+		if !sameMessage(got, payload) {
+			t.Errorf("round trip changed %v to %v", payload, got)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ConsumerConfig configures a Consumer
+type ConsumerConfig struct {
+	// Queue is the durable queue consumed, shared by the instances of a
+	// service, each message going to one of them
+	Queue string
+	// Exchange is the exchange the queue is bound to; the queue is not
+	// bound when empty
+	Exchange string
+	// BindingKeys are the routing keys the queue is bound with, e.g. "order.*"
+	BindingKeys []string
+	// DeadLetterExchange receives the messages that are not requeued;
+	// they are dropped when empty. It is set when the queue is declared.
+	DeadLetterExchange string
+	// Requeue requeues a message whose handlers fail the first time it is
+	// delivered; it is dead-lettered when it fails again or without Requeue
+	Requeue bool
+	// Prefetch is how many unacknowledged messages the broker sends at once
+	Prefetch int
+	// Origin identifies this instance, whose own events are acknowledged
+	// without being dispatched; the random ID of the process's transports is
+	// used when empty
+	Origin string
+	// Serializer decodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be decoded or dispatched;
+	// nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultConsumerConfig returns default consumer configuration
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		Exchange:    "mediator.events",
+		BindingKeys: []string{"#"},
+		Requeue:     true,
+		Prefetch:    10,
+	}
+}
+
+// consumeChannel is the part of *amqp.Channel a Consumer uses
+type consumeChannel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+}
+
+// Consumer feeds the events of a RabbitMQ queue into a mediator,
+// acknowledging each message only once the local handlers succeeded
+type Consumer struct {
+	channel  consumeChannel
+	mediator *mediator.Mediator
+	config   ConsumerConfig
+}
+
+// NewConsumer creates a consumer dispatching the messages of a queue into m
+func NewConsumer(channel *amqp.Channel, m *mediator.Mediator, config ConsumerConfig) *Consumer {
+	return newConsumer(channel, m, config)
+}
+
+// newConsumer creates a consumer on any consume channel
+func newConsumer(channel consumeChannel, m *mediator.Mediator, config ConsumerConfig) *Consumer {
+	if config.Origin == "" {
+		config.Origin = processOrigin
+	}
+	return &Consumer{channel: channel, mediator: m, config: config}
+}
+
+// Run declares and binds the queue, then dispatches its messages until ctx
+// is cancelled. Messages left unacknowledged when Run stops are requeued by
+// the broker.
+func (c *Consumer) Run(ctx context.Context) error {
+	if c.config.Queue == "" {
+		return fmt.Errorf("a queue name is required")
+	}
+	if err := c.declare(); err != nil {
+		return err
+	}
+
+	tag := "mediator-" + randomID()
+	deliveries, err := c.channel.Consume(c.config.Queue, tag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", c.config.Queue, err)
+	}
+	defer c.channel.Cancel(tag, false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("channel of %s closed", c.config.Queue)
+			}
+			if err := c.handle(ctx, delivery); err != nil && c.config.Logger != nil {
+				c.config.Logger.Printf("rabbitmq consumer: %v", err)
+			}
+		}
+	}
+}
+
+// declare sets the prefetch count and declares and binds the queue
+func (c *Consumer) declare() error {
+	if c.config.Prefetch > 0 {
+		if err := c.channel.Qos(c.config.Prefetch, 0, false); err != nil {
+			return fmt.Errorf("failed to set prefetch count: %w", err)
+		}
+	}
+
+	var args amqp.Table
+	if c.config.DeadLetterExchange != "" {
+		args = amqp.Table{"x-dead-letter-exchange": c.config.DeadLetterExchange}
+	}
+	if _, err := c.channel.QueueDeclare(c.config.Queue, true, false, false, false, args); err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", c.config.Queue, err)
+	}
+	if c.config.Exchange == "" {
+		return nil
+	}
+	for _, key := range c.config.BindingKeys {
+		if err := c.channel.QueueBind(c.config.Queue, key, c.config.Exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue %s to %s: %w", c.config.Queue, key, err)
+		}
+	}
+	return nil
+}
+
+// handle dispatches the event of a delivery, then acknowledges it, or nacks
+// it when it can't be decoded or its handlers fail
+func (c *Consumer) handle(ctx context.Context, delivery amqp.Delivery) error {
+	if origin, _ := delivery.Headers[OriginHeader].(string); origin == c.config.Origin {
+		return delivery.Ack(false)
+	}
+
+	stored, err := mediator.DecodeStoredEvent(c.config.Serializer, delivery.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal message %s: %w", delivery.MessageId, err)
+		return errors.Join(err, delivery.Nack(false, false))
+	}
+
+	err = c.mediator.DispatchRemote(ctx, stored.Event())
+	if err != nil {
+		err = fmt.Errorf("failed to dispatch event %s: %w", stored.ID, err)
+		return errors.Join(err, delivery.Nack(false, c.config.Requeue && !delivery.Redelivered))
+	}
+	return delivery.Ack(false)
+}
@@ -0,0 +1,188 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// settlement is how a delivery was settled
+type settlement struct {
+	tag     uint64
+	ack     bool
+	requeue bool
+}
+
+// fakeAcknowledger records how deliveries are settled
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	settled []settlement
+}
+
+func (a *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.settled = append(a.settled, settlement{tag: tag, ack: true})
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.settled = append(a.settled, settlement{tag: tag, requeue: requeue})
+	return nil
+}
+
+func (a *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func (a *fakeAcknowledger) settlements() []settlement {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]settlement(nil), a.settled...)
+}
+
+// fakeConsumeChannel serves queued deliveries and records declarations
+type fakeConsumeChannel struct {
+	deliveries chan amqp.Delivery
+
+	mu       sync.Mutex
+	prefetch int
+	args     amqp.Table
+	bindings []string
+	canceled bool
+}
+
+func (c *fakeConsumeChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	c.prefetch = prefetchCount
+	return nil
+}
+
+func (c *fakeConsumeChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	c.args = args
+	return amqp.Queue{Name: name}, nil
+}
+
+func (c *fakeConsumeChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	c.bindings = append(c.bindings, exchange+" "+key)
+	return nil
+}
+
+func (c *fakeConsumeChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return c.deliveries, nil
+}
+
+func (c *fakeConsumeChannel) Cancel(consumer string, noWait bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.canceled = true
+	return nil
+}
+
+// delivery returns a delivery of an event published by origin
+func delivery(t *testing.T, ack amqp.Acknowledger, tag uint64, origin string, event mediator.Event) amqp.Delivery {
+	t.Helper()
+	body, err := mediator.EncodeEventRecord(nil, event)
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	return amqp.Delivery{
+		Acknowledger: ack,
+		DeliveryTag:  tag,
+		Headers:      amqp.Table{OriginHeader: origin},
+		MessageId:    event.ID,
+		Body:         body,
+	}
+}
+
+func TestConsumer(t *testing.T) {
+	ack := &fakeAcknowledger{}
+	channel := &fakeConsumeChannel{deliveries: make(chan amqp.Delivery, 10)}
+	channel.deliveries <- delivery(t, ack, 1, "orders", mediator.Event{Name: "order.placed", ID: "evt-own"})
+	channel.deliveries <- delivery(t, ack, 2, "billing", mediator.Event{Name: "order.placed", ID: "evt-ok"})
+	channel.deliveries <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 3, Body: []byte("not json")}
+	channel.deliveries <- delivery(t, ack, 4, "billing", mediator.Event{Name: "order.placed", ID: "evt-fail"})
+	redelivered := delivery(t, ack, 5, "billing", mediator.Event{Name: "order.placed", ID: "evt-fail"})
+	redelivered.Redelivered = true
+	channel.deliveries <- redelivered
+	channel.deliveries <- delivery(t, ack, 6, "billing", mediator.Event{Name: "user.created", ID: "evt-user"})
+
+	m := mediator.NewMediator()
+	received := make(chan mediator.Event, 10)
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		received <- event
+		if event.ID == "evt-fail" {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	config := DefaultConsumerConfig()
+	config.Queue = "billing"
+	config.DeadLetterExchange = "mediator.dead"
+	config.Origin = "orders"
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- newConsumer(channel, m, config).Run(ctx)
+	}()
+
+	// Test every delivery is settled in order
+	want := []settlement{
+		{tag: 1, ack: true},     // own event, skipped
+		{tag: 2, ack: true},     // dispatched
+		{tag: 3},                // not an event, dead-lettered
+		{tag: 4, requeue: true}, // failed, requeued
+		{tag: 5},                // failed again, dead-lettered
+		{tag: 6, ack: true},     // no handlers
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(ack.settlements()) < len(want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d settlements, got %+v", len(want), ack.settlements())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for i, got := range ack.settlements() {
+		if got != want[i] {
+			t.Errorf("Settlement %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	event := <-received
+	if event.ID != "evt-ok" || !mediator.IsRemote(event) {
+		t.Errorf("Expected remote evt-ok, got %+v", event)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+
+	// Test the queue was declared, bound and canceled
+	if channel.prefetch != 10 {
+		t.Errorf("Expected prefetch 10, got %d", channel.prefetch)
+	}
+	if channel.args["x-dead-letter-exchange"] != "mediator.dead" {
+		t.Errorf("Expected dead letter exchange mediator.dead, got %v", channel.args)
+	}
+	if len(channel.bindings) != 1 || channel.bindings[0] != "mediator.events #" {
+		t.Errorf("Expected binding mediator.events #, got %v", channel.bindings)
+	}
+	if !channel.canceled {
+		t.Error("Expected consumer to be canceled")
+	}
+}
+
+func TestConsumer_RequiresQueue(t *testing.T) {
+	consumer := newConsumer(&fakeConsumeChannel{}, mediator.NewMediator(), DefaultConsumerConfig())
+	if err := consumer.Run(context.Background()); err == nil {
+		t.Error("Expected an error without a queue")
+	}
+}
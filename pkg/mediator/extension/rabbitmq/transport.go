@@ -0,0 +1,266 @@
+package rabbitmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// OriginHeader carries the Origin of the publishing instance
+const OriginHeader = "mediator-origin"
+
+// processOrigin is the Origin of transports and consumers configured
+// without one, so those of a process skip its own events
+var processOrigin = randomID()
+
+// randomID returns a random hex ID
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// TransportConfig configures a Transport
+type TransportConfig struct {
+	// Exchange is the exchange events are published to
+	Exchange string
+	// ExchangeKind is the kind the exchange is declared as, durable; an
+	// existing exchange is used without declaring it when empty
+	ExchangeKind string
+	// RoutingKeyPrefix is prepended to event names to form their routing keys
+	RoutingKeyPrefix string
+	// RoutingKeys maps event names to routing keys, overriding RoutingKeyPrefix
+	RoutingKeys map[string]string
+	// Events limits the events published to these names; every event when empty
+	Events []string
+	// Confirm puts the channel in confirm mode and waits for the broker to
+	// confirm each event, failing the publish when it is nacked
+	Confirm bool
+	// ConfirmTimeout bounds the wait for a confirmation
+	ConfirmTimeout time.Duration
+	// Buffer is how many events a subscription buffers for the mediator
+	Buffer int
+	// Origin identifies this instance, whose own events its consumers skip;
+	// a random ID shared by the process is used when empty
+	Origin string
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports messages that could not be decoded; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultTransportConfig returns default transport configuration
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Exchange:       "mediator.events",
+		ExchangeKind:   amqp.ExchangeTopic,
+		Confirm:        true,
+		ConfirmTimeout: 5 * time.Second,
+		Buffer:         64,
+	}
+}
+
+// transportChannel is the part of *amqp.Channel a Transport uses
+type transportChannel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	Confirm(noWait bool) error
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error)
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+}
+
+// Transport is a mediator.Transport carrying events over a RabbitMQ
+// exchange, routed by keys mapped from their names. Attach it with
+// mediator.WithTransport: the events dispatched locally are published to
+// the exchange, and each event name subscribed to is received through an
+// exclusive queue of the transport bound with its routing key. Those queues
+// are deleted with the channel, so they deliver at most once, to the
+// transports connected at the time; use a Consumer of a durable queue for
+// at-least-once delivery.
+type Transport struct {
+	channel transportChannel
+	config  TransportConfig
+	events  map[string]bool
+
+	// ctx stops the subscriptions when the transport is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ mediator.Transport = (*Transport)(nil)
+
+// NewTransport creates a transport on channel, declaring the exchange. The
+// channel should be used by the transport only, as confirm mode applies to
+// all of its publishes.
+func NewTransport(channel *amqp.Channel, config TransportConfig) (*Transport, error) {
+	return newTransport(channel, config)
+}
+
+// newTransport creates a transport on any transport channel
+func newTransport(channel transportChannel, config TransportConfig) (*Transport, error) {
+	if config.Origin == "" {
+		config.Origin = processOrigin
+	}
+	if config.ExchangeKind != "" {
+		if err := channel.ExchangeDeclare(config.Exchange, config.ExchangeKind, true, false, false, false, nil); err != nil {
+			return nil, fmt.Errorf("failed to declare exchange %s: %w", config.Exchange, err)
+		}
+	}
+	if config.Confirm {
+		if err := channel.Confirm(false); err != nil {
+			return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{
+		channel: channel,
+		config:  config,
+		events:  make(map[string]bool, len(config.Events)),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	for _, name := range config.Events {
+		t.events[name] = true
+	}
+	return t, nil
+}
+
+// RoutingKey returns the routing key of an event name. The dots of event
+// names separate the words of routing keys, so topic bindings such as
+// "order.*" match event names.
+func (t *Transport) RoutingKey(eventName string) string {
+	if key, ok := t.config.RoutingKeys[eventName]; ok {
+		return key
+	}
+	return t.config.RoutingKeyPrefix + eventName
+}
+
+// Publish publishes an event as a persistent message, unless Events leaves
+// its name out, and, with Confirm, waits for the broker to confirm it
+func (t *Transport) Publish(ctx context.Context, event mediator.Event) error {
+	if len(t.events) > 0 && !t.events[event.Name] {
+		return nil
+	}
+
+	body, err := mediator.EncodeEventRecord(t.config.Serializer, event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := amqp.Publishing{
+		ContentType:   "application/json",
+		DeliveryMode:  amqp.Persistent,
+		MessageId:     event.ID,
+		CorrelationId: event.CorrelationID,
+		Timestamp:     event.Timestamp,
+		Type:          event.Name,
+		Headers:       amqp.Table{OriginHeader: t.config.Origin},
+		Body:          body,
+	}
+	confirmation, err := t.channel.PublishWithDeferredConfirmWithContext(ctx, t.config.Exchange, t.RoutingKey(event.Name), false, false, msg)
+	if err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.ID, err)
+	}
+	// Channels not in confirm mode return no confirmation
+	if confirmation == nil {
+		return nil
+	}
+
+	if t.config.ConfirmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.config.ConfirmTimeout)
+		defer cancel()
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to confirm event %s: %w", event.ID, err)
+	}
+	if !acked {
+		return fmt.Errorf("broker nacked event %s", event.ID)
+	}
+	return nil
+}
+
+// Subscribe declares an exclusive queue bound to the exchange with the
+// routing key of an event name and returns its events, until the transport
+// is closed, when the channel is closed
+func (t *Transport) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	if t.ctx.Err() != nil {
+		return nil, fmt.Errorf("transport is closed")
+	}
+
+	queue, err := t.channel.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare queue of %s: %w", eventName, err)
+	}
+	if err := t.channel.QueueBind(queue.Name, t.RoutingKey(eventName), t.config.Exchange, false, nil); err != nil {
+		return nil, fmt.Errorf("failed to bind queue of %s: %w", eventName, err)
+	}
+	tag := "mediator-" + randomID()
+	deliveries, err := t.channel.Consume(queue.Name, tag, true, true, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume queue of %s: %w", eventName, err)
+	}
+
+	events := make(chan mediator.Event, t.config.Buffer)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer close(events)
+		defer t.channel.Cancel(tag, false)
+		t.receive(deliveries, events)
+	}()
+	return events, nil
+}
+
+// receive decodes the deliveries of a queue into events until the transport
+// is closed or the channel is
+func (t *Transport) receive(deliveries <-chan amqp.Delivery, events chan<- mediator.Event) {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case delivery, ok := <-deliveries:
+			if !ok {
+				t.logf("channel closed")
+				return
+			}
+			stored, err := mediator.DecodeStoredEvent(t.config.Serializer, delivery.Body)
+			if err != nil {
+				t.logf("failed to unmarshal message %s: %v", delivery.MessageId, err)
+				continue
+			}
+
+			select {
+			case events <- stored.Event():
+			case <-t.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Close stops the subscriptions and closes their channels. It does not
+// close the AMQP channel.
+func (t *Transport) Close() error {
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// logf reports a failure to the configured logger
+func (t *Transport) logf(format string, args ...interface{}) {
+	if t.config.Logger != nil {
+		t.config.Logger.Printf("rabbitmq transport: "+format, args...)
+	}
+}
@@ -0,0 +1,257 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// published is a message published to an exchange
+type published struct {
+	exchange string
+	key      string
+	msg      amqp.Publishing
+}
+
+// fakeTransportChannel records declarations and publishes, routing each
+// publish to the queues bound with its key
+type fakeTransportChannel struct {
+	mu        sync.Mutex
+	declared  []string
+	confirm   bool
+	published []published
+	err       error
+
+	// queues are the deliveries of the declared queues, by name
+	queues map[string]chan amqp.Delivery
+	// bindings are the routing keys of the queues, by name
+	bindings map[string]string
+	// consumers are the queues consumed, by consumer tag
+	consumers map[string]string
+}
+
+func newFakeTransportChannel() *fakeTransportChannel {
+	return &fakeTransportChannel{
+		queues:    make(map[string]chan amqp.Delivery),
+		bindings:  make(map[string]string),
+		consumers: make(map[string]string),
+	}
+}
+
+func (c *fakeTransportChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	c.declared = append(c.declared, name+" "+kind)
+	return nil
+}
+
+func (c *fakeTransportChannel) Confirm(noWait bool) error {
+	c.confirm = true
+	return nil
+}
+
+func (c *fakeTransportChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) (*amqp.DeferredConfirmation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.published = append(c.published, published{exchange: exchange, key: key, msg: msg})
+	for queue, bound := range c.bindings {
+		if bound == key {
+			c.queues[queue] <- amqp.Delivery{MessageId: msg.MessageId, Headers: msg.Headers, Body: msg.Body}
+		}
+	}
+	return nil, nil
+}
+
+func (c *fakeTransportChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name == "" {
+		name = fmt.Sprintf("amq.gen-%d", len(c.queues))
+	}
+	c.queues[name] = make(chan amqp.Delivery, 10)
+	return amqp.Queue{Name: name}, nil
+}
+
+func (c *fakeTransportChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bindings[name] = key
+	return nil
+}
+
+func (c *fakeTransportChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consumers[consumer] = queue
+	return c.queues[queue], nil
+}
+
+// Cancel deletes the queue of a consumer, as exclusive auto-delete queues are
+func (c *fakeTransportChannel) Cancel(consumer string, noWait bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queue := c.consumers[consumer]
+	delete(c.consumers, consumer)
+	delete(c.bindings, queue)
+	delete(c.queues, queue)
+	return nil
+}
+
+func (c *fakeTransportChannel) queueCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queues)
+}
+
+func TestTransport_RoutingKey(t *testing.T) {
+	config := DefaultTransportConfig()
+	config.RoutingKeyPrefix = "acme."
+	config.RoutingKeys = map[string]string{"audit.logged": "audit"}
+	transport, err := newTransport(newFakeTransportChannel(), config)
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+
+	tests := []struct {
+		eventName string
+		want      string
+	}{
+		{"order.placed", "acme.order.placed"},
+		{"user.created", "acme.user.created"},
+		{"audit.logged", "audit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventName, func(t *testing.T) {
+			if got := transport.RoutingKey(tt.eventName); got != tt.want {
+				t.Errorf("RoutingKey() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       TransportConfig
+		wantDeclared int
+		wantConfirm  bool
+	}{
+		{"defaults", DefaultTransportConfig(), 1, true},
+		{"existing exchange", TransportConfig{Exchange: "events"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := newFakeTransportChannel()
+			if _, err := newTransport(channel, tt.config); err != nil {
+				t.Fatalf("Failed to create transport: %v", err)
+			}
+			if len(channel.declared) != tt.wantDeclared {
+				t.Errorf("Expected %d exchanges declared, got %v", tt.wantDeclared, channel.declared)
+			}
+			if channel.confirm != tt.wantConfirm {
+				t.Errorf("Confirm mode = %v, want %v", channel.confirm, tt.wantConfirm)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	channel := newFakeTransportChannel()
+	ctx := context.Background()
+
+	// Start two instances attached to each other
+	recorders := []*deliverytest.Recorder{deliverytest.NewRecorder(), deliverytest.NewRecorder()}
+	mediators := make([]*mediator.Mediator, len(recorders))
+	for i := range mediators {
+		config := DefaultTransportConfig()
+		config.Origin = "orders"
+		config.Events = []string{"order.placed"}
+		transport, err := newTransport(channel, config)
+		if err != nil {
+			t.Fatalf("Failed to create transport: %v", err)
+		}
+		defer transport.Close()
+
+		m := mediator.NewMediator(mediator.WithTransport(transport))
+		m.Subscribe("order.placed", recorders[i].Handle)
+		m.Subscribe("user.created", func(ctx context.Context, event mediator.Event) error { return nil })
+		mediators[i] = m
+	}
+
+	placed := mediator.Event{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"id": "o-1"}}
+	if err := mediators[0].Publish(ctx, placed); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if err := mediators[0].Publish(ctx, mediator.Event{Name: "user.created", ID: "evt-2"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Test only the event of the configured names is published
+	channel.mu.Lock()
+	messages := append([]published(nil), channel.published...)
+	channel.mu.Unlock()
+	if len(messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.exchange != "mediator.events" || message.key != "order.placed" {
+		t.Errorf("Expected order.placed on mediator.events, got %s on %s", message.key, message.exchange)
+	}
+	if message.msg.MessageId != "evt-1" || message.msg.Type != "order.placed" || message.msg.DeliveryMode != amqp.Persistent {
+		t.Errorf("Unexpected message properties: %+v", message.msg)
+	}
+	if origin := message.msg.Headers[OriginHeader]; origin != "orders" {
+		t.Errorf("Expected origin orders, got %v", origin)
+	}
+
+	// Test the publishing instance dispatches once, the other once, remotely
+	deliverytest.CheckOnce(t, 5*time.Second, placed, mediators, recorders)
+}
+
+func TestTransport_PublishError(t *testing.T) {
+	channel := newFakeTransportChannel()
+	channel.err = errors.New("channel closed")
+	transport, err := newTransport(channel, DefaultTransportConfig())
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+
+	err = transport.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"})
+	if err == nil || !errors.Is(err, channel.err) {
+		t.Errorf("Publish() error = %v, want %v", err, channel.err)
+	}
+}
+
+func TestTransport_Close(t *testing.T) {
+	channel := newFakeTransportChannel()
+	transport, err := newTransport(channel, DefaultTransportConfig())
+	if err != nil {
+		t.Fatalf("Failed to create transport: %v", err)
+	}
+	events, err := transport.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// Test closing the transport closes the channel and cancels the consumer
+	transport.Close()
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if n := channel.queueCount(); n != 0 {
+		t.Errorf("Expected the queue to be deleted, got %d queues", n)
+	}
+	if _, err := transport.Subscribe("order.placed"); err == nil {
+		t.Error("Subscribe() error = nil after Close")
+	}
+}
@@ -0,0 +1,176 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// BridgeConfig configures a Bridge
+type BridgeConfig struct {
+	// Channel prefixes the Pub/Sub channels events are broadcast on, one
+	// per event name: the prefix, ':' and the name
+	Channel string
+	// Events limits the bridge to these event names; every event when empty
+	Events []string
+	// Buffer is how many events a subscription buffers for the mediator
+	Buffer int
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Logger reports broadcasts that could not be decoded; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultBridgeConfig returns default bridge configuration
+func DefaultBridgeConfig() BridgeConfig {
+	return BridgeConfig{
+		Channel: "mediator:events:bridge",
+		Buffer:  64,
+	}
+}
+
+// Bridge is a mediator.Transport fanning events out between the instances
+// of a service over Redis Pub/Sub. Attach it with mediator.WithTransport:
+// the events dispatched by an instance are broadcast, and those broadcast
+// by the other instances are dispatched to its handlers. Pub/Sub delivers
+// at most once, to the instances subscribed at the time.
+type Bridge struct {
+	client redis.UniversalClient
+	config BridgeConfig
+	events map[string]bool
+
+	// ctx stops the subscriptions when the bridge is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ mediator.Transport = (*Bridge)(nil)
+
+// NewBridge creates a bridge broadcasting on the channels of config
+func NewBridge(client redis.UniversalClient, config BridgeConfig) *Bridge {
+	if config.Channel == "" {
+		config.Channel = DefaultBridgeConfig().Channel
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bridge{
+		client: client,
+		config: config,
+		events: make(map[string]bool, len(config.Events)),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, name := range config.Events {
+		b.events[name] = true
+	}
+	return b
+}
+
+// bridged reports whether the bridge carries an event name
+func (b *Bridge) bridged(eventName string) bool {
+	return len(b.events) == 0 || b.events[eventName]
+}
+
+// channel returns the Pub/Sub channel of an event name
+func (b *Bridge) channel(eventName string) string {
+	return b.config.Channel + ":" + eventName
+}
+
+// Publish broadcasts an event to the other instances, unless the bridge
+// doesn't carry its name
+func (b *Bridge) Publish(ctx context.Context, event mediator.Event) error {
+	if !b.bridged(event.Name) {
+		return nil
+	}
+
+	data, err := mediator.EncodeEventRecord(b.config.Serializer, event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if err := b.client.Publish(ctx, b.channel(event.Name), data).Err(); err != nil {
+		return fmt.Errorf("failed to broadcast event %s: %w", event.ID, err)
+	}
+	return nil
+}
+
+// Subscribe returns the events of an event name broadcast by the instances,
+// once subscribed to its channel, until the bridge is closed, when the
+// channel is closed. The channel of a name the bridge doesn't carry gets no
+// events.
+func (b *Bridge) Subscribe(eventName string) (<-chan mediator.Event, error) {
+	if b.ctx.Err() != nil {
+		return nil, fmt.Errorf("bridge is closed")
+	}
+
+	events := make(chan mediator.Event, b.config.Buffer)
+	if !b.bridged(eventName) {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			defer close(events)
+			<-b.ctx.Done()
+		}()
+		return events, nil
+	}
+
+	// Wait for the subscription so no broadcast is missed once it returns
+	pubsub := b.client.Subscribe(b.ctx, b.channel(eventName))
+	if _, err := pubsub.Receive(b.ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", b.channel(eventName), err)
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer close(events)
+		defer pubsub.Close()
+		b.receive(pubsub.Channel(), events)
+	}()
+	return events, nil
+}
+
+// receive decodes the messages of a subscription into events until the
+// bridge is closed
+func (b *Bridge) receive(messages <-chan *redis.Message, events chan<- mediator.Event) {
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			stored, err := mediator.DecodeStoredEvent(b.config.Serializer, []byte(message.Payload))
+			if err != nil {
+				b.logf("failed to unmarshal event: %v", err)
+				continue
+			}
+
+			select {
+			case events <- stored.Event():
+			case <-b.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Close stops the subscriptions and closes their channels. It does not
+// close the client.
+func (b *Bridge) Close() error {
+	b.cancel()
+	b.wg.Wait()
+	return nil
+}
+
+// logf reports a failure to the configured logger
+func (b *Bridge) logf(format string, args ...interface{}) {
+	if b.config.Logger != nil {
+		b.config.Logger.Printf("redis bridge: "+format, args...)
+	}
+}
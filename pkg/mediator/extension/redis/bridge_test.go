@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+)
+
+func TestBridge(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Start two instances, bridging order events only
+	config := DefaultBridgeConfig()
+	config.Events = []string{"order.placed"}
+	mediators := make([]*mediator.Mediator, 2)
+	orders := []*deliverytest.Recorder{deliverytest.NewRecorder(), deliverytest.NewRecorder()}
+	caches := []*deliverytest.Recorder{deliverytest.NewRecorder(), deliverytest.NewRecorder()}
+	for i := range mediators {
+		bridge := NewBridge(client, config)
+		defer bridge.Close()
+		m := mediator.NewMediator(mediator.WithTransport(bridge))
+		m.Subscribe("order.placed", orders[i].Handle)
+		m.Subscribe("cache.warmed", caches[i].Handle)
+		mediators[i] = m
+	}
+
+	// Test both bridges subscribed to the order channel only
+	subs, err := client.PubSubNumSub(ctx, "mediator:events:bridge:order.placed", "mediator:events:bridge:cache.warmed").Result()
+	if err != nil {
+		t.Fatalf("Failed to count subscribers: %v", err)
+	}
+	if subs["mediator:events:bridge:order.placed"] != 2 || subs["mediator:events:bridge:cache.warmed"] != 0 {
+		t.Fatalf("Expected 2 subscribers of order.placed only, got %v", subs)
+	}
+
+	placed := mediator.Event{Name: "order.placed", ID: "evt-1", Payload: map[string]interface{}{"id": "o-1"}}
+	if err := mediators[0].Publish(ctx, placed); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if err := mediators[0].Publish(ctx, mediator.Event{Name: "cache.warmed", ID: "evt-2"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	// Test the other instance receives the bridged event once, marked remote,
+	// and the publishing instance skips its own broadcast
+	deliverytest.CheckOnce(t, 5*time.Second, placed, mediators, orders)
+	if remote := orders[1].Events(); len(remote) == 1 {
+		if payload, ok := remote[0].Payload.(map[string]interface{}); !ok || payload["id"] != "o-1" {
+			t.Errorf("Expected payload with id o-1, got %#v", remote[0].Payload)
+		}
+	}
+
+	// Test the events left out of Events are handled locally only
+	if local, remote := caches[0].Events(), caches[1].Events(); len(local) != 1 || len(remote) != 0 {
+		t.Errorf("Expected evt-2 to be handled by the publishing instance only, got %d and %d", len(local), len(remote))
+	}
+}
+
+func TestBridge_Close(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	config := DefaultBridgeConfig()
+	config.Events = []string{"order.placed"}
+	bridge := NewBridge(client, config)
+	placed, err := bridge.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	warmed, err := bridge.Subscribe("cache.warmed")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	// Test closing the bridge closes the channels of its subscriptions
+	bridge.Close()
+	for _, ch := range []<-chan mediator.Event{placed, warmed} {
+		if _, ok := <-ch; ok {
+			t.Error("Expected the channel to be closed")
+		}
+	}
+	if _, err := bridge.Subscribe("order.placed"); err == nil {
+		t.Error("Subscribe() error = nil after Close")
+	}
+}
+
+func TestBridge_DeliveryGuarantees(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	// Events published by one instance are handled by another over the bridge
+	config := deliverytest.DefaultConfig()
+	config.Backend = "redis bridge"
+	config.Claims = deliverytest.AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) deliverytest.System {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		store := NewEventStore(client, DefaultConfig())
+		dedupe := mediator.NewStoreDedupeStore(store, 1000)
+
+		publisherBridge := NewBridge(client, DefaultBridgeConfig())
+		subscriberBridge := NewBridge(client, DefaultBridgeConfig())
+		publisher := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithEventStore(store), mediator.WithTransport(publisherBridge))
+		subscriber := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithDeduplication(dedupe), mediator.WithTransport(subscriberBridge))
+		return deliverytest.System{
+			Publish: publisher.Publish,
+			Subscribe: func(eventName string, handler mediator.EventHandler) {
+				subscriber.Subscribe(eventName, handler)
+			},
+			Store:  store,
+			Dedupe: dedupe,
+			Close: func() error {
+				return errors.Join(subscriber.Close(), publisher.Close(), subscriberBridge.Close(), publisherBridge.Close(), store.Close())
+			},
+		}
+	}
+
+	report := deliverytest.Run(t, config)
+	var buf bytes.Buffer
+	report.WriteMarkdown(&buf)
+	t.Logf("conformance report:\n%s", buf.String())
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// nameKey returns the part of the keys of an event name before their suffix.
+// With hash tags the event name is wrapped in braces, so Redis Cluster hashes
+// only the name and every key of an event name lands in the same slot.
+func nameKey(prefix, eventName string, hashTags bool) string {
+	if hashTags {
+		return fmt.Sprintf("%s:{%s}", prefix, eventName)
+	}
+	return fmt.Sprintf("%s:%s", prefix, eventName)
+}
+
+// keyName returns the event name of a key made by nameKey and suffix
+func keyName(key, prefix, suffix string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(key, prefix+":"), suffix)
+	if strings.HasPrefix(name, "{") && strings.HasSuffix(name, "}") {
+		name = name[1 : len(name)-1]
+	}
+	return name
+}
+
+// scanKeys returns the keys matching pattern. A cluster client scans every
+// master, as SCAN only walks the keys of the node it runs on.
+func scanKeys(ctx context.Context, client redis.UniversalClient, pattern string) ([]string, error) {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return scanNode(ctx, client, pattern)
+	}
+
+	var mu sync.Mutex
+	var keys []string
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		nodeKeys, err := scanNode(ctx, node, pattern)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		keys = append(keys, nodeKeys...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// scanNode returns the keys matching pattern on a single node
+func scanNode(ctx context.Context, client redis.Cmdable, pattern string) ([]string, error) {
+	var keys []string
+	iter := client.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		hashTags bool
+		want     string
+	}{
+		{"plain", false, "mediator:events:order.placed"},
+		{"hash tags", true, "mediator:events:{order.placed}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := nameKey("mediator:events", "order.placed", tt.hashTags)
+			if key != tt.want {
+				t.Errorf("nameKey() = %s, want %s", key, tt.want)
+			}
+			if name := keyName(key+":timeline", "mediator:events", ":timeline"); name != "order.placed" {
+				t.Errorf("keyName() = %s, want order.placed", name)
+			}
+		})
+	}
+}
+
+func TestEventStore_Cluster(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	// miniredis answers CLUSTER SLOTS as a single node owning every slot
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	defer client.Close()
+
+	config := DefaultConfig()
+	config.HashTags = true
+	config.Retention = mediator.Retention{Default: mediator.KeepLast(1)}
+	store := NewEventStore(client, config)
+	ctx := context.Background()
+
+	events := []mediator.Event{
+		{Name: "order.placed", ID: "evt-1"},
+		{Name: "order.placed", ID: "evt-2"},
+	}
+	if err := store.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	if len(mr.Keys()) != 3 {
+		t.Fatalf("Expected 3 keys, got %v", mr.Keys())
+	}
+	// Redis Cluster hashes only the hash tag, so every key shares its slot
+	for _, key := range mr.Keys() {
+		if !strings.Contains(key, "{order.placed}") {
+			t.Errorf("Expected key %s to carry the hash tag {order.placed}", key)
+		}
+	}
+
+	streams, err := store.GetStreams(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	if len(streams) != 1 || streams[0].Name != "order.placed" || streams[0].Count != 2 {
+		t.Errorf("Expected 1 stream of 2 events, got %+v", streams)
+	}
+
+	// Test the transactional trim runs within the event name's slot
+	if err := store.EnforceRetention(ctx); err != nil {
+		t.Fatalf("Failed to enforce retention: %v", err)
+	}
+	stored, err := store.ReadEvents(ctx, "order.placed", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(stored) != 1 || stored[0].ID != "evt-2" {
+		t.Errorf("Expected evt-2 to be kept, got %+v", stored)
+	}
+}
+
+func TestEventStore_ClusterWithoutHashTags(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	// Keys without hash tags may live in different slots, so they are read
+	// with a GET each rather than a single MGET
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	defer client.Close()
+	store := NewEventStore(client, DefaultConfig())
+	ctx := context.Background()
+
+	for _, id := range []string{"evt-1", "evt-2"} {
+		if err := store.StoreEvent(ctx, mediator.Event{Name: "order.placed", ID: id}); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+	stored, err := store.ReadEvents(ctx, "order.placed", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(stored) != 2 || stored[0].ID != "evt-1" || stored[1].ID != "evt-2" {
+		t.Errorf("Expected evt-1 and evt-2, got %+v", stored)
+	}
+}
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// GroupStore is a Redis-based mediator.GroupStore. Offsets come from a counter
+// per group and subscribed event name; pending records are kept in a hash until acked.
+type GroupStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewGroupStore creates a Redis group store using the prefix of config
+func NewGroupStore(client redis.UniversalClient, config Config) *GroupStore {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+	return &GroupStore{
+		client: client,
+		prefix: config.Prefix,
+	}
+}
+
+// Append adds an event to the group's log of eventName and returns its offset
+func (s *GroupStore) Append(ctx context.Context, group, eventName string, event mediator.Event) (int64, error) {
+	offset, err := s.client.Incr(ctx, s.key(group, eventName, "offset")).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate offset: %w", err)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	err = s.client.HSet(ctx, s.key(group, eventName, "pending"), strconv.FormatInt(offset, 10), data).Err()
+	if err != nil {
+		return 0, fmt.Errorf("failed to store pending event: %w", err)
+	}
+	return offset, nil
+}
+
+// Ack marks the record at offset as processed by the group
+func (s *GroupStore) Ack(ctx context.Context, group, eventName string, offset int64) error {
+	err := s.client.HDel(ctx, s.key(group, eventName, "pending"), strconv.FormatInt(offset, 10)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to ack event: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the unacknowledged records of the group, oldest first
+func (s *GroupStore) Pending(ctx context.Context, group, eventName string) ([]mediator.GroupRecord, error) {
+	entries, err := s.client.HGetAll(ctx, s.key(group, eventName, "pending")).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending events: %w", err)
+	}
+
+	records := make([]mediator.GroupRecord, 0, len(entries))
+	for field, data := range entries {
+		offset, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset %q: %w", field, err)
+		}
+
+		var event mediator.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		records = append(records, mediator.GroupRecord{Offset: offset, Event: event})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Offset < records[j].Offset
+	})
+	return records, nil
+}
+
+// key returns the Redis key of a group log component
+func (s *GroupStore) key(group, eventName, kind string) string {
+	return fmt.Sprintf("%s:groups:%s:%s:%s", s.prefix, group, eventName, kind)
+}
@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestGroupStore(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewGroupStore(client, DefaultConfig())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		offset, err := store.Append(ctx, "billing", "order.placed", mediator.Event{Name: "order.placed", ID: string(rune('a' + i))})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		if offset != int64(i+1) {
+			t.Errorf("Append() offset = %d, want %d", offset, i+1)
+		}
+	}
+
+	if err := store.Ack(ctx, "billing", "order.placed", 2); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+
+	records, err := store.Pending(ctx, "billing", "order.placed")
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(records) != 2 || records[0].Offset != 1 || records[1].Offset != 3 {
+		t.Fatalf("Pending() = %+v, want offsets [1 3]", records)
+	}
+	if records[0].Event.ID != "a" || records[1].Event.ID != "c" {
+		t.Errorf("Pending() events = %s, %s, want a, c", records[0].Event.ID, records[1].Event.ID)
+	}
+
+	// Test groups are tracked independently
+	records, err = store.Pending(ctx, "shipping", "order.placed")
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("Pending() for other group = %d records, want 0", len(records))
+	}
+}
+
+func TestGroupStore_RedeliversAfterRestart(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	store := NewGroupStore(client, DefaultConfig())
+
+	// First process: the handler fails, leaving the event unacknowledged
+	first := mediator.NewMediator(mediator.WithGroupStore(store))
+	first.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		return errors.New("billing unavailable")
+	}, mediator.WithGroup("billing"))
+	if err := first.Publish(ctx, mediator.Event{Name: "order.placed", Payload: map[string]interface{}{"total": 10.0}}); err == nil {
+		t.Fatal("Publish() expected handler error")
+	}
+
+	// Second process: the same group recovers the pending event
+	var received []mediator.Event
+	second := mediator.NewMediator(mediator.WithGroupStore(NewGroupStore(client, DefaultConfig())))
+	second.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		received = append(received, event)
+		return nil
+	}, mediator.WithGroup("billing"))
+
+	n, err := second.Recover(ctx)
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if n != 1 || len(received) != 1 {
+		t.Fatalf("Recover() = %d, handler received %d events, want 1", n, len(received))
+	}
+
+	// Test acknowledged events are not redelivered again
+	if n, err := second.Recover(ctx); err != nil || n != 0 {
+		t.Errorf("Recover() = %d, %v, want 0, nil", n, err)
+	}
+}
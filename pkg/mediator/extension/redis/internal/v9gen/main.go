@@ -0,0 +1,75 @@
+// Command v9gen generates the go-redis v9 flavour of the Redis extension from
+// its go-redis v8 sources, so both share one implementation.
+//
+// Usage, from the v9 package directory:
+//
+//	go run ../internal/v9gen
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	v8Import = `"github.com/go-redis/redis/v8"`
+	v9Import = `"github.com/redis/go-redis/v9"`
+)
+
+func main() {
+	if err := generate("..", "."); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate rewrites the Go files of src importing go-redis v8 into dst, and
+// removes the files it generated before whose source is gone
+func generate(src, dst string) error {
+	stale, err := filepath.Glob(filepath.Join(dst, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(data, []byte("// Code generated by v9gen")) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	sources, err := filepath.Glob(filepath.Join(src, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range sources {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(data, []byte(v8Import)) && !strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		name := filepath.Base(path)
+		header := fmt.Sprintf("// Code generated by v9gen from %s. DO NOT EDIT.\n\n", name)
+		data = bytes.ReplaceAll(data, []byte(v8Import), []byte(v9Import))
+		// Format to sort the new import among the others
+		data, err = format.Source(append([]byte(header), data...))
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dst, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
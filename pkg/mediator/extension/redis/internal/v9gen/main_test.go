@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	if err := generate("../..", dir); err != nil {
+		t.Fatalf("generate() error = %v", err)
+	}
+
+	generated, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatalf("Failed to list generated files: %v", err)
+	}
+	for _, path := range generated {
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read generated file: %v", err)
+		}
+		got, err := os.ReadFile(filepath.Join("../../v9", filepath.Base(path)))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("v9/%s is out of date; run go generate in the v9 package", filepath.Base(path))
+		}
+	}
+}
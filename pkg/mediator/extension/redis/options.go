@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Option sets a field of the Config NewConfig builds
+type Option func(*Config)
+
+// WithPrefix sets the prefix of the store's keys
+func WithPrefix(prefix string) Option {
+	return func(c *Config) {
+		c.Prefix = prefix
+	}
+}
+
+// WithEventTTL expires events after ttl; 0 keeps them until trimmed
+func WithEventTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.EventTTL = ttl
+	}
+}
+
+// WithMaxEventsPerType caps the timeline of each event name; 0 means no cap
+func WithMaxEventsPerType(n int64) Option {
+	return func(c *Config) {
+		c.MaxEventsPerType = n
+	}
+}
+
+// WithRetention sets the retention policies EnforceRetention applies
+func WithRetention(retention mediator.Retention) Option {
+	return func(c *Config) {
+		c.Retention = retention
+	}
+}
+
+// WithSerializer encodes event payloads with serializer
+func WithSerializer(serializer mediator.Serializer) Option {
+	return func(c *Config) {
+		c.Serializer = serializer
+	}
+}
+
+// WithLogger reports failures of the store's best-effort work
+func WithLogger(logger mediator.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithHashTags keeps the keys of an event name in one Redis Cluster slot
+func WithHashTags() Option {
+	return func(c *Config) {
+		c.HashTags = true
+	}
+}
+
+// NewConfig builds a Config from DefaultConfig and opts, and validates it
+func NewConfig(opts ...Option) (Config, error) {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// New creates a Redis event store configured by opts, failing on an invalid
+// configuration instead of falling back to defaults
+func New(client redis.UniversalClient, opts ...Option) (*EventStore, error) {
+	config, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventStore(client, config), nil
+}
+
+// Validate reports every setting that is invalid or conflicts with another
+func (c Config) Validate() error {
+	var errs []error
+	if c.Prefix == "" {
+		errs = append(errs, errors.New("empty Prefix"))
+	}
+	if c.HashTags && strings.ContainsAny(c.Prefix, "{}") {
+		errs = append(errs, fmt.Errorf("braces in Prefix %q break the hash tags of HashTags", c.Prefix))
+	}
+	if c.EventTTL < 0 {
+		errs = append(errs, fmt.Errorf("negative EventTTL %s", c.EventTTL))
+	} else if c.EventTTL > 0 && c.EventTTL < time.Millisecond {
+		errs = append(errs, fmt.Errorf("EventTTL %s is below the 1ms resolution of Redis expiry", c.EventTTL))
+	}
+	if c.MaxEventsPerType < 0 {
+		errs = append(errs, fmt.Errorf("negative MaxEventsPerType %d", c.MaxEventsPerType))
+	}
+	if err := c.Retention.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid redis store config: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    func(c Config) bool
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: func(c Config) bool { return c.Prefix == DefaultConfig().Prefix && c.EventTTL == 24*time.Hour },
+		},
+		{
+			name: "options",
+			opts: []Option{
+				WithPrefix("orders"),
+				WithEventTTL(time.Hour),
+				WithMaxEventsPerType(50),
+				WithRetention(mediator.Retention{Default: mediator.KeepLast(10)}),
+				WithHashTags(),
+			},
+			want: func(c Config) bool {
+				return c.Prefix == "orders" && c.EventTTL == time.Hour && c.MaxEventsPerType == 50 &&
+					c.Retention.Default.MaxCount == 10 && c.HashTags
+			},
+		},
+		{name: "empty prefix", opts: []Option{WithPrefix("")}, wantErr: "empty Prefix"},
+		{name: "braces with hash tags", opts: []Option{WithPrefix("{app}"), WithHashTags()}, wantErr: "braces in Prefix"},
+		{name: "negative TTL", opts: []Option{WithEventTTL(-time.Second)}, wantErr: "negative EventTTL"},
+		{name: "sub-millisecond TTL", opts: []Option{WithEventTTL(time.Microsecond)}, wantErr: "1ms resolution"},
+		{name: "negative cap", opts: []Option{WithMaxEventsPerType(-1)}, wantErr: "negative MaxEventsPerType"},
+		{name: "negative retention", opts: []Option{WithRetention(mediator.Retention{Default: mediator.KeepLast(-1)})}, wantErr: "negative MaxCount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConfig(tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewConfig() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewConfig() error = %v", err)
+			}
+			if !tt.want(got) {
+				t.Errorf("NewConfig() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateReportsEveryError(t *testing.T) {
+	err := Config{EventTTL: -time.Second, MaxEventsPerType: -1}.Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil")
+	}
+	for _, want := range []string{"empty Prefix", "negative EventTTL", "negative MaxEventsPerType"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	if _, err := New(client, WithMaxEventsPerType(-1)); err == nil {
+		t.Error("New() with an invalid option error = nil")
+	}
+
+	store, err := New(client, WithPrefix("orders"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "order.placed", Payload: "p-1"}); err != nil {
+		t.Fatalf("StoreEvent() error = %v", err)
+	}
+	keys, err := client.Keys(ctx, "orders:*").Result()
+	if err != nil {
+		t.Fatalf("Keys() error = %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("keys = %v, want an event and its timeline under the prefix", keys)
+	}
+}
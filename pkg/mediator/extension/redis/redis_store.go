@@ -2,8 +2,11 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -12,15 +15,35 @@ import (
 
 // EventStore represents a Redis-based event store
 type EventStore struct {
-	client *redis.Client
-	prefix string
+	client     redis.UniversalClient
+	prefix     string
+	hashTags   bool
+	eventTTL   time.Duration
+	maxEvents  int64
+	serializer mediator.Serializer
+	retention  mediator.Retention
+	logger     mediator.Logger
 }
 
 // Config represents Redis event store configuration
 type Config struct {
-	Prefix           string
-	EventTTL         time.Duration
+	Prefix string
+	// EventTTL expires events after this long; 0 keeps them until trimmed
+	EventTTL time.Duration
+	// MaxEventsPerType caps the timeline of each event name, dropping its
+	// oldest events, and is the default read limit; 0 means no cap
 	MaxEventsPerType int64
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Retention decides which events EnforceRetention keeps, on top of EventTTL
+	Retention mediator.Retention
+	// HashTags wraps event names in keys in braces, so the keys of an event
+	// name share a Redis Cluster slot; required on Redis Cluster. Keys written
+	// without hash tags are not read with them.
+	HashTags bool
+	// Logger reports failures of best-effort work, such as pruning expired
+	// keys from timelines; nothing is logged when nil
+	Logger mediator.Logger
 }
 
 // DefaultConfig returns default configuration
@@ -32,128 +55,551 @@ func DefaultConfig() Config {
 	}
 }
 
-// NewEventStore creates a new Redis event store
-func NewEventStore(client *redis.Client, config Config) *EventStore {
+// NewEventStore creates a new Redis event store. client may be a
+// *redis.Client, a *redis.ClusterClient, or a Sentinel-backed failover client.
+func NewEventStore(client redis.UniversalClient, config Config) *EventStore {
 	if config.Prefix == "" {
 		config.Prefix = DefaultConfig().Prefix
 	}
 	return &EventStore{
-		client: client,
-		prefix: config.Prefix,
+		client:     client,
+		prefix:     config.Prefix,
+		hashTags:   config.HashTags,
+		eventTTL:   config.EventTTL,
+		maxEvents:  config.MaxEventsPerType,
+		serializer: config.Serializer,
+		retention:  config.Retention,
+		logger:     config.Logger,
 	}
 }
 
-// StoreEvent stores an event in Redis
+// StoreEvent stores an event in Redis, atomically with its timeline entry
 func (s *EventStore) StoreEvent(ctx context.Context, event mediator.Event) error {
-	// Create event data with metadata
-	timestamp := time.Now().UTC()
-	eventData := map[string]interface{}{
-		"name":      event.Name,
-		"payload":   event.Payload,
-		"timestamp": timestamp,
+	key, record, err := s.encode(event)
+	if err != nil {
+		return err
 	}
+	defer record.Release()
 
-	// Convert to JSON
-	data, err := json.Marshal(eventData)
+	err = storeScript.Run(ctx, s.client, []string{s.timelineKey(event.Name), key}, s.eventTTL.Milliseconds(), s.maxEvents, record.Bytes()).Err()
 	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
+		return fmt.Errorf("failed to store event: %w", err)
 	}
+	return nil
+}
 
-	// Generate key with timestamp for ordering
-	key := fmt.Sprintf("%s:%s:%d", s.prefix, event.Name, timestamp.UnixNano())
+// StoreEvents stores several events in a single pipeline round-trip. The
+// events of each event name are stored atomically.
+func (s *EventStore) StoreEvents(ctx context.Context, events []mediator.Event) error {
+	// Group the keys and records by event name, keeping their order
+	var names []string
+	keys := make(map[string][]string)
+	args := make(map[string][]interface{})
+	records := make([]*mediator.RecordBuffer, 0, len(events))
+	defer func() { releaseRecords(records) }()
+	for _, event := range events {
+		key, record, err := s.encode(event)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+		if _, ok := keys[event.Name]; !ok {
+			names = append(names, event.Name)
+			keys[event.Name] = []string{s.timelineKey(event.Name)}
+			args[event.Name] = []interface{}{s.eventTTL.Milliseconds(), s.maxEvents}
+		}
+		keys[event.Name] = append(keys[event.Name], key)
+		args[event.Name] = append(args[event.Name], record.Bytes())
+	}
 
-	// Store event with expiration
-	err = s.client.Set(ctx, key, data, DefaultConfig().EventTTL).Err()
-	if err != nil {
-		return fmt.Errorf("failed to store event: %w", err)
+	// EVAL rather than EVALSHA, as a pipeline can't fall back when the script isn't cached
+	pipe := s.client.Pipeline()
+	for _, name := range names {
+		storeScript.Eval(ctx, pipe, keys[name], args[name]...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store events: %w", err)
 	}
+	return nil
+}
+
+// readLimit returns the number of events a read with limit returns: limit,
+// or MaxEventsPerType when limit is not positive, or every event without a cap
+func readLimit(limit, maxEvents int64) int64 {
+	if limit > 0 {
+		return limit
+	}
+	if maxEvents > 0 {
+		return maxEvents
+	}
+	return math.MaxInt64
+}
 
-	// Add to time series list
-	listKey := fmt.Sprintf("%s:%s:timeline", s.prefix, event.Name)
-	err = s.client.RPush(ctx, listKey, key).Err()
+// encode returns the key and record of an event; the caller releases the record
+// once it has been written
+func (s *EventStore) encode(event mediator.Event) (string, *mediator.RecordBuffer, error) {
+	// Default the timestamp of events stored outside Publish
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	// Convert to a JSON record, encoding the payload with the configured serializer
+	record, err := mediator.EncodeEventRecordBuffer(s.serializer, event)
 	if err != nil {
-		return fmt.Errorf("failed to push event to list: %w", err)
+		return "", nil, fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	return nil
+	// Generate key with timestamp for ordering; the event ID keeps keys of
+	// events sharing a timestamp apart
+	key := fmt.Sprintf("%s:%d", s.nameKey(event.Name), event.Timestamp.UnixNano())
+	if event.ID != "" {
+		key += ":" + event.ID
+	}
+	return key, record, nil
+}
+
+// releaseRecords returns the buffers of records written to the pool
+func releaseRecords(records []*mediator.RecordBuffer) {
+	for _, record := range records {
+		record.Release()
+	}
+}
+
+// timelineKey returns the key of the list ordering the events of an event name
+func (s *EventStore) timelineKey(eventName string) string {
+	return s.nameKey(eventName) + ":timeline"
+}
+
+// nameKey returns the part of the keys of an event name before their suffix
+func (s *EventStore) nameKey(eventName string) string {
+	return nameKey(s.prefix, eventName, s.hashTags)
 }
 
 // GetEvents retrieves events from Redis by event name
 func (s *EventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
-	if limit <= 0 {
-		limit = DefaultConfig().MaxEventsPerType
+	records, err := s.fetch(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		event, err := mediator.DecodeEventRecord(s.serializer, record.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		events = append(events, event)
 	}
 
+	return events, nil
+}
+
+// ReadEvents retrieves events by event name as typed records
+func (s *EventStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]mediator.StoredEvent, error) {
+	records, err := s.fetch(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(records)
+}
+
+// EachEvent calls fn with the most recent events of an event name, oldest
+// first, like ReadEvents, but loads them readChunk at a time, so large limits
+// don't hold every event in memory. It stops at the first error fn returns.
+func (s *EventStore) EachEvent(ctx context.Context, eventName string, limit int64, fn func(event mediator.StoredEvent) error) error {
+	limit = readLimit(limit, s.maxEvents)
+	listKey := s.timelineKey(eventName)
+
+	length, err := s.client.LLen(ctx, listKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get event count: %w", err)
+	}
+
+	// Expired keys are pruned once the walk is done, so positions stay put
+	var expired []string
+	defer func() { s.prune(ctx, listKey, expired) }()
+
+	start := length - limit
+	if start < 0 {
+		start = 0
+	}
+	for ; start < length; start += readChunk {
+		keys, err := s.client.LRange(ctx, listKey, start, start+readChunk-1).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get event keys: %w", err)
+		}
+		records, missing, err := s.load(ctx, keys)
+		if err != nil {
+			return err
+		}
+		expired = append(expired, missing...)
+
+		events, err := s.decodeStored(records)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetEventsPage returns up to pageSize events of an event name, oldest first.
+// The cursor is the key of the last event of the previous page; if that event
+// has since been removed from the timeline, the next page starts at the oldest event.
+func (s *EventStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]mediator.StoredEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = mediator.DefaultPageSize
+	}
+	listKey := s.timelineKey(eventName)
+
+	var start int64
+	if cursor != "" {
+		pos, err := s.client.LPos(ctx, listKey, cursor, redis.LPosArgs{}).Result()
+		switch {
+		case err == nil:
+			start = pos + 1
+		case err != redis.Nil:
+			return nil, "", fmt.Errorf("failed to find cursor: %w", err)
+		}
+	}
+
+	// Fetch one extra key to learn whether there is a next page
+	keys, err := s.client.LRange(ctx, listKey, start, start+int64(pageSize)).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get event keys: %w", err)
+	}
+
+	next := ""
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		next = keys[pageSize-1]
+	}
+
+	records, _, err := s.load(ctx, keys)
+	if err != nil {
+		return nil, "", err
+	}
+	events, err := s.decodeStored(records)
+	if err != nil {
+		return nil, "", err
+	}
+	return events, next, nil
+}
+
+// QueryEvents retrieves the most recent events of an event name matching query.
+// The time range is applied to the timestamps in the timeline keys, so only
+// events within it are loaded; the other filters are applied after loading.
+func (s *EventStore) QueryEvents(ctx context.Context, eventName string, query mediator.EventQuery, limit int64) ([]mediator.StoredEvent, error) {
+	limit = readLimit(limit, s.maxEvents)
+
+	keys, err := s.client.LRange(ctx, s.timelineKey(eventName), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event keys: %w", err)
+	}
+
+	// Walk the timeline from the newest event, loading a chunk of keys at a time
+	prefix := s.nameKey(eventName) + ":"
+	events := make([]mediator.StoredEvent, 0)
+	for end := len(keys); end > 0 && int64(len(events)) < limit; {
+		var chunk []string
+		for ; end > 0 && int64(len(chunk)) < limit; end-- {
+			key := keys[end-1]
+			if timestamp, ok := keyTimestamp(key, prefix); ok {
+				if !query.Until.IsZero() && !timestamp.Before(query.Until) {
+					continue
+				}
+				if !query.Since.IsZero() && timestamp.Before(query.Since) {
+					// Older keys are all out of range
+					end = 0
+					break
+				}
+			}
+			chunk = append(chunk, key)
+		}
+
+		records, _, err := s.load(ctx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := s.decodeStored(records)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range decoded {
+			if query.Matches(event) && int64(len(events)) < limit {
+				events = append(events, event)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// keyTimestamp parses the publish time encoded in an event key
+func keyTimestamp(key, prefix string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(key, prefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	nanos, _, _ := strings.Cut(rest, ":")
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n).UTC(), true
+}
+
+// fetch returns the records of the most recent events of an event name
+func (s *EventStore) fetch(ctx context.Context, eventName string, limit int64) ([]record, error) {
+	limit = readLimit(limit, s.maxEvents)
+
 	// Get event keys from timeline
-	listKey := fmt.Sprintf("%s:%s:timeline", s.prefix, eventName)
+	listKey := s.timelineKey(eventName)
 	// Get most recent events
 	keys, err := s.client.LRange(ctx, listKey, -limit, -1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event keys: %w", err)
 	}
 
-	if len(keys) == 0 {
-		return []map[string]interface{}{}, nil
+	records, expired, err := s.load(ctx, keys)
+	if err != nil {
+		return nil, err
 	}
+	s.prune(ctx, listKey, expired)
+	return records, nil
+}
 
-	// Get events data
-	pipe := s.client.Pipeline()
-	cmds := make([]*redis.StringCmd, len(keys))
-	for i, key := range keys {
-		cmds[i] = pipe.Get(ctx, key)
+// record is an encoded event and the key it is stored under
+type record struct {
+	key  string
+	data []byte
+}
+
+// readChunk is the number of events fetched by a single MGET, and loaded at
+// a time by EachEvent, bounding the size of each command and reply
+const readChunk = 500
+
+// load returns the records stored under keys, in order, in a single round
+// trip, and the keys of expired events, which are skipped. Keys are fetched
+// with MGET, a chunk at a time, unless they may live in different slots of a
+// Redis Cluster, where each is fetched with GET.
+func (s *EventStore) load(ctx context.Context, keys []string) ([]record, []string, error) {
+	if len(keys) == 0 {
+		return nil, nil, nil
 	}
 
-	_, err = pipe.Exec(ctx)
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("failed to get events: %w", err)
+	values, err := s.get(ctx, keys)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Process results
-	events := make([]map[string]interface{}, 0, len(cmds))
-	for _, cmd := range cmds {
-		data, err := cmd.Result()
-		if err == redis.Nil {
+	records := make([]record, 0, len(keys))
+	var expired []string
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			expired = append(expired, keys[i])
 			continue
 		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to get event data: %w", err)
+		records = append(records, record{key: keys[i], data: []byte(data)})
+	}
+	return records, expired, nil
+}
+
+// get returns the values of keys, nil for those that don't exist
+func (s *EventStore) get(ctx context.Context, keys []string) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(keys))
+	pipe := s.client.Pipeline()
+
+	// Without hash tags the keys of an event name are spread across slots,
+	// which a single MGET can't span on a cluster
+	if _, cluster := s.client.(*redis.ClusterClient); cluster && !s.hashTags {
+		cmds := make([]*redis.StringCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get events: %w", err)
+		}
+		for _, cmd := range cmds {
+			data, err := cmd.Result()
+			switch {
+			case err == redis.Nil:
+				values = append(values, nil)
+			case err != nil:
+				return nil, fmt.Errorf("failed to get event data: %w", err)
+			default:
+				values = append(values, data)
+			}
+		}
+		return values, nil
+	}
+
+	var cmds []*redis.SliceCmd
+	for start := 0; start < len(keys); start += readChunk {
+		end := start + readChunk
+		if end > len(keys) {
+			end = len(keys)
 		}
+		cmds = append(cmds, pipe.MGet(ctx, keys[start:end]...))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	for _, cmd := range cmds {
+		values = append(values, cmd.Val()...)
+	}
+	return values, nil
+}
+
+// prune removes the keys of expired events from a timeline, so reads and
+// counts stop visiting them. It is best effort: keys left behind are skipped
+// and pruned by a later read.
+func (s *EventStore) prune(ctx context.Context, listKey string, expired []string) {
+	if len(expired) == 0 {
+		return
+	}
+	pipe := s.client.Pipeline()
+	for _, key := range expired {
+		pipe.LRem(ctx, listKey, 1, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && s.logger != nil {
+		s.logger.Printf("redis store: failed to prune %d expired keys from %s: %v", len(expired), listKey, err)
+	}
+}
 
-		var event map[string]interface{}
-		if err := json.Unmarshal([]byte(data), &event); err != nil {
+// decodeStored decodes records into typed events whose offset is their key
+func (s *EventStore) decodeStored(records []record) ([]mediator.StoredEvent, error) {
+	events := make([]mediator.StoredEvent, 0, len(records))
+	for _, record := range records {
+		event, err := mediator.DecodeStoredEvent(s.serializer, record.data)
+		if err != nil {
 			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
 		}
+		event.Offset = record.key
 		events = append(events, event)
 	}
-
 	return events, nil
 }
 
-// ClearEvents removes all events for a given event name
-func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
-	// Get event keys from timeline
-	listKey := fmt.Sprintf("%s:%s:timeline", s.prefix, eventName)
-	keys, err := s.client.LRange(ctx, listKey, 0, -1).Result()
+// GetStreams returns every event name with a timeline and their counts and
+// first and last timestamps, ordered by name. Counts include expired events
+// still in the timeline.
+func (s *EventStore) GetStreams(ctx context.Context) ([]mediator.StreamInfo, error) {
+	timelines, err := scanKeys(ctx, s.client, s.timelineKey("*"))
 	if err != nil {
-		return fmt.Errorf("failed to get event keys: %w", err)
+		return nil, fmt.Errorf("failed to scan timelines: %w", err)
 	}
 
-	if len(keys) == 0 {
-		return nil
+	pipe := s.client.Pipeline()
+	lens := make([]*redis.IntCmd, len(timelines))
+	firsts := make([]*redis.StringCmd, len(timelines))
+	lasts := make([]*redis.StringCmd, len(timelines))
+	for i, key := range timelines {
+		lens[i] = pipe.LLen(ctx, key)
+		firsts[i] = pipe.LIndex(ctx, key, 0)
+		lasts[i] = pipe.LIndex(ctx, key, -1)
+	}
+	if len(timelines) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to read timelines: %w", err)
+		}
 	}
 
-	// Delete all events and timeline
-	pipe := s.client.Pipeline()
-	for _, key := range keys {
-		pipe.Del(ctx, key)
+	streams := make([]mediator.StreamInfo, 0, len(timelines))
+	for i, key := range timelines {
+		count := lens[i].Val()
+		if count == 0 {
+			continue
+		}
+		name := keyName(key, s.prefix, ":timeline")
+		prefix := s.nameKey(name) + ":"
+		stream := mediator.StreamInfo{Name: name, Count: count}
+		stream.FirstEvent, _ = keyTimestamp(firsts[i].Val(), prefix)
+		stream.LastEvent, _ = keyTimestamp(lasts[i].Val(), prefix)
+		streams = append(streams, stream)
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams, nil
+}
+
+// DeleteBefore removes the events of an event name stored before t. The
+// timeline is in publish order, so its oldest events are removed up to the
+// first one stored at or after t.
+func (s *EventStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	keys, err := s.client.LRange(ctx, s.timelineKey(eventName), 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get event keys: %w", err)
+	}
+
+	prefix := s.nameKey(eventName) + ":"
+	n := 0
+	for ; n < len(keys); n++ {
+		if timestamp, ok := keyTimestamp(keys[n], prefix); !ok || !timestamp.Before(t) {
+			break
+		}
 	}
-	pipe.Del(ctx, listKey)
+	return s.removeOldest(ctx, eventName, n)
+}
 
-	_, err = pipe.Exec(ctx)
+// EnforceRetention applies the retention policy of every event name
+func (s *EventStore) EnforceRetention(ctx context.Context) error {
+	streams, err := s.GetStreams(ctx)
 	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		policy := s.retention.Policy(stream.Name)
+		if policy.MaxAge > 0 {
+			if err := s.DeleteBefore(ctx, stream.Name, time.Now().Add(-policy.MaxAge)); err != nil {
+				return err
+			}
+		}
+		if policy.MaxCount > 0 {
+			if err := s.trimEvents(ctx, stream.Name, policy.MaxCount); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// trimEvents removes all but the most recent maxCount events of an event name
+func (s *EventStore) trimEvents(ctx context.Context, eventName string, maxCount int64) error {
+	if err := capScript.Run(ctx, s.client, []string{s.timelineKey(eventName)}, maxCount).Err(); err != nil {
+		return fmt.Errorf("failed to trim events: %w", err)
+	}
+	return nil
+}
+
+// removeOldest deletes the n oldest events of the timeline of an event name
+func (s *EventStore) removeOldest(ctx context.Context, eventName string, n int) error {
+	if n == 0 {
+		return nil
+	}
+	if err := dropScript.Run(ctx, s.client, []string{s.timelineKey(eventName)}, n).Err(); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return nil
+}
+
+// ClearEvents atomically removes all events for a given event name
+func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
+	if err := clearScript.Run(ctx, s.client, []string{s.timelineKey(eventName)}).Err(); err != nil {
 		return fmt.Errorf("failed to clear events: %w", err)
 	}
+	return nil
+}
 
+// HealthCheck pings Redis
+func (s *EventStore) HealthCheck(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
 	return nil
 }
 
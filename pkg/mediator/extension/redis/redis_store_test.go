@@ -1,12 +1,20 @@
 package redis
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"github.com/mandocaesar/mediator/pkg/mediator/storetest"
 )
 
 func setupTestRedis(t *testing.T) (*redis.Client, func()) {
@@ -59,6 +67,38 @@ func TestEventStore(t *testing.T) {
 		}
 	})
 
+	t.Run("store envelope fields", func(t *testing.T) {
+		ctx := context.Background()
+		event := mediator.Event{
+			Name:          "envelope.test",
+			Payload:       map[string]interface{}{"key": "value"},
+			ID:            "evt-1",
+			CorrelationID: "corr-1",
+			CausationID:   "evt-0",
+			Metadata:      map[string]string{"tenant": "acme"},
+		}
+
+		if err := store.StoreEvent(ctx, event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+
+		events, err := store.GetEvents(ctx, "envelope.test", 10)
+		if err != nil {
+			t.Fatalf("Failed to get events: %v", err)
+		}
+		if len(events) != 1 {
+			t.Fatalf("Expected 1 event, got %d", len(events))
+		}
+
+		if events[0]["id"] != "evt-1" || events[0]["correlation_id"] != "corr-1" || events[0]["causation_id"] != "evt-0" {
+			t.Errorf("Envelope fields not stored: %v", events[0])
+		}
+		metadata, _ := events[0]["metadata"].(map[string]interface{})
+		if metadata["tenant"] != "acme" {
+			t.Errorf("Expected metadata tenant 'acme', got %v", events[0]["metadata"])
+		}
+	})
+
 	t.Run("clear events", func(t *testing.T) {
 		ctx := context.Background()
 		event := mediator.Event{
@@ -89,3 +129,625 @@ func TestEventStore(t *testing.T) {
 		}
 	})
 }
+
+func TestEventStore_StoreEvents(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewEventStore(client, DefaultConfig())
+	ctx := context.Background()
+
+	events := []mediator.Event{
+		{Name: "batch.test", ID: "evt-1", Payload: "a"},
+		{Name: "batch.test", ID: "evt-2", Payload: "b"},
+		{Name: "other.test", ID: "evt-3", Payload: "c"},
+	}
+	if err := store.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	stored, err := store.GetEvents(ctx, "batch.test", 10)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(stored) != 2 || stored[0]["id"] != "evt-1" || stored[1]["id"] != "evt-2" {
+		t.Errorf("Expected evt-1 and evt-2 in order, got %v", stored)
+	}
+
+	other, err := store.GetEvents(ctx, "other.test", 10)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(other) != 1 {
+		t.Errorf("Expected 1 other event, got %d", len(other))
+	}
+}
+
+type storedProduct struct {
+	ID   string
+	Name string
+}
+
+func TestEventStore_Serializer(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	gob.Register(storedProduct{})
+	config := DefaultConfig()
+	config.Serializer = mediator.GobSerializer{}
+	store := NewEventStore(client, config)
+
+	ctx := context.Background()
+	want := storedProduct{ID: "p-1", Name: "Coffee"}
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "product.created", Payload: want}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	events, err := store.GetEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if got, ok := events[0]["payload"].(storedProduct); !ok || got != want {
+		t.Errorf("Expected payload %+v, got %#v", want, events[0]["payload"])
+	}
+}
+
+func TestEventStore_ReadEvents(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewEventStore(client, DefaultConfig())
+	var _ mediator.EventStoreV2 = store
+
+	ctx := context.Background()
+	event := mediator.Event{
+		Name:     "product.created",
+		ID:       "evt-1",
+		Payload:  map[string]interface{}{"id": "p-1"},
+		Metadata: map[string]string{"source": "test"},
+	}
+	if err := store.StoreEvent(ctx, event); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	events, err := store.ReadEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	got := events[0]
+	if got.ID != "evt-1" || got.Metadata["source"] != "test" {
+		t.Errorf("Expected envelope of evt-1, got %+v", got)
+	}
+	if string(got.RawPayload) != `{"id":"p-1"}` {
+		t.Errorf("Expected raw payload {\"id\":\"p-1\"}, got %s", got.RawPayload)
+	}
+	if payload, ok := got.Payload.(map[string]interface{}); !ok || payload["id"] != "p-1" {
+		t.Errorf("Expected decoded payload with id p-1, got %#v", got.Payload)
+	}
+}
+
+func TestEventStore_ReadEventsPrunesExpired(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	config := DefaultConfig()
+	config.EventTTL = time.Minute
+	store := NewEventStore(client, config)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := store.StoreEvent(ctx, mediator.Event{Name: "order.placed", ID: fmt.Sprintf("old-%d", i)}); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+	mr.FastForward(2 * time.Minute)
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "order.placed", ID: "new"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	events, err := store.ReadEvents(ctx, "order.placed", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "new" {
+		t.Errorf("Expected only the unexpired event, got %+v", events)
+	}
+	if n, err := client.LLen(ctx, store.timelineKey("order.placed")).Result(); err != nil || n != 1 {
+		t.Errorf("Expected expired events to be pruned from the timeline, got %d keys (%v)", n, err)
+	}
+}
+
+func TestEventStore_EachEvent(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	config := DefaultConfig()
+	config.MaxEventsPerType = 0
+	store := NewEventStore(client, config)
+	ctx := context.Background()
+
+	// More events than a chunk, so they are loaded in several
+	const stored = readChunk*2 + 10
+	events := make([]mediator.Event, stored)
+	for i := range events {
+		events[i] = mediator.Event{Name: "order.placed", ID: fmt.Sprintf("evt-%04d", i)}
+	}
+	if err := store.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		limit int64
+		stop  int
+		want  int
+	}{
+		{name: "all", limit: 0, want: stored},
+		{name: "most recent", limit: readChunk + 5, want: readChunk + 5},
+		{name: "beyond the timeline", limit: stored * 2, want: stored},
+		{name: "stopped", limit: 0, stop: readChunk + 1, want: readChunk + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errStop := fmt.Errorf("stop")
+			var got []string
+			err := store.EachEvent(ctx, "order.placed", tt.limit, func(event mediator.StoredEvent) error {
+				got = append(got, event.ID)
+				if len(got) == tt.stop {
+					return errStop
+				}
+				return nil
+			})
+			if tt.stop > 0 && err != errStop {
+				t.Errorf("Expected EachEvent to return the error of fn, got %v", err)
+			}
+			if tt.stop == 0 && err != nil {
+				t.Fatalf("Failed to iterate events: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Fatalf("Expected %d events, got %d", tt.want, len(got))
+			}
+
+			// Oldest first, ending with the most recent unless stopped
+			first := stored - tt.want
+			if tt.stop > 0 {
+				first = 0
+			}
+			for i, id := range got {
+				if want := fmt.Sprintf("evt-%04d", first+i); id != want {
+					t.Fatalf("Expected event %d to be %s, got %s", i, want, id)
+				}
+			}
+		})
+	}
+}
+
+func TestEventStore_GetEventsPage(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewEventStore(client, DefaultConfig())
+	var _ mediator.PagedEventStore = store
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		event := mediator.Event{Name: "page.test", ID: fmt.Sprintf("evt-%d", i), Payload: float64(i)}
+		if err := store.StoreEvent(ctx, event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	var got []interface{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected pagination to end after 3 pages")
+		}
+		events, next, err := store.GetEventsPage(ctx, "page.test", cursor, 2)
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		for _, event := range events {
+			got = append(got, event.Payload)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("Expected 5 events, got %d", len(got))
+	}
+	for i, payload := range got {
+		if payload != float64(i) {
+			t.Errorf("Expected event %d to have payload %d, got %v", i, i, payload)
+		}
+	}
+
+	// Test a cursor that is no longer in the timeline restarts at the oldest event
+	events, _, err := store.GetEventsPage(ctx, "page.test", "missing", 2)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != "evt-0" {
+		t.Errorf("Expected page from the oldest event, got %+v", events)
+	}
+
+	// Test an event's offset resumes after it
+	events, _, err = store.GetEventsPage(ctx, "page.test", events[1].Offset, 2)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != "evt-2" {
+		t.Errorf("Expected page from evt-2, got %+v", events)
+	}
+}
+
+func TestEventStore_QueryEvents(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewEventStore(client, DefaultConfig())
+	var _ mediator.QueryableEventStore = store
+
+	ctx := context.Background()
+	start := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		event := mediator.Event{
+			Name:          "query.test",
+			ID:            fmt.Sprintf("evt-%d", i),
+			Payload:       float64(i),
+			Timestamp:     start.Add(time.Duration(i) * time.Minute),
+			CorrelationID: fmt.Sprintf("corr-%d", i%2),
+			Metadata:      map[string]string{"tenant": fmt.Sprintf("tenant-%d", i%3)},
+		}
+		if err := store.StoreEvent(ctx, event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query mediator.EventQuery
+		limit int64
+		want  []string
+	}{
+		{"time range", mediator.EventQuery{Since: start.Add(time.Minute), Until: start.Add(4 * time.Minute)}, 10, []string{"evt-3", "evt-2", "evt-1"}},
+		{"correlation", mediator.EventQuery{CorrelationID: "corr-1"}, 10, []string{"evt-5", "evt-3", "evt-1"}},
+		{"metadata", mediator.EventQuery{Metadata: map[string]string{"tenant": "tenant-0"}}, 10, []string{"evt-3", "evt-0"}},
+		{"limit", mediator.EventQuery{CorrelationID: "corr-0"}, 2, []string{"evt-4", "evt-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.QueryEvents(ctx, "query.test", tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Failed to query events: %v", err)
+			}
+			var got []string
+			for _, event := range events {
+				got = append(got, event.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDeliveryGuarantees(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	config := deliverytest.DefaultConfig()
+	config.Backend = "redis"
+	config.Claims = deliverytest.AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) deliverytest.System {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		store := NewEventStore(client, DefaultConfig())
+		dedupe := mediator.NewStoreDedupeStore(store, 1000)
+
+		m := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithEventStore(store), mediator.WithDeduplication(dedupe))
+		return deliverytest.System{
+			Publish: m.Publish,
+			Subscribe: func(eventName string, handler mediator.EventHandler) {
+				m.Subscribe(eventName, handler)
+			},
+			Store:  store,
+			Dedupe: dedupe,
+			Close:  store.Close,
+		}
+	}
+
+	report := deliverytest.Run(t, config)
+	var buf bytes.Buffer
+	report.WriteMarkdown(&buf)
+	t.Logf("conformance report:\n%s", buf.String())
+}
+
+func TestEventStore_StoreSubscribe(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	// Two mediators stand in for two processes sharing the store
+	store := NewEventStore(client, DefaultConfig())
+	publisher := mediator.NewMediator(mediator.WithEventStore(store), mediator.WithDeliveryMode(mediator.StoreOnly))
+	subscriber := mediator.NewMediator(mediator.WithEventStore(store), mediator.WithStorePollInterval(5*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := publisher.Publish(ctx, mediator.Event{Name: "tail.test", ID: "evt-old"}); err != nil {
+		t.Fatalf("Failed to publish event: %v", err)
+	}
+
+	events, err := subscriber.StoreSubscribe(ctx, "tail.test", mediator.LatestOffset)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := publisher.Publish(ctx, mediator.Event{Name: "tail.test", ID: fmt.Sprintf("evt-%d", i)}); err != nil {
+			t.Fatalf("Failed to publish event: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-events:
+			if want := fmt.Sprintf("evt-%d", i); event.ID != want {
+				t.Errorf("Expected %s, got %s", want, event.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for a tailed event")
+		}
+	}
+}
+
+func TestEventStore_GetStreams(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewEventStore(client, DefaultConfig())
+	var _ mediator.CatalogEventStore = store
+
+	ctx := context.Background()
+	start := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	events := []mediator.Event{
+		{Name: "order.placed", ID: "evt-1", Timestamp: start},
+		{Name: "order.placed", ID: "evt-2", Timestamp: start.Add(time.Minute)},
+		{Name: "order", ID: "evt-3", Timestamp: start.Add(2 * time.Minute)},
+	}
+	for _, event := range events {
+		if err := store.StoreEvent(ctx, event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	streams, err := store.GetStreams(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	want := []mediator.StreamInfo{
+		{Name: "order", Count: 1, FirstEvent: start.Add(2 * time.Minute), LastEvent: start.Add(2 * time.Minute)},
+		{Name: "order.placed", Count: 2, FirstEvent: start, LastEvent: start.Add(time.Minute)},
+	}
+	if !reflect.DeepEqual(streams, want) {
+		t.Errorf("Expected streams %+v, got %+v", want, streams)
+	}
+}
+
+func TestEventStore_Retention(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	config := DefaultConfig()
+	config.Retention = mediator.Retention{
+		Default: mediator.KeepLast(2),
+		Events:  map[string]mediator.RetentionPolicy{"audit.logged": mediator.KeepForever()},
+	}
+	store := NewEventStore(client, config)
+	var _ mediator.CompactingEventStore = store
+	var _ mediator.RetentionEnforcer = store
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour)
+	for _, name := range []string{"order.placed", "audit.logged"} {
+		for i := 0; i < 4; i++ {
+			event := mediator.Event{Name: name, ID: fmt.Sprintf("evt-%d", i), Timestamp: start.Add(time.Duration(i) * time.Minute)}
+			if err := store.StoreEvent(ctx, event); err != nil {
+				t.Fatalf("Failed to store event: %v", err)
+			}
+		}
+	}
+
+	ids := func(name string) []string {
+		events, err := store.ReadEvents(ctx, name, 10)
+		if err != nil {
+			t.Fatalf("Failed to read events: %v", err)
+		}
+		var got []string
+		for _, event := range events {
+			got = append(got, event.ID)
+		}
+		return got
+	}
+
+	// Test DeleteBefore removes the events stored before the cutoff
+	if err := store.DeleteBefore(ctx, "audit.logged", start.Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to delete events: %v", err)
+	}
+	if got, want := ids("audit.logged"), []string{"evt-1", "evt-2", "evt-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v after DeleteBefore, got %v", want, got)
+	}
+
+	// Test EnforceRetention applies the default and per-event policies
+	if err := store.EnforceRetention(ctx); err != nil {
+		t.Fatalf("Failed to enforce retention: %v", err)
+	}
+	if got, want := ids("order.placed"), []string{"evt-2", "evt-3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected events %v after retention, got %v", want, got)
+	}
+	if got := ids("audit.logged"); len(got) != 3 {
+		t.Errorf("Expected audit events to be kept, got %v", got)
+	}
+	if n, err := client.Exists(ctx, "mediator:events:order.placed:"+fmt.Sprint(start.UnixNano())+":evt-0").Result(); err != nil || n != 0 {
+		t.Errorf("Expected trimmed event data to be deleted, got %d (%v)", n, err)
+	}
+}
+
+func TestEventStore_Config(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		maxCount int64
+		batch    bool
+		wantIDs  []string
+	}{
+		{"ttl", time.Hour, 0, false, []string{"evt-0", "evt-1", "evt-2", "evt-3"}},
+		{"no ttl", 0, 0, false, []string{"evt-0", "evt-1", "evt-2", "evt-3"}},
+		{"max events", time.Hour, 2, false, []string{"evt-2", "evt-3"}},
+		{"max events batched", time.Hour, 3, true, []string{"evt-1", "evt-2", "evt-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, cleanup := setupTestRedis(t)
+			defer cleanup()
+
+			config := DefaultConfig()
+			config.EventTTL = tt.ttl
+			config.MaxEventsPerType = tt.maxCount
+			store := NewEventStore(client, config)
+			ctx := context.Background()
+
+			events := make([]mediator.Event, 4)
+			for i := range events {
+				events[i] = mediator.Event{Name: "config.test", ID: fmt.Sprintf("evt-%d", i)}
+			}
+			if tt.batch {
+				if err := store.StoreEvents(ctx, events); err != nil {
+					t.Fatalf("Failed to store events: %v", err)
+				}
+			} else {
+				for _, event := range events {
+					if err := store.StoreEvent(ctx, event); err != nil {
+						t.Fatalf("Failed to store event: %v", err)
+					}
+				}
+			}
+
+			// Test the timeline and the event keys are both trimmed
+			stored, err := store.ReadEvents(ctx, "config.test", 0)
+			if err != nil {
+				t.Fatalf("Failed to read events: %v", err)
+			}
+			if got := eventIDs(stored); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("Expected events %v, got %v", tt.wantIDs, got)
+			}
+			keys, err := client.Keys(ctx, "mediator:events:config.test:*").Result()
+			if err != nil {
+				t.Fatalf("Failed to list keys: %v", err)
+			}
+			if len(keys) != len(tt.wantIDs)+1 {
+				t.Errorf("Expected %d event keys and the timeline, got %v", len(tt.wantIDs), keys)
+			}
+
+			ttl, err := client.TTL(ctx, stored[0].Offset).Result()
+			if err != nil {
+				t.Fatalf("Failed to get TTL: %v", err)
+			}
+			if tt.ttl == 0 && ttl != -1 {
+				t.Errorf("Expected no expiry, got TTL %v", ttl)
+			}
+			if tt.ttl > 0 && (ttl <= 0 || ttl > tt.ttl) {
+				t.Errorf("Expected TTL up to %v, got %v", tt.ttl, ttl)
+			}
+		})
+	}
+}
+
+func TestEventStore_HealthCheck(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	stores := map[string]mediator.HealthChecker{
+		"event store":  NewEventStore(client, DefaultConfig()),
+		"stream store": NewStreamStore(client, DefaultConfig()),
+	}
+	for name, store := range stores {
+		if err := store.HealthCheck(ctx); err != nil {
+			t.Errorf("%s: HealthCheck() error = %v", name, err)
+		}
+	}
+
+	mr.Close()
+	for name, store := range stores {
+		if err := store.HealthCheck(ctx); err == nil {
+			t.Errorf("%s: Expected HealthCheck() to fail without Redis", name)
+		}
+	}
+}
+
+func TestEventStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, retention mediator.Retention) mediator.EventStore {
+		client, cleanup := setupTestRedis(t)
+		t.Cleanup(cleanup)
+		config := DefaultConfig()
+		config.Retention = retention
+		return NewEventStore(client, config)
+	})
+}
+
+func BenchmarkEventStore_ReadEvents(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			mr, err := miniredis.Run()
+			if err != nil {
+				b.Fatalf("Failed to start miniredis: %v", err)
+			}
+			defer mr.Close()
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer client.Close()
+
+			config := DefaultConfig()
+			config.MaxEventsPerType = 0
+			store := NewEventStore(client, config)
+			ctx := context.Background()
+			events := make([]mediator.Event, n)
+			for i := range events {
+				events[i] = mediator.Event{Name: "order.placed", ID: fmt.Sprintf("evt-%d", i), Payload: map[string]interface{}{"id": i}}
+			}
+			if err := store.StoreEvents(ctx, events); err != nil {
+				b.Fatalf("Failed to store events: %v", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.ReadEvents(ctx, "order.placed", int64(n)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
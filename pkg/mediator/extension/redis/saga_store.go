@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// saveSagaScript stores the instance ARGV[1..6] in the hash KEYS[1] if it
+// holds version ARGV[2]-1, or none for version 1, and records its deadline
+// ARGV[3] under ARGV[1] in the hash KEYS[2], or removes it when empty.
+// It returns 1 if it stored the instance.
+var saveSagaScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], 'version') or '0')
+if current ~= tonumber(ARGV[2]) - 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'version', ARGV[2], 'deadline', ARGV[3], 'state', ARGV[4], 'content_type', ARGV[5], 'updated_at', ARGV[6])
+if ARGV[3] == '' then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+else
+	redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+end
+return 1
+`)
+
+// SagaStore is a Redis-based mediator.SagaStore keeping each saga instance in
+// a hash and the deadlines of the instances of a saga in another
+type SagaStore struct {
+	client   redis.UniversalClient
+	prefix   string
+	hashTags bool
+}
+
+var _ mediator.SagaStore = (*SagaStore)(nil)
+
+// NewSagaStore creates a Redis saga store using the prefix and hash tags of
+// config
+func NewSagaStore(client redis.UniversalClient, config Config) *SagaStore {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+	return &SagaStore{
+		client:   client,
+		prefix:   config.Prefix,
+		hashTags: config.HashTags,
+	}
+}
+
+// SaveSaga stores a record in place of the previous version of its instance,
+// or returns mediator.ErrSagaConflict
+func (s *SagaStore) SaveSaga(ctx context.Context, record mediator.SagaRecord) error {
+	var deadline string
+	if !record.Deadline.IsZero() {
+		deadline = record.Deadline.UTC().Format(time.RFC3339Nano)
+	}
+	keys := []string{s.instanceKey(record.Saga, record.ID), s.deadlinesKey(record.Saga)}
+	args := []interface{}{
+		record.ID,
+		record.Version,
+		deadline,
+		record.State,
+		record.ContentType,
+		record.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	saved, err := saveSagaScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to save saga %s instance %s: %w", record.Saga, record.ID, err)
+	}
+	if saved == 0 {
+		return fmt.Errorf("failed to save saga %s instance %s version %d: %w", record.Saga, record.ID, record.Version, mediator.ErrSagaConflict)
+	}
+	return nil
+}
+
+// LoadSaga returns the record of an instance, or mediator.ErrSagaNotFound
+func (s *SagaStore) LoadSaga(ctx context.Context, saga, id string) (mediator.SagaRecord, error) {
+	fields, err := s.client.HGetAll(ctx, s.instanceKey(saga, id)).Result()
+	if err != nil {
+		return mediator.SagaRecord{}, fmt.Errorf("failed to load saga %s instance %s: %w", saga, id, err)
+	}
+	if len(fields) == 0 {
+		return mediator.SagaRecord{}, mediator.ErrSagaNotFound
+	}
+
+	version, err := strconv.ParseInt(fields["version"], 10, 64)
+	if err != nil {
+		return mediator.SagaRecord{}, fmt.Errorf("invalid version of saga %s instance %s: %w", saga, id, err)
+	}
+	var deadline time.Time
+	if fields["deadline"] != "" {
+		if deadline, err = time.Parse(time.RFC3339Nano, fields["deadline"]); err != nil {
+			return mediator.SagaRecord{}, fmt.Errorf("invalid deadline of saga %s instance %s: %w", saga, id, err)
+		}
+	}
+	updatedAt, err := time.Parse(time.RFC3339Nano, fields["updated_at"])
+	if err != nil {
+		return mediator.SagaRecord{}, fmt.Errorf("invalid update time of saga %s instance %s: %w", saga, id, err)
+	}
+	return mediator.SagaRecord{
+		Saga:        saga,
+		ID:          id,
+		Version:     version,
+		Deadline:    deadline,
+		State:       []byte(fields["state"]),
+		ContentType: fields["content_type"],
+		UpdatedAt:   updatedAt,
+	}, nil
+}
+
+// SagaDeadlines returns the deadlines of the instances of a saga that have one
+func (s *SagaStore) SagaDeadlines(ctx context.Context, saga string) (map[string]time.Time, error) {
+	fields, err := s.client.HGetAll(ctx, s.deadlinesKey(saga)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deadlines of saga %s: %w", saga, err)
+	}
+
+	deadlines := make(map[string]time.Time, len(fields))
+	for id, value := range fields {
+		deadline, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deadline of saga %s instance %s: %w", saga, id, err)
+		}
+		deadlines[id] = deadline
+	}
+	return deadlines, nil
+}
+
+// instanceKey returns the Redis key of a saga instance. With hash tags it
+// shares the slot of the deadlines of its saga.
+func (s *SagaStore) instanceKey(saga, id string) string {
+	return nameKey(s.prefix+":sagas", saga, s.hashTags) + ":" + id
+}
+
+// deadlinesKey returns the Redis key of the deadlines of a saga
+func (s *SagaStore) deadlinesKey(saga string) string {
+	return nameKey(s.prefix+":saga-deadlines", saga, s.hashTags)
+}
@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestSagaStore(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewSagaStore(client, DefaultConfig())
+	ctx := context.Background()
+	now := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	deadline := now.Add(time.Hour)
+
+	if _, err := store.LoadSaga(ctx, "billing", "o-1"); !errors.Is(err, mediator.ErrSagaNotFound) {
+		t.Fatalf("LoadSaga() error = %v, want ErrSagaNotFound", err)
+	}
+
+	tests := []struct {
+		name      string
+		version   int64
+		wantErr   error
+		want      int64
+		wantState string
+	}{
+		{name: "first", version: 1, want: 1, wantState: "first"},
+		{name: "first again", version: 1, wantErr: mediator.ErrSagaConflict, want: 1, wantState: "first"},
+		{name: "skipped version", version: 3, wantErr: mediator.ErrSagaConflict, want: 1, wantState: "first"},
+		{name: "next", version: 2, want: 2, wantState: "next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := mediator.SagaRecord{Saga: "billing", ID: "o-1", Version: tt.version, Deadline: deadline, State: []byte(tt.name), ContentType: "application/json", UpdatedAt: now}
+			if err := store.SaveSaga(ctx, record); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveSaga() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := store.LoadSaga(ctx, "billing", "o-1")
+			if err != nil {
+				t.Fatalf("LoadSaga() error = %v", err)
+			}
+			if got.Version != tt.want || string(got.State) != tt.wantState || got.ContentType != "application/json" ||
+				!got.Deadline.Equal(deadline) || !got.UpdatedAt.Equal(now) {
+				t.Errorf("LoadSaga() = %+v, want version %d with %q", got, tt.want, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestSagaStore_Deadlines(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewSagaStore(client, DefaultConfig())
+	ctx := context.Background()
+	deadline := time.Date(2025, 5, 11, 14, 0, 0, 0, time.UTC)
+
+	store.SaveSaga(ctx, mediator.SagaRecord{Saga: "billing", ID: "o-1", Version: 1, Deadline: deadline})
+	store.SaveSaga(ctx, mediator.SagaRecord{Saga: "billing", ID: "o-2", Version: 1, Deadline: deadline})
+	store.SaveSaga(ctx, mediator.SagaRecord{Saga: "shipping", ID: "o-3", Version: 1, Deadline: deadline})
+
+	// Test an instance saved without a deadline leaves the listing
+	if err := store.SaveSaga(ctx, mediator.SagaRecord{Saga: "billing", ID: "o-2", Version: 2}); err != nil {
+		t.Fatalf("SaveSaga() error = %v", err)
+	}
+
+	deadlines, err := store.SagaDeadlines(ctx, "billing")
+	if err != nil {
+		t.Fatalf("SagaDeadlines() error = %v", err)
+	}
+	if len(deadlines) != 1 || !deadlines["o-1"].Equal(deadline) {
+		t.Errorf("SagaDeadlines() = %v, want o-1 only", deadlines)
+	}
+}
+
+func TestSagaStore_Keys(t *testing.T) {
+	tests := []struct {
+		name          string
+		hashTags      bool
+		wantInstance  string
+		wantDeadlines string
+	}{
+		{name: "plain", wantInstance: "mediator:events:sagas:billing:o-1", wantDeadlines: "mediator:events:saga-deadlines:billing"},
+		{name: "hash tags", hashTags: true, wantInstance: "mediator:events:sagas:{billing}:o-1", wantDeadlines: "mediator:events:saga-deadlines:{billing}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.HashTags = tt.hashTags
+			store := NewSagaStore(nil, config)
+			if got := store.instanceKey("billing", "o-1"); got != tt.wantInstance {
+				t.Errorf("instanceKey() = %q, want %q", got, tt.wantInstance)
+			}
+			if got := store.deadlinesKey("billing"); got != tt.wantDeadlines {
+				t.Errorf("deadlinesKey() = %q, want %q", got, tt.wantDeadlines)
+			}
+		})
+	}
+}
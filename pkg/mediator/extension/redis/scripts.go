@@ -0,0 +1,63 @@
+package redis
+
+import "github.com/go-redis/redis/v8"
+
+// The scripts below run atomically on the server, so a crash or a concurrent
+// writer never sees an event key without its timeline entry or the reverse.
+// They delete event keys read from the timeline rather than passed in KEYS;
+// on Redis Cluster, HashTags keeps those keys in the timeline's slot.
+
+// dropOldest is the Lua fragment deleting the drop oldest events of the
+// timeline KEYS[1], in chunks to stay within unpack's limit
+const dropOldest = `
+local function dropOldest(drop)
+	if drop <= 0 then
+		return 0
+	end
+	local keys = redis.call('LRANGE', KEYS[1], 0, drop - 1)
+	for i = 1, #keys, 1000 do
+		redis.call('DEL', unpack(keys, i, math.min(i + 999, #keys)))
+	end
+	redis.call('LTRIM', KEYS[1], #keys, -1)
+	return #keys
+end
+`
+
+// storeScript stores the events KEYS[2..] with the records ARGV[3..], expiring
+// after ARGV[1] milliseconds unless 0, appends them to the timeline KEYS[1] and
+// caps it at ARGV[2] events unless 0. It returns the length of the timeline.
+var storeScript = redis.NewScript(dropOldest + `
+local ttl = tonumber(ARGV[1])
+for i = 2, #KEYS do
+	-- Push first: scripts don't roll back, and only RPUSH can fail
+	redis.call('RPUSH', KEYS[1], KEYS[i])
+	if ttl > 0 then
+		redis.call('SET', KEYS[i], ARGV[i + 1], 'PX', ttl)
+	else
+		redis.call('SET', KEYS[i], ARGV[i + 1])
+	end
+end
+local length = redis.call('LLEN', KEYS[1])
+local max = tonumber(ARGV[2])
+if max > 0 and length > max then
+	length = length - dropOldest(length - max)
+end
+return length
+`)
+
+// dropScript deletes the ARGV[1] oldest events of the timeline KEYS[1]
+var dropScript = redis.NewScript(dropOldest + `
+return dropOldest(tonumber(ARGV[1]))
+`)
+
+// capScript deletes all but the ARGV[1] most recent events of the timeline KEYS[1]
+var capScript = redis.NewScript(dropOldest + `
+return dropOldest(redis.call('LLEN', KEYS[1]) - tonumber(ARGV[1]))
+`)
+
+// clearScript deletes the timeline KEYS[1] and all of its events
+var clearScript = redis.NewScript(dropOldest + `
+local dropped = dropOldest(redis.call('LLEN', KEYS[1]))
+redis.call('DEL', KEYS[1])
+return dropped
+`)
@@ -0,0 +1,88 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestEventStore_AtomicWrites(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	config := DefaultConfig()
+	config.MaxEventsPerType = 5
+	store := NewEventStore(client, config)
+	ctx := context.Background()
+
+	// Test concurrent writers never leave the timeline and event keys apart
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			event := mediator.Event{Name: "atomic.test", ID: fmt.Sprintf("evt-%d", i)}
+			if err := store.StoreEvent(ctx, event); err != nil {
+				t.Errorf("Failed to store event: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	timeline, err := client.LRange(ctx, store.timelineKey("atomic.test"), 0, -1).Result()
+	if err != nil {
+		t.Fatalf("Failed to read timeline: %v", err)
+	}
+	keys, err := client.Keys(ctx, "mediator:events:atomic.test:*").Result()
+	if err != nil {
+		t.Fatalf("Failed to list keys: %v", err)
+	}
+	if len(timeline) != 5 || len(keys) != 6 {
+		t.Errorf("Expected 5 timeline entries and 5 event keys, got %d and %v", len(timeline), keys)
+	}
+
+	if err := store.ClearEvents(ctx, "atomic.test"); err != nil {
+		t.Fatalf("Failed to clear events: %v", err)
+	}
+	if keys, _ := client.Keys(ctx, "mediator:events:atomic.test:*").Result(); len(keys) != 0 {
+		t.Errorf("Expected no keys after clear, got %v", keys)
+	}
+}
+
+func TestEventStore_FailedWrite(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewEventStore(client, DefaultConfig())
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		store func() error
+	}{
+		{"single", func() error {
+			return store.StoreEvent(ctx, mediator.Event{Name: "broken", ID: "evt-1"})
+		}},
+		{"batch", func() error {
+			return store.StoreEvents(ctx, []mediator.Event{{Name: "broken", ID: "evt-1"}, {Name: "broken", ID: "evt-2"}})
+		}},
+	}
+
+	// A timeline of the wrong type makes RPUSH fail
+	if err := client.Set(ctx, store.timelineKey("broken"), "not a list", 0).Err(); err != nil {
+		t.Fatalf("Failed to set key: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.store(); err == nil {
+				t.Fatal("Expected the write to fail")
+			}
+			if keys, _ := client.Keys(ctx, "mediator:events:broken:*").Result(); len(keys) != 1 {
+				t.Errorf("Expected no event key written, got %v", keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// saveSnapshotScript stores the snapshot ARGV[1..4] in the hash KEYS[1]
+// unless it holds the same or a later version, and returns 1 if it did
+var saveSnapshotScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'version')
+if current and tonumber(current) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'version', ARGV[1], 'state', ARGV[2], 'content_type', ARGV[3], 'timestamp', ARGV[4])
+return 1
+`)
+
+// SnapshotStore is a Redis-based mediator.SnapshotStore keeping the latest
+// snapshot of each stream in a hash
+type SnapshotStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var _ mediator.SnapshotStore = (*SnapshotStore)(nil)
+
+// NewSnapshotStore creates a Redis snapshot store using the prefix of config
+func NewSnapshotStore(client redis.UniversalClient, config Config) *SnapshotStore {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+	return &SnapshotStore{
+		client: client,
+		prefix: config.Prefix,
+	}
+}
+
+// SaveSnapshot stores a snapshot unless its stream has a snapshot of the same
+// or a later version
+func (s *SnapshotStore) SaveSnapshot(ctx context.Context, snapshot mediator.Snapshot) error {
+	args := []interface{}{
+		snapshot.Version,
+		snapshot.State,
+		snapshot.ContentType,
+		snapshot.Timestamp.UTC().Format(time.RFC3339Nano),
+	}
+	if err := saveSnapshotScript.Run(ctx, s.client, []string{s.key(snapshot.StreamID)}, args...).Err(); err != nil {
+		return fmt.Errorf("failed to save snapshot of stream %s: %w", snapshot.StreamID, err)
+	}
+	return nil
+}
+
+// LoadSnapshot returns the latest snapshot of a stream, or mediator.ErrSnapshotNotFound
+func (s *SnapshotStore) LoadSnapshot(ctx context.Context, streamID string) (mediator.Snapshot, error) {
+	fields, err := s.client.HGetAll(ctx, s.key(streamID)).Result()
+	if err != nil {
+		return mediator.Snapshot{}, fmt.Errorf("failed to load snapshot of stream %s: %w", streamID, err)
+	}
+	if len(fields) == 0 {
+		return mediator.Snapshot{}, mediator.ErrSnapshotNotFound
+	}
+
+	version, err := strconv.ParseInt(fields["version"], 10, 64)
+	if err != nil {
+		return mediator.Snapshot{}, fmt.Errorf("invalid version of snapshot of stream %s: %w", streamID, err)
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, fields["timestamp"])
+	if err != nil {
+		return mediator.Snapshot{}, fmt.Errorf("invalid timestamp of snapshot of stream %s: %w", streamID, err)
+	}
+	if _, ok := fields["state"]; !ok {
+		return mediator.Snapshot{}, fmt.Errorf("snapshot of stream %s has no state", streamID)
+	}
+	return mediator.Snapshot{
+		StreamID:    streamID,
+		Version:     version,
+		State:       []byte(fields["state"]),
+		ContentType: fields["content_type"],
+		Timestamp:   timestamp,
+	}, nil
+}
+
+// key returns the Redis key of the snapshot of a stream
+func (s *SnapshotStore) key(streamID string) string {
+	return fmt.Sprintf("%s:snapshots:%s", s.prefix, streamID)
+}
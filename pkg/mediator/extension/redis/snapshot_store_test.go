@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewSnapshotStore(client, DefaultConfig())
+	ctx := context.Background()
+	now := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+
+	if _, err := store.LoadSnapshot(ctx, "product-p-1"); !errors.Is(err, mediator.ErrSnapshotNotFound) {
+		t.Fatalf("LoadSnapshot() error = %v, want ErrSnapshotNotFound", err)
+	}
+
+	tests := []struct {
+		name      string
+		version   int64
+		want      int64
+		wantState string
+	}{
+		{name: "first", version: 100, want: 100, wantState: "first"},
+		{name: "later", version: 200, want: 200, wantState: "later"},
+		{name: "older", version: 150, want: 200, wantState: "later"},
+		{name: "same", version: 200, want: 200, wantState: "later"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := mediator.Snapshot{StreamID: "product-p-1", Version: tt.version, State: []byte(tt.name), ContentType: "application/json", Timestamp: now}
+			if err := store.SaveSnapshot(ctx, snapshot); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+
+			got, err := store.LoadSnapshot(ctx, "product-p-1")
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+			if got.Version != tt.want || string(got.State) != tt.wantState || got.ContentType != "application/json" || !got.Timestamp.Equal(now) {
+				t.Errorf("LoadSnapshot() = %+v, want version %d with %q", got, tt.want, tt.wantState)
+			}
+		})
+	}
+}
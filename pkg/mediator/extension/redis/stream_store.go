@@ -0,0 +1,522 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// tailBlock is how long TailEvents blocks on XREAD before checking whether
+// its context was cancelled
+const tailBlock = time.Second
+
+// StreamStore is a Redis event store keeping the events of each event name in
+// a Redis Stream. Entry ids order events and serve as offsets; consumer groups
+// share out events with ReadGroup and redeliver unacknowledged ones with
+// ClaimPending.
+type StreamStore struct {
+	client     redis.UniversalClient
+	prefix     string
+	hashTags   bool
+	maxLen     int64
+	serializer mediator.Serializer
+	retention  mediator.Retention
+
+	// groups are the consumer groups this store has created
+	groupsMu sync.Mutex
+	groups   map[string]bool
+}
+
+// NewStreamStore creates a Redis Streams event store. Streams are capped at
+// about MaxEventsPerType entries; EventTTL does not apply, as stream entries
+// don't expire. Each stream is a single key, so it works on Redis Cluster
+// with or without HashTags.
+func NewStreamStore(client redis.UniversalClient, config Config) *StreamStore {
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig().Prefix
+	}
+	return &StreamStore{
+		client:     client,
+		prefix:     config.Prefix,
+		hashTags:   config.HashTags,
+		maxLen:     config.MaxEventsPerType,
+		serializer: config.Serializer,
+		retention:  config.Retention,
+		groups:     make(map[string]bool),
+	}
+}
+
+// streamKey returns the key of the stream of an event name
+func (s *StreamStore) streamKey(eventName string) string {
+	return nameKey(s.prefix, eventName, s.hashTags) + ":stream"
+}
+
+// addArgs returns the XADD arguments of an event and its record, which the
+// caller releases once it has been written
+func (s *StreamStore) addArgs(event mediator.Event) (*redis.XAddArgs, *mediator.RecordBuffer, error) {
+	// Default the timestamp of events stored outside Publish
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	record, err := mediator.EncodeEventRecordBuffer(s.serializer, event)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	// Trimming approximately lets Redis drop whole nodes of the stream
+	return &redis.XAddArgs{
+		Stream: s.streamKey(event.Name),
+		MaxLen: s.maxLen,
+		Approx: s.maxLen > 0,
+		Values: map[string]interface{}{"data": record.Bytes()},
+	}, record, nil
+}
+
+// StoreEvent appends an event to the stream of its name
+func (s *StreamStore) StoreEvent(ctx context.Context, event mediator.Event) error {
+	args, record, err := s.addArgs(event)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+	if err := s.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to store event: %w", err)
+	}
+	return nil
+}
+
+// StoreEvents appends several events in a single pipeline round-trip
+func (s *StreamStore) StoreEvents(ctx context.Context, events []mediator.Event) error {
+	pipe := s.client.Pipeline()
+	records := make([]*mediator.RecordBuffer, 0, len(events))
+	defer func() { releaseRecords(records) }()
+	for _, event := range events {
+		args, record, err := s.addArgs(event)
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+		pipe.XAdd(ctx, args)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store events: %w", err)
+	}
+	return nil
+}
+
+// GetEvents retrieves the most recent events of an event name
+func (s *StreamStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	messages, err := s.fetch(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		event, err := mediator.DecodeEventRecord(s.serializer, messageData(message))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ReadEvents retrieves the most recent events of an event name as typed records
+func (s *StreamStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]mediator.StoredEvent, error) {
+	messages, err := s.fetch(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(messages)
+}
+
+// fetch returns the most recent entries of the stream of an event name, oldest first
+func (s *StreamStore) fetch(ctx context.Context, eventName string, limit int64) ([]redis.XMessage, error) {
+	limit = readLimit(limit, s.maxLen)
+
+	messages, err := s.client.XRevRangeN(ctx, s.streamKey(eventName), "+", "-", limit).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// GetEventsPage returns up to pageSize events of an event name stored after
+// the entry id cursor, oldest first
+func (s *StreamStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]mediator.StoredEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = mediator.DefaultPageSize
+	}
+	start := "-"
+	if cursor != "" {
+		start = "(" + cursor
+	}
+
+	// Fetch one extra entry to learn whether there is a next page
+	messages, err := s.client.XRangeN(ctx, s.streamKey(eventName), start, "+", int64(pageSize)+1).Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	next := ""
+	if len(messages) > pageSize {
+		messages = messages[:pageSize]
+		next = messages[pageSize-1].ID
+	}
+
+	events, err := s.decodeStored(messages)
+	if err != nil {
+		return nil, "", err
+	}
+	return events, next, nil
+}
+
+// QueryEvents retrieves the most recent events of an event name matching
+// query, newest first, reading the stream backwards a chunk at a time
+func (s *StreamStore) QueryEvents(ctx context.Context, eventName string, query mediator.EventQuery, limit int64) ([]mediator.StoredEvent, error) {
+	limit = readLimit(limit, s.maxLen)
+
+	events := make([]mediator.StoredEvent, 0)
+	end := "+"
+	for int64(len(events)) < limit {
+		messages, err := s.client.XRevRangeN(ctx, s.streamKey(eventName), end, "-", limit).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+		decoded, err := s.decodeStored(messages)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range decoded {
+			if query.Matches(event) && int64(len(events)) < limit {
+				events = append(events, event)
+			}
+		}
+		if int64(len(messages)) < limit {
+			break
+		}
+		end = "(" + messages[len(messages)-1].ID
+	}
+
+	return events, nil
+}
+
+// TailEvents streams the events of an event name appended after fromOffset
+// with blocking XREAD calls, until ctx is cancelled
+func (s *StreamStore) TailEvents(ctx context.Context, eventName, fromOffset string) (<-chan mediator.StoredEvent, error) {
+	key := s.streamKey(eventName)
+	last := fromOffset
+	switch fromOffset {
+	case "":
+		last = "0-0"
+	case mediator.LatestOffset:
+		// Resolve $ once, as each XREAD would otherwise skip events appended in between
+		messages, err := s.client.XRevRangeN(ctx, key, "+", "-", 1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stream: %w", err)
+		}
+		last = "0-0"
+		if len(messages) > 0 {
+			last = messages[0].ID
+		}
+	}
+
+	events := make(chan mediator.StoredEvent)
+	go func() {
+		defer close(events)
+		for ctx.Err() == nil {
+			streams, err := s.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{key, last},
+				Count:   int64(mediator.DefaultPageSize),
+				Block:   tailBlock,
+			}).Result()
+			if err != nil {
+				if err != redis.Nil && ctx.Err() == nil {
+					// Back off before retrying a failed read
+					select {
+					case <-ctx.Done():
+					case <-time.After(tailBlock):
+					}
+				}
+				continue
+			}
+
+			for _, stream := range streams {
+				for _, message := range stream.Messages {
+					decoded, err := s.decodeStored([]redis.XMessage{message})
+					last = message.ID
+					if err != nil {
+						continue
+					}
+					select {
+					case events <- decoded[0]:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// ReadGroup reads up to count events of an event name not yet delivered to
+// group, on behalf of consumer, waiting up to block for one when there are
+// none; block <= 0 doesn't wait. The group starts at the beginning of the
+// stream when it is first used. Delivered events stay pending for the
+// consumer until acknowledged with Ack.
+func (s *StreamStore) ReadGroup(ctx context.Context, eventName, group, consumer string, count int64, block time.Duration) ([]mediator.StoredEvent, error) {
+	if err := s.ensureGroup(ctx, eventName, group); err != nil {
+		return nil, err
+	}
+	if block <= 0 {
+		block = -1
+	}
+
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{s.streamKey(eventName), ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group %s: %w", group, err)
+	}
+
+	var messages []redis.XMessage
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+	return s.decodeStored(messages)
+}
+
+// Ack acknowledges the events at offsets, removing them from the pending
+// entries of group
+func (s *StreamStore) Ack(ctx context.Context, eventName, group string, offsets ...string) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+	if err := s.client.XAck(ctx, s.streamKey(eventName), group, offsets...).Err(); err != nil {
+		return fmt.Errorf("failed to ack events: %w", err)
+	}
+	return nil
+}
+
+// ClaimPending hands consumer up to count events delivered to other
+// consumers of group but not acknowledged for at least minIdle, e.g. because
+// their consumer crashed, so they are redelivered
+func (s *StreamStore) ClaimPending(ctx context.Context, eventName, group, consumer string, minIdle time.Duration, count int64) ([]mediator.StoredEvent, error) {
+	if err := s.ensureGroup(ctx, eventName, group); err != nil {
+		return nil, err
+	}
+
+	// XPENDING and XCLAIM rather than XAUTOCLAIM, whose reply changed in Redis 7
+	key := s.streamKey(eventName)
+	pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: key,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending events: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range pending {
+		if entry.Idle >= minIdle {
+			ids = append(ids, entry.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// XCLAIM checks the idle time again, in case another consumer claimed first
+	messages, err := s.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   key,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending events: %w", err)
+	}
+	return s.decodeStored(messages)
+}
+
+// ensureGroup creates a consumer group at the start of the stream of an
+// event name, unless this store already did
+func (s *StreamStore) ensureGroup(ctx context.Context, eventName, group string) error {
+	key := s.streamKey(eventName)
+	s.groupsMu.Lock()
+	created := s.groups[key+"/"+group]
+	s.groupsMu.Unlock()
+	if created {
+		return nil
+	}
+
+	err := s.client.XGroupCreateMkStream(ctx, key, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create group %s: %w", group, err)
+	}
+
+	s.groupsMu.Lock()
+	s.groups[key+"/"+group] = true
+	s.groupsMu.Unlock()
+	return nil
+}
+
+// messageData returns the record of a stream entry
+func messageData(message redis.XMessage) []byte {
+	data, _ := message.Values["data"].(string)
+	return []byte(data)
+}
+
+// messageTime returns the time a stream entry was added, from its id
+func messageTime(id string) time.Time {
+	millis, _, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n).UTC()
+}
+
+// decodeStored decodes stream entries into typed events whose offset is their id
+func (s *StreamStore) decodeStored(messages []redis.XMessage) ([]mediator.StoredEvent, error) {
+	events := make([]mediator.StoredEvent, 0, len(messages))
+	for _, message := range messages {
+		event, err := mediator.DecodeStoredEvent(s.serializer, messageData(message))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		event.Offset = message.ID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// GetStreams returns every event name with a stream and their counts and the
+// times their first and last entries were added, ordered by name
+func (s *StreamStore) GetStreams(ctx context.Context) ([]mediator.StreamInfo, error) {
+	keys, err := scanKeys(ctx, s.client, s.streamKey("*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan streams: %w", err)
+	}
+
+	pipe := s.client.Pipeline()
+	lens := make([]*redis.IntCmd, len(keys))
+	firsts := make([]*redis.XMessageSliceCmd, len(keys))
+	lasts := make([]*redis.XMessageSliceCmd, len(keys))
+	for i, key := range keys {
+		lens[i] = pipe.XLen(ctx, key)
+		firsts[i] = pipe.XRangeN(ctx, key, "-", "+", 1)
+		lasts[i] = pipe.XRevRangeN(ctx, key, "+", "-", 1)
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to read streams: %w", err)
+		}
+	}
+
+	streams := make([]mediator.StreamInfo, 0, len(keys))
+	for i, key := range keys {
+		count := lens[i].Val()
+		if count == 0 {
+			continue
+		}
+		name := keyName(key, s.prefix, ":stream")
+		stream := mediator.StreamInfo{Name: name, Count: count}
+		if first := firsts[i].Val(); len(first) > 0 {
+			stream.FirstEvent = messageTime(first[0].ID)
+		}
+		if last := lasts[i].Val(); len(last) > 0 {
+			stream.LastEvent = messageTime(last[0].ID)
+		}
+		streams = append(streams, stream)
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams, nil
+}
+
+// DeleteBefore removes the entries of the stream of an event name added before t
+func (s *StreamStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	minID := strconv.FormatInt(t.UnixMilli(), 10)
+	if err := s.client.XTrimMinID(ctx, s.streamKey(eventName), minID).Err(); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return nil
+}
+
+// EnforceRetention applies the retention policy of every event name
+func (s *StreamStore) EnforceRetention(ctx context.Context) error {
+	streams, err := s.GetStreams(ctx)
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		policy := s.retention.Policy(stream.Name)
+		if policy.MaxAge > 0 {
+			if err := s.DeleteBefore(ctx, stream.Name, time.Now().Add(-policy.MaxAge)); err != nil {
+				return err
+			}
+		}
+		if policy.MaxCount > 0 {
+			if err := s.client.XTrimMaxLen(ctx, s.streamKey(stream.Name), policy.MaxCount).Err(); err != nil {
+				return fmt.Errorf("failed to trim events: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ClearEvents removes the stream of an event name, with its consumer groups
+func (s *StreamStore) ClearEvents(ctx context.Context, eventName string) error {
+	key := s.streamKey(eventName)
+	if err := s.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to clear events: %w", err)
+	}
+
+	s.groupsMu.Lock()
+	for group := range s.groups {
+		if strings.HasPrefix(group, key+"/") {
+			delete(s.groups, group)
+		}
+	}
+	s.groupsMu.Unlock()
+	return nil
+}
+
+// HealthCheck pings Redis
+func (s *StreamStore) HealthCheck(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Close closes the Redis client
+func (s *StreamStore) Close() error {
+	return s.client.Close()
+}
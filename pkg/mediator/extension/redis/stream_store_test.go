@@ -0,0 +1,246 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/storetest"
+)
+
+func TestStreamStore(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewStreamStore(client, DefaultConfig())
+	var _ mediator.EventStoreV2 = store
+	var _ mediator.PagedEventStore = store
+	var _ mediator.QueryableEventStore = store
+	var _ mediator.TailingEventStore = store
+
+	ctx := context.Background()
+	events := make([]mediator.Event, 5)
+	for i := range events {
+		events[i] = mediator.Event{
+			Name:          "order.placed",
+			ID:            fmt.Sprintf("evt-%d", i),
+			Payload:       float64(i),
+			CorrelationID: fmt.Sprintf("corr-%d", i%2),
+		}
+	}
+	if err := store.StoreEvent(ctx, events[0]); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+	if err := store.StoreEvents(ctx, events[1:]); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		read func() ([]mediator.StoredEvent, error)
+		want []string
+	}{
+		{"read", func() ([]mediator.StoredEvent, error) {
+			return store.ReadEvents(ctx, "order.placed", 3)
+		}, []string{"evt-2", "evt-3", "evt-4"}},
+		{"query", func() ([]mediator.StoredEvent, error) {
+			return store.QueryEvents(ctx, "order.placed", mediator.EventQuery{CorrelationID: "corr-0"}, 2)
+		}, []string{"evt-4", "evt-2"}},
+		{"first page", func() ([]mediator.StoredEvent, error) {
+			page, _, err := store.GetEventsPage(ctx, "order.placed", "", 2)
+			return page, err
+		}, []string{"evt-0", "evt-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.read()
+			if err != nil {
+				t.Fatalf("Failed to read events: %v", err)
+			}
+			if ids := eventIDs(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, ids)
+			}
+		})
+	}
+
+	// Test pages resume after the entry id of their last event
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected pagination to end after 3 pages")
+		}
+		page, next, err := store.GetEventsPage(ctx, "order.placed", cursor, 2)
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		ids = append(ids, eventIDs(page)...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(ids) != 5 {
+		t.Errorf("Expected 5 paged events, got %v", ids)
+	}
+
+	streams, err := store.GetStreams(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	if len(streams) != 1 || streams[0].Name != "order.placed" || streams[0].Count != 5 || streams[0].LastEvent.IsZero() {
+		t.Errorf("Expected 1 stream of 5 events, got %+v", streams)
+	}
+
+	if err := store.ClearEvents(ctx, "order.placed"); err != nil {
+		t.Fatalf("Failed to clear events: %v", err)
+	}
+	if got, _ := store.ReadEvents(ctx, "order.placed", 10); len(got) != 0 {
+		t.Errorf("Expected no events after clear, got %d", len(got))
+	}
+}
+
+func TestStreamStore_ConsumerGroups(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewStreamStore(client, DefaultConfig())
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if err := store.StoreEvent(ctx, mediator.Event{Name: "job.queued", ID: fmt.Sprintf("evt-%d", i)}); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	// Test consumers of a group share out the events
+	first, err := store.ReadGroup(ctx, "job.queued", "workers", "worker-1", 2, 0)
+	if err != nil {
+		t.Fatalf("Failed to read group: %v", err)
+	}
+	second, err := store.ReadGroup(ctx, "job.queued", "workers", "worker-2", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to read group: %v", err)
+	}
+	if got := append(eventIDs(first), eventIDs(second)...); !reflect.DeepEqual(got, []string{"evt-0", "evt-1", "evt-2", "evt-3"}) {
+		t.Errorf("Expected every event delivered once, got %v", got)
+	}
+
+	// Test another group reads the stream from the start
+	other, err := store.ReadGroup(ctx, "job.queued", "audit", "auditor", 10, 0)
+	if err != nil || len(other) != 4 {
+		t.Errorf("ReadGroup() = %d events, %v, want 4", len(other), err)
+	}
+
+	// Test nothing is left to read without waiting
+	if rest, err := store.ReadGroup(ctx, "job.queued", "workers", "worker-1", 10, 0); err != nil || len(rest) != 0 {
+		t.Errorf("ReadGroup() = %v, %v, want no events", rest, err)
+	}
+
+	// Test unacknowledged events are redelivered to another consumer
+	if err := store.Ack(ctx, "job.queued", "workers", first[0].Offset); err != nil {
+		t.Fatalf("Failed to ack event: %v", err)
+	}
+	claimed, err := store.ClaimPending(ctx, "job.queued", "workers", "worker-3", 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to claim pending events: %v", err)
+	}
+	if got := eventIDs(claimed); !reflect.DeepEqual(got, []string{"evt-1", "evt-2", "evt-3"}) {
+		t.Errorf("Expected unacknowledged events to be claimed, got %v", got)
+	}
+
+	// Test events are not claimed before they idle for minIdle
+	if claimed, err := store.ClaimPending(ctx, "job.queued", "workers", "worker-1", time.Hour, 10); err != nil || len(claimed) != 0 {
+		t.Errorf("ClaimPending() = %v, %v, want no events", claimed, err)
+	}
+}
+
+func TestStreamStore_TailEvents(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	store := NewStreamStore(client, DefaultConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "tail.test", ID: "evt-old"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+	events, err := store.TailEvents(ctx, "tail.test", mediator.LatestOffset)
+	if err != nil {
+		t.Fatalf("Failed to tail events: %v", err)
+	}
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "tail.test", ID: "evt-new"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event.ID != "evt-new" {
+			t.Errorf("Expected evt-new, got %s", event.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the tailed event")
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestStreamStore_Retention(t *testing.T) {
+	client, cleanup := setupTestRedis(t)
+	defer cleanup()
+
+	config := DefaultConfig()
+	config.Retention = mediator.Retention{
+		Default: mediator.RetentionPolicy{MaxCount: 2},
+	}
+	store := NewStreamStore(client, config)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if err := store.StoreEvent(ctx, mediator.Event{Name: "retained", ID: fmt.Sprintf("evt-%d", i)}); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	if err := store.EnforceRetention(ctx); err != nil {
+		t.Fatalf("Failed to enforce retention: %v", err)
+	}
+	events, err := store.ReadEvents(ctx, "retained", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if got := eventIDs(events); !reflect.DeepEqual(got, []string{"evt-3", "evt-4"}) {
+		t.Errorf("Expected the newest 2 events, got %v", got)
+	}
+
+	if err := store.DeleteBefore(ctx, "retained", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to delete events: %v", err)
+	}
+	if events, _ := store.ReadEvents(ctx, "retained", 10); len(events) != 0 {
+		t.Errorf("Expected no events after DeleteBefore, got %d", len(events))
+	}
+}
+
+// eventIDs returns the ids of events, in order
+func eventIDs(events []mediator.StoredEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestStreamStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, retention mediator.Retention) mediator.EventStore {
+		client, cleanup := setupTestRedis(t)
+		t.Cleanup(cleanup)
+		config := DefaultConfig()
+		config.Retention = retention
+		return NewStreamStore(client, config)
+	})
+}
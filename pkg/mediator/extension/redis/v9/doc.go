@@ -0,0 +1,6 @@
+// Package redis is the Redis extension built on github.com/redis/go-redis/v9,
+// for applications that moved off github.com/go-redis/redis/v8. Its API is the
+// same as that of the v8 extension, from whose sources it is generated.
+package redis
+
+//go:generate go run ../internal/v9gen
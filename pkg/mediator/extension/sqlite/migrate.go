@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migrations are the schema changes of the store, applied in order; the
+// version of each is its position plus one. {{table}} is the events table.
+var migrations = []string{
+	`
+	CREATE TABLE IF NOT EXISTS {{table}} (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_name TEXT NOT NULL,
+		event_data TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS {{index}} ON {{table}} (event_name, created_at);
+	`,
+}
+
+// migrate applies the migrations the database has not had yet, each in a
+// transaction recording its version
+func (s *EventStore) migrate(ctx context.Context) error {
+	versions := quoteIdent(s.config.Table + "_schema_version")
+	create := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version INTEGER PRIMARY KEY,
+			applied_at INTEGER NOT NULL
+		)
+	`, versions)
+	if _, err := s.db.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("failed to create version table: %w", err)
+	}
+
+	var current int
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", versions)
+	if err := s.db.QueryRowContext(ctx, query).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	replacer := strings.NewReplacer(
+		"{{table}}", s.table(),
+		"{{index}}", quoteIdent(s.config.Table+"_name_created_idx"),
+	)
+	for i := current; i < len(migrations); i++ {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, replacer.Replace(migrations[i])); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+		record := fmt.Sprintf("INSERT INTO %s (version, applied_at) VALUES (?, ?)", versions)
+		if _, err := tx.ExecContext(ctx, record, i+1, time.Now().UnixNano()); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
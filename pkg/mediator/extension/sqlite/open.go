@@ -0,0 +1,69 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Open opens the SQLite database at path with a registered driver, "sqlite3"
+// for github.com/mattn/go-sqlite3 or "sqlite" for modernc.org/sqlite, setting
+// BusyTimeout and Synchronous on every connection, and creates a store on it.
+// The store owns the database and closes it on Close. An in-memory database,
+// ":memory:", is kept on a single connection, as each connection would
+// otherwise get a database of its own.
+func Open(driverName, path string, config Config) (*EventStore, error) {
+	dsn, err := dataSourceName(driverName, path, config)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if path == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
+
+	store, err := NewEventStore(db, config)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// dataSourceName returns the DSN of path setting the per-connection pragmas
+// of config in the syntax of the driver
+func dataSourceName(driverName, path string, config Config) (string, error) {
+	params := url.Values{}
+	switch driverName {
+	case "sqlite3":
+		if config.BusyTimeout > 0 {
+			params.Set("_busy_timeout", fmt.Sprint(config.BusyTimeout.Milliseconds()))
+		}
+		if config.Synchronous != "" {
+			params.Set("_synchronous", config.Synchronous)
+		}
+	case "sqlite":
+		if config.BusyTimeout > 0 {
+			params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", config.BusyTimeout.Milliseconds()))
+		}
+		if config.Synchronous != "" {
+			params.Add("_pragma", fmt.Sprintf("synchronous(%s)", config.Synchronous))
+		}
+	default:
+		return "", fmt.Errorf("unsupported SQLite driver %q", driverName)
+	}
+
+	if len(params) == 0 {
+		return path, nil
+	}
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + params.Encode(), nil
+}
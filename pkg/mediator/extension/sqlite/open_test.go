@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestDataSourceName(t *testing.T) {
+	config := Config{BusyTimeout: 2 * time.Second, Synchronous: "NORMAL"}
+	tests := []struct {
+		name    string
+		driver  string
+		path    string
+		config  Config
+		want    string
+		wantErr bool
+	}{
+		{"mattn", "sqlite3", "events.db", config, "events.db?_busy_timeout=2000&_synchronous=NORMAL", false},
+		{"modernc", "sqlite", "events.db", config, "events.db?_pragma=busy_timeout%282000%29&_pragma=synchronous%28NORMAL%29", false},
+		{"existing params", "sqlite3", "file:events.db?cache=shared", Config{BusyTimeout: time.Second}, "file:events.db?cache=shared&_busy_timeout=1000", false},
+		{"no pragmas", "sqlite3", "events.db", Config{}, "events.db", false},
+		{"unknown driver", "postgres", "events.db", config, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dataSourceName(tt.driver, tt.path, tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("dataSourceName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("dataSourceName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen_Memory(t *testing.T) {
+	store, err := Open("sqlite3", ":memory:", DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	// Test every statement sees the same in-memory database
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := store.StoreEvent(ctx, mediator.Event{Name: "memory.test"}); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+	if events, err := store.ReadEvents(ctx, "memory.test", 10); err != nil || len(events) != 3 {
+		t.Errorf("ReadEvents() = %d events, %v, want 3", len(events), err)
+	}
+}
@@ -0,0 +1,490 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// EventStore represents a SQLite-based event store
+type EventStore struct {
+	db     *sql.DB
+	config Config
+
+	// lastTrim holds when each event name was last trimmed on write
+	trimMu   sync.Mutex
+	lastTrim map[string]time.Time
+}
+
+// Config represents SQLite event store configuration
+type Config struct {
+	// Table is the name of the events table
+	Table string
+	// JournalMode is set on the database when the store is created; WAL lets
+	// readers run alongside the writer. Empty leaves the database's mode.
+	JournalMode string
+	// BusyTimeout is how long Open makes a connection wait for a lock held by
+	// another connection before failing with SQLITE_BUSY
+	BusyTimeout time.Duration
+	// Synchronous is the synchronous pragma Open sets on each connection;
+	// NORMAL is durable in WAL mode but for the last transactions on power loss
+	Synchronous string
+	// MaxEventsPerType keeps the most recent events of each event name up to
+	// this count when Retention has no default policy, and is how many events
+	// GetEvents returns when no limit is given; 0 means no limit
+	MaxEventsPerType int64
+	// Serializer encodes event payloads; JSON is used when nil
+	Serializer mediator.Serializer
+	// Retention decides which events are kept; it is applied to an event name
+	// when it is written to and to every event name by EnforceRetention
+	Retention mediator.Retention
+	// DisableTrim stops writes from applying retention; EnforceRetention still does
+	DisableTrim bool
+	// TrimInterval is the least time between trims of an event name on write;
+	// 0 trims on every write
+	TrimInterval time.Duration
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Table:            "mediator_events",
+		JournalMode:      "WAL",
+		BusyTimeout:      5 * time.Second,
+		Synchronous:      "NORMAL",
+		MaxEventsPerType: 1000,
+	}
+}
+
+// NewEventStore creates a new SQLite event store on db, opened with any
+// SQLite driver. Per-connection settings such as BusyTimeout must be set in
+// the DSN; Open does so.
+func NewEventStore(db *sql.DB, config Config) (*EventStore, error) {
+	if config.Table == "" {
+		config.Table = DefaultConfig().Table
+	}
+	store := &EventStore{
+		db:       db,
+		config:   config,
+		lastTrim: make(map[string]time.Time),
+	}
+
+	ctx := context.Background()
+	if config.JournalMode != "" {
+		// The journal mode is stored in the database file, so one connection sets it for all
+		var mode string
+		if err := db.QueryRowContext(ctx, "PRAGMA journal_mode = "+config.JournalMode).Scan(&mode); err != nil {
+			return nil, fmt.Errorf("failed to set journal mode: %w", err)
+		}
+	}
+	if err := store.migrate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize tables: %w", err)
+	}
+
+	return store, nil
+}
+
+// StoreEvent stores an event in SQLite
+func (s *EventStore) StoreEvent(ctx context.Context, event mediator.Event) error {
+	return s.StoreEvents(ctx, []mediator.Event{event})
+}
+
+// StoreEvents stores several events with a single multi-row INSERT
+func (s *EventStore) StoreEvents(ctx context.Context, events []mediator.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(events))
+	args := make([]interface{}, 0, len(events)*3)
+	var names []string
+	seen := make(map[string]bool)
+	for i, event := range events {
+		// Default the timestamp of events stored outside Publish
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now().UTC()
+		}
+		record, err := mediator.EncodeEventRecordBuffer(s.config.Serializer, event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		// The string copies the record, so its buffer is reused right away
+		placeholders[i] = "(?, ?, ?)"
+		args = append(args, event.Name, string(record.Bytes()), event.Timestamp.UnixNano())
+		record.Release()
+		if !seen[event.Name] {
+			seen[event.Name] = true
+			names = append(names, event.Name)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (event_name, event_data, created_at)
+		VALUES %s
+	`, s.table(), strings.Join(placeholders, ", "))
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to store events: %w", err)
+	}
+
+	for _, name := range names {
+		if err := s.trimOnWrite(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// table returns the quoted name of the events table
+func (s *EventStore) table() string {
+	return quoteIdent(s.config.Table)
+}
+
+// quoteIdent quotes a SQLite identifier
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// trimOnWrite applies retention to an event name that was written to, unless
+// trimming is disabled or the name was trimmed less than TrimInterval ago
+func (s *EventStore) trimOnWrite(ctx context.Context, eventName string) error {
+	if s.config.DisableTrim {
+		return nil
+	}
+	if s.config.TrimInterval > 0 {
+		now := time.Now()
+		s.trimMu.Lock()
+		due := now.Sub(s.lastTrim[eventName]) >= s.config.TrimInterval
+		if due {
+			s.lastTrim[eventName] = now
+		}
+		s.trimMu.Unlock()
+		if !due {
+			return nil
+		}
+	}
+	return s.applyRetention(ctx, eventName)
+}
+
+// policy returns the retention policy of an event name; without a default
+// policy, event names are capped at MaxEventsPerType
+func (s *EventStore) policy(eventName string) mediator.RetentionPolicy {
+	if policy, ok := s.config.Retention.Events[eventName]; ok {
+		return policy
+	}
+	if s.config.Retention.Default.Forever() && s.config.MaxEventsPerType > 0 {
+		return mediator.KeepLast(s.config.MaxEventsPerType)
+	}
+	return s.config.Retention.Default
+}
+
+// applyRetention deletes the events of an event name its retention policy no longer keeps
+func (s *EventStore) applyRetention(ctx context.Context, eventName string) error {
+	policy := s.policy(eventName)
+	if policy.MaxAge > 0 {
+		if err := s.DeleteBefore(ctx, eventName, time.Now().Add(-policy.MaxAge)); err != nil {
+			return err
+		}
+	}
+	if policy.MaxCount > 0 {
+		if err := s.trimEvents(ctx, eventName, policy.MaxCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimEvents ensures that only the most recent maxCount events are kept
+func (s *EventStore) trimEvents(ctx context.Context, eventName string, maxCount int64) error {
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE event_name = ?
+			ORDER BY created_at DESC, id DESC
+			LIMIT -1 OFFSET ?
+		)
+	`, s.table())
+
+	if _, err := s.db.ExecContext(ctx, query, eventName, maxCount); err != nil {
+		return fmt.Errorf("failed to trim events: %w", err)
+	}
+	return nil
+}
+
+// DeleteBefore removes the events of an event name stored before t
+func (s *EventStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE event_name = ? AND created_at < ?
+	`, s.table())
+
+	if _, err := s.db.ExecContext(ctx, query, eventName, t.UnixNano()); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return nil
+}
+
+// EnforceRetention applies the retention policy of every event name, removing
+// events that have aged out of streams no longer written to
+func (s *EventStore) EnforceRetention(ctx context.Context) error {
+	streams, err := s.GetStreams(ctx)
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		if err := s.applyRetention(ctx, stream.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetEvents retrieves events from SQLite by event name
+func (s *EventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	records, err := s.fetch(ctx, eventName, mediator.EventQuery{}, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		event, err := mediator.DecodeEventRecord(s.config.Serializer, record.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ReadEvents retrieves events by event name as typed records
+func (s *EventStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]mediator.StoredEvent, error) {
+	records, err := s.fetch(ctx, eventName, mediator.EventQuery{}, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(records)
+}
+
+// GetEventsPage returns up to pageSize events of an event name, oldest first,
+// using the row id as a keyset cursor
+func (s *EventStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]mediator.StoredEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = mediator.DefaultPageSize
+	}
+	var after int64
+	if cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, "", fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	query := fmt.Sprintf(`
+		SELECT id, event_data
+		FROM %s
+		WHERE event_name = ? AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, s.table())
+
+	records, err := s.query(ctx, query, eventName, after, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(records) > pageSize {
+		records = records[:pageSize]
+		next = strconv.FormatInt(records[pageSize-1].id, 10)
+	}
+
+	events, err := s.decodeStored(records)
+	if err != nil {
+		return nil, "", err
+	}
+	return events, next, nil
+}
+
+// QueryEvents retrieves the most recent events of an event name matching
+// query. Filters run in SQLite, with the JSON functions matching metadata and
+// payload fields.
+func (s *EventStore) QueryEvents(ctx context.Context, eventName string, query mediator.EventQuery, limit int64) ([]mediator.StoredEvent, error) {
+	records, err := s.fetch(ctx, eventName, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.decodeStored(records)
+}
+
+// record is an encoded event and the id of its row
+type record struct {
+	id   int64
+	data []byte
+}
+
+// fetch returns the records of the most recent events of an event name matching filter
+func (s *EventStore) fetch(ctx context.Context, eventName string, filter mediator.EventQuery, limit int64) ([]record, error) {
+	if limit <= 0 {
+		limit = s.config.MaxEventsPerType
+	}
+	if limit <= 0 {
+		// LIMIT -1 returns every row
+		limit = -1
+	}
+
+	conditions := []string{"event_name = ?"}
+	args := []interface{}{eventName}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+	if filter.CorrelationID != "" {
+		conditions = append(conditions, "json_extract(event_data, '$.correlation_id') = ?")
+		args = append(args, filter.CorrelationID)
+	}
+	for key, value := range filter.Metadata {
+		conditions = append(conditions, "json_extract(event_data, ?) = ?")
+		args = append(args, jsonPath("metadata", key), value)
+	}
+	for field, value := range filter.Payload {
+		want, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal query filter: %w", err)
+		}
+		// Extracting both sides alike compares objects as minified JSON
+		conditions = append(conditions, "json_type(event_data, ?) IS NOT NULL AND json_extract(event_data, ?) IS json_extract(?, '$')")
+		path := jsonPath("payload", field)
+		args = append(args, path, path, string(want))
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT id, event_data
+		FROM %s
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, s.table(), strings.Join(conditions, " AND "))
+
+	return s.query(ctx, query, args...)
+}
+
+// jsonPath returns the JSON path of a key of the object under field
+func jsonPath(field, key string) string {
+	return fmt.Sprintf(`$.%s."%s"`, field, strings.ReplaceAll(key, `"`, `\"`))
+}
+
+// query returns the id and event_data rows of query
+func (s *EventStore) query(ctx context.Context, query string, args ...interface{}) ([]record, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var records []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.data); err != nil {
+			return nil, fmt.Errorf("failed to scan event data: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return records, nil
+}
+
+// decodeStored decodes records into typed events whose offset is their row id
+func (s *EventStore) decodeStored(records []record) ([]mediator.StoredEvent, error) {
+	events := make([]mediator.StoredEvent, 0, len(records))
+	for _, record := range records {
+		event, err := mediator.DecodeStoredEvent(s.config.Serializer, record.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		event.Offset = strconv.FormatInt(record.id, 10)
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// GetStreams returns every event name with stored events and their counts and
+// first and last timestamps, ordered by name
+func (s *EventStore) GetStreams(ctx context.Context) ([]mediator.StreamInfo, error) {
+	query := fmt.Sprintf(`
+		SELECT event_name, COUNT(*), MIN(created_at), MAX(created_at)
+		FROM %s
+		GROUP BY event_name
+		ORDER BY event_name
+	`, s.table())
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query streams: %w", err)
+	}
+	defer rows.Close()
+
+	streams := make([]mediator.StreamInfo, 0)
+	for rows.Next() {
+		var stream mediator.StreamInfo
+		var first, last int64
+		if err := rows.Scan(&stream.Name, &stream.Count, &first, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan stream: %w", err)
+		}
+		stream.FirstEvent = time.Unix(0, first).UTC()
+		stream.LastEvent = time.Unix(0, last).UTC()
+		streams = append(streams, stream)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating streams: %w", err)
+	}
+
+	return streams, nil
+}
+
+// ClearEvents removes all events for a given event name
+func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
+	query := fmt.Sprintf(`
+		DELETE FROM %s
+		WHERE event_name = ?
+	`, s.table())
+
+	if _, err := s.db.ExecContext(ctx, query, eventName); err != nil {
+		return fmt.Errorf("failed to clear events: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck pings the database
+func (s *EventStore) HealthCheck(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *EventStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,334 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/deliverytest"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"github.com/mandocaesar/mediator/pkg/mediator/storetest"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setupTestStore opens a store on a database file in a temporary directory
+func setupTestStore(t *testing.T, config Config) *EventStore {
+	t.Helper()
+	store, err := Open("sqlite3", filepath.Join(t.TempDir(), "events.db"), config)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// eventIDs returns the ids of events, in order
+func eventIDs(events []mediator.StoredEvent) []string {
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestEventStore(t *testing.T) {
+	store := setupTestStore(t, DefaultConfig())
+	var _ mediator.EventStoreV2 = store
+	var _ mediator.PagedEventStore = store
+	var _ mediator.QueryableEventStore = store
+	var _ mediator.CatalogEventStore = store
+	var _ mediator.CompactingEventStore = store
+	var _ mediator.RetentionEnforcer = store
+
+	ctx := context.Background()
+	event := mediator.Event{
+		Name:     "product.created",
+		ID:       "evt-1",
+		Payload:  map[string]interface{}{"id": "p-1"},
+		Metadata: map[string]string{"source": "test"},
+	}
+	if err := store.StoreEvent(ctx, event); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+
+	records, err := store.GetEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	if len(records) != 1 || records[0]["id"] != "evt-1" {
+		t.Errorf("Expected evt-1, got %v", records)
+	}
+
+	events, err := store.ReadEvents(ctx, "product.created", 10)
+	if err != nil {
+		t.Fatalf("Failed to read events: %v", err)
+	}
+	if len(events) != 1 || events[0].Metadata["source"] != "test" || string(events[0].RawPayload) != `{"id":"p-1"}` {
+		t.Errorf("Expected the envelope of evt-1, got %+v", events)
+	}
+
+	if err := store.ClearEvents(ctx, "product.created"); err != nil {
+		t.Fatalf("Failed to clear events: %v", err)
+	}
+	if events, _ := store.ReadEvents(ctx, "product.created", 10); len(events) != 0 {
+		t.Errorf("Expected no events after clear, got %d", len(events))
+	}
+}
+
+func TestEventStore_GetEventsPage(t *testing.T) {
+	store := setupTestStore(t, DefaultConfig())
+	ctx := context.Background()
+
+	events := make([]mediator.Event, 5)
+	for i := range events {
+		events[i] = mediator.Event{Name: "page.test", ID: fmt.Sprintf("evt-%d", i)}
+	}
+	if err := store.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	var ids []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Expected pagination to end after 3 pages")
+		}
+		page, next, err := store.GetEventsPage(ctx, "page.test", cursor, 2)
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		ids = append(ids, eventIDs(page)...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	want := []string{"evt-0", "evt-1", "evt-2", "evt-3", "evt-4"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected events %v, got %v", want, ids)
+	}
+
+	if _, _, err := store.GetEventsPage(ctx, "page.test", "not-a-cursor", 2); err == nil {
+		t.Error("Expected an invalid cursor to fail")
+	}
+}
+
+func TestEventStore_QueryEvents(t *testing.T) {
+	store := setupTestStore(t, DefaultConfig())
+	ctx := context.Background()
+
+	start := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		event := mediator.Event{
+			Name:          "query.test",
+			ID:            fmt.Sprintf("evt-%d", i),
+			Payload:       map[string]interface{}{"n": i, "even": i%2 == 0, "tags": []string{"a"}},
+			Timestamp:     start.Add(time.Duration(i) * time.Minute),
+			CorrelationID: fmt.Sprintf("corr-%d", i%2),
+			Metadata:      map[string]string{"tenant": fmt.Sprintf("tenant-%d", i%3)},
+		}
+		if err := store.StoreEvent(ctx, event); err != nil {
+			t.Fatalf("Failed to store event: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query mediator.EventQuery
+		limit int64
+		want  []string
+	}{
+		{"time range", mediator.EventQuery{Since: start.Add(time.Minute), Until: start.Add(4 * time.Minute)}, 10, []string{"evt-3", "evt-2", "evt-1"}},
+		{"correlation", mediator.EventQuery{CorrelationID: "corr-1"}, 10, []string{"evt-5", "evt-3", "evt-1"}},
+		{"metadata", mediator.EventQuery{Metadata: map[string]string{"tenant": "tenant-0"}}, 10, []string{"evt-3", "evt-0"}},
+		{"payload number", mediator.EventQuery{Payload: map[string]interface{}{"n": 4}}, 10, []string{"evt-4"}},
+		{"payload bool", mediator.EventQuery{Payload: map[string]interface{}{"even": false}}, 10, []string{"evt-5", "evt-3", "evt-1"}},
+		{"payload array", mediator.EventQuery{Payload: map[string]interface{}{"tags": []string{"a"}, "n": 2}}, 10, []string{"evt-2"}},
+		{"payload missing", mediator.EventQuery{Payload: map[string]interface{}{"missing": nil}}, 10, []string{}},
+		{"limit", mediator.EventQuery{CorrelationID: "corr-0"}, 2, []string{"evt-4", "evt-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.QueryEvents(ctx, "query.test", tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Failed to query events: %v", err)
+			}
+			if got := eventIDs(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventStore_Retention(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name    string
+		config  func(*Config)
+		enforce bool
+		want    []string
+	}{
+		{"max events on write", func(c *Config) { c.MaxEventsPerType = 2 }, false, []string{"evt-3", "evt-4"}},
+		{"policy on write", func(c *Config) {
+			c.Retention = mediator.Retention{Events: map[string]mediator.RetentionPolicy{"retained": mediator.KeepLast(3)}}
+		}, false, []string{"evt-2", "evt-3", "evt-4"}},
+		{"max age by janitor", func(c *Config) {
+			c.DisableTrim = true
+			c.Retention = mediator.Retention{Default: mediator.RetentionPolicy{MaxAge: 90 * time.Minute}}
+		}, true, []string{"evt-3", "evt-4"}},
+		{"no trim", func(c *Config) { c.DisableTrim = true }, false, []string{"evt-0", "evt-1", "evt-2", "evt-3", "evt-4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.config(&config)
+			store := setupTestStore(t, config)
+			ctx := context.Background()
+
+			for i := 0; i < 5; i++ {
+				event := mediator.Event{Name: "retained", ID: fmt.Sprintf("evt-%d", i), Timestamp: now.Add(time.Duration(i-4) * time.Hour)}
+				if err := store.StoreEvent(ctx, event); err != nil {
+					t.Fatalf("Failed to store event: %v", err)
+				}
+			}
+			if tt.enforce {
+				if err := store.EnforceRetention(ctx); err != nil {
+					t.Fatalf("Failed to enforce retention: %v", err)
+				}
+			}
+
+			events, err := store.ReadEvents(ctx, "retained", 0)
+			if err != nil {
+				t.Fatalf("Failed to read events: %v", err)
+			}
+			got := eventIDs(events)
+			for i, j := 0, len(got)-1; i < j; i, j = i+1, j-1 {
+				got[i], got[j] = got[j], got[i]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEventStore_GetStreams(t *testing.T) {
+	store := setupTestStore(t, DefaultConfig())
+	ctx := context.Background()
+
+	first := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	events := []mediator.Event{
+		{Name: "order.placed", Timestamp: first},
+		{Name: "order.placed", Timestamp: first.Add(time.Hour)},
+		{Name: "cart.updated", Timestamp: first},
+	}
+	if err := store.StoreEvents(ctx, events); err != nil {
+		t.Fatalf("Failed to store events: %v", err)
+	}
+
+	streams, err := store.GetStreams(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get streams: %v", err)
+	}
+	want := []mediator.StreamInfo{
+		{Name: "cart.updated", Count: 1, FirstEvent: first, LastEvent: first},
+		{Name: "order.placed", Count: 2, FirstEvent: first, LastEvent: first.Add(time.Hour)},
+	}
+	if !reflect.DeepEqual(streams, want) {
+		t.Errorf("Expected streams %+v, got %+v", want, streams)
+	}
+}
+
+func TestEventStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+	ctx := context.Background()
+
+	store, err := Open("sqlite3", path, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	if err := store.StoreEvent(ctx, mediator.Event{Name: "durable", ID: "evt-1"}); err != nil {
+		t.Fatalf("Failed to store event: %v", err)
+	}
+	store.Close()
+
+	// Test events survive a restart and migrations are not applied twice
+	store, err = Open("sqlite3", path, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	events, err := store.ReadEvents(ctx, "durable", 10)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("ReadEvents() = %v, %v, want 1 event", events, err)
+	}
+
+	var mode string
+	if err := store.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v, want wal", mode, err)
+	}
+}
+
+func TestEventStore_HealthCheck(t *testing.T) {
+	store, err := Open("sqlite3", filepath.Join(t.TempDir(), "events.db"), DefaultConfig())
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+	store.Close()
+	if err := store.HealthCheck(ctx); err == nil {
+		t.Error("Expected HealthCheck() to fail once closed")
+	}
+}
+
+func TestEventStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T, retention mediator.Retention) mediator.EventStore {
+		config := DefaultConfig()
+		config.Retention = retention
+		return setupTestStore(t, config)
+	})
+}
+
+func TestDeliveryGuarantees(t *testing.T) {
+	// Every restart reopens the same database file
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	config := deliverytest.DefaultConfig()
+	config.Backend = "sqlite"
+	config.Claims = deliverytest.AllGuarantees
+	config.Factory = func(t testing.TB, clock *mediatortest.Clock) deliverytest.System {
+		store, err := Open("sqlite3", path, DefaultConfig())
+		if err != nil {
+			t.Fatalf("Failed to open store: %v", err)
+		}
+		dedupe := mediator.NewStoreDedupeStore(store, 1000)
+
+		m := mediator.NewMediator(mediatortest.Deterministic(clock), mediator.WithEventStore(store), mediator.WithDeduplication(dedupe))
+		return deliverytest.System{
+			Publish: m.Publish,
+			Subscribe: func(eventName string, handler mediator.EventHandler) {
+				m.Subscribe(eventName, handler)
+			},
+			Store:  store,
+			Dedupe: dedupe,
+			Close:  store.Close,
+		}
+	}
+
+	report := deliverytest.Run(t, config)
+	var buf bytes.Buffer
+	report.WriteMarkdown(&buf)
+	t.Logf("conformance report:\n%s", buf.String())
+}
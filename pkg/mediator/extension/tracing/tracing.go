@@ -0,0 +1,187 @@
+// Package tracing instruments a mediator with OpenTelemetry spans and carries
+// trace context in event metadata, so traces continue across event stores
+// and transports.
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the spans
+const instrumentationName = "github.com/mandocaesar/mediator"
+
+// Span attributes
+const (
+	SystemAttribute        = attribute.Key("messaging.system")
+	OperationAttribute     = attribute.Key("messaging.operation")
+	EventNameAttribute     = attribute.Key("messaging.destination.name")
+	EventIDAttribute       = attribute.Key("messaging.message.id")
+	CorrelationIDAttribute = attribute.Key("messaging.message.conversation_id")
+	HandlerNameAttribute   = attribute.Key("mediator.handler.name")
+	NamespaceAttribute     = attribute.Key("mediator.namespace")
+	RemoteAttribute        = attribute.Key("mediator.remote")
+)
+
+// Values of the system and operation attributes
+const (
+	systemName       = "mediator"
+	publishOperation = "publish"
+	receiveOperation = "receive"
+	processOperation = "process"
+)
+
+// Config configures the instrumentation
+type Config struct {
+	// TracerProvider creates the tracer; the global provider is used when nil
+	TracerProvider trace.TracerProvider
+	// Propagator writes and reads the trace context in event metadata; the
+	// global propagator is used when nil
+	Propagator propagation.TextMapPropagator
+}
+
+// DefaultConfig returns configuration using the global OpenTelemetry provider
+// and propagator
+func DefaultConfig() Config {
+	return Config{}
+}
+
+// MetadataCarrier adapts event metadata to a propagation.TextMapCarrier
+type MetadataCarrier map[string]string
+
+// Get returns the value of a key
+func (c MetadataCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set stores a key and value
+func (c MetadataCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists the keys of the carrier
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// tracer holds the configured tracer and propagator
+type tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func newTracer(config Config) tracer {
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	propagator := config.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return tracer{tracer: provider.Tracer(instrumentationName), propagator: propagator}
+}
+
+// Instrument traces the publishes and handler invocations of m
+func Instrument(m *mediator.Mediator, config Config) {
+	m.UsePublish(PublishMiddleware(config))
+	m.Use(HandlerMiddleware(config))
+}
+
+// PublishMiddleware returns publish middleware starting a span around each
+// publish and recording it in the event metadata. Events received through a
+// transport or read from a store continue the trace recorded in their
+// metadata: as its child when the publish has no span of its own, linked to
+// it otherwise.
+func PublishMiddleware(config Config) mediator.Middleware {
+	t := newTracer(config)
+	return func(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+		operation, kind := publishOperation, trace.SpanKindProducer
+		if mediator.IsRemote(event) {
+			operation, kind = receiveOperation, trace.SpanKindConsumer
+		}
+
+		ctx, span := t.start(ctx, event, operation, kind)
+		defer span.End()
+
+		// Record this span as the parent of the spans of the event's handlers,
+		// wherever they run
+		carrier := make(MetadataCarrier, len(event.Metadata)+2)
+		for key, value := range event.Metadata {
+			carrier[key] = value
+		}
+		t.propagator.Inject(ctx, carrier)
+		event.Metadata = carrier
+
+		err := next(ctx, event)
+		if err != nil && !errors.Is(err, mediator.ErrNoHandlers) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// HandlerMiddleware returns middleware starting a span around each handler
+// attempt, a child of the publish span, named after the handler
+func HandlerMiddleware(config Config) mediator.Middleware {
+	t := newTracer(config)
+	return func(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+		ctx, span := t.start(ctx, event, processOperation, trace.SpanKindConsumer)
+		defer span.End()
+		if name, ok := mediator.HandlerNameFromContext(ctx); ok {
+			span.SetAttributes(HandlerNameAttribute.String(name))
+		}
+
+		err := next(ctx, event)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// start starts a span for an event, continuing the trace of its metadata
+func (t tracer) start(ctx context.Context, event mediator.Event, operation string, kind trace.SpanKind) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			SystemAttribute.String(systemName),
+			OperationAttribute.String(operation),
+			EventNameAttribute.String(event.Name),
+			EventIDAttribute.String(event.ID),
+		),
+	}
+	if event.CorrelationID != "" {
+		opts = append(opts, trace.WithAttributes(CorrelationIDAttribute.String(event.CorrelationID)))
+	}
+	if event.Namespace != "" {
+		opts = append(opts, trace.WithAttributes(NamespaceAttribute.String(event.Namespace)))
+	}
+	if mediator.IsRemote(event) {
+		opts = append(opts, trace.WithAttributes(RemoteAttribute.Bool(true)))
+	}
+
+	recorded := trace.SpanContextFromContext(t.propagator.Extract(context.Background(), MetadataCarrier(event.Metadata)))
+	current := trace.SpanContextFromContext(ctx)
+	switch {
+	case recorded.IsValid() && !current.IsValid():
+		ctx = trace.ContextWithRemoteSpanContext(ctx, recorded)
+	case recorded.IsValid() && recorded.SpanID() != current.SpanID():
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: recorded}))
+	}
+
+	return t.tracer.Start(ctx, event.Name+" "+operation, opts...)
+}
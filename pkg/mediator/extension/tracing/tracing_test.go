@@ -0,0 +1,176 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// testConfig returns a configuration recording spans
+func testConfig() (Config, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return Config{TracerProvider: provider, Propagator: propagation.TraceContext{}}, recorder
+}
+
+// spanAttribute returns the value of a span attribute
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) string {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+// spansNamed returns the ended spans with a name
+func spansNamed(recorder *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestInstrument(t *testing.T) {
+	config, recorder := testConfig()
+	m := mediator.NewMediator()
+	Instrument(m, config)
+
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil },
+		mediator.WithHandlerName("reserve-stock"))
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return errors.New("smtp down") },
+		mediator.WithHandlerName("send-email"))
+	var published mediator.Event
+	m.OnAfterPublish(func(ctx context.Context, event mediator.Event, err error) {
+		published = event
+	})
+
+	err := m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"})
+	if err == nil {
+		t.Fatal("Expected the failing handler to fail the publish")
+	}
+
+	publishes := spansNamed(recorder, "order.placed publish")
+	if len(publishes) != 1 {
+		t.Fatalf("Expected 1 publish span, got %d", len(publishes))
+	}
+	publish := publishes[0]
+	if publish.SpanKind() != trace.SpanKindProducer || spanAttribute(publish, "messaging.message.id") != "evt-1" ||
+		spanAttribute(publish, "messaging.destination.name") != "order.placed" {
+		t.Errorf("Unexpected publish span %s %v", publish.SpanKind(), publish.Attributes())
+	}
+	if publish.Status().Code != codes.Error {
+		t.Errorf("Expected the publish span to record the failure, got %v", publish.Status())
+	}
+
+	handlers := spansNamed(recorder, "order.placed process")
+	if len(handlers) != 2 {
+		t.Fatalf("Expected 2 handler spans, got %d", len(handlers))
+	}
+	for _, span := range handlers {
+		if span.Parent().SpanID() != publish.SpanContext().SpanID() {
+			t.Errorf("Handler span %s is not a child of the publish span", spanAttribute(span, "mediator.handler.name"))
+		}
+		failed := span.Status().Code == codes.Error
+		if name := spanAttribute(span, "mediator.handler.name"); failed != (name == "send-email") {
+			t.Errorf("Handler %s failed = %v", name, failed)
+		}
+	}
+
+	// Test the publish span is recorded in the metadata of the event
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), MetadataCarrier(published.Metadata)))
+	if sc.SpanID() != publish.SpanContext().SpanID() {
+		t.Errorf("Metadata %v does not record the publish span", published.Metadata)
+	}
+}
+
+func TestInstrument_AcrossTransport(t *testing.T) {
+	config, recorder := testConfig()
+	transport := mediator.NewMemoryTransport()
+	defer transport.Close()
+
+	sender := mediator.NewMediator(mediator.WithTransport(transport))
+	defer sender.Close()
+	receiver := mediator.NewMediator(mediator.WithTransport(transport))
+	defer receiver.Close()
+	Instrument(sender, config)
+	Instrument(receiver, config)
+
+	handled := make(chan struct{}, 1)
+	receiver.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		handled <- struct{}{}
+		return nil
+	})
+
+	if err := sender.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the remote handler")
+	}
+	// Let the receive span end after the handler returned
+	deadline := time.Now().Add(5 * time.Second)
+	for len(spansNamed(recorder, "order.placed receive")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the receive span")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	publish := spansNamed(recorder, "order.placed publish")[0]
+	receive := spansNamed(recorder, "order.placed receive")[0]
+	if receive.SpanKind() != trace.SpanKindConsumer || spanAttribute(receive, "mediator.remote") != "true" {
+		t.Errorf("Unexpected receive span %s %v", receive.SpanKind(), receive.Attributes())
+	}
+	if receive.Parent().SpanID() != publish.SpanContext().SpanID() || receive.SpanContext().TraceID() != publish.SpanContext().TraceID() {
+		t.Error("Expected the receive span to continue the trace of the publish span")
+	}
+	process := spansNamed(recorder, "order.placed process")[0]
+	if process.Parent().SpanID() != receive.SpanContext().SpanID() {
+		t.Error("Expected the remote handler span to be a child of the receive span")
+	}
+}
+
+func TestPublishMiddleware_LinksRecordedTrace(t *testing.T) {
+	config, recorder := testConfig()
+	m := mediator.NewMediator()
+	m.UsePublish(PublishMiddleware(config))
+	m.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error { return nil })
+
+	// An event recorded under another trace, published within a span
+	recorded := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	metadata := MetadataCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpanContext(context.Background(), recorded), metadata)
+
+	ctx, parent := config.TracerProvider.Tracer("test").Start(context.Background(), "request")
+	m.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-1", Metadata: metadata})
+	parent.End()
+
+	publish := spansNamed(recorder, "order.placed publish")[0]
+	if publish.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the publish span to be a child of the current span")
+	}
+	if links := publish.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != recorded.SpanID() {
+		t.Errorf("Expected a link to the recorded span, got %+v", links)
+	}
+	if metadata["traceparent"] == "" || metadata["traceparent"] != "00-01000000000000000000000000000000-0200000000000000-01" {
+		t.Errorf("Expected the caller's metadata to be left unchanged, got %v", metadata)
+	}
+}
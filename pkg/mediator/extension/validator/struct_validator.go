@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Validator checks struct payloads against their `validate` tags using
+// go-playground/validator. Payloads that are not structs pass.
+type Validator struct {
+	validate *validator.Validate
+}
+
+var _ mediator.Validator = (*Validator)(nil)
+
+// NewValidator creates a struct tag validator
+func NewValidator() *Validator {
+	return &Validator{validate: validator.New(validator.WithRequiredStructEnabled())}
+}
+
+// Engine returns the underlying validator, e.g. to register custom tags
+func (v *Validator) Engine() *validator.Validate {
+	return v.validate
+}
+
+// Validate checks the event payload's struct tags
+func (v *Validator) Validate(ctx context.Context, event mediator.Event) error {
+	value := reflect.ValueOf(event.Payload)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	return v.validate.StructCtx(ctx, event.Payload)
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+type product struct {
+	ID    string  `validate:"required"`
+	Price float64 `validate:"gte=0"`
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v := NewValidator()
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		wantErr bool
+	}{
+		{"valid struct", product{ID: "p-1", Price: 4.5}, false},
+		{"valid pointer", &product{ID: "p-1"}, false},
+		{"missing id", product{Price: 4.5}, true},
+		{"negative price", &product{ID: "p-1", Price: -1}, true},
+		{"not a struct", map[string]interface{}{"id": ""}, false},
+		{"nil payload", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(context.Background(), mediator.Event{Name: "product.created", Payload: tt.payload})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidator_RejectsPublish(t *testing.T) {
+	m := mediator.NewMediator(mediator.WithValidator(NewValidator()))
+	m.Subscribe("product.created", func(ctx context.Context, event mediator.Event) error {
+		t.Error("handler invoked for invalid payload")
+		return nil
+	})
+
+	err := m.Publish(context.Background(), mediator.Event{Name: "product.created", Payload: product{Price: 1}})
+	if !errors.Is(err, mediator.ErrInvalidEvent) {
+		t.Errorf("Publish() error = %v, want ErrInvalidEvent", err)
+	}
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeliveryStatus is the outcome of a delivery attempt
+type DeliveryStatus string
+
+const (
+	// Delivered means the endpoint answered with a 2xx status
+	Delivered DeliveryStatus = "delivered"
+	// Retrying means the attempt failed and another one is scheduled
+	Retrying DeliveryStatus = "retrying"
+	// Failed means the attempt failed and the event is given up on
+	Failed DeliveryStatus = "failed"
+)
+
+// Delivery records an attempt to deliver an event to an endpoint
+type Delivery struct {
+	ID         string         `json:"id"`
+	EndpointID string         `json:"endpoint_id"`
+	EventID    string         `json:"event_id"`
+	EventName  string         `json:"event_name"`
+	Attempt    int            `json:"attempt"`
+	Status     DeliveryStatus `json:"status"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Duration   time.Duration  `json:"duration"`
+	Time       time.Time      `json:"time"`
+}
+
+// DeliveryLog keeps the delivery attempts of endpoints
+type DeliveryLog interface {
+	// Record adds a delivery attempt
+	Record(ctx context.Context, delivery Delivery) error
+	// List returns the most recent attempts of an endpoint, newest first;
+	// limit <= 0 returns all
+	List(ctx context.Context, endpointID string, limit int) ([]Delivery, error)
+}
+
+// MemoryDeliveryLog is an in-memory DeliveryLog keeping the most recent
+// attempts of each endpoint
+type MemoryDeliveryLog struct {
+	capacity int
+
+	mu         sync.RWMutex
+	deliveries map[string][]Delivery
+}
+
+// NewMemoryDeliveryLog creates a delivery log keeping up to capacity attempts
+// per endpoint; every attempt is kept when capacity <= 0
+func NewMemoryDeliveryLog(capacity int) *MemoryDeliveryLog {
+	return &MemoryDeliveryLog{
+		capacity:   capacity,
+		deliveries: make(map[string][]Delivery),
+	}
+}
+
+// Record adds a delivery attempt, dropping the oldest one of the endpoint
+// beyond capacity
+func (l *MemoryDeliveryLog) Record(ctx context.Context, delivery Delivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deliveries := append(l.deliveries[delivery.EndpointID], delivery)
+	if l.capacity > 0 && len(deliveries) > l.capacity {
+		deliveries = append(deliveries[:0:0], deliveries[len(deliveries)-l.capacity:]...)
+	}
+	l.deliveries[delivery.EndpointID] = deliveries
+	return nil
+}
+
+// List returns the most recent attempts of an endpoint, newest first
+func (l *MemoryDeliveryLog) List(ctx context.Context, endpointID string, limit int) ([]Delivery, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	deliveries := l.deliveries[endpointID]
+	if limit <= 0 || limit > len(deliveries) {
+		limit = len(deliveries)
+	}
+	result := make([]Delivery, limit)
+	for i := range result {
+		result[i] = deliveries[len(deliveries)-1-i]
+	}
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryDeliveryLog(t *testing.T) {
+	ctx := context.Background()
+	log := NewMemoryDeliveryLog(3)
+	for _, id := range []string{"d-1", "d-2", "d-3", "d-4"} {
+		log.Record(ctx, Delivery{ID: id, EndpointID: "ep-1"})
+	}
+	log.Record(ctx, Delivery{ID: "d-5", EndpointID: "ep-2"})
+
+	tests := []struct {
+		name       string
+		endpointID string
+		limit      int
+		want       []string
+	}{
+		{"newest first, bounded by capacity", "ep-1", 0, []string{"d-4", "d-3", "d-2"}},
+		{"limited", "ep-1", 2, []string{"d-4", "d-3"}},
+		{"other endpoint", "ep-2", 10, []string{"d-5"}},
+		{"unknown endpoint", "ep-3", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries, err := log.List(ctx, tt.endpointID, tt.limit)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(deliveries) != len(tt.want) {
+				t.Fatalf("List() returned %d deliveries, want %d", len(deliveries), len(tt.want))
+			}
+			for i, delivery := range deliveries {
+				if delivery.ID != tt.want[i] {
+					t.Errorf("deliveries[%d] = %s, want %s", i, delivery.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on webhook requests
+const (
+	EventHeader     = "X-Mediator-Event"
+	EventIDHeader   = "X-Mediator-Event-Id"
+	DeliveryHeader  = "X-Mediator-Delivery"
+	TimestampHeader = "X-Mediator-Timestamp"
+	SignatureHeader = "X-Mediator-Signature"
+)
+
+// signaturePrefix names the algorithm of signatures
+const signaturePrefix = "sha256="
+
+// ErrInvalidSignature is returned by Verify when a request is not signed
+// with the secret, or too long ago
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature of a request body sent at timestamp: the
+// HMAC-SHA256 of the Unix timestamp, a '.' and the body, keyed by secret,
+// hex encoded after "sha256="
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a request against its
+// body, rejecting requests signed more than tolerance ago, or in the future,
+// when tolerance is positive
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	sent := time.Unix(seconds, 0)
+	if tolerance > 0 {
+		if age := time.Since(sent); age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+		}
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return fmt.Errorf("%w: unknown algorithm", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"name":"order.placed"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		wantErr   bool
+	}{
+		{"valid", "secret", signature, timestamp, body, false},
+		{"wrong secret", "other", signature, timestamp, body, true},
+		{"tampered body", "secret", signature, timestamp, []byte(`{}`), true},
+		{"other timestamp", "secret", signature, strconv.FormatInt(now.Unix()-1, 10), body, true},
+		{"expired", "secret", Sign("secret", now.Add(-time.Hour), body), strconv.FormatInt(now.Add(-time.Hour).Unix(), 10), body, true},
+		{"malformed timestamp", "secret", signature, "yesterday", body, true},
+		{"unknown algorithm", "secret", "md5=abc", timestamp, body, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, 5*time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Expected ErrInvalidSignature, got %v", err)
+			}
+		})
+	}
+}
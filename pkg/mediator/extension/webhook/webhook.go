@@ -0,0 +1,369 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// ErrClosed is returned when registering endpoints with a closed Dispatcher
+var ErrClosed = errors.New("webhook dispatcher is closed")
+
+// Endpoint is a URL events are POSTed to
+type Endpoint struct {
+	// ID identifies the endpoint; a random one is assigned when empty
+	ID string `json:"id"`
+	// URL receives the events, over http or https
+	URL string `json:"url"`
+	// Events filters the events sent to the endpoint: exact names, prefixes
+	// ending in ".*" such as "order.*", or "*". Every event is sent when empty.
+	Events []string `json:"events,omitempty"`
+	// Secret signs requests with HMAC-SHA256; requests are unsigned when empty
+	Secret string `json:"-"`
+	// Headers are added to every request
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Matches reports whether the endpoint receives events of a name
+func (e Endpoint) Matches(eventName string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, filter := range e.Events {
+		switch {
+		case filter == "*" || filter == eventName:
+			return true
+		case strings.HasSuffix(filter, ".*") && strings.HasPrefix(eventName, filter[:len(filter)-1]):
+			return true
+		}
+	}
+	return false
+}
+
+// Config configures a Dispatcher
+type Config struct {
+	// MaxAttempts is the number of times a delivery is attempted
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled after each one
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries
+	MaxBackoff time.Duration
+	// Timeout bounds each request
+	Timeout time.Duration
+	// Workers is the number of concurrent deliveries
+	Workers int
+	// QueueSize is the number of deliveries waiting for a worker; deliveries
+	// beyond it fail right away
+	QueueSize int
+	// Client sends the requests; http.DefaultClient is used when nil
+	Client *http.Client
+	// Log records delivery attempts; an in-memory log of the 100 most recent
+	// attempts per endpoint is used when nil
+	Log DeliveryLog
+	// Logger reports failed deliveries; nothing is logged when nil
+	Logger mediator.Logger
+}
+
+// DefaultConfig returns default dispatcher configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Timeout:        10 * time.Second,
+		Workers:        4,
+		QueueSize:      1000,
+	}
+}
+
+// delivery is an event on its way to an endpoint
+type delivery struct {
+	id         string
+	endpointID string
+	event      mediator.Event
+	body       []byte
+	attempt    int
+}
+
+// Dispatcher POSTs the events published through a mediator to the
+// registered endpoints whose filters match them
+type Dispatcher struct {
+	config Config
+	queue  chan delivery
+	wg     sync.WaitGroup
+
+	mu        sync.RWMutex
+	endpoints map[string]Endpoint
+	closed    bool
+}
+
+// NewDispatcher creates a dispatcher for the events published through m.
+// Dispatching starts right away; call Close to stop it.
+func NewDispatcher(m *mediator.Mediator, config Config) *Dispatcher {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Log == nil {
+		config.Log = NewMemoryDeliveryLog(100)
+	}
+
+	d := &Dispatcher{
+		config:    config,
+		queue:     make(chan delivery, config.QueueSize),
+		endpoints: make(map[string]Endpoint),
+	}
+	for i := 0; i < config.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	m.OnAfterPublish(d.dispatch)
+	return d
+}
+
+// Register adds an endpoint, or replaces the one with the same ID, and
+// returns it with its ID
+func (d *Dispatcher) Register(endpoint Endpoint) (Endpoint, error) {
+	u, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("failed to parse webhook URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, fmt.Errorf("invalid webhook URL %q: want an absolute http or https URL", endpoint.URL)
+	}
+	if endpoint.ID == "" {
+		endpoint.ID = randomID()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return Endpoint{}, ErrClosed
+	}
+	d.endpoints[endpoint.ID] = endpoint
+	return endpoint, nil
+}
+
+// Unregister removes an endpoint; its pending retries are dropped
+func (d *Dispatcher) Unregister(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.endpoints, id)
+}
+
+// Endpoints returns the registered endpoints, ordered by ID
+func (d *Dispatcher) Endpoints() []Endpoint {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	endpoints := make([]Endpoint, 0, len(d.endpoints))
+	for _, endpoint := range d.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].ID < endpoints[j].ID })
+	return endpoints
+}
+
+// Deliveries returns the most recent delivery attempts of an endpoint,
+// newest first; limit <= 0 returns all the log keeps
+func (d *Dispatcher) Deliveries(ctx context.Context, endpointID string, limit int) ([]Delivery, error) {
+	deliveries, err := d.config.Log.List(ctx, endpointID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Close stops accepting events and waits for the queued deliveries; pending
+// retries are dropped
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// dispatch queues a locally dispatched event for the endpoints matching it
+func (d *Dispatcher) dispatch(ctx context.Context, event mediator.Event, err error) {
+	if !mediator.ShouldForward(ctx, event, err) {
+		return
+	}
+
+	var endpoints []Endpoint
+	d.mu.RLock()
+	for _, endpoint := range d.endpoints {
+		if endpoint.Matches(event.Name) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	d.mu.RUnlock()
+	if len(endpoints) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.logf("failed to encode event %s: %v", event.ID, err)
+		return
+	}
+	for _, endpoint := range endpoints {
+		d.enqueue(delivery{id: randomID(), endpointID: endpoint.ID, event: event, body: body, attempt: 1})
+	}
+}
+
+// enqueue hands a delivery to the workers, failing it when the queue is full
+func (d *Dispatcher) enqueue(job delivery) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.queue <- job:
+	default:
+		d.record(job, Delivery{Status: Failed, Error: "delivery queue is full", Time: time.Now()})
+	}
+}
+
+// work delivers queued deliveries until the queue is closed
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for job := range d.queue {
+		d.deliver(job)
+	}
+}
+
+// deliver attempts a delivery, scheduling a retry when it fails with an
+// error worth retrying
+func (d *Dispatcher) deliver(job delivery) {
+	d.mu.RLock()
+	endpoint, ok := d.endpoints[job.endpointID]
+	d.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	statusCode, err := d.send(endpoint, job)
+	attempt := Delivery{StatusCode: statusCode, Duration: time.Since(start), Time: start}
+	if err == nil {
+		attempt.Status = Delivered
+		d.record(job, attempt)
+		return
+	}
+
+	attempt.Error = err.Error()
+	retryable := statusCode == 0 || statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests || statusCode >= 500
+	if !retryable || job.attempt >= d.config.MaxAttempts {
+		attempt.Status = Failed
+		d.record(job, attempt)
+		d.logf("failed to deliver event %s to endpoint %s: %v", job.event.ID, endpoint.ID, err)
+		return
+	}
+
+	attempt.Status = Retrying
+	d.record(job, attempt)
+	backoff := d.backoff(job.attempt)
+	job.attempt++
+	time.AfterFunc(backoff, func() { d.enqueue(job) })
+}
+
+// send POSTs a delivery to an endpoint, returning the response status
+func (d *Dispatcher) send(endpoint Endpoint, job delivery) (int, error) {
+	ctx := context.Background()
+	if d.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range endpoint.Headers {
+		req.Header.Set(key, value)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, job.event.Name)
+	req.Header.Set(EventIDHeader, job.event.ID)
+	req.Header.Set(DeliveryHeader, job.id)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, now, job.body))
+	}
+
+	resp, err := d.config.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the wait before retrying after an attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	backoff := d.config.InitialBackoff
+	for i := 1; i < attempt && (d.config.MaxBackoff <= 0 || backoff < d.config.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if d.config.MaxBackoff > 0 && backoff > d.config.MaxBackoff {
+		backoff = d.config.MaxBackoff
+	}
+	return backoff
+}
+
+// record adds an attempt of a delivery to the log
+func (d *Dispatcher) record(job delivery, attempt Delivery) {
+	attempt.ID = job.id
+	attempt.EndpointID = job.endpointID
+	attempt.EventID = job.event.ID
+	attempt.EventName = job.event.Name
+	attempt.Attempt = job.attempt
+	if err := d.config.Log.Record(context.Background(), attempt); err != nil {
+		d.logf("failed to record delivery %s: %v", job.id, err)
+	}
+}
+
+// logf reports a failure through the configured logger
+func (d *Dispatcher) logf(format string, args ...interface{}) {
+	if d.config.Logger != nil {
+		d.config.Logger.Printf("webhook: "+format, args...)
+	}
+}
+
+// randomID returns a random hex ID
+func randomID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
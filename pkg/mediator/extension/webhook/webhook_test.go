@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// request is a request received by a test endpoint
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// endpoint serves a test endpoint answering with the statuses in order, then
+// 200, and returns its URL and the requests it receives
+func endpoint(t *testing.T, statuses ...int) (string, <-chan request) {
+	t.Helper()
+	requests := make(chan request, 10)
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{header: r.Header, body: body}
+		if n := int(atomic.AddInt32(&calls, 1)); n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, requests
+}
+
+// testConfig returns a configuration retrying quickly
+func testConfig() Config {
+	config := DefaultConfig()
+	config.InitialBackoff = time.Millisecond
+	config.MaxBackoff = 5 * time.Millisecond
+	config.MaxAttempts = 3
+	return config
+}
+
+// next returns the next request of ch
+func next(t *testing.T, ch <-chan request) request {
+	t.Helper()
+	select {
+	case req := <-ch:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a request")
+		return request{}
+	}
+}
+
+// waitDeliveries waits for n attempts of an endpoint in the log
+func waitDeliveries(t *testing.T, d *Dispatcher, endpointID string, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := d.Deliveries(context.Background(), endpointID, 0)
+		if err != nil {
+			t.Fatalf("Deliveries() error = %v", err)
+		}
+		if len(deliveries) >= n {
+			return deliveries
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d deliveries, got %+v", n, deliveries)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEndpoint_Matches(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []string
+		eventName string
+		want      bool
+	}{
+		{"no filter", nil, "order.placed", true},
+		{"exact", []string{"order.placed"}, "order.placed", true},
+		{"other name", []string{"order.placed"}, "order.shipped", false},
+		{"prefix", []string{"order.*"}, "order.shipped", true},
+		{"prefix of other name", []string{"order.*"}, "orders.shipped", false},
+		{"wildcard", []string{"*"}, "user.created", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Endpoint{Events: tt.events}).Matches(tt.eventName); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.eventName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Register(t *testing.T) {
+	d := NewDispatcher(mediator.NewMediator(), testConfig())
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"https", "https://example.com/hooks", false},
+		{"http", "http://localhost:8080/hooks", false},
+		{"other scheme", "ftp://example.com", true},
+		{"relative", "/hooks", true},
+		{"malformed", "http://[::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, err := d.Register(Endpoint{URL: tt.url})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && endpoint.ID == "" {
+				t.Error("Expected an ID to be assigned")
+			}
+		})
+	}
+
+	if endpoints := d.Endpoints(); len(endpoints) != 2 {
+		t.Errorf("Expected 2 endpoints, got %d", len(endpoints))
+	}
+	d.Unregister(d.Endpoints()[0].ID)
+	if endpoints := d.Endpoints(); len(endpoints) != 1 {
+		t.Errorf("Expected 1 endpoint after Unregister, got %d", len(endpoints))
+	}
+
+	d.Close()
+	if _, err := d.Register(Endpoint{URL: "https://example.com"}); err != ErrClosed {
+		t.Errorf("Register() after Close error = %v, want ErrClosed", err)
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	m := mediator.NewMediator()
+	d := NewDispatcher(m, testConfig())
+	defer d.Close()
+
+	url, requests := endpoint(t)
+	orders, err := d.Register(Endpoint{
+		ID:      "orders",
+		URL:     url,
+		Events:  []string{"order.*"},
+		Secret:  "secret",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	ctx := context.Background()
+	m.Publish(ctx, mediator.Event{Name: "user.created", ID: "evt-1"})
+	m.Publish(ctx, mediator.Event{Name: "order.placed", ID: "evt-2", Payload: map[string]interface{}{"id": "o-1"}})
+
+	req := next(t, requests)
+	if got := req.header.Get(EventIDHeader); got != "evt-2" {
+		t.Fatalf("Received %s, want evt-2", got)
+	}
+	if req.header.Get(EventHeader) != "order.placed" || req.header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Unexpected headers %v", req.header)
+	}
+	if err := Verify("secret", req.header.Get(SignatureHeader), req.header.Get(TimestampHeader), req.body, time.Minute); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	var event mediator.Event
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if payload, ok := event.Payload.(map[string]interface{}); event.Name != "order.placed" || !ok || payload["id"] != "o-1" {
+		t.Errorf("Unexpected body %s", req.body)
+	}
+
+	deliveries := waitDeliveries(t, d, orders.ID, 1)
+	if deliveries[0].Status != Delivered || deliveries[0].StatusCode != http.StatusOK || deliveries[0].EventID != "evt-2" {
+		t.Errorf("Unexpected delivery %+v", deliveries[0])
+	}
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		wantStatus []DeliveryStatus
+	}{
+		{"recovers", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, []DeliveryStatus{Delivered, Retrying, Retrying}},
+		{"gives up", []int{500, 502, 504}, []DeliveryStatus{Failed, Retrying, Retrying}},
+		{"not retryable", []int{http.StatusBadRequest}, []DeliveryStatus{Failed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mediator.NewMediator()
+			d := NewDispatcher(m, testConfig())
+			defer d.Close()
+
+			url, requests := endpoint(t, tt.statuses...)
+			ep, _ := d.Register(Endpoint{URL: url})
+			m.Publish(context.Background(), mediator.Event{Name: "order.placed", ID: "evt-1"})
+
+			deliveries := waitDeliveries(t, d, ep.ID, len(tt.wantStatus))
+			for i, want := range tt.wantStatus {
+				if deliveries[i].Status != want || deliveries[i].Attempt != len(tt.wantStatus)-i {
+					t.Errorf("deliveries[%d] = %+v, want %s attempt %d", i, deliveries[i], want, len(tt.wantStatus)-i)
+				}
+			}
+
+			// All attempts share the delivery ID
+			first := next(t, requests).header.Get(DeliveryHeader)
+			for range tt.wantStatus[1:] {
+				if id := next(t, requests).header.Get(DeliveryHeader); id != first {
+					t.Errorf("Delivery ID = %s, want %s", id, first)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcher_SkipsRemoteEvents(t *testing.T) {
+	m := mediator.NewMediator()
+	d := NewDispatcher(m, testConfig())
+	defer d.Close()
+
+	url, requests := endpoint(t)
+	d.Register(Endpoint{URL: url})
+
+	event := mediator.Event{Name: "order.placed", ID: "evt-1", Metadata: map[string]string{mediator.RemoteMetadataKey: "true"}}
+	m.Publish(context.Background(), event)
+
+	select {
+	case req := <-requests:
+		t.Errorf("Expected no request, got %s", req.header.Get(EventIDHeader))
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,150 @@
+package mediator
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Filter decides whether a handler receives an event
+type Filter func(event Event) bool
+
+// WithFilter only invokes the handler for events accepted by filter. Several
+// filters must all accept the event. Filters run while Publish holds the
+// mediator's read lock and must not call back into the mediator.
+func WithFilter(filter Filter) SubscribeOption {
+	return func(s *Subscription) {
+		s.filters = append(s.filters, filter)
+	}
+}
+
+// accepts reports whether every filter of the subscription accepts the event
+func (s *Subscription) accepts(event Event) bool {
+	for _, filter := range s.filters {
+		if !filter(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// Field returns a Filter comparing a payload field with value. The path uses
+// dots for nested fields and matches map keys, struct field names and JSON
+// tags, e.g. Field("price", ">", 100). Supported operators are ==, !=, >, >=,
+// < and <=; numbers are compared as float64. Events lacking the field are rejected.
+func Field(path, op string, value interface{}) Filter {
+	keys := strings.Split(path, ".")
+	return func(event Event) bool {
+		actual, ok := lookupField(event.Payload, keys)
+		if !ok {
+			return false
+		}
+		return compareValues(actual, op, value)
+	}
+}
+
+// lookupField walks a payload along keys
+func lookupField(payload interface{}, keys []string) (interface{}, bool) {
+	current := reflect.ValueOf(payload)
+	for _, key := range keys {
+		for current.Kind() == reflect.Pointer || current.Kind() == reflect.Interface {
+			if current.IsNil() {
+				return nil, false
+			}
+			current = current.Elem()
+		}
+
+		switch current.Kind() {
+		case reflect.Map:
+			if current.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			current = current.MapIndex(reflect.ValueOf(key).Convert(current.Type().Key()))
+			if !current.IsValid() {
+				return nil, false
+			}
+		case reflect.Struct:
+			field, ok := structField(current, key)
+			if !ok {
+				return nil, false
+			}
+			current = field
+		default:
+			return nil, false
+		}
+	}
+	if !current.CanInterface() {
+		return nil, false
+	}
+	return current.Interface(), true
+}
+
+// structField finds an exported field by name or JSON tag
+func structField(value reflect.Value, key string) (reflect.Value, bool) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == key || strings.EqualFold(field.Name, key) {
+			return value.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// compareValues applies op to actual and expected
+func compareValues(actual interface{}, op string, expected interface{}) bool {
+	a, aNumeric := toFloat(actual)
+	e, eNumeric := toFloat(expected)
+	if aNumeric && eNumeric {
+		switch op {
+		case "==":
+			return a == e
+		case "!=":
+			return a != e
+		case ">":
+			return a > e
+		case ">=":
+			return a >= e
+		case "<":
+			return a < e
+		case "<=":
+			return a <= e
+		}
+		return false
+	}
+
+	as, es := fmt.Sprint(actual), fmt.Sprint(expected)
+	switch op {
+	case "==":
+		return as == es
+	case "!=":
+		return as != es
+	case ">":
+		return as > es
+	case ">=":
+		return as >= es
+	case "<":
+		return as < es
+	case "<=":
+		return as <= es
+	}
+	return false
+}
+
+// toFloat converts numeric values to float64
+func toFloat(v interface{}) (float64, bool) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,89 @@
+package mediator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribe_WithFilter(t *testing.T) {
+	m := NewMediator()
+
+	var premium, all int
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		premium++
+		return nil
+	}, WithFilter(Field("price", ">", 100)))
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		all++
+		return nil
+	})
+
+	for _, price := range []float64{50, 150, 250} {
+		payload := map[string]interface{}{"price": price}
+		if err := m.Publish(context.Background(), Event{Name: "product.created", Payload: payload}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if premium != 2 || all != 3 {
+		t.Errorf("premium=%d all=%d, want 2, 3", premium, all)
+	}
+}
+
+func TestSubscribe_FilterRejectsAll(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		t.Error("handler invoked for rejected event")
+		return nil
+	}, WithFilter(func(event Event) bool { return false }))
+
+	if err := m.Publish(context.Background(), Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(store.events) != 1 {
+		t.Errorf("stored %d events, want 1", len(store.events))
+	}
+}
+
+func TestField(t *testing.T) {
+	type category struct {
+		Name string `json:"name"`
+	}
+	type product struct {
+		ID       string   `json:"id"`
+		Price    int      `json:"price"`
+		Category category `json:"category"`
+		Internal *category
+	}
+
+	structPayload := &product{ID: "p-1", Price: 120, Category: category{Name: "coffee"}}
+	mapPayload := map[string]interface{}{
+		"price":    120.0,
+		"category": map[string]interface{}{"name": "coffee"},
+	}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+		filter  Filter
+		want    bool
+	}{
+		{name: "struct json tag", payload: structPayload, filter: Field("price", ">=", 120), want: true},
+		{name: "struct field name", payload: structPayload, filter: Field("Price", "<", 100), want: false},
+		{name: "struct nested", payload: structPayload, filter: Field("category.name", "==", "coffee"), want: true},
+		{name: "struct nil pointer", payload: structPayload, filter: Field("internal.name", "==", "x"), want: false},
+		{name: "map numeric", payload: mapPayload, filter: Field("price", "==", 120), want: true},
+		{name: "map nested not equal", payload: mapPayload, filter: Field("category.name", "!=", "tea"), want: true},
+		{name: "missing field", payload: mapPayload, filter: Field("stock", ">", 0), want: false},
+		{name: "unknown operator", payload: mapPayload, filter: Field("price", "~", 120), want: false},
+		{name: "nil payload", payload: nil, filter: Field("price", ">", 0), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter(Event{Payload: tt.payload}); got != tt.want {
+				t.Errorf("Field() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// GroupRecord is an event delivered to a subscriber group, identified by its
+// offset in the group's log of the subscribed event name
+type GroupRecord struct {
+	Offset int64 `json:"offset"`
+	Event  Event `json:"event"`
+}
+
+// GroupStore persists the per-group event logs behind at-least-once delivery.
+// Each group has its own log per subscribed event name, which differs from the
+// event's name when a group subscribes to an ancestor topic (see
+// WithHierarchicalTopics); records stay pending until acked.
+type GroupStore interface {
+	// Append adds an event to the group's log of eventName and returns its offset
+	Append(ctx context.Context, group, eventName string, event Event) (int64, error)
+	// Ack marks the record at offset as processed by the group
+	Ack(ctx context.Context, group, eventName string, offset int64) error
+	// Pending returns the unacknowledged records of the group, oldest first
+	Pending(ctx context.Context, group, eventName string) ([]GroupRecord, error)
+}
+
+// WithGroup registers the handler under a subscriber group. Events are
+// appended to the group's log before the handlers run and acknowledged once
+// every handler of the group succeeds; Recover redelivers the rest.
+func WithGroup(group string) SubscribeOption {
+	return func(s *Subscription) {
+		s.group = group
+	}
+}
+
+// Group returns the subscriber group of the handler, if any
+func (s *Subscription) Group() string {
+	return s.group
+}
+
+// WithGroupStore sets the store of subscriber group logs. NewMediator uses an
+// in-memory store, which redelivers failed events but does not survive restarts.
+func WithGroupStore(store GroupStore) Option {
+	return func(m *Mediator) {
+		m.groupStore = store
+	}
+}
+
+// withGroupRecord dispatches a pending record to its group's handlers only,
+// acknowledging it at its existing offset instead of appending it again
+func withGroupRecord(group, eventName string, offset int64) PublishOption {
+	return func(c *publishConfig) {
+		c.group = group
+		c.groupOffset = groupOffset{eventName: eventName, offset: offset}
+	}
+}
+
+// groupOffset locates an event in a group's log
+type groupOffset struct {
+	eventName string
+	offset    int64
+}
+
+// appendToGroups appends an event to the log of every group among invocations
+// and returns the offset per group, nil when none belongs to a group
+func (m *Mediator) appendToGroups(ctx context.Context, store GroupStore, event Event, invocations []invocation) (map[string]groupOffset, error) {
+	var offsets map[string]groupOffset
+	for _, inv := range invocations {
+		if inv.group == "" {
+			continue
+		}
+		if _, done := offsets[inv.group]; done {
+			continue
+		}
+		if offsets == nil {
+			offsets = make(map[string]groupOffset)
+		}
+		offset, err := store.Append(ctx, inv.group, inv.topic, event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to append event to group %s: %w", inv.group, err)
+		}
+		offsets[inv.group] = groupOffset{eventName: inv.topic, offset: offset}
+	}
+	return offsets, nil
+}
+
+// ackGroups acknowledges the event for every group whose handlers all ran and
+// succeeded; results holds the outcome of each invocation
+func (m *Mediator) ackGroups(ctx context.Context, store GroupStore, invocations []invocation, offsets map[string]groupOffset, results []error) []error {
+	failed := make(map[string]bool)
+	for i, inv := range invocations {
+		if results[i] != nil && inv.group != "" {
+			failed[inv.group] = true
+		}
+	}
+
+	var ackErrs []error
+	for group, offset := range offsets {
+		if failed[group] {
+			continue
+		}
+		// Acknowledge even when the publish was cancelled; the handlers did succeed
+		if err := store.Ack(context.WithoutCancel(ctx), group, offset.eventName, offset.offset); err != nil {
+			ackErrs = append(ackErrs, fmt.Errorf("failed to ack event for group %s: %w", group, err))
+		}
+	}
+	return ackErrs
+}
+
+// Recover redelivers the unacknowledged events of every registered subscriber
+// group, e.g. after a restart. It returns the number of events redelivered
+// successfully and the errors of those that failed again.
+func (m *Mediator) Recover(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	store := m.groupStore
+	type groupKey struct{ group, eventName string }
+	var keys []groupKey
+	seen := make(map[groupKey]bool)
+	for eventName, subs := range m.subscribers.load() {
+		for _, sub := range subs {
+			key := groupKey{sub.group, eventName}
+			if sub.group != "" && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	if store == nil {
+		return 0, fmt.Errorf("no group store configured")
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].eventName != keys[j].eventName {
+			return keys[i].eventName < keys[j].eventName
+		}
+		return keys[i].group < keys[j].group
+	})
+
+	delivered := 0
+	var errs []error
+	for _, key := range keys {
+		records, err := store.Pending(ctx, key.group, key.eventName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read pending events of group %s: %w", key.group, err))
+			continue
+		}
+
+		for _, record := range records {
+			event := record.Event
+			if event.Payload, err = m.rehydrate(event.Name, event.Payload); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := m.publish(ctx, event, []PublishOption{withoutStore(), localOnly(), withGroupRecord(key.group, key.eventName, record.Offset)}); err != nil {
+				errs = append(errs, fmt.Errorf("redelivery of %s to group %s failed: %w", event.ID, key.group, err))
+				continue
+			}
+			delivered++
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// MemoryGroupStore is an in-memory GroupStore
+type MemoryGroupStore struct {
+	mu   sync.Mutex
+	logs map[string]*memoryGroupLog
+}
+
+// memoryGroupLog is the log of one group and event name
+type memoryGroupLog struct {
+	next    int64
+	pending []GroupRecord
+}
+
+// NewMemoryGroupStore creates an empty MemoryGroupStore
+func NewMemoryGroupStore() *MemoryGroupStore {
+	return &MemoryGroupStore{
+		logs: make(map[string]*memoryGroupLog),
+	}
+}
+
+// Append adds an event to the group's log of eventName and returns its offset
+func (s *MemoryGroupStore) Append(ctx context.Context, group, eventName string, event Event) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := group + "/" + eventName
+	log, exists := s.logs[key]
+	if !exists {
+		log = &memoryGroupLog{}
+		s.logs[key] = log
+	}
+	log.next++
+	log.pending = append(log.pending, GroupRecord{Offset: log.next, Event: event})
+	return log.next, nil
+}
+
+// Ack marks the record at offset as processed by the group
+func (s *MemoryGroupStore) Ack(ctx context.Context, group, eventName string, offset int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, exists := s.logs[group+"/"+eventName]
+	if !exists {
+		return nil
+	}
+	for i, record := range log.pending {
+		if record.Offset == offset {
+			log.pending = append(log.pending[:i:i], log.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Pending returns the unacknowledged records of the group, oldest first
+func (s *MemoryGroupStore) Pending(ctx context.Context, group, eventName string) ([]GroupRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, exists := s.logs[group+"/"+eventName]
+	if !exists {
+		return nil, nil
+	}
+	return append([]GroupRecord(nil), log.pending...), nil
+}
@@ -0,0 +1,112 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMediator_GroupAcknowledgesSuccess(t *testing.T) {
+	store := NewMemoryGroupStore()
+	m := NewMediator(WithGroupStore(store))
+
+	sub := m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil }, WithGroup("billing"))
+	if sub.Group() != "billing" {
+		t.Errorf("Group() = %s, want billing", sub.Group())
+	}
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	pending, err := store.Pending(context.Background(), "billing", "order.placed")
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Pending() = %d records, want 0 after success", len(pending))
+	}
+}
+
+func TestMediator_RecoverRedeliversUnacknowledged(t *testing.T) {
+	store := NewMemoryGroupStore()
+	m := NewMediator(WithGroupStore(store))
+
+	failing := true
+	billing := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		billing++
+		if failing {
+			return errors.New("billing unavailable")
+		}
+		return nil
+	}, WithGroup("billing"))
+
+	shipping := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		shipping++
+		return nil
+	}, WithGroup("shipping"))
+
+	plain := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		plain++
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed", ID: "evt-1"}); err == nil {
+		t.Fatal("Publish() expected handler error")
+	}
+
+	// Test only the failed group's handlers are redelivered to
+	failing = false
+	n, err := m.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Recover() = %d, want 1", n)
+	}
+	if billing != 2 || shipping != 1 || plain != 1 {
+		t.Errorf("calls billing=%d shipping=%d plain=%d, want 2, 1, 1", billing, shipping, plain)
+	}
+
+	// Test nothing is pending after the successful redelivery
+	if n, err := m.Recover(context.Background()); err != nil || n != 0 {
+		t.Errorf("Recover() = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestMediator_RecoverReportsRepeatedFailures(t *testing.T) {
+	m := NewMediator()
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		return errors.New("still failing")
+	}, WithGroup("billing"))
+
+	_ = m.Publish(context.Background(), Event{Name: "order.placed"})
+
+	n, err := m.Recover(context.Background())
+	if err == nil || n != 0 {
+		t.Errorf("Recover() = %d, %v, want 0 and error", n, err)
+	}
+}
+
+func TestMemoryGroupStore_Offsets(t *testing.T) {
+	store := NewMemoryGroupStore()
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		offset, err := store.Append(ctx, "billing", "order.placed", Event{Name: "order.placed"})
+		if err != nil || offset != i {
+			t.Errorf("Append() = %d, %v, want %d", offset, err, i)
+		}
+	}
+	if err := store.Ack(ctx, "billing", "order.placed", 2); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+
+	pending, _ := store.Pending(ctx, "billing", "order.placed")
+	if len(pending) != 2 || pending[0].Offset != 1 || pending[1].Offset != 3 {
+		t.Errorf("Pending() = %+v, want offsets [1 3]", pending)
+	}
+}
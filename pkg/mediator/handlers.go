@@ -0,0 +1,230 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// handlerPrefix starts the names of methods RegisterHandlers subscribes by convention
+const handlerPrefix = "Handle"
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	eventType   = reflect.TypeOf(Event{})
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterHandlers subscribes the handler methods of target, which is usually
+// a pointer to a struct, and returns their subscriptions. Handler methods take
+// a context.Context and either an Event or a typed payload, as in
+// SubscribeTyped, and return an error.
+//
+// Methods are found by convention or by struct tag. A method named Handle
+// followed by the event name in CamelCase handles that event, so
+// HandleProductUpdate handles "product.update" and HandleSKUCreated handles
+// "sku.created"; methods of that name without a handler signature are ignored.
+// A struct field tagged with event and handler subscribes the named method to
+// the comma-separated event names instead, and turns its convention off:
+//
+//	type SKUUseCase struct {
+//		_ struct{} `event:"sku.created,sku.restocked" handler:"HandleSKUCreation"`
+//	}
+//
+// Subscriptions are named after the type and method, and opts apply to every
+// one of them. Nothing is subscribed when a tag names a missing method or one
+// without a handler signature, or when WithStrictEvents rejects an event name.
+func RegisterHandlers(m *Mediator, target interface{}, opts ...SubscribeOption) ([]*Subscription, error) {
+	value := reflect.ValueOf(target)
+	if !value.IsValid() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+		return nil, errors.New("failed to register handlers: target is nil")
+	}
+
+	bindings, err := handlerBindings(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register handlers of %s: %w", value.Type(), err)
+	}
+
+	for _, b := range bindings {
+		if err := m.ValidateEventName(b.eventName); err != nil {
+			return nil, fmt.Errorf("failed to register handlers of %s: %w", value.Type(), err)
+		}
+	}
+
+	// Bind the payload types of typed handlers before subscribing any of them
+	for _, b := range bindings {
+		if b.payload == nil {
+			continue
+		}
+		if err := m.bindPayloadType(b.eventName, b.payload); err != nil {
+			return nil, fmt.Errorf("failed to register handlers of %s: %w", value.Type(), err)
+		}
+	}
+
+	subs := make([]*Subscription, 0, len(bindings))
+	for _, b := range bindings {
+		subOpts := append([]SubscribeOption{WithHandlerName(b.name)}, opts...)
+		subs = append(subs, m.Subscribe(b.eventName, m.methodHandler(b.method, b.payload), subOpts...))
+	}
+	return subs, nil
+}
+
+// handlerBinding is a handler method and the event name it subscribes to
+type handlerBinding struct {
+	eventName string
+	name      string
+	method    reflect.Value
+	// payload is the payload type of a typed handler, nil for an EventHandler
+	payload reflect.Type
+}
+
+// handlerBindings finds the handler methods of value, from its struct tags
+// first and then by naming convention
+func handlerBindings(value reflect.Value) ([]handlerBinding, error) {
+	typeName := value.Type().String()
+	if value.Kind() == reflect.Pointer {
+		typeName = value.Type().Elem().String()
+	}
+
+	var bindings []handlerBinding
+	tagged := make(map[string]bool)
+
+	if structType := reflect.Indirect(value).Type(); structType.Kind() == reflect.Struct {
+		for i := 0; i < structType.NumField(); i++ {
+			field := structType.Field(i)
+			events, hasEvents := field.Tag.Lookup("event")
+			methodName, hasHandler := field.Tag.Lookup("handler")
+			if !hasEvents && !hasHandler {
+				continue
+			}
+			if events == "" || methodName == "" {
+				return nil, fmt.Errorf("field %s needs both an event and a handler tag", field.Name)
+			}
+
+			method := value.MethodByName(methodName)
+			if !method.IsValid() {
+				return nil, fmt.Errorf("field %s names missing method %s", field.Name, methodName)
+			}
+			payload, ok := handlerPayload(method.Type())
+			if !ok {
+				return nil, fmt.Errorf("method %s is not a handler: want func(context.Context, Event or payload) error, got %s", methodName, method.Type())
+			}
+
+			tagged[methodName] = true
+			for _, eventName := range strings.Split(events, ",") {
+				if eventName = strings.TrimSpace(eventName); eventName == "" {
+					return nil, fmt.Errorf("field %s has an empty event name", field.Name)
+				}
+				bindings = append(bindings, handlerBinding{
+					eventName: eventName,
+					name:      typeName + "." + methodName,
+					method:    method,
+					payload:   payload,
+				})
+			}
+		}
+	}
+
+	for i := 0; i < value.NumMethod(); i++ {
+		methodType := value.Type().Method(i)
+		if tagged[methodType.Name] {
+			continue
+		}
+		eventName := conventionalEventName(methodType.Name)
+		if eventName == "" {
+			continue
+		}
+		method := value.Method(i)
+		payload, ok := handlerPayload(method.Type())
+		if !ok {
+			continue
+		}
+		bindings = append(bindings, handlerBinding{
+			eventName: eventName,
+			name:      typeName + "." + methodType.Name,
+			method:    method,
+			payload:   payload,
+		})
+	}
+	return bindings, nil
+}
+
+// handlerPayload reports whether fn has a handler signature, and returns its
+// payload type unless it takes an Event
+func handlerPayload(fn reflect.Type) (reflect.Type, bool) {
+	if fn.NumIn() != 2 || fn.NumOut() != 1 || fn.IsVariadic() {
+		return nil, false
+	}
+	if fn.In(0) != contextType || fn.Out(0) != errorType {
+		return nil, false
+	}
+	if fn.In(1) == eventType {
+		return nil, true
+	}
+	return fn.In(1), true
+}
+
+// methodHandler adapts a handler method to an EventHandler, converting the
+// payloads of typed handlers like SubscribeTyped
+func (m *Mediator) methodHandler(method reflect.Value, payload reflect.Type) EventHandler {
+	if payload == nil {
+		return method.Interface().(func(context.Context, Event) error)
+	}
+	return func(ctx context.Context, event Event) error {
+		arg, err := convertPayloadValue(event.Payload, payload, m.Serializer())
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.Name, err)
+		}
+		out := method.Call([]reflect.Value{reflect.ValueOf(ctx), arg})
+		err, _ = out[0].Interface().(error)
+		return err
+	}
+}
+
+// convertPayloadValue is convertPayload for a payload type known at run time
+func convertPayloadValue(payload interface{}, typ reflect.Type, serializer Serializer) (reflect.Value, error) {
+	if payload != nil && reflect.TypeOf(payload).AssignableTo(typ) {
+		value := reflect.New(typ).Elem()
+		value.Set(reflect.ValueOf(payload))
+		return value, nil
+	}
+	if payload == nil {
+		return reflect.Value{}, fmt.Errorf("payload is nil, want %s", typ)
+	}
+
+	target := reflect.New(typ)
+	if err := decodePayload(payload, target.Interface(), serializer); err != nil {
+		return reflect.Value{}, err
+	}
+	return target.Elem(), nil
+}
+
+// conventionalEventName returns the event name of a method named Handle
+// followed by CamelCase words, lowercased and joined with dots, or "" for
+// other methods. Acronyms are one word: HandleSKUCreated is "sku.created".
+func conventionalEventName(methodName string) string {
+	rest, ok := strings.CutPrefix(methodName, handlerPrefix)
+	if !ok || rest == "" || !unicode.IsUpper(rune(rest[0])) {
+		return ""
+	}
+
+	runes := []rune(rest)
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		// A word starts at an upper-case letter after a lower-case one, or
+		// at the last letter of an acronym followed by a lower-case one
+		if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	words = append(words, strings.ToLower(string(runes[start:])))
+	return strings.Join(words, ".")
+}
@@ -0,0 +1,167 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// catalogHandlers has handlers found by convention and by struct tag
+type catalogHandlers struct {
+	_ struct{} `event:"sku.created, sku.restocked" handler:"HandleSKUCreation"`
+
+	events []string
+	prices []float64
+}
+
+func (h *catalogHandlers) HandleProductUpdate(ctx context.Context, event Event) error {
+	h.events = append(h.events, event.Name)
+	return nil
+}
+
+func (h *catalogHandlers) HandlePriceChanged(ctx context.Context, p *testProduct) error {
+	h.prices = append(h.prices, p.Price)
+	return nil
+}
+
+func (h *catalogHandlers) HandleSKUCreation(ctx context.Context, event Event) error {
+	h.events = append(h.events, event.Name)
+	return errors.New("out of stock")
+}
+
+// HandleRequest has no handler signature and is skipped
+func (h *catalogHandlers) HandleRequest(path string) {}
+
+func (h *catalogHandlers) Reset(ctx context.Context, event Event) error {
+	h.events = nil
+	return nil
+}
+
+func TestRegisterHandlers(t *testing.T) {
+	m := NewMediator()
+	h := &catalogHandlers{}
+
+	subs, err := RegisterHandlers(m, h, WithPriority(5))
+	if err != nil {
+		t.Fatalf("RegisterHandlers() error = %v", err)
+	}
+
+	var got []string
+	for _, sub := range subs {
+		got = append(got, sub.EventName()+" "+sub.Name())
+		if sub.Priority() != 5 {
+			t.Errorf("subscription %s priority = %d, want 5", sub.Name(), sub.Priority())
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"price.changed mediator.catalogHandlers.HandlePriceChanged",
+		"product.update mediator.catalogHandlers.HandleProductUpdate",
+		"sku.created mediator.catalogHandlers.HandleSKUCreation",
+		"sku.restocked mediator.catalogHandlers.HandleSKUCreation",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("subscriptions =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	ctx := context.Background()
+	m.Publish(ctx, Event{Name: "product.update"})
+	if err := m.Publish(ctx, Event{Name: "sku.restocked"}); err == nil || !strings.Contains(err.Error(), "out of stock") {
+		t.Errorf("Publish() error = %v, want the handler error", err)
+	}
+	m.Publish(ctx, Event{Name: "price.changed", Payload: &testProduct{Price: 10}})
+	m.Publish(ctx, Event{Name: "price.changed", Payload: map[string]interface{}{"price": 20.0}})
+
+	if strings.Join(h.events, ",") != "product.update,sku.restocked" {
+		t.Errorf("handled events = %v", h.events)
+	}
+	if len(h.prices) != 2 || h.prices[0] != 10 || h.prices[1] != 20 {
+		t.Errorf("handled prices = %v, want [10 20]", h.prices)
+	}
+
+	// Test typed handlers bind the payload type of their event
+	if err := PublishTyped(ctx, m, "price.changed", "free"); err == nil {
+		t.Error("PublishTyped() of another payload type error = nil")
+	}
+}
+
+type missingHandler struct {
+	_ struct{} `event:"order.placed" handler:"HandleOrder"`
+}
+
+type invalidHandler struct {
+	_ struct{} `event:"order.placed" handler:"Place"`
+}
+
+func (invalidHandler) Place(ctx context.Context) error { return nil }
+
+type untaggedEvent struct {
+	_ struct{} `handler:"HandleOrderPlaced"`
+}
+
+func (untaggedEvent) HandleOrderPlaced(ctx context.Context, event Event) error { return nil }
+
+// conflictingHandlers binds two payload types to one event name
+type conflictingHandlers struct {
+	_ struct{} `event:"order.placed" handler:"HandleOrderTotal"`
+}
+
+func (conflictingHandlers) HandleOrderPlaced(ctx context.Context, p *testProduct) error { return nil }
+
+func (conflictingHandlers) HandleOrderTotal(ctx context.Context, total float64) error { return nil }
+
+func TestRegisterHandlers_Errors(t *testing.T) {
+	var nilHandlers *catalogHandlers
+
+	tests := []struct {
+		name    string
+		target  interface{}
+		wantErr string
+	}{
+		{name: "nil", target: nil, wantErr: "target is nil"},
+		{name: "nil pointer", target: nilHandlers, wantErr: "target is nil"},
+		{name: "missing method", target: &missingHandler{}, wantErr: "missing method HandleOrder"},
+		{name: "invalid signature", target: invalidHandler{}, wantErr: "method Place is not a handler"},
+		{name: "tag without event", target: untaggedEvent{}, wantErr: "both an event and a handler tag"},
+		{name: "conflicting payload types", target: conflictingHandlers{}, wantErr: "already bound to payload type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator()
+			subs, err := RegisterHandlers(m, tt.target)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("RegisterHandlers() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if subs != nil || len(m.Subscriptions()) != 0 {
+				t.Errorf("RegisterHandlers() subscribed %d handlers after an error", len(m.Subscriptions()))
+			}
+		})
+	}
+}
+
+func TestConventionalEventName(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{method: "HandleProductUpdate", want: "product.update"},
+		{method: "HandleSKUCreated", want: "sku.created"},
+		{method: "HandleOrderV2Placed", want: "order.v2.placed"},
+		{method: "HandleHTTP", want: "http"},
+		{method: "HandleX", want: "x"},
+		{method: "Handle", want: ""},
+		{method: "Handler", want: ""},
+		{method: "ProductUpdate", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			if got := conventionalEventName(tt.method); got != tt.want {
+				t.Errorf("conventionalEventName(%q) = %q, want %q", tt.method, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package mediator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the status of a mediator or one of its components
+type HealthStatus string
+
+const (
+	// HealthUp means the component works
+	HealthUp HealthStatus = "up"
+	// HealthDown means the component fails or is stopped
+	HealthDown HealthStatus = "down"
+)
+
+// healthTimeout bounds the checks of a health request
+const healthTimeout = 5 * time.Second
+
+// HealthChecker is implemented by event stores and transports that can check
+// their connection, e.g. by pinging their server
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ComponentHealth is the health of one component of a mediator
+type ComponentHealth struct {
+	Name   string       `json:"name"`
+	Status HealthStatus `json:"status"`
+	// Error explains why the component is down
+	Error string `json:"error,omitempty"`
+	// Details holds component specific state, e.g. the queue depth
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Health is the health of a mediator: down when any component is down
+type Health struct {
+	Status     HealthStatus      `json:"status"`
+	Components []ComponentHealth `json:"components,omitempty"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+// healthCheck is a check added with AddHealthCheck
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// AddHealthCheck adds a named check to Health, e.g. for a dependency of the
+// handlers; the component is down while check returns an error
+func (m *Mediator) AddHealthCheck(name string, check func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthChecks = append(m.healthChecks, healthCheck{name: name, check: check})
+}
+
+// Health checks the event store and transports implementing HealthChecker,
+// the schedulers, the async worker pool and the checks added with
+// AddHealthCheck. Checks run concurrently within ctx.
+func (m *Mediator) Health(ctx context.Context) Health {
+	m.mu.RLock()
+	eventStore := unwrapStore(m.eventStore)
+	var transports []Transport
+	if m.remote != nil {
+		transports = m.remote.transports
+	}
+	schedulers := m.schedulers
+	checks := m.healthChecks
+	async := m.async
+	closed := m.closed
+	m.mu.RUnlock()
+
+	var pending []func() ComponentHealth
+	if eventStore != nil {
+		pending = append(pending, func() ComponentHealth {
+			return checkComponent(ctx, "event_store", eventStore)
+		})
+	}
+	for i, transport := range transports {
+		name, transport := fmt.Sprintf("transport.%d", i), transport
+		pending = append(pending, func() ComponentHealth {
+			return checkComponent(ctx, name, transport)
+		})
+	}
+	for _, check := range checks {
+		check := check
+		pending = append(pending, func() ComponentHealth {
+			return componentHealth(check.name, check.check(ctx))
+		})
+	}
+
+	// Checks may wait on the network, so they run concurrently
+	results := make([]ComponentHealth, len(pending))
+	var wg sync.WaitGroup
+	for i, check := range pending {
+		wg.Add(1)
+		go func(i int, check func() ComponentHealth) {
+			defer wg.Done()
+			results[i] = check()
+		}(i, check)
+	}
+	wg.Wait()
+	components := results
+
+	for i, scheduler := range schedulers {
+		running, jobs := scheduler.running()
+		component := ComponentHealth{
+			Name:    fmt.Sprintf("scheduler.%d", i),
+			Status:  HealthUp,
+			Details: map[string]interface{}{"jobs": jobs},
+		}
+		if !running {
+			component.Status, component.Error = HealthDown, "scheduler is not running"
+		}
+		components = append(components, component)
+	}
+
+	workers := ComponentHealth{Name: "workers", Status: HealthUp, Details: map[string]interface{}{"queue_depth": m.QueueDepth()}}
+	if async != nil {
+		workers.Details["workers"] = len(async.queues)
+	}
+	if closed {
+		workers.Status, workers.Error = HealthDown, ErrMediatorClosed.Error()
+	}
+	components = append(components, workers)
+
+	health := Health{Status: HealthUp, Components: components, CheckedAt: m.now()}
+	for _, component := range components {
+		if component.Status != HealthUp {
+			health.Status = HealthDown
+		}
+	}
+	return health
+}
+
+// checkComponent checks a store or transport implementing HealthChecker;
+// others are reported up
+func checkComponent(ctx context.Context, name string, component interface{}) ComponentHealth {
+	checker, ok := component.(HealthChecker)
+	if !ok {
+		return ComponentHealth{Name: name, Status: HealthUp}
+	}
+	return componentHealth(name, checker.HealthCheck(ctx))
+}
+
+// componentHealth reports a component down when its check failed
+func componentHealth(name string, err error) ComponentHealth {
+	if err != nil {
+		return ComponentHealth{Name: name, Status: HealthDown, Error: err.Error()}
+	}
+	return ComponentHealth{Name: name, Status: HealthUp}
+}
+
+// HealthHandler returns an http.Handler serving probes: /healthz answers 200
+// until the mediator is closed, for liveness, and /readyz answers 200 while
+// Health is up and 503 otherwise, for readiness. Both describe the health in
+// a JSON body.
+func (m *Mediator) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.RLock()
+		closed := m.closed
+		m.mu.RUnlock()
+
+		health := Health{Status: HealthUp, CheckedAt: m.now()}
+		if closed {
+			health.Status = HealthDown
+		}
+		writeHealth(w, health)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+		defer cancel()
+		writeHealth(w, m.Health(ctx))
+	})
+	return mux
+}
+
+// writeHealth writes a health as JSON, with 503 when it is down
+func writeHealth(w http.ResponseWriter, health Health) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if health.Status != HealthUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(health)
+}
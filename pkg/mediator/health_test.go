@@ -0,0 +1,122 @@
+package mediator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// checkedStore is an event store with a health check
+type checkedStore struct {
+	mockEventStore
+	err error
+}
+
+func (s *checkedStore) HealthCheck(ctx context.Context) error {
+	return s.err
+}
+
+// component returns the health of a named component
+func component(health Health, name string) (ComponentHealth, bool) {
+	for _, c := range health.Components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ComponentHealth{}, false
+}
+
+func TestMediator_Health(t *testing.T) {
+	store := &checkedStore{}
+	transport := NewMemoryTransport()
+	defer transport.Close()
+	m := NewMediator(WithEventStore(store), WithTransport(transport))
+	scheduler := NewScheduler(m)
+	scheduler.Every(time.Hour, Event{Name: "report.due"})
+	scheduler.Start()
+	var dependencyErr error
+	m.AddHealthCheck("payments", func(ctx context.Context) error { return dependencyErr })
+
+	ctx := context.Background()
+	health := m.Health(ctx)
+	if health.Status != HealthUp {
+		t.Fatalf("Health() = %+v, want up", health)
+	}
+	for _, name := range []string{"event_store", "transport.0", "payments", "scheduler.0", "workers"} {
+		if c, ok := component(health, name); !ok || c.Status != HealthUp {
+			t.Errorf("Component %s = %+v, %v, want up", name, c, ok)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		component string
+		fail      func()
+	}{
+		{"store down", "event_store", func() { store.err = errors.New("connection refused") }},
+		{"check failing", "payments", func() { dependencyErr = errors.New("timeout") }},
+		{"scheduler stopped", "scheduler.0", scheduler.Stop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fail()
+			health := m.Health(ctx)
+			c, _ := component(health, tt.component)
+			if health.Status != HealthDown || c.Status != HealthDown || c.Error == "" {
+				t.Errorf("Health() = %+v, want %s down", health, tt.component)
+			}
+		})
+	}
+}
+
+func TestMediator_HealthHandler(t *testing.T) {
+	store := &checkedStore{}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMediator(WithEventStore(store), WithClock(fixedClock{now: now}))
+	srv := httptest.NewServer(m.HealthHandler())
+	defer srv.Close()
+
+	probe := func(path string) (int, Health) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		defer resp.Body.Close()
+		var health Health
+		if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+			t.Fatalf("Failed to decode %s: %v", path, err)
+		}
+		return resp.StatusCode, health
+	}
+
+	if code, health := probe("/readyz"); code != http.StatusOK || health.Status != HealthUp || len(health.Components) == 0 {
+		t.Errorf("/readyz = %d %+v, want 200 up", code, health)
+	}
+
+	// Test checks are timed by the mediator's clock
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if _, health := probe(path); !health.CheckedAt.Equal(now) {
+			t.Errorf("%s checked at %v, want %v", path, health.CheckedAt, now)
+		}
+	}
+
+	// Liveness does not depend on the store
+	store.err = errors.New("connection refused")
+	if code, _ := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz = %d with the store down, want 503", code)
+	}
+	if code, _ := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz = %d with the store down, want 200", code)
+	}
+
+	m.Close()
+	if code, health := probe("/healthz"); code != http.StatusServiceUnavailable || health.Status != HealthDown {
+		t.Errorf("/healthz = %d %+v once closed, want 503 down", code, health)
+	}
+}
@@ -0,0 +1,127 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPublishVetoed is returned when a BeforePublishHook rejects an event
+var ErrPublishVetoed = errors.New("publish vetoed")
+
+// BeforePublishHook runs before an event is dispatched. It may modify the event,
+// e.g. to stamp a tenant ID, or return an error to veto the publish.
+type BeforePublishHook func(ctx context.Context, event *Event) error
+
+// AfterPublishHook runs once a publish has finished, with its result
+type AfterPublishHook func(ctx context.Context, event Event, err error)
+
+// HandlerErrorHook runs when a handler fails after all its attempts
+type HandlerErrorHook func(ctx context.Context, event Event, err *HandlerError)
+
+// Kinds of event store operations
+const (
+	StoreWrite = "write"
+	StoreRead  = "read"
+	StoreClear = "clear"
+)
+
+// StoreOperation describes a call the mediator made to its event store
+type StoreOperation struct {
+	// Kind is StoreWrite, StoreRead or StoreClear
+	Kind string
+	// EventName is the event name the call was for, empty for batches of
+	// several names
+	EventName string
+	Duration  time.Duration
+	Err       error
+}
+
+// StoreHook runs after every call the mediator makes to its event store
+type StoreHook func(ctx context.Context, op StoreOperation)
+
+// OnBeforePublish registers a hook run before every publish. Hooks run in
+// registration order; the first to return an error stops the publish.
+func (m *Mediator) OnBeforePublish(hook BeforePublishHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.beforePublish = append(m.beforePublish, hook)
+	m.refreshSettings()
+}
+
+// OnAfterPublish registers a hook run after every publish that was not vetoed.
+// Hooks run in registration order.
+func (m *Mediator) OnAfterPublish(hook AfterPublishHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.afterPublish = append(m.afterPublish, hook)
+	m.refreshSettings()
+}
+
+// OnHandlerError registers a hook run for every final handler failure. Hooks
+// run in registration order, concurrently with other handlers in Parallel mode.
+func (m *Mediator) OnHandlerError(hook HandlerErrorHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlerErrHooks = append(m.handlerErrHooks, hook)
+	m.refreshSettings()
+}
+
+// OnStoreOperation registers a hook run after every event store call, e.g. to
+// measure store latency. Hooks run in registration order.
+func (m *Mediator) OnStoreOperation(hook StoreHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storeHooks = append(m.storeHooks, hook)
+	m.refreshSettings()
+}
+
+// runBeforePublish applies the before-publish hooks to event, stopping at the
+// first veto, and returns the event they modified
+func (m *Mediator) runBeforePublish(ctx context.Context, event Event) (Event, error) {
+	hooks := m.publishSettings().beforePublish
+	if len(hooks) == 0 {
+		return event, nil
+	}
+
+	// Hooks take the address of the event; copy it only when there are any
+	modified := event
+	for _, hook := range hooks {
+		if err := hook(ctx, &modified); err != nil {
+			return event, fmt.Errorf("%w: %s: %w", ErrPublishVetoed, modified.Name, err)
+		}
+	}
+	return modified, nil
+}
+
+// runAfterPublish reports the result of a publish to the after-publish hooks
+func (m *Mediator) runAfterPublish(ctx context.Context, event Event, err error) {
+	hooks := m.publishSettings().afterPublish
+	for _, hook := range hooks {
+		hook(ctx, event, err)
+	}
+}
+
+// runHandlerErrorHooks reports a final handler failure to the handler error hooks
+func (m *Mediator) runHandlerErrorHooks(ctx context.Context, event Event, err *HandlerError) {
+	hooks := m.publishSettings().handlerErrHooks
+	for _, hook := range hooks {
+		hook(ctx, event, err)
+	}
+}
+
+// observeStore runs an event store call and reports it to the store hooks
+func (m *Mediator) observeStore(ctx context.Context, kind, eventName string, call func() error) error {
+	hooks := m.publishSettings().storeHooks
+	if len(hooks) == 0 {
+		return call()
+	}
+	start := time.Now()
+	err := call()
+	op := StoreOperation{Kind: kind, EventName: eventName, Duration: time.Since(start), Err: err}
+	for _, hook := range hooks {
+		hook(ctx, op)
+	}
+	return err
+}
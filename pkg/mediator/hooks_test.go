@@ -0,0 +1,120 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMediator_BeforePublishHooks(t *testing.T) {
+	m := NewMediator()
+
+	var calls []string
+	m.OnBeforePublish(func(ctx context.Context, event *Event) error {
+		calls = append(calls, "first")
+		event.Metadata = map[string]string{"tenant": "acme"}
+		return nil
+	})
+	m.OnBeforePublish(func(ctx context.Context, event *Event) error {
+		calls = append(calls, "second:"+event.Metadata["tenant"])
+		return nil
+	})
+
+	var tenant string
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		tenant = event.Metadata["tenant"]
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if want := []string{"first", "second:acme"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hook calls = %v, want %v", calls, want)
+	}
+	if tenant != "acme" {
+		t.Errorf("handler saw tenant %q, want acme", tenant)
+	}
+}
+
+func TestMediator_BeforePublishHookVeto(t *testing.T) {
+	m := NewMediator()
+	errForbidden := errors.New("forbidden")
+
+	m.OnBeforePublish(func(ctx context.Context, event *Event) error {
+		return errForbidden
+	})
+	m.OnBeforePublish(func(ctx context.Context, event *Event) error {
+		t.Error("hook after a veto should not run")
+		return nil
+	})
+	m.OnAfterPublish(func(ctx context.Context, event Event, err error) {
+		t.Error("after hook should not run for a vetoed publish")
+	})
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		t.Error("handler should not run for a vetoed publish")
+		return nil
+	})
+
+	err := m.Publish(context.Background(), Event{Name: "order.placed"})
+	if !errors.Is(err, ErrPublishVetoed) || !errors.Is(err, errForbidden) {
+		t.Errorf("Publish() error = %v, want ErrPublishVetoed wrapping the hook error", err)
+	}
+}
+
+func TestMediator_AfterPublishAndHandlerErrorHooks(t *testing.T) {
+	m := NewMediator()
+	errFailed := errors.New("failed")
+
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil }, WithHandlerName("ok"))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return errFailed }, WithHandlerName("broken"))
+
+	var failed []string
+	m.OnHandlerError(func(ctx context.Context, event Event, err *HandlerError) {
+		failed = append(failed, err.HandlerName)
+	})
+
+	var results []error
+	m.OnAfterPublish(func(ctx context.Context, event Event, err error) {
+		results = append(results, err)
+	})
+
+	publishErr := m.Publish(context.Background(), Event{Name: "order.placed"})
+	if publishErr == nil {
+		t.Fatal("Publish() expected handler error")
+	}
+	if want := []string{"broken"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed handlers = %v, want %v", failed, want)
+	}
+	if len(results) != 1 || results[0] != publishErr {
+		t.Errorf("after hook results = %v, want [%v]", results, publishErr)
+	}
+}
+
+func TestMediator_OnStoreOperation(t *testing.T) {
+	m := NewMediator(WithEventStore(&mockEventStore{}))
+
+	var ops []StoreOperation
+	m.OnStoreOperation(func(ctx context.Context, op StoreOperation) {
+		ops = append(ops, op)
+	})
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := context.Background()
+	m.Publish(ctx, Event{Name: "order.placed"})
+	m.GetEvents(ctx, "order.placed", 0)
+	m.GetEventsPage(ctx, "order.placed", "", 10)
+	m.ClearEvents(ctx, "order.placed")
+
+	var kinds []string
+	for _, op := range ops {
+		if op.EventName != "order.placed" || op.Err != nil {
+			t.Errorf("Unexpected operation %+v", op)
+		}
+		kinds = append(kinds, op.Kind)
+	}
+	if want := []string{StoreWrite, StoreRead, StoreRead, StoreClear}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("operations = %v, want %v", kinds, want)
+	}
+}
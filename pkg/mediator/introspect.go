@@ -0,0 +1,63 @@
+package mediator
+
+import (
+	"sort"
+	"time"
+)
+
+// HandlerInfo describes a registered handler
+type HandlerInfo struct {
+	Name         string    `json:"name"`
+	Priority     int       `json:"priority"`
+	Group        string    `json:"group,omitempty"`
+	SubscribedAt time.Time `json:"subscribed_at"`
+	Paused       bool      `json:"paused,omitempty"`
+	Module       string    `json:"module,omitempty"`
+}
+
+// SubscriptionInfo describes the handlers of an event name
+type SubscriptionInfo struct {
+	EventName    string        `json:"event_name"`
+	HandlerCount int           `json:"handler_count"`
+	Handlers     []HandlerInfo `json:"handlers"`
+}
+
+// Subscriptions returns a snapshot of the registered handlers per event name,
+// sorted by event name with handlers in dispatch order
+func (m *Mediator) Subscriptions() []SubscriptionInfo {
+	registered := m.subscribers.load()
+	infos := make([]SubscriptionInfo, 0, len(registered))
+	for eventName, subs := range registered {
+		info := SubscriptionInfo{
+			EventName:    eventName,
+			HandlerCount: len(subs),
+			Handlers:     make([]HandlerInfo, len(subs)),
+		}
+		for i, sub := range subs {
+			info.Handlers[i] = HandlerInfo{
+				Name:         sub.name,
+				Priority:     sub.priority,
+				Group:        sub.group,
+				SubscribedAt: sub.createdAt,
+				Paused:       sub.Paused(),
+				Module:       sub.Module(),
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].EventName < infos[j].EventName })
+	return infos
+}
+
+// LookupSubscription returns the subscription of a handler by event name and
+// handler name, e.g. to pause it from an admin endpoint
+func (m *Mediator) LookupSubscription(eventName, handlerName string) (*Subscription, bool) {
+	subs, _ := m.subscribers.get(eventName)
+	for _, sub := range subs {
+		if sub.name == handlerName {
+			return sub, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,66 @@
+package mediator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func namedTestHandler(ctx context.Context, event Event) error { return nil }
+
+func TestMediator_Subscriptions(t *testing.T) {
+	m := NewMediator()
+	before := time.Now().UTC()
+
+	m.Subscribe("product.created", namedTestHandler)
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error { return nil },
+		WithHandlerName("sku.autocreate"), WithPriority(10), WithGroup("catalog"))
+	sub := m.Subscribe("order.placed", namedTestHandler)
+
+	infos := m.Subscriptions()
+	if len(infos) != 2 {
+		t.Fatalf("Subscriptions() returned %d event names, want 2", len(infos))
+	}
+	if infos[0].EventName != "order.placed" || infos[1].EventName != "product.created" {
+		t.Errorf("Subscriptions() order = %s, %s, want sorted event names", infos[0].EventName, infos[1].EventName)
+	}
+
+	product := infos[1]
+	if product.HandlerCount != 2 || len(product.Handlers) != 2 {
+		t.Fatalf("product.created handlers = %d, want 2", product.HandlerCount)
+	}
+	first := product.Handlers[0]
+	if first.Name != "sku.autocreate" || first.Priority != 10 || first.Group != "catalog" {
+		t.Errorf("first handler = %+v, want sku.autocreate with priority 10 in group catalog", first)
+	}
+	if !strings.HasSuffix(product.Handlers[1].Name, "namedTestHandler") {
+		t.Errorf("derived handler name = %s, want suffix namedTestHandler", product.Handlers[1].Name)
+	}
+	if first.SubscribedAt.Before(before) {
+		t.Errorf("SubscribedAt = %v, want after %v", first.SubscribedAt, before)
+	}
+
+	// Test the snapshot reflects unsubscribes
+	sub.Unsubscribe()
+	if infos := m.Subscriptions(); len(infos) != 1 {
+		t.Errorf("Subscriptions() after unsubscribe returned %d event names, want 1", len(infos))
+	}
+}
+
+func TestMediator_LookupSubscription(t *testing.T) {
+	m := NewMediator()
+	sub := m.Subscribe("order.placed", namedTestHandler, WithHandlerName("reserve-stock"))
+	sub.Pause()
+
+	got, ok := m.LookupSubscription("order.placed", "reserve-stock")
+	if !ok || got != sub {
+		t.Errorf("LookupSubscription() = %v, %v, want the subscription", got, ok)
+	}
+	if _, ok := m.LookupSubscription("order.shipped", "reserve-stock"); ok {
+		t.Error("LookupSubscription() found a handler of another event name")
+	}
+	if infos := m.Subscriptions(); !infos[0].Handlers[0].Paused {
+		t.Errorf("Expected Subscriptions to report the pause, got %+v", infos)
+	}
+}
@@ -2,21 +2,86 @@ package mediator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Event represents a generic event in the system
+// Event represents a generic event in the system. Besides the name and payload
+// it carries an envelope of metadata; Publish fills in ID, Timestamp and
+// CorrelationID when they are empty. Events published from a handler inherit
+// CausationID and CorrelationID from the event being handled.
 type Event struct {
-	Name    string
-	Payload interface{}
+	Name    string      `json:"name"`
+	Payload interface{} `json:"payload"`
+
+	// ID uniquely identifies the event
+	ID string `json:"id,omitempty"`
+	// Timestamp is when the event was published
+	Timestamp time.Time `json:"timestamp"`
+	// CorrelationID groups every event of a multi-hop flow; it defaults to ID
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// CausationID is the ID of the event that caused this one
+	CausationID string `json:"causation_id,omitempty"`
+	// Metadata holds arbitrary key/value annotations
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Namespace scopes the event to a tenant; stores keep each namespace apart
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // Mediator manages event subscriptions and publishing
 type Mediator struct {
-	subscribers map[string][]EventHandler
-	eventStore  EventStore
-	mu          sync.RWMutex
+	subscribers      subscriberRegistry
+	payloadTypes     map[string]reflect.Type
+	events           map[string]bool
+	modules          map[string]*Module
+	strictEvents     bool
+	requestHandlers  map[reflect.Type][]requestHandler
+	middlewares      []Middleware
+	publishChain     []Middleware
+	eventStore       EventStore
+	logger           Logger
+	concurrency      ConcurrencyMode
+	maxConcurrency   int
+	handlerTimeout   time.Duration
+	retryPolicy      *RetryPolicy
+	deadLetters      DeadLetterQueue
+	serializer       Serializer
+	typeRegistry     *TypeRegistry
+	groupStore       GroupStore
+	dedupe           DedupeStore
+	closers          []func() error
+	closed           bool
+	schedulers       []*Scheduler
+	healthChecks     []healthCheck
+	partitionKey     PartitionKey
+	partitionWorkers int
+	async            *asyncDispatcher
+	asyncOnce        sync.Once
+	eventLimiters    map[string]*rateLimiter
+	priorities       map[string]Priority
+	limits           *Limits
+	inFlight         atomic.Int64
+	hierarchical     bool
+	namespace        string
+	validators       []Validator
+	errorStrategy    ErrorStrategy
+	deliveryMode     DeliveryMode
+	bufferConfig     *BufferConfig
+	pollInterval     time.Duration
+	janitorInterval  time.Duration
+	beforePublish    []BeforePublishHook
+	afterPublish     []AfterPublishHook
+	handlerErrHooks  []HandlerErrorHook
+	storeHooks       []StoreHook
+	remote           *remoteFanOut
+	clock            Clock
+	synchronous      bool
+	settings         atomic.Pointer[publishSettings]
+	mu               sync.RWMutex
 }
 
 // EventHandler is a function type that handles events
@@ -30,18 +95,63 @@ var (
 // New creates a singleton Mediator instance
 func New() *Mediator {
 	mediatorOnce.Do(func() {
-		globalMediator = &Mediator{
-			subscribers: make(map[string][]EventHandler),
-		}
+		globalMediator = NewMediator()
 	})
 	return globalMediator
 }
 
+// NewMediator creates an independent Mediator instance configured with the given options
+func NewMediator(opts ...Option) *Mediator {
+	m := &Mediator{
+		groupStore: NewMemoryGroupStore(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	// Wrap the store once every option is applied, whatever their order
+	if m.bufferConfig != nil && m.eventStore != nil {
+		buffer := newBufferedStore(m.eventStore, *m.bufferConfig, m.logf)
+		m.eventStore = buffer
+		m.onClose(buffer.close)
+	}
+	if enforcer, ok := unwrapStore(m.eventStore).(RetentionEnforcer); ok && m.janitorInterval > 0 {
+		m.startJanitor(enforcer, m.janitorInterval)
+	}
+	m.refreshSettings()
+	return m
+}
+
 // SetEventStore sets the event store for the mediator
 func (m *Mediator) SetEventStore(store EventStore) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.eventStore = store
+	m.refreshSettings()
+}
+
+// Close stops the background components attached to the mediator, such as
+// schedulers, in reverse order of creation
+func (m *Mediator) Close() error {
+	m.mu.Lock()
+	closers := m.closers
+	m.closers = nil
+	m.closed = true
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// onClose registers a function to run when the mediator is closed
+func (m *Mediator) onClose(fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closers = append(m.closers, fn)
 }
 
 // GetMediator returns the existing mediator instance
@@ -52,64 +162,182 @@ func GetMediator() *Mediator {
 	return globalMediator
 }
 
-// Subscribe adds an event handler for a specific event type
-func (m *Mediator) Subscribe(eventName string, handler EventHandler) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.subscribers[eventName] = append(m.subscribers[eventName], handler)
+// Subscribe adds an event handler for a specific event type and returns a
+// Subscription that can be used to remove it again. Handlers run in order of
+// descending priority (see WithPriority), then in registration order. Like
+// MustSubscribe, it panics on an event name ValidateEventName rejects with
+// WithStrictEvents; use TrySubscribe for names not fixed in the program.
+func (m *Mediator) Subscribe(eventName string, handler EventHandler, opts ...SubscribeOption) *Subscription {
+	return m.MustSubscribe(eventName, handler, opts...)
 }
 
-// Publish sends an event to all registered handlers and stores it if event store is configured
-func (m *Mediator) Publish(ctx context.Context, event Event) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// MustSubscribe is like TrySubscribe but panics on an error, so typos in the
+// event names of a program surface at startup
+func (m *Mediator) MustSubscribe(eventName string, handler EventHandler, opts ...SubscribeOption) *Subscription {
+	sub, err := m.TrySubscribe(eventName, handler, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("mediator: %v", err))
+	}
+	return sub
+}
 
-	handlers, exists := m.subscribers[event.Name]
-	if !exists {
-		return fmt.Errorf("no handlers for event: %s", event.Name)
+// TrySubscribe adds an event handler like Subscribe, but returns an error
+// wrapping ErrUnknownEvent for an event name ValidateEventName rejects with
+// WithStrictEvents instead of panicking
+func (m *Mediator) TrySubscribe(eventName string, handler EventHandler, opts ...SubscribeOption) (*Subscription, error) {
+	if err := m.ValidateEventName(eventName); err != nil {
+		return nil, fmt.Errorf("cannot subscribe to %w", err)
+	}
+	sub := &Subscription{
+		eventName: eventName,
+		name:      handlerName(handler),
+		handler:   handler,
+		mediator:  m,
+		createdAt: m.now(),
+	}
+	for _, opt := range opts {
+		opt(sub)
 	}
 
-	var errs []error
-	for _, handler := range handlers {
-		if err := handler(ctx, event); err != nil {
-			errs = append(errs, err)
+	duplicate := false
+	m.subscribers.update(eventName, func(subs []*Subscription) ([]*Subscription, bool) {
+		for _, existing := range subs {
+			duplicate = duplicate || (existing.name == sub.name && sub.name != "")
 		}
+		return insertByPriority(subs, sub), true
+	})
+	if duplicate {
+		m.logf("handler %s is subscribed to %s more than once; dead letters and deduplication cannot tell them apart", sub.name, eventName)
 	}
 
-	// Store event if event store is configured
-	if m.eventStore != nil {
-		if err := m.eventStore.StoreEvent(ctx, event); err != nil {
-			errs = append(errs, fmt.Errorf("failed to store event: %w", err))
+	m.receive(eventName)
+	return sub, nil
+}
+
+// stamp fills in the envelope fields Publish is responsible for, timestamping
+// the event with now
+func (e Event) stamp(now time.Time) Event {
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = now
+	}
+	if e.CorrelationID == "" {
+		e.CorrelationID = e.ID
+	}
+	return e
+}
+
+// Publish sends an event to all registered handlers and stores it if event store is configured.
+// When handlers or the store fail, the returned error is a *PublishError.
+func (m *Mediator) Publish(ctx context.Context, event Event) error {
+	return m.PublishWith(ctx, event)
+}
+
+// GetEvents retrieves events from the event store, narrowed by opts. Payloads are
+// rehydrated into the type registered with WithTypeRegistry or bound by SubscribeTyped.
+func (m *Mediator) GetEvents(ctx context.Context, eventName string, limit int64, opts ...QueryOption) ([]map[string]interface{}, error) {
+	ctx = m.storeContext(ctx)
+	if len(opts) > 0 {
+		stored, err := m.ReadEvents(ctx, eventName, limit, opts...)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]map[string]interface{}, len(stored))
+		for i, event := range stored {
+			records[i] = event.record()
 		}
+		return records, nil
 	}
 
-	if len(errs) > 0 {
-		return fmt.Errorf("errors in event handlers: %v", errs)
+	m.mu.RLock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
+
+	if eventStore == nil {
+		return nil, fmt.Errorf("no event store configured")
 	}
 
-	return nil
+	var events []map[string]interface{}
+	err := m.observeStore(ctx, StoreRead, eventName, func() error {
+		var err error
+		events, err = eventStore.GetEvents(ctx, namespacedName(m.namespaceOf(ctx), eventName), limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		payload, err := m.rehydrate(eventName, event["payload"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to rehydrate payload: %w", err)
+		}
+		event["payload"] = payload
+	}
+	return events, nil
 }
 
-// GetEvents retrieves events from the event store
-func (m *Mediator) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+// ReadEvents retrieves events by event name as typed records, narrowed by opts,
+// with payloads rehydrated like GetEvents
+func (m *Mediator) ReadEvents(ctx context.Context, eventName string, limit int64, opts ...QueryOption) ([]StoredEvent, error) {
+	ctx = m.storeContext(ctx)
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
 
-	if m.eventStore == nil {
+	if eventStore == nil {
 		return nil, fmt.Errorf("no event store configured")
 	}
 
-	return m.eventStore.GetEvents(ctx, eventName, limit)
+	streamName := namespacedName(m.namespaceOf(ctx), eventName)
+	var events []StoredEvent
+	err := m.observeStore(ctx, StoreRead, eventName, func() error {
+		var err error
+		if len(opts) > 0 {
+			events, err = queryEvents(ctx, eventStore, streamName, newEventQuery(opts), limit)
+		} else {
+			events, err = AsEventStoreV2(eventStore).ReadEvents(ctx, streamName, limit)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.rehydrateStored(eventName, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// rehydrateStored rehydrates the payloads of events read from the stream of eventName
+func (m *Mediator) rehydrateStored(eventName string, events []StoredEvent) error {
+	for i := range events {
+		// Report the name events were published with, not their namespaced stream
+		events[i].Name = eventName
+
+		var err error
+		if events[i].Payload, err = m.rehydrateStoredPayload(eventName, events[i]); err != nil {
+			return fmt.Errorf("failed to rehydrate payload: %w", err)
+		}
+	}
+	return nil
 }
 
 // ClearEvents removes all events for a given event name
 func (m *Mediator) ClearEvents(ctx context.Context, eventName string) error {
+	ctx = m.storeContext(ctx)
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
 
-	if m.eventStore == nil {
+	if eventStore == nil {
 		return fmt.Errorf("no event store configured")
 	}
 
-	return m.eventStore.ClearEvents(ctx, eventName)
+	return m.observeStore(ctx, StoreClear, eventName, func() error {
+		return eventStore.ClearEvents(ctx, namespacedName(m.namespaceOf(ctx), eventName))
+	})
 }
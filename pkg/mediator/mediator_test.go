@@ -45,23 +45,21 @@ func TestGetMediator(t *testing.T) {
 }
 
 func TestMediator_Subscribe(t *testing.T) {
-	m := &Mediator{
-		subscribers: make(map[string][]EventHandler),
-	}
+	m := &Mediator{}
 
 	eventName := "test.event"
 	handler := func(ctx context.Context, event Event) error { return nil }
 
 	// Test subscribing single handler
 	m.Subscribe(eventName, handler)
-	if len(m.subscribers[eventName]) != 1 {
-		t.Errorf("Subscribe() failed to add handler, got %d handlers", len(m.subscribers[eventName]))
+	if len(m.subscribers.load()[eventName]) != 1 {
+		t.Errorf("Subscribe() failed to add handler, got %d handlers", len(m.subscribers.load()[eventName]))
 	}
 
 	// Test subscribing multiple handlers
 	m.Subscribe(eventName, handler)
-	if len(m.subscribers[eventName]) != 2 {
-		t.Errorf("Subscribe() failed to add multiple handlers, got %d handlers", len(m.subscribers[eventName]))
+	if len(m.subscribers.load()[eventName]) != 2 {
+		t.Errorf("Subscribe() failed to add multiple handlers, got %d handlers", len(m.subscribers.load()[eventName]))
 	}
 }
 
@@ -77,9 +75,7 @@ func TestMediator_Publish(t *testing.T) {
 			name:      "successful publish",
 			eventName: "test.success",
 			setupMock: func() *Mediator {
-				m := &Mediator{
-					subscribers: make(map[string][]EventHandler),
-				}
+				m := &Mediator{}
 				m.Subscribe("test.success", func(ctx context.Context, event Event) error {
 					return nil
 				})
@@ -91,9 +87,7 @@ func TestMediator_Publish(t *testing.T) {
 			name:      "no handlers",
 			eventName: "test.nohandlers",
 			setupMock: func() *Mediator {
-				return &Mediator{
-					subscribers: make(map[string][]EventHandler),
-				}
+				return &Mediator{}
 			},
 			wantErr:    true,
 			errMessage: "no handlers for event: test.nohandlers",
@@ -102,9 +96,7 @@ func TestMediator_Publish(t *testing.T) {
 			name:      "handler error",
 			eventName: "test.error",
 			setupMock: func() *Mediator {
-				m := &Mediator{
-					subscribers: make(map[string][]EventHandler),
-				}
+				m := &Mediator{}
 				m.Subscribe("test.error", func(ctx context.Context, event Event) error {
 					return errors.New("handler error")
 				})
@@ -117,9 +109,7 @@ func TestMediator_Publish(t *testing.T) {
 			name:      "multiple handlers with error",
 			eventName: "test.multiple",
 			setupMock: func() *Mediator {
-				m := &Mediator{
-					subscribers: make(map[string][]EventHandler),
-				}
+				m := &Mediator{}
 				m.Subscribe("test.multiple", func(ctx context.Context, event Event) error {
 					return nil
 				})
@@ -154,3 +144,39 @@ func TestMediator_Publish(t *testing.T) {
 		})
 	}
 }
+
+func TestMediator_PublishStampsEnvelope(t *testing.T) {
+	m := NewMediator()
+
+	var received Event
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		received = event
+		return nil
+	})
+
+	// Test generated envelope fields
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if received.ID == "" || received.Timestamp.IsZero() {
+		t.Errorf("Publish() did not stamp ID and Timestamp: %+v", received)
+	}
+	if received.CorrelationID != received.ID {
+		t.Errorf("CorrelationID = %s, want event ID %s", received.CorrelationID, received.ID)
+	}
+
+	// Test preserving caller supplied envelope fields
+	event := Event{
+		Name:          "test.event",
+		ID:            "evt-1",
+		CorrelationID: "corr-1",
+		CausationID:   "evt-0",
+		Metadata:      map[string]string{"tenant": "acme"},
+	}
+	if err := m.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if received.ID != "evt-1" || received.CorrelationID != "corr-1" || received.CausationID != "evt-0" || received.Metadata["tenant"] != "acme" {
+		t.Errorf("Publish() overwrote envelope fields: %+v", received)
+	}
+}
@@ -0,0 +1,237 @@
+package mediatortest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Matcher checks an event, returning an error describing why it doesn't match
+type Matcher func(event mediator.Event) error
+
+// WithPayload matches events whose payload equals want. Payloads of
+// different types are compared by their JSON encoding, so a struct matches
+// the map a store decoded it into.
+func WithPayload(want interface{}) Matcher {
+	return func(event mediator.Event) error {
+		if reflect.DeepEqual(event.Payload, want) {
+			return nil
+		}
+		got, gotErr := normalize(event.Payload)
+		expected, wantErr := normalize(want)
+		if gotErr == nil && wantErr == nil && reflect.DeepEqual(got, expected) {
+			return nil
+		}
+		return fmt.Errorf("payload is %#v, want %#v", event.Payload, want)
+	}
+}
+
+// WithPayloadField matches events whose JSON payload has want at field, a
+// dot-separated path such as "customer.id"
+func WithPayloadField(field string, want interface{}) Matcher {
+	return func(event mediator.Event) error {
+		payload, err := normalize(event.Payload)
+		if err != nil {
+			return fmt.Errorf("payload is not JSON: %w", err)
+		}
+		got, ok := lookup(payload, field)
+		if !ok {
+			return fmt.Errorf("payload has no field %s", field)
+		}
+		expected, err := normalize(want)
+		if err != nil || !reflect.DeepEqual(got, expected) {
+			return fmt.Errorf("payload field %s is %#v, want %#v", field, got, want)
+		}
+		return nil
+	}
+}
+
+// WithCorrelationID matches events of a correlation
+func WithCorrelationID(id string) Matcher {
+	return func(event mediator.Event) error {
+		if event.CorrelationID != id {
+			return fmt.Errorf("correlation ID is %q, want %q", event.CorrelationID, id)
+		}
+		return nil
+	}
+}
+
+// WithCausationID matches events caused by the event with an ID
+func WithCausationID(id string) Matcher {
+	return func(event mediator.Event) error {
+		if event.CausationID != id {
+			return fmt.Errorf("causation ID is %q, want %q", event.CausationID, id)
+		}
+		return nil
+	}
+}
+
+// WithMetadata matches events annotated with key set to value
+func WithMetadata(key, value string) Matcher {
+	return func(event mediator.Event) error {
+		if got, ok := event.Metadata[key]; !ok || got != value {
+			return fmt.Errorf("metadata %s is %q, want %q", key, got, value)
+		}
+		return nil
+	}
+}
+
+// WithNamespace matches events of a namespace
+func WithNamespace(namespace string) Matcher {
+	return func(event mediator.Event) error {
+		if event.Namespace != namespace {
+			return fmt.Errorf("namespace is %q, want %q", event.Namespace, namespace)
+		}
+		return nil
+	}
+}
+
+// Match matches events fn accepts; description names the check in failures
+func Match(description string, fn func(event mediator.Event) bool) Matcher {
+	return func(event mediator.Event) error {
+		if !fn(event) {
+			return fmt.Errorf("does not match %s", description)
+		}
+		return nil
+	}
+}
+
+// match returns nil when event satisfies every matcher, or their failures
+func match(event mediator.Event, matchers []Matcher) []string {
+	var failures []string
+	for _, matcher := range matchers {
+		if err := matcher(event); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	return failures
+}
+
+// AssertPublished checks that an event of an event name matching every
+// matcher was published, and returns the first such event
+func (r *Recorder) AssertPublished(t testing.TB, eventName string, matchers ...Matcher) (mediator.Event, bool) {
+	t.Helper()
+	events := r.Events(eventName)
+	var mismatches []string
+	for _, event := range events {
+		failures := match(event, matchers)
+		if len(failures) == 0 {
+			return event, true
+		}
+		mismatches = append(mismatches, fmt.Sprintf("  %s: %s", event.ID, strings.Join(failures, "; ")))
+	}
+
+	if len(events) == 0 {
+		t.Errorf("expected %s to be published, but it was not; published: %s", eventName, r.names())
+	} else {
+		t.Errorf("expected %s to be published matching, but none of %d matched:\n%s", eventName, len(events), strings.Join(mismatches, "\n"))
+	}
+	return mediator.Event{}, false
+}
+
+// AssertNotPublished checks that no event of an event name matching every
+// matcher was published
+func (r *Recorder) AssertNotPublished(t testing.TB, eventName string, matchers ...Matcher) bool {
+	t.Helper()
+	for _, event := range r.Events(eventName) {
+		if len(match(event, matchers)) == 0 {
+			t.Errorf("expected %s not to be published, but event %s was", eventName, event.ID)
+			return false
+		}
+	}
+	return true
+}
+
+// AssertPublishedCount checks how many events of an event name were published
+func (r *Recorder) AssertPublishedCount(t testing.TB, eventName string, want int) bool {
+	t.Helper()
+	if got := len(r.Events(eventName)); got != want {
+		t.Errorf("expected %s to be published %d times, got %d", eventName, want, got)
+		return false
+	}
+	return true
+}
+
+// AssertHandledBy checks that a handler ran for an event of an event name
+// matching every matcher and succeeded
+func (r *Recorder) AssertHandledBy(t testing.TB, eventName, handlerName string, matchers ...Matcher) bool {
+	t.Helper()
+	var ran []string
+	var lastErr error
+	for _, h := range r.Handled() {
+		if h.Event.Name != eventName {
+			continue
+		}
+		ran = append(ran, h.HandlerName)
+		if h.HandlerName != handlerName || len(match(h.Event, matchers)) > 0 {
+			continue
+		}
+		if h.Err == nil {
+			return true
+		}
+		lastErr = h.Err
+	}
+
+	if lastErr != nil {
+		t.Errorf("expected %s to handle %s, but it failed: %v", handlerName, eventName, lastErr)
+	} else {
+		t.Errorf("expected %s to handle %s; handlers run: %v", handlerName, eventName, ran)
+	}
+	return false
+}
+
+// AssertNotHandledBy checks that a handler didn't run for an event of an event name
+func (r *Recorder) AssertNotHandledBy(t testing.TB, eventName, handlerName string) bool {
+	t.Helper()
+	for _, h := range r.Handled() {
+		if h.Event.Name == eventName && h.HandlerName == handlerName {
+			t.Errorf("expected %s not to handle %s, but it handled event %s", handlerName, eventName, h.Event.ID)
+			return false
+		}
+	}
+	return true
+}
+
+// names returns the distinct event names published, for failure messages
+func (r *Recorder) names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, p := range r.Published() {
+		if !seen[p.Event.Name] {
+			seen[p.Event.Name] = true
+			names = append(names, p.Event.Name)
+		}
+	}
+	return names
+}
+
+// normalize converts v to its generic JSON form
+func normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// lookup returns the value at a dot-separated path of a generic JSON value
+func lookup(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
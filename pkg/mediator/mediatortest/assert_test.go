@@ -0,0 +1,123 @@
+package mediatortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// fakeT records the failures reported by assertions
+type fakeT struct {
+	testing.TB
+	failures []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+type product struct {
+	ID    string `json:"id"`
+	Price int    `json:"price"`
+}
+
+// recorded returns a recorder that published a product.created event
+func recorded(t *testing.T) *Recorder {
+	t.Helper()
+	r := NewRecorder()
+	r.Subscribe("product.created", func(ctx context.Context, event mediator.Event) error {
+		return nil
+	}, mediator.WithHandlerName("index"))
+	r.Subscribe("product.created", func(ctx context.Context, event mediator.Event) error {
+		return errors.New("boom")
+	}, mediator.WithHandlerName("notify"))
+
+	r.Publish(context.Background(), mediator.Event{
+		Name:          "product.created",
+		Payload:       product{ID: "p-1", Price: 10},
+		CorrelationID: "c-1",
+		Metadata:      map[string]string{"tenant": "acme"},
+	})
+	return r
+}
+
+func TestRecorder_AssertPublished(t *testing.T) {
+	r := recorded(t)
+
+	tests := []struct {
+		name     string
+		event    string
+		matchers []Matcher
+		pass     bool
+		failure  string
+	}{
+		{"by name", "product.created", nil, true, ""},
+		{"payload", "product.created", []Matcher{WithPayload(product{ID: "p-1", Price: 10})}, true, ""},
+		{"payload as map", "product.created", []Matcher{WithPayload(map[string]interface{}{"id": "p-1", "price": 10})}, true, ""},
+		{"payload field", "product.created", []Matcher{WithPayloadField("price", 10)}, true, ""},
+		{"envelope", "product.created", []Matcher{WithCorrelationID("c-1"), WithMetadata("tenant", "acme")}, true, ""},
+		{"custom", "product.created", []Matcher{Match("cheap", func(e mediator.Event) bool { return e.Payload.(product).Price < 20 })}, true, ""},
+		{"wrong payload", "product.created", []Matcher{WithPayloadField("price", 11)}, false, "price is"},
+		{"missing field", "product.created", []Matcher{WithPayloadField("sku", "x")}, false, "no field sku"},
+		{"not published", "product.deleted", nil, false, "published: [product.created]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeT{}
+			_, ok := r.AssertPublished(ft, tt.event, tt.matchers...)
+			if ok != tt.pass || (len(ft.failures) == 0) != tt.pass {
+				t.Fatalf("AssertPublished() = %v with failures %v, want %v", ok, ft.failures, tt.pass)
+			}
+			if !tt.pass && !strings.Contains(ft.failures[0], tt.failure) {
+				t.Errorf("failure = %q, want it to mention %q", ft.failures[0], tt.failure)
+			}
+		})
+	}
+
+	ft := &fakeT{}
+	if r.AssertNotPublished(ft, "product.created", WithCorrelationID("c-1")) || !r.AssertNotPublished(ft, "product.created", WithCorrelationID("c-2")) {
+		t.Errorf("AssertNotPublished() failures = %v, want one for c-1 only", ft.failures)
+	}
+	if !r.AssertPublishedCount(ft, "product.created", 1) || r.AssertPublishedCount(ft, "product.created", 2) {
+		t.Errorf("AssertPublishedCount() failures = %v, want one for 2", ft.failures)
+	}
+}
+
+func TestRecorder_AssertHandledBy(t *testing.T) {
+	r := recorded(t)
+
+	tests := []struct {
+		name     string
+		handler  string
+		matchers []Matcher
+		pass     bool
+		failure  string
+	}{
+		{"succeeded", "index", nil, true, ""},
+		{"with matcher", "index", []Matcher{WithCorrelationID("c-1")}, true, ""},
+		{"failed", "notify", nil, false, "it failed: boom"},
+		{"not run", "archive", nil, false, "handlers run: [index notify]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := &fakeT{}
+			if ok := r.AssertHandledBy(ft, "product.created", tt.handler, tt.matchers...); ok != tt.pass {
+				t.Fatalf("AssertHandledBy() = %v with failures %v, want %v", ok, ft.failures, tt.pass)
+			}
+			if !tt.pass && !strings.Contains(ft.failures[0], tt.failure) {
+				t.Errorf("failure = %q, want it to mention %q", ft.failures[0], tt.failure)
+			}
+		})
+	}
+
+	ft := &fakeT{}
+	if !r.AssertNotHandledBy(ft, "product.created", "archive") || r.AssertNotHandledBy(ft, "product.created", "index") {
+		t.Errorf("AssertNotHandledBy() failures = %v, want one for index", ft.failures)
+	}
+}
@@ -0,0 +1,149 @@
+package mediatortest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Clock is a mediator.Clock whose time only moves when a test advances it.
+// Advance fires the timers it passes, such as retry backoffs and scheduled
+// events; with AutoAdvance every timer fires as soon as it is created,
+// moving the clock to its deadline.
+type Clock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []*timer
+	waits   []time.Duration
+	auto    bool
+}
+
+// NewClock creates a clock stopped at start
+func NewClock(start time.Time) *Clock {
+	c := &Clock{now: start}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Deterministic returns an option running a mediator in test mode: PublishAsync
+// publishes on the calling goroutine, and timestamps, retry backoff and
+// schedules follow clock
+func Deterministic(clock *Clock) mediator.Option {
+	return func(m *mediator.Mediator) {
+		mediator.WithClock(clock)(m)
+		mediator.WithSynchronousDispatch()(m)
+	}
+}
+
+// Now returns the clock's time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer firing once the clock has advanced by d
+func (c *Clock) NewTimer(d time.Duration) mediator.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.waits = append(c.waits, d)
+	if c.auto || d <= 0 {
+		if t.deadline.After(c.now) {
+			c.now = t.deadline
+		}
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.changed.Broadcast()
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers due by then in
+// deadline order. Timers created by their owners in response fire on a
+// later Advance.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].deadline.Before(c.timers[j].deadline) })
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(end) {
+			pending = append(pending, t)
+			continue
+		}
+		c.now = t.deadline
+		t.ch <- t.deadline
+	}
+	c.timers = pending
+	c.now = end
+	c.changed.Broadcast()
+}
+
+// AutoAdvance sets whether timers fire as soon as they are created, so code
+// backing off, such as retries, runs without a test advancing the clock.
+// Leave it off while a Scheduler runs, whose timers would then fire forever.
+func (c *Clock) AutoAdvance(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auto = on
+}
+
+// BlockUntil waits until n timers are pending, e.g. until a scheduler or a
+// retrying handler started by another goroutine waits on the clock
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
+
+// Pending returns the number of timers waiting to fire
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Waits returns the durations of every timer created, in order, e.g. to
+// check the backoff between retries
+func (c *Clock) Waits() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+// timer is a timer of a Clock
+type timer struct {
+	clock    *Clock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// C returns the channel the time is sent on when the timer fires
+func (t *timer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop removes the timer from the clock, reporting whether it was pending
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
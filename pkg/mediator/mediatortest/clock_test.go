@@ -0,0 +1,290 @@
+package mediatortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClock_Advance(t *testing.T) {
+	clock := NewClock(start)
+	late := clock.NewTimer(2 * time.Second)
+	early := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	if !stopped.Stop() || stopped.Stop() {
+		t.Error("Stop() should report a pending timer once")
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	select {
+	case fired := <-early.C():
+		if !fired.Equal(start.Add(time.Second)) {
+			t.Errorf("timer fired at %v, want its deadline", fired)
+		}
+	default:
+		t.Fatal("expected the due timer to fire")
+	}
+	select {
+	case <-late.C():
+		t.Fatal("expected the later timer to wait")
+	case <-stopped.C():
+		t.Fatal("expected the stopped timer not to fire")
+	default:
+	}
+	if clock.Pending() != 1 || !clock.Now().Equal(start.Add(1500*time.Millisecond)) {
+		t.Errorf("Pending() = %d at %v, want 1 at 1.5s", clock.Pending(), clock.Now())
+	}
+}
+
+func TestClock_RetryBackoff(t *testing.T) {
+	clock := NewClock(start)
+	clock.AutoAdvance(true)
+	dlq := mediator.NewMemoryDeadLetterQueue()
+	r := NewRecorder(Deterministic(clock), mediator.WithDeadLetterQueue(dlq), mediator.WithRetryPolicy(mediator.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		Multiplier:     2,
+	}))
+	r.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		return errors.New("unavailable")
+	}, mediator.WithHandlerName("charge"))
+
+	// No real time passes while the handler backs off
+	r.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+
+	if waits := clock.Waits(); !reflect.DeepEqual(waits, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("Waits() = %v, want 1s then 2s", waits)
+	}
+	if len(r.Handled()) != 3 {
+		t.Errorf("handler ran %d times, want 3", len(r.Handled()))
+	}
+	letters, _ := dlq.List(context.Background(), "order.placed", 0)
+	if len(letters) != 1 || !letters[0].FailedAt.Equal(start.Add(3*time.Second)) {
+		t.Errorf("dead letters = %+v, want one failed at 3s", letters)
+	}
+}
+
+func TestClock_Scheduler(t *testing.T) {
+	clock := NewClock(start)
+	r := NewRecorder(Deterministic(clock))
+	r.Sink("report.due")
+
+	scheduler := mediator.NewScheduler(r.Mediator)
+	scheduler.Every(time.Minute, mediator.Event{Name: "report.due"})
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	for i := 0; i < 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+	}
+	// The scheduler waits again once it has published
+	clock.BlockUntil(1)
+
+	events := r.Events("report.due")
+	if len(events) != 3 {
+		t.Fatalf("published %d events, want 3", len(events))
+	}
+	for i, event := range events {
+		if want := start.Add(time.Duration(i+1) * time.Minute); !event.Timestamp.Equal(want) {
+			t.Errorf("event %d timestamp = %v, want %v", i, event.Timestamp, want)
+		}
+	}
+
+	// Test Close stops the scheduler
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if pending := clock.Pending(); pending != 0 {
+		t.Errorf("Pending() = %d after Close(), want the scheduler stopped", pending)
+	}
+}
+
+func TestClock_RetentionJanitor(t *testing.T) {
+	clock := NewClock(start)
+	store := NewEventStore()
+	store.SetRetention(mediator.Retention{Default: mediator.KeepLast(1)})
+	m := mediator.NewMediator(mediator.WithClock(clock), mediator.WithEventStore(store), mediator.WithRetentionJanitor(time.Hour))
+
+	ctx := context.Background()
+	store.StoreEvent(ctx, mediator.Event{Name: "order.placed", ID: "evt-1"})
+	store.StoreEvent(ctx, mediator.Event{Name: "order.placed", ID: "evt-2"})
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	// The janitor waits again once it has enforced retention
+	clock.BlockUntil(1)
+	if events := store.Events("order.placed"); len(events) != 1 || events[0].ID != "evt-2" {
+		t.Errorf("Events() = %v, want evt-2 only", events)
+	}
+
+	// Test Close stops the janitor
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if pending := clock.Pending(); pending != 0 {
+		t.Errorf("Pending() = %d after Close(), want the janitor stopped", pending)
+	}
+}
+
+// timedSaga returns a saga awaiting sku.created for a minute after
+// product.created, appending the compensations it runs to compensated
+func timedSaga(m *mediator.Mediator, store mediator.SagaStore, compensated *[]string) *mediator.Saga[string] {
+	compensation := func(name string) mediator.SagaCompensation[string] {
+		return func(ctx context.Context, saga *mediator.SagaInstance[string]) error {
+			*compensated = append(*compensated, name)
+			return nil
+		}
+	}
+	return mediator.NewSaga[string](m, "product-onboarding", mediator.SagaConfig{Store: store}).
+		StartOn("product.created", func(ctx context.Context, saga *mediator.SagaInstance[string], event mediator.Event) error {
+			saga.Compensate("archive-product")
+			saga.TransitionTo("awaiting-sku")
+			return nil
+		}).
+		On("awaiting-sku", "sku.reserved", func(ctx context.Context, saga *mediator.SagaInstance[string], event mediator.Event) error {
+			saga.Compensate("release-sku")
+			return nil
+		}).
+		On("awaiting-sku", "sku.created", func(ctx context.Context, saga *mediator.SagaInstance[string], event mediator.Event) error {
+			saga.Complete()
+			return nil
+		}).
+		Timeout("awaiting-sku", time.Minute, nil).
+		Compensation("archive-product", compensation("archive-product")).
+		Compensation("release-sku", compensation("release-sku"))
+}
+
+func TestClock_SagaTimeout(t *testing.T) {
+	tests := []struct {
+		name            string
+		handler         mediator.SagaHandler[string]
+		wantStatus      mediator.SagaStatus
+		wantState       string
+		wantCompensated []string
+		wantReminder    bool
+	}{
+		{
+			name:            "compensates",
+			wantStatus:      mediator.SagaCompensated,
+			wantState:       "awaiting-sku",
+			wantCompensated: []string{"release-sku", "archive-product"},
+		},
+		{
+			name: "handler",
+			handler: func(ctx context.Context, saga *mediator.SagaInstance[string], event mediator.Event) error {
+				saga.Emit(mediator.Event{Name: "sku.reminder"})
+				saga.TransitionTo("reminded")
+				return nil
+			},
+			wantStatus:   mediator.SagaActive,
+			wantState:    "reminded",
+			wantReminder: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewClock(start)
+			r := NewRecorder(Deterministic(clock))
+			defer r.Close()
+			r.Sink("sku.reminder")
+
+			var compensated []string
+			saga := timedSaga(r.Mediator, mediator.NewMemorySagaStore(), &compensated)
+			if tt.handler != nil {
+				saga.Timeout("awaiting-sku", time.Minute, tt.handler)
+			}
+			if err := saga.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			ctx := context.Background()
+			r.Publish(ctx, mediator.Event{Name: "product.created", CorrelationID: "p-1"})
+			r.Publish(ctx, mediator.Event{Name: "sku.reserved", CorrelationID: "p-1"})
+			clock.BlockUntil(1)
+			clock.Advance(time.Minute)
+			// The saga checks timeouts again once it has fired them
+			clock.BlockUntil(1)
+
+			instance, err := saga.Load(ctx, "p-1")
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if instance.Status != tt.wantStatus || instance.State != tt.wantState {
+				t.Errorf("Load() = %+v, want %s in state %s", instance, tt.wantStatus, tt.wantState)
+			}
+			if tt.wantStatus == mediator.SagaCompensated && instance.Error != "timed out in state awaiting-sku" {
+				t.Errorf("Error = %q, want the timeout", instance.Error)
+			}
+			if !reflect.DeepEqual(compensated, tt.wantCompensated) {
+				t.Errorf("compensated = %v, want %v", compensated, tt.wantCompensated)
+			}
+			if tt.wantReminder {
+				r.AssertPublished(t, "sku.reminder", WithCorrelationID("p-1"))
+			} else {
+				r.AssertNotPublished(t, "sku.reminder")
+			}
+		})
+	}
+}
+
+func TestClock_SagaRestart(t *testing.T) {
+	const instances = 3
+	clock := NewClock(start)
+	store := mediator.NewMemorySagaStore()
+	var compensated []string
+
+	first := mediator.NewMediator(mediator.WithClock(clock))
+	if err := timedSaga(first, store, &compensated).Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	for i := 0; i < instances; i++ {
+		first.Publish(context.Background(), mediator.Event{Name: "product.created", CorrelationID: fmt.Sprintf("p-%d", i)})
+	}
+	first.Close()
+
+	// Test a restarted saga fires the timeouts kept in its store
+	second := mediator.NewMediator(mediator.WithClock(clock))
+	defer second.Close()
+	saga := timedSaga(second, store, &compensated)
+	if err := saga.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+
+	for i := 0; i < instances; i++ {
+		id := fmt.Sprintf("p-%d", i)
+		if instance, err := saga.Load(context.Background(), id); err != nil || instance.Status != mediator.SagaCompensated {
+			t.Errorf("Load(%s) = %+v, %v; want compensated", id, instance, err)
+		}
+	}
+}
+
+func TestDeterministic_PublishAsync(t *testing.T) {
+	r := NewRecorder(Deterministic(NewClock(start)))
+	r.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		return nil
+	}, mediator.WithHandlerName("ship"))
+
+	if err := r.PublishAsync(context.Background(), mediator.Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+	// Handled before PublishAsync returned
+	r.AssertHandledBy(t, "order.placed", "ship")
+	if event, _ := r.AssertPublished(t, "order.placed"); !event.Timestamp.Equal(start) {
+		t.Errorf("event timestamp = %v, want the clock's time", event.Timestamp)
+	}
+
+	r.Close()
+	if err := r.PublishAsync(context.Background(), mediator.Event{Name: "order.placed"}); !errors.Is(err, mediator.ErrMediatorClosed) {
+		t.Errorf("PublishAsync() after Close() error = %v, want ErrMediatorClosed", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package mediatortest provides helpers for unit testing code built on the
+// mediator: a Recorder capturing published events and handler runs, assertions
+// on them, an in-memory EventStore and a Clock advanced by hand. Give each test
+// its own Recorder instead of the global mediator.New() singleton.
+package mediatortest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// SinkHandlerName names the handlers subscribed by Recorder.Sink
+const SinkHandlerName = "mediatortest.sink"
+
+// Publish is a publish seen by a Recorder
+type Publish struct {
+	// Event is the event as dispatched, with its ID and timestamp filled in
+	Event mediator.Event
+	// Err is the error Publish returned
+	Err error
+}
+
+// Handling is a handler run seen by a Recorder; a handler retried after
+// failing is recorded once per attempt
+type Handling struct {
+	Event       mediator.Event
+	HandlerName string
+	Err         error
+}
+
+// Recorder is a mediator recording every publish and handler run. It embeds
+// *mediator.Mediator, so code under test can be given recorder.Mediator.
+type Recorder struct {
+	*mediator.Mediator
+
+	mu        sync.Mutex
+	published []Publish
+	handled   []Handling
+}
+
+// NewRecorder creates a recording mediator configured with opts
+func NewRecorder(opts ...mediator.Option) *Recorder {
+	r := &Recorder{Mediator: mediator.NewMediator(opts...)}
+	r.OnAfterPublish(r.recordPublish)
+	r.Use(r.recordHandling)
+	return r
+}
+
+// Sink subscribes a handler that accepts and ignores events to each event
+// name, so code publishing events no test subscribes to doesn't fail with
+// mediator.ErrNoHandlers
+func (r *Recorder) Sink(eventNames ...string) {
+	for _, name := range eventNames {
+		r.Subscribe(name, func(ctx context.Context, event mediator.Event) error {
+			return nil
+		}, mediator.WithHandlerName(SinkHandlerName))
+	}
+}
+
+// recordPublish records the result of a publish
+func (r *Recorder) recordPublish(ctx context.Context, event mediator.Event, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, Publish{Event: event, Err: err})
+}
+
+// recordHandling records a handler run
+func (r *Recorder) recordHandling(ctx context.Context, event mediator.Event, next mediator.EventHandler) error {
+	err := next(ctx, event)
+	name, _ := mediator.HandlerNameFromContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handled = append(r.handled, Handling{Event: event, HandlerName: name, Err: err})
+	return err
+}
+
+// Published returns every publish recorded, in order
+func (r *Recorder) Published() []Publish {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Publish(nil), r.published...)
+}
+
+// Events returns the events published with an event name, in order
+func (r *Recorder) Events(eventName string) []mediator.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []mediator.Event
+	for _, p := range r.published {
+		if p.Event.Name == eventName {
+			events = append(events, p.Event)
+		}
+	}
+	return events
+}
+
+// Handled returns every handler run recorded, in order
+func (r *Recorder) Handled() []Handling {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Handling(nil), r.handled...)
+}
+
+// Reset forgets the recorded publishes and handler runs
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = nil
+	r.handled = nil
+}
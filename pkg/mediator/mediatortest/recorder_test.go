@@ -0,0 +1,61 @@
+package mediatortest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	r.Subscribe("order.placed", func(ctx context.Context, event mediator.Event) error {
+		return r.Publish(ctx, mediator.Event{Name: "stock.reserved"})
+	}, mediator.WithHandlerName("reserve-stock"))
+	r.Subscribe("stock.reserved", func(ctx context.Context, event mediator.Event) error {
+		return errors.New("out of stock")
+	}, mediator.WithHandlerName("ship"))
+
+	r.Publish(context.Background(), mediator.Event{Name: "order.placed"})
+
+	published := r.Published()
+	if len(published) != 2 {
+		t.Fatalf("Published() = %d publishes, want 2", len(published))
+	}
+	// The nested publish finishes first
+	if published[0].Event.Name != "stock.reserved" || published[0].Err == nil {
+		t.Errorf("first publish = %+v, want the failed stock.reserved", published[0])
+	}
+	if published[1].Event.ID == "" || published[1].Event.Timestamp.IsZero() {
+		t.Errorf("recorded event = %+v, want its ID and timestamp filled in", published[1].Event)
+	}
+	if events := r.Events("stock.reserved"); len(events) != 1 || events[0].CausationID != published[1].Event.ID {
+		t.Errorf("Events() = %+v, want stock.reserved caused by order.placed", events)
+	}
+
+	handled := r.Handled()
+	if len(handled) != 2 || handled[0].HandlerName != "ship" || handled[0].Err == nil || handled[1].HandlerName != "reserve-stock" {
+		t.Errorf("Handled() = %+v, want ship failing then reserve-stock", handled)
+	}
+
+	r.Reset()
+	if len(r.Published()) != 0 || len(r.Handled()) != 0 {
+		t.Error("expected Reset() to forget every record")
+	}
+}
+
+func TestRecorder_Sink(t *testing.T) {
+	r := NewRecorder()
+	if err := r.Publish(context.Background(), mediator.Event{Name: "audit.logged"}); !errors.Is(err, mediator.ErrNoHandlers) {
+		t.Fatalf("Publish() error = %v, want ErrNoHandlers", err)
+	}
+
+	r.Sink("audit.logged")
+	if err := r.Publish(context.Background(), mediator.Event{Name: "audit.logged"}); err != nil {
+		t.Errorf("Publish() after Sink() error = %v", err)
+	}
+	if len(r.Events("audit.logged")) != 2 {
+		t.Errorf("Events() = %d, want failed publishes recorded too", len(r.Events("audit.logged")))
+	}
+}
@@ -0,0 +1,283 @@
+package mediatortest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// EventStore is an in-memory mediator.EventStore for tests. It keeps events
+// as published, so payloads keep their Go types, and can be made to fail.
+// It is also a mediator.StreamEventStore.
+type EventStore struct {
+	mu        sync.Mutex
+	events    map[string][]mediator.Event
+	streams   map[string][]mediator.StoredEvent
+	err       error
+	retention mediator.Retention
+}
+
+// NewEventStore creates an empty in-memory event store
+func NewEventStore() *EventStore {
+	return &EventStore{
+		events:  make(map[string][]mediator.Event),
+		streams: make(map[string][]mediator.StoredEvent),
+	}
+}
+
+// FailWith makes every following call return err; nil makes calls succeed again
+func (s *EventStore) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// SetRetention sets the retention policies EnforceRetention applies
+func (s *EventStore) SetRetention(retention mediator.Retention) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retention = retention
+}
+
+// StoreEvent stores an event, timestamping events stored outside Publish
+func (s *EventStore) StoreEvent(ctx context.Context, event mediator.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	s.events[event.Name] = append(s.events[event.Name], event)
+	return nil
+}
+
+// AppendToStream appends events to a stream if it is at expectedVersion, or
+// whatever its version with mediator.AnyVersion, and stores them by name too
+func (s *EventStore) AppendToStream(ctx context.Context, streamID string, expectedVersion int64, events []mediator.Event) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	if expectedVersion < mediator.AnyVersion {
+		return 0, fmt.Errorf("invalid expected version %d", expectedVersion)
+	}
+	version := int64(len(s.streams[streamID]))
+	if expectedVersion != mediator.AnyVersion && version != expectedVersion {
+		return 0, &mediator.VersionConflictError{StreamID: streamID, Expected: expectedVersion, Actual: version}
+	}
+
+	for _, event := range events {
+		if event.Timestamp.IsZero() {
+			event.Timestamp = time.Now().UTC()
+		}
+		version++
+		record := stored([]mediator.Event{event}, 0, 1)[0]
+		record.Offset, record.StreamID, record.Version = "", streamID, version
+		s.streams[streamID] = append(s.streams[streamID], record)
+		s.events[event.Name] = append(s.events[event.Name], event)
+	}
+	return version, nil
+}
+
+// ReadStream returns the events of a stream from fromVersion onwards, oldest first
+func (s *EventStore) ReadStream(ctx context.Context, streamID string, fromVersion int64) ([]mediator.StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	stream := s.streams[streamID]
+	if fromVersion < 1 {
+		fromVersion = 1
+	}
+	if fromVersion > int64(len(stream)) {
+		return []mediator.StoredEvent{}, nil
+	}
+	return append([]mediator.StoredEvent(nil), stream[fromVersion-1:]...), nil
+}
+
+// GetEvents returns the most recent events of an event name, oldest first;
+// limit <= 0 returns all
+func (s *EventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	events, err := s.ReadEvents(ctx, eventName, limit)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]map[string]interface{}, len(events))
+	for i, event := range events {
+		records[i] = map[string]interface{}{
+			"id":             event.ID,
+			"name":           event.Name,
+			"payload":        event.Payload,
+			"timestamp":      event.Timestamp,
+			"correlation_id": event.CorrelationID,
+			"causation_id":   event.CausationID,
+			"metadata":       event.Metadata,
+			"namespace":      event.Namespace,
+		}
+	}
+	return records, nil
+}
+
+// ReadEvents returns the most recent events of an event name as typed
+// records, oldest first; limit <= 0 returns all
+func (s *EventStore) ReadEvents(ctx context.Context, eventName string, limit int64) ([]mediator.StoredEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	events := s.events[eventName]
+	start := 0
+	if limit > 0 && int64(len(events)) > limit {
+		start = len(events) - int(limit)
+	}
+	return stored(events, start, len(events)), nil
+}
+
+// GetEventsPage returns up to pageSize events of an event name stored after
+// cursor, oldest first. Cursors are positions in the stream.
+func (s *EventStore) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]mediator.StoredEvent, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, "", s.err
+	}
+	if pageSize <= 0 {
+		pageSize = mediator.DefaultPageSize
+	}
+
+	start := 0
+	if cursor != "" {
+		position, err := strconv.Atoi(cursor)
+		if err != nil || position < 0 {
+			return nil, "", fmt.Errorf("invalid cursor %q", cursor)
+		}
+		start = position
+	}
+	events := s.events[eventName]
+	if start > len(events) {
+		start = len(events)
+	}
+	end := start + pageSize
+	if end > len(events) {
+		end = len(events)
+	}
+
+	var next string
+	if end < len(events) {
+		next = strconv.Itoa(end)
+	}
+	return stored(events, start, end), next, nil
+}
+
+// GetStreams returns every event name with stored events, ordered by name
+func (s *EventStore) GetStreams(ctx context.Context) ([]mediator.StreamInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	streams := make([]mediator.StreamInfo, 0, len(s.events))
+	for name, events := range s.events {
+		if len(events) == 0 {
+			continue
+		}
+		streams = append(streams, mediator.StreamInfo{
+			Name:       name,
+			Namespace:  events[0].Namespace,
+			Count:      int64(len(events)),
+			FirstEvent: events[0].Timestamp,
+			LastEvent:  events[len(events)-1].Timestamp,
+		})
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].Name < streams[j].Name })
+	return streams, nil
+}
+
+// ClearEvents removes all events for a given event name
+func (s *EventStore) ClearEvents(ctx context.Context, eventName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	delete(s.events, eventName)
+	return nil
+}
+
+// DeleteBefore removes the events of an event name timestamped before t
+func (s *EventStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.deleteBefore(eventName, t)
+	return nil
+}
+
+// EnforceRetention applies the retention policy of every event name
+func (s *EventStore) EnforceRetention(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	for name := range s.events {
+		policy := s.retention.Policy(name)
+		if policy.MaxAge > 0 {
+			s.deleteBefore(name, time.Now().Add(-policy.MaxAge))
+		}
+		if events := s.events[name]; policy.MaxCount > 0 && int64(len(events)) > policy.MaxCount {
+			s.events[name] = append([]mediator.Event(nil), events[int64(len(events))-policy.MaxCount:]...)
+		}
+	}
+	return nil
+}
+
+// deleteBefore removes the events of an event name timestamped before t
+func (s *EventStore) deleteBefore(eventName string, t time.Time) {
+	var kept []mediator.Event
+	for _, event := range s.events[eventName] {
+		if !event.Timestamp.Before(t) {
+			kept = append(kept, event)
+		}
+	}
+	s.events[eventName] = kept
+}
+
+// Events returns the stored events of an event name, oldest first
+func (s *EventStore) Events(eventName string) []mediator.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mediator.Event(nil), s.events[eventName]...)
+}
+
+// stored converts events[start:end] to typed records, with their offsets
+func stored(events []mediator.Event, start, end int) []mediator.StoredEvent {
+	records := make([]mediator.StoredEvent, 0, end-start)
+	for i := start; i < end; i++ {
+		event := events[i]
+		records = append(records, mediator.StoredEvent{
+			ID:            event.ID,
+			Name:          event.Name,
+			Namespace:     event.Namespace,
+			Payload:       event.Payload,
+			Timestamp:     event.Timestamp,
+			CorrelationID: event.CorrelationID,
+			CausationID:   event.CausationID,
+			Metadata:      event.Metadata,
+			Offset:        strconv.Itoa(i + 1),
+		})
+	}
+	return records
+}
@@ -0,0 +1,159 @@
+package mediatortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+func TestEventStore(t *testing.T) {
+	store := NewEventStore()
+	r := NewRecorder(mediator.WithEventStore(store))
+	r.Sink("order.placed", "order.shipped")
+	for i := 1; i <= 3; i++ {
+		r.Publish(context.Background(), mediator.Event{Name: "order.placed", Payload: product{ID: "p", Price: i}})
+	}
+	r.Publish(context.Background(), mediator.Event{Name: "order.shipped"})
+
+	// Payloads keep their Go types
+	events, err := r.ReadEvents(context.Background(), "order.placed", 2)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("ReadEvents() = %d events, %v, want 2", len(events), err)
+	}
+	if p, ok := events[0].Payload.(product); !ok || p.Price != 2 {
+		t.Errorf("ReadEvents() payload = %#v, want the second product", events[0].Payload)
+	}
+
+	tests := []struct {
+		cursor string
+		want   int
+		next   string
+	}{
+		{"", 2, "2"},
+		{"2", 1, ""},
+		{"3", 0, ""},
+	}
+	for _, tt := range tests {
+		page, next, err := r.GetEventsPage(context.Background(), "order.placed", tt.cursor, 2)
+		if err != nil || len(page) != tt.want || next != tt.next {
+			t.Errorf("GetEventsPage(%q) = %d events, next %q, %v, want %d, next %q", tt.cursor, len(page), next, err, tt.want, tt.next)
+		}
+	}
+
+	streams, err := r.GetStreams(context.Background())
+	if err != nil || len(streams) != 2 || streams[0].Name != "order.placed" || streams[0].Count != 3 {
+		t.Errorf("GetStreams() = %+v, %v, want order.placed with 3 events first", streams, err)
+	}
+
+	if err := r.ClearEvents(context.Background(), "order.placed"); err != nil || len(store.Events("order.placed")) != 0 {
+		t.Errorf("ClearEvents() = %v, left %d events", err, len(store.Events("order.placed")))
+	}
+}
+
+func TestEventStore_FailWith(t *testing.T) {
+	store := NewEventStore()
+	r := NewRecorder(mediator.WithEventStore(store))
+	r.Sink("order.placed")
+
+	failure := errors.New("disk full")
+	store.FailWith(failure)
+	if err := r.Publish(context.Background(), mediator.Event{Name: "order.placed"}); !errors.Is(err, failure) {
+		t.Errorf("Publish() error = %v, want the store failure", err)
+	}
+	if _, err := r.ReadEvents(context.Background(), "order.placed", 0); !errors.Is(err, failure) {
+		t.Errorf("ReadEvents() error = %v, want the store failure", err)
+	}
+
+	store.FailWith(nil)
+	if err := r.Publish(context.Background(), mediator.Event{Name: "order.placed"}); err != nil {
+		t.Errorf("Publish() error = %v after clearing the failure", err)
+	}
+}
+
+func TestEventStore_Retention(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name   string
+		policy mediator.RetentionPolicy
+		want   int
+	}{
+		{name: "keep forever", policy: mediator.KeepForever(), want: 4},
+		{name: "keep last", policy: mediator.KeepLast(3), want: 3},
+		{name: "keep for", policy: mediator.KeepFor(90 * time.Minute), want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewEventStore()
+			store.SetRetention(mediator.Retention{Default: tt.policy})
+			for i := 3; i >= 0; i-- {
+				event := mediator.Event{Name: "order.placed", ID: fmt.Sprint(i), Timestamp: now.Add(-time.Duration(i) * time.Hour)}
+				if err := store.StoreEvent(context.Background(), event); err != nil {
+					t.Fatalf("StoreEvent() error = %v", err)
+				}
+			}
+			if err := store.EnforceRetention(context.Background()); err != nil {
+				t.Fatalf("EnforceRetention() error = %v", err)
+			}
+			if got := len(store.Events("order.placed")); got != tt.want {
+				t.Errorf("kept %d events, want %d", got, tt.want)
+			}
+		})
+	}
+
+	store := NewEventStore()
+	store.StoreEvent(context.Background(), mediator.Event{Name: "order.placed", Timestamp: now.Add(-time.Hour)})
+	store.StoreEvent(context.Background(), mediator.Event{Name: "order.placed"})
+	if err := store.DeleteBefore(context.Background(), "order.placed", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("DeleteBefore() error = %v", err)
+	}
+	if events := store.Events("order.placed"); len(events) != 1 || events[0].Timestamp.IsZero() {
+		t.Errorf("DeleteBefore() kept %+v, want the event timestamped on store", events)
+	}
+}
+
+func TestEventStore_Streams(t *testing.T) {
+	store := NewEventStore()
+	ctx := context.Background()
+	placed := []mediator.Event{{Name: "order.placed", ID: "e-1"}, {Name: "order.paid", ID: "e-2"}}
+
+	tests := []struct {
+		name     string
+		expected int64
+		want     int64
+		wantErr  error
+	}{
+		{name: "new stream", expected: mediator.NoStream, want: 2},
+		{name: "stale version", expected: 1, wantErr: mediator.ErrVersionConflict},
+		{name: "current version", expected: 2, want: 4},
+		{name: "any version", expected: mediator.AnyVersion, want: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.AppendToStream(ctx, "order-1", tt.expected, placed)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("AppendToStream() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("AppendToStream() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+
+	events, err := store.ReadStream(ctx, "order-1", 5)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("ReadStream() = %d events, %v, want 2", len(events), err)
+	}
+	if events[0].Version != 5 || events[0].StreamID != "order-1" || events[1].Name != "order.paid" {
+		t.Errorf("ReadStream() = %+v, want versions 5 and 6", events)
+	}
+	if got := len(store.Events("order.placed")); got != 3 {
+		t.Errorf("stored %d order.placed events by name, want 3", got)
+	}
+}
@@ -0,0 +1,53 @@
+package mediator
+
+import "context"
+
+// Middleware wraps a handler invocation. It receives the context, the event and
+// the next handler in the chain, and decides whether and how to call it.
+type Middleware func(ctx context.Context, event Event, next EventHandler) error
+
+// Use appends middleware to the chain wrapping every handler invocation.
+// Middleware registered first runs outermost.
+func (m *Mediator) Use(middlewares ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.middlewares = append(m.middlewares, middlewares...)
+	m.refreshSettings()
+}
+
+// WithMiddleware registers middleware when creating a Mediator
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(m *Mediator) {
+		m.middlewares = append(m.middlewares, middlewares...)
+	}
+}
+
+// UsePublish appends middleware to the chain wrapping the dispatch of every
+// published event, after the before-publish hooks. It may change the context
+// and the event passed on, e.g. to start a span and record it in the
+// metadata; the handlers, stores, transports and after-publish hooks see
+// what it passes on. Middleware registered first runs outermost.
+func (m *Mediator) UsePublish(middlewares ...Middleware) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.publishChain = append(m.publishChain, middlewares...)
+	m.refreshSettings()
+}
+
+// WithPublishMiddleware registers publish middleware when creating a Mediator
+func WithPublishMiddleware(middlewares ...Middleware) Option {
+	return func(m *Mediator) {
+		m.publishChain = append(m.publishChain, middlewares...)
+	}
+}
+
+// chain wraps handler with middlewares so that middlewares[0] runs first
+func chain(middlewares []Middleware, handler EventHandler) EventHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		mw, next := middlewares[i], handler
+		handler = func(ctx context.Context, event Event) error {
+			return mw(ctx, event, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,112 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMediator_Use(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(ctx context.Context, event Event, next EventHandler) error {
+			calls = append(calls, name+":before")
+			err := next(ctx, event)
+			calls = append(calls, name+":after")
+			return err
+		}
+	}
+
+	m := NewMediator(WithMiddleware(trace("outer")))
+	m.Use(trace("inner"))
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	want := []string{"outer:before", "inner:before", "handler", "inner:after", "outer:after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("call order = %v, want %v", calls, want)
+	}
+}
+
+func TestMediator_UseShortCircuit(t *testing.T) {
+	m := NewMediator()
+	m.Use(func(ctx context.Context, event Event, next EventHandler) error {
+		if event.Payload == nil {
+			return errors.New("payload required")
+		}
+		return next(ctx, event)
+	})
+
+	called := 0
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		called++
+		return nil
+	})
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		called++
+		return nil
+	})
+
+	// Middleware wraps each handler, so each rejection is reported
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err == nil {
+		t.Error("Publish() expected middleware error")
+	}
+	if called != 0 {
+		t.Errorf("handlers called %d times, want 0", called)
+	}
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event", Payload: "ok"}); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if called != 2 {
+		t.Errorf("handlers called %d times, want 2", called)
+	}
+}
+
+func TestMediator_UsePublish(t *testing.T) {
+	var calls []string
+	m := NewMediator(WithPublishMiddleware(func(ctx context.Context, event Event, next EventHandler) error {
+		calls = append(calls, "outer")
+		return next(ctx, event)
+	}))
+	m.UsePublish(func(ctx context.Context, event Event, next EventHandler) error {
+		calls = append(calls, "inner")
+		event.Metadata = map[string]string{"traceparent": "00-abc"}
+		return next(ctx, event)
+	})
+
+	var handled, reported Event
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls = append(calls, "handler")
+		handled = event
+		return nil
+	})
+	m.OnAfterPublish(func(ctx context.Context, event Event, err error) {
+		reported = event
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if want := []string{"outer", "inner", "handler"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("call order = %v, want %v", calls, want)
+	}
+	if handled.Metadata["traceparent"] != "00-abc" || reported.Metadata["traceparent"] != "00-abc" {
+		t.Errorf("Expected handlers and hooks to see the changed event, got %v and %v", handled.Metadata, reported.Metadata)
+	}
+
+	// Publish middleware runs once per publish, not per handler
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+	calls = nil
+	m.Publish(context.Background(), Event{Name: "test.event"})
+	if len(calls) != 3 {
+		t.Errorf("call order = %v, want publish middleware to run once", calls)
+	}
+}
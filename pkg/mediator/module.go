@@ -0,0 +1,205 @@
+package mediator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrModuleExists is returned by RegisterModule for a name already registered
+var ErrModuleExists = errors.New("module already registered")
+
+// ErrModuleUnregistered is returned by Module.Subscribe once the module is unregistered
+var ErrModuleUnregistered = errors.New("module unregistered")
+
+// Module groups related subscriptions, e.g. those of one feature, so they can
+// be paused, resumed and unregistered as a unit at runtime
+type Module struct {
+	name     string
+	mediator *Mediator
+	paused   atomic.Bool
+
+	mu           sync.Mutex
+	subs         []*Subscription
+	unregistered bool
+}
+
+// ModuleSetup subscribes the handlers of a module
+type ModuleSetup func(mod *Module) error
+
+// ModuleInfo describes a registered module
+type ModuleInfo struct {
+	Name     string `json:"name"`
+	Paused   bool   `json:"paused,omitempty"`
+	Handlers int    `json:"handlers"`
+}
+
+// RegisterModule registers a module and runs setup to subscribe its handlers
+// through Module.Subscribe and Module.Add. When setup fails, the handlers it
+// subscribed are removed again and the module is not registered.
+func (m *Mediator) RegisterModule(name string, setup ModuleSetup) (*Module, error) {
+	if name == "" {
+		return nil, errors.New("failed to register module: empty module name")
+	}
+	mod := &Module{name: name, mediator: m}
+
+	m.mu.Lock()
+	if _, exists := m.modules[name]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("failed to register module %s: %w", name, ErrModuleExists)
+	}
+	if m.modules == nil {
+		m.modules = make(map[string]*Module)
+	}
+	m.modules[name] = mod
+	m.mu.Unlock()
+
+	if setup != nil {
+		if err := setup(mod); err != nil {
+			mod.Unregister()
+			return nil, fmt.Errorf("failed to register module %s: %w", name, err)
+		}
+	}
+	return mod, nil
+}
+
+// LookupModule returns a registered module by name, e.g. to pause it from an
+// admin endpoint or a feature flag
+func (m *Mediator) LookupModule(name string) (*Module, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mod, ok := m.modules[name]
+	return mod, ok
+}
+
+// Modules returns a snapshot of the registered modules, sorted by name
+func (m *Mediator) Modules() []ModuleInfo {
+	m.mu.RLock()
+	mods := make([]*Module, 0, len(m.modules))
+	for _, mod := range m.modules {
+		mods = append(mods, mod)
+	}
+	m.mu.RUnlock()
+
+	infos := make([]ModuleInfo, len(mods))
+	for i, mod := range mods {
+		infos[i] = ModuleInfo{Name: mod.name, Paused: mod.Paused(), Handlers: len(mod.Subscriptions())}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Name returns the name of the module
+func (mod *Module) Name() string {
+	return mod.name
+}
+
+// Subscribe adds a handler to the mediator as part of the module, paused if
+// the module is. Like TrySubscribe, it returns an error for an event name
+// WithStrictEvents rejects, which fails a setup, and ErrModuleUnregistered
+// once the module is unregistered.
+func (mod *Module) Subscribe(eventName string, handler EventHandler, opts ...SubscribeOption) (*Subscription, error) {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+	if mod.unregistered {
+		return nil, fmt.Errorf("module %s: %w", mod.name, ErrModuleUnregistered)
+	}
+
+	// Copy so the caller's options are never appended to
+	sub, err := mod.mediator.TrySubscribe(eventName, handler, append(opts[:len(opts):len(opts)], inModule(mod))...)
+	if err != nil {
+		return nil, fmt.Errorf("module %s: %w", mod.name, err)
+	}
+	mod.subs = append(mod.subs, sub)
+	return sub, nil
+}
+
+// Add moves subscriptions made elsewhere, e.g. by SubscribeTyped or
+// RegisterHandlers, into the module. After Unregister, it unsubscribes them
+// instead and returns ErrModuleUnregistered.
+func (mod *Module) Add(subs ...*Subscription) error {
+	mod.mu.Lock()
+	unregistered := mod.unregistered
+	for _, sub := range subs {
+		if sub == nil || unregistered {
+			continue
+		}
+		sub.module.Store(mod)
+		mod.subs = append(mod.subs, sub)
+	}
+	mod.mu.Unlock()
+	if !unregistered {
+		return nil
+	}
+
+	// Unsubscribe outside the lock, like Unregister
+	for _, sub := range subs {
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+	}
+	return fmt.Errorf("module %s: %w", mod.name, ErrModuleUnregistered)
+}
+
+// Subscriptions returns the handlers of the module still subscribed
+func (mod *Module) Subscriptions() []*Subscription {
+	mod.mu.Lock()
+	defer mod.mu.Unlock()
+
+	subs := make([]*Subscription, 0, len(mod.subs))
+	for _, sub := range mod.subs {
+		if sub.subscribed() {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// Pause stops dispatching events to every handler of the module until
+// Resume, like Subscription.Pause. Handlers paused on their own stay paused
+// when the module resumes.
+func (mod *Module) Pause() {
+	mod.paused.Store(true)
+}
+
+// Resume dispatches events to the handlers of a paused module again
+func (mod *Module) Resume() {
+	mod.paused.Store(false)
+}
+
+// Paused reports whether the module is paused
+func (mod *Module) Paused() bool {
+	return mod.paused.Load()
+}
+
+// Unregister removes every handler of the module from the mediator and the
+// module from its registry, so the name can be registered again. The module
+// takes no more handlers. It reports whether the module was still registered.
+func (mod *Module) Unregister() bool {
+	m := mod.mediator
+	m.mu.Lock()
+	registered := m.modules[mod.name] == mod
+	if registered {
+		delete(m.modules, mod.name)
+	}
+	m.mu.Unlock()
+
+	mod.mu.Lock()
+	subs := mod.subs
+	mod.subs = nil
+	mod.unregistered = true
+	mod.mu.Unlock()
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	return registered
+}
+
+// inModule makes a subscription part of mod
+func inModule(mod *Module) SubscribeOption {
+	return func(s *Subscription) {
+		s.module.Store(mod)
+	}
+}
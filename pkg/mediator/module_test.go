@@ -0,0 +1,265 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMediator_RegisterModule(t *testing.T) {
+	m := NewMediator()
+	ctx := context.Background()
+	handled := make(map[string]int)
+	record := func(name string) EventHandler {
+		return func(ctx context.Context, event Event) error {
+			handled[name]++
+			return nil
+		}
+	}
+	m.Subscribe("sku.created", record("core"), WithHandlerName("core"))
+
+	mod, err := m.RegisterModule("sku-automation", func(mod *Module) error {
+		if _, err := mod.Subscribe("sku.created", record("restock"), WithHandlerName("restock")); err != nil {
+			return err
+		}
+		sub, err := SubscribeTyped(m, "sku.updated", func(ctx context.Context, p *testProduct) error {
+			handled["reprice"]++
+			return nil
+		}, WithHandlerName("reprice"))
+		if err != nil {
+			return err
+		}
+		return mod.Add(sub)
+	})
+	if err != nil {
+		t.Fatalf("RegisterModule() error = %v", err)
+	}
+	if got, ok := m.LookupModule("sku-automation"); !ok || got != mod {
+		t.Fatalf("LookupModule() = %v, %v; want the module", got, ok)
+	}
+	if sub, _ := m.LookupSubscription("sku.created", "restock"); sub.Module() != "sku-automation" {
+		t.Errorf("Module() = %q, want sku-automation", sub.Module())
+	}
+
+	publish := func() {
+		m.Publish(ctx, Event{Name: "sku.created"})
+		m.Publish(ctx, Event{Name: "sku.updated", Payload: &testProduct{}})
+	}
+
+	publish()
+	mod.Pause()
+	publish()
+	if !mod.Paused() {
+		t.Error("Paused() = false after Pause")
+	}
+	if handled["core"] != 2 || handled["restock"] != 1 || handled["reprice"] != 1 {
+		t.Errorf("handled = %v, want the module's handlers skipped while paused", handled)
+	}
+
+	mod.Resume()
+	publish()
+	if handled["restock"] != 2 || handled["reprice"] != 2 {
+		t.Errorf("handled = %v, want the module's handlers to run after Resume", handled)
+	}
+
+	infos := m.Modules()
+	if len(infos) != 1 || infos[0] != (ModuleInfo{Name: "sku-automation", Handlers: 2}) {
+		t.Errorf("Modules() = %+v", infos)
+	}
+
+	if !mod.Unregister() {
+		t.Error("Unregister() = false, want true")
+	}
+	if mod.Unregister() {
+		t.Error("Unregister() again = true, want false")
+	}
+	publish()
+	if handled["core"] != 4 || handled["restock"] != 2 || handled["reprice"] != 2 {
+		t.Errorf("handled = %v, want only the core handler after Unregister", handled)
+	}
+	if _, ok := m.LookupModule("sku-automation"); ok {
+		t.Error("LookupModule() after Unregister found the module")
+	}
+
+	// Test the name can be registered again
+	if _, err := m.RegisterModule("sku-automation", nil); err != nil {
+		t.Errorf("RegisterModule() after Unregister error = %v", err)
+	}
+}
+
+func TestModule_PauseKeepsHandlerPauses(t *testing.T) {
+	m := NewMediator()
+	var paused *Subscription
+	mod, _ := m.RegisterModule("reports", func(mod *Module) error {
+		var err error
+		paused, err = mod.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+		return err
+	})
+
+	paused.Pause()
+	mod.Pause()
+	mod.Resume()
+	if !paused.Paused() {
+		t.Error("handler paused on its own resumed with its module")
+	}
+
+	mod.Pause()
+	var info HandlerInfo
+	for _, sub := range m.Subscriptions() {
+		info = sub.Handlers[0]
+	}
+	if !info.Paused || info.Module != "reports" {
+		t.Errorf("HandlerInfo = %+v, want paused in module reports", info)
+	}
+}
+
+func TestModule_SubscribePaused(t *testing.T) {
+	m := NewMediator()
+	mod, _ := m.RegisterModule("reports", nil)
+	mod.Pause()
+
+	handled := 0
+	mod.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		handled++
+		return nil
+	})
+	m.Publish(context.Background(), Event{Name: "order.placed"})
+	if handled != 0 {
+		t.Errorf("handled %d events, want a handler added to a paused module to be paused", handled)
+	}
+}
+
+func TestModule_Subscribe(t *testing.T) {
+	noop := func(ctx context.Context, event Event) error { return nil }
+
+	tests := []struct {
+		name    string
+		prepare func(mod *Module)
+		wantErr error
+	}{
+		{name: "registered"},
+		{name: "unregistered", prepare: func(mod *Module) { mod.Unregister() }, wantErr: ErrModuleUnregistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator()
+			mod, err := m.RegisterModule("reports", nil)
+			if err != nil {
+				t.Fatalf("RegisterModule() error = %v", err)
+			}
+			if tt.prepare != nil {
+				tt.prepare(mod)
+			}
+
+			// Spare capacity must not be written to
+			opts := make([]SubscribeOption, 1, 2)
+			opts[0] = WithHandlerName("report")
+			spare := opts[:2]
+			sub, err := mod.Subscribe("order.placed", noop, opts...)
+			if spare[1] != nil {
+				t.Error("Subscribe() appended to the caller's options")
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Subscribe() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if sub != nil || len(m.Subscriptions()) != 0 {
+					t.Errorf("Subscribe() = %v, want no subscription", sub)
+				}
+				return
+			}
+			if sub.Module() != "reports" || len(mod.Subscriptions()) != 1 {
+				t.Errorf("Subscribe() = %v in module %q, want it in reports", sub, sub.Module())
+			}
+		})
+	}
+}
+
+func TestModule_Add(t *testing.T) {
+	noop := func(ctx context.Context, event Event) error { return nil }
+
+	tests := []struct {
+		name    string
+		prepare func(mod *Module)
+		wantErr error
+	}{
+		{name: "registered"},
+		{name: "unregistered", prepare: func(mod *Module) { mod.Unregister() }, wantErr: ErrModuleUnregistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator()
+			mod, err := m.RegisterModule("reports", nil)
+			if err != nil {
+				t.Fatalf("RegisterModule() error = %v", err)
+			}
+			if tt.prepare != nil {
+				tt.prepare(mod)
+			}
+
+			sub := m.Subscribe("order.placed", noop, WithHandlerName("report"))
+			if err := mod.Add(sub, nil); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Add() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if sub.subscribed() || len(m.Subscriptions()) != 0 {
+					t.Error("Add() left the subscription of an unregistered module subscribed")
+				}
+				return
+			}
+			if sub.Module() != "reports" || len(mod.Subscriptions()) != 1 {
+				t.Errorf("Add() put %v in module %q, want it in reports", sub, sub.Module())
+			}
+		})
+	}
+}
+
+func TestMediator_RegisterModuleErrors(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	m := NewMediator()
+	if _, err := m.RegisterModule("billing", nil); err != nil {
+		t.Fatalf("RegisterModule() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		module  string
+		setup   ModuleSetup
+		wantErr error
+	}{
+		{name: "existing name", module: "billing", wantErr: ErrModuleExists},
+		{name: "empty name", module: ""},
+		{
+			name:   "setup failure",
+			module: "invoices",
+			setup: func(mod *Module) error {
+				mod.Subscribe("invoice.sent", func(ctx context.Context, event Event) error { return nil })
+				return errSetup
+			},
+			wantErr: errSetup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, err := m.RegisterModule(tt.module, tt.setup)
+			if err == nil || mod != nil {
+				t.Fatalf("RegisterModule() = %v, %v; want an error", mod, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterModule() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Test a failed setup leaves nothing behind
+	if len(m.Subscriptions()) != 0 {
+		t.Errorf("Subscriptions() = %+v, want none after a failed setup", m.Subscriptions())
+	}
+	if _, ok := m.LookupModule("invoices"); ok {
+		t.Error("LookupModule() found the module of a failed setup")
+	}
+}
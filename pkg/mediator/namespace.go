@@ -0,0 +1,78 @@
+package mediator
+
+import "context"
+
+// NamespaceSeparator joins a namespace and an event name in store keys
+const NamespaceSeparator = ":"
+
+// namespaceContextKey is the context key under which the namespace is stored
+type namespaceContextKey struct{}
+
+// WithNamespace sets the namespace of events published without one in their
+// context, e.g. a service-wide tenant
+func WithNamespace(namespace string) Option {
+	return func(m *Mediator) {
+		m.namespace = namespace
+	}
+}
+
+// ContextWithNamespace returns a copy of ctx whose publishes and event store
+// reads are scoped to namespace
+func ContextWithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceContextKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace set on ctx or, inside a handler,
+// the namespace of the event being handled
+func NamespaceFromContext(ctx context.Context) (string, bool) {
+	if namespace, ok := ctx.Value(namespaceContextKey{}).(string); ok {
+		return namespace, true
+	}
+	if event, ok := EventFromContext(ctx); ok && event.Namespace != "" {
+		return event.Namespace, true
+	}
+	return "", false
+}
+
+// namespaceOf returns the namespace that applies to ctx
+func (m *Mediator) namespaceOf(ctx context.Context) string {
+	if namespace, ok := NamespaceFromContext(ctx); ok {
+		return namespace
+	}
+	return m.publishSettings().namespace
+}
+
+// storeContext returns ctx carrying the namespace that applies to it, so
+// event stores can route each namespace, e.g. to tables of its own
+func (m *Mediator) storeContext(ctx context.Context) context.Context {
+	if _, ok := NamespaceFromContext(ctx); ok {
+		return ctx
+	}
+	if namespace := m.namespaceOf(ctx); namespace != "" {
+		return ContextWithNamespace(ctx, namespace)
+	}
+	return ctx
+}
+
+// scope sets the namespace of event from ctx unless the caller set one
+func (m *Mediator) scope(ctx context.Context, event Event) Event {
+	if event.Namespace == "" {
+		event.Namespace = m.namespaceOf(ctx)
+	}
+	return event
+}
+
+// namespacedName prefixes name with namespace, if any
+func namespacedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + NamespaceSeparator + name
+}
+
+// stored returns the event as written to an event store, its name prefixed
+// with its namespace so each namespace has its own stream
+func (e Event) stored() Event {
+	e.Name = namespacedName(e.Namespace, e.Name)
+	return e
+}
@@ -0,0 +1,124 @@
+package mediator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNamespacedName(t *testing.T) {
+	tests := []struct {
+		namespace string
+		name      string
+		want      string
+	}{
+		{"", "order.placed", "order.placed"},
+		{"acme", "order.placed", "acme:order.placed"},
+	}
+
+	for _, tt := range tests {
+		if got := namespacedName(tt.namespace, tt.name); got != tt.want {
+			t.Errorf("namespacedName(%q, %q) = %q, want %q", tt.namespace, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMediator_NamespaceIsolatesStreams(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+
+	var namespaces []string
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		namespaces = append(namespaces, event.Namespace)
+		return nil
+	})
+
+	acme := ContextWithNamespace(context.Background(), "acme")
+	globex := ContextWithNamespace(context.Background(), "globex")
+	for _, ctx := range []context.Context{acme, acme, globex} {
+		if err := m.Publish(ctx, Event{Name: "order.placed"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	if len(namespaces) != 3 || namespaces[0] != "acme" || namespaces[2] != "globex" {
+		t.Errorf("handler saw namespaces %v, want [acme acme globex]", namespaces)
+	}
+
+	tests := []struct {
+		ctx  context.Context
+		want int
+	}{
+		{acme, 2},
+		{globex, 1},
+		{context.Background(), 0},
+	}
+	for _, tt := range tests {
+		events, err := m.GetEvents(tt.ctx, "order.placed", 0)
+		if err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+		if len(events) != tt.want {
+			t.Errorf("GetEvents() = %d events, want %d", len(events), tt.want)
+		}
+	}
+
+	// Test clearing one namespace leaves the others intact
+	if err := m.ClearEvents(acme, "order.placed"); err != nil {
+		t.Fatalf("ClearEvents() error = %v", err)
+	}
+	if events, _ := m.GetEvents(globex, "order.placed", 0); len(events) != 1 {
+		t.Errorf("GetEvents() after clearing acme = %d events, want 1", len(events))
+	}
+}
+
+func TestMediator_DefaultNamespaceAndInheritance(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store), WithNamespace("acme"))
+
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		return m.Publish(ctx, Event{Name: "invoice.created"})
+	})
+	var invoiceNamespace string
+	m.Subscribe("invoice.created", func(ctx context.Context, event Event) error {
+		invoiceNamespace = event.Namespace
+		return nil
+	})
+
+	if err := m.Publish(ContextWithNamespace(context.Background(), "globex"), Event{Name: "order.placed"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if invoiceNamespace != "globex" {
+		t.Errorf("follow-up namespace = %q, want globex", invoiceNamespace)
+	}
+
+	if err := m.Publish(context.Background(), Event{Name: "invoice.created"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if invoiceNamespace != "acme" {
+		t.Errorf("default namespace = %q, want acme", invoiceNamespace)
+	}
+}
+
+func TestMediator_StoreContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		ctx       context.Context
+		want      string
+		wantOK    bool
+	}{
+		{"no namespace", "", context.Background(), "", false},
+		{"default namespace", "acme", context.Background(), "acme", true},
+		{"context namespace", "acme", ContextWithNamespace(context.Background(), "globex"), "globex", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator(WithNamespace(tt.namespace))
+			got, ok := NamespaceFromContext(m.storeContext(tt.ctx))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("storeContext() namespace = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
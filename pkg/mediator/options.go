@@ -0,0 +1,107 @@
+package mediator
+
+import "time"
+
+// Logger is the logging interface used by the mediator. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// ConcurrencyMode controls how Publish invokes the handlers of an event
+type ConcurrencyMode int
+
+const (
+	// Sequential invokes handlers one after another in registration order
+	Sequential ConcurrencyMode = iota
+	// Parallel invokes handlers concurrently and waits for them to finish.
+	// The first failure cancels the context of the remaining handlers.
+	Parallel
+)
+
+// ErrorStrategy controls how Publish reacts to handler failures
+type ErrorStrategy int
+
+const (
+	// CollectAll runs every handler and reports all failures together
+	CollectAll ErrorStrategy = iota
+	// FailFast stops at the first failure: later handlers are not invoked
+	FailFast
+	// BestEffort runs every handler, logs failures and reports success
+	BestEffort
+)
+
+// DeliveryMode controls when Publish persists an event relative to dispatching it
+type DeliveryMode int
+
+const (
+	// DispatchFirst runs the handlers, then stores the event
+	DispatchFirst DeliveryMode = iota
+	// StoreFirst stores the event before any handler runs, so a crash during
+	// dispatch leaves it in the store to be replayed. A failed store write
+	// aborts the publish.
+	StoreFirst
+	// StoreOnly stores the event without running handlers, e.g. to dispatch
+	// it later with Replay
+	StoreOnly
+)
+
+// Option configures a Mediator created by NewMediator
+type Option func(*Mediator)
+
+// WithEventStore sets the event store used to persist published events
+func WithEventStore(store EventStore) Option {
+	return func(m *Mediator) {
+		m.eventStore = store
+	}
+}
+
+// WithLogger sets the logger used to report handler failures
+func WithLogger(logger Logger) Option {
+	return func(m *Mediator) {
+		m.logger = logger
+	}
+}
+
+// WithConcurrency sets how handlers are invoked during Publish
+func WithConcurrency(mode ConcurrencyMode) Option {
+	return func(m *Mediator) {
+		m.concurrency = mode
+	}
+}
+
+// WithMaxConcurrency limits how many handlers of one event run at the same time
+// in Parallel mode; n <= 0 means no limit
+func WithMaxConcurrency(n int) Option {
+	return func(m *Mediator) {
+		m.maxConcurrency = n
+	}
+}
+
+// WithErrorStrategy sets how Publish reacts to handler failures
+func WithErrorStrategy(strategy ErrorStrategy) Option {
+	return func(m *Mediator) {
+		m.errorStrategy = strategy
+	}
+}
+
+// WithDeliveryMode sets when Publish stores events relative to dispatching them
+func WithDeliveryMode(mode DeliveryMode) Option {
+	return func(m *Mediator) {
+		m.deliveryMode = mode
+	}
+}
+
+// WithHandlerTimeout sets the default time limit for each handler invocation.
+// Subscriptions can override it with WithTimeout; d <= 0 means no limit.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(m *Mediator) {
+		m.handlerTimeout = d
+	}
+}
+
+// logf writes to the configured logger, if any
+func (m *Mediator) logf(format string, args ...interface{}) {
+	if m.logger != nil {
+		m.logger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,146 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockEventStore is an in-memory EventStore used by tests
+type mockEventStore struct {
+	mu       sync.Mutex
+	events   []Event
+	storeErr error
+}
+
+func (s *mockEventStore) StoreEvent(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.storeErr != nil {
+		return s.storeErr
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *mockEventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []map[string]interface{}
+	for _, event := range s.events {
+		if event.Name == eventName {
+			events = append(events, map[string]interface{}{"name": event.Name, "payload": event.Payload})
+		}
+	}
+	return events, nil
+}
+
+func (s *mockEventStore) ClearEvents(ctx context.Context, eventName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.events[:0]
+	for _, event := range s.events {
+		if event.Name != eventName {
+			kept = append(kept, event)
+		}
+	}
+	s.events = kept
+	return nil
+}
+
+// mockLogger records formatted log lines
+type mockLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *mockLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNewMediator(t *testing.T) {
+	m1 := NewMediator()
+	m2 := NewMediator()
+	if m1 == m2 {
+		t.Fatal("NewMediator() returned the same instance twice")
+	}
+
+	// Subscriptions must not leak between instances
+	m1.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+	if err := m2.Publish(context.Background(), Event{Name: "test.event"}); err == nil {
+		t.Error("Publish() on second instance found handlers of the first")
+	}
+}
+
+func TestWithEventStore(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event", Payload: "payload"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	events, err := m.GetEvents(context.Background(), "test.event", 10)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("GetEvents() returned %d events, want 1", len(events))
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	logger := &mockLogger{}
+	m := NewMediator(WithLogger(logger))
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		return errors.New("handler error")
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err == nil {
+		t.Fatal("Publish() expected error")
+	}
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "handler error") {
+		t.Errorf("logger lines = %v, want handler failure", logger.lines)
+	}
+}
+
+func TestWithConcurrency(t *testing.T) {
+	m := NewMediator(WithConcurrency(Parallel))
+
+	// Each handler waits for the other, which only completes if they run concurrently
+	first := make(chan struct{})
+	second := make(chan struct{})
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		close(first)
+		select {
+		case <-second:
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("timed out waiting for second handler")
+		}
+	})
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		close(second)
+		select {
+		case <-first:
+			return errors.New("second handler error")
+		case <-time.After(time.Second):
+			return errors.New("timed out waiting for first handler")
+		}
+	})
+
+	err := m.Publish(context.Background(), Event{Name: "test.event"})
+	if err == nil || !strings.Contains(err.Error(), "second handler error") {
+		t.Errorf("Publish() error = %v, want second handler error", err)
+	}
+	if strings.Contains(err.Error(), "timed out") {
+		t.Errorf("handlers did not run concurrently: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OutboxWriter writes events to a transactional outbox, typically bound to a
+// database transaction so the event commits together with business data
+type OutboxWriter interface {
+	WriteOutbox(ctx context.Context, event Event) error
+}
+
+// OutboxRecord is an event waiting in the outbox to be dispatched
+type OutboxRecord struct {
+	ID    string
+	Event Event
+}
+
+// Outbox is the relay side of a transactional outbox
+type Outbox interface {
+	// Pending returns up to limit committed, undispatched records, oldest first
+	Pending(ctx context.Context, limit int) ([]OutboxRecord, error)
+	// MarkDispatched records that the given records were dispatched
+	MarkDispatched(ctx context.Context, ids ...string) error
+}
+
+// outboxContextKey is the context key under which the outbox writer is stored
+type outboxContextKey struct{}
+
+// ContextWithOutbox returns a copy of ctx in which Publish writes events to
+// writer instead of dispatching them. A Relay dispatches them once committed.
+func ContextWithOutbox(ctx context.Context, writer OutboxWriter) context.Context {
+	return context.WithValue(ctx, outboxContextKey{}, writer)
+}
+
+// InOutbox reports whether publishes in ctx are written to an outbox rather
+// than dispatched
+func InOutbox(ctx context.Context) bool {
+	_, ok := outboxFromContext(ctx)
+	return ok
+}
+
+// outboxFromContext returns the outbox writer of ctx, if any
+func outboxFromContext(ctx context.Context) (OutboxWriter, bool) {
+	writer, ok := ctx.Value(outboxContextKey{}).(OutboxWriter)
+	return writer, ok && writer != nil
+}
+
+// RelayConfig configures a Relay
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+// DefaultRelayConfig returns default relay configuration
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		PollInterval: time.Second,
+		BatchSize:    100,
+	}
+}
+
+// Relay dispatches committed outbox events through a mediator
+type Relay struct {
+	mediator *Mediator
+	outbox   Outbox
+	config   RelayConfig
+}
+
+// NewRelay creates a relay that dispatches events from outbox through m
+func NewRelay(m *Mediator, outbox Outbox, config RelayConfig) *Relay {
+	defaults := DefaultRelayConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	return &Relay{
+		mediator: m,
+		outbox:   outbox,
+		config:   config,
+	}
+}
+
+// Run dispatches outbox events every poll interval until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain full batches before waiting for the next tick
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				r.mediator.logf("outbox relay failed: %v", err)
+				break
+			}
+			if n < r.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayHandlerName is the handler name of the dead letters of outbox records
+// the relay cannot dispatch
+const RelayHandlerName = "outbox.relay"
+
+// RelayOnce dispatches one batch of outbox events and returns how many were
+// dispatched. Handler failures are left to retries and the dead-letter queue;
+// the event still counts as dispatched. Records that can never be dispatched,
+// whose payload doesn't decode or that are invalid, are dead-lettered, when
+// the mediator has a dead-letter queue, and skipped. Any other failure, such
+// as a store or transport write, stops the batch and leaves the record
+// pending, to be dispatched again by the next run.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	records, err := r.outbox.Pending(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	for i, record := range records {
+		if err := r.dispatch(ctx, record); err != nil {
+			return i, err
+		}
+		if err := r.outbox.MarkDispatched(context.WithoutCancel(ctx), record.ID); err != nil {
+			return i, fmt.Errorf("failed to mark outbox record %s dispatched: %w", record.ID, err)
+		}
+	}
+	return len(records), nil
+}
+
+// dispatch publishes the event of a record, returning an error when the
+// record should stay pending
+func (r *Relay) dispatch(ctx context.Context, record OutboxRecord) error {
+	event := record.Event
+	var err error
+	if event.Payload, err = r.mediator.rehydrate(event.Name, event.Payload); err != nil {
+		return r.drop(ctx, record, fmt.Errorf("failed to rehydrate payload: %w", err))
+	}
+
+	err = r.mediator.Publish(ctx, event)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNoHandlers) || onlyHandlerErrors(err):
+		r.mediator.logf("outbox event %s dispatched with errors: %v", event.ID, err)
+		return nil
+	case ctx.Err() != nil:
+		// A cancelled relay leaves the event for the next run
+		return ctx.Err()
+	case errors.Is(err, ErrInvalidEvent):
+		return r.drop(ctx, record, err)
+	}
+	return fmt.Errorf("failed to dispatch outbox record %s: %w", record.ID, err)
+}
+
+// onlyHandlerErrors reports whether err is a *PublishError of handler
+// failures only
+func onlyHandlerErrors(err error) bool {
+	var publishErr *PublishError
+	return errors.As(err, &publishErr) && len(publishErr.HandlerErrors()) == len(publishErr.Errors)
+}
+
+// drop dead-letters a record that can never be dispatched, when the mediator
+// has a dead-letter queue, so it can be marked dispatched
+func (r *Relay) drop(ctx context.Context, record OutboxRecord, err error) error {
+	r.mediator.logf("outbox record %s skipped: %v", record.ID, err)
+	if r.mediator.deadLetters == nil {
+		return nil
+	}
+
+	letter := DeadLetter{
+		ID:          newID(),
+		Event:       record.Event,
+		HandlerName: RelayHandlerName,
+		Error:       err.Error(),
+		Attempts:    1,
+		FailedAt:    r.mediator.now(),
+	}
+	if err := r.mediator.deadLetters.Add(context.WithoutCancel(ctx), letter); err != nil {
+		return fmt.Errorf("failed to dead-letter outbox record %s: %w", record.ID, err)
+	}
+	return nil
+}
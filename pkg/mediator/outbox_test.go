@@ -0,0 +1,192 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryOutbox is an in-memory Outbox and OutboxWriter used by tests
+type memoryOutbox struct {
+	mu         sync.Mutex
+	records    []OutboxRecord
+	dispatched map[string]bool
+}
+
+func (o *memoryOutbox) WriteOutbox(ctx context.Context, event Event) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.records = append(o.records, OutboxRecord{ID: event.ID, Event: event})
+	return nil
+}
+
+func (o *memoryOutbox) Pending(ctx context.Context, limit int) ([]OutboxRecord, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var pending []OutboxRecord
+	for _, record := range o.records {
+		if !o.dispatched[record.ID] && len(pending) < limit {
+			pending = append(pending, record)
+		}
+	}
+	return pending, nil
+}
+
+func (o *memoryOutbox) MarkDispatched(ctx context.Context, ids ...string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.dispatched == nil {
+		o.dispatched = make(map[string]bool)
+	}
+	for _, id := range ids {
+		o.dispatched[id] = true
+	}
+	return nil
+}
+
+type failingOutboxWriter struct{}
+
+func (failingOutboxWriter) WriteOutbox(ctx context.Context, event Event) error {
+	return errors.New("tx aborted")
+}
+
+func TestPublish_WritesToOutbox(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+
+	calls := 0
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	})
+
+	outbox := &memoryOutbox{}
+	ctx := ContextWithOutbox(context.Background(), outbox)
+	if err := m.Publish(ctx, Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if calls != 0 || len(store.events) != 0 {
+		t.Errorf("Publish() dispatched within outbox context: calls=%d stored=%d", calls, len(store.events))
+	}
+	if len(outbox.records) != 1 || outbox.records[0].Event.ID == "" {
+		t.Fatalf("outbox records = %+v, want 1 stamped event", outbox.records)
+	}
+
+	// Test the relay dispatches and stores the event exactly once
+	relay := NewRelay(m, outbox, RelayConfig{BatchSize: 10})
+	for i := 0; i < 2; i++ {
+		if _, err := relay.RelayOnce(context.Background()); err != nil {
+			t.Fatalf("RelayOnce() error = %v", err)
+		}
+	}
+	if calls != 1 || len(store.events) != 1 {
+		t.Errorf("after relay calls=%d stored=%d, want 1, 1", calls, len(store.events))
+	}
+}
+
+func TestPublish_OutboxWriteError(t *testing.T) {
+	m := NewMediator()
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := ContextWithOutbox(context.Background(), failingOutboxWriter{})
+	if err := m.Publish(ctx, Event{Name: "product.created"}); err == nil {
+		t.Error("Publish() expected outbox write error")
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	m := NewMediator()
+	received := make(chan Event, 1)
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		received <- event
+		return nil
+	})
+
+	outbox := &memoryOutbox{}
+	if err := m.Publish(ContextWithOutbox(context.Background(), outbox), Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewRelay(m, outbox, RelayConfig{PollInterval: 10 * time.Millisecond}).Run(ctx)
+	}()
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not dispatch the outbox event")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	tests := []struct {
+		name           string
+		payload        interface{}
+		handlerErr     error
+		storeErr       error
+		deadLetters    bool
+		wantErr        bool
+		wantDispatched int
+		wantLetters    int
+	}{
+		{"dispatched", map[string]interface{}{"id": "p-1"}, nil, nil, false, false, 2, 0},
+		{"handler failure", map[string]interface{}{"id": "p-1"}, errors.New("handler failed"), nil, false, false, 2, 0},
+		{"store failure", map[string]interface{}{"id": "p-1"}, nil, errors.New("store down"), false, true, 0, 0},
+		{"undecodable with dead letters", map[string]interface{}{"price": "free"}, nil, nil, true, false, 2, 1},
+		{"undecodable without dead letters", map[string]interface{}{"price": "free"}, nil, nil, false, false, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dlq := NewMemoryDeadLetterQueue()
+			opts := []Option{WithEventStore(&mockEventStore{storeErr: tt.storeErr})}
+			if tt.deadLetters {
+				opts = append(opts, WithDeadLetterQueue(dlq))
+			}
+			m := NewMediator(opts...)
+			RegisterEvent[*testProduct](m, "product.created")
+			m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+				return tt.handlerErr
+			})
+
+			// A record followed by one that always dispatches
+			outbox := &memoryOutbox{}
+			outbox.WriteOutbox(context.Background(), Event{Name: "product.created", ID: "evt-1", Payload: tt.payload})
+			outbox.WriteOutbox(context.Background(), Event{Name: "product.created", ID: "evt-2", Payload: map[string]interface{}{"id": "p-2"}})
+
+			n, err := NewRelay(m, outbox, DefaultRelayConfig()).RelayOnce(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RelayOnce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != tt.wantDispatched || len(outbox.dispatched) != tt.wantDispatched {
+				t.Errorf("RelayOnce() = %d with %d marked dispatched, want %d", n, len(outbox.dispatched), tt.wantDispatched)
+			}
+			letters, _ := dlq.List(context.Background(), "product.created", 0)
+			if len(letters) != tt.wantLetters {
+				t.Fatalf("Expected %d dead letters, got %d", tt.wantLetters, len(letters))
+			}
+			if len(letters) > 0 && (letters[0].Event.ID != "evt-1" || letters[0].HandlerName != RelayHandlerName) {
+				t.Errorf("Expected evt-1 dead-lettered by the relay, got %+v", letters[0])
+			}
+		})
+	}
+}
+
+func TestInOutbox(t *testing.T) {
+	ctx := context.Background()
+	if InOutbox(ctx) {
+		t.Error("InOutbox() = true without an outbox")
+	}
+	if !InOutbox(ContextWithOutbox(ctx, &memoryOutbox{})) {
+		t.Error("InOutbox() = false with an outbox")
+	}
+}
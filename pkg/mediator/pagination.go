@@ -0,0 +1,149 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// DefaultPageSize is the page size used when a page size <= 0 is requested
+const DefaultPageSize = 100
+
+// PagedEventStore is implemented by event stores that can read events page by page
+type PagedEventStore interface {
+	// GetEventsPage returns up to pageSize events of an event name stored after
+	// cursor, oldest first, and the cursor of the next page. An empty cursor
+	// starts at the oldest event; an empty next cursor means there are no more.
+	// Each event's Offset is the cursor of the page after it.
+	GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]StoredEvent, string, error)
+}
+
+// GetEventsPage returns a page of events of an event name, oldest first, and
+// the cursor of the next page. Stores that do not implement PagedEventStore
+// are read in full and paged in memory.
+func (m *Mediator) GetEventsPage(ctx context.Context, eventName, cursor string, pageSize int) ([]StoredEvent, string, error) {
+	ctx = m.storeContext(ctx)
+	m.mu.RLock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
+
+	if eventStore == nil {
+		return nil, "", fmt.Errorf("no event store configured")
+	}
+
+	var events []StoredEvent
+	var next string
+	err := m.observeStore(ctx, StoreRead, eventName, func() error {
+		var err error
+		events, next, err = pageEvents(ctx, eventStore, namespacedName(m.namespaceOf(ctx), eventName), cursor, pageSize)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := m.rehydrateStored(eventName, events); err != nil {
+		return nil, "", err
+	}
+	return events, next, nil
+}
+
+// pageEvents reads a page from store. Stores that cannot page are read in full,
+// ordered by timestamp and paged by offset.
+func pageEvents(ctx context.Context, store EventStore, eventName, cursor string, pageSize int) ([]StoredEvent, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if paged, ok := store.(PagedEventStore); ok {
+		return paged.GetEventsPage(ctx, eventName, cursor, pageSize)
+	}
+
+	offset := 0
+	if cursor != "" {
+		var err error
+		if offset, err = strconv.Atoi(cursor); err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("invalid cursor %q", cursor)
+		}
+	}
+
+	events, err := AsEventStoreV2(store).ReadEvents(ctx, eventName, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+
+	if offset >= len(events) {
+		return nil, "", nil
+	}
+	end := min(offset+pageSize, len(events))
+	next := ""
+	if end < len(events) {
+		next = strconv.Itoa(end)
+	}
+	events = events[offset:end]
+	for i := range events {
+		events[i].Offset = strconv.Itoa(offset + i + 1)
+	}
+	return events, next, nil
+}
+
+// EventIterator walks the events of an event name page by page, oldest first:
+//
+//	it := med.IterateEvents(ctx, "order.placed", 500)
+//	for it.Next() {
+//		process(it.Event())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type EventIterator struct {
+	ctx       context.Context
+	m         *Mediator
+	eventName string
+	pageSize  int
+	cursor    string
+	page      []StoredEvent
+	event     StoredEvent
+	last      bool
+	err       error
+}
+
+// IterateEvents returns an iterator over the events of an event name that
+// fetches pageSize events at a time
+func (m *Mediator) IterateEvents(ctx context.Context, eventName string, pageSize int) *EventIterator {
+	return &EventIterator{ctx: ctx, m: m, eventName: eventName, pageSize: pageSize}
+}
+
+// Next advances to the next event, fetching the next page when needed. It
+// returns false when there are no more events or a page could not be read.
+func (it *EventIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.last || it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		page, next, err := it.m.GetEventsPage(it.ctx, it.eventName, it.cursor, it.pageSize)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page, it.cursor, it.last = page, next, next == ""
+	}
+
+	it.event, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Event returns the current event
+func (it *EventIterator) Event() StoredEvent {
+	return it.event
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *EventIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,87 @@
+package mediator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMediator_GetEventsPage(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := context.Background()
+	start := time.Now().UTC()
+	for i := 0; i < 5; i++ {
+		event := Event{Name: "order.placed", Payload: i, Timestamp: start.Add(time.Duration(i) * time.Second)}
+		if err := m.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		cursor   string
+		wantLen  int
+		wantNext string
+	}{
+		{"", 2, "2"},
+		{"2", 2, "4"},
+		{"4", 1, ""},
+		{"9", 0, ""},
+	}
+	for _, tt := range tests {
+		events, next, err := m.GetEventsPage(ctx, "order.placed", tt.cursor, 2)
+		if err != nil {
+			t.Fatalf("GetEventsPage(%q) error = %v", tt.cursor, err)
+		}
+		if len(events) != tt.wantLen || next != tt.wantNext {
+			t.Errorf("GetEventsPage(%q) = %d events, next %q, want %d, %q", tt.cursor, len(events), next, tt.wantLen, tt.wantNext)
+		}
+	}
+
+	if _, _, err := m.GetEventsPage(ctx, "order.placed", "not-a-number", 2); err == nil {
+		t.Error("GetEventsPage() expected error for invalid cursor")
+	}
+}
+
+func TestMediator_IterateEvents(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := context.Background()
+	start := time.Now().UTC()
+	for i := 0; i < 7; i++ {
+		event := Event{Name: "order.placed", Payload: i, Timestamp: start.Add(time.Duration(i) * time.Second)}
+		if err := m.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	var payloads []interface{}
+	it := m.IterateEvents(ctx, "order.placed", 3)
+	for it.Next() {
+		payloads = append(payloads, it.Event().Payload)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(payloads) != 7 {
+		t.Fatalf("iterated %d events, want 7", len(payloads))
+	}
+	// Test events are iterated oldest first
+	for i, payload := range payloads {
+		if payload != i {
+			t.Errorf("event %d payload = %v, want %d", i, payload, i)
+		}
+	}
+
+	// Test a cancelled context stops the iteration
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	it = m.IterateEvents(cancelled, "order.placed", 3)
+	if it.Next() || it.Err() == nil {
+		t.Error("Next() on a cancelled context should stop with an error")
+	}
+}
@@ -0,0 +1,282 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Source produces the events a pipeline moves
+type Source interface {
+	// Open streams the events after fromOffset, empty for the oldest, until
+	// ctx is cancelled. Events carry the Offset resuming after them, or none
+	// when the source cannot resume.
+	Open(ctx context.Context, fromOffset string) (<-chan StoredEvent, error)
+}
+
+// Sink receives the events of a pipeline
+type Sink interface {
+	// Write delivers an event; a failed write is retried
+	Write(ctx context.Context, event Event) error
+}
+
+// SinkFunc adapts a function such as Mediator.Publish to a Sink
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Write calls f
+func (f SinkFunc) Write(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Transform changes an event on its way to the sink, or drops it by returning
+// false. Failed transforms are not retried.
+type Transform func(ctx context.Context, event Event) (Event, bool, error)
+
+// OffsetStore keeps the offset each pipeline has reached
+type OffsetStore interface {
+	// LoadOffset returns the saved offset of a pipeline, and false when none is saved
+	LoadOffset(ctx context.Context, pipeline string) (string, bool, error)
+	// SaveOffset saves the offset a pipeline has reached
+	SaveOffset(ctx context.Context, pipeline, offset string) error
+}
+
+// Pipeline moves the events of a source through transforms to a sink, e.g.
+// from one event store to another or from a store to a transport
+type Pipeline struct {
+	// Name identifies the pipeline in offsets, dead letters and logs
+	Name string
+	// Source produces the events
+	Source Source
+	// Transforms run in order on each event
+	Transforms []Transform
+	// Sink receives the events
+	Sink Sink
+	// Offsets resumes the pipeline where a previous run stopped; every run
+	// starts at StartOffset when nil
+	Offsets OffsetStore
+	// StartOffset is where a pipeline without a saved offset starts: empty for
+	// the oldest event or LatestOffset for new events only
+	StartOffset string
+	// RetryPolicy retries failed sink writes; they are attempted once when nil
+	RetryPolicy *RetryPolicy
+	// DeadLetters receives the events that failed a transform or the sink, and
+	// the pipeline moves on; the pipeline stops at the first failure when nil
+	DeadLetters DeadLetterQueue
+}
+
+// RunPipeline runs a pipeline until ctx is cancelled or its source ends,
+// returning nil, or until an event fails without a dead-letter queue,
+// returning the error. The offset is saved after each event, so a pipeline
+// that stopped resumes at the event that failed.
+func (m *Mediator) RunPipeline(ctx context.Context, p Pipeline) error {
+	if p.Source == nil || p.Sink == nil {
+		return fmt.Errorf("pipeline %s needs a source and a sink", p.Name)
+	}
+	if p.Offsets != nil && p.Name == "" {
+		return errors.New("pipeline with offsets needs a name")
+	}
+
+	offset := p.StartOffset
+	if p.Offsets != nil {
+		saved, ok, err := p.Offsets.LoadOffset(ctx, p.Name)
+		if err != nil {
+			return fmt.Errorf("failed to load offset of pipeline %s: %w", p.Name, err)
+		}
+		if ok {
+			offset = saved
+		}
+	}
+
+	events, err := p.Source.Open(ctx, offset)
+	if err != nil {
+		return fmt.Errorf("failed to open source of pipeline %s: %w", p.Name, err)
+	}
+	for {
+		var stored StoredEvent
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return nil
+		case stored, ok = <-events:
+			if !ok {
+				return nil
+			}
+		}
+
+		attempts, err := p.process(ctx, m.timeSource(), stored.Event())
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if p.DeadLetters == nil {
+				return fmt.Errorf("pipeline %s failed at event %s: %w", p.Name, stored.ID, err)
+			}
+			letter := DeadLetter{
+				ID:          newID(),
+				Event:       stored.Event(),
+				HandlerName: "pipeline." + p.Name,
+				Error:       err.Error(),
+				Attempts:    attempts,
+				FailedAt:    m.now(),
+			}
+			if err := p.DeadLetters.Add(ctx, letter); err != nil {
+				return fmt.Errorf("failed to dead-letter event %s of pipeline %s: %w", stored.ID, p.Name, err)
+			}
+			m.logf("pipeline %s dead-lettered event %s: %s", p.Name, stored.ID, letter.Error)
+		}
+
+		if p.Offsets != nil && stored.Offset != "" {
+			if err := p.Offsets.SaveOffset(ctx, p.Name, stored.Offset); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("failed to save offset of pipeline %s: %w", p.Name, err)
+			}
+		}
+	}
+}
+
+// process runs an event through the transforms and writes it to the sink,
+// backing off on clock between attempts, and returns the number of write attempts
+func (p Pipeline) process(ctx context.Context, clock Clock, event Event) (int, error) {
+	for i, transform := range p.Transforms {
+		var keep bool
+		var err error
+		if event, keep, err = transform(ctx, event); err != nil {
+			return 0, fmt.Errorf("transform %d failed: %w", i, err)
+		}
+		if !keep {
+			return 0, nil
+		}
+	}
+
+	attempts, err := p.RetryPolicy.retry(ctx, clock, func() error {
+		return p.Sink.Write(ctx, event)
+	})
+	if err != nil {
+		return attempts, fmt.Errorf("failed to write to sink: %w", err)
+	}
+	return attempts, nil
+}
+
+// StoreSource reads the events of an event name from an event store, then
+// tails it for new ones. Offsets are the page cursors of the store.
+type StoreSource struct {
+	Store     EventStore
+	EventName string
+	// PollInterval is how often stores that cannot push new events are
+	// polled; DefaultStorePollInterval when zero
+	PollInterval time.Duration
+	// Logger reports failed polls; nothing is logged when nil
+	Logger Logger
+}
+
+// Open streams the stored events after fromOffset
+func (s StoreSource) Open(ctx context.Context, fromOffset string) (<-chan StoredEvent, error) {
+	interval := s.PollInterval
+	if interval <= 0 {
+		interval = DefaultStorePollInterval
+	}
+	logf := func(format string, args ...interface{}) {
+		if s.Logger != nil {
+			s.Logger.Printf(format, args...)
+		}
+	}
+	return tailStore(ctx, unwrapStore(s.Store), s.EventName, fromOffset, interval, logf)
+}
+
+// TransportSource receives the events of an event name from a transport. It
+// cannot resume: events are received from the time it opens, without offsets.
+type TransportSource struct {
+	Transport Transport
+	EventName string
+}
+
+// Open subscribes to the event name; fromOffset is ignored
+func (s TransportSource) Open(ctx context.Context, fromOffset string) (<-chan StoredEvent, error) {
+	in, err := s.Transport.Subscribe(s.EventName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to transport: %w", err)
+	}
+
+	out := make(chan StoredEvent)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-in:
+				if !ok {
+					return
+				}
+				stored := StoredEvent{
+					ID:            event.ID,
+					Name:          event.Name,
+					Namespace:     event.Namespace,
+					Payload:       event.Payload,
+					Timestamp:     event.Timestamp,
+					CorrelationID: event.CorrelationID,
+					CausationID:   event.CausationID,
+					Metadata:      event.Metadata,
+				}
+				select {
+				case out <- stored:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// StoreSink stores events in an event store, e.g. to mirror a store into
+// another or export events through a store-shaped producer
+type StoreSink struct {
+	Store EventStore
+}
+
+// Write stores an event
+func (s StoreSink) Write(ctx context.Context, event Event) error {
+	return s.Store.StoreEvent(ctx, event)
+}
+
+// TransportSink publishes events to a transport
+type TransportSink struct {
+	Transport Transport
+}
+
+// Write publishes an event
+func (s TransportSink) Write(ctx context.Context, event Event) error {
+	return s.Transport.Publish(ctx, event)
+}
+
+// MemoryOffsetStore is an in-memory OffsetStore
+type MemoryOffsetStore struct {
+	mu      sync.RWMutex
+	offsets map[string]string
+}
+
+// NewMemoryOffsetStore creates an empty in-memory offset store
+func NewMemoryOffsetStore() *MemoryOffsetStore {
+	return &MemoryOffsetStore{offsets: make(map[string]string)}
+}
+
+// LoadOffset returns the saved offset of a pipeline
+func (s *MemoryOffsetStore) LoadOffset(ctx context.Context, pipeline string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	offset, ok := s.offsets[pipeline]
+	return offset, ok, nil
+}
+
+// SaveOffset saves the offset of a pipeline
+func (s *MemoryOffsetStore) SaveOffset(ctx context.Context, pipeline, offset string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets[pipeline] = offset
+	return nil
+}
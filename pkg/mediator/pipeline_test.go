@@ -0,0 +1,244 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink records the events written to it, failing the writes of
+// events named in fail
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+	fail   map[string]int
+	wrote  chan struct{}
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{fail: make(map[string]int), wrote: make(chan struct{}, 100)}
+}
+
+func (s *recordingSink) Write(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, _ := event.Payload.(string); s.fail[key] > 0 {
+		s.fail[key]--
+		return errors.New("sink unavailable")
+	}
+	s.events = append(s.events, event)
+	s.wrote <- struct{}{}
+	return nil
+}
+
+func (s *recordingSink) payloads() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var payloads []string
+	for _, event := range s.events {
+		payload, _ := event.Payload.(string)
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+// waitWrites waits until the sink has n events
+func (s *recordingSink) waitWrites(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for len(s.payloads()) < n {
+		select {
+		case <-s.wrote:
+		case <-deadline:
+			t.Fatalf("Timed out waiting for %d writes, got %v", n, s.payloads())
+		}
+	}
+}
+
+// storeWith returns a store holding events of "stock.changed" with the payloads
+func storeWith(payloads ...string) *mockEventStore {
+	store := &mockEventStore{}
+	for _, payload := range payloads {
+		store.StoreEvent(context.Background(), Event{Name: "stock.changed", Payload: payload})
+	}
+	return store
+}
+
+func TestMediator_RunPipeline(t *testing.T) {
+	m := NewMediator()
+	store := storeWith("a", "skip", "b")
+	sink := newRecordingSink()
+	offsets := NewMemoryOffsetStore()
+	pipeline := Pipeline{
+		Name:   "mirror",
+		Source: StoreSource{Store: store, EventName: "stock.changed", PollInterval: 10 * time.Millisecond},
+		Transforms: []Transform{
+			func(ctx context.Context, event Event) (Event, bool, error) {
+				return event, event.Payload != "skip", nil
+			},
+			func(ctx context.Context, event Event) (Event, bool, error) {
+				event.Payload = strings.ToUpper(event.Payload.(string))
+				return event, true, nil
+			},
+		},
+		Sink:    sink,
+		Offsets: offsets,
+	}
+
+	run := func() (context.CancelFunc, chan error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- m.RunPipeline(ctx, pipeline) }()
+		return cancel, done
+	}
+
+	cancel, done := run()
+	sink.waitWrites(t, 2)
+	// Test events stored while the pipeline runs are picked up
+	store.StoreEvent(context.Background(), Event{Name: "stock.changed", Payload: "c"})
+	sink.waitWrites(t, 3)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("RunPipeline() error = %v", err)
+	}
+	if offset, _, _ := offsets.LoadOffset(context.Background(), "mirror"); offset != "4" {
+		t.Errorf("Saved offset = %q, want 4", offset)
+	}
+
+	// Test a second run resumes after the saved offset
+	store.StoreEvent(context.Background(), Event{Name: "stock.changed", Payload: "d"})
+	cancel, done = run()
+	sink.waitWrites(t, 4)
+	cancel()
+	<-done
+
+	want := []string{"A", "B", "C", "D"}
+	if got := sink.payloads(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Sink received %v, want %v", got, want)
+	}
+}
+
+func TestMediator_RunPipelineFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		retry       *RetryPolicy
+		deadLetters bool
+		wantErr     bool
+		want        []string
+		wantOffset  string
+	}{
+		{"stops at the failed event", 1, nil, false, true, []string{"a"}, "1"},
+		{"retried", 2, &RetryPolicy{MaxAttempts: 3}, false, false, []string{"a", "b", "c"}, "3"},
+		{"dead-lettered", 5, &RetryPolicy{MaxAttempts: 2}, true, false, []string{"a", "c"}, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := newRecordingSink()
+			sink.fail["b"] = tt.failures
+			offsets := NewMemoryOffsetStore()
+			dlq := NewMemoryDeadLetterQueue()
+			pipeline := Pipeline{
+				Name:        "export",
+				Source:      StoreSource{Store: storeWith("a", "b", "c"), EventName: "stock.changed", PollInterval: 10 * time.Millisecond},
+				Sink:        sink,
+				Offsets:     offsets,
+				RetryPolicy: tt.retry,
+			}
+			if tt.deadLetters {
+				pipeline.DeadLetters = dlq
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- NewMediator().RunPipeline(ctx, pipeline) }()
+
+			if !tt.wantErr {
+				sink.waitWrites(t, len(tt.want))
+				// Let the offset of the last event be saved
+				time.Sleep(20 * time.Millisecond)
+				cancel()
+			}
+			if err := <-done; (err != nil) != tt.wantErr {
+				t.Fatalf("RunPipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := sink.payloads(); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Sink received %v, want %v", got, tt.want)
+			}
+			if offset, _, _ := offsets.LoadOffset(context.Background(), "export"); offset != tt.wantOffset {
+				t.Errorf("Saved offset = %q, want %q", offset, tt.wantOffset)
+			}
+			letters, _ := dlq.List(context.Background(), "stock.changed", 0)
+			if tt.deadLetters && (len(letters) != 1 || letters[0].HandlerName != "pipeline.export" || letters[0].Attempts != 2) {
+				t.Errorf("Expected one dead letter after 2 attempts, got %+v", letters)
+			}
+		})
+	}
+}
+
+func TestMediator_RunPipelineTransport(t *testing.T) {
+	transport := NewMemoryTransport()
+	defer transport.Close()
+	store := &mockEventStore{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewMediator().RunPipeline(ctx, Pipeline{
+			Name:   "archive",
+			Source: TransportSource{Transport: transport, EventName: "stock.changed"},
+			Sink:   StoreSink{Store: store},
+		})
+	}()
+
+	// The transport delivers to subscribers only, so publish until the source
+	// subscribed
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		transport.Publish(ctx, Event{Name: "stock.changed", ID: "evt-1", Payload: "a"})
+		store.mu.Lock()
+		stored := len(store.events)
+		store.mu.Unlock()
+		if stored > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the event to be stored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("RunPipeline() error = %v", err)
+	}
+}
+
+func TestMediator_RunPipelineInvalid(t *testing.T) {
+	sink := SinkFunc(func(ctx context.Context, event Event) error { return nil })
+	source := StoreSource{Store: &mockEventStore{}, EventName: "stock.changed"}
+
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+	}{
+		{"no source", Pipeline{Name: "p", Sink: sink}},
+		{"no sink", Pipeline{Name: "p", Source: source}},
+		{"offsets without a name", Pipeline{Source: source, Sink: sink, Offsets: NewMemoryOffsetStore()}},
+		{"invalid start offset", Pipeline{Name: "p", Source: source, Sink: sink, StartOffset: "abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewMediator().RunPipeline(context.Background(), tt.pipeline); err == nil {
+				t.Error("RunPipeline() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package mediator
+
+// Priority orders PublishAsync events waiting for the same worker
+type Priority int
+
+const (
+	// PriorityLow events run once no normal or high priority event is waiting
+	PriorityLow Priority = iota - 1
+	// PriorityNormal is the priority of events without one
+	PriorityNormal
+	// PriorityHigh events run before any waiting normal or low priority event
+	PriorityHigh
+)
+
+// priorityLanes is the number of queues of each async worker, one per priority
+const priorityLanes = 3
+
+// lane returns the queue index of a priority, high first; priorities above
+// PriorityHigh or below PriorityLow share its lane
+func (p Priority) lane() int {
+	switch {
+	case p > PriorityNormal:
+		return 0
+	case p < PriorityNormal:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// WithEventPriority sets the priority PublishAsync queues events of an event
+// name with, e.g. PriorityHigh for payment.failed or PriorityLow for analytics
+func WithEventPriority(eventName string, priority Priority) Option {
+	return func(m *Mediator) {
+		if m.priorities == nil {
+			m.priorities = make(map[string]Priority)
+		}
+		m.priorities[eventName] = priority
+	}
+}
+
+// WithPublishPriority overrides the priority of one PublishAsync event
+func WithPublishPriority(priority Priority) PublishOption {
+	return func(c *publishConfig) {
+		c.priority = &priority
+	}
+}
+
+// priority returns the priority of an event: the publish option's, or else
+// the one set for its event name
+func (m *Mediator) priority(event Event, opts []PublishOption) Priority {
+	var config publishConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.priority != nil {
+		return *config.priority
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.priorities[event.Name]
+}
+
+// asyncLanes are the queues of an async worker, high priority first
+type asyncLanes [priorityLanes]chan asyncItem
+
+// next returns the highest priority queued event, waiting for one while every
+// lane is empty. It returns false once done is closed and the lanes are drained.
+func (l asyncLanes) next(done <-chan struct{}) (asyncItem, bool) {
+	for {
+		for _, lane := range l {
+			select {
+			case item := <-lane:
+				return item, true
+			default:
+			}
+		}
+
+		select {
+		case item := <-l[0]:
+			return item, true
+		case item := <-l[1]:
+			return item, true
+		case item := <-l[2]:
+			return item, true
+		case <-done:
+			for _, lane := range l {
+				select {
+				case item := <-lane:
+					return item, true
+				default:
+				}
+			}
+			return asyncItem{}, false
+		}
+	}
+}
+
+// depth returns the number of queued events
+func (l asyncLanes) depth() int {
+	depth := 0
+	for _, lane := range l {
+		depth += len(lane)
+	}
+	return depth
+}
@@ -0,0 +1,115 @@
+package mediator
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestPriority_Lane(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority Priority
+		want     int
+	}{
+		{name: "high", priority: PriorityHigh, want: 0},
+		{name: "above high", priority: PriorityHigh + 5, want: 0},
+		{name: "normal", priority: PriorityNormal, want: 1},
+		{name: "low", priority: PriorityLow, want: 2},
+		{name: "below low", priority: PriorityLow - 5, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.priority.lane(); got != tt.want {
+				t.Errorf("lane() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediator_PublishAsyncPriority(t *testing.T) {
+	// A single worker, so every event waits in the same lanes
+	m := NewMediator(
+		WithPartitioning(func(event Event) string { return "" }, 1),
+		WithEventPriority("payment.failed", PriorityHigh),
+		WithEventPriority("page.viewed", PriorityLow),
+	)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	m.Subscribe("blocker", func(ctx context.Context, event Event) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	var mu sync.Mutex
+	var handled []string
+	record := func(ctx context.Context, event Event) error {
+		mu.Lock()
+		handled = append(handled, event.ID)
+		mu.Unlock()
+		return nil
+	}
+	for _, name := range []string{"page.viewed", "order.placed", "payment.failed"} {
+		m.Subscribe(name, record)
+	}
+
+	ctx := context.Background()
+	if err := m.PublishAsync(ctx, Event{Name: "blocker"}); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+	<-started
+
+	// Queue behind the blocked worker, lowest priority first
+	publishes := []struct {
+		event Event
+		opts  []PublishOption
+	}{
+		{event: Event{ID: "view-1", Name: "page.viewed"}},
+		{event: Event{ID: "order-1", Name: "order.placed"}},
+		{event: Event{ID: "view-2", Name: "page.viewed"}},
+		{event: Event{ID: "payment-1", Name: "payment.failed"}},
+		{event: Event{ID: "order-2", Name: "order.placed"}, opts: []PublishOption{WithPublishPriority(PriorityHigh)}},
+		{event: Event{ID: "payment-2", Name: "payment.failed"}, opts: []PublishOption{WithPublishPriority(PriorityNormal)}},
+	}
+	for _, p := range publishes {
+		if err := m.PublishAsync(ctx, p.event, p.opts...); err != nil {
+			t.Fatalf("PublishAsync() error = %v", err)
+		}
+	}
+	if depth := m.QueueDepth(); depth != len(publishes) {
+		t.Errorf("QueueDepth() = %d, want %d", depth, len(publishes))
+	}
+	close(release)
+
+	// Close waits for queued events
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []string{"payment-1", "order-2", "order-1", "payment-2", "view-1", "view-2"}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+}
+
+func TestMediator_PublishAsyncPrioritySynchronous(t *testing.T) {
+	m := NewMediator(WithSynchronousDispatch(), WithEventPriority("page.viewed", PriorityLow))
+	defer m.Close()
+
+	handled := 0
+	m.Subscribe("page.viewed", func(ctx context.Context, event Event) error {
+		handled++
+		return nil
+	})
+
+	// Priorities don't delay synchronous dispatch
+	if err := m.PublishAsync(context.Background(), Event{Name: "page.viewed"}, WithPublishPriority(PriorityLow)); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+	if handled != 1 {
+		t.Errorf("handled %d events, want 1", handled)
+	}
+}
@@ -0,0 +1,557 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrHandlerTimeout is recorded when a handler does not finish within its time limit
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// ErrNoHandlers is returned when an event is published without subscribers
+var ErrNoHandlers = errors.New("no handlers")
+
+// errHandlerSkipped marks a handler that FailFast kept from running
+var errHandlerSkipped = errors.New("handler skipped")
+
+// PublishOption configures a single PublishWith call
+type PublishOption func(*publishConfig)
+
+// publishConfig holds the settings of a single publish
+type publishConfig struct {
+	concurrency    ConcurrencyMode
+	maxConcurrency int
+	errorStrategy  ErrorStrategy
+	deliveryMode   DeliveryMode
+	skipStore      bool
+	localOnly      bool
+	subscription   *Subscription
+	group          string
+	groupOffset    groupOffset
+	priority       *Priority
+}
+
+// publishSettings is the configuration a publish reads. The mediator rebuilds
+// it whenever that configuration changes, so publishes load it without
+// locking; it never changes once stored.
+type publishSettings struct {
+	defaults        publishConfig
+	publishChain    []Middleware
+	middlewares     []Middleware
+	beforePublish   []BeforePublishHook
+	afterPublish    []AfterPublishHook
+	handlerErrHooks []HandlerErrorHook
+	storeHooks      []StoreHook
+	validators      []Validator
+	limits          *Limits
+	strictEvents    bool
+	namespace       string
+	handlerTimeout  time.Duration
+	retryPolicy     *RetryPolicy
+	dedupe          DedupeStore
+	eventStore      EventStore
+	groupStore      GroupStore
+	eventLimiters   map[string]*rateLimiter
+	transports      []Transport
+}
+
+// noSettings are the settings of a mediator not created by NewMediator
+var noSettings publishSettings
+
+// refreshSettings rebuilds the publish settings from the configuration. The
+// caller holds m.mu, or has not shared the mediator yet.
+func (m *Mediator) refreshSettings() {
+	settings := &publishSettings{
+		defaults: publishConfig{
+			concurrency:    m.concurrency,
+			maxConcurrency: m.maxConcurrency,
+			errorStrategy:  m.errorStrategy,
+			deliveryMode:   m.deliveryMode,
+		},
+		publishChain:    m.publishChain,
+		middlewares:     m.middlewares,
+		beforePublish:   m.beforePublish,
+		afterPublish:    m.afterPublish,
+		handlerErrHooks: m.handlerErrHooks,
+		storeHooks:      m.storeHooks,
+		validators:      m.validators,
+		limits:          m.limits,
+		strictEvents:    m.strictEvents,
+		namespace:       m.namespace,
+		handlerTimeout:  m.handlerTimeout,
+		retryPolicy:     m.retryPolicy,
+		dedupe:          m.dedupe,
+		eventStore:      m.eventStore,
+		groupStore:      m.groupStore,
+		eventLimiters:   m.eventLimiters,
+	}
+	if m.remote != nil {
+		settings.transports = m.remote.transports
+	}
+	m.settings.Store(settings)
+}
+
+// publishSettings returns the current publish settings
+func (m *Mediator) publishSettings() *publishSettings {
+	if settings := m.settings.Load(); settings != nil {
+		return settings
+	}
+	return &noSettings
+}
+
+// WithPublishConcurrency overrides the mediator's concurrency mode for one publish
+func WithPublishConcurrency(mode ConcurrencyMode) PublishOption {
+	return func(c *publishConfig) {
+		c.concurrency = mode
+	}
+}
+
+// WithPublishMaxConcurrency overrides the mediator's parallel handler limit for one publish
+func WithPublishMaxConcurrency(n int) PublishOption {
+	return func(c *publishConfig) {
+		c.maxConcurrency = n
+	}
+}
+
+// WithPublishErrorStrategy overrides the mediator's error strategy for one publish
+func WithPublishErrorStrategy(strategy ErrorStrategy) PublishOption {
+	return func(c *publishConfig) {
+		c.errorStrategy = strategy
+	}
+}
+
+// WithPublishDeliveryMode overrides the mediator's delivery mode for one publish
+func WithPublishDeliveryMode(mode DeliveryMode) PublishOption {
+	return func(c *publishConfig) {
+		c.deliveryMode = mode
+	}
+}
+
+// apply applies opts to the config
+func (c *publishConfig) apply(opts []PublishOption) {
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// withoutStore dispatches the event without storing it, e.g. when replaying
+func withoutStore() PublishOption {
+	return func(c *publishConfig) {
+		c.skipStore = true
+	}
+}
+
+// withSubscription dispatches the event to a single subscription only
+func withSubscription(sub *Subscription) PublishOption {
+	return func(c *publishConfig) {
+		c.subscription = sub
+	}
+}
+
+// PublishWith behaves like Publish with per-call options applied on top of the mediator configuration
+func (m *Mediator) PublishWith(ctx context.Context, event Event, opts ...PublishOption) error {
+	if err := m.checkPublish(event); err != nil {
+		return err
+	}
+	if err := m.admit(); err != nil {
+		return err
+	}
+	return m.publish(ctx, event, opts)
+}
+
+// publish is PublishWith without the WithLimits check, for events the
+// mediator already accepted, e.g. queued by PublishAsync
+func (m *Mediator) publish(ctx context.Context, event Event, opts []PublishOption) error {
+	event, err := m.runBeforePublish(ctx, m.scope(ctx, event.inherit(ctx).stamp(m.now())))
+	if err != nil {
+		return err
+	}
+
+	middlewares := m.publishSettings().publishChain
+	if len(middlewares) == 0 {
+		err := m.dispatch(ctx, event, opts)
+		m.runAfterPublish(ctx, event, err)
+		return err
+	}
+
+	// Report the context and event the publish middleware passed on. The
+	// options are copied so the caller's slice does not escape with them.
+	chained := append([]PublishOption(nil), opts...)
+	dispatchCtx, dispatched := ctx, event
+	err = chain(middlewares, func(ctx context.Context, event Event) error {
+		dispatchCtx, dispatched = ctx, event
+		return m.dispatch(ctx, event, chained)
+	})(ctx, event)
+	m.runAfterPublish(dispatchCtx, dispatched, err)
+	return err
+}
+
+// dispatch delivers a stamped event to its handlers and stores it
+func (m *Mediator) dispatch(ctx context.Context, event Event, opts []PublishOption) error {
+	if err := m.validate(ctx, event); err != nil {
+		return err
+	}
+
+	// Inside a transaction, write to the outbox and let the relay dispatch
+	if writer, ok := outboxFromContext(ctx); ok {
+		if err := writer.WriteOutbox(ctx, event); err != nil {
+			return fmt.Errorf("failed to write event to outbox: %w", err)
+		}
+		return nil
+	}
+
+	env := envelopes.Get().(*envelope)
+	defer env.release()
+
+	// Options take the address of the config; keep it in the pooled envelope
+	settings := m.publishSettings()
+	env.config = settings.defaults
+	env.config.apply(opts)
+	config := &env.config
+
+	subs, exists := m.matchingSubscriptions(event.Name)
+	if config.subscription != nil {
+		subs, exists = onlySubscription(subs, config.subscription)
+	}
+	if config.group != "" {
+		subs, exists = groupSubscriptions(subs, config.group)
+	}
+	env.subs = acceptingSubscriptions(env.subs[:0], subs, event)
+	subs = env.subs
+	env.invocations = resize(env.invocations, len(subs))
+	invocations := env.invocations
+	for i, sub := range subs {
+		invocations[i] = invocation{
+			index:   i,
+			name:    sub.name,
+			group:   sub.group,
+			topic:   sub.eventName,
+			limiter: sub.rateLimiter,
+			timeout: settings.handlerTimeout,
+			retry:   settings.retryPolicy,
+			handler: chain(settings.middlewares, sub.handler),
+		}
+		if settings.dedupe != nil {
+			invocations[i].handler = m.dedupeHandler(settings.dedupe, sub.name, invocations[i].handler)
+		}
+		if sub.timeout > 0 {
+			invocations[i].timeout = sub.timeout
+		}
+		if sub.retryPolicy != nil {
+			invocations[i].retry = sub.retryPolicy
+		}
+	}
+	eventStore := settings.eventStore
+	groupStore := settings.groupStore
+	eventLimiter := settings.eventLimiters[event.Name]
+	var transports []Transport
+	if !config.localOnly && !IsRemote(event) {
+		transports = settings.transports
+	}
+
+	// Events replayed or published in a batch are dispatched even with StoreOnly
+	if config.deliveryMode == StoreOnly && !config.skipStore {
+		if eventStore == nil {
+			return fmt.Errorf("no event store configured")
+		}
+		if err := m.storeEvent(ctx, eventStore, event); err != nil {
+			return fmt.Errorf("failed to store event: %w", err)
+		}
+		return nil
+	}
+
+	if !exists && len(transports) == 0 {
+		return fmt.Errorf("%w for event: %s", ErrNoHandlers, event.Name)
+	}
+
+	if err := m.acquireEventRate(ctx, eventLimiter, event.Name); err != nil {
+		return err
+	}
+
+	// Other processes may handle events no local handler subscribes to
+	if !exists {
+		var errs []error
+		if eventStore != nil && !config.skipStore {
+			if err := m.storeEvent(ctx, eventStore, event); err != nil {
+				errs = append(errs, fmt.Errorf("failed to store event: %w", err))
+			}
+		}
+		errs = append(errs, m.forward(ctx, event, transports)...)
+		if len(errs) > 0 {
+			return &PublishError{EventName: event.Name, Errors: errs}
+		}
+		return nil
+	}
+
+	storeFirst := config.deliveryMode == StoreFirst && eventStore != nil && !config.skipStore
+	if storeFirst {
+		if err := m.storeEvent(ctx, eventStore, event); err != nil {
+			return fmt.Errorf("failed to store event: %w", err)
+		}
+	}
+
+	// Persist the event for subscriber groups before any handler runs
+	var offsets map[string]groupOffset
+	if groupStore != nil {
+		if config.group != "" {
+			offsets = map[string]groupOffset{config.group: config.groupOffset}
+		} else {
+			var err error
+			if offsets, err = m.appendToGroups(ctx, groupStore, event, invocations); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Let events published from handlers inherit the correlation of this one,
+	// and reach the mediator through FromContext
+	handlerCtx := contextWithEvent(ctx, m, event)
+
+	failFast := config.errorStrategy == FailFast
+	env.results = resize(env.results, len(invocations))
+	results := env.results
+	if config.concurrency == Parallel {
+		m.runParallel(handlerCtx, event, invocations, results, config.maxConcurrency, failFast)
+	} else {
+		m.runSequential(handlerCtx, event, invocations, results, failFast)
+	}
+
+	var errs []error
+	for _, err := range results {
+		if err != nil && err != errHandlerSkipped {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(offsets) > 0 {
+		errs = append(errs, m.ackGroups(ctx, groupStore, invocations, offsets, results)...)
+	}
+
+	// Store event if event store is configured
+	if eventStore != nil && !config.skipStore && !storeFirst {
+		if err := m.storeEvent(ctx, eventStore, event); err != nil {
+			errs = append(errs, fmt.Errorf("failed to store event: %w", err))
+		}
+	}
+
+	if len(transports) > 0 {
+		errs = append(errs, m.forward(ctx, event, transports)...)
+	}
+
+	if len(errs) > 0 && config.errorStrategy == BestEffort {
+		// Handler failures were logged when they happened
+		for _, err := range errs {
+			if _, ok := err.(*HandlerError); !ok {
+				m.logf("publish of event %s: %v", event.Name, err)
+			}
+		}
+		return nil
+	}
+	if len(errs) > 0 {
+		return &PublishError{EventName: event.Name, Errors: errs}
+	}
+
+	return nil
+}
+
+// onlySubscription narrows subs to sub, reporting whether it is registered
+func onlySubscription(subs []*Subscription, sub *Subscription) ([]*Subscription, bool) {
+	for _, existing := range subs {
+		if existing == sub {
+			return []*Subscription{sub}, true
+		}
+	}
+	return nil, false
+}
+
+// groupSubscriptions narrows subs to the members of a subscriber group,
+// reporting whether there are any
+func groupSubscriptions(subs []*Subscription, group string) ([]*Subscription, bool) {
+	var members []*Subscription
+	for _, sub := range subs {
+		if sub.group == group {
+			members = append(members, sub)
+		}
+	}
+	return members, len(members) > 0
+}
+
+// acceptingSubscriptions appends to accepted the subs not paused whose filters
+// accept the event
+func acceptingSubscriptions(accepted, subs []*Subscription, event Event) []*Subscription {
+	for _, sub := range subs {
+		if !sub.Paused() && sub.accepts(event) {
+			accepted = append(accepted, sub)
+		}
+	}
+	return accepted
+}
+
+// envelope holds the buffers of a single dispatch. Envelopes are pooled so
+// publishing allocates nothing per subscriber; handlers never see them.
+type envelope struct {
+	config      publishConfig
+	subs        []*Subscription
+	invocations []invocation
+	results     []error
+}
+
+// envelopes pools the envelopes of finished dispatches
+var envelopes = sync.Pool{New: func() interface{} { return new(envelope) }}
+
+// release clears the envelope, so the pool keeps no handlers or errors
+// alive, and returns it to the pool
+func (e *envelope) release() {
+	e.config = publishConfig{}
+	clear(e.subs)
+	clear(e.invocations)
+	clear(e.results)
+	envelopes.Put(e)
+}
+
+// resize returns s with length n, reusing its backing array when large enough
+func resize[T any](s []T, n int) []T {
+	if cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// invocation is a handler prepared for a single Publish call
+type invocation struct {
+	index   int
+	name    string
+	group   string
+	topic   string
+	limiter *rateLimiter
+	timeout time.Duration
+	retry   *RetryPolicy
+	handler EventHandler
+}
+
+// invoke runs the handler within its rate limit, retrying per its policy, and
+// wraps a final failure in a *HandlerError
+func (m *Mediator) invoke(ctx context.Context, event Event, inv invocation) error {
+	m.inFlight.Add(1)
+	defer m.inFlight.Add(-1)
+
+	var attempts int
+	err := inv.limiter.acquire(ctx)
+	if err == nil {
+		attempts, err = inv.retry.retry(ctx, m.timeSource(), func() error {
+			if inv.timeout > 0 {
+				return invokeWithTimeout(ctx, event, inv.handler, inv.timeout)
+			}
+			return inv.handler(ctx, event)
+		})
+	}
+	if err == nil {
+		return nil
+	}
+
+	m.logf("handler %s for event %s failed after %d attempt(s): %v", inv.name, event.Name, attempts, err)
+	m.deadLetter(ctx, event, inv, attempts, err)
+	handlerErr := &HandlerError{
+		EventName:    event.Name,
+		HandlerIndex: inv.index,
+		HandlerName:  inv.name,
+		Attempts:     attempts,
+		Err:          err,
+	}
+	m.runHandlerErrorHooks(ctx, event, handlerErr)
+	return handlerErr
+}
+
+// invokeWithTimeout runs handler with a deadline. When the deadline passes the
+// handler's context is cancelled and ErrHandlerTimeout is returned without
+// waiting for the handler, so a stuck handler cannot block Publish.
+func invokeWithTimeout(parent context.Context, event Event, handler EventHandler, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler(ctx, event)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// Report cancellation of the publish itself as such, not as a timeout
+		if err := parent.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w after %s", ErrHandlerTimeout, timeout)
+	}
+}
+
+// runSequential invokes handlers one after another in priority order and
+// records the result of each in results. With failFast the handlers after the
+// first failure are skipped.
+func (m *Mediator) runSequential(ctx context.Context, event Event, invocations []invocation, results []error, failFast bool) {
+	contexts := handlerContexts(ctx, invocations)
+	failed := false
+	for i, inv := range invocations {
+		if failed {
+			results[i] = errHandlerSkipped
+			continue
+		}
+		results[i] = m.invoke(&contexts[i], event, inv)
+		failed = failFast && results[i] != nil
+	}
+}
+
+// runParallel invokes handlers concurrently, at most limit at a time when limit > 0,
+// and records the result of each in results. Like errgroup.WithContext, the first failure
+// cancels the context passed to the other handlers. With failFast the handlers
+// still waiting for a slot are skipped.
+func (m *Mediator) runParallel(ctx context.Context, event Event, invocations []invocation, results []error, limit int, failFast bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	contexts := handlerContexts(ctx, invocations)
+
+	var sem chan struct{}
+	if limit > 0 {
+		sem = make(chan struct{}, limit)
+	}
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for i, inv := range invocations {
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		if failFast && failed.Load() {
+			results[i] = errHandlerSkipped
+			if sem != nil {
+				<-sem
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, inv invocation) {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			if results[i] = m.invoke(&contexts[i], event, inv); results[i] != nil {
+				failed.Store(true)
+				cancel()
+			}
+		}(i, inv)
+	}
+	wg.Wait()
+}
+
+// storeEvent writes event to eventStore, reporting the write to the store hooks
+func (m *Mediator) storeEvent(ctx context.Context, eventStore EventStore, event Event) error {
+	return m.observeStore(ctx, StoreWrite, event.Name, func() error {
+		return eventStore.StoreEvent(ctx, event.stored())
+	})
+}
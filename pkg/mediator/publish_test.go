@@ -0,0 +1,443 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublishWith_MaxConcurrency(t *testing.T) {
+	m := NewMediator(WithConcurrency(Parallel), WithMaxConcurrency(2))
+
+	var running, peak int32
+	for i := 0; i < 6; i++ {
+		m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				old := atomic.LoadInt32(&peak)
+				if current <= old || atomic.CompareAndSwapInt32(&peak, old, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+
+	// Test overriding the limit for a single publish
+	atomic.StoreInt32(&peak, 0)
+	if err := m.PublishWith(context.Background(), Event{Name: "test.event"}, WithPublishMaxConcurrency(3)); err != nil {
+		t.Fatalf("PublishWith() error = %v", err)
+	}
+	if peak != 3 {
+		t.Errorf("peak concurrency = %d, want 3", peak)
+	}
+
+	// Test overriding the mode for a single publish
+	atomic.StoreInt32(&peak, 0)
+	if err := m.PublishWith(context.Background(), Event{Name: "test.event"}, WithPublishConcurrency(Sequential)); err != nil {
+		t.Fatalf("PublishWith() error = %v", err)
+	}
+	if peak != 1 {
+		t.Errorf("peak concurrency = %d, want 1", peak)
+	}
+}
+
+func TestPublishWith_CancelOnError(t *testing.T) {
+	m := NewMediator(WithConcurrency(Parallel))
+
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		return errors.New("fail fast")
+	})
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return errors.New("context was not cancelled")
+		}
+	})
+
+	err := m.Publish(context.Background(), Event{Name: "test.event"})
+
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) {
+		t.Fatalf("Publish() error = %v, want *PublishError", err)
+	}
+	if len(publishErr.Errors) != 2 {
+		t.Fatalf("Errors = %v, want both handler errors", publishErr.Errors)
+	}
+	if !errors.Is(publishErr.Errors[1], context.Canceled) {
+		t.Errorf("second handler error = %v, want context.Canceled", publishErr.Errors[1])
+	}
+}
+
+func TestPublish_HandlerTimeout(t *testing.T) {
+	m := NewMediator(WithHandlerTimeout(20 * time.Millisecond))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var cancelled int32
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		<-ctx.Done()
+		atomic.StoreInt32(&cancelled, 1)
+		return ctx.Err()
+	})
+	// A handler that ignores its context must not block Publish
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		<-release
+		return nil
+	})
+	var completed int32
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		atomic.StoreInt32(&completed, 1)
+		return nil
+	}, WithTimeout(time.Second))
+
+	start := time.Now()
+	err := m.Publish(context.Background(), Event{Name: "test.event"})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Publish() took %s, handlers were not timed out", elapsed)
+	}
+
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) || len(publishErr.Errors) != 2 {
+		t.Fatalf("Publish() error = %v, want two timeouts", err)
+	}
+	if !errors.Is(err, ErrHandlerTimeout) {
+		t.Errorf("Publish() error = %v, want ErrHandlerTimeout", err)
+	}
+	if atomic.LoadInt32(&completed) != 1 {
+		t.Error("remaining handler did not run after timeouts")
+	}
+
+	// The timed out handler observes cancellation
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Error("timed out handler context was not cancelled")
+	}
+}
+
+func TestPublishWith_ErrorStrategy(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        ConcurrencyMode
+		strategy    ErrorStrategy
+		wantCalls   int32
+		wantErrs    int
+		wantSuccess bool
+	}{
+		{"collect all sequential", Sequential, CollectAll, 3, 2, false},
+		{"fail fast sequential", Sequential, FailFast, 1, 1, false},
+		{"best effort sequential", Sequential, BestEffort, 3, 0, true},
+		{"fail fast parallel", Parallel, FailFast, 1, 1, false},
+		{"best effort parallel", Parallel, BestEffort, 3, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One slot makes parallel handlers start in order, so FailFast can skip
+			m := NewMediator(WithConcurrency(tt.mode), WithMaxConcurrency(1))
+
+			var calls int32
+			fail := func(ctx context.Context, event Event) error {
+				atomic.AddInt32(&calls, 1)
+				return errors.New("failed")
+			}
+			m.Subscribe("test.event", fail)
+			m.Subscribe("test.event", fail)
+			m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			})
+
+			err := m.PublishWith(context.Background(), Event{Name: "test.event"}, WithPublishErrorStrategy(tt.strategy))
+			if calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantSuccess {
+				if err != nil {
+					t.Errorf("PublishWith() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var publishErr *PublishError
+			if !errors.As(err, &publishErr) {
+				t.Fatalf("PublishWith() error = %v, want *PublishError", err)
+			}
+			if len(publishErr.Errors) != tt.wantErrs {
+				t.Errorf("Errors = %v, want %d", publishErr.Errors, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestPublish_FailFastKeepsSkippedGroupsPending(t *testing.T) {
+	store := NewMemoryGroupStore()
+	m := NewMediator(WithGroupStore(store), WithErrorStrategy(FailFast))
+
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		return errors.New("failed")
+	}, WithGroup("billing"))
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		t.Error("handler after a FailFast failure was invoked")
+		return nil
+	}, WithGroup("shipping"))
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err == nil {
+		t.Fatal("Publish() expected handler error")
+	}
+
+	// Test the skipped group is not acknowledged, so Recover delivers to it later
+	pending, err := store.Pending(context.Background(), "shipping", "order.placed")
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("Pending() = %d records, want 1", len(pending))
+	}
+}
+
+func TestPublish_DeliveryMode(t *testing.T) {
+	errStore := errors.New("store down")
+
+	tests := []struct {
+		name        string
+		mode        DeliveryMode
+		storeErr    error
+		wantOrder   []string
+		wantErr     bool
+		wantHandled bool
+	}{
+		{"dispatch first", DispatchFirst, nil, []string{"handler", "store"}, false, true},
+		{"store first", StoreFirst, nil, []string{"store", "handler"}, false, true},
+		{"store only", StoreOnly, nil, []string{"store"}, false, false},
+		{"store first aborts on store failure", StoreFirst, errStore, nil, true, false},
+		{"dispatch first reports store failure", DispatchFirst, errStore, []string{"handler"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+			store := &orderedEventStore{mockEventStore: mockEventStore{storeErr: tt.storeErr}, order: &order}
+			m := NewMediator(WithEventStore(store), WithDeliveryMode(tt.mode))
+
+			handled := false
+			m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+				handled = true
+				order = append(order, "handler")
+				return nil
+			})
+
+			err := m.Publish(context.Background(), Event{Name: "test.event"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if handled != tt.wantHandled {
+				t.Errorf("handled = %v, want %v", handled, tt.wantHandled)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestPublish_StoreOnlyReplay(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store), WithDeliveryMode(StoreOnly))
+
+	handled := 0
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		handled++
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if handled != 0 {
+		t.Fatalf("handled = %d before replay, want 0", handled)
+	}
+
+	if n, err := m.Replay(context.Background(), "test.event"); err != nil || n != 1 {
+		t.Fatalf("Replay() = %d, %v, want 1, nil", n, err)
+	}
+	if handled != 1 {
+		t.Errorf("handled = %d after replay, want 1", handled)
+	}
+	if len(store.events) != 1 {
+		t.Errorf("store holds %d events, want 1", len(store.events))
+	}
+}
+
+// orderedEventStore records when events are stored relative to handlers
+type orderedEventStore struct {
+	mockEventStore
+	order *[]string
+}
+
+func (s *orderedEventStore) StoreEvent(ctx context.Context, event Event) error {
+	if err := s.mockEventStore.StoreEvent(ctx, event); err != nil {
+		return err
+	}
+	*s.order = append(*s.order, "store")
+	return nil
+}
+
+// Publish reads its configuration without the mediator lock, so it never
+// waits for a writer holding it
+func TestPublish_WhileLocked(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "sequential"},
+		{name: "parallel", opts: []Option{WithConcurrency(Parallel)}},
+		{name: "store", opts: []Option{WithEventStore(discardEventStore{})}},
+		{name: "publish middleware", opts: []Option{WithPublishMiddleware(func(ctx context.Context, event Event, next EventHandler) error {
+			return next(ctx, event)
+		})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := benchMediator(2, tt.opts...)
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			done := make(chan error, 1)
+			go func() { done <- m.Publish(context.Background(), Event{Name: "bench"}) }()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("Publish() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Publish() waited for the mediator lock")
+			}
+		})
+	}
+}
+
+// The store-less synchronous path allocates the same objects whatever the
+// number of subscribers and options: the context carrying the event and the
+// contexts of the handlers, which handlers may keep, and an ID for events
+// without one
+func TestPublish_Allocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable with the race detector")
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		opts  []Option
+		with  []PublishOption
+		want  float64
+	}{
+		{name: "with ID", event: Event{Name: "bench", ID: "evt-1"}, want: 2},
+		{name: "generated ID", event: Event{Name: "bench"}, want: 3},
+		{name: "publish options", event: Event{Name: "bench", ID: "evt-1"}, with: []PublishOption{WithPublishErrorStrategy(BestEffort), WithPublishMaxConcurrency(1)}, want: 2},
+		{name: "limits", event: Event{Name: "bench", ID: "evt-1"}, opts: []Option{WithLimits(Limits{MaxQueuedEvents: 100, MaxInFlightHandlers: 100})}, want: 2},
+		{name: "fail fast", event: Event{Name: "bench", ID: "evt-1"}, opts: []Option{WithErrorStrategy(FailFast)}, want: 2},
+	}
+
+	for _, tt := range tests {
+		for _, subscribers := range []int{1, 10, 100} {
+			t.Run(fmt.Sprintf("%s/subscribers=%d", tt.name, subscribers), func(t *testing.T) {
+				m := benchMediator(subscribers, tt.opts...)
+				ctx := context.Background()
+				allocs := testing.AllocsPerRun(100, func() {
+					if err := m.PublishWith(ctx, tt.event, tt.with...); err != nil {
+						t.Fatalf("PublishWith() error = %v", err)
+					}
+				})
+				if allocs != tt.want {
+					t.Errorf("PublishWith() allocations = %v, want %v", allocs, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// benchMediator creates a mediator with subscribers handlers of "bench" doing nothing
+func benchMediator(subscribers int, opts ...Option) *Mediator {
+	m := NewMediator(opts...)
+	for i := 0; i < subscribers; i++ {
+		m.Subscribe("bench", func(ctx context.Context, event Event) error { return nil }, WithHandlerName(fmt.Sprintf("handler-%d", i)))
+	}
+	return m
+}
+
+// discardEventStore is an event store dropping every event
+type discardEventStore struct{}
+
+func (discardEventStore) StoreEvent(ctx context.Context, event Event) error { return nil }
+
+func (discardEventStore) GetEvents(ctx context.Context, eventName string, limit int64) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (discardEventStore) ClearEvents(ctx context.Context, eventName string) error { return nil }
+
+// benchmarkPublish publishes to 1, 10 and 100 subscribers of a mediator
+// created with opts; run with -count=10 and compare runs with benchstat
+func benchmarkPublish(b *testing.B, opts ...Option) {
+	for _, subscribers := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("subscribers=%d", subscribers), func(b *testing.B) {
+			m := benchMediator(subscribers, opts...)
+			ctx := context.Background()
+			event := Event{Name: "bench", Payload: "payload"}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := m.Publish(ctx, event); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPublish(b *testing.B) {
+	benchmarkPublish(b)
+}
+
+func BenchmarkPublish_Parallel(b *testing.B) {
+	benchmarkPublish(b, WithConcurrency(Parallel))
+}
+
+func BenchmarkPublish_Store(b *testing.B) {
+	benchmarkPublish(b, WithEventStore(discardEventStore{}))
+}
+
+func BenchmarkPublish_Concurrent(b *testing.B) {
+	m := benchMediator(10)
+	event := Event{Name: "bench", Payload: "payload"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			if err := m.Publish(ctx, event); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
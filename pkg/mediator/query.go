@@ -0,0 +1,172 @@
+package mediator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// EventQuery narrows the events returned by GetEvents and ReadEvents. Zero
+// fields do not filter.
+type EventQuery struct {
+	// Since keeps events published at or after this time
+	Since time.Time
+	// Until keeps events published before this time
+	Until time.Time
+	// CorrelationID keeps events of a single flow
+	CorrelationID string
+	// Metadata keeps events carrying every one of these key/value pairs
+	Metadata map[string]string
+	// Payload keeps events whose JSON payload has every one of these
+	// top-level fields, with values equal to these once encoded as JSON
+	Payload map[string]interface{}
+}
+
+// QueryOption sets a filter of an EventQuery
+type QueryOption func(*EventQuery)
+
+// WithQuerySince keeps events published at or after t
+func WithQuerySince(t time.Time) QueryOption {
+	return func(q *EventQuery) {
+		q.Since = t
+	}
+}
+
+// WithQueryUntil keeps events published before t
+func WithQueryUntil(t time.Time) QueryOption {
+	return func(q *EventQuery) {
+		q.Until = t
+	}
+}
+
+// WithQueryCorrelationID keeps events with the given correlation ID
+func WithQueryCorrelationID(id string) QueryOption {
+	return func(q *EventQuery) {
+		q.CorrelationID = id
+	}
+}
+
+// WithQueryMetadata keeps events whose metadata has key set to value. It can
+// be given several times; every pair must match.
+func WithQueryMetadata(key, value string) QueryOption {
+	return func(q *EventQuery) {
+		if q.Metadata == nil {
+			q.Metadata = make(map[string]string)
+		}
+		q.Metadata[key] = value
+	}
+}
+
+// WithQueryPayload keeps events whose JSON payload has field set to value,
+// compared as JSON. It can be given several times; every field must match.
+func WithQueryPayload(field string, value interface{}) QueryOption {
+	return func(q *EventQuery) {
+		if q.Payload == nil {
+			q.Payload = make(map[string]interface{})
+		}
+		q.Payload[field] = value
+	}
+}
+
+// QueryableEventStore is implemented by event stores that filter events themselves
+type QueryableEventStore interface {
+	// QueryEvents retrieves the most recent events of an event name matching query
+	QueryEvents(ctx context.Context, eventName string, query EventQuery, limit int64) ([]StoredEvent, error)
+}
+
+// Matches reports whether event passes every filter of the query
+func (q EventQuery) Matches(event StoredEvent) bool {
+	if !q.Since.IsZero() && event.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !event.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.CorrelationID != "" && event.CorrelationID != q.CorrelationID {
+		return false
+	}
+	for key, value := range q.Metadata {
+		if got, ok := event.Metadata[key]; !ok || got != value {
+			return false
+		}
+	}
+	if len(q.Payload) > 0 && !payloadMatches(event.Payload, q.Payload) {
+		return false
+	}
+	return true
+}
+
+// payloadMatches reports whether payload, encoded as a JSON object, has every
+// field of fields with an equal JSON value
+func payloadMatches(payload interface{}, fields map[string]interface{}) bool {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return false
+	}
+	for field, value := range fields {
+		got, ok := object[field]
+		if !ok {
+			return false
+		}
+		want, err := json.Marshal(value)
+		if err != nil || !bytes.Equal(canonicalJSON(got), canonicalJSON(want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalJSON re-encodes data so equal values compare equal, whatever their
+// key order and number formatting
+func canonicalJSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return canonical
+}
+
+// newEventQuery builds a query from opts
+func newEventQuery(opts []QueryOption) EventQuery {
+	var query EventQuery
+	for _, opt := range opts {
+		opt(&query)
+	}
+	return query
+}
+
+// queryEvents reads the events of store matching query. Stores that cannot
+// filter are read in full and filtered in memory, most recent first.
+func queryEvents(ctx context.Context, store EventStore, eventName string, query EventQuery, limit int64) ([]StoredEvent, error) {
+	if queryable, ok := store.(QueryableEventStore); ok {
+		return queryable.QueryEvents(ctx, eventName, query, limit)
+	}
+
+	events, err := AsEventStoreV2(store).ReadEvents(ctx, eventName, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := events[:0]
+	for _, event := range events {
+		if query.Matches(event) {
+			matched = append(matched, event)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Timestamp.After(matched[j].Timestamp) })
+
+	if limit > 0 && int64(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+	return matched, nil
+}
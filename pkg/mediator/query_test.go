@@ -0,0 +1,91 @@
+package mediator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventQuery_Matches(t *testing.T) {
+	now := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+	event := StoredEvent{
+		Timestamp:     now,
+		CorrelationID: "corr-1",
+		Metadata:      map[string]string{"tenant": "acme", "source": "api"},
+		Payload: struct {
+			ProductID string
+			Quantity  int
+			Tags      map[string]string
+		}{"p-1", 3, map[string]string{"a": "1", "b": "2"}},
+	}
+
+	tests := []struct {
+		name  string
+		opts  []QueryOption
+		match bool
+	}{
+		{"no filters", nil, true},
+		{"since inclusive", []QueryOption{WithQuerySince(now)}, true},
+		{"since after", []QueryOption{WithQuerySince(now.Add(time.Second))}, false},
+		{"until exclusive", []QueryOption{WithQueryUntil(now)}, false},
+		{"until after", []QueryOption{WithQueryUntil(now.Add(time.Second))}, true},
+		{"correlation", []QueryOption{WithQueryCorrelationID("corr-1")}, true},
+		{"other correlation", []QueryOption{WithQueryCorrelationID("corr-2")}, false},
+		{"metadata", []QueryOption{WithQueryMetadata("tenant", "acme"), WithQueryMetadata("source", "api")}, true},
+		{"metadata mismatch", []QueryOption{WithQueryMetadata("tenant", "acme"), WithQueryMetadata("source", "job")}, false},
+		{"metadata missing", []QueryOption{WithQueryMetadata("region", "eu")}, false},
+		{"payload", []QueryOption{WithQueryPayload("ProductID", "p-1"), WithQueryPayload("Quantity", 3.0)}, true},
+		{"payload object", []QueryOption{WithQueryPayload("Tags", map[string]interface{}{"b": "2", "a": "1"})}, true},
+		{"payload mismatch", []QueryOption{WithQueryPayload("ProductID", "p-2")}, false},
+		{"payload type mismatch", []QueryOption{WithQueryPayload("Quantity", "3")}, false},
+		{"payload missing", []QueryOption{WithQueryPayload("SKU", "p-1")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newEventQuery(tt.opts).Matches(event); got != tt.match {
+				t.Errorf("Matches() = %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
+
+func TestMediator_GetEventsWithQuery(t *testing.T) {
+	store := &v2EventStore{}
+	m := NewMediator(WithEventStore(AsEventStore(store)))
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	events := []Event{
+		{Name: "product.updated", ID: "old", Timestamp: now.Add(-2 * time.Hour), CorrelationID: "corr-x"},
+		{Name: "product.updated", ID: "recent", Timestamp: now.Add(-time.Minute), CorrelationID: "corr-x"},
+		{Name: "product.updated", ID: "latest", Timestamp: now, CorrelationID: "corr-x"},
+		{Name: "product.updated", ID: "other", Timestamp: now, CorrelationID: "corr-y"},
+	}
+	for _, event := range events {
+		if err := m.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	records, err := m.GetEvents(ctx, "product.updated", 10,
+		WithQueryCorrelationID("corr-x"),
+		WithQuerySince(now.Add(-time.Hour)),
+	)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(records) != 2 || records[0]["id"] != "latest" || records[1]["id"] != "recent" {
+		t.Errorf("GetEvents() = %v, want latest and recent", records)
+	}
+
+	// Test the limit keeps the most recent matches
+	stored, err := m.ReadEvents(ctx, "product.updated", 1, WithQueryCorrelationID("corr-x"))
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	if len(stored) != 1 || stored[0].ID != "latest" {
+		t.Errorf("ReadEvents() = %+v, want latest", stored)
+	}
+}
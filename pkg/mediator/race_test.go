@@ -0,0 +1,7 @@
+//go:build race
+
+package mediator
+
+// raceEnabled reports whether tests run with the race detector, which makes
+// sync.Pool drop items at random
+const raceEnabled = true
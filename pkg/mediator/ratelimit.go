@@ -0,0 +1,89 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is returned when an event exceeds a rate limit with the RateLimitReject policy
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitPolicy decides what happens to events beyond a rate limit
+type RateLimitPolicy int
+
+const (
+	// RateLimitWait queues excess events until a token is available or the context ends
+	RateLimitWait RateLimitPolicy = iota
+	// RateLimitReject fails excess events with ErrRateLimited
+	RateLimitReject
+)
+
+// RateLimit configures a token bucket
+type RateLimit struct {
+	// Rate is the number of events allowed per second
+	Rate float64
+	// Burst is the bucket size; values below 1 allow a burst of 1
+	Burst int
+	// Policy decides what happens to excess events
+	Policy RateLimitPolicy
+}
+
+// rateLimiter applies a RateLimit
+type rateLimiter struct {
+	limiter *rate.Limiter
+	policy  RateLimitPolicy
+}
+
+// newRateLimiter creates a token bucket for limit
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		limiter: rate.NewLimiter(rate.Limit(limit.Rate), burst),
+		policy:  limit.Policy,
+	}
+}
+
+// acquire takes a token, waiting or rejecting according to the policy
+func (l *rateLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	if l.policy == RateLimitReject {
+		if !l.limiter.Allow() {
+			return ErrRateLimited
+		}
+		return nil
+	}
+	return l.limiter.Wait(ctx)
+}
+
+// WithRateLimit limits how often the handler is invoked
+func WithRateLimit(limit RateLimit) SubscribeOption {
+	return func(s *Subscription) {
+		s.rateLimiter = newRateLimiter(limit)
+	}
+}
+
+// WithEventRateLimit limits how often events of an event name are dispatched
+func WithEventRateLimit(eventName string, limit RateLimit) Option {
+	return func(m *Mediator) {
+		if m.eventLimiters == nil {
+			m.eventLimiters = make(map[string]*rateLimiter)
+		}
+		m.eventLimiters[eventName] = newRateLimiter(limit)
+	}
+}
+
+// acquireEventRate takes a token from the limiter of an event name, if any
+func (m *Mediator) acquireEventRate(ctx context.Context, limiter *rateLimiter, eventName string) error {
+	if err := limiter.acquire(ctx); err != nil {
+		return fmt.Errorf("event %s: %w", eventName, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubscribe_WithRateLimitReject(t *testing.T) {
+	dlq := NewMemoryDeadLetterQueue()
+	m := NewMediator(WithDeadLetterQueue(dlq))
+
+	calls := 0
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	}, WithRateLimit(RateLimit{Rate: 1, Burst: 2, Policy: RateLimitReject}))
+
+	var rejected int
+	for i := 0; i < 4; i++ {
+		err := m.Publish(context.Background(), Event{Name: "product.updated"})
+		if errors.Is(err, ErrRateLimited) {
+			rejected++
+		} else if err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if calls != 2 || rejected != 2 {
+		t.Errorf("calls=%d rejected=%d, want 2, 2", calls, rejected)
+	}
+
+	// Test rejected events are kept in the dead-letter queue
+	letters, _ := dlq.List(context.Background(), "product.updated", 0)
+	if len(letters) != 2 {
+		t.Errorf("dead letters = %d, want 2", len(letters))
+	}
+}
+
+func TestSubscribe_WithRateLimitWait(t *testing.T) {
+	m := NewMediator()
+
+	calls := 0
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	}, WithRateLimit(RateLimit{Rate: 50, Burst: 1}))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "product.updated"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	// Two events wait for a token at 50 per second
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("3 events took %s, want at least 30ms", elapsed)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+
+	// Test waiting respects context cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.Publish(ctx, Event{Name: "product.updated"}); err == nil {
+		t.Error("Publish() expected error for cancelled context")
+	}
+}
+
+func TestMediator_WithEventRateLimit(t *testing.T) {
+	m := NewMediator(WithEventRateLimit("product.updated", RateLimit{Rate: 1, Burst: 1, Policy: RateLimitReject}))
+
+	calls := 0
+	m.Subscribe("product.updated", func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	})
+	m.Subscribe("product.deleted", func(ctx context.Context, event Event) error { return nil })
+
+	if err := m.Publish(context.Background(), Event{Name: "product.updated"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := m.Publish(context.Background(), Event{Name: "product.updated"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Publish() error = %v, want ErrRateLimited", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	// Test other event names are not limited
+	for i := 0; i < 3; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "product.deleted"}); err != nil {
+			t.Errorf("Publish() error = %v for unlimited event", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mediator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledRecordSize is the capacity above which record buffers are dropped
+// rather than pooled, so one large event doesn't pin its memory
+const maxPooledRecordSize = 64 << 10
+
+// RecordBuffer holds an event record encoded by EncodeEventRecordBuffer in
+// a pooled buffer. Its bytes are valid until Release is called.
+type RecordBuffer struct {
+	buf     bytes.Buffer
+	encoder *json.Encoder
+	record  eventRecord
+}
+
+// recordBuffers pools the record buffers released by stores
+var recordBuffers = sync.Pool{
+	New: func() interface{} {
+		b := new(RecordBuffer)
+		b.encoder = json.NewEncoder(&b.buf)
+		return b
+	},
+}
+
+// EncodeEventRecordBuffer encodes an event like EncodeEventRecord, reusing a
+// pooled buffer and envelope instead of allocating them. Stores call it on
+// their write path and release the buffer once the record has been written:
+//
+//	record, err := mediator.EncodeEventRecordBuffer(serializer, event)
+//	if err != nil {
+//		return err
+//	}
+//	defer record.Release()
+//	_, err = db.ExecContext(ctx, query, record.Bytes())
+func EncodeEventRecordBuffer(serializer Serializer, event Event) (*RecordBuffer, error) {
+	b := recordBuffers.Get().(*RecordBuffer)
+	if err := b.encode(serializer, event); err != nil {
+		b.Release()
+		return nil, err
+	}
+	return b, nil
+}
+
+// encode writes the record of an event to the buffer
+func (b *RecordBuffer) encode(serializer Serializer, event Event) error {
+	defer func() {
+		// Keep no payload alive while pooled
+		b.record = eventRecord{}
+	}()
+	if err := b.record.set(serializer, event); err != nil {
+		return err
+	}
+	return b.encoder.Encode(&b.record)
+}
+
+// Bytes returns the encoded record. They must not be used after Release.
+func (b *RecordBuffer) Bytes() []byte {
+	// Leave out the newline the encoder ends each value with
+	return b.buf.Bytes()[:b.buf.Len()-1]
+}
+
+// Line returns the encoded record followed by a newline, for line-delimited
+// logs. They must not be used after Release.
+func (b *RecordBuffer) Line() []byte {
+	return b.buf.Bytes()
+}
+
+// Release returns the buffer to the pool
+func (b *RecordBuffer) Release() {
+	if b.buf.Cap() > maxPooledRecordSize {
+		return
+	}
+	b.buf.Reset()
+	recordBuffers.Put(b)
+}
@@ -0,0 +1,210 @@
+package mediator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mapRecord encodes an event the way EncodeEventRecord did before records
+// had a type, as a map
+func mapRecord(t *testing.T, serializer Serializer, event Event) []byte {
+	t.Helper()
+	record := map[string]interface{}{
+		"id":             event.ID,
+		"name":           event.Name,
+		"payload":        event.Payload,
+		"timestamp":      event.Timestamp,
+		"correlation_id": event.CorrelationID,
+		"causation_id":   event.CausationID,
+		"metadata":       event.Metadata,
+	}
+	if event.Namespace != "" {
+		record["namespace"] = event.Namespace
+	}
+	if serializer != nil && serializer.ContentType() != jsonContentType {
+		payload, err := serializer.Marshal(event.Payload)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		record["payload"] = payload
+		record["content_type"] = serializer.ContentType()
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
+
+func TestEncodeEventRecordBuffer(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123, time.UTC)
+	tests := []struct {
+		name       string
+		serializer Serializer
+		event      Event
+	}{
+		{
+			name:  "minimal",
+			event: Event{Name: "order.placed"},
+		},
+		{
+			name: "envelope",
+			event: Event{
+				Name:          "order.placed",
+				ID:            "evt-1",
+				Payload:       map[string]interface{}{"id": "o-1", "note": "<b>&</b>"},
+				Timestamp:     at,
+				CorrelationID: "corr-1",
+				CausationID:   "evt-0",
+				Metadata:      map[string]string{"tenant": "acme"},
+				Namespace:     "acme",
+			},
+		},
+		{
+			name:       "json serializer",
+			serializer: JSONSerializer{},
+			event:      Event{Name: "order.placed", Payload: []int{1, 2}, Timestamp: at},
+		},
+		{
+			name:       "other serializer",
+			serializer: GobSerializer{},
+			event:      Event{Name: "order.placed", Payload: "o-1", Timestamp: at, Namespace: "acme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := mapRecord(t, tt.serializer, tt.event)
+
+			encoded, err := EncodeEventRecord(tt.serializer, tt.event)
+			if err != nil {
+				t.Fatalf("EncodeEventRecord() error = %v", err)
+			}
+			if !bytes.Equal(encoded, want) {
+				t.Errorf("EncodeEventRecord() = %s, want %s", encoded, want)
+			}
+
+			record, err := EncodeEventRecordBuffer(tt.serializer, tt.event)
+			if err != nil {
+				t.Fatalf("EncodeEventRecordBuffer() error = %v", err)
+			}
+			defer record.Release()
+			if !bytes.Equal(record.Bytes(), want) {
+				t.Errorf("EncodeEventRecordBuffer() = %s, want %s", record.Bytes(), want)
+			}
+			if line := record.Line(); !bytes.Equal(line, append(want, '\n')) {
+				t.Errorf("Line() = %q, want the record and a newline", line)
+			}
+		})
+	}
+}
+
+func TestEncodeEventRecordBuffer_Reuse(t *testing.T) {
+	long, err := EncodeEventRecordBuffer(nil, Event{Name: "order.placed", Payload: strings.Repeat("x", 1024)})
+	if err != nil {
+		t.Fatalf("EncodeEventRecordBuffer() error = %v", err)
+	}
+	long.Release()
+
+	// Whether or not the pool hands the same buffer back, nothing of the
+	// previous record remains
+	for i := 0; i < 10; i++ {
+		record, err := EncodeEventRecordBuffer(nil, Event{Name: "order.placed", Payload: "short"})
+		if err != nil {
+			t.Fatalf("EncodeEventRecordBuffer() error = %v", err)
+		}
+		if want := mapRecord(t, nil, Event{Name: "order.placed", Payload: "short"}); !bytes.Equal(record.Bytes(), want) {
+			t.Fatalf("EncodeEventRecordBuffer() = %s, want %s", record.Bytes(), want)
+		}
+		if record.record.Payload != nil {
+			t.Errorf("buffer keeps payload %v alive", record.record.Payload)
+		}
+		record.Release()
+	}
+}
+
+// failingSerializer fails to marshal every payload
+type failingSerializer struct{ GobSerializer }
+
+func (failingSerializer) ContentType() string { return "application/x-failing" }
+
+func (failingSerializer) Marshal(v interface{}) ([]byte, error) {
+	return nil, errors.New("unsupported payload")
+}
+
+func TestEncodeEventRecordBuffer_Error(t *testing.T) {
+	record, err := EncodeEventRecordBuffer(failingSerializer{}, Event{Name: "order.placed", Payload: "o-1"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported payload") {
+		t.Errorf("EncodeEventRecordBuffer() error = %v, want unsupported payload", err)
+	}
+	if record != nil {
+		t.Error("EncodeEventRecordBuffer() returned a buffer with an error")
+	}
+}
+
+func TestEncodeEventRecordBuffer_Allocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable with the race detector")
+	}
+
+	event := Event{
+		Name:          "order.placed",
+		ID:            "evt-1",
+		Payload:       "o-1",
+		Timestamp:     time.Now().UTC(),
+		CorrelationID: "evt-1",
+	}
+	encoded := testing.AllocsPerRun(100, func() {
+		if _, err := EncodeEventRecord(nil, event); err != nil {
+			t.Fatal(err)
+		}
+	})
+	pooled := testing.AllocsPerRun(100, func() {
+		record, err := EncodeEventRecordBuffer(nil, event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record.Release()
+	})
+	if pooled >= encoded {
+		t.Errorf("EncodeEventRecordBuffer() allocations = %v, want fewer than EncodeEventRecord's %v", pooled, encoded)
+	}
+}
+
+func BenchmarkEncodeEventRecord(b *testing.B) {
+	event := benchRecordEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncodeEventRecord(nil, event); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeEventRecordBuffer(b *testing.B) {
+	event := benchRecordEvent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		record, err := EncodeEventRecordBuffer(nil, event)
+		if err != nil {
+			b.Fatal(err)
+		}
+		record.Release()
+	}
+}
+
+// benchRecordEvent returns an event with a typical envelope and payload
+func benchRecordEvent() Event {
+	return Event{
+		Name:          "order.placed",
+		ID:            "0123456789abcdef0123456789abcdef",
+		Payload:       map[string]interface{}{"id": "o-1", "total": 42.5, "items": []string{"a", "b"}},
+		Timestamp:     time.Now().UTC(),
+		CorrelationID: "0123456789abcdef0123456789abcdef",
+		Metadata:      map[string]string{"tenant": "acme"},
+	}
+}
@@ -0,0 +1,161 @@
+package mediator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// PayloadFactory returns a new pointer for a payload to be decoded into
+type PayloadFactory func() interface{}
+
+// TypeRegistry maps event names to payload Go types so payloads read back from
+// an event store can be rehydrated into the types live handlers receive
+type TypeRegistry struct {
+	types map[string]payloadType
+	mu    sync.RWMutex
+}
+
+// payloadType describes how to create and unwrap a registered payload
+type payloadType struct {
+	factory PayloadFactory
+	// deref unwraps the factory pointer, so the registered value type is returned
+	deref bool
+}
+
+// NewTypeRegistry creates an empty TypeRegistry
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		types: make(map[string]payloadType),
+	}
+}
+
+// Register maps an event name to a factory. Decoded payloads are the pointer
+// returned by the factory, e.g. func() interface{} { return &product.Product{} }.
+func (r *TypeRegistry) Register(eventName string, factory PayloadFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[eventName] = payloadType{factory: factory}
+}
+
+// RegisterType maps an event name to the payload type T; decoded payloads are
+// values of type T, so register *product.Product to receive pointers
+func RegisterType[T any](r *TypeRegistry, eventName string) {
+	typ := typeOf[T]()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[eventName] = payloadType{
+		factory: func() interface{} { return reflect.New(typ).Interface() },
+		deref:   true,
+	}
+}
+
+// Lookup reports whether a payload type is registered for an event name
+func (r *TypeRegistry) Lookup(eventName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.types[eventName]
+	return exists
+}
+
+// Decode converts a generic payload of an event into its registered type.
+// Payloads of unregistered events, and payloads that are not generic maps,
+// slices, JSON or raw bytes, are returned unchanged.
+func (r *TypeRegistry) Decode(eventName string, payload interface{}, serializer Serializer) (interface{}, error) {
+	r.mu.RLock()
+	typ, exists := r.types[eventName]
+	r.mu.RUnlock()
+
+	if !exists || !isGenericPayload(payload) {
+		return payload, nil
+	}
+
+	target := typ.factory()
+	if value := reflect.ValueOf(target); value.Kind() != reflect.Pointer || value.IsNil() {
+		return nil, fmt.Errorf("payload factory for event %s returned %T, want a non-nil pointer", eventName, target)
+	}
+	if err := decodePayload(payload, target, serializer); err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventName, err)
+	}
+	if typ.deref {
+		return reflect.ValueOf(target).Elem().Interface(), nil
+	}
+	return target, nil
+}
+
+// isGenericPayload reports whether a payload still needs decoding into a Go type
+func isGenericPayload(payload interface{}) bool {
+	switch payload.(type) {
+	case map[string]interface{}, []interface{}, json.RawMessage, []byte:
+		return true
+	}
+	return false
+}
+
+// WithTypeRegistry sets the registry used to rehydrate stored payloads
+func WithTypeRegistry(registry *TypeRegistry) Option {
+	return func(m *Mediator) {
+		m.typeRegistry = registry
+	}
+}
+
+// rehydrate converts a stored payload of an event into the type live handlers
+// receive: the registered type, or else the type bound by SubscribeTyped
+func (m *Mediator) rehydrate(eventName string, payload interface{}) (interface{}, error) {
+	m.mu.RLock()
+	registry := m.typeRegistry
+	bound, isBound := m.payloadTypes[eventName]
+	serializer := m.serializer
+	m.mu.RUnlock()
+
+	if registry != nil && registry.Lookup(eventName) {
+		return registry.Decode(eventName, payload, serializer)
+	}
+	if !isBound || !isGenericPayload(payload) {
+		return payload, nil
+	}
+
+	target := reflect.New(bound)
+	if err := decodePayload(payload, target.Interface(), serializer); err != nil {
+		return nil, fmt.Errorf("event %s: %w", eventName, err)
+	}
+	return target.Elem().Interface(), nil
+}
+
+// rehydrateStoredPayload converts the payload of a stored event like rehydrate. When
+// the payload type is known it is decoded from the payload as stored rather
+// than its generic form, so integers beyond float64 precision survive, as do
+// payloads of serializers whose generic form isn't a map[string]interface{}.
+func (m *Mediator) rehydrateStoredPayload(eventName string, event StoredEvent) (interface{}, error) {
+	if raw := m.rawPayload(eventName, event); raw != nil {
+		return m.rehydrate(eventName, raw)
+	}
+	return m.rehydrate(eventName, event.Payload)
+}
+
+// rawPayload returns the stored payload of event in the form decodePayload
+// decodes with its serializer, or nil when the payload type is unknown or the
+// payload can't be decoded from its stored form
+func (m *Mediator) rawPayload(eventName string, event StoredEvent) interface{} {
+	if len(event.RawPayload) == 0 || event.Payload == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	registry := m.typeRegistry
+	_, bound := m.payloadTypes[eventName]
+	serializer := m.serializer
+	m.mu.RUnlock()
+	if !bound && (registry == nil || !registry.Lookup(eventName)) {
+		return nil
+	}
+
+	switch {
+	case event.ContentType == "" || event.ContentType == jsonContentType:
+		return json.RawMessage(event.RawPayload)
+	case serializer != nil && serializer.ContentType() == event.ContentType:
+		return event.RawPayload
+	}
+	return nil
+}
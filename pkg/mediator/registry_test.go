@@ -0,0 +1,150 @@
+package mediator
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func TestTypeRegistry_Decode(t *testing.T) {
+	r := NewTypeRegistry()
+	RegisterType[testProduct](r, "product.created")
+	r.Register("product.updated", func() interface{} { return &testProduct{} })
+	r.Register("product.broken", func() interface{} { return testProduct{} })
+
+	generic := map[string]interface{}{"id": "p-1", "price": 9.5}
+	want := testProduct{ID: "p-1", Price: 9.5}
+
+	tests := []struct {
+		name      string
+		eventName string
+		payload   interface{}
+		want      interface{}
+		wantErr   bool
+	}{
+		{name: "registered type", eventName: "product.created", payload: generic, want: want},
+		{name: "raw json", eventName: "product.created", payload: json.RawMessage(`{"id":"p-1","price":9.5}`), want: want},
+		{name: "factory pointer", eventName: "product.updated", payload: generic, want: &want},
+		{name: "unregistered", eventName: "product.deleted", payload: generic, want: generic},
+		{name: "already typed", eventName: "product.created", payload: want, want: want},
+		{name: "invalid factory", eventName: "product.broken", payload: generic, wantErr: true},
+		{name: "mismatched payload", eventName: "product.created", payload: map[string]interface{}{"price": "free"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Decode(tt.eventName, tt.payload, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			switch want := tt.want.(type) {
+			case *testProduct:
+				if p, ok := got.(*testProduct); !ok || *p != *want {
+					t.Errorf("Decode() = %#v, want %#v", got, want)
+				}
+			case testProduct:
+				if got != want {
+					t.Errorf("Decode() = %#v, want %#v", got, want)
+				}
+			default:
+				if _, ok := got.(map[string]interface{}); !ok {
+					t.Errorf("Decode() = %#v, want unchanged map", got)
+				}
+			}
+		})
+	}
+}
+
+func TestMediator_GetEventsRehydratesPayloads(t *testing.T) {
+	registry := NewTypeRegistry()
+	RegisterType[*testProduct](registry, "product.created")
+
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store), WithTypeRegistry(registry))
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error { return nil })
+
+	// Publish the generic form a store hands back after a JSON round trip
+	payload := map[string]interface{}{"id": "p-1", "price": 9.5}
+	if err := m.Publish(context.Background(), Event{Name: "product.created", Payload: payload}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	events, err := m.GetEvents(context.Background(), "product.created", 10)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("GetEvents() returned %d events, want 1", len(events))
+	}
+	if p, ok := events[0]["payload"].(*testProduct); !ok || p.ID != "p-1" {
+		t.Errorf("GetEvents() payload = %#v, want *testProduct", events[0]["payload"])
+	}
+}
+
+func TestMediator_GetEventsUsesTypedBinding(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+	if _, err := SubscribeTyped(m, "product.created", func(ctx context.Context, p testProduct) error { return nil }); err != nil {
+		t.Fatalf("SubscribeTyped() error = %v", err)
+	}
+
+	payload := map[string]interface{}{"id": "p-1", "price": 9.5}
+	if err := m.Publish(context.Background(), Event{Name: "product.created", Payload: payload}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	events, err := m.GetEvents(context.Background(), "product.created", 10)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if p, ok := events[0]["payload"].(testProduct); !ok || p.ID != "p-1" {
+		t.Errorf("GetEvents() payload = %#v, want testProduct", events[0]["payload"])
+	}
+}
+
+func TestMediator_ReadEventsDecodesStoredPayload(t *testing.T) {
+	type counter struct {
+		Count int64
+		Total uint64
+	}
+	// Beyond float64 precision, so the generic form of the payload loses them
+	want := counter{Count: 1<<53 + 1, Total: 1<<64 - 1}
+
+	tests := []struct {
+		name  string
+		store Serializer
+		m     Serializer
+	}{
+		{name: "json", store: JSONSerializer{}},
+		{name: "mediator serializer", store: GobSerializer{}, m: GobSerializer{}},
+		{name: "other serializer", store: GobSerializer{}},
+	}
+	gob.Register(counter{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewTypeRegistry()
+			RegisterType[counter](registry, "counted")
+			opts := []Option{WithEventStore(newRecordStore(tt.store)), WithTypeRegistry(registry), WithDeliveryMode(StoreOnly)}
+			if tt.m != nil {
+				opts = append(opts, WithSerializer(tt.m))
+			}
+			m := NewMediator(opts...)
+
+			if err := m.Publish(context.Background(), Event{Name: "counted", Payload: want}); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			events, err := m.ReadEvents(context.Background(), "counted", 10)
+			if err != nil {
+				t.Fatalf("ReadEvents() error = %v", err)
+			}
+			if len(events) != 1 || events[0].Payload != want {
+				t.Errorf("ReadEvents() payload = %#v, want %#v", events[0].Payload, want)
+			}
+		})
+	}
+}
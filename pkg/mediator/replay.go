@@ -0,0 +1,100 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ReplayMetadataKey is the metadata key WithReplayFlag sets on replayed events
+const ReplayMetadataKey = "replay"
+
+// ReplayOption configures a Replay call
+type ReplayOption func(*replayConfig)
+
+// replayConfig holds the settings of a single replay
+type replayConfig struct {
+	limit        int64
+	flag         bool
+	subscription *Subscription
+}
+
+// WithReplayLimit replays at most the n most recent events; n <= 0 uses the store default
+func WithReplayLimit(n int64) ReplayOption {
+	return func(c *replayConfig) {
+		c.limit = n
+	}
+}
+
+// WithReplayFlag marks replayed events with the "replay" metadata key so
+// handlers can skip side effects; see IsReplay
+func WithReplayFlag() ReplayOption {
+	return func(c *replayConfig) {
+		c.flag = true
+	}
+}
+
+// WithReplaySubscription replays to a single subscription only, e.g. to build
+// the read model of a newly added subscriber
+func WithReplaySubscription(sub *Subscription) ReplayOption {
+	return func(c *replayConfig) {
+		c.subscription = sub
+	}
+}
+
+// IsReplay reports whether an event is being replayed with WithReplayFlag
+func IsReplay(event Event) bool {
+	return event.Metadata[ReplayMetadataKey] == "true"
+}
+
+// Replay reads the stored events of an event name and dispatches them, oldest
+// first, to the current subscribers. Replayed events are not stored again.
+// It returns the number of events replayed before the first failure.
+func (m *Mediator) Replay(ctx context.Context, eventName string, opts ...ReplayOption) (int, error) {
+	var config replayConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	stored, err := m.ReadEvents(ctx, eventName, config.limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	events := make([]Event, len(stored))
+	for i, event := range stored {
+		events[i] = event.Event()
+	}
+	// Stores return events in different orders; replay them chronologically
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+
+	publishOpts := []PublishOption{withoutStore(), localOnly()}
+	if config.subscription != nil {
+		publishOpts = append(publishOpts, withSubscription(config.subscription))
+	}
+
+	for i, event := range events {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if config.flag {
+			event.Metadata = withMetadata(event.Metadata, ReplayMetadataKey, "true")
+		}
+		if err := m.PublishWith(ctx, event, publishOpts...); err != nil {
+			return i, fmt.Errorf("failed to replay event %s: %w", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// withMetadata returns a copy of metadata with key set to value
+func withMetadata(metadata map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
@@ -0,0 +1,114 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMediator_Replay(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+
+	live := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		live++
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "order.placed", Payload: i}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// Test replaying into a newly added subscriber only
+	var replayed []Event
+	readModel := m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		replayed = append(replayed, event)
+		return nil
+	})
+
+	n, err := m.Replay(context.Background(), "order.placed", WithReplaySubscription(readModel), WithReplayFlag())
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if n != 3 || len(replayed) != 3 {
+		t.Fatalf("Replay() = %d, handler received %d events, want 3", n, len(replayed))
+	}
+	if live != 3 {
+		t.Errorf("live handler called %d times, want 3", live)
+	}
+	for i, event := range replayed {
+		if event.Payload != i {
+			t.Errorf("replayed[%d].Payload = %v, want %d", i, event.Payload, i)
+		}
+		if !IsReplay(event) {
+			t.Errorf("replayed[%d] not flagged as replay", i)
+		}
+	}
+	if len(store.events) != 3 {
+		t.Errorf("store holds %d events after replay, want 3", len(store.events))
+	}
+}
+
+func TestMediator_ReplayStopsOnError(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store))
+
+	calls := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		calls++
+		if calls > 3 {
+			return errors.New("projection failed")
+		}
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "order.placed"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	n, err := m.Replay(context.Background(), "order.placed")
+	if err == nil {
+		t.Fatal("Replay() expected error")
+	}
+	if n != 0 {
+		t.Errorf("Replay() = %d, want 0", n)
+	}
+	if IsReplay(Event{}) {
+		t.Error("IsReplay() = true for event without flag")
+	}
+}
+
+func TestMediator_ReplayWithoutStore(t *testing.T) {
+	m := NewMediator()
+	if _, err := m.Replay(context.Background(), "order.placed"); err == nil {
+		t.Error("Replay() expected error without event store")
+	}
+}
+
+func TestEventFromRecord(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	record := map[string]interface{}{
+		"id":             "evt-1",
+		"name":           "order.placed",
+		"payload":        map[string]interface{}{"total": 10.0},
+		"timestamp":      ts.Format(time.RFC3339Nano),
+		"correlation_id": "corr-1",
+		"causation_id":   "evt-0",
+		"metadata":       map[string]interface{}{"tenant": "acme"},
+	}
+
+	event := eventFromRecord(record)
+	if event.ID != "evt-1" || event.Name != "order.placed" || event.CorrelationID != "corr-1" || event.CausationID != "evt-0" {
+		t.Errorf("eventFromRecord() envelope = %+v", event)
+	}
+	if !event.Timestamp.Equal(ts) {
+		t.Errorf("eventFromRecord() Timestamp = %v, want %v", event.Timestamp, ts)
+	}
+	if event.Metadata["tenant"] != "acme" {
+		t.Errorf("eventFromRecord() Metadata = %v", event.Metadata)
+	}
+}
@@ -0,0 +1,151 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCompactionNotSupported is returned by DeleteBefore when the event store cannot delete events by age
+var ErrCompactionNotSupported = errors.New("event store does not delete events by age")
+
+// RetentionPolicy decides which stored events of an event name are kept. The
+// zero policy keeps events forever; when both limits are set an event must
+// satisfy both to be kept.
+type RetentionPolicy struct {
+	// MaxCount keeps the most recent events up to this count; 0 means no limit
+	MaxCount int64
+	// MaxAge keeps events younger than this; 0 means no limit
+	MaxAge time.Duration
+}
+
+// KeepLast returns a policy keeping the n most recent events
+func KeepLast(n int64) RetentionPolicy {
+	return RetentionPolicy{MaxCount: n}
+}
+
+// KeepFor returns a policy keeping events younger than d
+func KeepFor(d time.Duration) RetentionPolicy {
+	return RetentionPolicy{MaxAge: d}
+}
+
+// KeepForever returns a policy keeping every event
+func KeepForever() RetentionPolicy {
+	return RetentionPolicy{}
+}
+
+// Forever reports whether the policy keeps every event
+func (p RetentionPolicy) Forever() bool {
+	return p.MaxCount <= 0 && p.MaxAge <= 0
+}
+
+// Retention holds the retention policy of every event name
+type Retention struct {
+	// Default applies to event names without a policy of their own
+	Default RetentionPolicy
+	// Events holds policies by stored event name; namespaced streams are
+	// named namespace:name
+	Events map[string]RetentionPolicy
+}
+
+// Policy returns the policy of an event name
+func (r Retention) Policy(eventName string) RetentionPolicy {
+	if policy, ok := r.Events[eventName]; ok {
+		return policy
+	}
+	return r.Default
+}
+
+// Validate reports policies with a negative limit
+func (r Retention) Validate() error {
+	if err := r.Default.validate(); err != nil {
+		return fmt.Errorf("default retention policy: %w", err)
+	}
+	for eventName, policy := range r.Events {
+		if err := policy.validate(); err != nil {
+			return fmt.Errorf("retention policy of %s: %w", eventName, err)
+		}
+	}
+	return nil
+}
+
+// validate reports a negative limit
+func (p RetentionPolicy) validate() error {
+	if p.MaxCount < 0 {
+		return fmt.Errorf("negative MaxCount %d", p.MaxCount)
+	}
+	if p.MaxAge < 0 {
+		return fmt.Errorf("negative MaxAge %s", p.MaxAge)
+	}
+	return nil
+}
+
+// CompactingEventStore is implemented by event stores that can delete events by age
+type CompactingEventStore interface {
+	// DeleteBefore removes the events of an event name stored before t
+	DeleteBefore(ctx context.Context, eventName string, t time.Time) error
+}
+
+// RetentionEnforcer is implemented by event stores that apply retention policies
+type RetentionEnforcer interface {
+	// EnforceRetention removes the events of every stream its policy no longer keeps
+	EnforceRetention(ctx context.Context) error
+}
+
+// DeleteBefore removes the events of an event name stored before t, e.g. to
+// compact a stream by hand
+func (m *Mediator) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	ctx = m.storeContext(ctx)
+	m.mu.RLock()
+	eventStore := m.eventStore
+	m.mu.RUnlock()
+
+	if eventStore == nil {
+		return fmt.Errorf("no event store configured")
+	}
+	return deleteBefore(ctx, eventStore, namespacedName(m.namespaceOf(ctx), eventName), t)
+}
+
+// deleteBefore removes the events of store stored before t
+func deleteBefore(ctx context.Context, store EventStore, eventName string, t time.Time) error {
+	compacting, ok := store.(CompactingEventStore)
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+	if err := compacting.DeleteBefore(ctx, eventName, t); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return nil
+}
+
+// WithRetentionJanitor has the event store enforce its retention policies
+// every interval of the mediator's clock in the background until Close.
+// Stores that do not implement RetentionEnforcer are left alone.
+func WithRetentionJanitor(interval time.Duration) Option {
+	return func(m *Mediator) {
+		m.janitorInterval = interval
+	}
+}
+
+// startJanitor enforces the retention policies of store every interval
+func (m *Mediator) startJanitor(store RetentionEnforcer, interval time.Duration) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		clock := m.timeSource()
+		for sleep(clock, interval, done) {
+			if err := store.EnforceRetention(context.Background()); err != nil {
+				m.logf("retention janitor: %v", err)
+			}
+		}
+	}()
+
+	m.onClose(func() error {
+		close(done)
+		<-stopped
+		return nil
+	})
+}
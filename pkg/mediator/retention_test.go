@@ -0,0 +1,117 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// compactingEventStore records retention calls
+type compactingEventStore struct {
+	mockEventStore
+	deleted   string
+	before    time.Time
+	enforced  atomic.Int32
+	enforcedC chan struct{}
+}
+
+func (s *compactingEventStore) DeleteBefore(ctx context.Context, eventName string, t time.Time) error {
+	s.deleted, s.before = eventName, t
+	return nil
+}
+
+func (s *compactingEventStore) EnforceRetention(ctx context.Context) error {
+	if s.enforced.Add(1) == 1 {
+		close(s.enforcedC)
+	}
+	return nil
+}
+
+func TestRetention_Policy(t *testing.T) {
+	retention := Retention{
+		Default: KeepLast(100),
+		Events: map[string]RetentionPolicy{
+			"audit.logged":      KeepForever(),
+			"acme:session.seen": KeepFor(time.Hour),
+		},
+	}
+
+	tests := []struct {
+		eventName string
+		want      RetentionPolicy
+		forever   bool
+	}{
+		{"order.placed", RetentionPolicy{MaxCount: 100}, false},
+		{"audit.logged", RetentionPolicy{}, true},
+		{"acme:session.seen", RetentionPolicy{MaxAge: time.Hour}, false},
+		{"session.seen", RetentionPolicy{MaxCount: 100}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventName, func(t *testing.T) {
+			got := retention.Policy(tt.eventName)
+			if got != tt.want {
+				t.Errorf("Policy() = %+v, want %+v", got, tt.want)
+			}
+			if got.Forever() != tt.forever {
+				t.Errorf("Forever() = %v, want %v", got.Forever(), tt.forever)
+			}
+		})
+	}
+}
+
+func TestRetention_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention Retention
+		wantErr   bool
+	}{
+		{name: "zero", retention: Retention{}},
+		{name: "policies", retention: Retention{Default: KeepLast(10), Events: map[string]RetentionPolicy{"audit": KeepForever()}}},
+		{name: "negative default count", retention: Retention{Default: KeepLast(-1)}, wantErr: true},
+		{name: "negative event age", retention: Retention{Events: map[string]RetentionPolicy{"audit": KeepFor(-time.Hour)}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.retention.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMediator_DeleteBefore(t *testing.T) {
+	ctx := context.Background()
+	cutoff := time.Now().Add(-time.Hour)
+
+	store := &compactingEventStore{enforcedC: make(chan struct{})}
+	m := NewMediator(WithEventStore(store), WithNamespace("acme"), WithBufferedStore(DefaultBufferConfig()))
+	defer m.Close()
+	if err := m.DeleteBefore(ctx, "order.placed", cutoff); err != nil {
+		t.Fatalf("DeleteBefore() error = %v", err)
+	}
+	if store.deleted != "acme:order.placed" || !store.before.Equal(cutoff) {
+		t.Errorf("DeleteBefore() deleted %s before %s, want acme:order.placed before %s", store.deleted, store.before, cutoff)
+	}
+
+	m = NewMediator(WithEventStore(&mockEventStore{}))
+	if err := m.DeleteBefore(ctx, "order.placed", cutoff); !errors.Is(err, ErrCompactionNotSupported) {
+		t.Errorf("DeleteBefore() error = %v, want ErrCompactionNotSupported", err)
+	}
+}
+
+func TestWithRetentionJanitor(t *testing.T) {
+	store := &compactingEventStore{enforcedC: make(chan struct{})}
+	m := NewMediator(WithEventStore(store), WithRetentionJanitor(5*time.Millisecond))
+
+	defer m.Close()
+
+	select {
+	case <-store.enforcedC:
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not enforce retention")
+	}
+}
@@ -0,0 +1,92 @@
+package mediator
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how a failing handler is retried during Publish
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first; values below 2 disable retries
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts; zero means no cap
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after every retry; values below 1 keep it constant
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction (0 to 1) in either direction
+	Jitter float64
+	// Retryable decides whether an error is worth retrying; nil retries every error
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns an exponential backoff policy with three attempts
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetryPolicy sets the default retry policy for every handler
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(m *Mediator) {
+		m.retryPolicy = &policy
+	}
+}
+
+// WithRetry sets the retry policy of a handler, overriding the mediator default
+func WithRetry(policy RetryPolicy) SubscribeOption {
+	return func(s *Subscription) {
+		s.retryPolicy = &policy
+	}
+}
+
+// backoff returns the delay before the given retry (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	if p.Multiplier > 1 {
+		delay *= math.Pow(p.Multiplier, float64(retry-1))
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// retry calls attempt until it succeeds, the policy gives up or ctx is done,
+// waiting on clock between attempts. It returns the last error and the number
+// of attempts made.
+func (p *RetryPolicy) retry(ctx context.Context, clock Clock, attempt func() error) (int, error) {
+	err := attempt()
+	if p == nil {
+		return 1, err
+	}
+
+	attempts := 1
+	for err != nil && attempts < p.MaxAttempts {
+		if p.Retryable != nil && !p.Retryable(err) {
+			break
+		}
+
+		if !sleep(clock, p.backoff(attempts), ctx.Done()) {
+			return attempts, err
+		}
+
+		attempts++
+		err = attempt()
+	}
+	return attempts, err
+}
@@ -0,0 +1,102 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		if got := policy.backoff(1); got < 5*time.Millisecond || got > 15*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter = %s, want within 5ms-15ms", got)
+		}
+	}
+}
+
+func TestPublish_Retry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	m := NewMediator(WithRetryPolicy(policy))
+
+	// Test a transient failure that recovers
+	calls := 0
+	m.Subscribe("test.transient", func(ctx context.Context, event Event) error {
+		calls++
+		if calls < 3 {
+			return errors.New("db blip")
+		}
+		return nil
+	})
+	if err := m.Publish(context.Background(), Event{Name: "test.transient"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("handler called %d times, want 3", calls)
+	}
+
+	// Test a persistent failure exhausting the attempts
+	m.Subscribe("test.persistent", func(ctx context.Context, event Event) error {
+		return errors.New("down")
+	})
+	err := m.Publish(context.Background(), Event{Name: "test.persistent"})
+	var handlerErr *HandlerError
+	if !errors.As(err, &handlerErr) || handlerErr.Attempts != 3 {
+		t.Errorf("Publish() error = %v, want HandlerError after 3 attempts", err)
+	}
+}
+
+func TestPublish_RetryOverride(t *testing.T) {
+	errPermanent := errors.New("permanent")
+	m := NewMediator(WithRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}))
+
+	calls := 0
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls++
+		return errPermanent
+	}, WithRetry(RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, errPermanent) },
+	}))
+
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err == nil {
+		t.Fatal("Publish() expected error")
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1 for non-retryable error", calls)
+	}
+}
+
+func TestPublish_RetryStopsOnCancel(t *testing.T) {
+	m := NewMediator(WithRetryPolicy(RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour}))
+
+	calls := 0
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls++
+		return errors.New("fail")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Publish(ctx, Event{Name: "test.event"}); err == nil {
+		t.Fatal("Publish() expected error")
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,568 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrSagaNotFound is returned by Saga.Load for a correlation ID without an instance
+var ErrSagaNotFound = errors.New("saga instance not found")
+
+// SagaStatus is the lifecycle status of a saga instance
+type SagaStatus string
+
+const (
+	// SagaActive instances react to events and timeouts
+	SagaActive SagaStatus = "active"
+	// SagaCompleted instances finished successfully
+	SagaCompleted SagaStatus = "completed"
+	// SagaCompensated instances failed and ran their compensations
+	SagaCompensated SagaStatus = "compensated"
+	// SagaFailed instances failed and a compensation failed too
+	SagaFailed SagaStatus = "failed"
+)
+
+// SagaConfig holds configuration for a Saga
+type SagaConfig struct {
+	// CheckInterval is how often timeouts are checked
+	CheckInterval time.Duration
+	// Store persists the instances, one record each; it is required
+	Store SagaStore
+}
+
+// DefaultSagaConfig returns the default saga configuration
+func DefaultSagaConfig() SagaConfig {
+	return SagaConfig{
+		CheckInterval: time.Second,
+	}
+}
+
+// SagaHandler reacts to an event of a saga instance by changing its state,
+// emitting events or failing it. A returned error leaves the instance
+// unchanged, so the event can be retried.
+type SagaHandler[T any] func(ctx context.Context, saga *SagaInstance[T], event Event) error
+
+// SagaCompensation undoes a step of a failed saga instance
+type SagaCompensation[T any] func(ctx context.Context, saga *SagaInstance[T]) error
+
+// SagaInstance is the persisted state of one saga, keyed by the correlation
+// ID of the events it reacts to
+type SagaInstance[T any] struct {
+	ID     string     `json:"id"`
+	State  string     `json:"state"`
+	Status SagaStatus `json:"status"`
+	Data   T          `json:"data"`
+	// Deadline is when the current state times out, zero without a timeout
+	Deadline time.Time `json:"deadline"`
+	// Compensations are run in reverse order when the instance fails
+	Compensations []string  `json:"compensations,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Version       int64     `json:"version"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	emitted      []Event
+	transitioned bool
+	failed       bool
+}
+
+// TransitionTo moves the instance to state, starting the state's timeout
+func (i *SagaInstance[T]) TransitionTo(state string) {
+	i.State = state
+	i.transitioned = true
+}
+
+// Emit publishes event with the instance's correlation ID once its state is
+// persisted
+func (i *SagaInstance[T]) Emit(event Event) {
+	i.emitted = append(i.emitted, event)
+}
+
+// Complete finishes the instance successfully
+func (i *SagaInstance[T]) Complete() {
+	i.Status = SagaCompleted
+}
+
+// Compensate records a compensation, registered with Saga.Compensation, to
+// run if the instance fails later
+func (i *SagaInstance[T]) Compensate(name string) {
+	i.Compensations = append(i.Compensations, name)
+}
+
+// Fail fails the instance with reason and runs its compensations, last
+// recorded first
+func (i *SagaInstance[T]) Fail(reason string) {
+	i.Error = reason
+	i.failed = true
+}
+
+// record returns the persisted fields of the instance
+func (i *SagaInstance[T]) record() SagaInstance[T] {
+	return SagaInstance[T]{
+		ID:            i.ID,
+		State:         i.State,
+		Status:        i.Status,
+		Data:          i.Data,
+		Deadline:      i.Deadline,
+		Compensations: slices.Clone(i.Compensations),
+		Error:         i.Error,
+		Version:       i.Version,
+		UpdatedAt:     i.UpdatedAt,
+	}
+}
+
+// sagaTimeout is the timeout of a saga state
+type sagaTimeout[T any] struct {
+	after   time.Duration
+	handler SagaHandler[T]
+}
+
+// sagaStepKey is an event expected in a saga state
+type sagaStepKey struct {
+	state     string
+	eventName string
+}
+
+// Saga is a state machine reacting to events by correlation ID. Events
+// starting it create an instance keyed by their correlation ID; later events
+// with that correlation ID move it through its states. Instances are
+// persisted in the config's Store, so they and their timeouts survive
+// restarts whatever the retention of the event store.
+type Saga[T any] struct {
+	name     string
+	mediator *Mediator
+	config   SagaConfig
+
+	starts        map[string]SagaHandler[T]
+	steps         map[sagaStepKey]SagaHandler[T]
+	timeouts      map[string]sagaTimeout[T]
+	compensations map[string]SagaCompensation[T]
+	err           error
+
+	locks sagaLocks
+
+	mu        sync.Mutex
+	subs      []*Subscription
+	deadlines map[string]time.Time
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewSaga creates a saga named name and ties its lifecycle to m.Close. Define
+// its steps with StartOn, On, Timeout and Compensation, then call Start.
+func NewSaga[T any](m *Mediator, name string, config SagaConfig) *Saga[T] {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = DefaultSagaConfig().CheckInterval
+	}
+	s := &Saga[T]{
+		name:          name,
+		mediator:      m,
+		config:        config,
+		starts:        make(map[string]SagaHandler[T]),
+		steps:         make(map[sagaStepKey]SagaHandler[T]),
+		timeouts:      make(map[string]sagaTimeout[T]),
+		compensations: make(map[string]SagaCompensation[T]),
+		deadlines:     make(map[string]time.Time),
+	}
+	m.onClose(func() error {
+		s.Stop()
+		return nil
+	})
+	return s
+}
+
+// Name returns the name of the saga
+func (s *Saga[T]) Name() string {
+	return s.name
+}
+
+// StartOn starts an instance when eventName is published with a correlation
+// ID that has no instance yet
+func (s *Saga[T]) StartOn(eventName string, handler SagaHandler[T]) *Saga[T] {
+	s.starts[eventName] = handler
+	return s
+}
+
+// On handles eventName for instances in state
+func (s *Saga[T]) On(state, eventName string, handler SagaHandler[T]) *Saga[T] {
+	s.steps[sagaStepKey{state: state, eventName: eventName}] = handler
+	return s
+}
+
+// Timeout calls handler when an instance stays in state longer than after.
+// A nil handler fails the instance, running its compensations.
+func (s *Saga[T]) Timeout(state string, after time.Duration, handler SagaHandler[T]) *Saga[T] {
+	if after <= 0 {
+		s.err = fmt.Errorf("invalid timeout %s for state %s", after, state)
+		return s
+	}
+	s.timeouts[state] = sagaTimeout[T]{after: after, handler: handler}
+	return s
+}
+
+// Compensation registers a compensation instances record with
+// SagaInstance.Compensate
+func (s *Saga[T]) Compensation(name string, fn SagaCompensation[T]) *Saga[T] {
+	s.compensations[name] = fn
+	return s
+}
+
+// Start subscribes the saga to its events and starts checking timeouts,
+// including those of instances persisted before a restart
+func (s *Saga[T]) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return nil
+	}
+	if err := s.validate(); err != nil {
+		return fmt.Errorf("failed to start saga %s: %w", s.name, err)
+	}
+	if err := s.loadDeadlines(context.Background()); err != nil {
+		return fmt.Errorf("failed to start saga %s: %w", s.name, err)
+	}
+
+	subscribed := make(map[string]bool)
+	for eventName := range s.starts {
+		subscribed[eventName] = true
+	}
+	for key := range s.steps {
+		subscribed[key.eventName] = true
+	}
+	for eventName := range subscribed {
+		sub, err := s.mediator.TrySubscribe(eventName, s.handle, WithHandlerName(s.recordName()))
+		if err != nil {
+			for _, sub := range s.subs {
+				sub.Unsubscribe()
+			}
+			s.subs = nil
+			return fmt.Errorf("failed to start saga %s: %w", s.name, err)
+		}
+		s.subs = append(s.subs, sub)
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.wg.Add(1)
+	go s.checkTimeouts(s.ctx)
+	return nil
+}
+
+// Stop unsubscribes the saga and waits for in-flight timeouts to finish
+func (s *Saga[T]) Stop() {
+	s.mu.Lock()
+	cancel, subs := s.cancel, s.subs
+	s.cancel, s.subs = nil, nil
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// Load returns the instance of a correlation ID, or ErrSagaNotFound
+func (s *Saga[T]) Load(ctx context.Context, id string) (*SagaInstance[T], error) {
+	instance, err := s.load(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saga %s instance %s: %w", s.name, id, err)
+	}
+	if instance == nil {
+		return nil, fmt.Errorf("failed to load saga %s instance %s: %w", s.name, id, ErrSagaNotFound)
+	}
+	return instance, nil
+}
+
+// validate checks the saga's definition and the mediator it runs on
+func (s *Saga[T]) validate() error {
+	if s.name == "" {
+		return errors.New("empty saga name")
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.starts) == 0 {
+		return errors.New("no event starts the saga")
+	}
+	for eventName := range s.starts {
+		if err := s.mediator.ValidateEventName(eventName); err != nil {
+			return err
+		}
+	}
+	for key := range s.steps {
+		if err := s.mediator.ValidateEventName(key.eventName); err != nil {
+			return err
+		}
+	}
+	if s.config.Store == nil {
+		return errors.New("no saga store configured")
+	}
+	return nil
+}
+
+// recordName names the saga's handlers and timeout events
+func (s *Saga[T]) recordName() string {
+	return "saga." + s.name
+}
+
+// key names the saga in its store, prefixed with the namespace of ctx
+func (s *Saga[T]) key(ctx context.Context) string {
+	return namespacedName(s.mediator.namespaceOf(ctx), s.name)
+}
+
+// handle runs the step of the instance an event's correlation ID belongs to
+func (s *Saga[T]) handle(ctx context.Context, event Event) error {
+	if event.CorrelationID == "" {
+		return nil
+	}
+	unlock := s.locks.lock(event.CorrelationID)
+	defer unlock()
+
+	instance, err := s.load(ctx, event.CorrelationID)
+	if err != nil {
+		return fmt.Errorf("failed to load saga %s instance %s: %w", s.name, event.CorrelationID, err)
+	}
+
+	var handler SagaHandler[T]
+	switch {
+	case instance == nil:
+		if handler = s.starts[event.Name]; handler != nil {
+			instance = &SagaInstance[T]{ID: event.CorrelationID, Status: SagaActive}
+		}
+	case instance.Status == SagaActive:
+		handler = s.steps[sagaStepKey{state: instance.State, eventName: event.Name}]
+	}
+	// Events of finished instances or not expected in their state are ignored
+	if handler == nil {
+		return nil
+	}
+
+	if err := handler(ctx, instance, event); err != nil {
+		return err
+	}
+	return s.save(ctx, instance, false)
+}
+
+// checkTimeouts fires the timeouts of instances past their deadline until ctx is done
+func (s *Saga[T]) checkTimeouts(ctx context.Context) {
+	defer s.wg.Done()
+
+	clock := s.mediator.timeSource()
+	for sleep(clock, s.config.CheckInterval, ctx.Done()) {
+		now := clock.Now()
+		s.mu.Lock()
+		var due []string
+		for id, deadline := range s.deadlines {
+			if !deadline.After(now) {
+				due = append(due, id)
+			}
+		}
+		s.mu.Unlock()
+
+		for _, id := range due {
+			if err := s.timeout(ctx, id, now); err != nil {
+				s.mediator.logf("saga %s instance %s timeout failed: %v", s.name, id, err)
+			}
+		}
+	}
+}
+
+// timeout fires the timeout of an instance still in the state that timed out
+func (s *Saga[T]) timeout(ctx context.Context, id string, now time.Time) error {
+	unlock := s.locks.lock(id)
+	defer unlock()
+
+	instance, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if instance == nil || instance.Status != SagaActive || instance.Deadline.IsZero() || instance.Deadline.After(now) {
+		s.forget(id)
+		return nil
+	}
+
+	timeout, ok := s.timeouts[instance.State]
+	if !ok {
+		s.forget(id)
+		return nil
+	}
+	if timeout.handler == nil {
+		instance.Fail(fmt.Sprintf("timed out in state %s", instance.State))
+	} else {
+		event := Event{Name: s.recordName() + ".timeout", CorrelationID: id, Timestamp: now}
+		if err := timeout.handler(ctx, instance, event); err != nil {
+			return err
+		}
+	}
+	return s.save(ctx, instance, true)
+}
+
+// save runs the compensations of a failed instance, persists it and publishes
+// the events it emitted. Timing out restarts the timeout of its state.
+func (s *Saga[T]) save(ctx context.Context, instance *SagaInstance[T], timedOut bool) error {
+	if instance.failed && instance.Status == SagaActive {
+		instance.Status = SagaCompensated
+		for i := len(instance.Compensations) - 1; i >= 0; i-- {
+			if err := s.compensate(ctx, instance, instance.Compensations[i]); err != nil {
+				instance.Status = SagaFailed
+				instance.Error = fmt.Sprintf("%s: compensation %s failed: %v", instance.Error, instance.Compensations[i], err)
+				break
+			}
+		}
+	}
+
+	now := s.mediator.now()
+	timeout, hasTimeout := s.timeouts[instance.State]
+	switch {
+	case instance.Status != SagaActive || !hasTimeout:
+		instance.Deadline = time.Time{}
+	case instance.transitioned || timedOut || instance.Deadline.IsZero():
+		instance.Deadline = now.Add(timeout.after)
+	}
+	instance.Version++
+	instance.UpdatedAt = now
+
+	if err := s.store(ctx, instance); err != nil {
+		return fmt.Errorf("failed to save saga %s instance %s: %w", s.name, instance.ID, err)
+	}
+
+	if instance.Deadline.IsZero() {
+		s.forget(instance.ID)
+	} else {
+		s.mu.Lock()
+		s.deadlines[instance.ID] = instance.Deadline
+		s.mu.Unlock()
+	}
+
+	// The state is persisted, so a failed emit is logged rather than retried
+	emitted := instance.emitted
+	instance.emitted, instance.transitioned, instance.failed = nil, false, false
+	for _, event := range emitted {
+		event.CorrelationID = instance.ID
+		if err := s.mediator.Publish(ctx, event); err != nil {
+			s.mediator.logf("saga %s instance %s failed to emit %s: %v", s.name, instance.ID, event.Name, err)
+		}
+	}
+	return nil
+}
+
+// compensate runs the compensation registered as name
+func (s *Saga[T]) compensate(ctx context.Context, instance *SagaInstance[T], name string) error {
+	fn, ok := s.compensations[name]
+	if !ok {
+		return errors.New("compensation not registered")
+	}
+	return fn(ctx, instance)
+}
+
+// store writes the record of an instance to the saga store
+func (s *Saga[T]) store(ctx context.Context, instance *SagaInstance[T]) error {
+	m := s.mediator
+	serializer := m.Serializer()
+	state, err := serializer.Marshal(instance.record())
+	if err != nil {
+		return fmt.Errorf("failed to encode instance: %w", err)
+	}
+
+	ctx = m.storeContext(ctx)
+	return s.config.Store.SaveSaga(ctx, SagaRecord{
+		Saga:        s.key(ctx),
+		ID:          instance.ID,
+		Version:     instance.Version,
+		Deadline:    instance.Deadline,
+		State:       state,
+		ContentType: serializer.ContentType(),
+		UpdatedAt:   instance.UpdatedAt,
+	})
+}
+
+// load reads the record of an instance, nil when it has none
+func (s *Saga[T]) load(ctx context.Context, id string) (*SagaInstance[T], error) {
+	if s.config.Store == nil {
+		return nil, errors.New("no saga store configured")
+	}
+
+	m := s.mediator
+	ctx = m.storeContext(ctx)
+	record, err := s.config.Store.LoadSaga(ctx, s.key(ctx), id)
+	if errors.Is(err, ErrSagaNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serializer := m.Serializer()
+	if record.ContentType != serializer.ContentType() {
+		return nil, fmt.Errorf("instance is encoded as %s, want %s", record.ContentType, serializer.ContentType())
+	}
+	var instance SagaInstance[T]
+	if err := serializer.Unmarshal(record.State, &instance); err != nil {
+		return nil, fmt.Errorf("failed to decode instance: %w", err)
+	}
+	return &instance, nil
+}
+
+// loadDeadlines indexes the deadlines of the active instances in the saga store
+func (s *Saga[T]) loadDeadlines(ctx context.Context) error {
+	ctx = s.mediator.storeContext(ctx)
+	deadlines, err := s.config.Store.SagaDeadlines(ctx, s.key(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to read saga deadlines: %w", err)
+	}
+	for id, deadline := range deadlines {
+		s.deadlines[id] = deadline
+	}
+	return nil
+}
+
+// forget drops the deadline of an instance
+func (s *Saga[T]) forget(id string) {
+	s.mu.Lock()
+	delete(s.deadlines, id)
+	s.mu.Unlock()
+}
+
+// sagaLocks serializes the handling of each saga instance
+type sagaLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sagaLock
+}
+
+// sagaLock is the lock of one instance and the number of its holders and waiters
+type sagaLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock locks the instance id and returns its unlock function
+func (l *sagaLocks) lock(id string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*sagaLock)
+	}
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &sagaLock{}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		l.mu.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
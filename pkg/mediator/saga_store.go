@@ -0,0 +1,100 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSagaConflict is returned by SagaStore.SaveSaga when another process saved
+// the instance since it was loaded
+var ErrSagaConflict = errors.New("saga instance saved concurrently")
+
+// SagaRecord is the persisted state of one saga instance
+type SagaRecord struct {
+	// Saga names the saga, prefixed with the namespace of the instance if any
+	Saga string
+	// ID is the correlation ID of the instance
+	ID string
+	// Version is 1 for a new instance and grows by one with each save
+	Version int64
+	// Deadline is when the state of an active instance times out, zero
+	// without a timeout
+	Deadline time.Time
+	// State is the SagaInstance encoded as ContentType
+	State       []byte
+	ContentType string
+	UpdatedAt   time.Time
+}
+
+// SagaStore keeps the latest record of each saga instance. Unlike the event
+// store, it keeps every instance whatever its age or number, until the
+// application deletes it.
+type SagaStore interface {
+	// SaveSaga stores a record in place of the previous version of its
+	// instance, or returns ErrSagaConflict when the stored version is not
+	// record.Version-1
+	SaveSaga(ctx context.Context, record SagaRecord) error
+
+	// LoadSaga returns the record of an instance, or ErrSagaNotFound
+	LoadSaga(ctx context.Context, saga, id string) (SagaRecord, error)
+
+	// SagaDeadlines returns the deadlines of the instances of a saga that
+	// have one, by ID
+	SagaDeadlines(ctx context.Context, saga string) (map[string]time.Time, error)
+}
+
+// MemorySagaStore is an in-memory SagaStore
+type MemorySagaStore struct {
+	mu      sync.Mutex
+	records map[string]map[string]SagaRecord
+}
+
+// NewMemorySagaStore creates an empty in-memory saga store
+func NewMemorySagaStore() *MemorySagaStore {
+	return &MemorySagaStore{records: make(map[string]map[string]SagaRecord)}
+}
+
+// SaveSaga stores a record in place of the previous version of its instance,
+// or returns ErrSagaConflict
+func (s *MemorySagaStore) SaveSaga(ctx context.Context, record SagaRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records, ok := s.records[record.Saga]
+	if !ok {
+		records = make(map[string]SagaRecord)
+		s.records[record.Saga] = records
+	}
+	if records[record.ID].Version != record.Version-1 {
+		return ErrSagaConflict
+	}
+	record.State = append([]byte(nil), record.State...)
+	records[record.ID] = record
+	return nil
+}
+
+// LoadSaga returns the record of an instance, or ErrSagaNotFound
+func (s *MemorySagaStore) LoadSaga(ctx context.Context, saga, id string) (SagaRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[saga][id]
+	if !ok {
+		return SagaRecord{}, ErrSagaNotFound
+	}
+	record.State = append([]byte(nil), record.State...)
+	return record, nil
+}
+
+// SagaDeadlines returns the deadlines of the instances of a saga that have one
+func (s *MemorySagaStore) SagaDeadlines(ctx context.Context, saga string) (map[string]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deadlines := make(map[string]time.Time)
+	for id, record := range s.records[saga] {
+		if !record.Deadline.IsZero() {
+			deadlines[id] = record.Deadline
+		}
+	}
+	return deadlines, nil
+}
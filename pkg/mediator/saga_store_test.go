@@ -0,0 +1,58 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemorySagaStore(t *testing.T) {
+	store := NewMemorySagaStore()
+	ctx := context.Background()
+	deadline := time.Date(2025, 5, 11, 13, 0, 0, 0, time.UTC)
+
+	if _, err := store.LoadSaga(ctx, "billing", "o-1"); !errors.Is(err, ErrSagaNotFound) {
+		t.Fatalf("LoadSaga() error = %v, want ErrSagaNotFound", err)
+	}
+
+	tests := []struct {
+		name      string
+		version   int64
+		wantErr   error
+		want      int64
+		wantState string
+	}{
+		{name: "first", version: 1, want: 1, wantState: "first"},
+		{name: "first again", version: 1, wantErr: ErrSagaConflict, want: 1, wantState: "first"},
+		{name: "skipped version", version: 3, wantErr: ErrSagaConflict, want: 1, wantState: "first"},
+		{name: "next", version: 2, want: 2, wantState: "next"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := SagaRecord{Saga: "billing", ID: "o-1", Version: tt.version, Deadline: deadline, State: []byte(tt.name), ContentType: "application/json"}
+			if err := store.SaveSaga(ctx, record); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveSaga() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := store.LoadSaga(ctx, "billing", "o-1")
+			if err != nil {
+				t.Fatalf("LoadSaga() error = %v", err)
+			}
+			if got.Version != tt.want || string(got.State) != tt.wantState || !got.Deadline.Equal(deadline) {
+				t.Errorf("LoadSaga() = %+v, want version %d with %q", got, tt.want, tt.wantState)
+			}
+		})
+	}
+
+	// Test only the instances of the saga with a deadline are listed
+	store.SaveSaga(ctx, SagaRecord{Saga: "billing", ID: "o-2", Version: 1})
+	store.SaveSaga(ctx, SagaRecord{Saga: "shipping", ID: "o-3", Version: 1, Deadline: deadline})
+	deadlines, err := store.SagaDeadlines(ctx, "billing")
+	if err != nil {
+		t.Fatalf("SagaDeadlines() error = %v", err)
+	}
+	if len(deadlines) != 1 || !deadlines["o-1"].Equal(deadline) {
+		t.Errorf("SagaDeadlines() = %v, want o-1 only", deadlines)
+	}
+}
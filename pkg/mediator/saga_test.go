@@ -0,0 +1,291 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// productSaga tracks a product until its SKU is created
+type productSaga struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+}
+
+// newProductSaga returns a saga kept in store, awaiting sku.created after
+// product.created and emitting product.ready
+func newProductSaga(m *Mediator, store SagaStore, timeout time.Duration) *Saga[productSaga] {
+	return NewSaga[productSaga](m, "product-onboarding", SagaConfig{CheckInterval: 5 * time.Millisecond, Store: store}).
+		StartOn("product.created", func(ctx context.Context, saga *SagaInstance[productSaga], event Event) error {
+			saga.Data.ProductID = event.Payload.(string)
+			saga.Compensate("archive-product")
+			saga.TransitionTo("awaiting-sku")
+			return nil
+		}).
+		On("awaiting-sku", "sku.created", func(ctx context.Context, saga *SagaInstance[productSaga], event Event) error {
+			saga.Data.SKU = event.Payload.(string)
+			saga.Emit(Event{Name: "product.ready", Payload: saga.Data.ProductID})
+			saga.Complete()
+			return nil
+		}).
+		Timeout("awaiting-sku", timeout, nil).
+		Compensation("archive-product", func(ctx context.Context, saga *SagaInstance[productSaga]) error { return nil })
+}
+
+func TestSaga_Complete(t *testing.T) {
+	m := NewMediator()
+	defer m.Close()
+	ctx := context.Background()
+
+	ready := make(chan Event, 1)
+	m.Subscribe("product.ready", func(ctx context.Context, event Event) error {
+		ready <- event
+		return nil
+	})
+
+	saga := newProductSaga(m, NewMemorySagaStore(), time.Hour)
+	if err := saga.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Test events of unknown instances are ignored
+	if err := m.Publish(ctx, Event{Name: "sku.created", CorrelationID: "p-0", Payload: "sku-0"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if _, err := saga.Load(ctx, "p-0"); !errors.Is(err, ErrSagaNotFound) {
+		t.Errorf("Load() error = %v, want ErrSagaNotFound", err)
+	}
+
+	if err := m.Publish(ctx, Event{Name: "product.created", CorrelationID: "p-1", Payload: "p-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	instance, err := saga.Load(ctx, "p-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if instance.State != "awaiting-sku" || instance.Status != SagaActive || instance.Deadline.IsZero() {
+		t.Errorf("Load() = %+v, want active awaiting-sku with a deadline", instance)
+	}
+
+	if err := m.Publish(ctx, Event{Name: "sku.created", CorrelationID: "p-1", Payload: "sku-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	instance, err = saga.Load(ctx, "p-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if instance.Status != SagaCompleted || instance.Data != (productSaga{ProductID: "p-1", SKU: "sku-1"}) || instance.Version != 2 || !instance.Deadline.IsZero() {
+		t.Errorf("Load() = %+v, want the completed product", instance)
+	}
+
+	select {
+	case event := <-ready:
+		if event.CorrelationID != "p-1" || event.Payload != "p-1" {
+			t.Errorf("product.ready = %+v, want correlation ID p-1", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for product.ready")
+	}
+
+	// Test events of finished instances are ignored
+	if err := m.Publish(ctx, Event{Name: "product.created", CorrelationID: "p-1", Payload: "p-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if instance, _ := saga.Load(ctx, "p-1"); instance.Version != 2 {
+		t.Errorf("Version = %d after a restart event, want 2", instance.Version)
+	}
+}
+
+func TestSaga_FailedCompensation(t *testing.T) {
+	m := NewMediator()
+	defer m.Close()
+
+	saga := NewSaga[productSaga](m, "billing", SagaConfig{Store: NewMemorySagaStore()}).
+		StartOn("order.placed", func(ctx context.Context, saga *SagaInstance[productSaga], event Event) error {
+			saga.Compensate("refund")
+			saga.Fail("card declined")
+			return nil
+		}).
+		Compensation("refund", func(ctx context.Context, saga *SagaInstance[productSaga]) error {
+			return errors.New("gateway down")
+		})
+	if err := saga.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	m.Publish(context.Background(), Event{Name: "order.placed", CorrelationID: "o-1"})
+	instance, err := saga.Load(context.Background(), "o-1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if instance.Status != SagaFailed || instance.Error != "card declined: compensation refund failed: gateway down" {
+		t.Errorf("Load() = %+v, want failed with the compensation error", instance)
+	}
+}
+
+func TestSaga_HandlerErrorLeavesInstance(t *testing.T) {
+	m := NewMediator()
+	defer m.Close()
+
+	errDown := errors.New("inventory down")
+	saga := newProductSaga(m, NewMemorySagaStore(), time.Hour).
+		On("awaiting-sku", "sku.failed", func(ctx context.Context, saga *SagaInstance[productSaga], event Event) error {
+			saga.TransitionTo("broken")
+			return errDown
+		})
+	if err := saga.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	ctx := context.Background()
+	m.Publish(ctx, Event{Name: "product.created", CorrelationID: "p-1", Payload: "p-1"})
+	if err := m.Publish(ctx, Event{Name: "sku.failed", CorrelationID: "p-1"}); !errors.Is(err, errDown) {
+		t.Errorf("Publish() error = %v, want the handler error", err)
+	}
+	if instance, _ := saga.Load(ctx, "p-1"); instance.State != "awaiting-sku" || instance.Version != 1 {
+		t.Errorf("Load() = %+v, want the instance unchanged", instance)
+	}
+}
+
+// cappedEventStore keeps the last max events of each name, like a store
+// with KeepLast retention
+type cappedEventStore struct {
+	mockEventStore
+	max int
+}
+
+func (s *cappedEventStore) StoreEvent(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	count := 0
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].Name != event.Name {
+			continue
+		}
+		if count++; count > s.max {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func TestSaga_OutlivesEventStoreRetention(t *testing.T) {
+	const instances = 5
+	m := NewMediator(WithEventStore(&cappedEventStore{max: 2}))
+	defer m.Close()
+	saga := newProductSaga(m, NewMemorySagaStore(), time.Hour)
+	if err := saga.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < instances; i++ {
+		id := fmt.Sprintf("p-%d", i)
+		if err := m.Publish(ctx, Event{Name: "product.created", CorrelationID: id, Payload: id}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	// Test every instance moves on, though the event store trimmed most of
+	// the events that started them
+	for i := 0; i < instances; i++ {
+		id := fmt.Sprintf("p-%d", i)
+		if err := m.Publish(ctx, Event{Name: "sku.created", CorrelationID: id, Payload: "sku-" + id}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if instance, err := saga.Load(ctx, id); err != nil || instance.Status != SagaCompleted || instance.Data.SKU != "sku-"+id || instance.Version != 2 {
+			t.Errorf("Load() = %+v, %v; want %s completed with its SKU", instance, err, id)
+		}
+	}
+}
+
+func TestSaga_ConcurrentSave(t *testing.T) {
+	store := NewMemorySagaStore()
+	ctx := context.Background()
+
+	// Two processes running the saga on the same store
+	other := NewMediator()
+	defer other.Close()
+	if err := newProductSaga(other, store, time.Hour).Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	m := NewMediator()
+	defer m.Close()
+	raced := false
+	saga := newProductSaga(m, store, time.Hour).
+		On("awaiting-sku", "sku.reserved", func(ctx context.Context, saga *SagaInstance[productSaga], event Event) error {
+			// The other process moves the instance on after this one loaded it
+			if !raced {
+				raced = true
+				other.Publish(context.Background(), Event{Name: "sku.created", CorrelationID: saga.ID, Payload: "sku-1"})
+			}
+			saga.TransitionTo("reserved")
+			return nil
+		})
+	if err := saga.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	m.Publish(ctx, Event{Name: "product.created", CorrelationID: "p-1", Payload: "p-1"})
+	if err := m.Publish(ctx, Event{Name: "sku.reserved", CorrelationID: "p-1"}); !errors.Is(err, ErrSagaConflict) {
+		t.Errorf("Publish() error = %v, want ErrSagaConflict", err)
+	}
+	if instance, _ := saga.Load(ctx, "p-1"); instance.Status != SagaCompleted || instance.Version != 2 {
+		t.Errorf("Load() = %+v, want the save of the other process", instance)
+	}
+}
+
+func TestSaga_StartErrors(t *testing.T) {
+	start := func(ctx context.Context, saga *SagaInstance[productSaga], event Event) error { return nil }
+
+	tests := []struct {
+		name    string
+		saga    func() *Saga[productSaga]
+		wantErr error
+	}{
+		{
+			name: "no saga store",
+			saga: func() *Saga[productSaga] {
+				return NewSaga[productSaga](NewMediator(), "billing", DefaultSagaConfig()).StartOn("order.placed", start)
+			},
+		},
+		{
+			name: "no start event",
+			saga: func() *Saga[productSaga] {
+				return NewSaga[productSaga](NewMediator(), "billing", SagaConfig{Store: NewMemorySagaStore()})
+			},
+		},
+		{
+			name: "invalid timeout",
+			saga: func() *Saga[productSaga] {
+				return NewSaga[productSaga](NewMediator(), "billing", SagaConfig{Store: NewMemorySagaStore()}).
+					StartOn("order.placed", start).
+					Timeout("pending", 0, nil)
+			},
+		},
+		{
+			name: "unknown event",
+			saga: func() *Saga[productSaga] {
+				m := NewMediator(WithStrictEvents())
+				return NewSaga[productSaga](m, "billing", SagaConfig{Store: NewMemorySagaStore()}).StartOn("order.placed", start)
+			},
+			wantErr: ErrUnknownEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.saga().Start()
+			if err == nil {
+				t.Fatal("Start() error = nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Start() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
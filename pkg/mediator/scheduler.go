@@ -0,0 +1,144 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule computes when a scheduled event fires next
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+// every is a Schedule firing at a fixed interval
+type every time.Duration
+
+// Next returns t plus the interval
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// Scheduler publishes events on recurring schedules. It stops when Stop or
+// the mediator's Close is called.
+type Scheduler struct {
+	mediator *Mediator
+	jobs     []scheduledJob
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+}
+
+// scheduledJob is an event published on a schedule
+type scheduledJob struct {
+	schedule Schedule
+	event    Event
+}
+
+// NewScheduler creates a scheduler publishing through m and ties its lifecycle to m.Close
+func NewScheduler(m *Mediator) *Scheduler {
+	s := &Scheduler{mediator: m}
+	m.mu.Lock()
+	m.schedulers = append(m.schedulers, s)
+	m.mu.Unlock()
+	m.onClose(func() error {
+		s.Stop()
+		return nil
+	})
+	return s
+}
+
+// Cron publishes event on a standard five-field cron expression or a
+// descriptor such as "@hourly" or "@every 5m"
+func (s *Scheduler) Cron(spec string, event Event) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	s.add(scheduledJob{schedule: schedule, event: event})
+	return nil
+}
+
+// Every publishes event at a fixed interval
+func (s *Scheduler) Every(interval time.Duration, event Event) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval %s", interval)
+	}
+	s.add(scheduledJob{schedule: every(interval), event: event})
+	return nil
+}
+
+// Start begins publishing scheduled events. Jobs added afterwards start immediately.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, job := range s.jobs {
+		s.run(job)
+	}
+}
+
+// Stop stops publishing and waits for in-flight publishes to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// running reports whether the scheduler is started and the number of its jobs
+func (s *Scheduler) running() (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancel != nil, len(s.jobs)
+}
+
+// add registers a job, starting it when the scheduler is running
+func (s *Scheduler) add(job scheduledJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, job)
+	if s.cancel != nil {
+		s.run(job)
+	}
+}
+
+// run publishes the job's event on its schedule until the scheduler stops
+func (s *Scheduler) run(job scheduledJob) {
+	ctx := s.ctx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		clock := s.mediator.timeSource()
+		for {
+			now := clock.Now()
+			if !sleep(clock, job.schedule.Next(now).Sub(now), ctx.Done()) {
+				return
+			}
+
+			// Every occurrence is a new event with its own ID and timestamp
+			event := job.event
+			event.ID = ""
+			event.Timestamp = time.Time{}
+			event.CorrelationID = ""
+			if err := s.mediator.Publish(ctx, event); err != nil {
+				s.mediator.logf("scheduled event %s failed: %v", event.Name, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+package mediator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Every(t *testing.T) {
+	m := NewMediator()
+	defer m.Close()
+
+	var count int32
+	ids := make(chan string, 10)
+	m.Subscribe("inventory.reconcile", func(ctx context.Context, event Event) error {
+		if atomic.AddInt32(&count, 1) <= 10 {
+			ids <- event.ID
+		}
+		return nil
+	})
+
+	s := NewScheduler(m)
+	if err := s.Every(10*time.Millisecond, Event{Name: "inventory.reconcile"}); err != nil {
+		t.Fatalf("Every() error = %v", err)
+	}
+	s.Start()
+
+	first, second := <-ids, <-ids
+	if first == "" || first == second {
+		t.Errorf("scheduled events share ID %q, want distinct IDs", first)
+	}
+}
+
+func TestScheduler_AddAfterStart(t *testing.T) {
+	m := NewMediator()
+	fired := make(chan struct{}, 1)
+	m.Subscribe("report.daily", func(ctx context.Context, event Event) error {
+		select {
+		case fired <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	s := NewScheduler(m)
+	s.Start()
+	defer s.Stop()
+
+	if err := s.Every(10*time.Millisecond, Event{Name: "report.daily"}); err != nil {
+		t.Fatalf("Every() error = %v", err)
+	}
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("job added after Start() never fired")
+	}
+}
+
+func TestScheduler_Cron(t *testing.T) {
+	s := NewScheduler(NewMediator())
+
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "*/5 * * * *"},
+		{spec: "@hourly"},
+		{spec: "@every 5m"},
+		{spec: "not a cron", wantErr: true},
+		{spec: "61 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			err := s.Cron(tt.spec, Event{Name: "inventory.reconcile"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Cron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := s.Every(0, Event{Name: "inventory.reconcile"}); err == nil {
+		t.Error("Every() expected error for zero interval")
+	}
+}
+
+func TestMediator_CloseRunsClosersInReverse(t *testing.T) {
+	m := NewMediator()
+	var order []int
+	m.onClose(func() error { order = append(order, 1); return nil })
+	m.onClose(func() error { order = append(order, 2); return nil })
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 1 {
+		t.Errorf("Close() order = %v, want [2 1]", order)
+	}
+
+	// Test closing twice is a no-op
+	if err := m.Close(); err != nil || len(order) != 2 {
+		t.Errorf("second Close() = %v, order %v", err, order)
+	}
+}
@@ -0,0 +1,74 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrNoRequestHandler is returned by Send when no handler is registered for the request type
+	ErrNoRequestHandler = errors.New("no handler registered for request")
+	// ErrMultipleRequestHandlers is returned by Send when more than one handler is registered for the request type
+	ErrMultipleRequestHandlers = errors.New("multiple handlers registered for request")
+)
+
+// RequestHandler is a function type that processes a request and returns a response
+type RequestHandler[Req any, Res any] func(ctx context.Context, request Req) (Res, error)
+
+// requestHandler is a type-erased RequestHandler
+type requestHandler struct {
+	responseType reflect.Type
+	handle       func(ctx context.Context, request interface{}) (interface{}, error)
+}
+
+// HandleRequest registers the handler for requests of type Req. Send requires
+// exactly one handler per request type.
+func HandleRequest[Req any, Res any](m *Mediator, handler RequestHandler[Req, Res]) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.requestHandlers == nil {
+		m.requestHandlers = make(map[reflect.Type][]requestHandler)
+	}
+
+	requestType := typeOf[Req]()
+	m.requestHandlers[requestType] = append(m.requestHandlers[requestType], requestHandler{
+		responseType: typeOf[Res](),
+		handle: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return handler(ctx, request.(Req))
+		},
+	})
+}
+
+// Send dispatches a request to its single registered handler and returns the response
+func Send[Req any, Res any](ctx context.Context, m *Mediator, request Req) (Res, error) {
+	var zero Res
+	requestType := typeOf[Req]()
+
+	m.mu.RLock()
+	handlers := m.requestHandlers[requestType]
+	m.mu.RUnlock()
+
+	switch len(handlers) {
+	case 0:
+		return zero, fmt.Errorf("%w: %s", ErrNoRequestHandler, requestType)
+	case 1:
+	default:
+		return zero, fmt.Errorf("%w: %s has %d handlers", ErrMultipleRequestHandlers, requestType, len(handlers))
+	}
+
+	handler := handlers[0]
+	if want := typeOf[Res](); handler.responseType != want {
+		return zero, fmt.Errorf("handler for %s returns %s, not %s", requestType, handler.responseType, want)
+	}
+
+	response, err := handler.handle(ctx, request)
+	if err != nil {
+		return zero, err
+	}
+	// A nil interface response yields the zero value rather than panicking
+	result, _ := response.(Res)
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type getPriceQuery struct {
+	ProductID string
+}
+
+type priceResult struct {
+	Price float64
+}
+
+func TestSend(t *testing.T) {
+	m := NewMediator()
+	HandleRequest(m, func(ctx context.Context, q getPriceQuery) (priceResult, error) {
+		if q.ProductID == "" {
+			return priceResult{}, errors.New("missing product id")
+		}
+		return priceResult{Price: 9.99}, nil
+	})
+
+	ctx := context.Background()
+	res, err := Send[getPriceQuery, priceResult](ctx, m, getPriceQuery{ProductID: "1"})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if res.Price != 9.99 {
+		t.Errorf("Send() = %+v, want price 9.99", res)
+	}
+
+	// Handler errors are returned to the caller
+	if _, err := Send[getPriceQuery, priceResult](ctx, m, getPriceQuery{}); err == nil {
+		t.Error("Send() expected handler error")
+	}
+
+	// A mismatched response type is rejected
+	if _, err := Send[getPriceQuery, string](ctx, m, getPriceQuery{ProductID: "1"}); err == nil {
+		t.Error("Send() expected error for mismatched response type")
+	}
+}
+
+func TestSend_HandlerCount(t *testing.T) {
+	m := NewMediator()
+	ctx := context.Background()
+
+	_, err := Send[getPriceQuery, priceResult](ctx, m, getPriceQuery{ProductID: "1"})
+	if !errors.Is(err, ErrNoRequestHandler) {
+		t.Errorf("Send() error = %v, want ErrNoRequestHandler", err)
+	}
+
+	handler := func(ctx context.Context, q getPriceQuery) (priceResult, error) { return priceResult{}, nil }
+	HandleRequest(m, handler)
+	HandleRequest(m, handler)
+
+	_, err = Send[getPriceQuery, priceResult](ctx, m, getPriceQuery{ProductID: "1"})
+	if !errors.Is(err, ErrMultipleRequestHandlers) {
+		t.Errorf("Send() error = %v, want ErrMultipleRequestHandlers", err)
+	}
+}
@@ -0,0 +1,258 @@
+package mediator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// jsonContentType is the content type of JSONSerializer
+const jsonContentType = "application/json"
+
+// Serializer encodes and decodes event payloads for stores and transports
+type Serializer interface {
+	// ContentType identifies the encoding in stored records, e.g. "application/json"
+	ContentType() string
+	// Marshal encodes a payload
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v, which must be a non-nil pointer.
+	// Decoding into *interface{} yields the serializer's natural representation.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONSerializer encodes payloads with encoding/json. Decoding into
+// *interface{} yields generic maps and slices.
+type JSONSerializer struct{}
+
+// ContentType returns "application/json"
+func (JSONSerializer) ContentType() string {
+	return jsonContentType
+}
+
+// Marshal encodes v as JSON
+func (JSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v
+func (JSONSerializer) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobSerializer encodes payloads with encoding/gob. The concrete type is
+// recorded with the payload, so decoding into *interface{} yields the original
+// type as long as it was registered with gob.Register.
+type GobSerializer struct{}
+
+// ContentType returns "application/x-gob"
+func (GobSerializer) ContentType() string {
+	return "application/x-gob"
+}
+
+// Marshal encodes v as gob, including its concrete type
+func (GobSerializer) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v
+func (GobSerializer) Unmarshal(data []byte, v interface{}) error {
+	var decoded interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return err
+	}
+	return assignDecoded(decoded, v)
+}
+
+// assignDecoded stores a decoded value in the target pointer v, dereferencing
+// pointers when the target expects the underlying value
+func assignDecoded(decoded interface{}, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return fmt.Errorf("cannot decode into non-pointer %T", v)
+	}
+	if decoded == nil {
+		target.Elem().Set(reflect.Zero(target.Elem().Type()))
+		return nil
+	}
+
+	value := reflect.ValueOf(decoded)
+	for {
+		elem := target.Elem().Type()
+		if value.Type().AssignableTo(elem) {
+			target.Elem().Set(value)
+			return nil
+		}
+		if elem.Kind() == reflect.Pointer && value.Type().AssignableTo(elem.Elem()) {
+			ptr := reflect.New(elem.Elem())
+			ptr.Elem().Set(value)
+			target.Elem().Set(ptr)
+			return nil
+		}
+		if value.Kind() != reflect.Pointer || value.IsNil() {
+			return fmt.Errorf("cannot decode %T into %s", decoded, target.Elem().Type())
+		}
+		value = value.Elem()
+	}
+}
+
+// WithSerializer sets the serializer the mediator uses to decode raw payloads
+// ([]byte) for typed handlers; JSON is used by default
+func WithSerializer(serializer Serializer) Option {
+	return func(m *Mediator) {
+		m.serializer = serializer
+	}
+}
+
+// Serializer returns the mediator's payload serializer
+func (m *Mediator) Serializer() Serializer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.serializer == nil {
+		return JSONSerializer{}
+	}
+	return m.serializer
+}
+
+// EncodeEventRecord encodes an event and its envelope as a JSON record for
+// storage. JSON payloads are embedded as-is; payloads in other encodings are
+// stored as bytes next to a content_type field.
+func EncodeEventRecord(serializer Serializer, event Event) ([]byte, error) {
+	var record eventRecord
+	if err := record.set(serializer, event); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&record)
+}
+
+// eventRecord is the record EncodeEventRecord writes. Its fields are in the
+// order encoding/json writes the keys of a map, so records are byte for byte
+// those written before it replaced one.
+type eventRecord struct {
+	CausationID   string            `json:"causation_id"`
+	ContentType   string            `json:"content_type,omitempty"`
+	CorrelationID string            `json:"correlation_id"`
+	ID            string            `json:"id"`
+	Metadata      map[string]string `json:"metadata"`
+	Name          string            `json:"name"`
+	Namespace     string            `json:"namespace,omitempty"`
+	Payload       interface{}       `json:"payload"`
+	Timestamp     time.Time         `json:"timestamp"`
+}
+
+// set fills the record from an event, encoding the payload with serializer
+// unless it is JSON
+func (r *eventRecord) set(serializer Serializer, event Event) error {
+	*r = eventRecord{
+		CausationID:   event.CausationID,
+		CorrelationID: event.CorrelationID,
+		ID:            event.ID,
+		Metadata:      event.Metadata,
+		Name:          event.Name,
+		Namespace:     event.Namespace,
+		Payload:       event.Payload,
+		Timestamp:     event.Timestamp,
+	}
+
+	if serializer != nil && serializer.ContentType() != jsonContentType {
+		payload, err := serializer.Marshal(event.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		r.Payload = payload
+		r.ContentType = serializer.ContentType()
+	}
+	return nil
+}
+
+// DecodeStoredEvent decodes a record written by EncodeEventRecord into a
+// StoredEvent, keeping the payload both as stored and decoded
+func DecodeStoredEvent(serializer Serializer, data []byte) (StoredEvent, error) {
+	var record struct {
+		ID            string            `json:"id"`
+		Name          string            `json:"name"`
+		Namespace     string            `json:"namespace"`
+		Payload       json.RawMessage   `json:"payload"`
+		ContentType   string            `json:"content_type"`
+		Timestamp     time.Time         `json:"timestamp"`
+		CorrelationID string            `json:"correlation_id"`
+		CausationID   string            `json:"causation_id"`
+		Metadata      map[string]string `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return StoredEvent{}, err
+	}
+
+	event := StoredEvent{
+		ID:            record.ID,
+		Name:          record.Name,
+		Namespace:     record.Namespace,
+		RawPayload:    record.Payload,
+		ContentType:   record.ContentType,
+		Timestamp:     record.Timestamp,
+		CorrelationID: record.CorrelationID,
+		CausationID:   record.CausationID,
+		Metadata:      record.Metadata,
+	}
+
+	if event.ContentType == "" || event.ContentType == jsonContentType {
+		event.ContentType = jsonContentType
+		if len(record.Payload) > 0 {
+			if err := json.Unmarshal(record.Payload, &event.Payload); err != nil {
+				return StoredEvent{}, fmt.Errorf("failed to unmarshal payload: %w", err)
+			}
+		}
+		return event, nil
+	}
+	if serializer == nil || serializer.ContentType() != event.ContentType {
+		return StoredEvent{}, fmt.Errorf("payload is encoded as %s, no matching serializer configured", event.ContentType)
+	}
+
+	// Payload bytes are base64 encoded within the JSON record
+	if err := json.Unmarshal(record.Payload, &event.RawPayload); err != nil {
+		return StoredEvent{}, err
+	}
+	if err := serializer.Unmarshal(event.RawPayload, &event.Payload); err != nil {
+		return StoredEvent{}, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	return event, nil
+}
+
+// DecodeEventRecord decodes a record written by EncodeEventRecord, decoding
+// the payload with serializer when it was not stored as JSON
+func DecodeEventRecord(serializer Serializer, data []byte) (map[string]interface{}, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	contentType, _ := record["content_type"].(string)
+	if contentType == "" || contentType == jsonContentType {
+		return record, nil
+	}
+	if serializer == nil || serializer.ContentType() != contentType {
+		return nil, fmt.Errorf("payload is encoded as %s, no matching serializer configured", contentType)
+	}
+
+	// Payload bytes are base64 encoded within the JSON record
+	var raw struct {
+		Payload []byte `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var payload interface{}
+	if err := serializer.Unmarshal(raw.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	record["payload"] = payload
+	delete(record, "content_type")
+	return record, nil
+}
@@ -0,0 +1,67 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrSnapshotNotFound is returned by SnapshotStore.LoadSnapshot for a stream without a snapshot
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot is the state of an aggregate at a version of its stream, so
+// loading it replays only the events after that version
+type Snapshot struct {
+	StreamID string
+	Version  int64
+	// State is the aggregate encoded as ContentType
+	State       []byte
+	ContentType string
+	Timestamp   time.Time
+}
+
+// SnapshotStore keeps the latest snapshot of each stream
+type SnapshotStore interface {
+	// SaveSnapshot stores a snapshot unless its stream has a snapshot of the
+	// same or a later version
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+
+	// LoadSnapshot returns the latest snapshot of a stream, or ErrSnapshotNotFound
+	LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error)
+}
+
+// MemorySnapshotStore is an in-memory SnapshotStore
+type MemorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]Snapshot
+}
+
+// NewMemorySnapshotStore creates an empty in-memory snapshot store
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{snapshots: make(map[string]Snapshot)}
+}
+
+// SaveSnapshot stores a snapshot unless its stream has a snapshot of the same or a later version
+func (s *MemorySnapshotStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.snapshots[snapshot.StreamID]; ok && current.Version >= snapshot.Version {
+		return nil
+	}
+	snapshot.State = append([]byte(nil), snapshot.State...)
+	s.snapshots[snapshot.StreamID] = snapshot
+	return nil
+}
+
+// LoadSnapshot returns the latest snapshot of a stream, or ErrSnapshotNotFound
+func (s *MemorySnapshotStore) LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, ok := s.snapshots[streamID]
+	if !ok {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	snapshot.State = append([]byte(nil), snapshot.State...)
+	return snapshot, nil
+}
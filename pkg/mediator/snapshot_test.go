@@ -0,0 +1,45 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemorySnapshotStore(t *testing.T) {
+	store := NewMemorySnapshotStore()
+	ctx := context.Background()
+
+	if _, err := store.LoadSnapshot(ctx, "product-p-1"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Fatalf("LoadSnapshot() error = %v, want ErrSnapshotNotFound", err)
+	}
+
+	tests := []struct {
+		name      string
+		version   int64
+		want      int64
+		wantState string
+	}{
+		{name: "first", version: 10, want: 10, wantState: "first"},
+		{name: "later", version: 20, want: 20, wantState: "later"},
+		{name: "older", version: 15, want: 20, wantState: "later"},
+		{name: "same", version: 20, want: 20, wantState: "later"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := []byte(tt.name)
+			if err := store.SaveSnapshot(ctx, Snapshot{StreamID: "product-p-1", Version: tt.version, State: state}); err != nil {
+				t.Fatalf("SaveSnapshot() error = %v", err)
+			}
+			state[0] = 'x'
+
+			snapshot, err := store.LoadSnapshot(ctx, "product-p-1")
+			if err != nil {
+				t.Fatalf("LoadSnapshot() error = %v", err)
+			}
+			if snapshot.Version != tt.want || string(snapshot.State) != tt.wantState {
+				t.Errorf("LoadSnapshot() = version %d with %q, want version %d with %q", snapshot.Version, snapshot.State, tt.want, tt.wantState)
+			}
+		})
+	}
+}
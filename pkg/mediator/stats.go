@@ -0,0 +1,157 @@
+package mediator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrLimitExceeded is wrapped by the *LimitError of a publish shed by WithLimits
+var ErrLimitExceeded = errors.New("mediator limit exceeded")
+
+// LimitError reports the limit that shed a publish
+type LimitError struct {
+	// Limit is the exceeded limit, named like its Stats field in JSON
+	Limit string
+	Value int64
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: %s is %d, limit %d", ErrLimitExceeded, e.Limit, e.Value, e.Max)
+}
+
+// Unwrap returns ErrLimitExceeded
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// Stats is a snapshot of the work and memory a mediator holds
+type Stats struct {
+	// QueuedEvents wait for async workers or the store write buffer
+	QueuedEvents int `json:"queued_events"`
+	// InFlightHandlers are handler invocations running now
+	InFlightHandlers int `json:"in_flight_handlers"`
+	// DeadLetters are retained by a dead-letter queue implementing DeadLetterUsage
+	DeadLetters int `json:"dead_letters"`
+	// EstimatedMemory roughly estimates, in bytes, the queued events and dead
+	// letters held in memory
+	EstimatedMemory int64 `json:"estimated_memory"`
+}
+
+// DeadLetterUsage is implemented by dead-letter queues that hold their letters
+// in memory and can count them cheaply, like MemoryDeadLetterQueue
+type DeadLetterUsage interface {
+	// Usage returns the number of letters and their estimated size in bytes
+	Usage() (letters int, bytes int64)
+}
+
+// Limits caps the work a mediator holds; a limit of 0 is not enforced
+type Limits struct {
+	// MaxQueuedEvents caps events waiting for async workers or the store write buffer
+	MaxQueuedEvents int
+	// MaxInFlightHandlers caps handler invocations running at once
+	MaxInFlightHandlers int
+	// MaxDeadLetters caps dead letters retained by a DeadLetterUsage queue
+	MaxDeadLetters int
+	// MaxMemory caps Stats.EstimatedMemory
+	MaxMemory int64
+}
+
+// WithLimits sheds load past hard limits: while Stats exceeds one, Publish,
+// PublishWith, PublishAsync and PublishBatch fail with a *LimitError without
+// dispatching. Queued events, retries and redeliveries already accepted still run.
+func WithLimits(limits Limits) Option {
+	return func(m *Mediator) {
+		m.limits = &limits
+	}
+}
+
+// Stats returns the current counts of queued events, in-flight handlers and
+// retained dead letters, and an estimate of the memory they hold
+func (m *Mediator) Stats() Stats {
+	m.mu.RLock()
+	async := m.async
+	buffer, _ := m.eventStore.(*bufferedStore)
+	dlq := m.deadLetters
+	m.mu.RUnlock()
+
+	stats := Stats{InFlightHandlers: int(m.inFlight.Load())}
+	if async != nil {
+		for _, lanes := range async.queues {
+			stats.QueuedEvents += lanes.depth()
+		}
+		stats.EstimatedMemory += async.bytes.Load()
+	}
+	if buffer != nil {
+		stats.QueuedEvents += len(buffer.queue)
+		stats.EstimatedMemory += buffer.bytes.Load()
+	}
+	if usage, ok := dlq.(DeadLetterUsage); ok {
+		letters, bytes := usage.Usage()
+		stats.DeadLetters = letters
+		stats.EstimatedMemory += bytes
+	}
+	return stats
+}
+
+// admit returns a *LimitError when the mediator holds more than its limits allow
+func (m *Mediator) admit() error {
+	limits := m.publishSettings().limits
+	if limits == nil {
+		return nil
+	}
+
+	stats := m.Stats()
+	if err := checkLimit("queued_events", int64(stats.QueuedEvents), int64(limits.MaxQueuedEvents)); err != nil {
+		return err
+	}
+	if err := checkLimit("in_flight_handlers", int64(stats.InFlightHandlers), int64(limits.MaxInFlightHandlers)); err != nil {
+		return err
+	}
+	if err := checkLimit("dead_letters", int64(stats.DeadLetters), int64(limits.MaxDeadLetters)); err != nil {
+		return err
+	}
+	return checkLimit("estimated_memory", stats.EstimatedMemory, limits.MaxMemory)
+}
+
+// checkLimit returns a *LimitError when value reaches max; a max of zero is
+// no limit
+func checkLimit(limit string, value, max int64) error {
+	if max > 0 && value >= max {
+		return &LimitError{Limit: limit, Value: value, Max: max}
+	}
+	return nil
+}
+
+// eventSize is the size of an Event's fields, without what they point to
+var eventSize = int64(reflect.TypeOf(Event{}).Size())
+
+// estimateSize roughly estimates the memory an event holds: its fields, their
+// strings and metadata, and the length of byte and string payloads. Other
+// payloads count their shallow size only.
+func estimateSize(event Event) int64 {
+	size := eventSize + int64(len(event.Name)+len(event.ID)+len(event.CorrelationID)+len(event.CausationID)+len(event.Namespace))
+	for key, value := range event.Metadata {
+		size += int64(len(key) + len(value))
+	}
+
+	switch payload := event.Payload.(type) {
+	case nil:
+	case []byte:
+		size += int64(len(payload))
+	case json.RawMessage:
+		size += int64(len(payload))
+	case string:
+		size += int64(len(payload))
+	default:
+		size += int64(reflect.TypeOf(payload).Size())
+	}
+	return size
+}
+
+// estimateLetterSize roughly estimates the memory a dead letter holds
+func estimateLetterSize(letter DeadLetter) int64 {
+	return estimateSize(letter.Event) + int64(len(letter.ID)+len(letter.HandlerName)+len(letter.Error))
+}
@@ -0,0 +1,230 @@
+package mediator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// blockWorker occupies the single async worker of m until the returned
+// release func is called
+func blockWorker(t *testing.T, m *Mediator) func() {
+	t.Helper()
+	started, release := make(chan struct{}), make(chan struct{})
+	m.Subscribe("blocker", func(ctx context.Context, event Event) error {
+		close(started)
+		<-release
+		return nil
+	})
+	if err := m.PublishAsync(context.Background(), Event{Name: "blocker"}); err != nil {
+		t.Fatalf("PublishAsync() error = %v", err)
+	}
+	<-started
+	return func() { close(release) }
+}
+
+func TestMediator_Stats(t *testing.T) {
+	m := NewMediator(
+		WithPartitioning(func(event Event) string { return "" }, 1),
+		WithDeadLetterQueue(NewMemoryDeadLetterQueue()),
+	)
+	defer m.Close()
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return errors.New("boom") })
+
+	if got := m.Stats(); got != (Stats{}) {
+		t.Errorf("Stats() of an idle mediator = %+v, want zero", got)
+	}
+
+	m.Publish(context.Background(), Event{Name: "order.placed", Payload: []byte("payload")})
+	release := blockWorker(t, m)
+	for i := 0; i < 3; i++ {
+		if err := m.PublishAsync(context.Background(), Event{Name: "order.placed"}); err != nil {
+			t.Fatalf("PublishAsync() error = %v", err)
+		}
+	}
+
+	stats := m.Stats()
+	if stats.QueuedEvents != 3 {
+		t.Errorf("QueuedEvents = %d, want 3", stats.QueuedEvents)
+	}
+	if stats.InFlightHandlers != 1 {
+		t.Errorf("InFlightHandlers = %d, want 1", stats.InFlightHandlers)
+	}
+	if stats.DeadLetters != 1 {
+		t.Errorf("DeadLetters = %d, want 1", stats.DeadLetters)
+	}
+	if stats.EstimatedMemory <= 4*eventSize {
+		t.Errorf("EstimatedMemory = %d, want more than 4 events of %d bytes", stats.EstimatedMemory, eventSize)
+	}
+
+	release()
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	stats = m.Stats()
+	if stats.QueuedEvents != 0 || stats.InFlightHandlers != 0 {
+		t.Errorf("Stats() after Close = %+v, want no queued or in-flight work", stats)
+	}
+	if stats.DeadLetters != 4 {
+		t.Errorf("DeadLetters after Close = %d, want 4", stats.DeadLetters)
+	}
+}
+
+func TestMediator_Limits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		load      func(t *testing.T, m *Mediator)
+		wantLimit string
+	}{
+		{
+			name:   "queued events",
+			limits: Limits{MaxQueuedEvents: 2},
+			load: func(t *testing.T, m *Mediator) {
+				for i := 0; i < 2; i++ {
+					if err := m.PublishAsync(context.Background(), Event{Name: "order.placed"}); err != nil {
+						t.Fatalf("PublishAsync() under the limit error = %v", err)
+					}
+				}
+			},
+			wantLimit: "queued_events",
+		},
+		{
+			name:      "in-flight handlers",
+			limits:    Limits{MaxInFlightHandlers: 1},
+			load:      func(t *testing.T, m *Mediator) {},
+			wantLimit: "in_flight_handlers",
+		},
+		{
+			name:   "dead letters",
+			limits: Limits{MaxDeadLetters: 1},
+			load: func(t *testing.T, m *Mediator) {
+				m.deadLetters.Add(context.Background(), DeadLetter{ID: "dl-1", Event: Event{Name: "order.placed"}})
+			},
+			wantLimit: "dead_letters",
+		},
+		{
+			name:   "estimated memory",
+			limits: Limits{MaxMemory: 1024},
+			load: func(t *testing.T, m *Mediator) {
+				if err := m.PublishAsync(context.Background(), Event{Name: "order.placed", Payload: make([]byte, 1024)}); err != nil {
+					t.Fatalf("PublishAsync() under the limit error = %v", err)
+				}
+			},
+			wantLimit: "estimated_memory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMediator(
+				WithPartitioning(func(event Event) string { return "" }, 1),
+				WithDeadLetterQueue(NewMemoryDeadLetterQueue()),
+				WithLimits(tt.limits),
+			)
+			m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+			release := blockWorker(t, m)
+			defer m.Close()
+			defer release()
+
+			tt.load(t, m)
+
+			publishes := map[string]func() error{
+				"Publish": func() error { return m.Publish(context.Background(), Event{Name: "order.placed"}) },
+				"PublishAsync": func() error {
+					return m.PublishAsync(context.Background(), Event{Name: "order.placed"})
+				},
+				"PublishBatch": func() error {
+					_, err := m.PublishBatch(context.Background(), []Event{{Name: "order.placed"}})
+					return err
+				},
+			}
+			for name, publish := range publishes {
+				err := publish()
+				var limitErr *LimitError
+				if !errors.As(err, &limitErr) || !errors.Is(err, ErrLimitExceeded) {
+					t.Fatalf("%s() error = %v, want a *LimitError", name, err)
+				}
+				if limitErr.Limit != tt.wantLimit {
+					t.Errorf("%s() exceeded %s, want %s", name, limitErr.Limit, tt.wantLimit)
+				}
+			}
+		})
+	}
+}
+
+func TestMediator_LimitsKeepAcceptedEvents(t *testing.T) {
+	m := NewMediator(
+		WithPartitioning(func(event Event) string { return "" }, 1),
+		WithLimits(Limits{MaxQueuedEvents: 3}),
+	)
+	handled := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		handled++
+		return nil
+	})
+	release := blockWorker(t, m)
+
+	for i := 0; i < 3; i++ {
+		if err := m.PublishAsync(context.Background(), Event{Name: "order.placed"}); err != nil {
+			t.Fatalf("PublishAsync() error = %v", err)
+		}
+	}
+	release()
+
+	// Test queued events run although the queue was at its limit
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if handled != 3 {
+		t.Errorf("handled %d events, want 3", handled)
+	}
+}
+
+func TestEstimateSize(t *testing.T) {
+	type order struct {
+		ID    int64
+		Total float64
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		want  int64
+	}{
+		{name: "empty", event: Event{}, want: eventSize},
+		{name: "strings", event: Event{Name: "order.placed", ID: "evt-1"}, want: eventSize + 17},
+		{name: "metadata", event: Event{Metadata: map[string]string{"tenant": "acme"}}, want: eventSize + 10},
+		{name: "bytes payload", event: Event{Payload: make([]byte, 100)}, want: eventSize + 100},
+		{name: "raw JSON payload", event: Event{Payload: json.RawMessage(`{"id":1}`)}, want: eventSize + 8},
+		{name: "string payload", event: Event{Payload: "hello"}, want: eventSize + 5},
+		{name: "struct payload", event: Event{Payload: order{}}, want: eventSize + 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateSize(tt.event); got != tt.want {
+				t.Errorf("estimateSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryDeadLetterQueue_Usage(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryDeadLetterQueue()
+	letter := DeadLetter{ID: "dl-1", Event: Event{Name: "order.placed"}, Error: "boom"}
+
+	q.Add(ctx, letter)
+	q.Add(ctx, DeadLetter{ID: "dl-2", Event: Event{Name: "order.shipped"}})
+	if letters, bytes := q.Usage(); letters != 2 || bytes <= estimateLetterSize(letter) {
+		t.Errorf("Usage() = %d, %d; want 2 letters over %d bytes", letters, bytes, estimateLetterSize(letter))
+	}
+
+	q.Remove(ctx, "order.placed", "dl-1")
+	q.Remove(ctx, "order.shipped", "dl-2")
+	if letters, bytes := q.Usage(); letters != 0 || bytes != 0 {
+		t.Errorf("Usage() after Remove = %d, %d; want 0, 0", letters, bytes)
+	}
+}
@@ -0,0 +1,609 @@
+// Package storetest is a conformance suite for mediator.EventStore
+// implementations. Run it from a store's tests to check it orders, limits,
+// clears, retains and concurrently stores events like every other store.
+// Stores return events chronologically, oldest or newest first; the suite
+// learns which from a store's first read and holds it to it:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T, retention mediator.Retention) mediator.EventStore {
+//			config := DefaultConfig()
+//			config.Retention = retention
+//			return setupTestStore(t, config)
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+)
+
+// Factory creates an event store applying retention. It is called once per
+// check and should close the store with t.Cleanup. Stores may share a
+// backend: every check uses event names of its own.
+type Factory func(t *testing.T, retention mediator.Retention) mediator.EventStore
+
+// Config tunes a conformance run
+type Config struct {
+	// Writers is the number of goroutines storing events concurrently
+	Writers int
+	// EventsPerWriter is the number of events each writer stores
+	EventsPerWriter int
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Writers:         8,
+		EventsPerWriter: 20,
+	}
+}
+
+// Run checks the store created by factory against the EventStore contract
+func Run(t *testing.T, factory Factory) {
+	t.Helper()
+	RunWithConfig(t, factory, DefaultConfig())
+}
+
+// RunWithConfig is Run with a tuned configuration
+func RunWithConfig(t *testing.T, factory Factory, config Config) {
+	t.Helper()
+	defaults := DefaultConfig()
+	if config.Writers <= 0 {
+		config.Writers = defaults.Writers
+	}
+	if config.EventsPerWriter <= 0 {
+		config.EventsPerWriter = defaults.EventsPerWriter
+	}
+
+	s := &suite{factory: factory, config: config, prefix: fmt.Sprintf("storetest.%d", time.Now().UnixNano())}
+	t.Run("RoundTrip", s.roundTrip)
+	t.Run("Ordering", s.ordering)
+	t.Run("Limit", s.limit)
+	t.Run("Isolation", s.isolation)
+	t.Run("Clear", s.clear)
+	t.Run("Concurrency", s.concurrency)
+	t.Run("Retention", s.retention)
+	t.Run("DeleteBefore", s.deleteBefore)
+	t.Run("ReadEvents", s.readEvents)
+	t.Run("Pages", s.pages)
+}
+
+// cutoffMargin separates events stored either side of a DeleteBefore cutoff
+const cutoffMargin = 25 * time.Millisecond
+
+// order is the direction a store returns events in
+type order int
+
+const (
+	unknownOrder order = iota
+	oldestFirst
+	newestFirst
+)
+
+func (o order) String() string {
+	switch o {
+	case oldestFirst:
+		return "oldest first"
+	case newestFirst:
+		return "newest first"
+	default:
+		return "in no chronological order"
+	}
+}
+
+// suite holds the state of a conformance run
+type suite struct {
+	factory Factory
+	config  Config
+	// prefix keeps the event names of runs sharing a backend apart
+	prefix string
+	// order is learnt from the first read returning several events
+	order order
+}
+
+// name returns the event name of a check
+func (s *suite) name(check string) string {
+	return s.prefix + "." + check
+}
+
+// store creates a store applying retention
+func (s *suite) store(t *testing.T, retention mediator.Retention) mediator.EventStore {
+	t.Helper()
+	store := s.factory(t, retention)
+	if store == nil {
+		t.Fatal("factory returned a nil store")
+	}
+	return store
+}
+
+func (s *suite) roundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := s.store(t, mediator.Retention{})
+	name := s.name("roundtrip")
+
+	event := mediator.Event{
+		ID:            "evt-1",
+		Name:          name,
+		Payload:       map[string]interface{}{"sku": "A-1", "qty": 2, "tags": []string{"new"}},
+		Timestamp:     time.Now().UTC().Truncate(time.Millisecond),
+		CorrelationID: "corr-1",
+		CausationID:   "cause-1",
+		Metadata:      map[string]string{"source": "storetest"},
+	}
+	mustStore(t, store, event)
+
+	events := mustGet(t, store, name, 10)
+	if len(events) != 1 {
+		t.Fatalf("GetEvents() returned %d events, want 1", len(events))
+	}
+	got := events[0]
+	for key, want := range map[string]string{"id": "evt-1", "name": name, "correlation_id": "corr-1", "causation_id": "cause-1"} {
+		if got[key] != want {
+			t.Errorf("event %s = %v, want %q", key, got[key], want)
+		}
+	}
+	if !equalJSON(got["payload"], event.Payload) {
+		t.Errorf("event payload = %#v, want %#v", got["payload"], event.Payload)
+	}
+	if !equalJSON(got["metadata"], event.Metadata) {
+		t.Errorf("event metadata = %#v, want %#v", got["metadata"], event.Metadata)
+	}
+
+	missing, err := store.GetEvents(ctx, s.name("roundtrip.missing"), 10)
+	if err != nil {
+		t.Fatalf("GetEvents() of an unknown event name error = %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("GetEvents() of an unknown event name returned %d events, want none", len(missing))
+	}
+}
+
+func (s *suite) ordering(t *testing.T) {
+	store := s.store(t, mediator.Retention{})
+
+	t.Run("by timestamp", func(t *testing.T) {
+		name := s.name("ordering.timestamp")
+		storeSequence(t, store, name, 10)
+		s.assertIDs(t, mustGet(t, store, name, 100), sequence(0, 10))
+	})
+
+	t.Run("same timestamp", func(t *testing.T) {
+		// Events sharing a timestamp come back in the order they were stored
+		name := s.name("ordering.tie")
+		at := time.Now().UTC().Truncate(time.Millisecond)
+		for i := 0; i < 5; i++ {
+			mustStore(t, store, mediator.Event{ID: fmt.Sprintf("evt-%d", i), Name: name, Payload: float64(i), Timestamp: at})
+		}
+		s.assertIDs(t, mustGet(t, store, name, 100), sequence(0, 5))
+	})
+}
+
+func (s *suite) limit(t *testing.T) {
+	store := s.store(t, mediator.Retention{})
+	name := s.name("limit")
+	storeSequence(t, store, name, 10)
+
+	tests := []struct {
+		name  string
+		limit int64
+		want  []string
+	}{
+		{name: "most recent", limit: 3, want: sequence(7, 10)},
+		{name: "one", limit: 1, want: sequence(9, 10)},
+		{name: "limit above count", limit: 20, want: sequence(0, 10)},
+		{name: "no limit", limit: 0, want: sequence(0, 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.assertIDs(t, mustGet(t, store, name, tt.limit), tt.want)
+		})
+	}
+}
+
+func (s *suite) isolation(t *testing.T) {
+	store := s.store(t, mediator.Retention{})
+	first, second := s.name("isolation.first"), s.name("isolation.second")
+
+	// Interleave the writes of both event names
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	for i := 0; i < 4; i++ {
+		timestamp := at.Add(time.Duration(i) * time.Millisecond)
+		mustStore(t, store, mediator.Event{ID: fmt.Sprintf("evt-%d", i), Name: first, Payload: float64(i), Timestamp: timestamp})
+		mustStore(t, store, mediator.Event{ID: fmt.Sprintf("other-%d", i), Name: second, Payload: float64(i), Timestamp: timestamp})
+	}
+
+	s.assertIDs(t, mustGet(t, store, first, 100), sequence(0, 4))
+	for _, event := range mustGet(t, store, second, 100) {
+		if event["name"] != second {
+			t.Errorf("GetEvents(%s) returned an event of %v", second, event["name"])
+		}
+	}
+}
+
+func (s *suite) clear(t *testing.T) {
+	ctx := context.Background()
+	store := s.store(t, mediator.Retention{})
+	cleared, kept := s.name("clear.cleared"), s.name("clear.kept")
+	storeSequence(t, store, cleared, 3)
+	storeSequence(t, store, kept, 3)
+
+	if err := store.ClearEvents(ctx, cleared); err != nil {
+		t.Fatalf("ClearEvents() error = %v", err)
+	}
+	s.assertIDs(t, mustGet(t, store, cleared, 100), nil)
+	s.assertIDs(t, mustGet(t, store, kept, 100), sequence(0, 3))
+
+	if err := store.ClearEvents(ctx, s.name("clear.missing")); err != nil {
+		t.Errorf("ClearEvents() of an unknown event name error = %v", err)
+	}
+
+	// A cleared event name is written to afresh
+	mustStore(t, store, mediator.Event{ID: "evt-new", Name: cleared, Payload: "new"})
+	s.assertIDs(t, mustGet(t, store, cleared, 100), []string{"evt-new"})
+}
+
+func (s *suite) concurrency(t *testing.T) {
+	ctx := context.Background()
+	store := s.store(t, mediator.Retention{})
+	name := s.name("concurrency")
+	total := s.config.Writers * s.config.EventsPerWriter
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		done   = make(chan struct{})
+		report = func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}
+	)
+
+	// Read while writing, so stores serializing access badly fail under -race
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := store.GetEvents(ctx, name, 10); err != nil {
+				report(fmt.Errorf("failed to get events: %w", err))
+				return
+			}
+		}
+	}()
+
+	var writers sync.WaitGroup
+	for w := 0; w < s.config.Writers; w++ {
+		writers.Add(1)
+		go func(writer int) {
+			defer writers.Done()
+			for seq := 0; seq < s.config.EventsPerWriter; seq++ {
+				event := mediator.Event{
+					ID:      fmt.Sprintf("w%d-%d", writer, seq),
+					Name:    name,
+					Payload: map[string]interface{}{"writer": writer, "seq": seq},
+				}
+				if err := store.StoreEvent(ctx, event); err != nil {
+					report(fmt.Errorf("failed to store event %s: %w", event.ID, err))
+				}
+			}
+		}(w)
+	}
+	writers.Wait()
+	close(done)
+	wg.Wait()
+
+	for _, err := range errs {
+		t.Error(err)
+	}
+
+	events := mustGet(t, store, name, int64(total))
+	if len(events) != total {
+		t.Fatalf("GetEvents() returned %d events, want %d", len(events), total)
+	}
+
+	// Every event is stored once, and each writer's events keep their order
+	if s.order == newestFirst {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	seen := make(map[string]bool, total)
+	last := make(map[float64]float64)
+	for _, event := range events {
+		id := fmt.Sprint(event["id"])
+		if seen[id] {
+			t.Errorf("event %s stored twice", id)
+		}
+		seen[id] = true
+
+		var payload struct {
+			Writer float64 `json:"writer"`
+			Seq    float64 `json:"seq"`
+		}
+		if err := convert(event["payload"], &payload); err != nil {
+			t.Fatalf("failed to decode payload of event %s: %v", id, err)
+		}
+		if prev, ok := last[payload.Writer]; ok && payload.Seq <= prev {
+			t.Errorf("writer %v: seq %v returned after seq %v", payload.Writer, payload.Seq, prev)
+		}
+		last[payload.Writer] = payload.Seq
+	}
+}
+
+func (s *suite) retention(t *testing.T) {
+	ctx := context.Background()
+	capped, kept := s.name("retention.capped"), s.name("retention.kept")
+	store := s.store(t, mediator.Retention{Events: map[string]mediator.RetentionPolicy{
+		capped: mediator.KeepLast(3),
+		kept:   mediator.KeepForever(),
+	}})
+	enforcer, ok := store.(mediator.RetentionEnforcer)
+	if !ok {
+		t.Skip("store does not implement mediator.RetentionEnforcer")
+	}
+
+	storeSequence(t, store, capped, 6)
+	storeSequence(t, store, kept, 6)
+	if err := enforcer.EnforceRetention(ctx); err != nil {
+		t.Fatalf("EnforceRetention() error = %v", err)
+	}
+
+	s.assertIDs(t, mustGet(t, store, capped, 100), sequence(3, 6))
+	s.assertIDs(t, mustGet(t, store, kept, 100), sequence(0, 6))
+
+	// Enforcing again changes nothing
+	if err := enforcer.EnforceRetention(ctx); err != nil {
+		t.Fatalf("EnforceRetention() error = %v", err)
+	}
+	s.assertIDs(t, mustGet(t, store, capped, 100), sequence(3, 6))
+}
+
+func (s *suite) deleteBefore(t *testing.T) {
+	ctx := context.Background()
+	store := s.store(t, mediator.Retention{})
+	compacting, ok := store.(mediator.CompactingEventStore)
+	if !ok {
+		t.Skip("store does not implement mediator.CompactingEventStore")
+	}
+	name, other := s.name("compact"), s.name("compact.other")
+
+	// Stores time events by their timestamp or by when they were stored;
+	// leaving timestamps to the store and sleeping across the cutoff makes
+	// both agree, allowing for coarse clocks such as file modification times
+	for i := 0; i < 3; i++ {
+		mustStore(t, store, mediator.Event{ID: fmt.Sprintf("evt-%d", i), Name: name, Payload: float64(i)})
+	}
+	storeSequence(t, store, other, 2)
+	time.Sleep(cutoffMargin)
+	cutoff := time.Now()
+	time.Sleep(cutoffMargin)
+	for i := 3; i < 6; i++ {
+		mustStore(t, store, mediator.Event{ID: fmt.Sprintf("evt-%d", i), Name: name, Payload: float64(i)})
+	}
+
+	if err := compacting.DeleteBefore(ctx, name, cutoff); err != nil {
+		t.Fatalf("DeleteBefore() error = %v", err)
+	}
+	s.assertIDs(t, mustGet(t, store, name, 100), sequence(3, 6))
+	s.assertIDs(t, mustGet(t, store, other, 100), sequence(0, 2))
+
+	if err := compacting.DeleteBefore(ctx, s.name("compact.missing"), cutoff); err != nil {
+		t.Errorf("DeleteBefore() of an unknown event name error = %v", err)
+	}
+}
+
+func (s *suite) readEvents(t *testing.T) {
+	store := s.store(t, mediator.Retention{})
+	reader, ok := store.(mediator.EventStoreV2)
+	if !ok {
+		t.Skip("store does not implement mediator.EventStoreV2")
+	}
+	name := s.name("read")
+
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	event := mediator.Event{
+		ID:            "evt-first",
+		Name:          name,
+		Payload:       map[string]interface{}{"sku": "A-1"},
+		Timestamp:     at,
+		CorrelationID: "corr-1",
+		CausationID:   "cause-1",
+		Metadata:      map[string]string{"source": "storetest"},
+	}
+	mustStore(t, store, event)
+	storeSequence(t, store, name, 3)
+
+	events, err := reader.ReadEvents(context.Background(), name, 100)
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("ReadEvents() returned %d events, want 4", len(events))
+	}
+	var got mediator.StoredEvent
+	for _, stored := range events {
+		if stored.ID == event.ID {
+			got = stored
+		}
+	}
+	if got.ID != event.ID || got.Name != name || got.CorrelationID != event.CorrelationID || got.CausationID != event.CausationID {
+		t.Errorf("ReadEvents() = %+v, want the fields of %+v", got, event)
+	}
+	if !got.Timestamp.Equal(at) {
+		t.Errorf("event timestamp = %v, want %v", got.Timestamp, at)
+	}
+	if !reflect.DeepEqual(got.Metadata, event.Metadata) {
+		t.Errorf("event metadata = %v, want %v", got.Metadata, event.Metadata)
+	}
+	if !equalJSON(got.Payload, event.Payload) {
+		t.Errorf("event payload = %#v, want %#v", got.Payload, event.Payload)
+	}
+
+	// ReadEvents and GetEvents agree on which events a limit returns
+	limited, err := reader.ReadEvents(context.Background(), name, 2)
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	want := mustGet(t, store, name, 2)
+	if len(limited) != len(want) {
+		t.Fatalf("ReadEvents() returned %d events, GetEvents() %d", len(limited), len(want))
+	}
+	for i := range limited {
+		if limited[i].ID != want[i]["id"] {
+			t.Errorf("ReadEvents()[%d] = %s, GetEvents() %v", i, limited[i].ID, want[i]["id"])
+		}
+	}
+}
+
+func (s *suite) pages(t *testing.T) {
+	store := s.store(t, mediator.Retention{})
+	pager, ok := store.(mediator.PagedEventStore)
+	if !ok {
+		t.Skip("store does not implement mediator.PagedEventStore")
+	}
+	name := s.name("pages")
+	storeSequence(t, store, name, 10)
+
+	var ids []string
+	var sizes []int
+	cursor := ""
+	for page := 0; page < 10; page++ {
+		events, next, err := pager.GetEventsPage(context.Background(), name, cursor, 4)
+		if err != nil {
+			t.Fatalf("GetEventsPage() error = %v", err)
+		}
+		sizes = append(sizes, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		if next == "" {
+			break
+		}
+		if len(events) > 0 && events[len(events)-1].Offset != "" && events[len(events)-1].Offset != next {
+			t.Errorf("last event offset = %q, want the next cursor %q", events[len(events)-1].Offset, next)
+		}
+		cursor = next
+	}
+
+	if !reflect.DeepEqual(sizes, []int{4, 4, 2}) {
+		t.Errorf("page sizes = %v, want [4 4 2]", sizes)
+	}
+	if !reflect.DeepEqual(ids, sequence(0, 10)) {
+		t.Errorf("paged ids = %v, want %v", ids, sequence(0, 10))
+	}
+}
+
+// storeSequence stores count events of an event name with ids evt-0 onwards,
+// a millisecond apart
+func storeSequence(t *testing.T, store mediator.EventStore, eventName string, count int) {
+	t.Helper()
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	for i := 0; i < count; i++ {
+		mustStore(t, store, mediator.Event{
+			ID:        fmt.Sprintf("evt-%d", i),
+			Name:      eventName,
+			Payload:   float64(i),
+			Timestamp: at.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+}
+
+// mustStore stores an event, failing t on error
+func mustStore(t *testing.T, store mediator.EventStore, event mediator.Event) {
+	t.Helper()
+	if err := store.StoreEvent(context.Background(), event); err != nil {
+		t.Fatalf("StoreEvent() error = %v", err)
+	}
+}
+
+// mustGet gets events, failing t on error
+func mustGet(t *testing.T, store mediator.EventStore, eventName string, limit int64) []map[string]interface{} {
+	t.Helper()
+	events, err := store.GetEvents(context.Background(), eventName, limit)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	return events
+}
+
+// assertIDs checks the ids of events against want, oldest first, in the
+// order the store returns events
+func (s *suite) assertIDs(t *testing.T, events []map[string]interface{}, want []string) {
+	t.Helper()
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, fmt.Sprint(event["id"]))
+	}
+	if len(ids) == 0 && len(want) == 0 {
+		return
+	}
+
+	if s.order == unknownOrder && len(want) > 1 {
+		switch {
+		case reflect.DeepEqual(ids, want):
+			s.order = oldestFirst
+		case reflect.DeepEqual(ids, reversed(want)):
+			s.order = newestFirst
+		}
+		if s.order != unknownOrder {
+			t.Logf("store returns events %s", s.order)
+		}
+	}
+	if s.order == newestFirst {
+		want = reversed(want)
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("event ids = %v, want %v (%s)", ids, want, s.order)
+	}
+}
+
+// reversed returns a reversed copy of ids
+func reversed(ids []string) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[len(ids)-1-i] = id
+	}
+	return result
+}
+
+// sequence returns the ids evt-from up to evt-(to-1)
+func sequence(from, to int) []string {
+	ids := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		ids = append(ids, fmt.Sprintf("evt-%d", i))
+	}
+	return ids
+}
+
+// equalJSON reports whether a and b have the same JSON encoding, so payloads
+// kept as published match payloads a store decoded
+func equalJSON(a, b interface{}) bool {
+	var x, y interface{}
+	if convert(a, &x) != nil || convert(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// convert copies v into target through its JSON encoding
+func convert(v, target interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,24 @@
+package storetest_test
+
+import (
+	"testing"
+
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/mediatortest"
+	"github.com/mandocaesar/mediator/pkg/mediator/storetest"
+)
+
+// newStore creates an in-memory store applying retention
+func newStore(t *testing.T, retention mediator.Retention) mediator.EventStore {
+	store := mediatortest.NewEventStore()
+	store.SetRetention(retention)
+	return store
+}
+
+func TestRun(t *testing.T) {
+	storetest.Run(t, newStore)
+}
+
+func TestRunWithConfig(t *testing.T) {
+	storetest.RunWithConfig(t, newStore, storetest.Config{Writers: 2, EventsPerWriter: 3})
+}
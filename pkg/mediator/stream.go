@@ -0,0 +1,52 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Expected versions accepted by StreamEventStore.AppendToStream besides the
+// version a stream is known to be at
+const (
+	// AnyVersion appends to a stream whatever its version
+	AnyVersion int64 = -1
+	// NoStream appends only if the stream has no events yet
+	NoStream int64 = 0
+)
+
+// ErrVersionConflict is matched by every *VersionConflictError
+var ErrVersionConflict = errors.New("stream version conflict")
+
+// VersionConflictError reports that a stream was not at the version an append expected,
+// because another writer appended to it first
+type VersionConflictError struct {
+	StreamID string
+	Expected int64
+	// Actual is the version the stream was at when the append failed
+	Actual int64
+}
+
+// Error implements the error interface
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("stream %s is at version %d, expected %d", e.StreamID, e.Actual, e.Expected)
+}
+
+// Unwrap returns ErrVersionConflict so errors.Is matches every conflict
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// StreamEventStore is implemented by event stores that keep ordered, versioned
+// streams for event sourcing. A stream's version is the number of events
+// appended to it; its first event has version 1.
+type StreamEventStore interface {
+	// AppendToStream appends events to a stream if it is at expectedVersion,
+	// or whatever its version with AnyVersion, and returns its new version.
+	// Concurrent appends expecting the same version fail with a
+	// *VersionConflictError for all but one of them.
+	AppendToStream(ctx context.Context, streamID string, expectedVersion int64, events []Event) (int64, error)
+
+	// ReadStream returns the events of a stream from fromVersion onwards, oldest first
+	ReadStream(ctx context.Context, streamID string, fromVersion int64) ([]StoredEvent, error)
+}
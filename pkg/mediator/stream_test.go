@@ -0,0 +1,23 @@
+package mediator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVersionConflictError(t *testing.T) {
+	var err error = &VersionConflictError{StreamID: "order-1", Expected: 2, Actual: 3}
+	wrapped := fmt.Errorf("failed to append: %w", err)
+
+	if !errors.Is(wrapped, ErrVersionConflict) {
+		t.Error("errors.Is(err, ErrVersionConflict) = false, want true")
+	}
+	var conflict *VersionConflictError
+	if !errors.As(wrapped, &conflict) || conflict.Actual != 3 {
+		t.Errorf("errors.As() = %+v, want the conflict at version 3", conflict)
+	}
+	if got, want := err.Error(), "stream order-1 is at version 3, expected 2"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package mediator
+
+import "sync"
+
+// subscriberRegistry maps event names to their subscriptions in dispatch
+// order. Publish reads the slice of an event name without locking; Subscribe
+// and Unsubscribe copy that slice alone, change the copy and swap it in, so a
+// slice never changes once published and publishes never wait for them.
+type subscriberRegistry struct {
+	// mu serializes writers; readers never take it
+	mu sync.Mutex
+	// subs holds the []*Subscription of each event name
+	subs sync.Map
+}
+
+// load returns the subscriptions of every event name. It copies the
+// registry, for introspection rather than dispatch; the slices must not be
+// modified.
+func (r *subscriberRegistry) load() map[string][]*Subscription {
+	registered := make(map[string][]*Subscription)
+	r.subs.Range(func(name, subs interface{}) bool {
+		registered[name.(string)] = subs.([]*Subscription)
+		return true
+	})
+	return registered
+}
+
+// get returns the subscriptions of an event name in dispatch order
+func (r *subscriberRegistry) get(eventName string) ([]*Subscription, bool) {
+	subs, ok := r.subs.Load(eventName)
+	if !ok {
+		return nil, false
+	}
+	return subs.([]*Subscription), true
+}
+
+// update replaces the subscriptions of an event name with those fn returns
+// for the current ones, unless fn reports no change. fn must return a new
+// slice rather than modify the one it is given. An event name left without
+// subscriptions is removed.
+func (r *subscriberRegistry) update(eventName string, fn func(subs []*Subscription) ([]*Subscription, bool)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, _ := r.get(eventName)
+	subs, changed := fn(current)
+	if !changed {
+		return false
+	}
+
+	if len(subs) == 0 {
+		r.subs.Delete(eventName)
+	} else {
+		r.subs.Store(eventName, subs)
+	}
+	return true
+}
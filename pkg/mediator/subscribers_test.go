@@ -0,0 +1,189 @@
+package mediator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSubscriberRegistry_Update(t *testing.T) {
+	first, second := &Subscription{name: "first"}, &Subscription{name: "second"}
+
+	tests := []struct {
+		name    string
+		initial []*Subscription
+		fn      func(subs []*Subscription) ([]*Subscription, bool)
+		want    []*Subscription
+		changed bool
+	}{
+		{
+			name:    "add",
+			initial: []*Subscription{first},
+			fn: func(subs []*Subscription) ([]*Subscription, bool) {
+				return append(subs[:len(subs):len(subs)], second), true
+			},
+			want:    []*Subscription{first, second},
+			changed: true,
+		},
+		{
+			name:    "unchanged",
+			initial: []*Subscription{first},
+			fn: func(subs []*Subscription) ([]*Subscription, bool) {
+				return nil, false
+			},
+			want: []*Subscription{first},
+		},
+		{
+			name:    "remove last",
+			initial: []*Subscription{first},
+			fn: func(subs []*Subscription) ([]*Subscription, bool) {
+				return nil, true
+			},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r subscriberRegistry
+			r.update("test.event", func([]*Subscription) ([]*Subscription, bool) { return tt.initial, true })
+			before := r.load()
+
+			if changed := r.update("test.event", tt.fn); changed != tt.changed {
+				t.Errorf("update() = %v, want %v", changed, tt.changed)
+			}
+			got, exists := r.get("test.event")
+			if exists != (len(tt.want) > 0) || len(got) != len(tt.want) {
+				t.Fatalf("get() = %v, %v, want %v", got, exists, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("get()[%d] = %s, want %s", i, got[i].name, tt.want[i].name)
+				}
+			}
+
+			// Snapshots taken before the update never change
+			if len(before["test.event"]) != len(tt.initial) {
+				t.Errorf("earlier snapshot holds %d subscriptions, want %d", len(before["test.event"]), len(tt.initial))
+			}
+		})
+	}
+}
+
+func TestSubscriberRegistry_UpdateKeepsOtherEvents(t *testing.T) {
+	var r subscriberRegistry
+	other := []*Subscription{{name: "other"}}
+	r.update("test.other", func([]*Subscription) ([]*Subscription, bool) { return other, true })
+
+	r.update("test.event", func([]*Subscription) ([]*Subscription, bool) { return []*Subscription{{name: "first"}}, true })
+	r.update("test.event", func([]*Subscription) ([]*Subscription, bool) { return nil, true })
+
+	// The slices of other event names are neither copied nor replaced
+	if got, _ := r.get("test.other"); len(got) != 1 || &got[0] != &other[0] {
+		t.Errorf("get() = %v, want the slice stored for test.other", got)
+	}
+}
+
+func TestSubscriberRegistry_ZeroValue(t *testing.T) {
+	var r subscriberRegistry
+	if subs, exists := r.get("test.event"); exists || subs != nil {
+		t.Errorf("get() = %v, %v on empty registry", subs, exists)
+	}
+	if len(r.load()) != 0 {
+		t.Errorf("load() = %v on empty registry", r.load())
+	}
+}
+
+// Run with -race: publishes read the registry while handlers come and go
+func TestMediator_PublishWhileSubscribing(t *testing.T) {
+	m := NewMediator()
+	var handled atomic.Int64
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		handled.Add(1)
+		return nil
+	})
+
+	const publishers, publishes = 8, 200
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// Subscribe and unsubscribe on other goroutines until the publishers finish
+	var churn sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		churn.Add(1)
+		go func() {
+			defer churn.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				sub := m.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil }, WithPriority(1))
+				other := m.Subscribe("test.other", func(ctx context.Context, event Event) error { return nil })
+				sub.Unsubscribe()
+				other.Unsubscribe()
+			}
+		}()
+	}
+
+	errs := make(chan error, publishers*publishes)
+	for i := 0; i < publishers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < publishes; j++ {
+				if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	churn.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if got := handled.Load(); got != publishers*publishes {
+		t.Errorf("handled %d events, want %d", got, publishers*publishes)
+	}
+	if subs, _ := m.subscribers.get("test.event"); len(subs) != 1 {
+		t.Errorf("registry holds %d subscriptions, want 1", len(subs))
+	}
+	if _, exists := m.subscribers.get("test.other"); exists {
+		t.Error("registry kept an event name without subscriptions")
+	}
+}
+
+func BenchmarkPublish_WhileSubscribing(b *testing.B) {
+	m := benchMediator(10)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			m.Subscribe("bench.other", func(ctx context.Context, event Event) error { return nil }).Unsubscribe()
+		}
+	}()
+
+	event := Event{Name: "bench", Payload: "payload"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			if err := m.Publish(ctx, event); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package mediator
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Subscription represents a handler registered for an event name
+type Subscription struct {
+	eventName   string
+	name        string
+	group       string
+	priority    int
+	timeout     time.Duration
+	retryPolicy *RetryPolicy
+	filters     []Filter
+	rateLimiter *rateLimiter
+	handler     EventHandler
+	mediator    *Mediator
+	createdAt   time.Time
+	paused      atomic.Bool
+	module      atomic.Pointer[Module]
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*Subscription)
+
+// WithPriority sets the priority of a handler. Handlers with a higher priority
+// run before those with a lower one; the default priority is 0.
+func WithPriority(priority int) SubscribeOption {
+	return func(s *Subscription) {
+		s.priority = priority
+	}
+}
+
+// EventName returns the event name the subscription listens to
+func (s *Subscription) EventName() string {
+	return s.eventName
+}
+
+// Name returns the handler name used in errors and logs
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+// WithTimeout sets the time limit of the handler, overriding the mediator default
+func WithTimeout(d time.Duration) SubscribeOption {
+	return func(s *Subscription) {
+		s.timeout = d
+	}
+}
+
+// WithHandlerName gives the handler a stable identity used in errors, logs,
+// dead letters and Subscriptions instead of the name derived from its function
+// symbol. Names should be unique per event name.
+func WithHandlerName(name string) SubscribeOption {
+	return func(s *Subscription) {
+		s.name = name
+	}
+}
+
+// SubscribedAt returns when the handler was registered
+func (s *Subscription) SubscribedAt() time.Time {
+	return s.createdAt
+}
+
+// Priority returns the priority of the handler
+func (s *Subscription) Priority() int {
+	return s.priority
+}
+
+// Pause stops dispatching events to the handler until Resume. Events
+// published meanwhile skip it, as if its filters rejected them; with an event
+// store they can be replayed to it with WithReplaySubscription.
+func (s *Subscription) Pause() {
+	s.paused.Store(true)
+}
+
+// Resume dispatches events to a paused handler again
+func (s *Subscription) Resume() {
+	s.paused.Store(false)
+}
+
+// Paused reports whether the handler, or the module it belongs to, is paused
+func (s *Subscription) Paused() bool {
+	if s.paused.Load() {
+		return true
+	}
+	mod := s.module.Load()
+	return mod != nil && mod.Paused()
+}
+
+// Module returns the name of the module the handler belongs to, if any
+func (s *Subscription) Module() string {
+	if mod := s.module.Load(); mod != nil {
+		return mod.name
+	}
+	return ""
+}
+
+// subscribed reports whether the handler is still registered
+func (s *Subscription) subscribed() bool {
+	subs, _ := s.mediator.subscribers.get(s.eventName)
+	for _, sub := range subs {
+		if sub == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Unsubscribe removes the handler from the mediator. It reports whether the
+// subscription was still registered and is safe to call more than once,
+// including from within the handler itself.
+func (s *Subscription) Unsubscribe() bool {
+	return s.mediator.Unsubscribe(s)
+}
+
+// SubscribeOnce adds a handler that is removed after its first successful
+// invocation. Failed invocations leave it subscribed; invocations are
+// serialized so the handler succeeds at most once.
+func (m *Mediator) SubscribeOnce(eventName string, handler EventHandler, opts ...SubscribeOption) *Subscription {
+	var (
+		mu   sync.Mutex
+		done bool
+		sub  *Subscription
+	)
+
+	// Name the subscription after the handler rather than the wrapper
+	opts = append([]SubscribeOption{WithHandlerName(handlerName(handler))}, opts...)
+
+	sub = m.Subscribe(eventName, func(ctx context.Context, event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if done {
+			return nil
+		}
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+		done = true
+		sub.Unsubscribe()
+		return nil
+	}, opts...)
+	return sub
+}
+
+// Unsubscribe removes a subscription and reports whether it was registered
+func (m *Mediator) Unsubscribe(sub *Subscription) bool {
+	if sub == nil {
+		return false
+	}
+
+	return m.subscribers.update(sub.eventName, func(subs []*Subscription) ([]*Subscription, bool) {
+		for i, existing := range subs {
+			if existing != sub {
+				continue
+			}
+
+			// Copy rather than shift in place so snapshots taken by Publish stay intact
+			remaining := make([]*Subscription, 0, len(subs)-1)
+			remaining = append(remaining, subs[:i]...)
+			remaining = append(remaining, subs[i+1:]...)
+			return remaining, true
+		}
+		return subs, false
+	})
+}
+
+// insertByPriority returns a copy of subs with sub placed after every
+// subscription of equal or higher priority
+func insertByPriority(subs []*Subscription, sub *Subscription) []*Subscription {
+	pos := len(subs)
+	for i, existing := range subs {
+		if existing.priority < sub.priority {
+			pos = i
+			break
+		}
+	}
+
+	result := make([]*Subscription, 0, len(subs)+1)
+	result = append(result, subs[:pos]...)
+	result = append(result, sub)
+	result = append(result, subs[pos:]...)
+	return result
+}
+
+// handlerName derives a readable name for a handler from its function symbol
+func handlerName(handler interface{}) string {
+	value := reflect.ValueOf(handler)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(value.Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
@@ -0,0 +1,240 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSubscription_Unsubscribe(t *testing.T) {
+	m := &Mediator{}
+
+	eventName := "test.event"
+	var calls []string
+	first := m.Subscribe(eventName, func(ctx context.Context, event Event) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	m.Subscribe(eventName, func(ctx context.Context, event Event) error {
+		calls = append(calls, "second")
+		return nil
+	})
+
+	if first.EventName() != eventName {
+		t.Errorf("EventName() = %s, want %s", first.EventName(), eventName)
+	}
+
+	// Test removing a handler
+	if !first.Unsubscribe() {
+		t.Error("Unsubscribe() returned false for registered subscription")
+	}
+	if len(m.subscribers.load()[eventName]) != 1 {
+		t.Errorf("Unsubscribe() left %d handlers, want 1", len(m.subscribers.load()[eventName]))
+	}
+
+	if err := m.Publish(context.Background(), Event{Name: eventName}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "second" {
+		t.Errorf("Publish() invoked %v, want [second]", calls)
+	}
+
+	// Test unsubscribing twice
+	if first.Unsubscribe() {
+		t.Error("Unsubscribe() returned true for removed subscription")
+	}
+}
+
+func TestSubscription_UnsubscribeLast(t *testing.T) {
+	m := &Mediator{}
+
+	sub := m.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+	sub.Unsubscribe()
+
+	if _, exists := m.subscribers.get("test.event"); exists {
+		t.Error("Unsubscribe() did not remove empty event entry")
+	}
+	if err := m.Publish(context.Background(), Event{Name: "test.event"}); err == nil {
+		t.Error("Publish() expected no handlers error after last unsubscribe")
+	}
+}
+
+func TestSubscription_UnsubscribeFromHandler(t *testing.T) {
+	m := &Mediator{}
+
+	calls := 0
+	var sub *Subscription
+	sub = m.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		calls++
+		sub.Unsubscribe()
+		return nil
+	})
+	m.Subscribe("test.event", func(ctx context.Context, event Event) error { return nil })
+
+	for i := 0; i < 2; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "test.event"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestMediator_UnsubscribeNil(t *testing.T) {
+	m := &Mediator{}
+	if m.Unsubscribe(nil) {
+		t.Error("Unsubscribe(nil) returned true")
+	}
+}
+
+func TestSubscribe_WithPriority(t *testing.T) {
+	m := NewMediator()
+
+	var calls []string
+	record := func(name string) EventHandler {
+		return func(ctx context.Context, event Event) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	m.Subscribe("product.created", record("persist"))
+	m.Subscribe("product.created", record("notify"), WithPriority(-10))
+	m.Subscribe("product.created", record("validate"), WithPriority(100))
+	m.Subscribe("product.created", record("audit"))
+	m.Subscribe("product.created", record("authorize"), WithPriority(100))
+
+	if err := m.Publish(context.Background(), Event{Name: "product.created"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	want := []string{"validate", "authorize", "persist", "audit", "notify"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestSubscribe_WithHandlerName(t *testing.T) {
+	logger := &mockLogger{}
+	m := NewMediator(WithLogger(logger))
+
+	sub := m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		return errors.New("sku service down")
+	}, WithHandlerName("sku.autocreate"))
+	if sub.Name() != "sku.autocreate" {
+		t.Errorf("Name() = %s, want sku.autocreate", sub.Name())
+	}
+
+	err := m.Publish(context.Background(), Event{Name: "product.created"})
+	var handlerErr *HandlerError
+	if !errors.As(err, &handlerErr) || handlerErr.HandlerName != "sku.autocreate" {
+		t.Errorf("Publish() error = %v, want HandlerError for sku.autocreate", err)
+	}
+
+	// Test duplicate names are reported
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error { return nil }, WithHandlerName("sku.autocreate"))
+	last := logger.lines[len(logger.lines)-1]
+	if !strings.Contains(last, "more than once") {
+		t.Errorf("Subscribe() logged %q, want duplicate handler name warning", last)
+	}
+}
+
+func TestMediator_SubscribeOnce(t *testing.T) {
+	m := NewMediator()
+
+	calls := 0
+	fail := true
+	sub := m.SubscribeOnce("order.shipped", func(ctx context.Context, event Event) error {
+		calls++
+		if fail {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+	m.Subscribe("order.shipped", func(ctx context.Context, event Event) error { return nil })
+
+	// Test a failed invocation keeps the subscription
+	if err := m.Publish(context.Background(), Event{Name: "order.shipped"}); err == nil {
+		t.Fatal("Publish() expected handler error")
+	}
+
+	fail = false
+	for i := 0; i < 3; i++ {
+		if err := m.Publish(context.Background(), Event{Name: "order.shipped"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+	if sub.Unsubscribe() {
+		t.Error("subscription still registered after successful invocation")
+	}
+	if !strings.Contains(sub.Name(), "TestMediator_SubscribeOnce") {
+		t.Errorf("Name() = %s, want the handler's name", sub.Name())
+	}
+}
+
+func TestMediator_SubscribeOnceParallel(t *testing.T) {
+	m := NewMediator(WithConcurrency(Parallel))
+
+	var mu sync.Mutex
+	calls := 0
+	m.SubscribeOnce("order.shipped", func(ctx context.Context, event Event) error {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return nil
+	})
+	m.Subscribe("order.shipped", func(ctx context.Context, event Event) error { return nil })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = m.Publish(context.Background(), Event{Name: "order.shipped"})
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestSubscription_Pause(t *testing.T) {
+	m := NewMediator()
+	var calls int
+	sub := m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	})
+
+	ctx := context.Background()
+	sub.Pause()
+	if !sub.Paused() {
+		t.Error("Paused() = false after Pause")
+	}
+	// A paused handler is still subscribed, so the publish succeeds
+	if err := m.Publish(ctx, Event{Name: "order.placed"}); err != nil {
+		t.Errorf("Publish() error = %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Paused handler called %d times", calls)
+	}
+
+	sub.Resume()
+	m.Publish(ctx, Event{Name: "order.placed"})
+	if calls != 1 {
+		t.Errorf("Resumed handler called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,185 @@
+package mediator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatestOffset passed to StoreSubscribe tails only events stored after it is called
+const LatestOffset = "$"
+
+// DefaultStorePollInterval is how often StoreSubscribe polls stores that cannot
+// push new events
+const DefaultStorePollInterval = time.Second
+
+// TailingEventStore is implemented by event stores that push new events to
+// subscribers themselves instead of being polled
+type TailingEventStore interface {
+	// TailEvents streams the events of an event name stored after fromOffset,
+	// oldest first, until ctx is cancelled. fromOffset is a page cursor, empty
+	// for the oldest event or LatestOffset for new events only.
+	TailEvents(ctx context.Context, eventName, fromOffset string) (<-chan StoredEvent, error)
+}
+
+// WithStorePollInterval sets how often StoreSubscribe polls the event store
+func WithStorePollInterval(d time.Duration) Option {
+	return func(m *Mediator) {
+		m.pollInterval = d
+	}
+}
+
+// StoreSubscribe tails the events of an event name written to the event store,
+// including by other processes sharing it, so the store can serve as a
+// lightweight cross-process bus. Events stored after fromOffset are sent oldest
+// first until ctx is cancelled, when the channel is closed. fromOffset is a
+// page cursor such as the Offset of the last event processed, empty for the
+// oldest event or LatestOffset for new events only.
+func (m *Mediator) StoreSubscribe(ctx context.Context, eventName, fromOffset string) (<-chan StoredEvent, error) {
+	ctx = m.storeContext(ctx)
+	m.mu.RLock()
+	eventStore := m.eventStore
+	interval := m.pollInterval
+	m.mu.RUnlock()
+
+	if eventStore == nil {
+		return nil, fmt.Errorf("no event store configured")
+	}
+	if interval <= 0 {
+		interval = DefaultStorePollInterval
+	}
+	// Poll the store itself; reading through the buffer would flush it every poll
+	eventStore = unwrapStore(eventStore)
+
+	streamName := namespacedName(m.namespaceOf(ctx), eventName)
+	events, err := tailStore(ctx, eventStore, streamName, fromOffset, interval, m.logf)
+	if err != nil {
+		return nil, err
+	}
+	return m.rehydrateTail(ctx, eventName, events), nil
+}
+
+// tailStore streams the events of a stream stored after fromOffset, as
+// stored, until ctx is cancelled. Stores that cannot push new events are
+// polled every interval, reporting failed polls to logf.
+func tailStore(ctx context.Context, eventStore EventStore, streamName, fromOffset string, interval time.Duration, logf func(format string, args ...interface{})) (<-chan StoredEvent, error) {
+	if tailing, ok := eventStore.(TailingEventStore); ok {
+		events, err := tailing.TailEvents(ctx, streamName, fromOffset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tail events: %w", err)
+		}
+		return events, nil
+	}
+
+	cursor := fromOffset
+	if cursor == LatestOffset {
+		var err error
+		if cursor, err = latestOffset(ctx, eventStore, streamName); err != nil {
+			return nil, fmt.Errorf("failed to find latest offset: %w", err)
+		}
+	}
+	// Read the first page up front so an invalid offset is reported here
+	page, next, err := pageEvents(ctx, eventStore, streamName, cursor, DefaultPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	events := make(chan StoredEvent)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, event := range page {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if next != "" {
+				cursor = next
+			} else if len(page) > 0 {
+				cursor = page[len(page)-1].Offset
+			}
+			// Wait for new events unless a full page suggests more are stored
+			if next == "" {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if page, next, err = pageEvents(ctx, eventStore, streamName, cursor, DefaultPageSize); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logf("tailing of event %s: %v", streamName, err)
+				page, next = nil, ""
+			}
+		}
+	}()
+	return events, nil
+}
+
+// DispatchStored dispatches an event read from the event store to the local
+// subscribers without storing it again or sending it through the
+// transports, e.g. one another process stored
+func (m *Mediator) DispatchStored(ctx context.Context, stored StoredEvent) error {
+	event := stored.Event()
+	if event.Namespace != "" {
+		event.Name = strings.TrimPrefix(event.Name, event.Namespace+NamespaceSeparator)
+	}
+
+	var err error
+	if event.Payload, err = m.rehydrateStoredPayload(event.Name, stored); err != nil {
+		return fmt.Errorf("failed to rehydrate payload: %w", err)
+	}
+	return m.publish(ctx, event, []PublishOption{withoutStore(), localOnly()})
+}
+
+// rehydrateTail rehydrates the payloads of events pushed by a tailing store
+func (m *Mediator) rehydrateTail(ctx context.Context, eventName string, in <-chan StoredEvent) <-chan StoredEvent {
+	out := make(chan StoredEvent)
+	go func() {
+		defer close(out)
+		for event := range in {
+			batch := []StoredEvent{event}
+			if err := m.rehydrateStored(eventName, batch); err != nil {
+				m.logf("tailing of event %s: %v", eventName, err)
+			}
+			select {
+			case out <- batch[0]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// latestOffset returns the cursor after the most recent event of an event name
+func latestOffset(ctx context.Context, store EventStore, eventName string) (string, error) {
+	if _, ok := store.(PagedEventStore); ok {
+		events, err := AsEventStoreV2(store).ReadEvents(ctx, eventName, 1)
+		if err != nil || len(events) == 0 {
+			return "", err
+		}
+		if events[0].Offset == "" {
+			return "", fmt.Errorf("event store does not report offsets")
+		}
+		return events[0].Offset, nil
+	}
+
+	// Stores paged in memory use the number of events as the cursor
+	events, err := AsEventStoreV2(store).ReadEvents(ctx, eventName, 0)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(len(events)), nil
+}
@@ -0,0 +1,111 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// receive waits for the next tailed event
+func receive(t *testing.T, events <-chan StoredEvent) StoredEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("tail channel closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a tailed event")
+	}
+	return StoredEvent{}
+}
+
+func TestMediator_StoreSubscribe(t *testing.T) {
+	tests := []struct {
+		name       string
+		fromOffset string
+		want       []interface{}
+	}{
+		{"from oldest", "", []interface{}{"a", "b", "c"}},
+		{"from offset", "1", []interface{}{"b", "c"}},
+		{"latest only", LatestOffset, []interface{}{"c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Publisher and subscriber are separate mediators sharing a store
+			store := &mockEventStore{}
+			publisher := NewMediator(WithEventStore(store), WithDeliveryMode(StoreOnly))
+			subscriber := NewMediator(WithEventStore(store), WithStorePollInterval(5*time.Millisecond))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			for _, payload := range []string{"a", "b"} {
+				if err := publisher.Publish(ctx, Event{Name: "stock.changed", Payload: payload}); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+
+			events, err := subscriber.StoreSubscribe(ctx, "stock.changed", tt.fromOffset)
+			if err != nil {
+				t.Fatalf("StoreSubscribe() error = %v", err)
+			}
+			if err := publisher.Publish(ctx, Event{Name: "stock.changed", Payload: "c"}); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if got := receive(t, events); got.Payload != want {
+					t.Errorf("tailed payload = %v, want %v", got.Payload, want)
+				}
+			}
+
+			// Test cancelling the context closes the channel
+			cancel()
+			for range events {
+			}
+		})
+	}
+}
+
+func TestMediator_StoreSubscribeErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewMediator().StoreSubscribe(ctx, "stock.changed", ""); err == nil {
+		t.Error("StoreSubscribe() without an event store succeeded")
+	}
+
+	m := NewMediator(WithEventStore(&mockEventStore{}))
+	if _, err := m.StoreSubscribe(ctx, "stock.changed", "abc"); err == nil {
+		t.Error("StoreSubscribe() with an invalid offset succeeded")
+	}
+}
+
+func TestMediator_DispatchStored(t *testing.T) {
+	store := &mockEventStore{}
+	m := NewMediator(WithEventStore(store), WithNamespace("acme"))
+
+	var got Event
+	m.Subscribe("stock.changed", func(ctx context.Context, event Event) error {
+		got = event
+		return nil
+	})
+
+	ctx := context.Background()
+	stored := StoredEvent{ID: "evt-1", Name: "acme:stock.changed", Namespace: "acme", Payload: "a"}
+	if err := m.DispatchStored(ctx, stored); err != nil {
+		t.Fatalf("DispatchStored() error = %v", err)
+	}
+	if got.ID != "evt-1" || got.Name != "stock.changed" || got.Payload != "a" {
+		t.Errorf("dispatched event = %+v, want evt-1 as stock.changed", got)
+	}
+	if len(store.events) != 0 {
+		t.Errorf("DispatchStored() stored %d events, want none", len(store.events))
+	}
+
+	err := m.DispatchStored(ctx, StoredEvent{ID: "evt-2", Name: "acme:stock.counted", Namespace: "acme"})
+	if !errors.Is(err, ErrNoHandlers) {
+		t.Errorf("DispatchStored() error = %v, want ErrNoHandlers", err)
+	}
+}
@@ -0,0 +1,43 @@
+package mediator
+
+import "strings"
+
+// WithHierarchicalTopics lets subscribers of a topic receive the events of its
+// descendants: a handler of "product" also receives "product.created" and
+// "product.detail.created". Handlers of the exact event name run first, then
+// those of its ancestors from the nearest to the most distant; within each
+// level priority and registration order apply.
+func WithHierarchicalTopics() Option {
+	return func(m *Mediator) {
+		m.hierarchical = true
+	}
+}
+
+// matchingSubscriptions returns the subscriptions receiving an event name in
+// dispatch order
+func (m *Mediator) matchingSubscriptions(eventName string) ([]*Subscription, bool) {
+	subs, exists := m.subscribers.get(eventName)
+	if !m.hierarchical {
+		return subs, exists
+	}
+
+	for _, ancestor := range topicAncestors(eventName) {
+		if ancestorSubs, ok := m.subscribers.get(ancestor); ok {
+			// Copy so the registered slice is never appended to
+			subs = append(subs[:len(subs):len(subs)], ancestorSubs...)
+			exists = true
+		}
+	}
+	return subs, exists
+}
+
+// topicAncestors returns the ancestors of a dotted topic, nearest first:
+// "a.b.c" yields "a.b" and "a"
+func topicAncestors(topic string) []string {
+	var ancestors []string
+	for i := strings.LastIndex(topic, "."); i > 0; i = strings.LastIndex(topic, ".") {
+		topic = topic[:i]
+		ancestors = append(ancestors, topic)
+	}
+	return ancestors
+}
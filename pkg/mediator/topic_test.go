@@ -0,0 +1,111 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTopicAncestors(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  []string
+	}{
+		{topic: "product.detail.created", want: []string{"product.detail", "product"}},
+		{topic: "product.created", want: []string{"product"}},
+		{topic: "product", want: nil},
+		{topic: ".hidden", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			if got := topicAncestors(tt.topic); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("topicAncestors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediator_HierarchicalTopics(t *testing.T) {
+	m := NewMediator(WithHierarchicalTopics())
+
+	var calls []string
+	record := func(name string) EventHandler {
+		return func(ctx context.Context, event Event) error {
+			calls = append(calls, name+":"+event.Name)
+			return nil
+		}
+	}
+	m.Subscribe("product", record("product"), WithPriority(100))
+	m.Subscribe("product.detail", record("detail"))
+	m.Subscribe("product.detail.created", record("exact"))
+	m.Subscribe("sku", record("sku"))
+
+	if err := m.Publish(context.Background(), Event{Name: "product.detail.created"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	// Ancestor-only events are delivered too
+	if err := m.Publish(context.Background(), Event{Name: "product.updated"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	want := []string{
+		"exact:product.detail.created",
+		"detail:product.detail.created",
+		"product:product.detail.created",
+		"product:product.updated",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestMediator_FlatTopicsByDefault(t *testing.T) {
+	m := NewMediator()
+	m.Subscribe("product", func(ctx context.Context, event Event) error {
+		t.Error("ancestor handler invoked without WithHierarchicalTopics")
+		return nil
+	})
+
+	if err := m.Publish(context.Background(), Event{Name: "product.created"}); err == nil {
+		t.Error("Publish() expected no handlers error")
+	}
+}
+
+func TestMediator_HierarchicalGroupRecover(t *testing.T) {
+	store := NewMemoryGroupStore()
+	m := NewMediator(WithHierarchicalTopics(), WithGroupStore(store))
+
+	failing := true
+	var received []string
+	m.Subscribe("product", func(ctx context.Context, event Event) error {
+		received = append(received, event.Name)
+		if failing {
+			return errors.New("search index unavailable")
+		}
+		return nil
+	}, WithGroup("search"))
+
+	if err := m.Publish(context.Background(), Event{Name: "product.created"}); err == nil {
+		t.Fatal("Publish() expected handler error")
+	}
+
+	// Test the record is logged under the subscribed topic, not the event name
+	pending, err := store.Pending(context.Background(), "search", "product")
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("Pending() = %d records, want 1", len(pending))
+	}
+
+	failing = false
+	if n, err := m.Recover(context.Background()); err != nil || n != 1 {
+		t.Fatalf("Recover() = %d, %v, want 1, nil", n, err)
+	}
+	want := []string{"product.created", "product.created"}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("received = %v, want %v", received, want)
+	}
+}
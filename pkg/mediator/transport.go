@@ -0,0 +1,240 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RemoteMetadataKey is the metadata key set on the events of other instances
+// dispatched here, by transports or bridges, so they are not forwarded back
+const RemoteMetadataKey = "remote"
+
+// OriginMetadataKey is the metadata key identifying the mediator that sent an
+// event through its transports, so it skips the event when it comes back
+const OriginMetadataKey = "origin"
+
+// IsRemote reports whether an event was published by another instance and
+// dispatched here by a transport or bridge
+func IsRemote(event Event) bool {
+	return event.Metadata[RemoteMetadataKey] == "true"
+}
+
+// ShouldForward reports whether an AfterPublish hook should forward a
+// published event outside the process, given the error of its publish. It
+// skips events received from other instances, which forwarded them already,
+// events written to an outbox, which are published again once the relay
+// dispatches them, and events rejected before dispatch.
+func ShouldForward(ctx context.Context, event Event, err error) bool {
+	if IsRemote(event) || InOutbox(ctx) {
+		return false
+	}
+	return !errors.Is(err, ErrInvalidEvent) && !errors.Is(err, ErrRateLimited)
+}
+
+// MarkRemote returns stored marked with IsRemote, e.g. to dispatch an event
+// of another instance with DispatchStored. The metadata of
+// stored is copied, not modified.
+func MarkRemote(stored StoredEvent) StoredEvent {
+	stored.Metadata = withMetadata(stored.Metadata, RemoteMetadataKey, "true")
+	return stored
+}
+
+// Transport carries events between the mediators of different processes,
+// e.g. over a message broker
+type Transport interface {
+	// Publish sends an event dispatched locally to the other mediators
+	Publish(ctx context.Context, event Event) error
+	// Subscribe returns the events of an event name sent by other mediators;
+	// the channel is closed when the transport stops
+	Subscribe(eventName string) (<-chan Event, error)
+}
+
+// WithTransport attaches transports to the mediator. Every event dispatched
+// locally is also sent through them, and stored, even without local
+// handlers; the event names subscribed to are received from them and
+// dispatched to the local handlers, marked with IsRemote and not stored again.
+func WithTransport(transports ...Transport) Option {
+	return func(m *Mediator) {
+		if m.remote == nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			m.remote = &remoteFanOut{
+				origin:   newID(),
+				ctx:      ctx,
+				received: make(map[string]bool),
+			}
+			m.onClose(func() error {
+				cancel()
+				return nil
+			})
+		}
+		m.remote.transports = append(m.remote.transports, transports...)
+	}
+}
+
+// remoteFanOut is the state of the transports attached to a mediator
+type remoteFanOut struct {
+	transports []Transport
+	// origin identifies the mediator in the events it sends
+	origin string
+	// ctx stops receiving when the mediator is closed
+	ctx context.Context
+	// mu guards received, so subscribing doesn't take the mediator's lock
+	mu sync.Mutex
+	// received holds the event names subscribed to on the transports
+	received map[string]bool
+}
+
+// localOnly dispatches the event to the local handlers without sending it
+// through the transports, e.g. when it came from one or is redelivered
+func localOnly() PublishOption {
+	return func(c *publishConfig) {
+		c.localOnly = true
+	}
+}
+
+// forward sends an event dispatched locally through the transports
+func (m *Mediator) forward(ctx context.Context, event Event, transports []Transport) []error {
+	event.Metadata = withMetadata(event.Metadata, OriginMetadataKey, m.remote.origin)
+
+	var errs []error
+	for _, transport := range transports {
+		if err := transport.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send event to transport: %w", err))
+		}
+	}
+	return errs
+}
+
+// receive subscribes the transports to an event name the first time a
+// handler subscribes to it, dispatching their events to the local handlers
+func (m *Mediator) receive(eventName string) {
+	// Transports are only attached while creating the mediator
+	remote := m.remote
+	if remote == nil {
+		return
+	}
+
+	remote.mu.Lock()
+	if remote.received[eventName] {
+		remote.mu.Unlock()
+		return
+	}
+	remote.received[eventName] = true
+	transports := remote.transports
+	remote.mu.Unlock()
+
+	for _, transport := range transports {
+		events, err := transport.Subscribe(eventName)
+		if err != nil {
+			m.logf("subscription to %s on transport failed: %v", eventName, err)
+			continue
+		}
+		go m.dispatchRemote(events)
+	}
+}
+
+// dispatchRemote dispatches the events received from a transport until its
+// channel is closed or the mediator is closed
+func (m *Mediator) dispatchRemote(events <-chan Event) {
+	ctx := m.remote.ctx
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := m.DispatchRemote(ctx, event); err != nil {
+				m.logf("remote event %s: %v", event.ID, err)
+			}
+		}
+	}
+}
+
+// DispatchRemote dispatches an event another mediator published to the
+// local handlers, like the events received from transports: it is marked
+// with IsRemote, its payload is rehydrated, and it is neither stored nor
+// sent through the transports again. Events this mediator sent through its
+// transports are skipped, as are events without local handlers. Consumers
+// acknowledging broker messages once handlers succeeded dispatch with it.
+func (m *Mediator) DispatchRemote(ctx context.Context, event Event) error {
+	if m.remote != nil && event.Metadata[OriginMetadataKey] == m.remote.origin {
+		return nil
+	}
+	event.Metadata = withMetadata(event.Metadata, RemoteMetadataKey, "true")
+
+	var err error
+	if event.Payload, err = m.rehydrate(event.Name, event.Payload); err != nil {
+		return fmt.Errorf("failed to rehydrate payload: %w", err)
+	}
+	err = m.publish(ctx, event, []PublishOption{withoutStore(), localOnly()})
+	if err != nil && !errors.Is(err, ErrNoHandlers) {
+		return err
+	}
+	return nil
+}
+
+// MemoryTransport is a Transport between the mediators of one process,
+// e.g. to test services together
+type MemoryTransport struct {
+	mu          sync.RWMutex
+	subscribers map[string][]chan Event
+	closed      bool
+}
+
+// NewMemoryTransport creates an in-memory transport
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{subscribers: make(map[string][]chan Event)}
+}
+
+// Publish sends an event to the subscribers of its name, waiting for them
+// to have room for it
+func (t *MemoryTransport) Publish(ctx context.Context, event Event) error {
+	// Handlers of the subscribers may publish while this waits for room
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return fmt.Errorf("transport is closed")
+	}
+
+	for _, ch := range t.subscribers[event.Name] {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe returns the events published with an event name
+func (t *MemoryTransport) Subscribe(eventName string) (<-chan Event, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, fmt.Errorf("transport is closed")
+	}
+
+	ch := make(chan Event, 64)
+	t.subscribers[eventName] = append(t.subscribers[eventName], ch)
+	return ch, nil
+}
+
+// Close closes the channels of the subscribers
+func (t *MemoryTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	for _, subscribers := range t.subscribers {
+		for _, ch := range subscribers {
+			close(ch)
+		}
+	}
+	return nil
+}
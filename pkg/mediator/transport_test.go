@@ -0,0 +1,349 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport records the events published and serves subscriptions
+// from a channel per event name
+type recordingTransport struct {
+	mu        sync.Mutex
+	published []Event
+	channels  map[string]chan Event
+	err       error
+}
+
+func (t *recordingTransport) Publish(ctx context.Context, event Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	t.published = append(t.published, event)
+	return nil
+}
+
+func (t *recordingTransport) Subscribe(eventName string) (<-chan Event, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.channels == nil {
+		t.channels = make(map[string]chan Event)
+	}
+	ch := make(chan Event, 10)
+	t.channels[eventName] = ch
+	return ch, nil
+}
+
+func (t *recordingTransport) events() []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Event(nil), t.published...)
+}
+
+func (t *recordingTransport) channel(eventName string) chan Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.channels[eventName]
+}
+
+func TestIsRemote(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		want     bool
+	}{
+		{"no metadata", nil, false},
+		{"other metadata", map[string]string{"tenant": "acme"}, false},
+		{"remote", map[string]string{RemoteMetadataKey: "true"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRemote(Event{Name: "order.placed", Metadata: tt.metadata}); got != tt.want {
+				t.Errorf("IsRemote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldForward(t *testing.T) {
+	remote := Event{Name: "order.placed", Metadata: map[string]string{RemoteMetadataKey: "true"}}
+	outbox := ContextWithOutbox(context.Background(), &memoryOutbox{})
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		event Event
+		err   error
+		want  bool
+	}{
+		{"dispatched", context.Background(), Event{Name: "order.placed"}, nil, true},
+		{"handler failed", context.Background(), Event{Name: "order.placed"}, errors.New("handler failed"), true},
+		{"no handlers", context.Background(), Event{Name: "order.placed"}, ErrNoHandlers, true},
+		{"remote", context.Background(), remote, nil, false},
+		{"in outbox", outbox, Event{Name: "order.placed"}, nil, false},
+		{"invalid", context.Background(), Event{Name: "order.placed"}, fmt.Errorf("%w: missing id", ErrInvalidEvent), false},
+		{"rate limited", context.Background(), Event{Name: "order.placed"}, ErrRateLimited, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldForward(tt.ctx, tt.event, tt.err); got != tt.want {
+				t.Errorf("ShouldForward() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkRemote(t *testing.T) {
+	metadata := map[string]string{"tenant": "acme"}
+	stored := StoredEvent{ID: "evt-1", Name: "order.placed", Metadata: metadata}
+
+	remote := MarkRemote(stored)
+	if !IsRemote(remote.Event()) || remote.Metadata["tenant"] != "acme" {
+		t.Errorf("MarkRemote() metadata = %v, want tenant kept and marked remote", remote.Metadata)
+	}
+	if IsRemote(stored.Event()) || len(metadata) != 1 {
+		t.Errorf("MarkRemote() modified the metadata of its argument: %v", metadata)
+	}
+}
+
+func TestWithTransport_Publish(t *testing.T) {
+	transport := &recordingTransport{}
+	store := &mockEventStore{}
+	m := NewMediator(WithTransport(transport), WithEventStore(store))
+	defer m.Close()
+
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		publish func() error
+		wantErr error
+	}{
+		{"with local handlers", func() error { return m.Publish(ctx, Event{Name: "order.placed", ID: "evt-1"}) }, nil},
+		{"without local handlers", func() error { return m.Publish(ctx, Event{Name: "user.created", ID: "evt-2"}) }, nil},
+		{"remote", func() error {
+			return m.Publish(ctx, Event{Name: "order.placed", ID: "evt-3", Metadata: map[string]string{RemoteMetadataKey: "true"}})
+		}, nil},
+		{"dispatched from the store", func() error {
+			return m.DispatchStored(ctx, StoredEvent{ID: "evt-4", Name: "order.placed"})
+		}, nil},
+		{"replayed", func() error { _, err := m.Replay(ctx, "order.placed"); return err }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.publish(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Test only the events published locally for the first time were sent
+	events := transport.events()
+	if len(events) != 2 || events[0].ID != "evt-1" || events[1].ID != "evt-2" {
+		t.Fatalf("Expected evt-1 and evt-2 to be sent, got %+v", events)
+	}
+	if events[0].Metadata[OriginMetadataKey] == "" {
+		t.Error("Expected sent events to carry an origin")
+	}
+
+	// Test the event sent without local handlers was stored too
+	stored, _ := store.GetEvents(ctx, "user.created", 10)
+	if len(stored) != 1 {
+		t.Errorf("Expected evt-2 to be stored, got %d events", len(stored))
+	}
+}
+
+func TestWithTransport_PublishError(t *testing.T) {
+	transport := &recordingTransport{err: errors.New("broker down")}
+	m := NewMediator(WithTransport(transport))
+	defer m.Close()
+
+	calls := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		calls++
+		return nil
+	})
+
+	err := m.Publish(context.Background(), Event{Name: "order.placed"})
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) || !errors.Is(err, transport.err) {
+		t.Fatalf("Expected a PublishError wrapping the transport error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the local handler to run once, got %d", calls)
+	}
+}
+
+func TestWithTransport_Receive(t *testing.T) {
+	transport := &recordingTransport{}
+	store := &mockEventStore{}
+	m := NewMediator(WithTransport(transport), WithEventStore(store))
+
+	received := make(chan Event, 10)
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		received <- event
+		return nil
+	})
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error { return nil })
+
+	ch := transport.channel("order.placed")
+	if ch == nil {
+		t.Fatal("Expected the transport to be subscribed to order.placed")
+	}
+
+	// An event this mediator sent comes back and is skipped
+	if err := m.Publish(context.Background(), Event{Name: "order.placed", ID: "evt-own"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	<-received
+	ch <- transport.events()[0]
+	ch <- Event{Name: "order.placed", ID: "evt-remote"}
+
+	select {
+	case event := <-received:
+		if event.ID != "evt-remote" || !IsRemote(event) {
+			t.Errorf("Expected remote evt-remote, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the remote event")
+	}
+
+	// Test the remote event was neither stored nor sent back
+	if len(store.events) != 1 {
+		t.Errorf("Expected only the local event to be stored, got %d", len(store.events))
+	}
+	if len(transport.events()) != 1 {
+		t.Errorf("Expected only the local event to be sent, got %d", len(transport.events()))
+	}
+
+	// Test closing the mediator stops receiving
+	m.Close()
+	ch <- Event{Name: "order.placed", ID: "evt-late"}
+	select {
+	case event := <-received:
+		t.Errorf("Expected no event after Close, got %s", event.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDispatchRemote(t *testing.T) {
+	transport := &recordingTransport{}
+	store := &mockEventStore{}
+	m := NewMediator(WithTransport(transport), WithEventStore(store))
+	RegisterEvent[*testProduct](m, "product.created")
+
+	var received []Event
+	m.Subscribe("product.created", func(ctx context.Context, event Event) error {
+		received = append(received, event)
+		if event.ID == "evt-failing" {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	// Test the payload is rehydrated and the event marked remote
+	payload := map[string]interface{}{"id": "p-1", "price": 10.0}
+	if err := m.DispatchRemote(ctx, Event{Name: "product.created", ID: "evt-1", Payload: payload}); err != nil {
+		t.Fatalf("DispatchRemote() error = %v", err)
+	}
+	if len(received) != 1 || !IsRemote(received[0]) {
+		t.Fatalf("received = %+v, want evt-1 marked remote", received)
+	}
+	if product, ok := received[0].Payload.(*testProduct); !ok || product.ID != "p-1" {
+		t.Errorf("Payload = %#v, want a rehydrated *testProduct", received[0].Payload)
+	}
+
+	// Test the events of this mediator and events without handlers are skipped
+	own := Event{Name: "product.created", ID: "evt-own", Metadata: map[string]string{OriginMetadataKey: m.remote.origin}}
+	if err := m.DispatchRemote(ctx, own); err != nil || len(received) != 1 {
+		t.Errorf("DispatchRemote() of an own event error = %v, received %d", err, len(received))
+	}
+	if err := m.DispatchRemote(ctx, Event{Name: "product.deleted", ID: "evt-2"}); err != nil {
+		t.Errorf("DispatchRemote() without handlers error = %v", err)
+	}
+
+	// Test handler errors are returned, for consumers to redeliver
+	if err := m.DispatchRemote(ctx, Event{Name: "product.created", ID: "evt-failing", Payload: payload}); err == nil {
+		t.Error("DispatchRemote() error = nil, want the handler's error")
+	}
+
+	if len(store.events) != 0 || len(transport.events()) != 0 {
+		t.Errorf("stored %d and sent %d remote events, want none", len(store.events), len(transport.events()))
+	}
+}
+
+func TestMemoryTransport(t *testing.T) {
+	transport := NewMemoryTransport()
+	defer transport.Close()
+
+	orders := NewMediator(WithTransport(transport))
+	defer orders.Close()
+	billing := NewMediator(WithTransport(transport))
+	defer billing.Close()
+	shipping := NewMediator(WithTransport(transport))
+	defer shipping.Close()
+
+	var mu sync.Mutex
+	handled := make(map[string][]string)
+	done := make(chan struct{}, 10)
+	handler := func(service string) EventHandler {
+		return func(ctx context.Context, event Event) error {
+			mu.Lock()
+			handled[service] = append(handled[service], event.ID)
+			mu.Unlock()
+			done <- struct{}{}
+			return nil
+		}
+	}
+	orders.Subscribe("order.placed", handler("orders"))
+	billing.Subscribe("order.placed", handler("billing"))
+	shipping.Subscribe("order.shipped", handler("shipping"))
+
+	if err := orders.Publish(context.Background(), Event{Name: "order.placed", ID: "evt-1"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for handlers")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for service, want := range map[string]int{"orders": 1, "billing": 1, "shipping": 0} {
+		if got := len(handled[service]); got != want {
+			t.Errorf("%s handled %d events, want %d", service, got, want)
+		}
+	}
+}
+
+func TestMemoryTransport_Close(t *testing.T) {
+	transport := NewMemoryTransport()
+	events, err := transport.Subscribe("order.placed")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	transport.Close()
+
+	if _, ok := <-events; ok {
+		t.Error("Expected the channel to be closed")
+	}
+	if err := transport.Publish(context.Background(), Event{Name: "order.placed"}); err == nil {
+		t.Error("Expected an error publishing to a closed transport")
+	}
+	if _, err := transport.Subscribe("order.placed"); err == nil {
+		t.Error("Expected an error subscribing to a closed transport")
+	}
+}
@@ -0,0 +1,125 @@
+package mediator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// TypedHandler is a function type that handles events with a payload of type T
+type TypedHandler[T any] func(ctx context.Context, payload T) error
+
+// SubscribeTyped adds a handler that receives the event payload as T. The first
+// typed subscription binds the event name to T; subscribing with a different
+// type afterwards fails.
+func SubscribeTyped[T any](m *Mediator, eventName string, handler TypedHandler[T], opts ...SubscribeOption) (*Subscription, error) {
+	if err := m.ValidateEventName(eventName); err != nil {
+		return nil, fmt.Errorf("cannot subscribe to %w", err)
+	}
+	if err := m.bindPayloadType(eventName, typeOf[T]()); err != nil {
+		return nil, err
+	}
+
+	// Name the subscription after the typed handler rather than the wrapper
+	opts = append([]SubscribeOption{WithHandlerName(handlerName(handler))}, opts...)
+
+	return m.TrySubscribe(eventName, func(ctx context.Context, event Event) error {
+		payload, err := convertPayload[T](event.Payload, m.Serializer())
+		if err != nil {
+			return fmt.Errorf("event %s: %w", event.Name, err)
+		}
+		return handler(ctx, payload)
+	}, opts...)
+}
+
+// PublishTyped publishes an event with a payload of type T. It fails before
+// dispatch when the event name is bound to a different payload type.
+func PublishTyped[T any](ctx context.Context, m *Mediator, eventName string, payload T) error {
+	want := typeOf[T]()
+
+	m.mu.RLock()
+	bound, exists := m.payloadTypes[eventName]
+	m.mu.RUnlock()
+
+	if exists && bound != want {
+		return fmt.Errorf("event %s expects payload of type %s, got %s", eventName, bound, want)
+	}
+
+	return m.Publish(ctx, Event{Name: eventName, Payload: payload})
+}
+
+// bindPayloadType records the payload type of an event name, failing on conflicts
+func (m *Mediator) bindPayloadType(eventName string, typ reflect.Type) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.payloadTypes == nil {
+		m.payloadTypes = make(map[string]reflect.Type)
+	}
+
+	if bound, exists := m.payloadTypes[eventName]; exists && bound != typ {
+		return fmt.Errorf("event %s is already bound to payload type %s, cannot subscribe with %s", eventName, bound, typ)
+	}
+	m.payloadTypes[eventName] = typ
+	return nil
+}
+
+// typeOf returns the reflect.Type of T, including interface types
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// convertPayload returns payload as T, decoding generic values such as those
+// read back from an event store through JSON. Raw []byte payloads are decoded
+// with serializer.
+func convertPayload[T any](payload interface{}, serializer Serializer) (T, error) {
+	if typed, ok := payload.(T); ok {
+		return typed, nil
+	}
+
+	var zero T
+	if payload == nil {
+		return zero, fmt.Errorf("payload is nil, want %s", typeOf[T]())
+	}
+
+	// Decode into a pointer so both T and *T targets are populated
+	target := reflect.New(typeOf[T]())
+	if err := decodePayload(payload, target.Interface(), serializer); err != nil {
+		return zero, err
+	}
+	return target.Elem().Interface().(T), nil
+}
+
+// decodePayload decodes a generic payload (maps, slices, JSON or raw bytes)
+// into target, which must be a pointer. Raw []byte payloads are decoded with
+// serializer, everything else through JSON.
+func decodePayload(payload interface{}, target interface{}, serializer Serializer) error {
+	want := reflect.TypeOf(target).Elem()
+
+	var data []byte
+	switch p := payload.(type) {
+	case json.RawMessage:
+		data = p
+		serializer = JSONSerializer{}
+	case []byte:
+		data = p
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		data = encoded
+		serializer = JSONSerializer{}
+	default:
+		return fmt.Errorf("invalid payload type %T, want %s", payload, want)
+	}
+
+	if serializer == nil {
+		serializer = JSONSerializer{}
+	}
+	if err := serializer.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to convert payload to %s: %w", want, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package mediator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type testProduct struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+func TestSubscribeTyped(t *testing.T) {
+	m := NewMediator()
+
+	var received []*testProduct
+	_, err := SubscribeTyped(m, "product.created", func(ctx context.Context, p *testProduct) error {
+		received = append(received, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SubscribeTyped() error = %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Test publishing the exact type
+	if err := PublishTyped(ctx, m, "product.created", &testProduct{ID: "1", Price: 10}); err != nil {
+		t.Fatalf("PublishTyped() error = %v", err)
+	}
+
+	// Test converting a generic payload, e.g. one read from an event store
+	if err := m.Publish(ctx, Event{Name: "product.created", Payload: map[string]interface{}{"id": "2", "price": 20.0}}); err != nil {
+		t.Fatalf("Publish() with generic payload error = %v", err)
+	}
+
+	if len(received) != 2 || received[0].ID != "1" || received[1].ID != "2" || received[1].Price != 20 {
+		t.Errorf("handler received %+v", received)
+	}
+
+	// Test rejecting a mismatched payload at dispatch
+	err = m.Publish(ctx, Event{Name: "product.created", Payload: "not a product"})
+	if err == nil || !strings.Contains(err.Error(), "invalid payload type") {
+		t.Errorf("Publish() error = %v, want invalid payload type", err)
+	}
+}
+
+func TestSubscribeTyped_Conflict(t *testing.T) {
+	m := NewMediator()
+
+	if _, err := SubscribeTyped(m, "product.created", func(ctx context.Context, p *testProduct) error { return nil }); err != nil {
+		t.Fatalf("SubscribeTyped() error = %v", err)
+	}
+
+	// Same type is allowed
+	if _, err := SubscribeTyped(m, "product.created", func(ctx context.Context, p *testProduct) error { return nil }); err != nil {
+		t.Errorf("SubscribeTyped() with same type error = %v", err)
+	}
+
+	// Different type is rejected at registration
+	sub, err := SubscribeTyped(m, "product.created", func(ctx context.Context, p testProduct) error { return nil })
+	if err == nil || sub != nil {
+		t.Error("SubscribeTyped() expected error for conflicting payload type")
+	}
+	if len(m.subscribers.load()["product.created"]) != 2 {
+		t.Errorf("conflicting subscription was registered, got %d handlers", len(m.subscribers.load()["product.created"]))
+	}
+}
+
+func TestPublishTyped_Mismatch(t *testing.T) {
+	m := NewMediator()
+	if _, err := SubscribeTyped(m, "product.created", func(ctx context.Context, p *testProduct) error { return nil }); err != nil {
+		t.Fatalf("SubscribeTyped() error = %v", err)
+	}
+
+	err := PublishTyped(context.Background(), m, "product.created", "wrong")
+	if err == nil || !strings.Contains(err.Error(), "expects payload of type") {
+		t.Errorf("PublishTyped() error = %v, want type mismatch", err)
+	}
+}
@@ -0,0 +1,43 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidEvent is returned when a Validator rejects an event
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Validator checks an event before it is dispatched, so malformed payloads are
+// rejected at Publish instead of failing inside a handler
+type Validator interface {
+	Validate(ctx context.Context, event Event) error
+}
+
+// ValidatorFunc adapts a function to a Validator
+type ValidatorFunc func(ctx context.Context, event Event) error
+
+// Validate calls f
+func (f ValidatorFunc) Validate(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// WithValidator adds a validator every published event must pass. Validators
+// run in the order they were added.
+func WithValidator(validator Validator) Option {
+	return func(m *Mediator) {
+		m.validators = append(m.validators, validator)
+	}
+}
+
+// validate runs the validators against event and reports the first rejection
+func (m *Mediator) validate(ctx context.Context, event Event) error {
+	validators := m.publishSettings().validators
+	for _, validator := range validators {
+		if err := validator.Validate(ctx, event); err != nil {
+			return fmt.Errorf("%w %s: %w", ErrInvalidEvent, event.Name, err)
+		}
+	}
+	return nil
+}
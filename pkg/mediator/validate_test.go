@@ -0,0 +1,53 @@
+package mediator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMediator_ValidatorRejectsEvent(t *testing.T) {
+	errMissingID := errors.New("missing id")
+	store := &mockEventStore{}
+
+	var order []string
+	m := NewMediator(
+		WithEventStore(store),
+		WithValidator(ValidatorFunc(func(ctx context.Context, event Event) error {
+			order = append(order, "first")
+			if _, ok := event.Payload.(string); !ok {
+				return errMissingID
+			}
+			return nil
+		})),
+		WithValidator(ValidatorFunc(func(ctx context.Context, event Event) error {
+			order = append(order, "second")
+			return nil
+		})),
+	)
+
+	handled := 0
+	m.Subscribe("order.placed", func(ctx context.Context, event Event) error {
+		handled++
+		return nil
+	})
+
+	err := m.Publish(context.Background(), Event{Name: "order.placed", Payload: 42})
+	if !errors.Is(err, ErrInvalidEvent) || !errors.Is(err, errMissingID) {
+		t.Errorf("Publish() error = %v, want ErrInvalidEvent wrapping the validator error", err)
+	}
+	if handled != 0 || len(store.events) != 0 {
+		t.Errorf("invalid event was handled %d times and stored %d times, want 0", handled, len(store.events))
+	}
+
+	if err := m.Publish(context.Background(), Event{Name: "order.placed", Payload: "o-1"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if handled != 1 {
+		t.Errorf("valid event handled %d times, want 1", handled)
+	}
+	if want := []string{"first", "first", "second"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("validator calls = %v, want %v", order, want)
+	}
+}
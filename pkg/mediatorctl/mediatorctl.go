@@ -0,0 +1,307 @@
+// Package mediatorctl implements the commands of the mediatorctl tool, which
+// inspects and manages the event store of a mediator deployment.
+package mediatorctl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	"github.com/mandocaesar/mediator/pkg/mediator/extension/postgres"
+	redisstore "github.com/mandocaesar/mediator/pkg/mediator/extension/redis"
+)
+
+// ErrUnsupportedStore is returned by Open for store URLs of an unknown scheme
+var ErrUnsupportedStore = errors.New("unsupported event store")
+
+// Config represents the event store mediatorctl connects to
+type Config struct {
+	// Store is the URL of the event store: postgres://, postgresql://,
+	// redis:// or rediss://
+	Store string
+	// Prefix is the key or table prefix of the store; the store's default when empty
+	Prefix string
+	// Schema holds the PostgreSQL tables instead of the search path
+	Schema string
+	// RedisStreams reads a Redis StreamStore instead of a Redis EventStore
+	RedisStreams bool
+	// Retention is the default policy Compact applies, e.g. the policy the
+	// services sharing the store are configured with
+	Retention mediator.RetentionPolicy
+	// PollInterval is how often Tail polls stores that cannot push new events
+	PollInterval time.Duration
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+	}
+}
+
+// Client runs commands against an event store
+type Client struct {
+	store    mediator.EventStore
+	mediator *mediator.Mediator
+	close    func() error
+}
+
+// Open connects to the event store at config.Store
+func Open(ctx context.Context, config Config) (*Client, error) {
+	if config.Store == "" {
+		return nil, fmt.Errorf("%w: no store URL given", ErrUnsupportedStore)
+	}
+	storeURL, err := url.Parse(config.Store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse store URL: %w", err)
+	}
+
+	switch storeURL.Scheme {
+	case "postgres", "postgresql":
+		return openPostgres(ctx, config)
+	case "redis", "rediss":
+		return openRedis(config)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedStore, storeURL.Scheme)
+	}
+}
+
+// openPostgres connects to a PostgreSQL event store
+func openPostgres(ctx context.Context, config Config) (*Client, error) {
+	pool, err := pgxpool.New(ctx, config.Store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	storeConfig := postgres.DefaultConfig()
+	if config.Prefix != "" {
+		storeConfig.Prefix = config.Prefix
+	}
+	storeConfig.Schema = config.Schema
+	storeConfig.Retention = mediator.Retention{Default: config.Retention}
+	// Compact decides what is removed; writes such as re-drives don't trim
+	storeConfig.DisableTrim = true
+
+	store, err := postgres.NewPgxEventStore(pool, storeConfig)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return newClient(store, config, store.Close), nil
+}
+
+// openRedis connects to a Redis event store
+func openRedis(config Config) (*Client, error) {
+	options, err := redis.ParseURL(config.Store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+	client := redis.NewClient(options)
+
+	storeConfig := redisstore.DefaultConfig()
+	if config.Prefix != "" {
+		storeConfig.Prefix = config.Prefix
+	}
+	storeConfig.Retention = mediator.Retention{Default: config.Retention}
+
+	if config.RedisStreams {
+		return newClient(redisstore.NewStreamStore(client, storeConfig), config, client.Close), nil
+	}
+	return newClient(redisstore.NewEventStore(client, storeConfig), config, client.Close), nil
+}
+
+// newClient creates a client running commands on store
+func newClient(store mediator.EventStore, config Config, close func() error) *Client {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultConfig().PollInterval
+	}
+	return &Client{
+		store:    store,
+		mediator: mediator.NewMediator(mediator.WithEventStore(store), mediator.WithStorePollInterval(config.PollInterval)),
+		close:    close,
+	}
+}
+
+// Close disconnects from the event store
+func (c *Client) Close() error {
+	return c.close()
+}
+
+// Streams writes a table of the event names with stored events
+func (c *Client) Streams(ctx context.Context, w io.Writer) error {
+	streams, err := c.mediator.GetStreams(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tCOUNT\tFIRST EVENT\tLAST EVENT")
+	for _, stream := range streams {
+		fmt.Fprintf(table, "%s\t%d\t%s\t%s\n", stream.Name, stream.Count, formatTime(stream.FirstEvent), formatTime(stream.LastEvent))
+	}
+	return table.Flush()
+}
+
+// formatTime formats t for tables, or "-" when unknown
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// record is an event as written by Tail and Dump, one JSON object per line
+type record struct {
+	mediator.Event
+	Offset string `json:"offset,omitempty"`
+}
+
+// writeRecord writes event to w as a line of JSON
+func writeRecord(w io.Writer, event mediator.StoredEvent) error {
+	data, err := json.Marshal(record{Event: event.Event(), Offset: event.Offset})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", data); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+// Tail writes the events of an event name stored after fromOffset to w as JSON
+// lines until ctx is cancelled. An empty fromOffset starts at the oldest event
+// and mediator.LatestOffset at new events only.
+func (c *Client) Tail(ctx context.Context, w io.Writer, eventName, fromOffset string) error {
+	events, err := c.mediator.StoreSubscribe(ctx, eventName, fromOffset)
+	if err != nil {
+		return err
+	}
+	for event := range events {
+		if err := writeRecord(w, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dump writes the events of an event name stored after cursor to w as JSON
+// lines, oldest first, and returns how many it wrote; limit <= 0 dumps all
+func (c *Client) Dump(ctx context.Context, w io.Writer, eventName, cursor string, limit int) (int, error) {
+	written := 0
+	for {
+		pageSize := mediator.DefaultPageSize
+		if limit > 0 && limit-written < pageSize {
+			pageSize = limit - written
+		}
+
+		events, next, err := c.mediator.GetEventsPage(ctx, eventName, cursor, pageSize)
+		if err != nil {
+			return written, err
+		}
+		for _, event := range events {
+			if err := writeRecord(w, event); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if next == "" || (limit > 0 && written >= limit) {
+			return written, nil
+		}
+		cursor = next
+	}
+}
+
+// Clear removes every stored event of an event name
+func (c *Client) Clear(ctx context.Context, eventName string) error {
+	return c.mediator.ClearEvents(ctx, eventName)
+}
+
+// DeadLetters writes a table of the dead letters of an event name kept in the
+// store by a mediator.StoreDeadLetterQueue
+func (c *Client) DeadLetters(ctx context.Context, w io.Writer, eventName string) error {
+	letters, err := mediator.NewStoreDeadLetterQueue(c.store).List(ctx, eventName, 0)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tEVENT ID\tHANDLER\tATTEMPTS\tFAILED AT\tERROR")
+	for _, letter := range letters {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%s\t%s\n", letter.ID, letter.Event.ID, letter.HandlerName, letter.Attempts, formatTime(letter.FailedAt), letter.Error)
+	}
+	return table.Flush()
+}
+
+// Redrive stores dead-lettered events of an event name back in their stream
+// and removes them from the dead-letter queue, returning how many it moved.
+// Services dispatching stored events, e.g. with StoreSubscribe or a Postgres
+// Listener, handle them again; an empty id re-drives every dead letter.
+func (c *Client) Redrive(ctx context.Context, eventName, id string) (int, error) {
+	queue := mediator.NewStoreDeadLetterQueue(c.store)
+
+	var letters []mediator.DeadLetter
+	if id == "" {
+		all, err := queue.List(ctx, eventName, 0)
+		if err != nil {
+			return 0, err
+		}
+		letters = all
+	} else {
+		letter, err := queue.Get(ctx, eventName, id)
+		if err != nil {
+			return 0, err
+		}
+		letters = []mediator.DeadLetter{letter}
+	}
+
+	for i, letter := range letters {
+		if err := c.mediator.PublishWith(ctx, letter.Event, mediator.WithPublishDeliveryMode(mediator.StoreOnly)); err != nil {
+			return i, fmt.Errorf("failed to re-drive dead letter %s: %w", letter.ID, err)
+		}
+		if err := queue.Remove(ctx, eventName, letter.ID); err != nil {
+			return i, fmt.Errorf("failed to remove dead letter %s: %w", letter.ID, err)
+		}
+	}
+	return len(letters), nil
+}
+
+// Compact removes the stored events of an event name older than olderThan, or
+// of every event name when eventName is empty. When olderThan is 0 the
+// configured Retention policy is enforced on every event name instead.
+func (c *Client) Compact(ctx context.Context, eventName string, olderThan time.Duration) error {
+	if olderThan <= 0 {
+		enforcer, ok := c.store.(mediator.RetentionEnforcer)
+		if !ok {
+			return fmt.Errorf("%w: retention is not enforced by this store", mediator.ErrCompactionNotSupported)
+		}
+		return enforcer.EnforceRetention(ctx)
+	}
+
+	names := []string{eventName}
+	if eventName == "" {
+		streams, err := c.mediator.GetStreams(ctx)
+		if err != nil {
+			return err
+		}
+		names = names[:0]
+		for _, stream := range streams {
+			names = append(names, stream.Name)
+		}
+	}
+
+	before := time.Now().Add(-olderThan)
+	for _, name := range names {
+		if err := c.mediator.DeleteBefore(ctx, name, before); err != nil {
+			return fmt.Errorf("failed to compact %s: %w", name, err)
+		}
+	}
+	return nil
+}
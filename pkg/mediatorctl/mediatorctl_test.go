@@ -0,0 +1,317 @@
+package mediatorctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/mandocaesar/mediator/pkg/mediator"
+	redisstore "github.com/mandocaesar/mediator/pkg/mediator/extension/redis"
+)
+
+// setupClient opens a client on a miniredis server and returns a mediator
+// writing to the same store
+func setupClient(t *testing.T, config Config) (*Client, *mediator.Mediator) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	config.Store = "redis://" + mr.Addr()
+	client, err := Open(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	store := redisstore.NewEventStore(rdb, redisstore.DefaultConfig())
+	return client, mediator.NewMediator(mediator.WithEventStore(store), mediator.WithDeliveryMode(mediator.StoreOnly))
+}
+
+// publish publishes events named eventName with the given payloads
+func publish(t *testing.T, m *mediator.Mediator, eventName string, payloads ...interface{}) {
+	t.Helper()
+	for _, payload := range payloads {
+		if err := m.Publish(context.Background(), mediator.Event{Name: eventName, Payload: payload}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+}
+
+// decodeRecords decodes the JSON lines written by Dump or Tail
+func decodeRecords(t *testing.T, data string) []record {
+	t.Helper()
+	var records []record
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("failed to decode %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		store string
+	}{
+		{"empty", ""},
+		{"unknown scheme", "mysql://localhost/db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Store = tt.store
+			if _, err := Open(context.Background(), config); !errors.Is(err, ErrUnsupportedStore) {
+				t.Errorf("Open() error = %v, want ErrUnsupportedStore", err)
+			}
+		})
+	}
+}
+
+func TestClient_Streams(t *testing.T) {
+	client, m := setupClient(t, DefaultConfig())
+	publish(t, m, "order.created", 1, 2)
+	publish(t, m, "order.shipped", 3)
+
+	var out bytes.Buffer
+	if err := client.Streams(context.Background(), &out); err != nil {
+		t.Fatalf("Streams() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Streams() wrote %d lines, want a header and 2 streams:\n%s", len(lines), out.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "order.created" || fields[1] != "2" {
+		t.Errorf("Streams() line = %q, want order.created with 2 events", lines[1])
+	}
+}
+
+func TestClient_Dump(t *testing.T) {
+	client, m := setupClient(t, DefaultConfig())
+	publish(t, m, "order.created", "a", "b", "c")
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"all", 0, []string{"a", "b", "c"}},
+		{"limited", 2, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			n, err := client.Dump(context.Background(), &out, "order.created", "", tt.limit)
+			if err != nil {
+				t.Fatalf("Dump() error = %v", err)
+			}
+			records := decodeRecords(t, out.String())
+			if n != len(tt.want) || len(records) != len(tt.want) {
+				t.Fatalf("Dump() = %d events, wrote %d, want %d", n, len(records), len(tt.want))
+			}
+			for i, r := range records {
+				if r.Payload != tt.want[i] || r.Name != "order.created" || r.ID == "" || r.Offset == "" {
+					t.Errorf("record %d = %+v, want payload %q with name, ID and offset", i, r, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// Resuming after an offset skips the events before it
+	var out bytes.Buffer
+	first := decodeRecords(t, dumpString(t, client, "", 1))[0]
+	if _, err := client.Dump(context.Background(), &out, "order.created", first.Offset, 0); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	if records := decodeRecords(t, out.String()); len(records) != 2 || records[0].Payload != "b" {
+		t.Errorf("Dump() after %s = %+v, want b and c", first.Offset, records)
+	}
+}
+
+// dumpString dumps order.created and returns the output
+func dumpString(t *testing.T, client *Client, cursor string, limit int) string {
+	t.Helper()
+	var out bytes.Buffer
+	if _, err := client.Dump(context.Background(), &out, "order.created", cursor, limit); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	return out.String()
+}
+
+// syncBuffer is a bytes.Buffer safe to write and read concurrently
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestClient_Tail(t *testing.T) {
+	config := DefaultConfig()
+	config.PollInterval = 5 * time.Millisecond
+	client, m := setupClient(t, config)
+	publish(t, m, "order.created", "a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error, 1)
+	go func() { done <- client.Tail(ctx, out, "order.created", "") }()
+
+	publish(t, m, "order.created", "b")
+	deadline := time.Now().Add(2 * time.Second)
+	for strings.Count(out.String(), "\n") < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Tail() error = %v", err)
+	}
+
+	records := decodeRecords(t, out.String())
+	if len(records) != 2 || records[0].Payload != "a" || records[1].Payload != "b" {
+		t.Errorf("Tail() wrote %+v, want a then b", records)
+	}
+}
+
+func TestClient_Clear(t *testing.T) {
+	client, m := setupClient(t, DefaultConfig())
+	publish(t, m, "order.created", 1, 2)
+
+	if err := client.Clear(context.Background(), "order.created"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if out := dumpString(t, client, "", 0); out != "" {
+		t.Errorf("Dump() after Clear() = %q, want no events", out)
+	}
+}
+
+func TestClient_Redrive(t *testing.T) {
+	client, m := setupClient(t, DefaultConfig())
+	queue := mediator.NewStoreDeadLetterQueue(client.store)
+	for _, id := range []string{"dl-1", "dl-2"} {
+		letter := mediator.DeadLetter{
+			ID:          id,
+			Event:       mediator.Event{Name: "order.created", ID: "evt-" + id, Payload: id},
+			HandlerName: "ship",
+			Error:       "boom",
+			Attempts:    3,
+			FailedAt:    time.Now(),
+		}
+		if err := queue.Add(context.Background(), letter); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := client.DeadLetters(context.Background(), &out, "order.created"); err != nil {
+		t.Fatalf("DeadLetters() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "dl-1") || !strings.Contains(out.String(), "boom") {
+		t.Errorf("DeadLetters() = %q, want dl-1 and its error", out.String())
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		want      int
+		remaining int
+	}{
+		{"one", "dl-1", 1, 1},
+		{"all", "", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := client.Redrive(context.Background(), "order.created", tt.id)
+			if err != nil || n != tt.want {
+				t.Fatalf("Redrive() = %d, %v, want %d", n, err, tt.want)
+			}
+			letters, err := queue.List(context.Background(), "order.created", 0)
+			if err != nil || len(letters) != tt.remaining {
+				t.Errorf("List() = %d letters, %v, want %d", len(letters), err, tt.remaining)
+			}
+		})
+	}
+
+	events, err := m.ReadEvents(context.Background(), "order.created", 0)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("ReadEvents() = %d events, %v, want both re-driven events", len(events), err)
+	}
+
+	if _, err := client.Redrive(context.Background(), "order.created", "missing"); !errors.Is(err, mediator.ErrDeadLetterNotFound) {
+		t.Errorf("Redrive() error = %v, want ErrDeadLetterNotFound", err)
+	}
+}
+
+func TestClient_Compact(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour)
+	seed := func(t *testing.T, m *mediator.Mediator) {
+		for _, name := range []string{"order.created", "order.shipped"} {
+			for _, timestamp := range []time.Time{old, time.Now()} {
+				if err := m.Publish(context.Background(), mediator.Event{Name: name, Timestamp: timestamp}); err != nil {
+					t.Fatalf("Publish() error = %v", err)
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		event     string
+		olderThan time.Duration
+		retention mediator.RetentionPolicy
+		want      map[string]int64
+	}{
+		{"one event name", "order.created", time.Hour, mediator.KeepForever(), map[string]int64{"order.created": 1, "order.shipped": 2}},
+		{"every event name", "", time.Hour, mediator.KeepForever(), map[string]int64{"order.created": 1, "order.shipped": 1}},
+		{"retention policy", "", 0, mediator.KeepLast(1), map[string]int64{"order.created": 1, "order.shipped": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Retention = tt.retention
+			client, m := setupClient(t, config)
+			seed(t, m)
+
+			if err := client.Compact(context.Background(), tt.event, tt.olderThan); err != nil {
+				t.Fatalf("Compact() error = %v", err)
+			}
+			streams, err := m.GetStreams(context.Background())
+			if err != nil {
+				t.Fatalf("GetStreams() error = %v", err)
+			}
+			for _, stream := range streams {
+				if stream.Count != tt.want[stream.Name] {
+					t.Errorf("%s has %d events, want %d", stream.Name, stream.Count, tt.want[stream.Name])
+				}
+			}
+		})
+	}
+}
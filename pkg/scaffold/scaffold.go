@@ -0,0 +1,184 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Supported event store choices
+const (
+	StoreMemory   = "memory"
+	StoreRedis    = "redis"
+	StorePostgres = "postgres"
+)
+
+// identifierPattern matches names usable as a Go identifier
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// Config represents the options of a generated service
+type Config struct {
+	// Name is the service name; it also names the output directory when Dir is empty
+	Name string
+	// Module is the Go module path; defaults to Name
+	Module string
+	// Entity names the sample domain entity (e.g. "order")
+	Entity string
+	// Store is one of StoreMemory, StoreRedis or StorePostgres
+	Store string
+	// Dir is the output directory
+	Dir string
+	// MediatorPath adds a replace directive pointing at a local mediator checkout
+	MediatorPath string
+	// Force allows writing into a non-empty directory
+	Force bool
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Entity: "item",
+		Store:  StoreMemory,
+	}
+}
+
+// templateData is passed to every template
+type templateData struct {
+	Name         string
+	Module       string
+	Entity       string
+	EntityVar    string
+	Store        string
+	MediatorPath string
+}
+
+// Generate renders the service skeleton into the configured directory and
+// returns the paths of the written files relative to it
+func Generate(config Config) ([]string, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("service name is required")
+	}
+	if config.Module == "" {
+		config.Module = config.Name
+	}
+	if config.Entity == "" {
+		config.Entity = DefaultConfig().Entity
+	}
+	if config.Store == "" {
+		config.Store = DefaultConfig().Store
+	}
+	if config.Dir == "" {
+		config.Dir = config.Name
+	}
+
+	if !identifierPattern.MatchString(config.Entity) {
+		return nil, fmt.Errorf("invalid entity name: %s", config.Entity)
+	}
+	switch config.Store {
+	case StoreMemory, StoreRedis, StorePostgres:
+	default:
+		return nil, fmt.Errorf("unsupported store: %s", config.Store)
+	}
+
+	if err := ensureEmpty(config.Dir, config.Force); err != nil {
+		return nil, err
+	}
+
+	data := templateData{
+		Name:         config.Name,
+		Module:       config.Module,
+		Entity:       strings.ToUpper(config.Entity[:1]) + config.Entity[1:],
+		EntityVar:    strings.ToLower(config.Entity[:1]) + config.Entity[1:],
+		Store:        config.Store,
+		MediatorPath: config.MediatorPath,
+	}
+
+	var written []string
+	err := fs.WalkDir(templates, "templates", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel := strings.TrimSuffix(strings.TrimPrefix(name, "templates/"), ".tmpl")
+		rel = outputName(rel, data)
+
+		content, err := render(name, data)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(config.Dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(target, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+
+		written = append(written, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return written, nil
+}
+
+// outputName maps a template path to the generated file path
+func outputName(rel string, data templateData) string {
+	dir, file := path.Split(rel)
+	if dir == "usecase/" {
+		file = strings.Replace(file, "usecase", data.EntityVar+"_usecase", 1)
+	}
+	return dir + file
+}
+
+// render executes a template and gofmts Go output
+func render(name string, data templateData) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+
+	if !strings.HasSuffix(name, ".go.tmpl") {
+		return buf.Bytes(), nil
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", name, err)
+	}
+	return formatted, nil
+}
+
+// ensureEmpty fails when dir exists and contains files, unless force is set
+func ensureEmpty(dir string, force bool) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	if len(entries) > 0 && !force {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	for _, store := range []string{StoreMemory, StoreRedis, StorePostgres} {
+		t.Run(store, func(t *testing.T) {
+			dir := t.TempDir()
+			config := DefaultConfig()
+			config.Name = "order-service"
+			config.Module = "example.com/order-service"
+			config.Entity = "order"
+			config.Store = store
+			config.Dir = dir
+			config.Force = true
+
+			files, err := Generate(config)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			want := []string{"go.mod", "main.go", "server.go", "README.md", "usecase/order_usecase.go", "usecase/order_usecase_test.go"}
+			for _, file := range want {
+				if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+					t.Errorf("expected generated file %s: %v", file, err)
+				}
+			}
+			if len(files) != len(want) {
+				t.Errorf("Generate() wrote %d files, want %d: %v", len(files), len(want), files)
+			}
+
+			mainGo, err := os.ReadFile(filepath.Join(dir, "main.go"))
+			if err != nil {
+				t.Fatalf("failed to read main.go: %v", err)
+			}
+			if got := strings.Contains(string(mainGo), "extension/"+store); got != (store != StoreMemory) {
+				t.Errorf("main.go store extension import present = %v for store %s", got, store)
+			}
+			if !strings.Contains(string(mainGo), "usecase.NewOrderUseCase(med)") {
+				t.Error("main.go does not wire the sample use case")
+			}
+		})
+	}
+}
+
+func TestGenerate_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "missing name", config: Config{}},
+		{name: "invalid entity", config: Config{Name: "svc", Entity: "my-entity"}},
+		{name: "unsupported store", config: Config{Name: "svc", Store: "mongo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Dir = t.TempDir()
+			if _, err := Generate(tt.config); err == nil {
+				t.Error("Generate() expected error")
+			}
+		})
+	}
+}
+
+func TestGenerate_NonEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.Name = "svc"
+	config.Dir = dir
+	if _, err := Generate(config); err == nil {
+		t.Error("Generate() expected error for non-empty directory")
+	}
+}